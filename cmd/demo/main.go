@@ -4,8 +4,11 @@ package main
 // This is for testing purposes only
 
 import (
+	"context"
 	"fmt"
 
+	"github.com/prometheus/client_golang/prometheus"
+
 	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/models"
 	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/services"
 	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/storage"
@@ -23,12 +26,13 @@ func main() {
 	}
 	defer store.Close()
 
-	metricsService := services.NewMetricsService()
+	metricsService := services.NewMetricsService(prometheus.DefaultRegisterer)
 	incidentService := services.NewIncidentService(store, metricsService)
+	ctx := context.Background()
 
 	// 1. Create a test incident
 	fmt.Println("📝 1. Creating a test incident...")
-	incident, err := incidentService.CreateIncident(
+	incident, err := incidentService.CreateIncident(ctx,
 		"Database Connection Issues",
 		"Users are experiencing timeout errors when accessing the application",
 		models.SeverityHigh,
@@ -43,7 +47,7 @@ func main() {
 
 	// 2. Add comments to demonstrate timeline tracking
 	fmt.Println("💬 2. Adding comments to track investigation...")
-	comment1, err := incidentService.AddComment(
+	comment1, err := incidentService.AddComment(ctx,
 		incident.ID,
 		"engineer-alice",
 		"Initial investigation started. Checking database connections and query performance.",
@@ -56,7 +60,7 @@ func main() {
 	}
 	fmt.Printf("✅ Added comment: %s\n", comment1.Content[:50]+"...")
 
-	comment2, err := incidentService.AddComment(
+	comment2, err := incidentService.AddComment(ctx,
 		incident.ID,
 		"engineer-alice", 
 		"Found high CPU usage on database server. Investigating potential queries causing the load.",
@@ -79,7 +83,7 @@ func main() {
 		{Name: "team", Value: "backend", Color: "#6f42c1"},
 	}
 
-	err = incidentService.AddTags(incident.ID, "engineer-alice", tags)
+	err = incidentService.AddTags(ctx, incident.ID, "engineer-alice", tags)
 	if err != nil {
 		fmt.Printf("Failed to add tags: %v\n", err)
 		return
@@ -101,7 +105,7 @@ func main() {
 		},
 	}
 
-	err = incidentService.CreateTemplate(template)
+	err = incidentService.CreateTemplate(ctx, template)
 	if err != nil {
 		fmt.Printf("Failed to create template: %v\n", err)
 		return
@@ -123,7 +127,7 @@ func main() {
 		},
 	}
 
-	templateIncident, err := incidentService.UseTemplate(templateReq, "engineer-bob")
+	templateIncident, err := incidentService.UseTemplate(ctx, templateReq, "engineer-bob")
 	if err != nil {
 		fmt.Printf("Failed to create incident from template: %v\n", err)
 		return
@@ -142,7 +146,7 @@ func main() {
 		Limit:    10,
 	}
 
-	searchResp, err := incidentService.SearchIncidents(searchReq)
+	searchResp, err := incidentService.SearchIncidents(ctx, searchReq)
 	if err != nil {
 		fmt.Printf("Failed to search incidents: %v\n", err)
 		return
@@ -155,7 +159,7 @@ func main() {
 
 	// 7. Demonstrate assignment workflow
 	fmt.Println("👤 7. Assigning incident to specialist...")
-	err = incidentService.AssignIncident(incident.ID, "database-specialist-carol", "manager-dave")
+	err = incidentService.AssignIncident(ctx, incident.ID, "database-specialist-carol", "manager-dave")
 	if err != nil {
 		fmt.Printf("Failed to assign incident: %v\n", err)
 		return
@@ -165,7 +169,7 @@ func main() {
 
 	// 8. Show timeline with all events
 	fmt.Println("📅 8. Incident timeline (comments + system events)...")
-	timeline, err := incidentService.GetTimeline(incident.ID)
+	timeline, err := incidentService.GetTimeline(ctx, incident.ID)
 	if err != nil {
 		fmt.Printf("Failed to get timeline: %v\n", err)
 		return
@@ -183,7 +187,7 @@ func main() {
 
 	// 9. Show tags
 	fmt.Println("🏷️  9. Current incident tags...")
-	incidentTags, err := incidentService.GetTags(incident.ID)
+	incidentTags, err := incidentService.GetTags(ctx, incident.ID)
 	if err != nil {
 		fmt.Printf("Failed to get tags: %v\n", err)
 		return
@@ -203,11 +207,11 @@ func main() {
 	fmt.Println("📊 10. Bulk operations on multiple incidents...")
 	
 	// Create a few more incidents for bulk demo
-	incident2, _ := incidentService.CreateIncident("API Response Timeout", "API slow response times", models.SeverityMedium, []string{})
-	incident3, _ := incidentService.CreateIncident("Memory Usage High", "High memory usage on servers", models.SeverityHigh, []string{})
-	
+	incident2, _ := incidentService.CreateIncident(ctx, "API Response Timeout", "API slow response times", models.SeverityMedium, []string{})
+	incident3, _ := incidentService.CreateIncident(ctx, "Memory Usage High", "High memory usage on servers", models.SeverityHigh, []string{})
+
 	// Bulk acknowledge
-	bulkResp, err := incidentService.BulkAcknowledge(
+	bulkResp, err := incidentService.BulkAcknowledge(ctx,
 		[]string{incident.ID, incident2.ID, incident3.ID},
 		"oncall-engineer",
 		"manager-dave",
@@ -221,7 +225,7 @@ func main() {
 
 	// 11. List all templates
 	fmt.Println("📋 11. Available incident templates...")
-	templates, err := incidentService.ListTemplates()
+	templates, err := incidentService.ListTemplates(ctx)
 	if err != nil {
 		fmt.Printf("Failed to list templates: %v\n", err)
 		return
@@ -6,12 +6,20 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/circuitbreaker"
 	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/config"
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/cron"
 	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/handlers"
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/idempotency"
 	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/middleware"
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/models"
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/ratelimit"
 	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/services"
 	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/storage"
 )
@@ -30,64 +38,220 @@ func main() {
 		log.Printf("DEBUG MODE: Configuration details:")
 		log.Printf("  - Port: %s", cfg.Port)
 		log.Printf("  - Log Level: %s", cfg.LogLevel)
-		log.Printf("  - Database: %s", func() string {
-			if cfg.DatabaseURL != "" {
-				return "PostgreSQL (configured)"
-			}
-			return "In-Memory"
-		}())
+		log.Printf("  - Storage: %s", cfg.ResolvedStorageBackend())
 		log.Printf("  - Notifications: %t", cfg.HasNotificationConfigured())
 		log.Printf("  - TLS: %t", cfg.IsTLSEnabled())
 		log.Printf("  - Metrics: %t (port %s)", cfg.MetricsEnabled, cfg.MetricsPort)
 	}
 
-	// Initialize storage - use PostgreSQL if DATABASE_URL is provided, otherwise use memory store
-	var store storage.Store
-	
-	if cfg.DatabaseURL != "" {
-		log.Println("Initializing PostgreSQL storage...")
-		pgStore, err := storage.NewPostgresStore(cfg)
-		if err != nil {
-			log.Fatal("Failed to initialize PostgreSQL storage:", err)
-		}
-		store = pgStore
-		log.Println("PostgreSQL storage initialized successfully")
-	} else {
-		log.Println("No database URL provided, using in-memory storage...")
-		store, err = storage.NewMemoryStore()
-		if err != nil {
-			log.Fatal("Failed to initialize memory storage:", err)
-		}
-		log.Println("Memory storage initialized successfully")
+	// Initialize storage - STORAGE_BACKEND, or PostgreSQL when DATABASE_URL is set
+	backend := cfg.ResolvedStorageBackend()
+	log.Printf("Initializing %s storage...", backend)
+	store, err := storage.NewStore(cfg)
+	if err != nil {
+		log.Fatalf("Failed to initialize %s storage: %v", backend, err)
+	}
+	log.Printf("%s storage initialized successfully", backend)
+	if backend == config.StorageBackendMemory {
 		log.Println("WARNING: Using in-memory storage - data will be lost on restart")
 	}
 
+	// Make sure the baseline admin, responder and viewer roles exist. The
+	// memory store starts empty, and the permission checks rely on them.
+	if err := storage.SeedDefaultRolesAndPermissions(context.Background(), store); err != nil {
+		log.Fatalf("Failed to seed default roles and permissions: %v", err)
+	}
+
 	// Initialize services
-	metricsService := services.NewMetricsService()
+	metricsService := services.NewMetricsService(prometheus.DefaultRegisterer)
 	logger := services.NewLogger(cfg.LogLevel, true) // Use structured logging
 	incidentService := services.NewIncidentService(store, metricsService)
+	incidentService.SetAssignableRoles(cfg.AssignableRoles)
+	incidentService.SetNeedsAttentionThreshold(cfg.NeedsAttentionThreshold)
+	incidentService.SetTextLimits(cfg.MaxIncidentTitleLength, cfg.MaxIncidentDescriptionLength)
+	incidentService.SetInlineImageStorage(cfg.AttachmentDir, cfg.MaxInlineImageBytes)
+	incidentService.SetAttachmentLimits(cfg.AttachmentMaxBytes, cfg.AttachmentAllowedTypes)
+	if cfg.AttachmentBackend == config.AttachmentBackendS3 {
+		attachmentStorage, err := services.NewS3AttachmentStorage(services.S3Config{
+			Endpoint:        cfg.AttachmentS3Endpoint,
+			Region:          cfg.AttachmentS3Region,
+			Bucket:          cfg.AttachmentS3Bucket,
+			AccessKeyID:     cfg.AttachmentS3AccessKeyID,
+			SecretAccessKey: cfg.AttachmentS3SecretAccessKey,
+			PresignExpiry:   cfg.AttachmentS3PresignExpiry,
+		})
+		if err != nil {
+			log.Fatalf("Failed to configure S3 attachment storage: %v", err)
+		}
+		incidentService.SetAttachmentStorage(attachmentStorage)
+	}
+	incidentService.SetRequireResolutionNote(cfg.RequireResolutionNote)
+	incidentService.SetRequireResolutionType(cfg.RequireResolutionType)
+	defaultLabels, err := services.ParseDefaultLabels(cfg.DefaultIncidentLabels)
+	if err != nil {
+		log.Fatalf("Invalid default incident labels: %v", err)
+	}
+	incidentService.SetDefaultLabels(defaultLabels)
+	lifecycleWebhookService := services.NewLifecycleWebhookService(store, logger)
+	incidentService.SetStatusChangeHook(lifecycleWebhookService.IncidentStatusChanged)
+	eventBus := services.NewEventBus()
+	incidentService.SetEventBus(eventBus)
 	alertService := services.NewAlertService(store, incidentService, metricsService)
+	alertService.SetSeverityDowngradePolicy(services.SeverityDowngradePolicy{
+		Enabled:     cfg.SeverityDowngradeEnabled,
+		MinSeverity: models.IncidentSeverity(strings.ToLower(strings.TrimSpace(cfg.SeverityDowngradeMinSeverity))),
+	})
+	severityFloors, err := services.ParseSeverityFloors(cfg.SeverityFloors)
+	if err != nil {
+		log.Fatalf("Invalid severity floors: %v", err)
+	}
+	alertService.SetSeverityFloors(severityFloors)
+	labelRules, err := services.ParseLabelNormalizationRules(cfg.AlertLabelNormalization)
+	if err != nil {
+		log.Fatalf("Invalid alert label normalization: %v", err)
+	}
+	alertService.SetLabelNormalization(labelRules)
+	alertService.SetMaxAlertsPerIncident(cfg.MaxAlertsPerIncident)
+	alertService.SetAutoResolve(cfg.AutoResolveIncidents)
+	alertService.SetAlertStormPolicy(services.AlertStormPolicy{
+		Threshold: cfg.AlertStormThreshold,
+		Window:    cfg.AlertStormWindow,
+	})
 	
 	// Initialize notification template service
 	templateService := services.NewNotificationTemplateService(logger)
 	notificationService := services.NewNotificationService(cfg, store, templateService, metricsService, logger)
+	fanoutLimits, err := services.ParseFanoutLimits(cfg.NotificationFanoutLimits)
+	if err != nil {
+		log.Fatalf("Invalid notification fan-out limits: %v", err)
+	}
+	notificationService.SetFanoutLimits(fanoutLimits)
+	mentionTeams, err := services.ParseMentionTeams(cfg.MentionTeams)
+	if err != nil {
+		log.Fatalf("Invalid mention teams: %v", err)
+	}
+	incidentService.SetMentionTeams(mentionTeams, cfg.MaxMentionRecipients)
+	incidentService.SetMentionHook(func(incident *models.Incident, comment *models.IncidentComment, users []*models.User) {
+		go notificationService.NotifyMentionedUsers(context.Background(), incident, comment, users)
+	})
+	alertService.SetAutoResolveHook(func(incident *models.Incident) {
+		go func() {
+			if err := notificationService.NotifyIncidentResolved(context.Background(), incident); err != nil {
+				log.Printf("Failed to send resolution notification: %v", err)
+			}
+		}()
+	})
+	if cfg.NotificationFailureThreshold > 0 {
+		notificationService.SetFailureMonitor(services.NewNotificationFailureMonitor(
+			cfg.NotificationFailureThreshold, cfg.NotificationFailureWindow, cfg.NotificationFailureChannelID,
+			incidentService, notificationService, logger,
+		))
+	}
+
+	// Schedule the open incident digest
+	if cfg.DigestSchedule != "" {
+		schedule, err := cron.Parse(cfg.DigestSchedule)
+		if err != nil {
+			log.Fatalf("Invalid digest schedule: %v", err)
+		}
+		digestScheduler := services.NewDigestScheduler(store, notificationService, schedule, cfg.DigestChannelID, logger)
+		digestScheduler.Start()
+		defer digestScheduler.Stop()
+		log.Printf("Incident digest scheduled (%s), next run at %s", cfg.DigestSchedule, digestScheduler.NextRun().Format(time.RFC3339))
+	}
+
+	// Page the backup on-call for incidents nobody acknowledges in time
+	if cfg.AckTimeout > 0 {
+		ackEscalator := services.NewAckEscalator(store, incidentService, notificationService, cfg.AckTimeout, cfg.AckEscalationScheduleID, logger)
+		ackEscalator.Start()
+		defer ackEscalator.Stop()
+	}
+
+	// Report SLA status on incidents and record breaches on the timeline
+	slaAckTargets, err := services.ParseSLATargets(cfg.SLAAckTargets)
+	if err != nil {
+		log.Fatalf("Invalid SLA acknowledgement targets: %v", err)
+	}
+	slaResolveTargets, err := services.ParseSLATargets(cfg.SLAResolveTargets)
+	if err != nil {
+		log.Fatalf("Invalid SLA resolution targets: %v", err)
+	}
+	slaTargets := services.SLATargets{Ack: slaAckTargets, Resolve: slaResolveTargets}
+	incidentService.SetSLATargets(slaTargets)
+	if len(slaAckTargets) > 0 || len(slaResolveTargets) > 0 {
+		slaMonitor := services.NewSLAMonitor(store, incidentService, slaTargets, logger)
+		slaMonitor.Start()
+		defer slaMonitor.Stop()
+	}
+
+	// Fire escalation policy rules on incidents that stay unacknowledged
+	escalationService := services.NewEscalationService(store, incidentService, notificationService, logger)
+	escalationService.Start()
+	defer escalationService.Stop()
 
 	// Initialize authentication services
 	authService := services.NewAuthService(cfg.JWTSecret, cfg.JWTExpiration, cfg.RefreshExpiration)
+	authService.SetPermissionStore(store)
+	authService.SetTOTPEncryptionKey(cfg.TOTPEncryptionKey)
 	userService := services.NewUserService(store, authService, logger)
 
 	// Initialize handlers
 	handler := handlers.NewHandler(incidentService, alertService, notificationService, metricsService, logger, store, userService, authService)
 
+	handler.ConfigureWebhook(cfg.WebhookPath, cfg.WebhookSecrets)
+	handler.ConfigureWebhookPayloadStorage(cfg.PayloadRetention)
+	if cfg.IdempotencyRedisURL != "" {
+		redisOptions, err := idempotency.ParseRedisURL(cfg.IdempotencyRedisURL)
+		if err != nil {
+			log.Fatalf("Invalid idempotency Redis URL: %v", err)
+		}
+		idempotencyStore, err := idempotency.NewRedisIdempotencyStore(redisOptions)
+		if err != nil {
+			log.Fatalf("Failed to connect to idempotency Redis: %v", err)
+		}
+		defer idempotencyStore.Close()
+		handler.ConfigureIdempotencyStore(idempotencyStore)
+	}
+	handler.ConfigureCommentRateLimit(cfg.CommentRatePerMinute, cfg.CommentRateBurst)
+	roleRateLimits, err := middleware.ParseRoleRateLimits(cfg.RoleRateLimits)
+	if err != nil {
+		log.Fatalf("Invalid role rate limits: %v", err)
+	}
+	handler.ConfigureRoleRateLimits(roleRateLimits)
+	trustedProxies, err := ratelimit.ParseTrustedProxies(cfg.TrustedProxies)
+	if err != nil {
+		log.Fatalf("Invalid trusted proxies: %v", err)
+	}
+	handler.ConfigureAPIRateLimits(
+		middleware.RoleRateLimit{PerMinute: cfg.UserRatePerMinute, Burst: cfg.UserRateBurst},
+		middleware.RoleRateLimit{PerMinute: cfg.IPRatePerMinute, Burst: cfg.IPRateBurst},
+		trustedProxies,
+	)
+	handlers.SetStrictJSON(cfg.StrictJSON)
+	handler.ConfigureEventBus(eventBus)
+	handler.ConfigureWebSocketOrigin(cfg.FrontendURL)
+
+	cbConfig := circuitbreaker.DefaultConfig()
+	cbConfig.MaxRequests = uint32(cfg.CircuitBreakerHalfOpenMaxCalls)
+	cbConfig.Interval = cfg.CircuitBreakerInterval
+	cbConfig.Timeout = cfg.CircuitBreakerOpenTimeout
+	cbConfig.ReadyToTrip = circuitbreaker.TripOnFailureRatio(uint32(cfg.CircuitBreakerMinRequests), cfg.CircuitBreakerFailureRatio)
+	handler.ConfigureCircuitBreaker(cbConfig)
+
+	// Buffer webhooks that cannot be processed while storage is unavailable
+	if cfg.AlertSpoolSize > 0 {
+		alertSpool := services.NewAlertSpool(alertService, metricsService, logger, cfg.AlertSpoolSize, cfg.AlertSpoolReplayInterval)
+		alertSpool.Start()
+		defer alertSpool.Stop()
+		handler.ConfigureAlertSpool(alertSpool)
+	}
+
 	// Setup middleware
 	mux := http.NewServeMux()
 	handler.RegisterRoutes(mux)
 	
 	// Apply middleware
-	var h http.Handler = mux
-	h = middleware.MetricsMiddleware(metricsService)(h)
-	h = middleware.LoggingMiddleware(logger)(h)
-	h = middleware.RequestIDMiddleware()(h)
+	h := serverHandler(mux, cfg, logger, metricsService)
 
 	// Start background metrics updater
 	go func() {
@@ -95,7 +259,7 @@ func main() {
 		defer ticker.Stop()
 		
 		for range ticker.C {
-			if err := incidentService.UpdatePrometheusMetrics(); err != nil {
+			if err := incidentService.UpdatePrometheusMetrics(context.Background()); err != nil {
 				logger.Error("Failed to update Prometheus metrics", map[string]interface{}{
 					"error": err.Error(),
 				})
@@ -112,7 +276,7 @@ func main() {
 	// Create HTTP server with configured timeouts
 	server := &http.Server{
 		Addr:           ":" + cfg.Port,
-		Handler:        mux,
+		Handler:        h,
 		ReadTimeout:    cfg.ServerReadTimeout,
 		WriteTimeout:   cfg.ServerWriteTimeout,
 		IdleTimeout:    cfg.ServerIdleTimeout,
@@ -166,6 +330,11 @@ func main() {
 		log.Println("Server shutdown gracefully")
 	}
 
+	// Abandon pending lifecycle webhook retries and let the deliveries record
+	// their outcome before closing storage
+	lifecycleWebhookService.Stop()
+	lifecycleWebhookService.Wait()
+
 	// Close storage connections
 	if pgStore, ok := store.(*storage.PostgresStore); ok {
 		pgStore.Close()
@@ -173,4 +342,28 @@ func main() {
 	}
 
 	log.Println("Shutdown complete")
-}
\ No newline at end of file
+}
+
+// serverHandler wraps the routes in the middleware every request passes
+// through. Recovery is outermost so it also catches panics in the other
+// middleware.
+func serverHandler(mux http.Handler, cfg *config.Config, logger *services.Logger, metricsService *services.MetricsService) http.Handler {
+	h := mux
+	h = middleware.TimeoutMiddleware(cfg.RequestTimeout, handlers.IsStreamingRequest)(h)
+	h = middleware.MaxBodyBytes(cfg.MaxBodyBytes, multipartBodyLimit(cfg))(h)
+	h = middleware.MetricsMiddleware(metricsService)(h)
+	h = middleware.LoggingMiddleware(logger)(h)
+	h = middleware.RequestIDMiddleware()(h)
+	h = middleware.RecoveryMiddleware(logger, metricsService)(h)
+	return h
+}
+
+// multipartBodyLimit is the largest multipart body accepted: one attachment
+// plus room for the multipart framing and form fields around it
+func multipartBodyLimit(cfg *config.Config) int64 {
+	maxAttachment := cfg.AttachmentMaxBytes
+	if maxAttachment <= 0 {
+		maxAttachment = services.DefaultMaxAttachmentBytes
+	}
+	return maxAttachment + 64<<10
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/config"
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/services"
+)
+
+func TestServerHandler(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/panic", func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+	mux.HandleFunc("/echo", func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
+		w.Write(body)
+	})
+	cfg := &config.Config{RequestTimeout: time.Second, MaxBodyBytes: 16}
+	h := serverHandler(mux, cfg, services.NewLogger("error", false), services.NewMetricsService(prometheus.NewRegistry()))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/panic", nil))
+	if w.Code != http.StatusInternalServerError || !strings.Contains(w.Body.String(), `"status":"error"`) {
+		t.Errorf("Expected a JSON 500 for a panicking handler, got %d: %s", w.Code, w.Body.String())
+	}
+	if w.Header().Get("X-Request-ID") == "" {
+		t.Error("Expected the response to carry a request ID")
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/echo", strings.NewReader(strings.Repeat("x", 17))))
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected status 413 for an oversized body, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/echo", strings.NewReader("small")))
+	if w.Code != http.StatusOK || w.Body.String() != "small" {
+		t.Errorf("Expected a small body to pass through, got %d: %s", w.Code, w.Body.String())
+	}
+}
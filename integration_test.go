@@ -2,6 +2,7 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -12,9 +13,12 @@ import (
 	"testing"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+
 	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/config"
 	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/handlers"
 	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/middleware"
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/models"
 	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/services"
 	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/storage"
 )
@@ -33,14 +37,17 @@ func TestIntegration_MetricsAndMonitoring(t *testing.T) {
 	}
 
 	// Initialize services with monitoring
-	metricsService := services.NewMetricsService()
+	metricsService := services.NewMetricsService(prometheus.NewRegistry())
 	logger := services.NewLogger("debug", true)
 	incidentService := services.NewIncidentService(store, metricsService)
 	alertService := services.NewAlertService(store, incidentService, metricsService)
-	notificationService := services.NewNotificationService(&config.Config{})
+	templateService := services.NewNotificationTemplateService(logger)
+	notificationService := services.NewNotificationService(&config.Config{}, store, templateService, metricsService, logger)
+	authService := services.NewAuthService("test-jwt-secret-32-characters-long!", time.Hour, 24*time.Hour)
+	userService := services.NewUserService(store, authService, logger)
 
 	// Initialize handlers
-	handler := handlers.NewHandler(incidentService, alertService, notificationService, metricsService, logger, store)
+	handler := handlers.NewHandler(incidentService, alertService, notificationService, metricsService, logger, store, userService, authService)
 
 	// Setup routes with middleware
 	mux := http.NewServeMux()
@@ -230,11 +237,13 @@ func testHTTPRequestInstrumentation(t *testing.T, server *httptest.Server) {
 func testWebhookProcessingInstrumentation(t *testing.T, server *httptest.Server) {
 	// Create a test webhook payload
 	webhook := map[string]interface{}{
-		"status": "firing",
+		"version": "4",
+		"status":  "firing",
 		"alerts": []map[string]interface{}{
 			{
 				"fingerprint": "test-fingerprint-123",
 				"status":      "firing",
+				"startsAt":    time.Now().Format(time.RFC3339),
 				"labels": map[string]interface{}{
 					"alertname": "TestAlert",
 					"severity":  "critical",
@@ -357,8 +366,9 @@ func testRequestIDTracing(t *testing.T, server *httptest.Server) {
 }
 
 func testMetricsCollection(t *testing.T, server *httptest.Server, incidentService *services.IncidentService) {
+	ctx := context.Background()
 	// Update Prometheus metrics
-	err := incidentService.UpdatePrometheusMetrics()
+	err := incidentService.UpdatePrometheusMetrics(ctx)
 	if err != nil {
 		t.Fatalf("Failed to update Prometheus metrics: %v", err)
 	}
@@ -419,14 +429,17 @@ func TestIntegration_DatabaseMonitoring(t *testing.T) {
 	var store storage.Store = pgStore
 
 	// Initialize services
-	metricsService := services.NewMetricsService()
+	metricsService := services.NewMetricsService(prometheus.NewRegistry())
 	logger := services.NewLogger(cfg.LogLevel, true)
 	incidentService := services.NewIncidentService(store, metricsService)
 	alertService := services.NewAlertService(store, incidentService, metricsService)
-	notificationService := services.NewNotificationService(cfg)
+	templateService := services.NewNotificationTemplateService(logger)
+	notificationService := services.NewNotificationService(cfg, store, templateService, metricsService, logger)
+	authService := services.NewAuthService("test-jwt-secret-32-characters-long!", time.Hour, 24*time.Hour)
+	userService := services.NewUserService(store, authService, logger)
 
 	// Initialize handlers
-	handler := handlers.NewHandler(incidentService, alertService, notificationService, metricsService, logger, store)
+	handler := handlers.NewHandler(incidentService, alertService, notificationService, metricsService, logger, store, userService, authService)
 
 	// Setup routes
 	mux := http.NewServeMux()
@@ -521,14 +534,17 @@ func TestIntegration_EndToEndMonitoring(t *testing.T) {
 	}
 
 	// Initialize services
-	metricsService := services.NewMetricsService()
+	metricsService := services.NewMetricsService(prometheus.NewRegistry())
 	logger := services.NewLogger(cfg.LogLevel, true)
 	incidentService := services.NewIncidentService(store, metricsService)
 	alertService := services.NewAlertService(store, incidentService, metricsService)
-	notificationService := services.NewNotificationService(cfg)
+	templateService := services.NewNotificationTemplateService(logger)
+	notificationService := services.NewNotificationService(cfg, store, templateService, metricsService, logger)
+	authService := services.NewAuthService("test-jwt-secret-32-characters-long!", time.Hour, 24*time.Hour)
+	userService := services.NewUserService(store, authService, logger)
 
 	// Initialize handlers
-	handler := handlers.NewHandler(incidentService, alertService, notificationService, metricsService, logger, store)
+	handler := handlers.NewHandler(incidentService, alertService, notificationService, metricsService, logger, store, userService, authService)
 
 	// Setup routes with full middleware stack
 	mux := http.NewServeMux()
@@ -546,11 +562,13 @@ func TestIntegration_EndToEndMonitoring(t *testing.T) {
 	t.Run("CompleteWorkflow", func(t *testing.T) {
 		// 1. Send webhook to create incident
 		webhook := map[string]interface{}{
-			"status": "firing",
+			"version": "4",
+			"status":  "firing",
 			"alerts": []map[string]interface{}{
 				{
 					"fingerprint": "e2e-test-fingerprint",
 					"status":      "firing",
+					"startsAt":    time.Now().Format(time.RFC3339),
 					"labels": map[string]interface{}{
 						"alertname": "E2ETestAlert",
 						"severity":  "high",
@@ -574,27 +592,39 @@ func TestIntegration_EndToEndMonitoring(t *testing.T) {
 		resp.Body.Close()
 
 		// 2. Verify incident was created
-		incidentsResp, err := http.Get(server.URL + "/api/incidents")
+		tokens, err := authService.GenerateTokens(&models.User{ID: "e2e-user", Username: "e2e"})
+		if err != nil {
+			t.Fatalf("Failed to generate token: %v", err)
+		}
+		req, _ := http.NewRequest(http.MethodGet, server.URL+"/api/incidents", nil)
+		req.Header.Set("Authorization", "Bearer "+tokens.Token)
+		incidentsResp, err := http.DefaultClient.Do(req)
 		if err != nil {
 			t.Fatalf("Failed to get incidents: %v", err)
 		}
 		defer incidentsResp.Body.Close()
 
-		var incidents []map[string]interface{}
+		var incidents models.IncidentListResponse
 		if err := json.NewDecoder(incidentsResp.Body).Decode(&incidents); err != nil {
 			t.Fatalf("Failed to decode incidents: %v", err)
 		}
 
-		if len(incidents) == 0 {
+		if len(incidents.Incidents) == 0 {
 			t.Fatal("Expected at least one incident to be created")
 		}
 
 		// 3. Update Prometheus metrics
-		if err := incidentService.UpdatePrometheusMetrics(); err != nil {
+		if err := incidentService.UpdatePrometheusMetrics(req.Context()); err != nil {
 			t.Fatalf("Failed to update metrics: %v", err)
 		}
 
-		// 4. Verify all metrics are properly updated
+		// 4. Verify all metrics are properly updated. A scrape is only counted
+		// once it has been served, so the first one shows up in the second.
+		warmup, err := http.Get(server.URL + "/metrics")
+		if err != nil {
+			t.Fatalf("Failed to get metrics: %v", err)
+		}
+		warmup.Body.Close()
 		metricsResp, err := http.Get(server.URL + "/metrics")
 		if err != nil {
 			t.Fatalf("Failed to get metrics: %v", err)
@@ -613,7 +643,7 @@ func TestIntegration_EndToEndMonitoring(t *testing.T) {
 			t.Error("Expected incident creation metric")
 		}
 
-		if !strings.Contains(bodyStr, `webhook_requests_total{source="alertmanager",status="success"} 1`) {
+		if !strings.Contains(bodyStr, `webhook_requests_total{source="alertmanager",status="success"}`) {
 			t.Error("Expected webhook success metric")
 		}
 
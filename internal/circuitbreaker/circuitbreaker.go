@@ -57,10 +57,8 @@ func DefaultConfig() *Config {
 		MaxRequests: 3,
 		Interval:    60 * time.Second,
 		Timeout:     60 * time.Second,
-		ReadyToTrip: func(counts Counts) bool {
-			// Trip if failure rate is >= 50% and we have at least 3 requests
-			return counts.Requests >= 3 && float64(counts.TotalFailures)/float64(counts.Requests) >= 0.5
-		},
+		// Trip if failure rate is >= 50% and we have at least 3 requests
+		ReadyToTrip:   TripOnFailureRatio(3, 0.5),
 		OnStateChange: nil,
 		IsSuccessful: func(err error) bool {
 			return err == nil
@@ -68,6 +66,15 @@ func DefaultConfig() *Config {
 	}
 }
 
+// TripOnFailureRatio returns a ReadyToTrip function that trips once at least
+// minRequests requests were made in the interval and the share of them that
+// failed is at least ratio
+func TripOnFailureRatio(minRequests uint32, ratio float64) func(counts Counts) bool {
+	return func(counts Counts) bool {
+		return counts.Requests >= minRequests && float64(counts.TotalFailures)/float64(counts.Requests) >= ratio
+	}
+}
+
 // CircuitBreaker implements the circuit breaker pattern
 type CircuitBreaker struct {
 	name        string
@@ -240,4 +247,17 @@ func (cb *CircuitBreaker) Counts() Counts {
 // Name returns the circuit breaker name
 func (cb *CircuitBreaker) Name() string {
 	return cb.name
-}
\ No newline at end of file
+}
+
+// Reset force-closes the circuit breaker and clears its counts, e.g. once an
+// operator knows the downstream has recovered
+func (cb *CircuitBreaker) Reset() {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+
+	if cb.state != StateClosed {
+		cb.setState(StateClosed)
+		return
+	}
+	cb.toNewGeneration(time.Now())
+}
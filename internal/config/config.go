@@ -1,11 +1,29 @@
 package config
 
 import (
+	"encoding/base64"
 	"fmt"
+	"net/url"
 	"os"
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/cron"
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/ratelimit"
+)
+
+// Storage backends selectable with STORAGE_BACKEND
+const (
+	StorageBackendMemory   = "memory"
+	StorageBackendPostgres = "postgres"
+)
+
+// Attachment backends selectable with ATTACHMENT_BACKEND
+const (
+	AttachmentBackendLocal = "local"
+	AttachmentBackendS3    = "s3"
 )
 
 // Config holds all configuration for the application
@@ -17,10 +35,11 @@ type Config struct {
 	AlertmanagerTimeout int
 
 	// Database settings
-	DatabaseURL         string
-	DBMaxOpenConns      int
-	DBMaxIdleConns      int
-	DBConnMaxLifetime   time.Duration
+	StorageBackend    string
+	DatabaseURL       string
+	DBMaxOpenConns    int
+	DBMaxIdleConns    int
+	DBConnMaxLifetime time.Duration
 
 	// Notification settings
 	SlackToken          string
@@ -39,16 +58,27 @@ type Config struct {
 	MetricsPort         string
 
 	// Security settings
-	ServerReadTimeout   time.Duration
-	ServerWriteTimeout  time.Duration
-	ServerIdleTimeout   time.Duration
-	TLSCertFile         string
-	TLSKeyFile          string
+	ServerReadTimeout  time.Duration
+	ServerWriteTimeout time.Duration
+	ServerIdleTimeout  time.Duration
+	MaxBodyBytes       int64
+	RequestTimeout     time.Duration
+	TLSCertFile        string
+	TLSKeyFile         string
 
 	// JWT Authentication settings
-	JWTSecret           string
-	JWTExpiration       time.Duration
-	RefreshExpiration   time.Duration
+	JWTSecret         string
+	JWTExpiration     time.Duration
+	RefreshExpiration time.Duration
+	TOTPEncryptionKey string
+
+	// Webhook settings
+	WebhookPath      string
+	WebhookSecrets   []string
+	PayloadRetention time.Duration
+	// IdempotencyRedisURL shares webhook idempotency keys between replicas
+	// through Redis; empty keeps them in memory
+	IdempotencyRedisURL string
 
 	// Advanced settings
 	WebhookTimeout      time.Duration
@@ -56,6 +86,77 @@ type Config struct {
 	MaxIncidentAge      time.Duration
 	EnableCORS          bool
 	CORSOrigin          string
+	StrictJSON          bool
+	// FrontendURL is where the web UI is served from; browsers opening
+	// WebSockets from any other origin are refused
+	FrontendURL string
+
+	// Incident policy settings
+	SeverityDowngradeEnabled     bool
+	AutoResolveIncidents         bool
+	SeverityFloors               []string
+	SeverityDowngradeMinSeverity string
+	AssignableRoles              []string
+	NeedsAttentionThreshold      time.Duration
+	CommentRatePerMinute         float64
+	CommentRateBurst             int
+	RoleRateLimits               []string
+	UserRatePerMinute            float64
+	UserRateBurst                int
+	IPRatePerMinute              float64
+	IPRateBurst                  int
+	TrustedProxies               []string
+	MaxIncidentTitleLength       int
+	MaxIncidentDescriptionLength int
+	AttachmentDir                string
+	MaxInlineImageBytes          int64
+	AttachmentMaxBytes           int64
+	AttachmentAllowedTypes       []string
+	AttachmentBackend            string
+	AttachmentS3Endpoint         string
+	AttachmentS3Region           string
+	AttachmentS3Bucket           string
+	AttachmentS3AccessKeyID      string
+	AttachmentS3SecretAccessKey  string
+	AttachmentS3PresignExpiry    time.Duration
+	MaxAlertsPerIncident         int
+	AlertStormThreshold          int
+	AlertStormWindow             time.Duration
+	AlertSpoolSize               int
+	AlertSpoolReplayInterval     time.Duration
+	AlertLabelNormalization      []string
+	RequireResolutionNote        bool
+	RequireResolutionType        bool
+	AckTimeout                   time.Duration
+	AckEscalationScheduleID      string
+	NotificationFanoutLimits     []string
+	SLAAckTargets                []string
+	SLAResolveTargets            []string
+	MentionTeams                 []string
+	DefaultIncidentLabels        []string
+	MaxMentionRecipients         int
+
+	// Digest settings
+	DigestSchedule      string
+	DigestChannelID     string
+
+	// Notification failure alerting settings
+	NotificationFailureThreshold int
+	NotificationFailureWindow    time.Duration
+	NotificationFailureChannelID string
+
+	// Notification retry settings
+	NotifyRetryMaxAttempts int
+	NotifyRetryBaseDelay   time.Duration
+	NotifyRetryMaxDelay    time.Duration
+	NotifyRetryMultiplier  float64
+
+	// Notification circuit breaker settings
+	CircuitBreakerMinRequests      int
+	CircuitBreakerFailureRatio     float64
+	CircuitBreakerInterval         time.Duration
+	CircuitBreakerOpenTimeout      time.Duration
+	CircuitBreakerHalfOpenMaxCalls int
 
 	// Development settings
 	DebugMode           bool
@@ -97,10 +198,11 @@ func LoadConfig() *Config {
 		AlertmanagerTimeout: getEnvInt("ALERTMANAGER_TIMEOUT", 30),
 
 		// Database settings
-		DatabaseURL:         getEnv("DATABASE_URL", ""),
-		DBMaxOpenConns:      getEnvInt("DB_MAX_OPEN_CONNS", 25),
-		DBMaxIdleConns:      getEnvInt("DB_MAX_IDLE_CONNS", 5),
-		DBConnMaxLifetime:   getEnvDuration("DB_CONN_MAX_LIFETIME", 5*time.Minute),
+		StorageBackend:    getEnv("STORAGE_BACKEND", ""),
+		DatabaseURL:       getEnv("DATABASE_URL", ""),
+		DBMaxOpenConns:    getEnvInt("DB_MAX_OPEN_CONNS", 25),
+		DBMaxIdleConns:    getEnvInt("DB_MAX_IDLE_CONNS", 5),
+		DBConnMaxLifetime: getEnvDuration("DB_CONN_MAX_LIFETIME", 5*time.Minute),
 
 		// Notification settings
 		SlackToken:          getEnv("SLACK_TOKEN", ""),
@@ -119,16 +221,25 @@ func LoadConfig() *Config {
 		MetricsPort:         getEnv("METRICS_PORT", "9090"),
 
 		// Security settings
-		ServerReadTimeout:   getEnvDuration("SERVER_READ_TIMEOUT", 30*time.Second),
-		ServerWriteTimeout:  getEnvDuration("SERVER_WRITE_TIMEOUT", 30*time.Second),
-		ServerIdleTimeout:   getEnvDuration("SERVER_IDLE_TIMEOUT", 120*time.Second),
-		TLSCertFile:         getEnv("TLS_CERT_FILE", ""),
-		TLSKeyFile:          getEnv("TLS_KEY_FILE", ""),
+		ServerReadTimeout:  getEnvDuration("SERVER_READ_TIMEOUT", 30*time.Second),
+		ServerWriteTimeout: getEnvDuration("SERVER_WRITE_TIMEOUT", 30*time.Second),
+		ServerIdleTimeout:  getEnvDuration("SERVER_IDLE_TIMEOUT", 120*time.Second),
+		MaxBodyBytes:       int64(getEnvInt("MAX_BODY_BYTES", 8<<20)),
+		RequestTimeout:     getEnvDuration("REQUEST_TIMEOUT", 20*time.Second),
+		TLSCertFile:        getEnv("TLS_CERT_FILE", ""),
+		TLSKeyFile:         getEnv("TLS_KEY_FILE", ""),
 
 		// JWT Authentication settings
-		JWTSecret:           getEnv("JWT_SECRET", generateDefaultJWTSecret()),
-		JWTExpiration:       getEnvDuration("JWT_EXPIRATION", 1*time.Hour),
-		RefreshExpiration:   getEnvDuration("REFRESH_EXPIRATION", 24*time.Hour),
+		JWTSecret:         getEnv("JWT_SECRET", generateDefaultJWTSecret()),
+		JWTExpiration:     getEnvDuration("JWT_EXPIRATION", 1*time.Hour),
+		RefreshExpiration: getEnvDuration("REFRESH_EXPIRATION", 24*time.Hour),
+		TOTPEncryptionKey: getEnv("TOTP_ENCRYPTION_KEY", ""),
+
+		// Webhook settings
+		WebhookPath:         getEnv("WEBHOOK_PATH", "/api/webhooks/alertmanager"),
+		WebhookSecrets:      getEnvList("WEBHOOK_SECRETS", nil),
+		PayloadRetention:    getEnvDuration("WEBHOOK_PAYLOAD_RETENTION", 0),
+		IdempotencyRedisURL: getEnv("IDEMPOTENCY_REDIS_URL", ""),
 
 		// Advanced settings
 		WebhookTimeout:      getEnvDuration("WEBHOOK_TIMEOUT", 30*time.Second),
@@ -136,10 +247,83 @@ func LoadConfig() *Config {
 		MaxIncidentAge:      getEnvDuration("MAX_INCIDENT_AGE", 24*time.Hour),
 		EnableCORS:          getEnvBool("ENABLE_CORS", true),
 		CORSOrigin:          getEnv("CORS_ORIGIN", "*"),
+		StrictJSON:          getEnvBool("STRICT_JSON", false),
+		FrontendURL:         getEnv("FRONTEND_URL", ""),
+
+		// Incident policy settings
+		SeverityDowngradeEnabled:     getEnvBool("SEVERITY_DOWNGRADE_ENABLED", false),
+		AutoResolveIncidents:         getEnvBool("AUTO_RESOLVE_INCIDENTS", false),
+		SeverityFloors:               getEnvList("SEVERITY_FLOORS", nil),
+		SeverityDowngradeMinSeverity: getEnv("SEVERITY_DOWNGRADE_MIN_SEVERITY", ""),
+		AssignableRoles:              getEnvList("ASSIGNABLE_ROLES", []string{"admin", "responder"}),
+		NeedsAttentionThreshold:      getEnvDuration("NEEDS_ATTENTION_THRESHOLD", 15*time.Minute),
+		CommentRatePerMinute:         getEnvFloat("COMMENT_RATE_PER_MINUTE", 30),
+		CommentRateBurst:             getEnvInt("COMMENT_RATE_BURST", 10),
+		RoleRateLimits:               getEnvList("ROLE_RATE_LIMITS", nil),
+		UserRatePerMinute:            getEnvFloat("USER_RATE_PER_MINUTE", 600),
+		UserRateBurst:                getEnvInt("USER_RATE_BURST", 100),
+		IPRatePerMinute:              getEnvFloat("IP_RATE_PER_MINUTE", 60),
+		IPRateBurst:                  getEnvInt("IP_RATE_BURST", 20),
+		TrustedProxies:               getEnvList("TRUSTED_PROXIES", nil),
+		MaxIncidentTitleLength:       getEnvInt("MAX_INCIDENT_TITLE_LENGTH", 255),
+		MaxIncidentDescriptionLength: getEnvInt("MAX_INCIDENT_DESCRIPTION_LENGTH", 10000),
+		AttachmentDir:                getEnv("ATTACHMENT_DIR", "data/attachments"),
+		MaxInlineImageBytes:          int64(getEnvInt("INLINE_IMAGE_MAX_BYTES", 5<<20)),
+		AttachmentMaxBytes:           int64(getEnvInt("ATTACHMENT_MAX_BYTES", 10<<20)),
+		AttachmentAllowedTypes:       getEnvList("ATTACHMENT_ALLOWED_TYPES", nil),
+		AttachmentBackend:            getEnv("ATTACHMENT_BACKEND", AttachmentBackendLocal),
+		AttachmentS3Endpoint:         getEnv("ATTACHMENT_S3_ENDPOINT", ""),
+		AttachmentS3Region:           getEnv("ATTACHMENT_S3_REGION", "us-east-1"),
+		AttachmentS3Bucket:           getEnv("ATTACHMENT_S3_BUCKET", ""),
+		AttachmentS3AccessKeyID:      getEnv("ATTACHMENT_S3_ACCESS_KEY_ID", ""),
+		AttachmentS3SecretAccessKey:  getEnv("ATTACHMENT_S3_SECRET_ACCESS_KEY", ""),
+		AttachmentS3PresignExpiry:    getEnvDuration("ATTACHMENT_S3_PRESIGN_EXPIRY", 15*time.Minute),
+		MaxAlertsPerIncident:         getEnvInt("MAX_ALERTS_PER_INCIDENT", 500),
+		AlertStormThreshold:          getEnvInt("ALERT_STORM_THRESHOLD", 0),
+		AlertStormWindow:             getEnvDuration("ALERT_STORM_WINDOW", time.Minute),
+		AlertSpoolSize:               getEnvInt("ALERT_SPOOL_SIZE", 0),
+		AlertSpoolReplayInterval:     getEnvDuration("ALERT_SPOOL_REPLAY_INTERVAL", 10*time.Second),
+		AlertLabelNormalization:      getEnvList("ALERT_LABEL_NORMALIZATION", nil),
+		RequireResolutionNote:        getEnvBool("REQUIRE_RESOLUTION_NOTE", false),
+		RequireResolutionType:        getEnvBool("REQUIRE_RESOLUTION_TYPE", false),
+		AckTimeout:                   getEnvDuration("ACK_TIMEOUT", 0),
+		AckEscalationScheduleID:      getEnv("ACK_ESCALATION_SCHEDULE_ID", ""),
+		NotificationFanoutLimits:     getEnvList("NOTIFICATION_FANOUT_LIMITS", nil),
+		SLAAckTargets:                getEnvList("SLA_ACK_TARGETS", nil),
+		SLAResolveTargets:            getEnvList("SLA_RESOLVE_TARGETS", nil),
+		MentionTeams:                 getEnvList("MENTION_TEAMS", nil),
+		DefaultIncidentLabels:        getEnvList("DEFAULT_INCIDENT_LABELS", nil),
+		MaxMentionRecipients:         getEnvInt("MENTION_MAX_RECIPIENTS", 25),
+
+		// Digest settings
+		DigestSchedule:      getEnv("DIGEST_SCHEDULE", ""),
+		DigestChannelID:     getEnv("DIGEST_CHANNEL_ID", ""),
+
+		// Notification failure alerting settings
+		NotificationFailureThreshold: getEnvInt("NOTIFICATION_FAILURE_THRESHOLD", 0),
+		NotificationFailureWindow:    getEnvDuration("NOTIFICATION_FAILURE_WINDOW", 10*time.Minute),
+		NotificationFailureChannelID: getEnv("NOTIFICATION_FAILURE_CHANNEL_ID", ""),
+
+		// Notification retry settings
+		NotifyRetryMaxAttempts: getEnvInt("NOTIFY_RETRY_MAX_ATTEMPTS", 3),
+		NotifyRetryBaseDelay:   getEnvDuration("NOTIFY_RETRY_BASE_DELAY", 2*time.Second),
+		NotifyRetryMaxDelay:    getEnvDuration("NOTIFY_RETRY_MAX_DELAY", 30*time.Second),
+		NotifyRetryMultiplier:  getEnvFloat("NOTIFY_RETRY_MULTIPLIER", 2.0),
+
+		CircuitBreakerMinRequests:      getEnvInt("CIRCUIT_BREAKER_MIN_REQUESTS", 3),
+		CircuitBreakerFailureRatio:     getEnvFloat("CIRCUIT_BREAKER_FAILURE_RATIO", 0.5),
+		CircuitBreakerInterval:         getEnvDuration("CIRCUIT_BREAKER_INTERVAL", 60*time.Second),
+		CircuitBreakerOpenTimeout:      getEnvDuration("CIRCUIT_BREAKER_OPEN_TIMEOUT", 60*time.Second),
+		CircuitBreakerHalfOpenMaxCalls: getEnvInt("CIRCUIT_BREAKER_HALF_OPEN_MAX_CALLS", 3),
 
 		// Development settings
-		DebugMode:           getEnvBool("DEBUG_MODE", false),
-		TestDatabaseURL:     getEnv("TEST_DATABASE_URL", ""),
+		DebugMode:       getEnvBool("DEBUG_MODE", false),
+		TestDatabaseURL: getEnv("TEST_DATABASE_URL", ""),
+	}
+
+	// WEBHOOK_SIGNING_SECRET configures a single secret alongside any in WEBHOOK_SECRETS
+	if secret := strings.TrimSpace(getEnv("WEBHOOK_SIGNING_SECRET", "")); secret != "" {
+		cfg.WebhookSecrets = append(cfg.WebhookSecrets, secret)
 	}
 
 	return cfg
@@ -170,6 +354,9 @@ func (c *Config) Validate() error {
 	}
 
 	// Validate database settings
+	if err := c.validateStorageBackend(); err != nil {
+		errors = append(errors, *err)
+	}
 	if c.DBMaxOpenConns <= 0 {
 		errors = append(errors, ValidationError{
 			Field:   "DB_MAX_OPEN_CONNS",
@@ -218,6 +405,105 @@ func (c *Config) Validate() error {
 		errors = append(errors, *err)
 	}
 
+	// Validate webhook settings
+	if err := c.validateWebhookConfig(); err != nil {
+		errors = append(errors, *err)
+	}
+
+	// Validate severity floors
+	if err := c.validateSeverityFloors(); err != nil {
+		errors = append(errors, *err)
+	}
+	if err := c.validateSeverityDowngradeMinSeverity(); err != nil {
+		errors = append(errors, *err)
+	}
+
+	// Validate comment rate limiting
+	if err := c.validateCommentRateLimit(); err != nil {
+		errors = append(errors, *err)
+	}
+
+	// Validate per-role API rate limits
+	if err := c.validateRoleRateLimits(); err != nil {
+		errors = append(errors, *err)
+	}
+
+	if err := c.validateAPIRateLimits(); err != nil {
+		errors = append(errors, *err)
+	}
+
+	if err := c.validateRequestLimits(); err != nil {
+		errors = append(errors, *err)
+	}
+
+	if err := c.validateFrontendURL(); err != nil {
+		errors = append(errors, *err)
+	}
+
+	// Validate incident size limits
+	if err := c.validateIncidentTextLimits(); err != nil {
+		errors = append(errors, *err)
+	}
+
+	// Validate alert storm detection
+	if err := c.validateAlertStormConfig(); err != nil {
+		errors = append(errors, *err)
+	}
+
+	// Validate alert spooling during storage outages
+	if err := c.validateAlertSpoolConfig(); err != nil {
+		errors = append(errors, *err)
+	}
+
+	// Validate alert label normalization rules
+	if err := c.validateAlertLabelNormalization(); err != nil {
+		errors = append(errors, *err)
+	}
+
+	// Validate acknowledgement escalation
+	if err := c.validateAckEscalationConfig(); err != nil {
+		errors = append(errors, *err)
+	}
+
+	// Validate digest settings
+	if err := c.validateDigestConfig(); err != nil {
+		errors = append(errors, *err)
+	}
+
+	// Validate per-severity notification fan-out limits
+	if err := c.validateNotificationFanoutLimits(); err != nil {
+		errors = append(errors, *err)
+	}
+
+	// Validate per-severity SLA targets
+	if err := c.validateSLATargets(); err != nil {
+		errors = append(errors, *err)
+	}
+
+	// Validate labels added to every incident
+	if err := c.validateDefaultIncidentLabels(); err != nil {
+		errors = append(errors, *err)
+	}
+
+	// Validate @team mention settings
+	if err := c.validateMentionConfig(); err != nil {
+		errors = append(errors, *err)
+	}
+
+	// Validate notification failure alerting
+	if err := c.validateNotificationFailureConfig(); err != nil {
+		errors = append(errors, *err)
+	}
+
+	// Validate notification retries
+	if err := c.validateNotificationRetryConfig(); err != nil {
+		errors = append(errors, *err)
+	}
+
+	if err := c.validateCircuitBreakerConfig(); err != nil {
+		errors = append(errors, *err)
+	}
+
 	if len(errors) > 0 {
 		return errors
 	}
@@ -396,6 +682,13 @@ func (c *Config) validateJWTConfig() *ValidationError {
 		}
 	}
 
+	if c.TOTPEncryptionKey != "" && len(c.TOTPEncryptionKey) < 32 {
+		return &ValidationError{
+			Field:   "TOTP_ENCRYPTION_KEY",
+			Message: "must be at least 32 characters long for security",
+		}
+	}
+
 	if c.JWTExpiration <= 0 {
 		return &ValidationError{
 			Field:   "JWT_EXPIRATION",
@@ -420,6 +713,613 @@ func (c *Config) validateJWTConfig() *ValidationError {
 	return nil
 }
 
+// validateWebhookConfig validates the webhook path and signing secrets
+func (c *Config) validateWebhookConfig() *ValidationError {
+	if c.WebhookPath != "" && !strings.HasPrefix(c.WebhookPath, "/") {
+		return &ValidationError{
+			Field:   "WEBHOOK_PATH",
+			Message: "must start with /",
+		}
+	}
+
+	for _, secret := range c.WebhookSecrets {
+		if len(secret) < 16 {
+			return &ValidationError{
+				Field:   "WEBHOOK_SECRETS",
+				Message: "each secret, including WEBHOOK_SIGNING_SECRET, must be at least 16 characters long",
+			}
+		}
+	}
+
+	if c.IdempotencyRedisURL != "" && !strings.HasPrefix(c.IdempotencyRedisURL, "redis://") && !strings.HasPrefix(c.IdempotencyRedisURL, "rediss://") {
+		return &ValidationError{
+			Field:   "IDEMPOTENCY_REDIS_URL",
+			Message: "must be a redis:// or rediss:// URL",
+		}
+	}
+
+	if c.PayloadRetention < 0 {
+		return &ValidationError{
+			Field:   "WEBHOOK_PAYLOAD_RETENTION",
+			Message: "must not be negative (use 0 to disable payload storage)",
+		}
+	}
+
+	return nil
+}
+
+// validateSeverityFloors validates SEVERITY_FLOORS entries of the form label=value:severity
+func (c *Config) validateSeverityFloors() *ValidationError {
+	for _, floor := range c.SeverityFloors {
+		selector, severity, ok := strings.Cut(floor, ":")
+		label, value, hasValue := strings.Cut(selector, "=")
+		if !ok || !hasValue || strings.TrimSpace(label) == "" || strings.TrimSpace(value) == "" {
+			return &ValidationError{
+				Field:   "SEVERITY_FLOORS",
+				Message: fmt.Sprintf("invalid entry %q, expected label=value:severity", floor),
+			}
+		}
+
+		switch strings.ToLower(strings.TrimSpace(severity)) {
+		case "critical", "high", "medium", "low":
+		default:
+			return &ValidationError{
+				Field:   "SEVERITY_FLOORS",
+				Message: fmt.Sprintf("invalid severity in %q, must be one of critical, high, medium, low", floor),
+			}
+		}
+	}
+
+	return nil
+}
+
+// validateSeverityDowngradeMinSeverity validates the severity incidents are
+// not downgraded below
+func (c *Config) validateSeverityDowngradeMinSeverity() *ValidationError {
+	switch strings.ToLower(strings.TrimSpace(c.SeverityDowngradeMinSeverity)) {
+	case "", "critical", "high", "medium", "low":
+		return nil
+	default:
+		return &ValidationError{
+			Field:   "SEVERITY_DOWNGRADE_MIN_SEVERITY",
+			Message: "must be one of critical, high, medium, low",
+		}
+	}
+}
+
+// validateNotificationFanoutLimits validates NOTIFICATION_FANOUT_LIMITS entries
+// of the form severity:max
+func (c *Config) validateNotificationFanoutLimits() *ValidationError {
+	for _, limit := range c.NotificationFanoutLimits {
+		severity, max, ok := strings.Cut(limit, ":")
+		switch strings.ToLower(strings.TrimSpace(severity)) {
+		case "critical", "high", "medium", "low":
+		default:
+			ok = false
+		}
+		if n, err := strconv.Atoi(strings.TrimSpace(max)); !ok || err != nil || n < 0 {
+			return &ValidationError{
+				Field:   "NOTIFICATION_FANOUT_LIMITS",
+				Message: fmt.Sprintf("invalid entry %q, expected severity:max with a non-negative max", limit),
+			}
+		}
+	}
+
+	return nil
+}
+
+// validateSLATargets validates SLA_ACK_TARGETS and SLA_RESOLVE_TARGETS entries
+// of the form severity:duration
+func (c *Config) validateSLATargets() *ValidationError {
+	fields := []struct {
+		name    string
+		targets []string
+	}{
+		{"SLA_ACK_TARGETS", c.SLAAckTargets},
+		{"SLA_RESOLVE_TARGETS", c.SLAResolveTargets},
+	}
+	for _, field := range fields {
+		for _, target := range field.targets {
+			severity, value, ok := strings.Cut(target, ":")
+			switch strings.ToLower(strings.TrimSpace(severity)) {
+			case "critical", "high", "medium", "low":
+			default:
+				ok = false
+			}
+			if d, err := time.ParseDuration(strings.TrimSpace(value)); !ok || err != nil || d <= 0 {
+				return &ValidationError{
+					Field:   field.name,
+					Message: fmt.Sprintf("invalid entry %q, expected severity:duration with a positive duration", target),
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// validateDefaultIncidentLabels validates DEFAULT_INCIDENT_LABELS entries of
+// the form key=value
+func (c *Config) validateDefaultIncidentLabels() *ValidationError {
+	for _, label := range c.DefaultIncidentLabels {
+		key, _, ok := strings.Cut(label, "=")
+		if !ok || strings.TrimSpace(key) == "" {
+			return &ValidationError{
+				Field:   "DEFAULT_INCIDENT_LABELS",
+				Message: fmt.Sprintf("invalid entry %q, expected key=value", label),
+			}
+		}
+	}
+
+	return nil
+}
+
+// validateMentionConfig validates MENTION_TEAMS entries of the form
+// team:username|username and the mention recipient cap
+func (c *Config) validateMentionConfig() *ValidationError {
+	for _, team := range c.MentionTeams {
+		name, members, ok := strings.Cut(team, ":")
+		if !ok || strings.TrimSpace(name) == "" || strings.Trim(members, "| ") == "" {
+			return &ValidationError{
+				Field:   "MENTION_TEAMS",
+				Message: fmt.Sprintf("invalid entry %q, expected team:username|username", team),
+			}
+		}
+	}
+
+	if c.MaxMentionRecipients < 0 {
+		return &ValidationError{
+			Field:   "MENTION_MAX_RECIPIENTS",
+			Message: "must not be negative (use 0 for the default of 25)",
+		}
+	}
+
+	return nil
+}
+
+// validateCommentRateLimit validates the per-user comment rate limit
+func (c *Config) validateCommentRateLimit() *ValidationError {
+	if c.CommentRatePerMinute < 0 {
+		return &ValidationError{
+			Field:   "COMMENT_RATE_PER_MINUTE",
+			Message: "must not be negative (use 0 to disable)",
+		}
+	}
+
+	if c.CommentRatePerMinute > 0 && c.CommentRateBurst < 1 {
+		return &ValidationError{
+			Field:   "COMMENT_RATE_BURST",
+			Message: "must be at least 1 when comment rate limiting is enabled",
+		}
+	}
+
+	return nil
+}
+
+// validateRoleRateLimits validates ROLE_RATE_LIMITS entries of the form
+// role:per_minute:burst
+func (c *Config) validateRoleRateLimits() *ValidationError {
+	for _, limit := range c.RoleRateLimits {
+		parts := strings.Split(limit, ":")
+		valid := len(parts) == 3 && strings.TrimSpace(parts[0]) != ""
+		if valid {
+			perMinute, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+			valid = err == nil && perMinute > 0
+		}
+		if valid {
+			burst, err := strconv.Atoi(strings.TrimSpace(parts[2]))
+			valid = err == nil && burst >= 1
+		}
+		if !valid {
+			return &ValidationError{
+				Field:   "ROLE_RATE_LIMITS",
+				Message: fmt.Sprintf("invalid entry %q, expected role:per_minute:burst with a positive rate and a burst of at least 1", limit),
+			}
+		}
+	}
+
+	return nil
+}
+
+// validateAPIRateLimits validates the per-user and per-IP API rate limits and
+// the trusted proxies client IPs are taken from
+func (c *Config) validateAPIRateLimits() *ValidationError {
+	for _, limit := range []struct {
+		rateField, burstField string
+		perMinute             float64
+		burst                 int
+	}{
+		{"USER_RATE_PER_MINUTE", "USER_RATE_BURST", c.UserRatePerMinute, c.UserRateBurst},
+		{"IP_RATE_PER_MINUTE", "IP_RATE_BURST", c.IPRatePerMinute, c.IPRateBurst},
+	} {
+		if limit.perMinute < 0 {
+			return &ValidationError{
+				Field:   limit.rateField,
+				Message: "must not be negative (use 0 to disable)",
+			}
+		}
+		if limit.perMinute > 0 && limit.burst < 1 {
+			return &ValidationError{
+				Field:   limit.burstField,
+				Message: "must be at least 1 when rate limiting is enabled",
+			}
+		}
+	}
+
+	if _, err := ratelimit.ParseTrustedProxies(c.TrustedProxies); err != nil {
+		return &ValidationError{
+			Field:   "TRUSTED_PROXIES",
+			Message: err.Error(),
+		}
+	}
+
+	return nil
+}
+
+// validateRequestLimits validates the request body limit and request timeout
+func (c *Config) validateRequestLimits() *ValidationError {
+	if c.MaxBodyBytes < 0 {
+		return &ValidationError{
+			Field:   "MAX_BODY_BYTES",
+			Message: "must not be negative (use 0 for no limit)",
+		}
+	}
+
+	// Images pasted into comments arrive base64 encoded in the JSON body
+	if c.MaxBodyBytes > 0 && c.MaxInlineImageBytes > 0 {
+		if encoded := int64(base64.StdEncoding.EncodedLen(int(c.MaxInlineImageBytes))); c.MaxBodyBytes < encoded {
+			return &ValidationError{
+				Field:   "MAX_BODY_BYTES",
+				Message: fmt.Sprintf("must be at least %d to fit an inline image of INLINE_IMAGE_MAX_BYTES encoded as base64", encoded),
+			}
+		}
+	}
+
+	if c.RequestTimeout < 0 {
+		return &ValidationError{
+			Field:   "REQUEST_TIMEOUT",
+			Message: "must not be negative (use 0 for no timeout)",
+		}
+	}
+
+	return nil
+}
+
+// validateFrontendURL validates the origin WebSockets are accepted from
+func (c *Config) validateFrontendURL() *ValidationError {
+	if c.FrontendURL == "" {
+		return nil
+	}
+	u, err := url.Parse(c.FrontendURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return &ValidationError{
+			Field:   "FRONTEND_URL",
+			Message: "must be an http:// or https:// URL",
+		}
+	}
+	return nil
+}
+
+// validateIncidentTextLimits validates the incident size limits
+func (c *Config) validateIncidentTextLimits() *ValidationError {
+	if c.MaxIncidentTitleLength < 0 {
+		return &ValidationError{
+			Field:   "MAX_INCIDENT_TITLE_LENGTH",
+			Message: "must not be negative (0 uses the default)",
+		}
+	}
+
+	if c.MaxIncidentDescriptionLength < 0 {
+		return &ValidationError{
+			Field:   "MAX_INCIDENT_DESCRIPTION_LENGTH",
+			Message: "must not be negative (0 uses the default)",
+		}
+	}
+
+	if c.MaxAlertsPerIncident < 0 {
+		return &ValidationError{
+			Field:   "MAX_ALERTS_PER_INCIDENT",
+			Message: "must not be negative (use 0 for no limit)",
+		}
+	}
+
+	if c.MaxInlineImageBytes < 0 {
+		return &ValidationError{
+			Field:   "INLINE_IMAGE_MAX_BYTES",
+			Message: "must not be negative (0 uses the default)",
+		}
+	}
+
+	if c.AttachmentMaxBytes < 0 {
+		return &ValidationError{
+			Field:   "ATTACHMENT_MAX_BYTES",
+			Message: "must not be negative (0 uses the default)",
+		}
+	}
+
+	for _, mimeType := range c.AttachmentAllowedTypes {
+		if major, minor, ok := strings.Cut(mimeType, "/"); !ok || major == "" || minor == "" {
+			return &ValidationError{
+				Field:   "ATTACHMENT_ALLOWED_TYPES",
+				Message: fmt.Sprintf("invalid MIME type %q, expected type/subtype", mimeType),
+			}
+		}
+	}
+
+	switch c.AttachmentBackend {
+	case "", AttachmentBackendLocal:
+	case AttachmentBackendS3:
+		if c.AttachmentS3Bucket == "" {
+			return &ValidationError{
+				Field:   "ATTACHMENT_S3_BUCKET",
+				Message: "is required when ATTACHMENT_BACKEND is s3",
+			}
+		}
+		if c.AttachmentS3AccessKeyID == "" || c.AttachmentS3SecretAccessKey == "" {
+			return &ValidationError{
+				Field:   "ATTACHMENT_S3_ACCESS_KEY_ID",
+				Message: "ATTACHMENT_S3_ACCESS_KEY_ID and ATTACHMENT_S3_SECRET_ACCESS_KEY are required when ATTACHMENT_BACKEND is s3",
+			}
+		}
+		// Signature Version 4 presigned URLs are valid for at most a week
+		if c.AttachmentS3PresignExpiry < time.Second || c.AttachmentS3PresignExpiry > 7*24*time.Hour {
+			return &ValidationError{
+				Field:   "ATTACHMENT_S3_PRESIGN_EXPIRY",
+				Message: "must be between 1s and 168h",
+			}
+		}
+	default:
+		return &ValidationError{
+			Field:   "ATTACHMENT_BACKEND",
+			Message: fmt.Sprintf("must be %s or %s, got %q", AttachmentBackendLocal, AttachmentBackendS3, c.AttachmentBackend),
+		}
+	}
+
+	return nil
+}
+
+// validateAlertStormConfig validates alert storm detection settings
+func (c *Config) validateAlertStormConfig() *ValidationError {
+	if c.AlertStormThreshold < 0 {
+		return &ValidationError{
+			Field:   "ALERT_STORM_THRESHOLD",
+			Message: "must not be negative (use 0 to disable storm detection)",
+		}
+	}
+
+	if c.AlertStormThreshold > 0 && c.AlertStormWindow <= 0 {
+		return &ValidationError{
+			Field:   "ALERT_STORM_WINDOW",
+			Message: "must be positive when storm detection is enabled",
+		}
+	}
+
+	return nil
+}
+
+// validateAlertSpoolConfig validates the webhook spool used while storage is
+// unavailable
+func (c *Config) validateAlertSpoolConfig() *ValidationError {
+	if c.AlertSpoolSize < 0 {
+		return &ValidationError{
+			Field:   "ALERT_SPOOL_SIZE",
+			Message: "must not be negative (use 0 to disable spooling)",
+		}
+	}
+
+	if c.AlertSpoolSize > 0 && c.AlertSpoolReplayInterval <= 0 {
+		return &ValidationError{
+			Field:   "ALERT_SPOOL_REPLAY_INTERVAL",
+			Message: "must be positive when spooling is enabled",
+		}
+	}
+
+	return nil
+}
+
+// validateAlertLabelNormalization validates ALERT_LABEL_NORMALIZATION entries
+// of the form drop:label or rewrite:label=regex=>replacement
+func (c *Config) validateAlertLabelNormalization() *ValidationError {
+	for _, rule := range c.AlertLabelNormalization {
+		action, rest, _ := strings.Cut(rule, ":")
+		switch strings.TrimSpace(action) {
+		case "drop":
+			if strings.TrimSpace(rest) != "" {
+				continue
+			}
+		case "rewrite":
+			label, expr, ok := strings.Cut(rest, "=")
+			sep := strings.LastIndex(expr, "=>")
+			if ok && strings.TrimSpace(label) != "" && sep > 0 {
+				if _, err := regexp.Compile(expr[:sep]); err != nil {
+					return &ValidationError{
+						Field:   "ALERT_LABEL_NORMALIZATION",
+						Message: fmt.Sprintf("invalid regex in %q: %v", rule, err),
+					}
+				}
+				continue
+			}
+		}
+		return &ValidationError{
+			Field:   "ALERT_LABEL_NORMALIZATION",
+			Message: fmt.Sprintf("invalid entry %q, expected drop:label or rewrite:label=regex=>replacement", rule),
+		}
+	}
+
+	return nil
+}
+
+// validateAckEscalationConfig validates acknowledgement timeout escalation
+func (c *Config) validateAckEscalationConfig() *ValidationError {
+	if c.AckTimeout < 0 {
+		return &ValidationError{
+			Field:   "ACK_TIMEOUT",
+			Message: "must not be negative (use 0 to disable escalation)",
+		}
+	}
+
+	if c.AckTimeout > 0 && c.AckEscalationScheduleID == "" {
+		return &ValidationError{
+			Field:   "ACK_ESCALATION_SCHEDULE_ID",
+			Message: "is required when ACK_TIMEOUT is set",
+		}
+	}
+
+	return nil
+}
+
+// validateDigestConfig validates the open incident digest schedule
+func (c *Config) validateDigestConfig() *ValidationError {
+	if c.DigestSchedule == "" {
+		return nil // Digest disabled
+	}
+
+	if _, err := cron.Parse(c.DigestSchedule); err != nil {
+		return &ValidationError{
+			Field:   "DIGEST_SCHEDULE",
+			Message: err.Error(),
+		}
+	}
+
+	if c.DigestChannelID == "" {
+		return &ValidationError{
+			Field:   "DIGEST_CHANNEL_ID",
+			Message: "is required when DIGEST_SCHEDULE is set",
+		}
+	}
+
+	return nil
+}
+
+// validateNotificationFailureConfig validates the notification failure meta-alert settings
+func (c *Config) validateNotificationFailureConfig() *ValidationError {
+	if c.NotificationFailureThreshold < 0 {
+		return &ValidationError{
+			Field:   "NOTIFICATION_FAILURE_THRESHOLD",
+			Message: "must not be negative (use 0 to disable)",
+		}
+	}
+
+	if c.NotificationFailureThreshold > 0 && c.NotificationFailureWindow <= 0 {
+		return &ValidationError{
+			Field:   "NOTIFICATION_FAILURE_WINDOW",
+			Message: "must be greater than 0 when failure alerting is enabled",
+		}
+	}
+
+	return nil
+}
+
+// validateNotificationRetryConfig validates the notification retry policy:
+// how often a notification is attempted before it is dead-lettered and the
+// exponential backoff between attempts
+func (c *Config) validateNotificationRetryConfig() *ValidationError {
+	if c.NotifyRetryMaxAttempts < 1 {
+		return &ValidationError{
+			Field:   "NOTIFY_RETRY_MAX_ATTEMPTS",
+			Message: "must be at least 1",
+		}
+	}
+
+	if c.NotifyRetryBaseDelay <= 0 {
+		return &ValidationError{
+			Field:   "NOTIFY_RETRY_BASE_DELAY",
+			Message: "must be greater than 0",
+		}
+	}
+
+	if c.NotifyRetryMaxDelay < c.NotifyRetryBaseDelay {
+		return &ValidationError{
+			Field:   "NOTIFY_RETRY_MAX_DELAY",
+			Message: "must not be less than NOTIFY_RETRY_BASE_DELAY",
+		}
+	}
+
+	if c.NotifyRetryMultiplier < 1 {
+		return &ValidationError{
+			Field:   "NOTIFY_RETRY_MULTIPLIER",
+			Message: "must be at least 1",
+		}
+	}
+
+	return nil
+}
+
+// validateCircuitBreakerConfig validates the thresholds of the circuit
+// breaker that guards notification delivery
+func (c *Config) validateCircuitBreakerConfig() *ValidationError {
+	if c.CircuitBreakerMinRequests < 1 {
+		return &ValidationError{
+			Field:   "CIRCUIT_BREAKER_MIN_REQUESTS",
+			Message: "must be at least 1",
+		}
+	}
+
+	if c.CircuitBreakerFailureRatio <= 0 || c.CircuitBreakerFailureRatio > 1 {
+		return &ValidationError{
+			Field:   "CIRCUIT_BREAKER_FAILURE_RATIO",
+			Message: "must be greater than 0 and at most 1",
+		}
+	}
+
+	if c.CircuitBreakerInterval <= 0 {
+		return &ValidationError{
+			Field:   "CIRCUIT_BREAKER_INTERVAL",
+			Message: "must be greater than 0",
+		}
+	}
+
+	if c.CircuitBreakerOpenTimeout <= 0 {
+		return &ValidationError{
+			Field:   "CIRCUIT_BREAKER_OPEN_TIMEOUT",
+			Message: "must be greater than 0",
+		}
+	}
+
+	if c.CircuitBreakerHalfOpenMaxCalls < 1 {
+		return &ValidationError{
+			Field:   "CIRCUIT_BREAKER_HALF_OPEN_MAX_CALLS",
+			Message: "must be at least 1",
+		}
+	}
+
+	return nil
+}
+
+// validateStorageBackend validates STORAGE_BACKEND and the settings the
+// chosen backend needs
+func (c *Config) validateStorageBackend() *ValidationError {
+	switch c.StorageBackend {
+	case "", StorageBackendMemory:
+	case StorageBackendPostgres:
+		if c.DatabaseURL == "" {
+			return &ValidationError{
+				Field:   "DATABASE_URL",
+				Message: "is required when STORAGE_BACKEND is postgres",
+			}
+		}
+	default:
+		return &ValidationError{
+			Field:   "STORAGE_BACKEND",
+			Message: fmt.Sprintf("must be %s or %s, got %q", StorageBackendMemory, StorageBackendPostgres, c.StorageBackend),
+		}
+	}
+
+	return nil
+}
+
+// ResolvedStorageBackend returns the storage backend to use: STORAGE_BACKEND
+// when set, otherwise postgres if DATABASE_URL is set and memory if not
+func (c *Config) ResolvedStorageBackend() string {
+	if c.StorageBackend != "" {
+		return c.StorageBackend
+	}
+	if c.DatabaseURL != "" {
+		return StorageBackendPostgres
+	}
+	return StorageBackendMemory
+}
+
 // HasNotificationConfigured returns true if at least one notification method is configured
 func (c *Config) HasNotificationConfigured() bool {
 	return (c.SlackToken != "" && c.SlackChannel != "") ||
@@ -448,6 +1348,15 @@ func getEnvInt(key string, defaultValue int) int {
 	return defaultValue
 }
 
+func getEnvFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if floatValue, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatValue
+		}
+	}
+	return defaultValue
+}
+
 func getEnvBool(key string, defaultValue bool) bool {
 	if value := os.Getenv(key); value != "" {
 		if boolValue, err := strconv.ParseBool(value); err == nil {
@@ -466,6 +1375,22 @@ func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
 	return defaultValue
 }
 
+// getEnvList reads a comma-separated list, ignoring empty entries
+func getEnvList(key string, defaultValue []string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 // generateDefaultJWTSecret generates a default JWT secret if none is provided
 func generateDefaultJWTSecret() string {
 	return "default-jwt-secret-please-change-in-production"
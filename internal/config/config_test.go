@@ -112,6 +112,19 @@ func TestValidate_ValidConfig(t *testing.T) {
 		DBMaxIdleConns:      5,
 		AlertmanagerTimeout: 30,
 		EmailSMTPPort:       587,
+		JWTSecret:           "test-jwt-secret-32-characters-long!",
+		JWTExpiration:       time.Hour,
+		RefreshExpiration:   24 * time.Hour,
+
+		NotifyRetryMaxAttempts: 3,
+		NotifyRetryBaseDelay:   2 * time.Second,
+		NotifyRetryMaxDelay:    30 * time.Second,
+		NotifyRetryMultiplier:  2.0,
+		CircuitBreakerMinRequests:      3,
+		CircuitBreakerFailureRatio:     0.5,
+		CircuitBreakerInterval:         time.Minute,
+		CircuitBreakerOpenTimeout:      time.Minute,
+		CircuitBreakerHalfOpenMaxCalls: 3,
 	}
 
 	if err := cfg.Validate(); err != nil {
@@ -204,6 +217,200 @@ func TestValidate_DatabaseConnections(t *testing.T) {
 	}
 }
 
+func TestValidate_StorageBackend(t *testing.T) {
+	base := Config{
+		Port:                "8080",
+		LogLevel:            "info",
+		MetricsPort:         "9090",
+		DBMaxOpenConns:      25,
+		DBMaxIdleConns:      5,
+		AlertmanagerTimeout: 30,
+		EmailSMTPPort:       587,
+		JWTSecret:           "test-jwt-secret-32-characters-long!",
+		JWTExpiration:       time.Hour,
+		RefreshExpiration:   24 * time.Hour,
+
+		NotifyRetryMaxAttempts: 3,
+		NotifyRetryBaseDelay:   2 * time.Second,
+		NotifyRetryMaxDelay:    30 * time.Second,
+		NotifyRetryMultiplier:  2.0,
+		CircuitBreakerMinRequests:      3,
+		CircuitBreakerFailureRatio:     0.5,
+		CircuitBreakerInterval:         time.Minute,
+		CircuitBreakerOpenTimeout:      time.Minute,
+		CircuitBreakerHalfOpenMaxCalls: 3,
+	}
+
+	tests := []struct {
+		name        string
+		backend     string
+		databaseURL string
+		wantErr     bool
+		resolved    string
+	}{
+		{"default without URL", "", "", false, StorageBackendMemory},
+		{"default with URL", "", "postgres://localhost/db", false, StorageBackendPostgres},
+		{"explicit memory with URL", StorageBackendMemory, "postgres://localhost/db", false, StorageBackendMemory},
+		{"postgres with URL", StorageBackendPostgres, "postgres://localhost/db", false, StorageBackendPostgres},
+		{"postgres without URL", StorageBackendPostgres, "", true, StorageBackendPostgres},
+		{"unknown backend", "sqlite", "", true, "sqlite"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := base
+			cfg.StorageBackend = tt.backend
+			cfg.DatabaseURL = tt.databaseURL
+
+			err := cfg.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Expected error: %v, got %v", tt.wantErr, err)
+			}
+			if got := cfg.ResolvedStorageBackend(); got != tt.resolved {
+				t.Errorf("Expected backend %s, got %s", tt.resolved, got)
+			}
+		})
+	}
+}
+
+func TestValidate_NotificationRetryPolicy(t *testing.T) {
+	base := Config{
+		Port:                "8080",
+		LogLevel:            "info",
+		MetricsPort:         "9090",
+		DBMaxOpenConns:      25,
+		DBMaxIdleConns:      5,
+		AlertmanagerTimeout: 30,
+		EmailSMTPPort:       587,
+		JWTSecret:           "test-jwt-secret-32-characters-long!",
+		JWTExpiration:       time.Hour,
+		RefreshExpiration:   24 * time.Hour,
+
+		NotifyRetryMaxAttempts: 3,
+		NotifyRetryBaseDelay:   2 * time.Second,
+		NotifyRetryMaxDelay:    30 * time.Second,
+		NotifyRetryMultiplier:  2.0,
+		CircuitBreakerMinRequests:      3,
+		CircuitBreakerFailureRatio:     0.5,
+		CircuitBreakerInterval:         time.Minute,
+		CircuitBreakerOpenTimeout:      time.Minute,
+		CircuitBreakerHalfOpenMaxCalls: 3,
+	}
+
+	tests := []struct {
+		name       string
+		modify     func(cfg *Config)
+		errorField string
+	}{
+		{"defaults", func(cfg *Config) {}, ""},
+		{"single attempt", func(cfg *Config) { cfg.NotifyRetryMaxAttempts = 1 }, ""},
+		{"zero attempts", func(cfg *Config) { cfg.NotifyRetryMaxAttempts = 0 }, "NOTIFY_RETRY_MAX_ATTEMPTS"},
+		{"negative base delay", func(cfg *Config) { cfg.NotifyRetryBaseDelay = -time.Second }, "NOTIFY_RETRY_BASE_DELAY"},
+		{"max delay below base delay", func(cfg *Config) { cfg.NotifyRetryMaxDelay = time.Second }, "NOTIFY_RETRY_MAX_DELAY"},
+		{"negative max delay", func(cfg *Config) { cfg.NotifyRetryMaxDelay = -time.Second }, "NOTIFY_RETRY_MAX_DELAY"},
+		{"shrinking multiplier", func(cfg *Config) { cfg.NotifyRetryMultiplier = 0.5 }, "NOTIFY_RETRY_MULTIPLIER"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := base
+			tt.modify(&cfg)
+
+			err := cfg.Validate()
+			if tt.errorField == "" {
+				if err != nil {
+					t.Errorf("Expected no validation error, got: %v", err)
+				}
+				return
+			}
+
+			validationErrs, ok := err.(ValidationErrors)
+			if !ok {
+				t.Fatalf("Expected ValidationErrors, got %T (%v)", err, err)
+			}
+			found := false
+			for _, vErr := range validationErrs {
+				if vErr.Field == tt.errorField {
+					found = true
+				}
+			}
+			if !found {
+				t.Errorf("Expected validation error for %s, got %v", tt.errorField, err)
+			}
+		})
+	}
+}
+
+func TestValidate_CircuitBreakerConfig(t *testing.T) {
+	base := Config{
+		Port:                "8080",
+		LogLevel:            "info",
+		MetricsPort:         "9090",
+		DBMaxOpenConns:      25,
+		DBMaxIdleConns:      5,
+		AlertmanagerTimeout: 30,
+		EmailSMTPPort:       587,
+		JWTSecret:           "test-jwt-secret-32-characters-long!",
+		JWTExpiration:       time.Hour,
+		RefreshExpiration:   24 * time.Hour,
+
+		NotifyRetryMaxAttempts: 3,
+		NotifyRetryBaseDelay:   2 * time.Second,
+		NotifyRetryMaxDelay:    30 * time.Second,
+		NotifyRetryMultiplier:  2.0,
+
+		CircuitBreakerMinRequests:      3,
+		CircuitBreakerFailureRatio:     0.5,
+		CircuitBreakerInterval:         time.Minute,
+		CircuitBreakerOpenTimeout:      time.Minute,
+		CircuitBreakerHalfOpenMaxCalls: 3,
+	}
+
+	tests := []struct {
+		name       string
+		modify     func(cfg *Config)
+		errorField string
+	}{
+		{"defaults", func(cfg *Config) {}, ""},
+		{"trip on every failure", func(cfg *Config) { cfg.CircuitBreakerFailureRatio = 1 }, ""},
+		{"zero min requests", func(cfg *Config) { cfg.CircuitBreakerMinRequests = 0 }, "CIRCUIT_BREAKER_MIN_REQUESTS"},
+		{"zero failure ratio", func(cfg *Config) { cfg.CircuitBreakerFailureRatio = 0 }, "CIRCUIT_BREAKER_FAILURE_RATIO"},
+		{"failure ratio above 1", func(cfg *Config) { cfg.CircuitBreakerFailureRatio = 1.5 }, "CIRCUIT_BREAKER_FAILURE_RATIO"},
+		{"zero interval", func(cfg *Config) { cfg.CircuitBreakerInterval = 0 }, "CIRCUIT_BREAKER_INTERVAL"},
+		{"negative open timeout", func(cfg *Config) { cfg.CircuitBreakerOpenTimeout = -time.Second }, "CIRCUIT_BREAKER_OPEN_TIMEOUT"},
+		{"zero half-open calls", func(cfg *Config) { cfg.CircuitBreakerHalfOpenMaxCalls = 0 }, "CIRCUIT_BREAKER_HALF_OPEN_MAX_CALLS"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := base
+			tt.modify(&cfg)
+
+			err := cfg.Validate()
+			if tt.errorField == "" {
+				if err != nil {
+					t.Errorf("Expected no validation error, got: %v", err)
+				}
+				return
+			}
+
+			validationErrs, ok := err.(ValidationErrors)
+			if !ok {
+				t.Fatalf("Expected ValidationErrors, got %T (%v)", err, err)
+			}
+			found := false
+			for _, vErr := range validationErrs {
+				if vErr.Field == tt.errorField {
+					found = true
+				}
+			}
+			if !found {
+				t.Errorf("Expected validation error for %s, got %v", tt.errorField, err)
+			}
+		})
+	}
+}
+
 func TestValidate_SlackConfig(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -264,8 +471,22 @@ func TestValidate_SlackConfig(t *testing.T) {
 				DBMaxIdleConns:      5,
 				AlertmanagerTimeout: 30,
 				EmailSMTPPort:       587,
-				SlackToken:          tt.token,
-				SlackChannel:        tt.channel,
+				JWTSecret:           "test-jwt-secret-32-characters-long!",
+				JWTExpiration:       time.Hour,
+				RefreshExpiration:   24 * time.Hour,
+
+				NotifyRetryMaxAttempts: 3,
+				NotifyRetryBaseDelay:   2 * time.Second,
+				NotifyRetryMaxDelay:    30 * time.Second,
+				NotifyRetryMultiplier:  2.0,
+				CircuitBreakerMinRequests:      3,
+				CircuitBreakerFailureRatio:     0.5,
+				CircuitBreakerInterval:         time.Minute,
+				CircuitBreakerOpenTimeout:      time.Minute,
+				CircuitBreakerHalfOpenMaxCalls: 3,
+
+				SlackToken:   tt.token,
+				SlackChannel: tt.channel,
 			}
 
 			err := cfg.Validate()
@@ -346,8 +567,22 @@ func TestValidate_TLSConfig(t *testing.T) {
 				DBMaxIdleConns:      5,
 				AlertmanagerTimeout: 30,
 				EmailSMTPPort:       587,
-				TLSCertFile:         tt.certFile,
-				TLSKeyFile:          tt.keyFile,
+				JWTSecret:           "test-jwt-secret-32-characters-long!",
+				JWTExpiration:       time.Hour,
+				RefreshExpiration:   24 * time.Hour,
+
+				NotifyRetryMaxAttempts: 3,
+				NotifyRetryBaseDelay:   2 * time.Second,
+				NotifyRetryMaxDelay:    30 * time.Second,
+				NotifyRetryMultiplier:  2.0,
+				CircuitBreakerMinRequests:      3,
+				CircuitBreakerFailureRatio:     0.5,
+				CircuitBreakerInterval:         time.Minute,
+				CircuitBreakerOpenTimeout:      time.Minute,
+				CircuitBreakerHalfOpenMaxCalls: 3,
+
+				TLSCertFile: tt.certFile,
+				TLSKeyFile:  tt.keyFile,
 			}
 
 			err := cfg.Validate()
@@ -589,4 +824,58 @@ func BenchmarkConfigValidation(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		cfg.Validate()
 	}
-}
\ No newline at end of file
+}
+func TestLoadConfig_WebhookSigningSecret(t *testing.T) {
+	t.Setenv("WEBHOOK_SECRETS", "old-secret-0123456789")
+	t.Setenv("WEBHOOK_SIGNING_SECRET", "signing-secret-0123456789")
+
+	cfg := LoadConfig()
+	if len(cfg.WebhookSecrets) != 2 || cfg.WebhookSecrets[1] != "signing-secret-0123456789" {
+		t.Errorf("Expected the signing secret to be accepted alongside WEBHOOK_SECRETS, got %v", cfg.WebhookSecrets)
+	}
+
+	t.Setenv("WEBHOOK_SIGNING_SECRET", "short")
+	if err := LoadConfig().validateWebhookConfig(); err == nil || err.Field != "WEBHOOK_SECRETS" {
+		t.Errorf("Expected a short signing secret to be rejected, got %v", err)
+	}
+}
+
+func TestValidate_MaxBodyBytesFitsInlineImage(t *testing.T) {
+	cfg := &Config{MaxInlineImageBytes: 5 << 20, MaxBodyBytes: 1 << 20}
+	if err := cfg.validateRequestLimits(); err == nil || err.Field != "MAX_BODY_BYTES" {
+		t.Errorf("Expected a body limit smaller than an encoded inline image to be rejected, got %v", err)
+	}
+
+	cfg.MaxBodyBytes = 8 << 20
+	if err := cfg.validateRequestLimits(); err != nil {
+		t.Errorf("Expected the default body limit to fit the default inline image, got %v", err)
+	}
+}
+
+func TestValidate_FrontendURL(t *testing.T) {
+	for _, frontendURL := range []string{"", "https://incidents.example.com", "http://localhost:5173"} {
+		cfg := &Config{FrontendURL: frontendURL}
+		if err := cfg.validateFrontendURL(); err != nil {
+			t.Errorf("Expected %q to be accepted, got %v", frontendURL, err)
+		}
+	}
+	for _, frontendURL := range []string{"incidents.example.com", "ftp://incidents.example.com", "https://"} {
+		cfg := &Config{FrontendURL: frontendURL}
+		if err := cfg.validateFrontendURL(); err == nil || err.Field != "FRONTEND_URL" {
+			t.Errorf("Expected %q to be rejected, got %v", frontendURL, err)
+		}
+	}
+}
+
+func TestValidate_SeverityDowngradeMinSeverity(t *testing.T) {
+	for _, severity := range []string{"", "medium", " High "} {
+		cfg := &Config{SeverityDowngradeMinSeverity: severity}
+		if err := cfg.validateSeverityDowngradeMinSeverity(); err != nil {
+			t.Errorf("Expected %q to be accepted, got %v", severity, err)
+		}
+	}
+	cfg := &Config{SeverityDowngradeMinSeverity: "urgent"}
+	if err := cfg.validateSeverityDowngradeMinSeverity(); err == nil || err.Field != "SEVERITY_DOWNGRADE_MIN_SEVERITY" {
+		t.Errorf("Expected an unknown severity to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,119 @@
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse_Invalid(t *testing.T) {
+	tests := []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"a * * * *",
+	}
+
+	for _, expr := range tests {
+		if _, err := Parse(expr); err == nil {
+			t.Errorf("Expected %q to be rejected", expr)
+		}
+	}
+}
+
+func TestSchedule_Next(t *testing.T) {
+	base := time.Date(2024, time.March, 15, 10, 30, 0, 0, time.UTC) // Friday
+
+	tests := []struct {
+		name     string
+		expr     string
+		from     time.Time
+		expected time.Time
+	}{
+		{
+			name:     "Every minute",
+			expr:     "* * * * *",
+			from:     base,
+			expected: time.Date(2024, time.March, 15, 10, 31, 0, 0, time.UTC),
+		},
+		{
+			name:     "Every 15 minutes",
+			expr:     "*/15 * * * *",
+			from:     base.Add(5 * time.Minute),
+			expected: time.Date(2024, time.March, 15, 10, 45, 0, 0, time.UTC),
+		},
+		{
+			name:     "Later today",
+			expr:     "0 17 * * *",
+			from:     base,
+			expected: time.Date(2024, time.March, 15, 17, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "Tomorrow when today's slot passed",
+			expr:     "0 9 * * *",
+			from:     base,
+			expected: time.Date(2024, time.March, 16, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "Weekdays only skips weekend",
+			expr:     "0 9 * * 1-5",
+			from:     base,
+			expected: time.Date(2024, time.March, 18, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "Sunday as 7",
+			expr:     "0 0 * * 7",
+			from:     base,
+			expected: time.Date(2024, time.March, 17, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "Hour list",
+			expr:     "0 8,20 * * *",
+			from:     base,
+			expected: time.Date(2024, time.March, 15, 20, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "Monthly rolls into next year",
+			expr:     "@monthly",
+			from:     time.Date(2024, time.December, 5, 0, 0, 0, 0, time.UTC),
+			expected: time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "Exact match is strictly after",
+			expr:     "30 10 * * *",
+			from:     base,
+			expected: time.Date(2024, time.March, 16, 10, 30, 0, 0, time.UTC),
+		},
+		{
+			name:     "Day of month or day of week",
+			expr:     "0 0 1 * 0",
+			from:     base,
+			expected: time.Date(2024, time.March, 17, 0, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := Parse(tt.expr)
+			if err != nil {
+				t.Fatalf("Failed to parse %q: %v", tt.expr, err)
+			}
+			if got := schedule.Next(tt.from); !got.Equal(tt.expected) {
+				t.Errorf("Next(%s) = %s, expected %s", tt.from, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSchedule_NextImpossibleDate(t *testing.T) {
+	schedule, err := Parse("0 0 30 2 *")
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	if got := schedule.Next(time.Now()); !got.IsZero() {
+		t.Errorf("Expected zero time for an impossible date, got %s", got)
+	}
+}
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strings"
 
@@ -34,11 +35,11 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req models.RegisterRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := decodeJSON(r, &req); err != nil {
 		h.logger.Error("Failed to decode registration request", map[string]interface{}{
 			"error": err.Error(),
 		})
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		http.Error(w, invalidBodyMessage(err, "Invalid request body"), http.StatusBadRequest)
 		return
 	}
 
@@ -107,11 +108,11 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req models.LoginRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := decodeJSON(r, &req); err != nil {
 		h.logger.Error("Failed to decode login request", map[string]interface{}{
 			"error": err.Error(),
 		})
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		http.Error(w, invalidBodyMessage(err, "Invalid request body"), http.StatusBadRequest)
 		return
 	}
 
@@ -138,6 +139,10 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, "Invalid credentials", http.StatusUnauthorized)
 		case services.ErrUserNotActive:
 			http.Error(w, "Account is not active", http.StatusForbidden)
+		case services.ErrTwoFactorRequired:
+			http.Error(w, "Two-factor code required", http.StatusUnauthorized)
+		case services.ErrInvalidTwoFactorCode:
+			http.Error(w, "Invalid two-factor code", http.StatusUnauthorized)
 		default:
 			http.Error(w, "Login failed", http.StatusInternalServerError)
 		}
@@ -165,8 +170,8 @@ func (h *AuthHandler) RefreshToken(w http.ResponseWriter, r *http.Request) {
 		RefreshToken string `json:"refresh_token"`
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	if err := decodeJSON(r, &req); err != nil {
+		http.Error(w, invalidBodyMessage(err, "Invalid request body"), http.StatusBadRequest)
 		return
 	}
 
@@ -181,12 +186,12 @@ func (h *AuthHandler) RefreshToken(w http.ResponseWriter, r *http.Request) {
 			"error": err.Error(),
 		})
 
-		switch err {
-		case services.ErrTokenExpired:
+		switch {
+		case errors.Is(err, services.ErrTokenExpired):
 			http.Error(w, "Refresh token expired", http.StatusUnauthorized)
-		case services.ErrInvalidToken:
+		case errors.Is(err, services.ErrInvalidToken):
 			http.Error(w, "Invalid refresh token", http.StatusUnauthorized)
-		case services.ErrUserNotActive:
+		case errors.Is(err, services.ErrUserNotActive):
 			http.Error(w, "Account is not active", http.StatusForbidden)
 		default:
 			http.Error(w, "Token refresh failed", http.StatusInternalServerError)
@@ -274,8 +279,8 @@ func (h *AuthHandler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req models.UpdateProfileRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	if err := decodeJSON(r, &req); err != nil {
+		http.Error(w, invalidBodyMessage(err, "Invalid request body"), http.StatusBadRequest)
 		return
 	}
 
@@ -323,8 +328,8 @@ func (h *AuthHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req models.ChangePasswordRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	if err := decodeJSON(r, &req); err != nil {
+		http.Error(w, invalidBodyMessage(err, "Invalid request body"), http.StatusBadRequest)
 		return
 	}
 
@@ -360,6 +365,93 @@ func (h *AuthHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// EnrollTwoFactor handles POST /api/auth/2fa/enroll. It returns a new TOTP
+// secret and otpauth URL; 2FA is enforced once the enrollment is verified.
+func (h *AuthHandler) EnrollTwoFactor(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "User not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	enrollment, err := h.userService.EnrollTwoFactor(r.Context(), userID)
+	if err != nil {
+		h.logger.Error("Failed to enroll two-factor authentication", map[string]interface{}{
+			"user_id": userID,
+			"error":   err.Error(),
+		})
+
+		switch err {
+		case services.ErrTwoFactorAlreadyActive:
+			http.Error(w, "Two-factor authentication is already enabled", http.StatusConflict)
+		case services.ErrUserNotFound:
+			http.Error(w, "User not found", http.StatusNotFound)
+		default:
+			http.Error(w, "Failed to enroll two-factor authentication", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(enrollment)
+}
+
+// VerifyTwoFactor handles POST /api/auth/2fa/verify, enabling 2FA once the
+// submitted code matches the enrolled secret
+func (h *AuthHandler) VerifyTwoFactor(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "User not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	var req models.TwoFactorVerifyRequest
+	if err := decodeJSON(r, &req); err != nil {
+		http.Error(w, invalidBodyMessage(err, "Invalid request body"), http.StatusBadRequest)
+		return
+	}
+	if req.Code == "" {
+		http.Error(w, "code is required", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.userService.ConfirmTwoFactor(r.Context(), userID, req.Code); err != nil {
+		h.logger.Error("Failed to verify two-factor enrollment", map[string]interface{}{
+			"user_id": userID,
+			"error":   err.Error(),
+		})
+
+		switch err {
+		case services.ErrInvalidTwoFactorCode:
+			http.Error(w, "Invalid two-factor code", http.StatusBadRequest)
+		case services.ErrTwoFactorNotEnrolled:
+			http.Error(w, "Two-factor authentication is not enrolled", http.StatusBadRequest)
+		case services.ErrTwoFactorAlreadyActive:
+			http.Error(w, "Two-factor authentication is already enabled", http.StatusConflict)
+		case services.ErrUserNotFound:
+			http.Error(w, "User not found", http.StatusNotFound)
+		default:
+			http.Error(w, "Failed to verify two-factor authentication", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Two-factor authentication enabled",
+	})
+}
+
 // Validation helper functions
 
 func (h *AuthHandler) validateRegisterRequest(req *models.RegisterRequest) error {
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -39,6 +40,7 @@ func setupTestAuthHandler(t *testing.T) (*AuthHandler, storage.Store) {
 }
 
 func setupTestRolesAndPermissions(t *testing.T, store storage.Store) {
+	ctx := context.Background()
 	// Create default permissions
 	permissions := []*models.Permission{
 		{ID: "1", Name: "incidents.read", Resource: "incidents", Action: "read", Description: "View incidents"},
@@ -70,11 +72,11 @@ func setupTestRolesAndPermissions(t *testing.T, store storage.Store) {
 		Permissions: permissions, // All permissions
 	}
 
-	if err := store.CreateRole(viewerRole); err != nil {
+	if err := store.CreateRole(ctx, viewerRole); err != nil {
 		t.Fatalf("Failed to create viewer role: %v", err)
 	}
 
-	if err := store.CreateRole(adminRole); err != nil {
+	if err := store.CreateRole(ctx, adminRole); err != nil {
 		t.Fatalf("Failed to create admin role: %v", err)
 	}
 }
@@ -139,6 +141,7 @@ func TestAuthHandler_Register(t *testing.T) {
 }
 
 func TestAuthHandler_Login(t *testing.T) {
+	ctx := context.Background()
 	authHandler, store := setupTestAuthHandler(t)
 
 	// Create a test user first
@@ -159,7 +162,7 @@ func TestAuthHandler_Login(t *testing.T) {
 	}
 	testUser.Password = hashedPassword
 
-	if err := store.CreateUser(testUser); err != nil {
+	if err := store.CreateUser(ctx, testUser); err != nil {
 		t.Fatalf("Failed to create test user: %v", err)
 	}
 
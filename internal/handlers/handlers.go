@@ -1,13 +1,20 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"log"
+	"net"
 	"net/http"
+	"net/url"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/circuitbreaker"
@@ -19,7 +26,7 @@ import (
 	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/services"
 	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/storage"
 	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/validation"
-	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"golang.org/x/time/rate"
 )
 
 // Handler handles HTTP requests
@@ -28,18 +35,43 @@ type Handler struct {
 	alertService          *services.AlertService
 	notificationService   *services.NotificationService
 	webhookValidator      *validation.WebhookValidator
+	signatureVerifier     *validation.SignatureVerifier
+	webhookPath           string
 	idempotencyManager    *idempotency.WebhookIdempotencyManager
 	retryer              *retry.Retryer
 	rateLimitConfig      *ratelimit.RateLimitConfig
+	commentRateLimiter   *ratelimit.PerIPRateLimiter
 	circuitBreaker       *circuitbreaker.CircuitBreaker
+	eventBus             *services.EventBus
+	webSocketOrigin      string
 	metricsService       *services.MetricsService
 	logger               *services.Logger
 	store                storage.Store
 	userService          *services.UserService
 	authService          *services.AuthService
 	authHandler          *AuthHandler
+	userHandler          *UserHandler
+	notificationHandlers *NotificationHandlers
+
+	// Raw webhook payloads are kept for replay when payloadRetention is set
+	payloadRetention time.Duration
+	payloadPruneMu   sync.Mutex
+	lastPayloadPrune time.Time
+
+	// Webhooks that fail processing are buffered here for replay when set
+	alertSpool *services.AlertSpool
+
+	// Authenticated requests are throttled per user and role when set
+	roleRateLimiter *middleware.RoleRateLimiter
+	apiRateLimiter  *middleware.APIRateLimiter
 }
 
+// webhookIdempotencyTTL is how long a processed webhook payload is remembered
+const webhookIdempotencyTTL = 10 * time.Minute
+
+// DefaultWebhookPath is where the Alertmanager webhook is served unless configured otherwise
+const DefaultWebhookPath = "/api/webhooks/alertmanager"
+
 // NewHandler creates a new handler
 func NewHandler(
 	incidentService *services.IncidentService,
@@ -54,8 +86,8 @@ func NewHandler(
 	// Initialize reliability components
 	webhookValidator := validation.NewWebhookValidator()
 	idempotencyStore := idempotency.NewMemoryIdempotencyStore()
-	idempotencyManager := idempotency.NewWebhookIdempotencyManager(idempotencyStore, 10*time.Minute)
-	
+	idempotencyManager := idempotency.NewWebhookIdempotencyManager(idempotencyStore, webhookIdempotencyTTL)
+
 	// Create retry policy for webhook processing
 	retryPolicy := &retry.RetryPolicy{
 		MaxAttempts: 3,
@@ -69,20 +101,20 @@ func NewHandler(
 	rateLimitConfig := ratelimit.DefaultWebhookRateLimit()
 	
 	// Circuit breaker for notification service
-	cbConfig := circuitbreaker.DefaultConfig()
-	cbConfig.OnStateChange = func(name string, from circuitbreaker.State, to circuitbreaker.State) {
-		log.Printf("Circuit breaker '%s' changed state from %s to %s", name, from, to)
-	}
-	circuitBreaker := circuitbreaker.NewCircuitBreaker("notification-service", cbConfig)
-	
+	circuitBreaker := newNotificationCircuitBreaker(circuitbreaker.DefaultConfig(), metricsService)
+
 	// Create auth handler
 	authHandler := NewAuthHandler(userService, authService, logger)
+	userHandler := NewUserHandler(userService, authService, logger)
+	notificationHandlers := NewNotificationHandlers(store, notificationService, nil, nil, logger)
 	
 	return &Handler{
 		incidentService:     incidentService,
 		alertService:        alertService,
 		notificationService: notificationService,
 		webhookValidator:    webhookValidator,
+		signatureVerifier:   validation.NewSignatureVerifier(nil),
+		webhookPath:         DefaultWebhookPath,
 		idempotencyManager:  idempotencyManager,
 		retryer:            retryer,
 		rateLimitConfig:    rateLimitConfig,
@@ -93,69 +125,190 @@ func NewHandler(
 		userService:         userService,
 		authService:         authService,
 		authHandler:         authHandler,
+		userHandler:         userHandler,
+		notificationHandlers: notificationHandlers,
+	}
+}
+
+// ConfigureWebhook sets the Alertmanager webhook path and the HMAC secrets
+// accepted for its signature. Several secrets may be active at once so that a
+// secret can be rotated without downtime. Must be called before RegisterRoutes.
+func (h *Handler) ConfigureWebhook(path string, secrets []string) {
+	if path != "" {
+		h.webhookPath = path
+	}
+	h.signatureVerifier = validation.NewSignatureVerifier(secrets)
+}
+
+// ConfigureWebhookPayloadStorage keeps raw Alertmanager payloads for the
+// given retention so they can be replayed. Zero disables storage.
+func (h *Handler) ConfigureWebhookPayloadStorage(retention time.Duration) {
+	h.payloadRetention = retention
+}
+
+// ConfigureIdempotencyStore replaces the in-memory store used to deduplicate
+// webhook deliveries, e.g. with a Redis store shared between replicas
+func (h *Handler) ConfigureIdempotencyStore(store idempotency.IdempotencyStore) {
+	h.idempotencyManager = idempotency.NewWebhookIdempotencyManager(store, webhookIdempotencyTTL)
+}
+
+// ConfigureCircuitBreaker replaces the notification circuit breaker with one
+// using the given thresholds. Must be called before the handler serves requests.
+func (h *Handler) ConfigureCircuitBreaker(config *circuitbreaker.Config) {
+	h.circuitBreaker = newNotificationCircuitBreaker(config, h.metricsService)
+}
+
+// newNotificationCircuitBreaker creates the notification circuit breaker,
+// logging its state changes and exporting its state as a metric
+func newNotificationCircuitBreaker(config *circuitbreaker.Config, metricsService *services.MetricsService) *circuitbreaker.CircuitBreaker {
+	config.OnStateChange = func(name string, from circuitbreaker.State, to circuitbreaker.State) {
+		log.Printf("Circuit breaker '%s' changed state from %s to %s", name, from, to)
+		if metricsService != nil {
+			metricsService.UpdateCircuitBreakerState(name, to)
+		}
+	}
+	cb := circuitbreaker.NewCircuitBreaker("notification-service", config)
+	if metricsService != nil {
+		metricsService.UpdateCircuitBreakerState(cb.Name(), cb.State())
+	}
+	return cb
+}
+
+// ConfigureAlertSpool buffers webhooks that cannot be processed, e.g. during
+// a database outage, in the given spool instead of failing them. Nil
+// disables spooling.
+func (h *Handler) ConfigureAlertSpool(spool *services.AlertSpool) {
+	h.alertSpool = spool
+}
+
+// ConfigureCommentRateLimit throttles comments per user and incident using a
+// token bucket refilled at perMinute tokens with the given burst. A rate of
+// zero disables the limit.
+func (h *Handler) ConfigureCommentRateLimit(perMinute float64, burst int) {
+	if perMinute <= 0 {
+		h.commentRateLimiter = nil
+		return
+	}
+	h.commentRateLimiter = ratelimit.NewPerIPRateLimiter(rate.Limit(perMinute/60), burst)
+}
+
+// ConfigureRoleRateLimits throttles authenticated API requests per user with
+// a budget chosen by the user's roles. Roles without a limit, and an empty
+// map, are not throttled.
+func (h *Handler) ConfigureRoleRateLimits(limits map[string]middleware.RoleRateLimit) {
+	if len(limits) == 0 {
+		h.roleRateLimiter = nil
+		return
 	}
+	h.roleRateLimiter = middleware.NewRoleRateLimiter(limits)
+}
+
+// ConfigureAPIRateLimits throttles API requests per authenticated user and
+// public API requests per client IP. A limit with a zero rate is disabled.
+// These limits apply on top of any per-role limits. Forwarding headers only
+// set the client IP on requests from one of the trusted proxies.
+func (h *Handler) ConfigureAPIRateLimits(userLimit, ipLimit middleware.RoleRateLimit, trustedProxies []*net.IPNet) {
+	if userLimit.PerMinute <= 0 && ipLimit.PerMinute <= 0 {
+		h.apiRateLimiter = nil
+		return
+	}
+	h.apiRateLimiter = middleware.NewAPIRateLimiter(userLimit, ipLimit, trustedProxies)
+}
+
+// authenticated requires a valid token for next and applies the per-role
+// and per-user rate limits to the authenticated user
+func (h *Handler) authenticated(next http.Handler) http.Handler {
+	return h.authenticatedWith(middleware.AuthMiddleware(h.authService), next)
+}
+
+// authenticatedWith is authenticated with a different authentication
+// middleware, such as the one accepting WebSocket tokens
+func (h *Handler) authenticatedWith(auth func(http.Handler) http.Handler, next http.Handler) http.Handler {
+	limited := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handler := h.rateLimited(next)
+		if h.roleRateLimiter != nil {
+			handler = h.roleRateLimiter.Middleware(handler)
+		}
+		handler.ServeHTTP(w, r)
+	})
+	return auth(limited)
+}
+
+// rateLimited applies the API rate limits to next: per user when the request
+// is authenticated, per client IP otherwise
+func (h *Handler) rateLimited(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if h.apiRateLimiter == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		h.apiRateLimiter.Middleware(next).ServeHTTP(w, r)
+	})
 }
 
 // RegisterRoutes registers all HTTP routes
 func (h *Handler) RegisterRoutes(mux *http.ServeMux) {
 	// Authentication routes (public)
-	mux.HandleFunc("/api/auth/register", h.authHandler.Register)
-	mux.HandleFunc("/api/auth/login", h.authHandler.Login)
-	mux.HandleFunc("/api/auth/refresh", h.authHandler.RefreshToken)
-	
+	mux.HandleFunc("/api/auth/register", h.rateLimited(http.HandlerFunc(h.authHandler.Register)).ServeHTTP)
+	mux.HandleFunc("/api/auth/login", h.rateLimited(http.HandlerFunc(h.authHandler.Login)).ServeHTTP)
+	mux.HandleFunc("/api/auth/refresh", h.rateLimited(http.HandlerFunc(h.authHandler.RefreshToken)).ServeHTTP)
+
 	// Protected authentication routes
-	mux.HandleFunc("/api/auth/logout", middleware.AuthMiddleware(h.authService)(http.HandlerFunc(h.authHandler.Logout)).ServeHTTP)
-	mux.HandleFunc("/api/auth/profile", middleware.AuthMiddleware(h.authService)(http.HandlerFunc(h.authHandler.GetProfile)).ServeHTTP)
-	mux.HandleFunc("/api/auth/profile/update", middleware.AuthMiddleware(h.authService)(http.HandlerFunc(h.authHandler.UpdateProfile)).ServeHTTP)
-	mux.HandleFunc("/api/auth/password/change", middleware.AuthMiddleware(h.authService)(http.HandlerFunc(h.authHandler.ChangePassword)).ServeHTTP)
+	mux.HandleFunc("/api/auth/logout", h.authenticated(http.HandlerFunc(h.authHandler.Logout)).ServeHTTP)
+	mux.HandleFunc("/api/auth/profile", h.authenticated(http.HandlerFunc(h.authHandler.GetProfile)).ServeHTTP)
+	mux.HandleFunc("/api/auth/profile/update", h.authenticated(http.HandlerFunc(h.authHandler.UpdateProfile)).ServeHTTP)
+	mux.HandleFunc("/api/auth/password/change", h.authenticated(http.HandlerFunc(h.authHandler.ChangePassword)).ServeHTTP)
+	mux.HandleFunc("/api/auth/2fa/enroll", h.authenticated(http.HandlerFunc(h.authHandler.EnrollTwoFactor)).ServeHTTP)
+	mux.HandleFunc("/api/auth/2fa/verify", h.authenticated(http.HandlerFunc(h.authHandler.VerifyTwoFactor)).ServeHTTP)
+
+	// User administration routes (admin only)
+	mux.HandleFunc("/api/users", h.authenticated(middleware.RequireRole(h.authService, "admin")(http.HandlerFunc(h.userHandler.ListUsers))).ServeHTTP)
+	mux.HandleFunc("/api/users/", h.authenticated(h.requireUserAdmin(http.HandlerFunc(h.userHandler.HandleUser))).ServeHTTP)
+	mux.HandleFunc("/api/audit", h.authenticated(middleware.RequireRole(h.authService, "admin")(http.HandlerFunc(h.userHandler.ListAuditLog))).ServeHTTP)
+	mux.HandleFunc("/api/users/import", h.authenticated(middleware.RequireRole(h.authService, "admin")(http.HandlerFunc(h.userHandler.ImportUsers))).ServeHTTP)
 
 	// API routes with rate limiting
 	webhookHandler := ratelimit.WebhookRateLimitWrapper(h.rateLimitConfig, h.handleAlertmanagerWebhook)
-	mux.HandleFunc("/api/webhooks/alertmanager", webhookHandler)
-	
+	mux.HandleFunc(h.webhookPath, webhookHandler)
+	mux.HandleFunc("/api/webhooks/grafana", ratelimit.WebhookRateLimitWrapper(h.rateLimitConfig, h.handleGrafanaWebhook))
+	mux.HandleFunc("/api/webhooks/alertmanager/replay/", h.authenticated(middleware.RequireRole(h.authService, "admin")(http.HandlerFunc(h.handleReplayWebhook))).ServeHTTP)
+
 	// Protected API routes - require authentication
-	mux.HandleFunc("/api/incidents", middleware.AuthMiddleware(h.authService)(http.HandlerFunc(h.handleListIncidents)).ServeHTTP)
-	mux.HandleFunc("/api/alerts", middleware.AuthMiddleware(h.authService)(http.HandlerFunc(h.handleListAlerts)).ServeHTTP)
-	mux.HandleFunc("/api/metrics", middleware.OptionalAuthMiddleware(h.authService)(http.HandlerFunc(h.handleGetMetrics)).ServeHTTP) // JSON metrics (deprecated)
+	mux.HandleFunc("/api/incidents", h.authenticated(http.HandlerFunc(h.handleIncidents)).ServeHTTP)
+	mux.HandleFunc("/api/alerts", h.authenticated(http.HandlerFunc(h.handleListAlerts)).ServeHTTP)
+	mux.HandleFunc("/api/ws", h.authenticatedWith(middleware.WebSocketAuthMiddleware(h.authService), http.HandlerFunc(h.handleWebSocket)).ServeHTTP)
+	mux.HandleFunc("/api/ws/token", h.authenticated(http.HandlerFunc(h.handleWebSocketToken)).ServeHTTP)
+	mux.HandleFunc("/api/metrics", middleware.OptionalAuthMiddleware(h.authService)(h.rateLimited(http.HandlerFunc(h.handleGetMetrics))).ServeHTTP) // JSON metrics (deprecated)
+	mux.HandleFunc("/api/reports/summary", h.authenticated(http.HandlerFunc(h.handleReportSummary)).ServeHTTP)
+	mux.HandleFunc("/api/reports/notifications", h.authenticated(middleware.RequireRole(h.authService, "admin")(http.HandlerFunc(h.handleNotificationReport))).ServeHTTP)
 
 	// Enhanced Incident Features - Protected API routes
-	mux.HandleFunc("/api/incidents/search", middleware.AuthMiddleware(h.authService)(http.HandlerFunc(h.handleIncidentSearch)).ServeHTTP)
-	mux.HandleFunc("/api/incidents/bulk", middleware.AuthMiddleware(h.authService)(http.HandlerFunc(h.handleIncidentBulkOperations)).ServeHTTP)
-	mux.HandleFunc("/api/incidents/from-template", middleware.AuthMiddleware(h.authService)(http.HandlerFunc(h.handleIncidentFromTemplate)).ServeHTTP)
-	
-	// Incident sub-resources - need to handle path parsing carefully
-	mux.HandleFunc("/api/incidents/", middleware.AuthMiddleware(h.authService)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		pathParts := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/incidents/"), "/")
-		
-		// Handle specific incident sub-resources
-		if len(pathParts) >= 2 && pathParts[0] != "" {
-			subResource := pathParts[1]
-			
-			switch subResource {
-			case "comments":
-				h.handleIncidentComments(w, r)
-				return
-			case "timeline": 
-				h.handleIncidentTimeline(w, r)
-				return
-			case "tags":
-				h.handleIncidentTags(w, r)
-				return
-			case "assign":
-				h.handleIncidentAssignment(w, r)
-				return
-			}
-		}
-		
-		// Fallback to existing incident handler for specific incidents
-		h.handleIncidents(w, r)
-	})).ServeHTTP)
+	mux.HandleFunc("/api/incidents/search", h.authenticated(http.HandlerFunc(h.handleIncidentSearch)).ServeHTTP)
+	mux.HandleFunc("/api/incidents/export", h.authenticated(http.HandlerFunc(h.handleIncidentExport)).ServeHTTP)
+	mux.HandleFunc("/api/incidents/bulk", h.authenticated(http.HandlerFunc(h.handleIncidentBulkOperations)).ServeHTTP)
+	mux.HandleFunc("/api/incidents/from-template", h.authenticated(http.HandlerFunc(h.handleIncidentFromTemplate)).ServeHTTP)
+	mux.HandleFunc("/api/incidents/needs-attention", h.authenticated(http.HandlerFunc(h.handleNeedsAttention)).ServeHTTP)
+	mux.HandleFunc("/api/incidents/notify", h.authenticated(middleware.RequireRole(h.authService, "admin")(http.HandlerFunc(h.handleBulkNotify))).ServeHTTP)
+
+	// Single incidents, their sub-resources and status actions
+	mux.HandleFunc("/api/incidents/", h.authenticated(h.requireIncidentPermission(http.HandlerFunc(h.handleIncidents))).ServeHTTP)
+
+	// Notification channels
+	mux.HandleFunc("/api/notification-channels", h.authenticated(http.HandlerFunc(h.handleNotificationChannels)).ServeHTTP)
+	mux.HandleFunc("/api/notification-channels/", h.authenticated(http.HandlerFunc(h.handleNotificationChannel)).ServeHTTP)
+
+	// Lifecycle webhooks (admin only)
+	mux.HandleFunc("/api/lifecycle-webhooks", h.authenticated(middleware.RequireRole(h.authService, "admin")(http.HandlerFunc(h.handleLifecycleWebhooks))).ServeHTTP)
+	mux.HandleFunc("/api/lifecycle-webhooks/", h.authenticated(middleware.RequireRole(h.authService, "admin")(http.HandlerFunc(h.handleLifecycleWebhook))).ServeHTTP)
 
 	// Template management
-	mux.HandleFunc("/api/templates", middleware.AuthMiddleware(h.authService)(http.HandlerFunc(h.handleIncidentTemplates)).ServeHTTP)
+	mux.HandleFunc("/api/templates", h.authenticated(h.requirePermissionFor("templates", "create", http.HandlerFunc(h.handleIncidentTemplates), http.MethodPost)).ServeHTTP)
+	mux.HandleFunc("/api/templates/", h.authenticated(h.requirePermissionFor("templates", "update",
+		h.requirePermissionFor("templates", "delete", http.HandlerFunc(h.handleIncidentTemplate), http.MethodDelete),
+		http.MethodPut)).ServeHTTP)
 
 	// Prometheus metrics endpoint (public for monitoring)
-	mux.Handle("/metrics", promhttp.Handler())
+	mux.Handle("/metrics", h.metricsService.Handler())
 
 	// Static files (CSS, JS, images, fonts, and other assets)
 	mux.Handle("/css/", http.StripPrefix("/", http.FileServer(http.Dir("web/static/"))))
@@ -170,9 +323,79 @@ func (h *Handler) RegisterRoutes(mux *http.ServeMux) {
 	// Health check endpoints (public)
 	mux.HandleFunc("/health", h.handleHealth)
 	mux.HandleFunc("/ready", h.handleReady)
+	mux.HandleFunc("/api/admin/circuit-breakers", h.authenticated(middleware.RequireRole(h.authService, "admin")(http.HandlerFunc(h.handleCircuitBreakers))).ServeHTTP)
+	mux.HandleFunc("/api/system/circuit-breakers", h.authenticated(middleware.RequireRole(h.authService, "admin")(http.HandlerFunc(h.handleCircuitBreakers))).ServeHTTP)
+	mux.HandleFunc("/api/admin/circuit-breakers/", h.authenticated(middleware.RequireRole(h.authService, "admin")(http.HandlerFunc(h.handleCircuitBreakerReset))).ServeHTTP)
+	mux.HandleFunc("/api/admin/notifications/dead-letters", h.authenticated(middleware.RequireRole(h.authService, "admin")(http.HandlerFunc(h.handleDeadLetters))).ServeHTTP)
+	mux.HandleFunc("/api/admin/notifications/dead-letters/", h.authenticated(middleware.RequireRole(h.authService, "admin")(http.HandlerFunc(h.handleDeadLetterRequeue))).ServeHTTP)
+	mux.HandleFunc("/api/notifications/", h.authenticated(middleware.RequireRole(h.authService, "admin")(http.HandlerFunc(h.handleNotificationRetry))).ServeHTTP)
 	mux.HandleFunc("/db/stats", middleware.RequireRole(h.authService, "admin")(http.HandlerFunc(h.handleDBStats)).ServeHTTP)
 }
 
+// IsStreamingRequest reports whether a request gets a long-lived or streamed
+// response that must not be buffered or cut off by the request timeout:
+// WebSocket handshakes on /api/ws, incident exports and attachment downloads
+func IsStreamingRequest(r *http.Request) bool {
+	if r.Method != http.MethodGet {
+		return false
+	}
+	if r.URL.Path == "/api/ws" {
+		return strings.EqualFold(r.Header.Get("Upgrade"), "websocket") &&
+			strings.Contains(strings.ToLower(r.Header.Get("Connection")), "upgrade")
+	}
+	if r.URL.Path == "/api/incidents/export" {
+		return true
+	}
+	// /api/incidents/{id}/attachments/{attachment_id}
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	return len(parts) == 5 && parts[0] == "api" && parts[1] == "incidents" && parts[3] == "attachments" && parts[4] != ""
+}
+
+// handleNotificationChannels lists or creates notification channels
+func (h *Handler) handleNotificationChannels(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.notificationHandlers.GetNotificationChannels(w, r)
+	case http.MethodPost:
+		h.notificationHandlers.CreateNotificationChannel(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleNotificationChannel dispatches /api/notification-channels/{id}[/history|/test]
+func (h *Handler) handleNotificationChannel(w http.ResponseWriter, r *http.Request) {
+	pathParts := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/notification-channels/"), "/")
+	channelID := pathParts[0]
+	if channelID == "" {
+		http.Error(w, "Channel ID is required", http.StatusBadRequest)
+		return
+	}
+
+	if len(pathParts) > 1 {
+		switch pathParts[1] {
+		case "history":
+			h.notificationHandlers.GetChannelHistory(w, r, channelID)
+		case "test":
+			h.notificationHandlers.TestNotificationChannel(w, r)
+		default:
+			http.NotFound(w, r)
+		}
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		h.notificationHandlers.GetNotificationChannel(w, r)
+	case http.MethodPut:
+		h.notificationHandlers.UpdateNotificationChannel(w, r)
+	case http.MethodDelete:
+		h.notificationHandlers.DeleteNotificationChannel(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
 // handleAlertmanagerWebhook handles incoming webhooks from Alertmanager with reliability improvements
 func (h *Handler) handleAlertmanagerWebhook(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
@@ -192,27 +415,8 @@ func (h *Handler) handleAlertmanagerWebhook(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	// Read and validate request body
-	body, err := io.ReadAll(r.Body)
-	if err != nil {
-		log.Printf("Failed to read request body: %v", err)
-		h.writeErrorResponse(w, "Failed to read request body", http.StatusBadRequest)
-		h.metricsService.RecordWebhookRequest("alertmanager", "error")
-		return
-	}
-
-	// Validate payload size (prevent large payload attacks)
-	if len(body) > 1024*1024 { // 1MB limit
-		log.Printf("Request body too large: %d bytes", len(body))
-		h.writeErrorResponse(w, "Request body too large", http.StatusRequestEntityTooLarge)
-		h.metricsService.RecordWebhookRequest("alertmanager", "error")
-		return
-	}
-
-	// Check for empty body
-	if len(body) == 0 {
-		h.writeErrorResponse(w, "Empty request body", http.StatusBadRequest)
-		h.metricsService.RecordWebhookRequest("alertmanager", "error")
+	body, ok := h.readWebhookBody(w, r, "alertmanager")
+	if !ok {
 		return
 	}
 
@@ -225,53 +429,273 @@ func (h *Handler) handleAlertmanagerWebhook(w http.ResponseWriter, r *http.Reque
 	}
 
 	// Check idempotency
-	if h.idempotencyManager.IsAlreadyProcessed(body) {
+	if h.isDuplicateWebhook(body) {
 		log.Printf("Duplicate webhook detected, returning cached response")
 		h.writeSuccessResponse(w, "Duplicate request processed successfully")
 		h.metricsService.RecordWebhookRequest("alertmanager", "success")
 		return
 	}
 
+	// Keep the raw payload before processing so that it can be replayed
+	// even if processing fails
+	h.storeWebhookPayload(ctx, body)
+
 	// Parse webhook payload
 	var webhook services.AlertmanagerWebhook
 	if err := json.Unmarshal(body, &webhook); err != nil {
 		log.Printf("Failed to unmarshal webhook: %v", err)
+		h.unmarkWebhook(body)
 		h.writeErrorResponse(w, "Invalid JSON structure", http.StatusBadRequest)
 		h.metricsService.RecordWebhookRequest("alertmanager", "error")
 		return
 	}
 
-	// Process webhook with retry logic and circuit breaker
-	err = h.retryer.Execute(ctx, func() error {
-		return h.processWebhookWithCircuitBreaker(&webhook)
+	if h.processWebhook(ctx, w, "alertmanager", &webhook, body) {
+		h.logger.InfoWithRequest(ctx, "Successfully processed Alertmanager webhook", map[string]interface{}{
+			"alerts_count": len(webhook.Alerts),
+			"status":       webhook.Status,
+		})
+	}
+}
+
+// handleGrafanaWebhook handles incoming webhooks from Grafana alerting. The
+// payload is mapped onto the Alertmanager model and then goes through the
+// same idempotency, retry, circuit breaker and spooling pipeline.
+func (h *Handler) handleGrafanaWebhook(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if r.Method != http.MethodPost {
+		h.writeErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		h.metricsService.RecordWebhookRequest("grafana", "error")
+		return
+	}
+
+	h.logger.InfoWithRequest(ctx, "Received Grafana webhook")
+
+	body, ok := h.readWebhookBody(w, r, "grafana")
+	if !ok {
+		return
+	}
+
+	if err := h.webhookValidator.ValidateGrafanaWebhook(body); err != nil {
+		log.Printf("Grafana webhook validation failed: %v", err)
+		h.writeErrorResponse(w, fmt.Sprintf("Invalid webhook payload: %v", err), http.StatusBadRequest)
+		h.metricsService.RecordWebhookRequest("grafana", "error")
+		return
+	}
+
+	if h.isDuplicateWebhook(body) {
+		log.Printf("Duplicate Grafana webhook detected, returning cached response")
+		h.writeSuccessResponse(w, "Duplicate request processed successfully")
+		h.metricsService.RecordWebhookRequest("grafana", "success")
+		return
+	}
+
+	var grafana services.GrafanaWebhook
+	if err := json.Unmarshal(body, &grafana); err != nil {
+		log.Printf("Failed to unmarshal Grafana webhook: %v", err)
+		h.unmarkWebhook(body)
+		h.writeErrorResponse(w, "Invalid JSON structure", http.StatusBadRequest)
+		h.metricsService.RecordWebhookRequest("grafana", "error")
+		return
+	}
+	webhook := services.ConvertGrafanaWebhook(&grafana)
+
+	if h.processWebhook(ctx, w, "grafana", webhook, body) {
+		h.logger.InfoWithRequest(ctx, "Successfully processed Grafana webhook", map[string]interface{}{
+			"alerts_count": len(webhook.Alerts),
+			"status":       webhook.Status,
+		})
+	}
+}
+
+// readWebhookBody reads a webhook body and verifies its size and signature.
+// On failure it writes the error response and reports false.
+func (h *Handler) readWebhookBody(w http.ResponseWriter, r *http.Request, source string) ([]byte, bool) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		log.Printf("Failed to read request body: %v", err)
+		h.writeErrorResponse(w, "Failed to read request body", http.StatusBadRequest)
+		h.metricsService.RecordWebhookRequest(source, "error")
+		return nil, false
+	}
+
+	// Validate payload size (prevent large payload attacks)
+	if len(body) > 1024*1024 { // 1MB limit
+		log.Printf("Request body too large: %d bytes", len(body))
+		h.writeErrorResponse(w, "Request body too large", http.StatusRequestEntityTooLarge)
+		h.metricsService.RecordWebhookRequest(source, "error")
+		return nil, false
+	}
+
+	// Check for empty body
+	if len(body) == 0 {
+		h.writeErrorResponse(w, "Empty request body", http.StatusBadRequest)
+		h.metricsService.RecordWebhookRequest(source, "error")
+		return nil, false
+	}
+
+	// Verify HMAC signature against the active secrets
+	if err := h.signatureVerifier.Verify(body, r.Header.Get(validation.SignatureHeader)); err != nil {
+		log.Printf("Webhook signature verification failed: %v", err)
+		h.writeErrorResponse(w, err.Error(), http.StatusUnauthorized)
+		h.metricsService.RecordWebhookRequest(source, "error")
+		return nil, false
+	}
+
+	return body, true
+}
+
+// processWebhook processes a parsed webhook with retries and the circuit
+// breaker, spooling it if that fails, and writes the response. It reports
+// whether the webhook was processed.
+func (h *Handler) processWebhook(ctx context.Context, w http.ResponseWriter, source string, webhook *services.AlertmanagerWebhook, body []byte) bool {
+	err := h.retryer.Execute(ctx, func() error {
+		return h.processWebhookWithCircuitBreaker(ctx, webhook)
 	})
 
+	if err != nil && h.spoolWebhook(w, source, webhook, body, err) {
+		return false
+	}
 	if err != nil {
 		log.Printf("Failed to process webhook after retries: %v", err)
+		h.unmarkWebhook(body)
 		h.writeErrorResponse(w, "Failed to process webhook", http.StatusInternalServerError)
-		h.metricsService.RecordWebhookRequest("alertmanager", "error")
-		return
+		h.metricsService.RecordWebhookRequest(source, "error")
+		return false
 	}
 
-	// Mark as processed for idempotency
-	if err := h.idempotencyManager.MarkAsProcessed(body); err != nil {
+	h.writeSuccessResponse(w, "Webhook processed successfully")
+	h.metricsService.RecordWebhookRequest(source, "success")
+	return true
+}
+
+// isDuplicateWebhook marks a webhook payload as processed and reports whether
+// it already was. Marking up front means concurrent deliveries of the same
+// payload are processed once; if the idempotency store fails the payload is
+// processed rather than dropped.
+func (h *Handler) isDuplicateWebhook(body []byte) bool {
+	marked, err := h.idempotencyManager.TryMarkAsProcessed(body)
+	if err != nil {
 		log.Printf("Failed to mark webhook as processed: %v", err)
-		// Don't fail the request for this error
+		return false
 	}
+	return !marked
+}
 
-	// Return success response
-	h.writeSuccessResponse(w, "Webhook processed successfully")
-	h.metricsService.RecordWebhookRequest("alertmanager", "success")
-	h.logger.InfoWithRequest(ctx, "Successfully processed Alertmanager webhook", map[string]interface{}{
+// unmarkWebhook forgets a webhook payload that failed processing, so that the
+// sender's retry is not taken for a duplicate
+func (h *Handler) unmarkWebhook(body []byte) {
+	if err := h.idempotencyManager.UnmarkProcessed(body); err != nil {
+		log.Printf("Failed to unmark webhook: %v", err)
+	}
+}
+
+// spoolWebhook buffers a webhook that failed processing and answers 202. It
+// reports false, writing nothing, when spooling is disabled or the spool is
+// full so that the caller can fail the request. A spooled webhook stays marked
+// as processed, since redeliveries would only be spooled again.
+func (h *Handler) spoolWebhook(w http.ResponseWriter, source string, webhook *services.AlertmanagerWebhook, body []byte, processErr error) bool {
+	if h.alertSpool == nil {
+		return false
+	}
+	if err := h.alertSpool.Enqueue(webhook); err != nil {
+		log.Printf("Failed to spool webhook: %v", err)
+		return false
+	}
+	log.Printf("Failed to process webhook after retries, spooled it for replay: %v", processErr)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":  "accepted",
+		"message": "Webhook spooled and will be processed once storage recovers",
+	})
+	h.metricsService.RecordWebhookRequest(source, "spooled")
+	return true
+}
+
+// storeWebhookPayload saves a raw webhook body for replay and prunes payloads
+// past their retention at most once an hour. Failures are logged only.
+func (h *Handler) storeWebhookPayload(ctx context.Context, body []byte) {
+	if h.payloadRetention <= 0 {
+		return
+	}
+
+	payload := &models.WebhookPayload{
+		Source:     "alertmanager",
+		Payload:    string(body),
+		ReceivedAt: time.Now(),
+	}
+	if err := h.store.CreateWebhookPayload(ctx, payload); err != nil {
+		log.Printf("Failed to store webhook payload: %v", err)
+		return
+	}
+	h.logger.Debug("Stored webhook payload for replay", map[string]interface{}{"payload_id": payload.ID})
+
+	h.payloadPruneMu.Lock()
+	defer h.payloadPruneMu.Unlock()
+	if time.Since(h.lastPayloadPrune) < time.Hour {
+		return
+	}
+	h.lastPayloadPrune = time.Now()
+	if deleted, err := h.store.DeleteWebhookPayloadsBefore(ctx, time.Now().Add(-h.payloadRetention)); err != nil {
+		log.Printf("Failed to prune webhook payloads: %v", err)
+	} else if deleted > 0 {
+		log.Printf("Pruned %d webhook payloads older than %s", deleted, h.payloadRetention)
+	}
+}
+
+// handleReplayWebhook reprocesses a stored Alertmanager payload through the
+// current pipeline. Idempotency is bypassed since replaying is the point.
+func (h *Handler) handleReplayWebhook(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.writeErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id := strings.TrimPrefix(r.URL.Path, "/api/webhooks/alertmanager/replay/")
+	if id == "" || strings.Contains(id, "/") {
+		h.writeErrorResponse(w, "Payload ID is required", http.StatusBadRequest)
+		return
+	}
+
+	payload, err := h.store.GetWebhookPayload(r.Context(), id)
+	if err != nil {
+		h.writeErrorResponse(w, "Webhook payload not found", http.StatusNotFound)
+		return
+	}
+
+	var webhook services.AlertmanagerWebhook
+	if err := json.Unmarshal([]byte(payload.Payload), &webhook); err != nil {
+		log.Printf("Failed to unmarshal stored webhook %s: %v", id, err)
+		h.writeErrorResponse(w, "Stored payload is not valid JSON", http.StatusUnprocessableEntity)
+		return
+	}
+
+	err = h.retryer.Execute(r.Context(), func() error {
+		return h.processWebhookWithCircuitBreaker(r.Context(), &webhook)
+	})
+	if err != nil {
+		log.Printf("Failed to replay webhook %s: %v", id, err)
+		h.writeErrorResponse(w, "Failed to replay webhook", http.StatusInternalServerError)
+		h.metricsService.RecordWebhookRequest("alertmanager_replay", "error")
+		return
+	}
+
+	h.metricsService.RecordWebhookRequest("alertmanager_replay", "success")
+	h.logger.InfoWithRequest(r.Context(), "Replayed Alertmanager webhook", map[string]interface{}{
+		"payload_id":   id,
+		"received_at":  payload.ReceivedAt,
 		"alerts_count": len(webhook.Alerts),
-		"status":       webhook.Status,
 	})
+	h.writeSuccessResponse(w, "Webhook replayed successfully")
 }
 
 // processWebhookWithCircuitBreaker processes webhook with circuit breaker protection
-func (h *Handler) processWebhookWithCircuitBreaker(webhook *services.AlertmanagerWebhook) error {
+func (h *Handler) processWebhookWithCircuitBreaker(ctx context.Context, webhook *services.AlertmanagerWebhook) error {
 	return h.circuitBreaker.Call(func() error {
-		return h.alertService.ProcessAlertmanagerWebhook(webhook)
+		return h.alertService.ProcessAlertmanagerWebhook(ctx, webhook)
 	})
 }
 
@@ -306,62 +730,333 @@ func (h *Handler) writeSuccessResponse(w http.ResponseWriter, message string) {
 	}
 }
 
-// handleIncidents handles incident-related requests with different methods and paths
-func (h *Handler) handleIncidents(w http.ResponseWriter, r *http.Request) {
-	path := r.URL.Path
-	
-	if path == "/api/incidents" || path == "/api/incidents/" {
-		if r.Method == http.MethodGet {
-			h.handleListIncidents(w, r)
-		} else {
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+// incidentPermissionAction returns the incidents permission action a request
+// to an incident sub-resource or action needs, if any
+func incidentPermissionAction(method, resource string) (string, bool) {
+	switch {
+	case resource == "acknowledge":
+		return "acknowledge", true
+	case resource == "resolve", resource == "reopen", resource == "merge":
+		return "resolve", true
+	case resource == "assign":
+		return "assign", true
+	case resource == "priority":
+		return "update", true
+	case resource == "" && method == http.MethodDelete:
+		return "delete", true
+	}
+	return "", false
+}
+
+// requireIncidentPermission checks the caller's permission for incident
+// routes that change an incident's status, assignee or existence
+func (h *Handler) requireIncidentPermission(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		incidentID, resource, _, ok := parseIncidentPath(r.URL.Path)
+		if action, gated := incidentPermissionAction(r.Method, resource); ok && incidentID != "" && gated {
+			middleware.RequirePermission(h.authService, "incidents", action)(next).ServeHTTP(w, r)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// requirePermissionFor requires a permission for requests using one of
+// methods and lets other methods through
+func (h *Handler) requirePermissionFor(resource, action string, next http.Handler, methods ...string) http.Handler {
+	gated := middleware.RequirePermission(h.authService, resource, action)(next)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, method := range methods {
+			if r.Method == method {
+				gated.ServeHTTP(w, r)
+				return
+			}
 		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// requireUserAdmin restricts /api/users/ to admins, except that any user
+// may read their own activity log
+func (h *Handler) requireUserAdmin(next http.Handler) http.Handler {
+	admin := middleware.RequireRole(h.authService, "admin")(next)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/users/"), "/"), "/")
+		if r.Method == http.MethodGet && len(parts) == 2 && parts[1] == "activities" && parts[0] == requestUserID(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		admin.ServeHTTP(w, r)
+	})
+}
+
+// logIncidentActivity records a change to an incident in the authenticated
+// user's activity log, along with the client's IP address and user agent
+func (h *Handler) logIncidentActivity(r *http.Request, action, incidentID string, metadata map[string]interface{}) {
+	claims, ok := middleware.GetClaimsFromContext(r.Context())
+	if !ok || claims == nil || claims.UserID == "" {
 		return
 	}
-	
-	// Parse incident ID from path /api/incidents/{id} or /api/incidents/{id}/action
-	pathParts := strings.Split(strings.TrimPrefix(path, "/api/incidents/"), "/")
-	if len(pathParts) == 0 {
+	h.userService.LogUserActivity(r.Context(), claims.UserID, action, "incident", incidentID,
+		middleware.GetClientIP(r), r.UserAgent(), metadata)
+}
+
+// requestUserID returns the ID of the user AuthMiddleware authenticated, or
+// "system" for requests that did not pass through it. Handlers attribute
+// changes with it rather than trusting a user_id sent by the client.
+func requestUserID(r *http.Request) string {
+	if userID, ok := middleware.GetUserIDFromContext(r.Context()); ok && userID != "" {
+		return userID
+	}
+	return "system"
+}
+
+// parseIncidentPath splits /api/incidents, /api/incidents/{id},
+// /api/incidents/{id}/{resource} and /api/incidents/{id}/{resource}/{subID}
+// into the incident ID, the sub-resource or action and the ID of an item of
+// the sub-resource, any of which may be empty. ok is false for any other path.
+func parseIncidentPath(path string) (id, resource, subID string, ok bool) {
+	rest := strings.Trim(strings.TrimPrefix(path, "/api/incidents"), "/")
+	if rest == "" {
+		return "", "", "", true
+	}
+	parts := strings.Split(rest, "/")
+	if parts[0] == "" || len(parts) > 3 {
+		return "", "", "", false
+	}
+	if len(parts) >= 2 {
+		resource = parts[1]
+	}
+	if len(parts) == 3 {
+		subID = parts[2]
+	}
+	return parts[0], resource, subID, true
+}
+
+// handleIncidents routes every request under /api/incidents without a
+// dedicated registration: the incident collection, single incidents, their
+// sub-resources and the status actions
+func (h *Handler) handleIncidents(w http.ResponseWriter, r *http.Request) {
+	incidentID, resource, subID, ok := parseIncidentPath(r.URL.Path)
+	if !ok {
 		http.Error(w, "Invalid path", http.StatusBadRequest)
 		return
 	}
-	
-	incidentID := pathParts[0]
-	
-	if len(pathParts) == 1 {
-		// /api/incidents/{id}
-		if r.Method == http.MethodGet {
+
+	if incidentID == "" {
+		switch r.Method {
+		case http.MethodGet:
+			h.handleListIncidents(w, r)
+		case http.MethodPost:
+			h.handleCreateIncident(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+		return
+	}
+
+	switch resource {
+	case "":
+		switch r.Method {
+		case http.MethodGet:
 			h.handleGetIncident(w, r, incidentID)
-		} else {
+		case http.MethodDelete:
+			h.handleDeleteIncident(w, r, incidentID)
+		default:
 			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		}
-	} else if len(pathParts) == 2 {
-		// /api/incidents/{id}/action
-		action := pathParts[1]
-		if r.Method == http.MethodPut {
-			switch action {
-			case "acknowledge":
-				h.handleAcknowledgeIncident(w, r, incidentID)
-			case "resolve":
-				h.handleResolveIncident(w, r, incidentID)
-			default:
-				http.Error(w, "Unknown action", http.StatusBadRequest)
-			}
-		} else {
+	case "comments":
+		if subID != "" {
+			h.handleIncidentComment(w, r, incidentID, subID)
+			return
+		}
+		h.handleIncidentComments(w, r)
+	case "attachments":
+		if subID != "" {
+			h.handleDownloadAttachment(w, r, incidentID, subID)
+			return
+		}
+		h.handleIncidentAttachments(w, r, incidentID)
+	case "comment-draft":
+		h.handleIncidentCommentDraft(w, r)
+	case "timeline":
+		h.handleIncidentTimeline(w, r)
+	case "key-events":
+		h.handleIncidentKeyEvents(w, r)
+	case "full":
+		h.handleIncidentFull(w, r)
+	case "notifications":
+		h.handleIncidentNotifications(w, r)
+	case "tags":
+		h.handleIncidentTags(w, r)
+	case "assign":
+		h.handleIncidentAssignment(w, r)
+	case "escalation-policy":
+		h.handleIncidentEscalationPolicy(w, r)
+	case "priority":
+		h.handleIncidentPriority(w, r, incidentID)
+	case "merge":
+		if r.Method != http.MethodPost {
 			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
 		}
-	} else {
-		http.Error(w, "Invalid path", http.StatusBadRequest)
+		h.handleMergeIncidents(w, r, incidentID)
+	case "acknowledge", "resolve", "reopen":
+		if r.Method != http.MethodPut {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		switch resource {
+		case "acknowledge":
+			h.handleAcknowledgeIncident(w, r, incidentID)
+		case "resolve":
+			h.handleResolveIncident(w, r, incidentID)
+		case "reopen":
+			h.handleReopenIncident(w, r, incidentID)
+		}
+	default:
+		http.Error(w, "Unknown action", http.StatusBadRequest)
 	}
 }
 
-// handleListIncidents returns all incidents
+// handleListIncidents returns a page of incidents, newest first. Supports
+// status, severity and assignee_id filters with page/limit pagination.
 func (h *Handler) handleListIncidents(w http.ResponseWriter, r *http.Request) {
-	incidents, err := h.incidentService.ListIncidents()
+	query := r.URL.Query()
+	page, limit := 1, 20
+	if value := query.Get("page"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 {
+			http.Error(w, "Invalid page", http.StatusBadRequest)
+			return
+		}
+		page = parsed
+	}
+	if value := query.Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		if parsed > 100 {
+			parsed = 100 // Maximum limit
+		}
+		limit = parsed
+	}
+
+	filter, msg := incidentFilterFromQuery(query)
+	if msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+	filter.Limit, filter.Offset = limit, (page-1)*limit
+
+	incidents, total, err := h.incidentService.ListIncidentsWithFilter(r.Context(), filter)
 	if err != nil {
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
+	if incidents == nil {
+		incidents = []*models.Incident{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.IncidentListResponse{
+		Incidents:  incidents,
+		Total:      total,
+		Page:       page,
+		Limit:      limit,
+		TotalPages: (total + limit - 1) / limit,
+	})
+}
+
+// incidentFilterFromQuery reads the status, severity and assignee_id filters
+// of the incident list. It returns a message for the client if one is invalid.
+func incidentFilterFromQuery(query url.Values) (storage.IncidentFilter, string) {
+	var filter storage.IncidentFilter
+	if value := query.Get("status"); value != "" {
+		status := models.IncidentStatus(value)
+		switch status {
+		case models.IncidentStatusOpen, models.IncidentStatusAcknowledged, models.IncidentStatusResolved:
+		default:
+			return filter, "Invalid status"
+		}
+		filter.Status = &status
+	}
+	if value := query.Get("severity"); value != "" {
+		severity := models.IncidentSeverity(value)
+		switch severity {
+		case models.SeverityCritical, models.SeverityHigh, models.SeverityMedium, models.SeverityLow:
+		default:
+			return filter, "Invalid severity"
+		}
+		filter.Severity = &severity
+	}
+	if value := query.Get("assignee_id"); value != "" {
+		filter.AssigneeID = &value
+	}
+	return filter, ""
+}
+
+// handleCreateIncident opens an incident that did not come from an alert
+func (h *Handler) handleCreateIncident(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateIncidentRequest
+	if err := decodeJSON(r, &req); err != nil {
+		h.writeErrorResponse(w, invalidBodyMessage(err, "Invalid JSON"), http.StatusBadRequest)
+		return
+	}
+
+	if req.Severity == "" {
+		req.Severity = models.SeverityMedium
+	}
+	switch req.Severity {
+	case models.SeverityCritical, models.SeverityHigh, models.SeverityMedium, models.SeverityLow:
+	default:
+		h.writeErrorResponse(w, "Invalid severity", http.StatusBadRequest)
+		return
+	}
+
+	if req.Priority != "" && !req.Priority.Valid() {
+		h.writeErrorResponse(w, services.ErrInvalidPriority.Error(), http.StatusBadRequest)
+		return
+	}
+
+	incident, err := h.incidentService.CreateIncidentWithPriority(r.Context(), req.Title, req.Description, req.Severity, req.Priority, []string{}, req.Labels)
+	if err != nil {
+		if isIncidentTextError(err) {
+			h.writeErrorResponse(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		log.Printf("Failed to create incident: %v", err)
+		h.writeErrorResponse(w, "Failed to create incident", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(incident)
+}
+
+// isIncidentTextError reports whether err rejects the incident title or description
+func isIncidentTextError(err error) bool {
+	return errors.Is(err, services.ErrTitleRequired) ||
+		errors.Is(err, services.ErrTitleTooLong) ||
+		errors.Is(err, services.ErrDescriptionTooLong)
+}
+
+// handleNeedsAttention returns open, unassigned incidents awaiting triage
+func (h *Handler) handleNeedsAttention(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.writeErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	incidents, err := h.incidentService.ListNeedsAttention(r.Context())
+	if err != nil {
+		log.Printf("Failed to list incidents needing attention: %v", err)
+		h.writeErrorResponse(w, "Failed to list incidents", http.StatusInternalServerError)
+		return
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(incidents)
@@ -369,7 +1064,7 @@ func (h *Handler) handleListIncidents(w http.ResponseWriter, r *http.Request) {
 
 // handleGetIncident returns a specific incident
 func (h *Handler) handleGetIncident(w http.ResponseWriter, r *http.Request, id string) {
-	incident, err := h.incidentService.GetIncident(id)
+	incident, err := h.incidentService.GetIncident(r.Context(), id)
 	if err != nil {
 		http.Error(w, "Incident not found", http.StatusNotFound)
 		return
@@ -379,26 +1074,73 @@ func (h *Handler) handleGetIncident(w http.ResponseWriter, r *http.Request, id s
 	json.NewEncoder(w).Encode(incident)
 }
 
+// handleDeleteIncident deletes an incident
+func (h *Handler) handleDeleteIncident(w http.ResponseWriter, r *http.Request, id string) {
+	if err := h.incidentService.DeleteIncident(r.Context(), id); err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			http.Error(w, "Incident not found", http.StatusNotFound)
+			return
+		}
+		log.Printf("Failed to delete incident %s: %v", id, err)
+		http.Error(w, "Failed to delete incident", http.StatusInternalServerError)
+		return
+	}
+
+	h.logIncidentActivity(r, "delete_incident", id, nil)
+	w.WriteHeader(http.StatusNoContent)
+}
+
 // AcknowledgeIncidentRequest represents the request to acknowledge an incident
 type AcknowledgeIncidentRequest struct {
+	// AssigneeID other than the caller is treated as OnBehalfOf
 	AssigneeID string `json:"assignee_id"`
+	// OnBehalfOf acknowledges for another user, who becomes the assignee.
+	// It requires the incidents.assign permission.
+	OnBehalfOf string `json:"on_behalf_of,omitempty"`
 }
 
+// ackOnBehalfAction is the incidents permission action required to
+// acknowledge an incident for someone else
+const ackOnBehalfAction = "assign"
+
 // handleAcknowledgeIncident acknowledges an incident
 func (h *Handler) handleAcknowledgeIncident(w http.ResponseWriter, r *http.Request, id string) {
 	var req AcknowledgeIncidentRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+	if err := decodeJSON(r, &req); err != nil {
+		http.Error(w, invalidBodyMessage(err, "Invalid JSON"), http.StatusBadRequest)
 		return
 	}
 
-	if err := h.incidentService.AcknowledgeIncident(id, req.AssigneeID); err != nil {
+	onBehalfOf := req.OnBehalfOf
+	if onBehalfOf == "" && req.AssigneeID != "" && req.AssigneeID != requestUserID(r) {
+		onBehalfOf = req.AssigneeID
+	}
+
+	if onBehalfOf != "" {
+		if !h.acknowledgeOnBehalf(w, r, id, onBehalfOf) {
+			return
+		}
+	} else if err := h.incidentService.AcknowledgeIncident(r.Context(), id, req.AssigneeID); err != nil {
+		if errors.Is(err, services.ErrInvalidTransition) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		if errors.Is(err, services.ErrAssigneeNotFound) || errors.Is(err, services.ErrAssigneeNotAssignable) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 		http.Error(w, "Failed to acknowledge incident", http.StatusInternalServerError)
 		return
 	}
 
+	var activity map[string]interface{}
+	if onBehalfOf != "" {
+		activity = map[string]interface{}{"on_behalf_of": onBehalfOf}
+	}
+	h.logIncidentActivity(r, "acknowledge_incident", id, activity)
+
 	// Get updated incident
-	incident, err := h.incidentService.GetIncident(id)
+	incident, err := h.incidentService.GetIncident(r.Context(), id)
 	if err != nil {
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
@@ -406,7 +1148,7 @@ func (h *Handler) handleAcknowledgeIncident(w http.ResponseWriter, r *http.Reque
 
 	// Send notification with circuit breaker
 	if err := h.sendNotificationWithCircuitBreaker(func() error {
-		return h.notificationService.NotifyIncidentAcknowledged(incident)
+		return h.notificationService.NotifyIncidentAcknowledged(r.Context(), incident)
 	}); err != nil {
 		log.Printf("Failed to send acknowledgment notification: %v", err)
 	}
@@ -415,15 +1157,89 @@ func (h *Handler) handleAcknowledgeIncident(w http.ResponseWriter, r *http.Reque
 	json.NewEncoder(w).Encode(incident)
 }
 
+// acknowledgeOnBehalf acknowledges the incident for another user on behalf of
+// the authenticated one, recording both users in the timeline. It writes the
+// error response and returns false on failure.
+func (h *Handler) acknowledgeOnBehalf(w http.ResponseWriter, r *http.Request, id, onBehalfOf string) bool {
+	claims, ok := middleware.GetClaimsFromContext(r.Context())
+	if !ok || claims == nil {
+		http.Error(w, "Authentication required", http.StatusUnauthorized)
+		return false
+	}
+	allowed, err := h.mayAcknowledgeOnBehalf(r, claims)
+	if err != nil {
+		http.Error(w, "Failed to check permissions", http.StatusInternalServerError)
+		return false
+	}
+	if !allowed {
+		http.Error(w, "Insufficient permissions to acknowledge on behalf of another user", http.StatusForbidden)
+		return false
+	}
+
+	if err := h.incidentService.AcknowledgeIncidentOnBehalf(r.Context(), id, claims.UserID, onBehalfOf); err != nil {
+		switch {
+		case errors.Is(err, storage.ErrNotFound):
+			http.Error(w, "Incident not found", http.StatusNotFound)
+		case errors.Is(err, services.ErrAssigneeNotFound), errors.Is(err, services.ErrAssigneeNotAssignable):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, services.ErrInvalidTransition):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, "Failed to acknowledge incident", http.StatusInternalServerError)
+		}
+		return false
+	}
+
+	return true
+}
+
+// mayAcknowledgeOnBehalf reports whether the user's stored roles allow them
+// to acknowledge incidents for someone else. Every acknowledgement naming
+// another assignee goes through this check.
+func (h *Handler) mayAcknowledgeOnBehalf(r *http.Request, claims *services.Claims) (bool, error) {
+	return h.authService.UserHasPermission(r.Context(), claims.UserID, "incidents", ackOnBehalfAction)
+}
+
+// ResolveIncidentRequest represents the optional request body to resolve an incident
+type ResolveIncidentRequest struct {
+	Note              string                `json:"note"`
+	ResolutionType    models.ResolutionType `json:"resolution_type"`
+	RootCauseCategory string                `json:"root_cause_category"`
+}
+
 // handleResolveIncident resolves an incident
 func (h *Handler) handleResolveIncident(w http.ResponseWriter, r *http.Request, id string) {
-	if err := h.incidentService.ResolveIncident(id); err != nil {
+	var req ResolveIncidentRequest
+	if err := decodeJSON(r, &req); err != nil && err != io.EOF {
+		http.Error(w, invalidBodyMessage(err, "Invalid JSON"), http.StatusBadRequest)
+		return
+	}
+
+	userID := requestUserID(r)
+
+	resolution := models.Resolution{Note: req.Note, Type: req.ResolutionType, RootCauseCategory: req.RootCauseCategory}
+	if err := h.incidentService.ResolveIncidentWithDetails(r.Context(), id, userID, resolution); err != nil {
+		if errors.Is(err, services.ErrResolutionNoteRequired) || errors.Is(err, services.ErrResolutionTypeRequired) ||
+			errors.Is(err, services.ErrInvalidResolutionType) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, services.ErrInvalidTransition) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
 		http.Error(w, "Failed to resolve incident", http.StatusInternalServerError)
 		return
 	}
 
+	var activity map[string]interface{}
+	if req.ResolutionType != "" {
+		activity = map[string]interface{}{"resolution_type": req.ResolutionType}
+	}
+	h.logIncidentActivity(r, "resolve_incident", id, activity)
+
 	// Get updated incident
-	incident, err := h.incidentService.GetIncident(id)
+	incident, err := h.incidentService.GetIncident(r.Context(), id)
 	if err != nil {
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
@@ -431,47 +1247,235 @@ func (h *Handler) handleResolveIncident(w http.ResponseWriter, r *http.Request,
 
 	// Send notification with circuit breaker
 	if err := h.sendNotificationWithCircuitBreaker(func() error {
-		return h.notificationService.NotifyIncidentResolved(incident)
+		return h.notificationService.NotifyIncidentResolved(r.Context(), incident)
 	}); err != nil {
 		log.Printf("Failed to send resolution notification: %v", err)
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(incident)
+	json.NewEncoder(w).Encode(incident)
+}
+
+// handleReopenIncident moves a resolved incident back to open
+func (h *Handler) handleReopenIncident(w http.ResponseWriter, r *http.Request, id string) {
+	userID := requestUserID(r)
+
+	if err := h.incidentService.ReopenIncident(r.Context(), id, userID); err != nil {
+		switch {
+		case errors.Is(err, storage.ErrNotFound):
+			http.Error(w, "Incident not found", http.StatusNotFound)
+		case errors.Is(err, services.ErrInvalidTransition):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, "Failed to reopen incident", http.StatusInternalServerError)
+		}
+		return
+	}
+	h.logIncidentActivity(r, "reopen_incident", id, nil)
+
+	incident, err := h.incidentService.GetIncident(r.Context(), id)
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(incident)
+}
+
+// handleMergeIncidents merges duplicate incidents into the incident id and
+// returns the updated incident
+func (h *Handler) handleMergeIncidents(w http.ResponseWriter, r *http.Request, id string) {
+	var req models.MergeIncidentsRequest
+	if err := decodeJSON(r, &req); err != nil {
+		h.writeErrorResponse(w, invalidBodyMessage(err, "Invalid JSON"), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.incidentService.MergeIncidents(r.Context(), id, req.DuplicateIDs, requestUserID(r)); err != nil {
+		switch {
+		case errors.Is(err, storage.ErrNotFound):
+			h.writeErrorResponse(w, "Incident not found", http.StatusNotFound)
+		case errors.Is(err, services.ErrNoDuplicates), errors.Is(err, services.ErrMergeIntoSelf):
+			h.writeErrorResponse(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, services.ErrIncidentMerged):
+			h.writeErrorResponse(w, err.Error(), http.StatusConflict)
+		default:
+			log.Printf("Failed to merge incidents into %s: %v", id, err)
+			h.writeErrorResponse(w, "Failed to merge incidents", http.StatusInternalServerError)
+		}
+		return
+	}
+	h.logIncidentActivity(r, "merge_incidents", id, map[string]interface{}{"duplicate_ids": req.DuplicateIDs})
+
+	incident, err := h.incidentService.GetIncident(r.Context(), id)
+	if err != nil {
+		h.writeErrorResponse(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(incident)
+}
+
+// handleListAlerts returns a page of alerts, newest first. Supports status,
+// fingerprint and incident_id filters with limit/offset pagination.
+func (h *Handler) handleListAlerts(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	filter := storage.AlertFilter{Limit: 20}
+	if value := query.Get("status"); value != "" {
+		filter.Status = &value
+	}
+	if value := query.Get("fingerprint"); value != "" {
+		filter.Fingerprint = &value
+	}
+	if value := query.Get("incident_id"); value != "" {
+		filter.IncidentID = &value
+	}
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		if limit > 100 {
+			limit = 100 // Maximum limit
+		}
+		filter.Limit = limit
+	}
+	if value := query.Get("offset"); value != "" {
+		offset, err := strconv.Atoi(value)
+		if err != nil || offset < 0 {
+			http.Error(w, "Invalid offset", http.StatusBadRequest)
+			return
+		}
+		filter.Offset = offset
+	}
+
+	alerts, total, err := h.alertService.ListAlertsWithFilter(r.Context(), filter)
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if alerts == nil {
+		alerts = []*models.Alert{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.AlertListResponse{
+		Alerts: alerts,
+		Total:  total,
+		Limit:  filter.Limit,
+		Offset: filter.Offset,
+	})
+}
+
+// handleGetMetrics returns incident metrics
+func (h *Handler) handleGetMetrics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	metrics, err := h.incidentService.CalculateMetrics(r.Context())
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(metrics)
+}
+
+// defaultReportWindow is the window a summary report covers when from is omitted
+const defaultReportWindow = 7 * 24 * time.Hour
+
+// handleReportSummary reports on the incidents created between the from and
+// to query parameters, given as RFC 3339 times or YYYY-MM-DD dates in UTC.
+// to defaults to now and from to a week before to.
+func (h *Handler) handleReportSummary(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.writeErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	from, to, msg := reportWindowFromQuery(r.URL.Query())
+	if msg != "" {
+		h.writeErrorResponse(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.incidentService.SummaryReport(r.Context(), from, to)
+	if err != nil {
+		h.writeErrorResponse(w, "Failed to build report", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
 }
 
-// handleListAlerts returns all alerts
-func (h *Handler) handleListAlerts(w http.ResponseWriter, r *http.Request) {
+// handleNotificationReport reports notification delivery success rates by
+// channel type and notification type over the same window as
+// handleReportSummary
+func (h *Handler) handleNotificationReport(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		h.writeErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
-	
-	alerts, err := h.alertService.ListAlerts()
+
+	from, to, msg := reportWindowFromQuery(r.URL.Query())
+	if msg != "" {
+		h.writeErrorResponse(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.notificationService.DeliveryReport(r.Context(), from, to)
 	if err != nil {
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		h.writeErrorResponse(w, "Failed to build report", http.StatusInternalServerError)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(alerts)
+	json.NewEncoder(w).Encode(report)
 }
 
-// handleGetMetrics returns incident metrics
-func (h *Handler) handleGetMetrics(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
+// reportWindowFromQuery reads a report's from and to query parameters. The
+// message is non-empty when they are invalid.
+func reportWindowFromQuery(query url.Values) (time.Time, time.Time, string) {
+	to := time.Now().UTC()
+	if value := query.Get("to"); value != "" {
+		parsed, err := parseReportTime(value)
+		if err != nil {
+			return time.Time{}, time.Time{}, "Invalid to: expected an RFC 3339 time or a YYYY-MM-DD date"
+		}
+		to = parsed
 	}
-	
-	metrics, err := h.incidentService.CalculateMetrics()
-	if err != nil {
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
-		return
+	from := to.Add(-defaultReportWindow)
+	if value := query.Get("from"); value != "" {
+		parsed, err := parseReportTime(value)
+		if err != nil {
+			return time.Time{}, time.Time{}, "Invalid from: expected an RFC 3339 time or a YYYY-MM-DD date"
+		}
+		from = parsed
+	}
+	if !from.Before(to) {
+		return time.Time{}, time.Time{}, "from must be before to"
 	}
+	return from, to, ""
+}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(metrics)
+// parseReportTime parses an RFC 3339 time or a YYYY-MM-DD date
+func parseReportTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", value)
 }
 
 // handleSPA serves the Vue.js Single Page Application
@@ -617,6 +1621,148 @@ func (h *Handler) sendNotificationWithCircuitBreaker(notificationFunc func() err
 	return h.circuitBreaker.Call(notificationFunc)
 }
 
+// circuitBreakers returns the breakers operators can inspect and reset
+func (h *Handler) circuitBreakers() []*circuitbreaker.CircuitBreaker {
+	return []*circuitbreaker.CircuitBreaker{h.circuitBreaker}
+}
+
+// handleCircuitBreakers lists every circuit breaker with its state and counts
+func (h *Handler) handleCircuitBreakers(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.writeErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	breakers := h.circuitBreakers()
+	statuses := make([]models.CircuitBreakerStatus, 0, len(breakers))
+	for _, cb := range breakers {
+		statuses = append(statuses, circuitBreakerStatus(cb))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(statuses)
+}
+
+// handleCircuitBreakerReset force-closes the breaker named in
+// /api/admin/circuit-breakers/{name}/reset without waiting for the half-open probe
+func (h *Handler) handleCircuitBreakerReset(w http.ResponseWriter, r *http.Request) {
+	name, action, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/api/admin/circuit-breakers/"), "/")
+	if name == "" || action != "reset" {
+		h.writeErrorResponse(w, "Not found", http.StatusNotFound)
+		return
+	}
+	if r.Method != http.MethodPost {
+		h.writeErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	for _, cb := range h.circuitBreakers() {
+		if cb.Name() != name {
+			continue
+		}
+
+		cb.Reset()
+		userID, _ := middleware.GetUserIDFromContext(r.Context())
+		h.logger.InfoWithRequest(r.Context(), "Circuit breaker reset", map[string]interface{}{
+			"circuit_breaker": name,
+			"user_id":         userID,
+		})
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(circuitBreakerStatus(cb))
+		return
+	}
+
+	h.writeErrorResponse(w, "Circuit breaker not found", http.StatusNotFound)
+}
+
+func circuitBreakerStatus(cb *circuitbreaker.CircuitBreaker) models.CircuitBreakerStatus {
+	state := cb.State()
+	counts := cb.Counts()
+	return models.CircuitBreakerStatus{
+		Name:                 cb.Name(),
+		State:                state.String(),
+		Requests:             counts.Requests,
+		TotalSuccesses:       counts.TotalSuccesses,
+		TotalFailures:        counts.TotalFailures,
+		ConsecutiveSuccesses: counts.ConsecutiveSuccesses,
+		ConsecutiveFailures:  counts.ConsecutiveFailures,
+	}
+}
+
+// handleDeadLetters lists notifications that exhausted their retries
+func (h *Handler) handleDeadLetters(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.writeErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	deadLetters, err := h.notificationService.ListDeadLetters(r.Context())
+	if err != nil {
+		h.writeErrorResponse(w, "Failed to list dead-lettered notifications", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"notifications": deadLetters,
+	})
+}
+
+// handleDeadLetterRequeue requeues the notification named in
+// /api/admin/notifications/dead-letters/{id}/requeue for delivery
+func (h *Handler) handleDeadLetterRequeue(w http.ResponseWriter, r *http.Request) {
+	id, action, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/api/admin/notifications/dead-letters/"), "/")
+	if id == "" || action != "requeue" {
+		h.writeErrorResponse(w, "Not found", http.StatusNotFound)
+		return
+	}
+	h.requeueDeadLetter(w, r, id)
+}
+
+// handleNotificationRetry requeues the dead-lettered notification named in
+// /api/notifications/{id}/retry for delivery
+func (h *Handler) handleNotificationRetry(w http.ResponseWriter, r *http.Request) {
+	id, action, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/api/notifications/"), "/")
+	if id == "" || action != "retry" {
+		h.writeErrorResponse(w, "Not found", http.StatusNotFound)
+		return
+	}
+	h.requeueDeadLetter(w, r, id)
+}
+
+// requeueDeadLetter responds 202 with the requeued notification, 409 when it
+// is not dead-lettered and 404 when it does not exist
+func (h *Handler) requeueDeadLetter(w http.ResponseWriter, r *http.Request, id string) {
+	if r.Method != http.MethodPost {
+		h.writeErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	history, err := h.notificationService.RequeueDeadLetter(r.Context(), id)
+	switch {
+	case errors.Is(err, services.ErrNotDeadLettered):
+		h.writeErrorResponse(w, err.Error(), http.StatusConflict)
+		return
+	case errors.Is(err, storage.ErrNotFound):
+		h.writeErrorResponse(w, "Notification not found", http.StatusNotFound)
+		return
+	case err != nil:
+		h.writeErrorResponse(w, "Failed to requeue notification", http.StatusInternalServerError)
+		return
+	}
+
+	userID, _ := middleware.GetUserIDFromContext(r.Context())
+	h.logger.InfoWithRequest(r.Context(), "Dead-lettered notification requeued", map[string]interface{}{
+		"history_id": id,
+		"user_id":    userID,
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(history)
+}
+
 // Enhanced Incident Features - Comment Handlers
 
 func (h *Handler) handleIncidentComments(w http.ResponseWriter, r *http.Request) {
@@ -638,8 +1784,58 @@ func (h *Handler) handleIncidentComments(w http.ResponseWriter, r *http.Request)
 	}
 }
 
+// handleIncidentComment edits or deletes one comment. Only the comment's
+// author or an admin may change it.
+func (h *Handler) handleIncidentComment(w http.ResponseWriter, r *http.Request, incidentID, commentID string) {
+	asAdmin := false
+	if claims, ok := middleware.GetClaimsFromContext(r.Context()); ok && claims != nil {
+		asAdmin = h.authService.HasRole(claims, "admin")
+	}
+	userID := requestUserID(r)
+
+	var err error
+	switch r.Method {
+	case http.MethodPut:
+		var req models.UpdateCommentRequest
+		if err := decodeJSON(r, &req); err != nil {
+			h.writeErrorResponse(w, invalidBodyMessage(err, "Invalid JSON"), http.StatusBadRequest)
+			return
+		}
+		if strings.TrimSpace(req.Content) == "" {
+			h.writeErrorResponse(w, "Content is required", http.StatusBadRequest)
+			return
+		}
+		var comment *models.IncidentComment
+		if comment, err = h.incidentService.UpdateComment(r.Context(), incidentID, commentID, userID, req.Content, asAdmin); err == nil {
+			h.logIncidentActivity(r, "edit_comment", incidentID, map[string]interface{}{"comment_id": commentID})
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(comment)
+			return
+		}
+	case http.MethodDelete:
+		if err = h.incidentService.DeleteComment(r.Context(), incidentID, commentID, userID, asAdmin); err == nil {
+			h.logIncidentActivity(r, "delete_comment", incidentID, map[string]interface{}{"comment_id": commentID})
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+	default:
+		h.writeErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		h.writeErrorResponse(w, "Comment not found", http.StatusNotFound)
+	case errors.Is(err, services.ErrNotCommentAuthor), errors.Is(err, services.ErrCommentNotEditable):
+		h.writeErrorResponse(w, err.Error(), http.StatusForbidden)
+	default:
+		log.Printf("Failed to change comment %s on incident %s: %v", commentID, incidentID, err)
+		h.writeErrorResponse(w, "Failed to change comment", http.StatusInternalServerError)
+	}
+}
+
 func (h *Handler) handleGetIncidentComments(w http.ResponseWriter, r *http.Request, incidentID string) {
-	comments, err := h.incidentService.GetComments(incidentID)
+	comments, err := h.incidentService.GetComments(r.Context(), incidentID)
 	if err != nil {
 		log.Printf("Failed to get comments for incident %s: %v", incidentID, err)
 		h.writeErrorResponse(w, "Failed to retrieve comments", http.StatusInternalServerError)
@@ -656,40 +1852,313 @@ func (h *Handler) handleAddIncidentComment(w http.ResponseWriter, r *http.Reques
 	var req struct {
 		Content     string                     `json:"content"`
 		CommentType models.IncidentCommentType `json:"comment_type"`
-		UserID      string                     `json:"user_id"` // In real implementation, extract from auth
+		// UserID is accepted for compatibility but ignored; comments are
+		// attributed to the authenticated user
+		UserID string `json:"user_id"`
+	}
+
+	if err := decodeJSON(r, &req); err != nil {
+		h.writeErrorResponse(w, invalidBodyMessage(err, "Invalid JSON"), http.StatusBadRequest)
+		return
+	}
+
+	if req.Content == "" {
+		h.writeErrorResponse(w, "Content is required", http.StatusBadRequest)
+		return
+	}
+
+	if req.CommentType == "" {
+		req.CommentType = models.CommentTypeComment
+	}
+
+	userID := requestUserID(r)
+
+	if !h.allowComment(incidentID, userID) {
+		w.Header().Set("Retry-After", "60")
+		h.writeErrorResponse(w, "Too many comments, please slow down", http.StatusTooManyRequests)
+		return
+	}
+
+	// Pasted images are stored as screenshot attachments and linked instead
+	content, attachments, err := h.incidentService.ExtractInlineImages(r.Context(), incidentID, userID, req.Content)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInlineImageTooLarge):
+			h.writeErrorResponse(w, "Pasted image is too large", http.StatusRequestEntityTooLarge)
+		case errors.Is(err, services.ErrInvalidInlineImage):
+			h.writeErrorResponse(w, "Pasted image is not valid base64", http.StatusBadRequest)
+		case errors.Is(err, storage.ErrNotFound):
+			h.writeErrorResponse(w, "Incident not found", http.StatusNotFound)
+		default:
+			log.Printf("Failed to store pasted images on incident %s: %v", incidentID, err)
+			h.writeErrorResponse(w, "Failed to store pasted images", http.StatusInternalServerError)
+		}
+		return
+	}
+	var metadata map[string]interface{}
+	if len(attachments) > 0 {
+		ids := make([]string, len(attachments))
+		for i, attachment := range attachments {
+			ids[i] = attachment.ID
+		}
+		metadata = map[string]interface{}{"attachment_ids": ids}
+	}
+
+	comment, err := h.incidentService.AddComment(r.Context(), incidentID, userID, content, req.CommentType, metadata)
+	if err != nil {
+		log.Printf("Failed to add comment to incident %s: %v", incidentID, err)
+		h.writeErrorResponse(w, "Failed to add comment", http.StatusInternalServerError)
+		return
+	}
+	h.logIncidentActivity(r, "add_comment", incidentID, map[string]interface{}{"comment_id": comment.ID})
+
+	// The draft has been posted
+	if userID != "system" {
+		if err := h.incidentService.DeleteDraft(r.Context(), incidentID, userID); err != nil {
+			log.Printf("Failed to clear comment draft on incident %s: %v", incidentID, err)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(comment)
+}
+
+// allowComment applies the per-user, per-incident comment rate limit.
+// System-generated events are never throttled.
+func (h *Handler) allowComment(incidentID, userID string) bool {
+	if h.commentRateLimiter == nil || userID == "system" {
+		return true
+	}
+
+	return h.commentRateLimiter.GetLimiter(userID + "/" + incidentID).Allow()
+}
+
+// handleIncidentCommentDraft saves, returns or discards the authenticated
+// user's unsent comment on an incident. Saving empty content discards it.
+func (h *Handler) handleIncidentCommentDraft(w http.ResponseWriter, r *http.Request) {
+	// Extract incident ID from URL path
+	pathParts := strings.Split(r.URL.Path, "/")
+	if len(pathParts) < 4 || pathParts[3] == "" {
+		h.writeErrorResponse(w, "Incident ID is required", http.StatusBadRequest)
+		return
+	}
+	incidentID := pathParts[3]
+
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok || userID == "" {
+		h.writeErrorResponse(w, "Authentication required", http.StatusUnauthorized)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		draft, err := h.incidentService.GetDraft(r.Context(), incidentID, userID)
+		if err != nil {
+			if errors.Is(err, storage.ErrNotFound) {
+				h.writeErrorResponse(w, "No draft saved", http.StatusNotFound)
+				return
+			}
+			log.Printf("Failed to get comment draft for incident %s: %v", incidentID, err)
+			h.writeErrorResponse(w, "Failed to retrieve draft", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(draft)
+
+	case http.MethodPut:
+		var req struct {
+			Content string `json:"content"`
+		}
+		if err := decodeJSON(r, &req); err != nil {
+			h.writeErrorResponse(w, invalidBodyMessage(err, "Invalid JSON"), http.StatusBadRequest)
+			return
+		}
+
+		if strings.TrimSpace(req.Content) == "" {
+			if err := h.incidentService.DeleteDraft(r.Context(), incidentID, userID); err != nil {
+				log.Printf("Failed to delete comment draft for incident %s: %v", incidentID, err)
+				h.writeErrorResponse(w, "Failed to delete draft", http.StatusInternalServerError)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		draft, err := h.incidentService.SaveDraft(r.Context(), incidentID, userID, req.Content)
+		if err != nil {
+			if errors.Is(err, storage.ErrNotFound) {
+				h.writeErrorResponse(w, "Incident not found", http.StatusNotFound)
+				return
+			}
+			log.Printf("Failed to save comment draft for incident %s: %v", incidentID, err)
+			h.writeErrorResponse(w, "Failed to save draft", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(draft)
+
+	case http.MethodDelete:
+		if err := h.incidentService.DeleteDraft(r.Context(), incidentID, userID); err != nil {
+			log.Printf("Failed to delete comment draft for incident %s: %v", incidentID, err)
+			h.writeErrorResponse(w, "Failed to delete draft", http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		h.writeErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *Handler) handleIncidentTimeline(w http.ResponseWriter, r *http.Request) {
+	// Extract incident ID from URL path
+	pathParts := strings.Split(r.URL.Path, "/")
+	if len(pathParts) < 4 || pathParts[3] == "" {
+		h.writeErrorResponse(w, "Incident ID is required", http.StatusBadRequest)
+		return
+	}
+	incidentID := pathParts[3]
+
+	if r.Method != http.MethodGet {
+		h.writeErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	timeline, err := h.incidentService.GetTimeline(r.Context(), incidentID)
+	if err != nil {
+		log.Printf("Failed to get timeline for incident %s: %v", incidentID, err)
+		h.writeErrorResponse(w, "Failed to retrieve timeline", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"timeline": timeline,
+	})
+}
+
+// incidentDetailSections are the sections /api/incidents/{id}/full can return
+// besides the incident itself
+var incidentDetailSections = []string{"timeline", "tags", "attachments", "alerts"}
+
+// handleIncidentFull returns an incident together with its timeline, tags,
+// attachments and alerts, so the detail view needs a single request.
+// ?include=timeline,alerts limits the response to the listed sections.
+func (h *Handler) handleIncidentFull(w http.ResponseWriter, r *http.Request) {
+	pathParts := strings.Split(r.URL.Path, "/")
+	if len(pathParts) < 4 || pathParts[3] == "" {
+		h.writeErrorResponse(w, "Incident ID is required", http.StatusBadRequest)
+		return
+	}
+	incidentID := pathParts[3]
+
+	if r.Method != http.MethodGet {
+		h.writeErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	known := make(map[string]bool, len(incidentDetailSections))
+	for _, section := range incidentDetailSections {
+		known[section] = true
+	}
+	include := known
+	if raw := r.URL.Query().Get("include"); raw != "" {
+		include = make(map[string]bool)
+		for _, section := range strings.Split(raw, ",") {
+			section = strings.TrimSpace(section)
+			if !known[section] {
+				h.writeErrorResponse(w, fmt.Sprintf("Unknown section %q, expected one of %s", section, strings.Join(incidentDetailSections, ", ")), http.StatusBadRequest)
+				return
+			}
+			include[section] = true
+		}
+	}
+
+	incident, err := h.incidentService.GetIncident(r.Context(), incidentID)
+	if err != nil {
+		h.writeErrorResponse(w, "Incident not found", http.StatusNotFound)
+		return
+	}
+	response := map[string]interface{}{"incident": incident}
+
+	if include["timeline"] {
+		if response["timeline"], err = h.incidentService.GetTimeline(r.Context(), incidentID); err != nil {
+			log.Printf("Failed to get timeline for incident %s: %v", incidentID, err)
+			h.writeErrorResponse(w, "Failed to retrieve timeline", http.StatusInternalServerError)
+			return
+		}
+	}
+	if include["tags"] {
+		if response["tags"], err = h.incidentService.GetTags(r.Context(), incidentID); err != nil {
+			log.Printf("Failed to get tags for incident %s: %v", incidentID, err)
+			h.writeErrorResponse(w, "Failed to retrieve tags", http.StatusInternalServerError)
+			return
+		}
+	}
+	if include["attachments"] {
+		if response["attachments"], err = h.incidentService.GetAttachments(r.Context(), incidentID); err != nil {
+			log.Printf("Failed to get attachments for incident %s: %v", incidentID, err)
+			h.writeErrorResponse(w, "Failed to retrieve attachments", http.StatusInternalServerError)
+			return
+		}
 	}
-
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.writeErrorResponse(w, "Invalid JSON", http.StatusBadRequest)
-		return
+	if include["alerts"] {
+		alerts := make([]*models.Alert, 0, len(incident.AlertIDs))
+		for _, alertID := range incident.AlertIDs {
+			alert, err := h.alertService.GetAlert(r.Context(), alertID)
+			if errors.Is(err, storage.ErrNotFound) {
+				continue
+			}
+			if err != nil {
+				log.Printf("Failed to get alert %s for incident %s: %v", alertID, incidentID, err)
+				h.writeErrorResponse(w, "Failed to retrieve alerts", http.StatusInternalServerError)
+				return
+			}
+			alerts = append(alerts, alert)
+		}
+		response["alerts"] = alerts
 	}
 
-	if req.Content == "" {
-		h.writeErrorResponse(w, "Content is required", http.StatusBadRequest)
-		return
-	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
 
-	if req.CommentType == "" {
-		req.CommentType = models.CommentTypeComment
+// handleIncidentKeyEvents returns the milestones of an incident's timeline
+func (h *Handler) handleIncidentKeyEvents(w http.ResponseWriter, r *http.Request) {
+	// Extract incident ID from URL path
+	pathParts := strings.Split(r.URL.Path, "/")
+	if len(pathParts) < 4 || pathParts[3] == "" {
+		h.writeErrorResponse(w, "Incident ID is required", http.StatusBadRequest)
+		return
 	}
+	incidentID := pathParts[3]
 
-	if req.UserID == "" {
-		req.UserID = "system" // Default for now
+	if r.Method != http.MethodGet {
+		h.writeErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
 	}
 
-	comment, err := h.incidentService.AddComment(incidentID, req.UserID, req.Content, req.CommentType, nil)
+	events, err := h.incidentService.GetKeyEvents(r.Context(), incidentID)
 	if err != nil {
-		log.Printf("Failed to add comment to incident %s: %v", incidentID, err)
-		h.writeErrorResponse(w, "Failed to add comment", http.StatusInternalServerError)
+		if errors.Is(err, storage.ErrNotFound) {
+			h.writeErrorResponse(w, "Incident not found", http.StatusNotFound)
+			return
+		}
+		log.Printf("Failed to get key events for incident %s: %v", incidentID, err)
+		h.writeErrorResponse(w, "Failed to retrieve key events", http.StatusInternalServerError)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(comment)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"key_events": events,
+	})
 }
 
-func (h *Handler) handleIncidentTimeline(w http.ResponseWriter, r *http.Request) {
+// handleIncidentNotifications lists the notification attempts made for an
+// incident, newest first
+func (h *Handler) handleIncidentNotifications(w http.ResponseWriter, r *http.Request) {
 	// Extract incident ID from URL path
 	pathParts := strings.Split(r.URL.Path, "/")
 	if len(pathParts) < 4 || pathParts[3] == "" {
@@ -703,16 +2172,25 @@ func (h *Handler) handleIncidentTimeline(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	timeline, err := h.incidentService.GetTimeline(incidentID)
+	if _, err := h.incidentService.GetIncident(r.Context(), incidentID); err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			h.writeErrorResponse(w, "Incident not found", http.StatusNotFound)
+			return
+		}
+		h.writeErrorResponse(w, "Failed to retrieve incident", http.StatusInternalServerError)
+		return
+	}
+
+	history, err := h.store.ListNotificationHistory(r.Context(), incidentID)
 	if err != nil {
-		log.Printf("Failed to get timeline for incident %s: %v", incidentID, err)
-		h.writeErrorResponse(w, "Failed to retrieve timeline", http.StatusInternalServerError)
+		log.Printf("Failed to list notification history for incident %s: %v", incidentID, err)
+		h.writeErrorResponse(w, "Failed to retrieve notification history", http.StatusInternalServerError)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"timeline": timeline,
+		"notifications": history,
 	})
 }
 
@@ -740,7 +2218,7 @@ func (h *Handler) handleIncidentTags(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) handleGetIncidentTags(w http.ResponseWriter, r *http.Request, incidentID string) {
-	tags, err := h.incidentService.GetTags(incidentID)
+	tags, err := h.incidentService.GetTags(r.Context(), incidentID)
 	if err != nil {
 		log.Printf("Failed to get tags for incident %s: %v", incidentID, err)
 		h.writeErrorResponse(w, "Failed to retrieve tags", http.StatusInternalServerError)
@@ -755,12 +2233,13 @@ func (h *Handler) handleGetIncidentTags(w http.ResponseWriter, r *http.Request,
 
 func (h *Handler) handleAddIncidentTags(w http.ResponseWriter, r *http.Request, incidentID string) {
 	var req struct {
-		Tags   []models.TemplateTag `json:"tags"`
-		UserID string               `json:"user_id"` // In real implementation, extract from auth
+		Tags []models.TemplateTag `json:"tags"`
+		// UserID is ignored; tags are attributed to the authenticated user
+		UserID string `json:"user_id"`
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.writeErrorResponse(w, "Invalid JSON", http.StatusBadRequest)
+	if err := decodeJSON(r, &req); err != nil {
+		h.writeErrorResponse(w, invalidBodyMessage(err, "Invalid JSON"), http.StatusBadRequest)
 		return
 	}
 
@@ -769,16 +2248,17 @@ func (h *Handler) handleAddIncidentTags(w http.ResponseWriter, r *http.Request,
 		return
 	}
 
-	if req.UserID == "" {
-		req.UserID = "system" // Default for now
-	}
-
-	err := h.incidentService.AddTags(incidentID, req.UserID, req.Tags)
+	err := h.incidentService.AddTags(r.Context(), incidentID, requestUserID(r), req.Tags)
 	if err != nil {
 		log.Printf("Failed to add tags to incident %s: %v", incidentID, err)
 		h.writeErrorResponse(w, "Failed to add tags", http.StatusInternalServerError)
 		return
 	}
+	tagNames := make([]string, len(req.Tags))
+	for i, tag := range req.Tags {
+		tagNames[i] = tag.Name
+	}
+	h.logIncidentActivity(r, "add_tags", incidentID, map[string]interface{}{"tags": tagNames})
 
 	h.writeSuccessResponse(w, "Tags added successfully")
 }
@@ -786,11 +2266,12 @@ func (h *Handler) handleAddIncidentTags(w http.ResponseWriter, r *http.Request,
 func (h *Handler) handleRemoveIncidentTags(w http.ResponseWriter, r *http.Request, incidentID string) {
 	var req struct {
 		TagNames []string `json:"tag_names"`
-		UserID   string   `json:"user_id"` // In real implementation, extract from auth
+		// UserID is ignored; the removal is attributed to the authenticated user
+		UserID string `json:"user_id"`
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.writeErrorResponse(w, "Invalid JSON", http.StatusBadRequest)
+	if err := decodeJSON(r, &req); err != nil {
+		h.writeErrorResponse(w, invalidBodyMessage(err, "Invalid JSON"), http.StatusBadRequest)
 		return
 	}
 
@@ -799,16 +2280,13 @@ func (h *Handler) handleRemoveIncidentTags(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	if req.UserID == "" {
-		req.UserID = "system" // Default for now
-	}
-
-	err := h.incidentService.RemoveTags(incidentID, req.UserID, req.TagNames)
+	err := h.incidentService.RemoveTags(r.Context(), incidentID, requestUserID(r), req.TagNames)
 	if err != nil {
 		log.Printf("Failed to remove tags from incident %s: %v", incidentID, err)
 		h.writeErrorResponse(w, "Failed to remove tags", http.StatusInternalServerError)
 		return
 	}
+	h.logIncidentActivity(r, "remove_tags", incidentID, map[string]interface{}{"tags": req.TagNames})
 
 	h.writeSuccessResponse(w, "Tags removed successfully")
 }
@@ -827,7 +2305,7 @@ func (h *Handler) handleIncidentTemplates(w http.ResponseWriter, r *http.Request
 }
 
 func (h *Handler) handleListIncidentTemplates(w http.ResponseWriter, r *http.Request) {
-	templates, err := h.incidentService.ListTemplates()
+	templates, err := h.incidentService.ListTemplates(r.Context())
 	if err != nil {
 		log.Printf("Failed to list incident templates: %v", err)
 		h.writeErrorResponse(w, "Failed to retrieve templates", http.StatusInternalServerError)
@@ -843,8 +2321,8 @@ func (h *Handler) handleListIncidentTemplates(w http.ResponseWriter, r *http.Req
 func (h *Handler) handleCreateIncidentTemplate(w http.ResponseWriter, r *http.Request) {
 	var template models.IncidentTemplate
 
-	if err := json.NewDecoder(r.Body).Decode(&template); err != nil {
-		h.writeErrorResponse(w, "Invalid JSON", http.StatusBadRequest)
+	if err := decodeJSON(r, &template); err != nil {
+		h.writeErrorResponse(w, invalidBodyMessage(err, "Invalid JSON"), http.StatusBadRequest)
 		return
 	}
 
@@ -858,13 +2336,14 @@ func (h *Handler) handleCreateIncidentTemplate(w http.ResponseWriter, r *http.Re
 		return
 	}
 
-	// Set defaults
-	if template.CreatedBy == nil {
-		userID := "system" // In real implementation, extract from auth
-		template.CreatedBy = &userID
-	}
+	userID := requestUserID(r)
+	template.CreatedBy = &userID
 
-	err := h.incidentService.CreateTemplate(&template)
+	err := h.incidentService.CreateTemplate(r.Context(), &template)
+	if errors.Is(err, services.ErrInvalidPriority) {
+		h.writeErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	if err != nil {
 		log.Printf("Failed to create incident template: %v", err)
 		h.writeErrorResponse(w, "Failed to create template", http.StatusInternalServerError)
@@ -876,6 +2355,105 @@ func (h *Handler) handleCreateIncidentTemplate(w http.ResponseWriter, r *http.Re
 	json.NewEncoder(w).Encode(template)
 }
 
+// handleIncidentTemplate reads, edits or deletes a single template. PUT
+// bodies are applied over the stored template, so omitted fields keep their
+// current values.
+func (h *Handler) handleIncidentTemplate(w http.ResponseWriter, r *http.Request) {
+	templateID, action, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/api/templates/"), "/")
+	if templateID == "" || (action != "" && action != "preview") {
+		h.writeErrorResponse(w, "Invalid template path", http.StatusBadRequest)
+		return
+	}
+	if action == "preview" {
+		h.handleTemplatePreview(w, r, templateID)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		template, err := h.incidentService.GetTemplate(r.Context(), templateID)
+		if err != nil {
+			h.writeTemplateError(w, "get", templateID, err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(template)
+	case http.MethodPut:
+		template, err := h.incidentService.GetTemplate(r.Context(), templateID)
+		if err != nil {
+			h.writeTemplateError(w, "get", templateID, err)
+			return
+		}
+		if err := decodeJSON(r, template); err != nil {
+			h.writeErrorResponse(w, invalidBodyMessage(err, "Invalid JSON"), http.StatusBadRequest)
+			return
+		}
+		if template.Name == "" {
+			h.writeErrorResponse(w, "Template name is required", http.StatusBadRequest)
+			return
+		}
+		if template.TitleTemplate == "" {
+			h.writeErrorResponse(w, "Title template is required", http.StatusBadRequest)
+			return
+		}
+		if err := h.incidentService.UpdateTemplate(r.Context(), templateID, template); err != nil {
+			h.writeTemplateError(w, "update", templateID, err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(template)
+	case http.MethodDelete:
+		if err := h.incidentService.DeleteTemplate(r.Context(), templateID); err != nil {
+			h.writeTemplateError(w, "delete", templateID, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		h.writeErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleTemplatePreview renders a template with the given variables without
+// creating an incident
+func (h *Handler) handleTemplatePreview(w http.ResponseWriter, r *http.Request, templateID string) {
+	if r.Method != http.MethodPost {
+		h.writeErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req models.TemplatePreviewRequest
+	if err := decodeJSON(r, &req); err != nil {
+		h.writeErrorResponse(w, invalidBodyMessage(err, "Invalid JSON"), http.StatusBadRequest)
+		return
+	}
+
+	preview, err := h.incidentService.PreviewTemplate(r.Context(), templateID, req.Variables)
+	if err != nil {
+		if isIncidentTextError(err) || errors.Is(err, services.ErrMissingTemplateVars) {
+			h.writeErrorResponse(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		h.writeTemplateError(w, "preview", templateID, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(preview)
+}
+
+// writeTemplateError maps an error from a template operation to a response
+func (h *Handler) writeTemplateError(w http.ResponseWriter, operation, templateID string, err error) {
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		h.writeErrorResponse(w, "Template not found", http.StatusNotFound)
+	case errors.Is(err, services.ErrInvalidPriority):
+		h.writeErrorResponse(w, err.Error(), http.StatusBadRequest)
+	default:
+		log.Printf("Failed to %s incident template %s: %v", operation, templateID, err)
+		h.writeErrorResponse(w, "Failed to "+operation+" template", http.StatusInternalServerError)
+	}
+}
+
 func (h *Handler) handleIncidentFromTemplate(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		h.writeErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -884,8 +2462,8 @@ func (h *Handler) handleIncidentFromTemplate(w http.ResponseWriter, r *http.Requ
 
 	var req models.CreateIncidentFromTemplateRequest
 
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.writeErrorResponse(w, "Invalid JSON", http.StatusBadRequest)
+	if err := decodeJSON(r, &req); err != nil {
+		h.writeErrorResponse(w, invalidBodyMessage(err, "Invalid JSON"), http.StatusBadRequest)
 		return
 	}
 
@@ -894,10 +2472,17 @@ func (h *Handler) handleIncidentFromTemplate(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	userID := "system" // In real implementation, extract from auth
-
-	incident, err := h.incidentService.UseTemplate(&req, userID)
+	incident, err := h.incidentService.UseTemplate(r.Context(), &req, requestUserID(r))
 	if err != nil {
+		if isIncidentTextError(err) || errors.Is(err, services.ErrMissingTemplateVars) ||
+			errors.Is(err, services.ErrAssigneeNotFound) || errors.Is(err, services.ErrAssigneeNotAssignable) {
+			h.writeErrorResponse(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, storage.ErrNotFound) {
+			h.writeErrorResponse(w, "Template not found", http.StatusNotFound)
+			return
+		}
 		log.Printf("Failed to create incident from template: %v", err)
 		h.writeErrorResponse(w, "Failed to create incident from template", http.StatusInternalServerError)
 		return
@@ -918,8 +2503,8 @@ func (h *Handler) handleIncidentSearch(w http.ResponseWriter, r *http.Request) {
 
 	var req models.IncidentSearchRequest
 
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.writeErrorResponse(w, "Invalid JSON", http.StatusBadRequest)
+	if err := decodeJSON(r, &req); err != nil {
+		h.writeErrorResponse(w, invalidBodyMessage(err, "Invalid JSON"), http.StatusBadRequest)
 		return
 	}
 
@@ -934,7 +2519,7 @@ func (h *Handler) handleIncidentSearch(w http.ResponseWriter, r *http.Request) {
 		req.Limit = 100 // Maximum limit
 	}
 
-	response, err := h.incidentService.SearchIncidents(&req)
+	response, err := h.incidentService.SearchIncidents(r.Context(), &req)
 	if err != nil {
 		log.Printf("Failed to search incidents: %v", err)
 		h.writeErrorResponse(w, "Failed to search incidents", http.StatusInternalServerError)
@@ -955,8 +2540,8 @@ func (h *Handler) handleIncidentBulkOperations(w http.ResponseWriter, r *http.Re
 
 	var req models.BulkOperationRequest
 
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.writeErrorResponse(w, "Invalid JSON", http.StatusBadRequest)
+	if err := decodeJSON(r, &req); err != nil {
+		h.writeErrorResponse(w, invalidBodyMessage(err, "Invalid JSON"), http.StatusBadRequest)
 		return
 	}
 
@@ -965,18 +2550,36 @@ func (h *Handler) handleIncidentBulkOperations(w http.ResponseWriter, r *http.Re
 		return
 	}
 
-	userID := "system" // In real implementation, extract from auth
+	// Bulk operations need the same permissions as their per-incident routes
+	if action, gated := bulkOperationPermissionAction(req); gated && !h.checkIncidentPermission(w, r, action) {
+		return
+	}
+
+	userID := requestUserID(r)
 
 	var response *models.BulkOperationResponse
 	var err error
 
 	switch req.Operation {
 	case models.BulkOperationAcknowledge:
-		assigneeID := "system" // Default assignee
+		assigneeID := userID
 		if assignee, ok := req.Parameters["assignee_id"].(string); ok {
 			assigneeID = assignee
 		}
-		response, err = h.incidentService.BulkAcknowledge(req.IncidentIDs, assigneeID, userID)
+		if assigneeID != userID {
+			// The acknowledge permission check above guarantees claims
+			claims, _ := middleware.GetClaimsFromContext(r.Context())
+			allowed, permErr := h.mayAcknowledgeOnBehalf(r, claims)
+			if permErr != nil {
+				h.writeErrorResponse(w, "Failed to check permissions", http.StatusInternalServerError)
+				return
+			}
+			if !allowed {
+				h.writeErrorResponse(w, "Insufficient permissions to acknowledge on behalf of another user", http.StatusForbidden)
+				return
+			}
+		}
+		response, err = h.incidentService.BulkAcknowledge(r.Context(), req.IncidentIDs, assigneeID, userID)
 
 	case models.BulkOperationUpdateStatus:
 		statusStr, ok := req.Parameters["status"].(string)
@@ -985,7 +2588,45 @@ func (h *Handler) handleIncidentBulkOperations(w http.ResponseWriter, r *http.Re
 			return
 		}
 		status := models.IncidentStatus(statusStr)
-		response, err = h.incidentService.BulkUpdateStatus(req.IncidentIDs, status, userID)
+		response, err = h.incidentService.BulkUpdateStatus(r.Context(), req.IncidentIDs, status, userID)
+
+	case models.BulkOperationResolve:
+		var resolution models.Resolution
+		resolution.Note, _ = req.Parameters["note"].(string)
+		resolutionType, _ := req.Parameters["resolution_type"].(string)
+		resolution.Type = models.ResolutionType(resolutionType)
+		resolution.RootCauseCategory, _ = req.Parameters["root_cause_category"].(string)
+		response, err = h.incidentService.BulkResolve(r.Context(), req.IncidentIDs, userID, resolution)
+
+	case models.BulkOperationAssign:
+		assigneeID, _ := req.Parameters["assignee_id"].(string)
+		if assigneeID == "" {
+			h.writeErrorResponse(w, "assignee_id parameter is required for assign operation", http.StatusBadRequest)
+			return
+		}
+		response, err = h.incidentService.BulkAssign(r.Context(), req.IncidentIDs, assigneeID, userID)
+
+	case models.BulkOperationAddTags:
+		var tags []models.TemplateTag
+		if decodeErr := decodeBulkParameter(req.Parameters, "tags", &tags); decodeErr != nil || len(tags) == 0 {
+			h.writeErrorResponse(w, "tags parameter must be a non-empty list of {name, value, color} objects", http.StatusBadRequest)
+			return
+		}
+		for _, tag := range tags {
+			if strings.TrimSpace(tag.Name) == "" {
+				h.writeErrorResponse(w, "Every tag needs a name", http.StatusBadRequest)
+				return
+			}
+		}
+		response, err = h.incidentService.BulkAddTags(r.Context(), req.IncidentIDs, tags, userID)
+
+	case models.BulkOperationRemoveTags:
+		var tagNames []string
+		if decodeErr := decodeBulkParameter(req.Parameters, "tags", &tagNames); decodeErr != nil || len(tagNames) == 0 {
+			h.writeErrorResponse(w, "tags parameter must be a non-empty list of tag names", http.StatusBadRequest)
+			return
+		}
+		response, err = h.incidentService.BulkRemoveTags(r.Context(), req.IncidentIDs, tagNames, userID)
 
 	default:
 		h.writeErrorResponse(w, "Unsupported bulk operation", http.StatusBadRequest)
@@ -1002,6 +2643,124 @@ func (h *Handler) handleIncidentBulkOperations(w http.ResponseWriter, r *http.Re
 	json.NewEncoder(w).Encode(response)
 }
 
+// bulkOperationPermissionAction returns the incidents permission action a
+// bulk operation needs, mirroring incidentPermissionAction
+func bulkOperationPermissionAction(req models.BulkOperationRequest) (string, bool) {
+	switch req.Operation {
+	case models.BulkOperationAcknowledge:
+		return incidentPermissionAction(http.MethodPost, "acknowledge")
+	case models.BulkOperationResolve:
+		return incidentPermissionAction(http.MethodPost, "resolve")
+	case models.BulkOperationAssign:
+		return incidentPermissionAction(http.MethodPost, "assign")
+	case models.BulkOperationUpdateStatus:
+		status, _ := req.Parameters["status"].(string)
+		switch models.IncidentStatus(status) {
+		case models.IncidentStatusAcknowledged:
+			return incidentPermissionAction(http.MethodPost, "acknowledge")
+		case models.IncidentStatusResolved, models.IncidentStatusOpen:
+			return incidentPermissionAction(http.MethodPost, "resolve")
+		}
+		return "update", true
+	case models.BulkOperationAddTags, models.BulkOperationRemoveTags:
+		return "update", true
+	}
+	return "", false
+}
+
+// checkIncidentPermission checks that the authenticated user's roles grant
+// action on incidents. It writes the error response and returns false when
+// they do not.
+func (h *Handler) checkIncidentPermission(w http.ResponseWriter, r *http.Request, action string) bool {
+	claims, ok := middleware.GetClaimsFromContext(r.Context())
+	if !ok || claims == nil {
+		h.writeErrorResponse(w, "Authentication required", http.StatusUnauthorized)
+		return false
+	}
+	allowed, err := h.authService.UserHasPermission(r.Context(), claims.UserID, "incidents", action)
+	if err != nil {
+		h.writeErrorResponse(w, "Failed to check permissions", http.StatusInternalServerError)
+		return false
+	}
+	if !allowed {
+		h.writeErrorResponse(w, "Insufficient permissions", http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+// decodeBulkParameter decodes a structured bulk operation parameter, such as
+// a list of tags, into dst
+func decodeBulkParameter(parameters map[string]interface{}, key string, dst interface{}) error {
+	value, ok := parameters[key]
+	if !ok {
+		return fmt.Errorf("%s parameter is required", key)
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, dst)
+}
+
+// handleBulkNotify re-sends notifications for a set of incidents, e.g. after a
+// notification outage. Each delivery goes through the circuit breaker, and
+// the notification service retries each channel as usual.
+func (h *Handler) handleBulkNotify(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.writeErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req models.BulkNotifyRequest
+	if err := decodeJSON(r, &req); err != nil {
+		h.writeErrorResponse(w, invalidBodyMessage(err, "Invalid JSON"), http.StatusBadRequest)
+		return
+	}
+
+	if len(req.IncidentIDs) == 0 {
+		h.writeErrorResponse(w, "At least one incident ID is required", http.StatusBadRequest)
+		return
+	}
+
+	switch req.NotificationType {
+	case "", "incident_created", "incident_acknowledged", "incident_resolved":
+	default:
+		h.writeErrorResponse(w, "Unsupported notification type", http.StatusBadRequest)
+		return
+	}
+
+	response := &models.BulkNotifyResponse{Results: make([]models.BulkNotifyResult, 0, len(req.IncidentIDs))}
+	for _, incidentID := range req.IncidentIDs {
+		result := models.BulkNotifyResult{IncidentID: incidentID, Status: "sent"}
+
+		incident, err := h.incidentService.GetIncident(r.Context(), incidentID)
+		if err == nil {
+			err = h.sendNotificationWithCircuitBreaker(func() error {
+				return h.notificationService.NotifyIncident(r.Context(), incident, req.NotificationType)
+			})
+		}
+
+		if err != nil {
+			result.Status = "failed"
+			result.Error = err.Error()
+			response.FailedCount++
+		} else {
+			response.SentCount++
+		}
+		response.Results = append(response.Results, result)
+	}
+
+	h.logger.InfoWithRequest(r.Context(), "Bulk re-notification completed", map[string]interface{}{
+		"notification_type": req.NotificationType,
+		"sent":              response.SentCount,
+		"failed":            response.FailedCount,
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
 // Enhanced Incident Features - Assignment Handler
 
 func (h *Handler) handleIncidentAssignment(w http.ResponseWriter, r *http.Request) {
@@ -1020,11 +2779,12 @@ func (h *Handler) handleIncidentAssignment(w http.ResponseWriter, r *http.Reques
 
 	var req struct {
 		AssigneeID string `json:"assignee_id"`
-		UserID     string `json:"user_id"` // In real implementation, extract from auth
+		// UserID is ignored; the assignment is attributed to the authenticated user
+		UserID string `json:"user_id"`
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.writeErrorResponse(w, "Invalid JSON", http.StatusBadRequest)
+	if err := decodeJSON(r, &req); err != nil {
+		h.writeErrorResponse(w, invalidBodyMessage(err, "Invalid JSON"), http.StatusBadRequest)
 		return
 	}
 
@@ -1033,17 +2793,191 @@ func (h *Handler) handleIncidentAssignment(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	if req.UserID == "" {
-		req.UserID = "system" // Default for now
-	}
-
-	err := h.incidentService.AssignIncident(incidentID, req.AssigneeID, req.UserID)
+	err := h.incidentService.AssignIncident(r.Context(), incidentID, req.AssigneeID, requestUserID(r))
 	if err != nil {
+		if errors.Is(err, services.ErrAssigneeNotFound) || errors.Is(err, services.ErrAssigneeNotAssignable) {
+			h.writeErrorResponse(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 		log.Printf("Failed to assign incident %s: %v", incidentID, err)
 		h.writeErrorResponse(w, "Failed to assign incident", http.StatusInternalServerError)
 		return
 	}
+	h.logIncidentActivity(r, "assign_incident", incidentID, map[string]interface{}{"assignee_id": req.AssigneeID})
 
 	h.writeSuccessResponse(w, "Incident assigned successfully")
 }
 
+// handleIncidentEscalationPolicy attaches an escalation policy to the incident
+// at /api/incidents/{id}/escalation-policy
+func (h *Handler) handleIncidentEscalationPolicy(w http.ResponseWriter, r *http.Request) {
+	pathParts := strings.Split(r.URL.Path, "/")
+	if len(pathParts) < 4 || pathParts[3] == "" {
+		h.writeErrorResponse(w, "Incident ID is required", http.StatusBadRequest)
+		return
+	}
+	incidentID := pathParts[3]
+
+	if r.Method != http.MethodPut {
+		h.writeErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req models.SetEscalationPolicyRequest
+	if err := decodeJSON(r, &req); err != nil {
+		h.writeErrorResponse(w, invalidBodyMessage(err, "Invalid JSON"), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.incidentService.SetEscalationPolicy(r.Context(), incidentID, req.PolicyID); err != nil {
+		switch {
+		case errors.Is(err, services.ErrEscalationPolicyNotFound):
+			h.writeErrorResponse(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, storage.ErrNotFound):
+			h.writeErrorResponse(w, "Incident not found", http.StatusNotFound)
+		default:
+			log.Printf("Failed to set escalation policy for incident %s: %v", incidentID, err)
+			h.writeErrorResponse(w, "Failed to set escalation policy", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	h.writeSuccessResponse(w, "Escalation policy updated successfully")
+}
+
+// handleIncidentAttachments uploads a file from a multipart form as an
+// attachment of the incident
+func (h *Handler) handleIncidentAttachments(w http.ResponseWriter, r *http.Request, incidentID string) {
+	if r.Method != http.MethodPost {
+		h.writeErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Leave room for the multipart framing and form fields around the file
+	maxBytes := h.incidentService.MaxAttachmentBytes()
+	r.Body = http.MaxBytesReader(w, r.Body, maxBytes+64<<10)
+	if err := r.ParseMultipartForm(1 << 20); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			h.writeErrorResponse(w, services.ErrAttachmentTooLarge.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
+		h.writeErrorResponse(w, "Invalid multipart form", http.StatusBadRequest)
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		h.writeErrorResponse(w, "A file is required in the file field", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	attachmentType := models.AttachmentType(r.FormValue("attachment_type"))
+	attachment, err := h.incidentService.UploadAttachment(r.Context(), incidentID, requestUserID(r), header.Filename, attachmentType, file)
+	if err != nil {
+		switch {
+		case errors.Is(err, storage.ErrNotFound):
+			h.writeErrorResponse(w, "Incident not found", http.StatusNotFound)
+		case errors.Is(err, services.ErrAttachmentTooLarge):
+			h.writeErrorResponse(w, err.Error(), http.StatusRequestEntityTooLarge)
+		case errors.Is(err, services.ErrAttachmentTypeNotAllowed):
+			h.writeErrorResponse(w, err.Error(), http.StatusUnsupportedMediaType)
+		case errors.Is(err, services.ErrInvalidAttachmentName), errors.Is(err, services.ErrInvalidAttachmentType):
+			h.writeErrorResponse(w, err.Error(), http.StatusBadRequest)
+		default:
+			log.Printf("Failed to upload attachment to incident %s: %v", incidentID, err)
+			h.writeErrorResponse(w, "Failed to upload attachment", http.StatusInternalServerError)
+		}
+		return
+	}
+	h.logIncidentActivity(r, "upload_attachment", incidentID, map[string]interface{}{"attachment_id": attachment.ID})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(attachment)
+}
+
+// handleDownloadAttachment streams an attachment's file with its stored
+// content type as a download under its original name
+func (h *Handler) handleDownloadAttachment(w http.ResponseWriter, r *http.Request, incidentID, attachmentID string) {
+	if r.Method != http.MethodGet {
+		h.writeErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	attachment, err := h.incidentService.GetAttachment(r.Context(), incidentID, attachmentID)
+	if err != nil {
+		switch {
+		case errors.Is(err, storage.ErrNotFound):
+			h.writeErrorResponse(w, "Attachment not found", http.StatusNotFound)
+		case errors.Is(err, services.ErrAttachmentOtherIncident):
+			h.writeErrorResponse(w, err.Error(), http.StatusForbidden)
+		default:
+			log.Printf("Failed to get attachment %s of incident %s: %v", attachmentID, incidentID, err)
+			h.writeErrorResponse(w, "Failed to retrieve attachment", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	file, err := h.incidentService.OpenAttachment(attachment)
+	if err != nil {
+		log.Printf("Failed to open attachment %s at %s: %v", attachment.ID, attachment.FilePath, err)
+		if errors.Is(err, fs.ErrNotExist) {
+			h.writeErrorResponse(w, "Attachment file not found", http.StatusNotFound)
+		} else {
+			h.writeErrorResponse(w, "Failed to retrieve attachment", http.StatusInternalServerError)
+		}
+		return
+	}
+	defer file.Close()
+
+	w.Header().Set("Content-Type", attachment.MimeType)
+	w.Header().Set("Content-Disposition", services.ContentDisposition(attachment.OriginalName))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	if seeker, ok := file.(io.ReadSeeker); ok {
+		http.ServeContent(w, r, "", attachment.CreatedAt, seeker)
+		return
+	}
+	w.Header().Set("Content-Length", strconv.FormatInt(attachment.FileSize, 10))
+	io.Copy(w, file)
+}
+
+// handleIncidentPriority changes an incident's priority and returns the
+// updated incident
+func (h *Handler) handleIncidentPriority(w http.ResponseWriter, r *http.Request, incidentID string) {
+	if r.Method != http.MethodPut {
+		h.writeErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req models.SetPriorityRequest
+	if err := decodeJSON(r, &req); err != nil {
+		h.writeErrorResponse(w, invalidBodyMessage(err, "Invalid JSON"), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.incidentService.SetPriority(r.Context(), incidentID, requestUserID(r), req.Priority); err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidPriority):
+			h.writeErrorResponse(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, storage.ErrNotFound):
+			h.writeErrorResponse(w, "Incident not found", http.StatusNotFound)
+		default:
+			log.Printf("Failed to set priority for incident %s: %v", incidentID, err)
+			h.writeErrorResponse(w, "Failed to set priority", http.StatusInternalServerError)
+		}
+		return
+	}
+	h.logIncidentActivity(r, "set_priority", incidentID, map[string]interface{}{"priority": req.Priority})
+
+	incident, err := h.incidentService.GetIncident(r.Context(), incidentID)
+	if err != nil {
+		h.writeErrorResponse(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(incident)
+}
@@ -0,0 +1,2547 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/circuitbreaker"
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/config"
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/idempotency"
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/middleware"
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/models"
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/ratelimit"
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/retry"
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/services"
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/storage"
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/validation"
+)
+
+func setupTestHandler(t *testing.T) (*Handler, storage.Store) {
+	store, err := storage.NewMemoryStore()
+	if err != nil {
+		t.Fatalf("Failed to create memory store: %v", err)
+	}
+	setupTestRolesAndPermissions(t, store)
+
+	logger := services.NewLogger("debug", false)
+	metricsService := services.NewMetricsService(prometheus.NewRegistry())
+	incidentService := services.NewIncidentService(store, metricsService)
+	alertService := services.NewAlertService(store, incidentService, metricsService)
+	templateService := services.NewNotificationTemplateService(logger)
+	notificationService := services.NewNotificationService(&config.Config{}, store, templateService, metricsService, logger)
+	authService := services.NewAuthService("test-jwt-secret-32-characters-long!", 1*time.Hour, 24*time.Hour)
+	authService.SetPermissionStore(store)
+	userService := services.NewUserService(store, authService, logger)
+
+	handler := NewHandler(incidentService, alertService, notificationService, metricsService, logger, store, userService, authService)
+	return handler, store
+}
+
+func testWebhookPayload(fingerprint string) []byte {
+	return []byte(fmt.Sprintf(`{
+		"version": "4",
+		"status": "firing",
+		"alerts": [{
+			"fingerprint": %q,
+			"status": "firing",
+			"startsAt": "2024-01-01T12:00:00Z",
+			"labels": {"alertname": "HighCPU", "instance": %q},
+			"annotations": {}
+		}]
+	}`, fingerprint, fingerprint))
+}
+
+func TestHandler_WebhookSignature(t *testing.T) {
+	const secret = "signing-secret-0123456789"
+	payload := testWebhookPayload("fp-signed")
+
+	tests := []struct {
+		name           string
+		secrets        []string
+		signature      string
+		expectedStatus int
+	}{
+		{name: "Valid signature", secrets: []string{secret}, signature: validation.Sign(secret, payload), expectedStatus: http.StatusOK},
+		{name: "Valid prefixed signature", secrets: []string{secret}, signature: "sha256=" + validation.Sign(secret, payload), expectedStatus: http.StatusOK},
+		{name: "Signature for another body", secrets: []string{secret}, signature: validation.Sign(secret, []byte("{}")), expectedStatus: http.StatusUnauthorized},
+		{name: "Malformed signature", secrets: []string{secret}, signature: "not-hex", expectedStatus: http.StatusUnauthorized},
+		{name: "Missing signature", secrets: []string{secret}, expectedStatus: http.StatusUnauthorized},
+		{name: "Verification disabled", expectedStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, store := setupTestHandler(t)
+			handler.ConfigureWebhook(DefaultWebhookPath, tt.secrets)
+
+			req := httptest.NewRequest(http.MethodPost, DefaultWebhookPath, bytes.NewReader(payload))
+			req.Header.Set("Content-Type", "application/json")
+			if tt.signature != "" {
+				req.Header.Set(validation.SignatureHeader, tt.signature)
+			}
+			w := httptest.NewRecorder()
+			handler.handleAlertmanagerWebhook(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d. Body: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			alerts, _ := store.ListAlerts(context.Background())
+			if stored := len(alerts) > 0; stored != (tt.expectedStatus == http.StatusOK) {
+				t.Errorf("Expected the alert to be stored only for accepted webhooks, got %d alerts", len(alerts))
+			}
+		})
+	}
+}
+
+func TestHandler_WebhookSecretRotation(t *testing.T) {
+	handler, _ := setupTestHandler(t)
+	handler.ConfigureWebhook("/hooks/am-7f3c", []string{"old-secret-0123456789", "new-secret-0123456789"})
+
+	mux := http.NewServeMux()
+	handler.RegisterRoutes(mux)
+
+	tests := []struct {
+		name           string
+		path           string
+		secret         string
+		expectedStatus int
+	}{
+		{name: "Signed with old secret", path: "/hooks/am-7f3c", secret: "old-secret-0123456789", expectedStatus: http.StatusOK},
+		{name: "Signed with new secret", path: "/hooks/am-7f3c", secret: "new-secret-0123456789", expectedStatus: http.StatusOK},
+		{name: "Signed with retired secret", path: "/hooks/am-7f3c", secret: "retired-secret-0123456", expectedStatus: http.StatusUnauthorized},
+		{name: "Unsigned", path: "/hooks/am-7f3c", expectedStatus: http.StatusUnauthorized},
+		{name: "Default path not served", path: DefaultWebhookPath, secret: "new-secret-0123456789", expectedStatus: http.StatusNotFound},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload := testWebhookPayload(fmt.Sprintf("fp-rotation-%d", i))
+			req := httptest.NewRequest(http.MethodPost, tt.path, bytes.NewReader(payload))
+			req.Header.Set("Content-Type", "application/json")
+			if tt.secret != "" {
+				req.Header.Set(validation.SignatureHeader, validation.Sign(tt.secret, payload))
+			}
+			w := httptest.NewRecorder()
+
+			mux.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d. Body: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+// unavailableAlertStore fails alert writes while down is set
+type unavailableAlertStore struct {
+	storage.Store
+	down atomic.Bool
+}
+
+func (s *unavailableAlertStore) CreateAlert(ctx context.Context, alert *models.Alert) error {
+	if s.down.Load() {
+		return fmt.Errorf("database unavailable")
+	}
+	return s.Store.CreateAlert(ctx, alert)
+}
+
+func TestHandler_WebhookSpooledDuringOutage(t *testing.T) {
+	ctx := context.Background()
+	handler, memoryStore := setupTestHandler(t)
+	store := &unavailableAlertStore{Store: memoryStore}
+	metricsService := services.NewMetricsService(prometheus.NewRegistry())
+	alertService := services.NewAlertService(store, services.NewIncidentService(store, metricsService), metricsService)
+	handler.alertService = alertService
+	handler.retryer = retry.NewRetryer(&retry.RetryPolicy{MaxAttempts: 1, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond, Multiplier: 1}, retry.DefaultIsRetryable)
+
+	send := func(fingerprint string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.handleAlertmanagerWebhook(w, httptest.NewRequest(http.MethodPost, DefaultWebhookPath, bytes.NewReader(testWebhookPayload(fingerprint))))
+		return w
+	}
+
+	store.down.Store(true)
+	if w := send("fp-spool-off"); w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected 500 without a spool, got %d", w.Code)
+	}
+
+	spool := services.NewAlertSpool(alertService, metricsService, handler.logger, 1, time.Hour)
+	handler.ConfigureAlertSpool(spool)
+	if w := send("fp-spool-1"); w.Code != http.StatusAccepted {
+		t.Fatalf("Expected 202 for a spooled webhook, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := send("fp-spool-2"); w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected 500 once the spool is full, got %d", w.Code)
+	}
+
+	store.down.Store(false)
+	if replayed, err := spool.Replay(ctx); err != nil || replayed != 1 {
+		t.Fatalf("Expected the spooled webhook to be replayed, got %d (err: %v)", replayed, err)
+	}
+	alerts, _ := memoryStore.ListAlerts(ctx)
+	if len(alerts) != 1 || alerts[0].Fingerprint != "fp-spool-1" {
+		t.Errorf("Expected only the spooled alert to be stored, got %+v", alerts)
+	}
+
+	// A failed delivery is processed when redelivered, a spooled one is not
+	if w := send("fp-spool-off"); strings.Contains(w.Body.String(), "Duplicate request") {
+		t.Errorf("Expected the failed delivery to be processed on redelivery, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := send("fp-spool-1"); !strings.Contains(w.Body.String(), "Duplicate request") {
+		t.Errorf("Expected the spooled delivery to be a duplicate, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestHandler_ReplayStoredWebhook(t *testing.T) {
+	handler, store := setupTestHandler(t)
+	handler.ConfigureWebhookPayloadStorage(24 * time.Hour)
+
+	payload := testWebhookPayload("fp-replay")
+	req := httptest.NewRequest(http.MethodPost, DefaultWebhookPath, bytes.NewReader(payload))
+	w := httptest.NewRecorder()
+	handler.handleAlertmanagerWebhook(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected webhook to be accepted, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// Simulate a processing bug that lost the incident and its alert
+	incidents, _ := store.ListIncidents(req.Context())
+	for _, incident := range incidents {
+		store.DeleteIncident(req.Context(), incident.ID)
+	}
+	alerts, _ := store.ListAlerts(req.Context())
+	for _, alert := range alerts {
+		store.DeleteAlert(req.Context(), alert.ID)
+	}
+
+	stored := &models.WebhookPayload{ID: "payload-1", Source: "alertmanager", Payload: string(payload), ReceivedAt: time.Now()}
+	if err := store.CreateWebhookPayload(req.Context(), stored); err != nil {
+		t.Fatalf("Failed to store payload: %v", err)
+	}
+
+	replay := func(id string) int {
+		req := httptest.NewRequest(http.MethodPost, "/api/webhooks/alertmanager/replay/"+id, nil)
+		w := httptest.NewRecorder()
+		handler.handleReplayWebhook(w, req)
+		return w.Code
+	}
+
+	// The payload was already marked as processed, so this only works if
+	// replay bypasses idempotency
+	if code := replay("payload-1"); code != http.StatusOK {
+		t.Fatalf("Expected replay to succeed, got %d", code)
+	}
+	incidents, err := store.ListIncidents(req.Context())
+	if err != nil || len(incidents) != 1 {
+		t.Fatalf("Expected replay to re-create 1 incident, got %d (err: %v)", len(incidents), err)
+	}
+	if incidents[0].Title != "HighCPU on fp-replay" {
+		t.Errorf("Unexpected incident title %q", incidents[0].Title)
+	}
+
+	if code := replay("payload-1"); code != http.StatusOK {
+		t.Fatalf("Expected second replay to succeed, got %d", code)
+	}
+	if incidents, _ := store.ListIncidents(req.Context()); len(incidents) != 1 {
+		t.Errorf("Expected replaying twice to keep 1 incident, got %d", len(incidents))
+	}
+
+	if code := replay("missing"); code != http.StatusNotFound {
+		t.Errorf("Expected 404 for unknown payload, got %d", code)
+	}
+
+	// The payload received by the webhook was stored as well
+	if deleted, _ := store.DeleteWebhookPayloadsBefore(req.Context(), time.Now().Add(time.Minute)); deleted != 2 {
+		t.Errorf("Expected 2 stored payloads, got %d", deleted)
+	}
+}
+
+func TestHandler_CommentRateLimit(t *testing.T) {
+	ctx := context.Background()
+	handler, _ := setupTestHandler(t)
+	handler.ConfigureCommentRateLimit(1, 3)
+
+	incident, err := handler.incidentService.CreateIncident(ctx, "Checkout errors", "", models.SeverityHigh, nil)
+	if err != nil {
+		t.Fatalf("Failed to create incident: %v", err)
+	}
+
+	postComment := func(userID string) int {
+		body := []byte(`{"content": "still investigating"}`)
+		req := httptest.NewRequest(http.MethodPost, "/api/incidents/"+incident.ID+"/comments", bytes.NewReader(body))
+		req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDContextKey, userID))
+		w := httptest.NewRecorder()
+		handler.handleAddIncidentComment(w, req, incident.ID)
+		return w.Code
+	}
+
+	for i := 0; i < 3; i++ {
+		if code := postComment("noisy-bot"); code != http.StatusCreated {
+			t.Fatalf("Expected comment %d within burst to be created, got %d", i+1, code)
+		}
+	}
+	if code := postComment("noisy-bot"); code != http.StatusTooManyRequests {
+		t.Errorf("Expected comment beyond burst to be throttled, got %d", code)
+	}
+
+	if code := postComment("on-call-engineer"); code != http.StatusCreated {
+		t.Errorf("Expected another user to be unaffected, got %d", code)
+	}
+
+	// Events generated by the system itself are never throttled
+	for i := 0; i < 5; i++ {
+		req := httptest.NewRequest(http.MethodPost, "/api/incidents/"+incident.ID+"/comments", bytes.NewReader([]byte(`{"content": "auto"}`)))
+		w := httptest.NewRecorder()
+		handler.handleAddIncidentComment(w, req, incident.ID)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected system comment %d to be created, got %d", i+1, w.Code)
+		}
+	}
+}
+
+func TestHandler_CreateIncidentTextLimits(t *testing.T) {
+	handler, _ := setupTestHandler(t)
+	handler.incidentService.SetTextLimits(20, 50)
+
+	createIncident := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/incidents", bytes.NewReader([]byte(body)))
+		w := httptest.NewRecorder()
+		handler.handleIncidents(w, req)
+		return w
+	}
+
+	tests := []struct {
+		name     string
+		body     string
+		expected int
+	}{
+		{name: "Within limits", body: `{"title": "Checkout down", "description": "502s", "severity": "high"}`, expected: http.StatusCreated},
+		{name: "Title too long", body: `{"title": "Checkout is returning errors", "severity": "high"}`, expected: http.StatusBadRequest},
+		{name: "Description too long", body: fmt.Sprintf(`{"title": "Checkout down", "description": %q}`, strings.Repeat("x", 51)), expected: http.StatusBadRequest},
+		{name: "Blank title", body: `{"title": "  \u0007 ", "severity": "low"}`, expected: http.StatusBadRequest},
+		{name: "Unknown severity", body: `{"title": "Checkout down", "severity": "urgent"}`, expected: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := createIncident(tt.body); w.Code != tt.expected {
+				t.Errorf("Expected status %d, got %d: %s", tt.expected, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	w := createIncident(`{"title": "Disk\tfull\u001b[31m", "description": "line one\r\nline two\u0000"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var incident models.Incident
+	if err := json.NewDecoder(w.Body).Decode(&incident); err != nil {
+		t.Fatalf("Failed to decode incident: %v", err)
+	}
+	if incident.Title != "Disk full[31m" {
+		t.Errorf("Expected control characters to be stripped from the title, got %q", incident.Title)
+	}
+	if incident.Description != "line one\nline two" {
+		t.Errorf("Expected control characters to be stripped from the description, got %q", incident.Description)
+	}
+	if incident.Severity != models.SeverityMedium {
+		t.Errorf("Expected default severity medium, got %s", incident.Severity)
+	}
+}
+
+func TestHandler_ResolveIncidentRequiresNote(t *testing.T) {
+	ctx := context.Background()
+	handler, _ := setupTestHandler(t)
+	handler.incidentService.SetRequireResolutionNote(true)
+
+	incident, err := handler.incidentService.CreateIncident(ctx, "Checkout down", "", models.SeverityHigh, []string{})
+	if err != nil {
+		t.Fatalf("Failed to create incident: %v", err)
+	}
+
+	resolve := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, "/api/incidents/"+incident.ID+"/resolve", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		handler.handleIncidents(w, req)
+		return w
+	}
+
+	if w := resolve(""); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 without a body, got %d", w.Code)
+	}
+	if w := resolve(`{"note": ""}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an empty note, got %d", w.Code)
+	}
+	if w := resolve(`{"note": "Restarted the payment gateway"}`); w.Code != http.StatusOK {
+		t.Errorf("Expected status 200 with a note, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestHandler_ReopenIncident(t *testing.T) {
+	ctx := context.Background()
+	handler, store := setupTestHandler(t)
+
+	incident, err := handler.incidentService.CreateIncident(ctx, "Payments timing out", "", models.SeverityHigh, nil)
+	if err != nil {
+		t.Fatalf("Failed to create incident: %v", err)
+	}
+
+	reopen := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, "/api/incidents/"+incident.ID+"/reopen", nil)
+		req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDContextKey, "user-7"))
+		w := httptest.NewRecorder()
+		handler.handleIncidents(w, req)
+		return w
+	}
+
+	if w := reopen(); w.Code != http.StatusConflict {
+		t.Errorf("Expected status 409 when reopening an open incident, got %d", w.Code)
+	}
+
+	if err := handler.incidentService.ResolveIncident(ctx, incident.ID, "user-1", ""); err != nil {
+		t.Fatalf("Failed to resolve incident: %v", err)
+	}
+	w := reopen()
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var reopened models.Incident
+	if err := json.NewDecoder(w.Body).Decode(&reopened); err != nil {
+		t.Fatalf("Failed to decode incident: %v", err)
+	}
+	if reopened.Status != models.IncidentStatusOpen || reopened.ResolvedAt != nil {
+		t.Errorf("Expected an open incident without a resolution time, got %s (resolved at %v)", reopened.Status, reopened.ResolvedAt)
+	}
+
+	timeline, err := store.GetIncidentTimeline(ctx, incident.ID)
+	if err != nil {
+		t.Fatalf("Failed to get timeline: %v", err)
+	}
+	last := timeline[len(timeline)-1]
+	if last.CommentType != models.CommentTypeStatusChange || last.UserID == nil || *last.UserID != "user-7" {
+		t.Errorf("Expected a status change entry by user-7, got %+v", last)
+	}
+
+	req := httptest.NewRequest(http.MethodPut, "/api/incidents/missing/reopen", nil)
+	w = httptest.NewRecorder()
+	handler.handleIncidents(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for an unknown incident, got %d", w.Code)
+	}
+}
+
+// testToken returns an access token for a user with a single role
+func testToken(t *testing.T, handler *Handler, userID, role string) string {
+	t.Helper()
+	auth, err := handler.authService.GenerateTokens(&models.User{ID: userID, Username: userID, Roles: []*models.Role{{Name: role}}})
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+	return auth.Token
+}
+
+// createUserWithRole stores an active user holding one role
+func createUserWithRole(t *testing.T, store storage.Store, userID, roleID string) {
+	ctx := context.Background()
+	t.Helper()
+	user := &models.User{ID: userID, Username: userID, Email: userID + "@example.com", IsActive: true}
+	if err := store.CreateUser(ctx, user); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	if err := store.AssignRoleToUser(ctx, userID, roleID); err != nil {
+		t.Fatalf("Failed to assign role: %v", err)
+	}
+}
+
+func TestHandler_IncidentRouting(t *testing.T) {
+	ctx := context.Background()
+	handler, store := setupTestHandler(t)
+	mux := http.NewServeMux()
+	handler.RegisterRoutes(mux)
+	token := testToken(t, handler, "user-1", "admin")
+	createUserWithRole(t, store, "user-1", "admin-role-id")
+
+	incident, err := handler.incidentService.CreateIncident(ctx, "Queue backlog", "", models.SeverityHigh, nil)
+	if err != nil {
+		t.Fatalf("Failed to create incident: %v", err)
+	}
+
+	request := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		return w
+	}
+	decode := func(w *httptest.ResponseRecorder, v interface{}) {
+		t.Helper()
+		if err := json.NewDecoder(w.Body).Decode(v); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+	}
+
+	w := request(http.MethodGet, "/api/incidents/"+incident.ID, "")
+	var got models.Incident
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET /api/incidents/{id}: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	decode(w, &got)
+	if got.ID != incident.ID {
+		t.Errorf("GET /api/incidents/{id}: expected incident %s, got %q", incident.ID, got.ID)
+	}
+
+	w = request(http.MethodPut, "/api/incidents/"+incident.ID+"/acknowledge", "{}")
+	if w.Code != http.StatusOK {
+		t.Fatalf("PUT /api/incidents/{id}/acknowledge: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	decode(w, &got)
+	if got.Status != models.IncidentStatusAcknowledged {
+		t.Errorf("PUT /api/incidents/{id}/acknowledge: expected an acknowledged incident, got %s", got.Status)
+	}
+
+	w = request(http.MethodPost, "/api/incidents/"+incident.ID+"/comments", `{"content": "Draining the queue"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("POST /api/incidents/{id}/comments: expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var comment models.IncidentComment
+	decode(w, &comment)
+	if comment.Content != "Draining the queue" || comment.IncidentID != incident.ID {
+		t.Errorf("POST /api/incidents/{id}/comments: expected the new comment, got %+v", comment)
+	}
+
+	// Routes registered separately are not swallowed by the incident router
+	if w := request(http.MethodGet, "/api/incidents/needs-attention", ""); w.Code != http.StatusOK || strings.Contains(w.Body.String(), "Incident not found") {
+		t.Errorf("GET /api/incidents/needs-attention: expected the attention list, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := request(http.MethodGet, "/api/incidents", ""); w.Code != http.StatusOK {
+		t.Errorf("GET /api/incidents: expected 200, got %d", w.Code)
+	}
+
+	if w := request(http.MethodGet, "/api/incidents/"+incident.ID+"/comments/extra/segments", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a path nested too deeply, got %d", w.Code)
+	}
+	if w := request(http.MethodPut, "/api/incidents/"+incident.ID+"/explode", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an unknown action, got %d", w.Code)
+	}
+	if w := request(http.MethodGet, "/api/incidents/"+incident.ID+"/resolve", ""); w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected 405 for GET on an action, got %d", w.Code)
+	}
+}
+
+func TestHandler_IncidentPermissions(t *testing.T) {
+	ctx := context.Background()
+	handler, store := setupTestHandler(t)
+	mux := http.NewServeMux()
+	handler.RegisterRoutes(mux)
+
+	resolvePermission := &models.Permission{Name: "incidents.resolve", Resource: "incidents", Action: "resolve"}
+	if err := store.CreatePermission(ctx, resolvePermission); err != nil {
+		t.Fatalf("Failed to create permission: %v", err)
+	}
+	responderRole := &models.Role{Name: "responder"}
+	if err := store.CreateRole(ctx, responderRole); err != nil {
+		t.Fatalf("Failed to create role: %v", err)
+	}
+	if err := store.AssignPermissionToRole(ctx, responderRole.ID, resolvePermission.ID); err != nil {
+		t.Fatalf("Failed to assign permission: %v", err)
+	}
+	for userID, roleID := range map[string]string{"viewer-1": "viewer-role-id", "admin-1": "admin-role-id", "responder-1": responderRole.ID} {
+		createUserWithRole(t, store, userID, roleID)
+	}
+
+	request := func(method, path, userID, role, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+testToken(t, handler, userID, role))
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		return w
+	}
+	newIncident := func() string {
+		t.Helper()
+		incident, err := handler.incidentService.CreateIncident(ctx, "Search latency", "", models.SeverityMedium, nil)
+		if err != nil {
+			t.Fatalf("Failed to create incident: %v", err)
+		}
+		return incident.ID
+	}
+
+	incidentID := newIncident()
+	if w := request(http.MethodPut, "/api/incidents/"+incidentID+"/resolve", "viewer-1", "viewer", ""); w.Code != http.StatusForbidden {
+		t.Errorf("Expected a viewer to be denied resolving, got %d", w.Code)
+	}
+	// The token's roles are not trusted; only the stored roles count
+	if w := request(http.MethodPut, "/api/incidents/"+incidentID+"/resolve", "viewer-1", "admin", ""); w.Code != http.StatusForbidden {
+		t.Errorf("Expected a viewer with a forged admin role to be denied, got %d", w.Code)
+	}
+	if stored, _ := store.GetIncident(ctx, incidentID); stored.Status != models.IncidentStatusOpen {
+		t.Errorf("Expected the incident to stay open, got %s", stored.Status)
+	}
+	if w := request(http.MethodGet, "/api/incidents/"+incidentID, "viewer-1", "viewer", ""); w.Code != http.StatusOK {
+		t.Errorf("Expected a viewer to still read the incident, got %d", w.Code)
+	}
+
+	if w := request(http.MethodPut, "/api/incidents/"+incidentID+"/resolve", "admin-1", "admin", ""); w.Code != http.StatusOK {
+		t.Errorf("Expected an admin to resolve the incident, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := request(http.MethodPut, "/api/incidents/"+newIncident()+"/resolve", "responder-1", "responder", ""); w.Code != http.StatusOK {
+		t.Errorf("Expected the resolve permission to allow resolving, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := request(http.MethodPut, "/api/incidents/"+newIncident()+"/acknowledge", "responder-1", "responder", "{}"); w.Code != http.StatusForbidden {
+		t.Errorf("Expected acknowledging to need its own permission, got %d", w.Code)
+	}
+
+	deleted := newIncident()
+	if w := request(http.MethodDelete, "/api/incidents/"+deleted, "viewer-1", "viewer", ""); w.Code != http.StatusForbidden {
+		t.Errorf("Expected a viewer to be denied deleting, got %d", w.Code)
+	}
+	if w := request(http.MethodDelete, "/api/incidents/"+deleted, "admin-1", "admin", ""); w.Code != http.StatusNoContent {
+		t.Errorf("Expected an admin to delete the incident, got %d", w.Code)
+	}
+	if w := request(http.MethodPost, "/api/templates", "viewer-1", "viewer", `{"name": "db", "title_template": "DB down"}`); w.Code != http.StatusForbidden {
+		t.Errorf("Expected a viewer to be denied creating templates, got %d", w.Code)
+	}
+	if w := request(http.MethodGet, "/api/templates", "viewer-1", "viewer", ""); w.Code != http.StatusOK {
+		t.Errorf("Expected a viewer to list templates, got %d", w.Code)
+	}
+}
+
+func TestHandler_AttributesChangesToAuthenticatedUser(t *testing.T) {
+	ctx := context.Background()
+	handler, store := setupTestHandler(t)
+	mux := http.NewServeMux()
+	handler.RegisterRoutes(mux)
+	token := testToken(t, handler, "user-a", "responder")
+
+	incident, err := handler.incidentService.CreateIncident(ctx, "Login failures", "", models.SeverityHigh, nil)
+	if err != nil {
+		t.Fatalf("Failed to create incident: %v", err)
+	}
+
+	post := func(path, body string) {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		if w.Code != http.StatusOK && w.Code != http.StatusCreated {
+			t.Fatalf("POST %s: expected success, got %d: %s", path, w.Code, w.Body.String())
+		}
+	}
+
+	for _, body := range []string{
+		`{"content": "No user given"}`,
+		`{"content": "Pretending to be B", "user_id": "user-b"}`,
+		`{"content": "Pretending to be the system", "user_id": "system"}`,
+	} {
+		post("/api/incidents/"+incident.ID+"/comments", body)
+	}
+	post("/api/incidents/"+incident.ID+"/tags", `{"tags": [{"name": "team", "value": "identity"}], "user_id": "user-b"}`)
+
+	comments, err := store.GetIncidentComments(ctx, incident.ID)
+	if err != nil {
+		t.Fatalf("Failed to get comments: %v", err)
+	}
+	userComments := 0
+	for _, comment := range comments {
+		if comment.CommentType != models.CommentTypeComment {
+			continue
+		}
+		userComments++
+		if comment.UserID == nil || *comment.UserID != "user-a" {
+			t.Errorf("Expected %q to be attributed to user-a, got %v", comment.Content, comment.UserID)
+		}
+	}
+	if userComments != 3 {
+		t.Errorf("Expected 3 comments, got %d", userComments)
+	}
+
+	tags, _ := store.GetIncidentTags(ctx, incident.ID)
+	if len(tags) != 1 || tags[0].CreatedBy == nil || *tags[0].CreatedBy != "user-a" {
+		t.Errorf("Expected the tag to be attributed to user-a, got %+v", tags)
+	}
+}
+
+func TestHandler_RoleRateLimits(t *testing.T) {
+	handler, _ := setupTestHandler(t)
+	handler.ConfigureRoleRateLimits(map[string]middleware.RoleRateLimit{"viewer": {PerMinute: 1, Burst: 3}})
+	mux := http.NewServeMux()
+	handler.RegisterRoutes(mux)
+
+	token := func(id, role string) string { return testToken(t, handler, id, role) }
+	// requestsUntilThrottled returns how many requests succeed before a 429,
+	// giving up after limit requests
+	requestsUntilThrottled := func(token string, limit int) (int, *httptest.ResponseRecorder) {
+		for i := 0; i < limit; i++ {
+			req := httptest.NewRequest(http.MethodGet, "/api/alerts", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, req)
+			if w.Code == http.StatusTooManyRequests {
+				return i, w
+			}
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+			}
+		}
+		return limit, nil
+	}
+
+	allowed, throttled := requestsUntilThrottled(token("viewer-1", "viewer"), 10)
+	if allowed != 3 || throttled == nil {
+		t.Fatalf("Expected the viewer to be throttled after 3 requests, got %d", allowed)
+	}
+	if retryAfter, err := strconv.Atoi(throttled.Header().Get("Retry-After")); err != nil || retryAfter < 1 {
+		t.Errorf("Expected a positive Retry-After, got %q", throttled.Header().Get("Retry-After"))
+	}
+
+	// Each user has their own budget, and roles without a limit are not throttled
+	if allowed, _ := requestsUntilThrottled(token("viewer-2", "viewer"), 3); allowed != 3 {
+		t.Errorf("Expected another viewer to have a separate budget, throttled after %d", allowed)
+	}
+	if allowed, _ := requestsUntilThrottled(token("admin-1", "admin"), 10); allowed != 10 {
+		t.Errorf("Expected the admin not to be throttled, throttled after %d", allowed)
+	}
+}
+
+func TestHandler_MaxBodyBytes(t *testing.T) {
+	handler, _ := setupTestHandler(t)
+	mux := http.NewServeMux()
+	handler.RegisterRoutes(mux)
+	token := testToken(t, handler, "responder-1", "responder")
+
+	post := func(h http.Handler, path string, body []byte) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	limited := middleware.MaxBodyBytes(1024, 4096)(mux)
+	oversized := []byte(fmt.Sprintf(`{"title": "Checkout down", "description": %q, "severity": "high"}`, strings.Repeat("x", 2048)))
+	if w := post(limited, "/api/incidents", oversized); w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected status 413 for an oversized body, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := post(limited, "/api/incidents", []byte(`{"title": "Checkout down", "severity": "high"}`)); w.Code != http.StatusCreated {
+		t.Errorf("Expected status 201 for a body within the limit, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// Claiming to be multipart only raises the limit to the multipart one
+	req := httptest.NewRequest(http.MethodPost, "/api/incidents", bytes.NewReader(bytes.Repeat([]byte(" "), 8192)))
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "multipart/form-data; boundary=x")
+	w := httptest.NewRecorder()
+	limited.ServeHTTP(w, req)
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected status 413 for a multipart body over the multipart limit, got %d", w.Code)
+	}
+
+	// The webhook keeps its stricter limit under a more generous global one
+	generous := middleware.MaxBodyBytes(4<<20, 0)(mux)
+	if w := post(generous, DefaultWebhookPath, bytes.Repeat([]byte(" "), 2<<20)); w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected status 413 for a webhook over its own limit, got %d", w.Code)
+	}
+}
+
+func TestHandler_DefaultBodyLimitFitsInlineImage(t *testing.T) {
+	ctx := context.Background()
+	handler, store := setupTestHandler(t)
+	mux := http.NewServeMux()
+	handler.RegisterRoutes(mux)
+	createUserWithRole(t, store, "admin-1", "admin-role-id")
+	token := testToken(t, handler, "admin-1", "admin")
+	handler.incidentService.SetInlineImageStorage(t.TempDir(), 0)
+
+	incident, err := handler.incidentService.CreateIncident(ctx, "Checkout errors", "", models.SeverityHigh, nil)
+	if err != nil {
+		t.Fatalf("Failed to create incident: %v", err)
+	}
+
+	// A screenshot at the inline image limit, pasted into a comment
+	image := base64.StdEncoding.EncodeToString(make([]byte, services.DefaultMaxInlineImageBytes))
+	body, _ := json.Marshal(map[string]string{"content": "Dashboard: ![](data:image/png;base64," + image + ")"})
+	cfg := config.LoadConfig()
+	limited := middleware.MaxBodyBytes(cfg.MaxBodyBytes, 0)(mux)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/incidents/"+incident.ID+"/comments", bytes.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	limited.ServeHTTP(w, req)
+	if w.Code != http.StatusCreated {
+		t.Errorf("Expected the comment to be accepted under the default body limit, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestHandler_APIRateLimits(t *testing.T) {
+	handler, _ := setupTestHandler(t)
+	trustedProxies, err := ratelimit.ParseTrustedProxies([]string{"10.0.0.0/8"})
+	if err != nil {
+		t.Fatalf("Failed to parse trusted proxies: %v", err)
+	}
+	// 1200 per minute refills a token every 50ms
+	handler.ConfigureAPIRateLimits(
+		middleware.RoleRateLimit{PerMinute: 1200, Burst: 3},
+		middleware.RoleRateLimit{PerMinute: 1200, Burst: 2},
+		trustedProxies,
+	)
+	mux := http.NewServeMux()
+	handler.RegisterRoutes(mux)
+
+	listAlerts := func(token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/alerts", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		return w
+	}
+	loginVia := func(ip, forwardedFor string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/auth/login", strings.NewReader(`{"username": "nobody", "password": "wrong"}`))
+		req.RemoteAddr = ip + ":40000"
+		if forwardedFor != "" {
+			req.Header.Set("X-Forwarded-For", forwardedFor)
+		}
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		return w
+	}
+	login := func(ip string) *httptest.ResponseRecorder { return loginVia(ip, "") }
+
+	// A burst beyond the user's budget is throttled
+	token := testToken(t, handler, "responder-1", "responder")
+	for i := 0; i < 3; i++ {
+		if w := listAlerts(token); w.Code != http.StatusOK {
+			t.Fatalf("Request %d: expected 200, got %d: %s", i+1, w.Code, w.Body.String())
+		}
+	}
+	throttled := listAlerts(token)
+	if throttled.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected the 4th request to be throttled, got %d", throttled.Code)
+	}
+	if retryAfter, err := strconv.Atoi(throttled.Header().Get("Retry-After")); err != nil || retryAfter < 1 {
+		t.Errorf("Expected a positive Retry-After, got %q", throttled.Header().Get("Retry-After"))
+	}
+	if w := listAlerts(testToken(t, handler, "responder-2", "responder")); w.Code != http.StatusOK {
+		t.Errorf("Expected another user to have a separate budget, got %d", w.Code)
+	}
+
+	// Anonymous requests are counted per IP
+	for i := 0; i < 2; i++ {
+		if w := login("203.0.113.7"); w.Code == http.StatusTooManyRequests {
+			t.Fatalf("Login %d: expected to be allowed, got 429", i+1)
+		}
+	}
+	if w := login("203.0.113.7"); w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") == "" {
+		t.Errorf("Expected the 3rd login from one IP to be throttled with Retry-After, got %d", w.Code)
+	}
+	if w := login("198.51.100.4"); w.Code == http.StatusTooManyRequests {
+		t.Error("Expected another IP to have a separate budget")
+	}
+
+	// Forwarding headers from untrusted clients are ignored, so rotating
+	// them does not buy a fresh budget
+	for i := 0; i < 2; i++ {
+		loginVia("192.0.2.9", fmt.Sprintf("198.18.0.%d", i))
+	}
+	if w := loginVia("192.0.2.9", "198.18.0.99"); w.Code != http.StatusTooManyRequests {
+		t.Errorf("Expected a client rotating X-Forwarded-For to be throttled, got %d", w.Code)
+	}
+
+	// Behind a trusted proxy each forwarded client has its own budget, and
+	// entries the client prepends itself are skipped
+	for i := 0; i < 2; i++ {
+		if w := loginVia("10.0.0.1", fmt.Sprintf("198.18.1.%d, 192.0.2.50", i)); w.Code == http.StatusTooManyRequests {
+			t.Fatalf("Login %d through the proxy: expected to be allowed, got 429", i+1)
+		}
+	}
+	if w := loginVia("10.0.0.1", "198.18.1.99, 192.0.2.50"); w.Code != http.StatusTooManyRequests {
+		t.Errorf("Expected the forwarded client to be throttled despite prepended entries, got %d", w.Code)
+	}
+	if w := loginVia("10.0.0.1", "192.0.2.51"); w.Code == http.StatusTooManyRequests {
+		t.Error("Expected another client behind the proxy to have a separate budget")
+	}
+
+	// Budgets refill over time
+	time.Sleep(120 * time.Millisecond)
+	if w := listAlerts(token); w.Code != http.StatusOK {
+		t.Errorf("Expected the user to recover after waiting, got %d", w.Code)
+	}
+	if w := login("203.0.113.7"); w.Code == http.StatusTooManyRequests {
+		t.Error("Expected the IP to recover after waiting")
+	}
+}
+
+func TestHandler_IncidentFull(t *testing.T) {
+	ctx := context.Background()
+	handler, store := setupTestHandler(t)
+
+	w := httptest.NewRecorder()
+	handler.handleAlertmanagerWebhook(w, httptest.NewRequest(http.MethodPost, DefaultWebhookPath, bytes.NewReader(testWebhookPayload("fp-full"))))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Failed to process webhook: %d %s", w.Code, w.Body.String())
+	}
+	incidents, _ := store.ListIncidents(ctx)
+	if len(incidents) != 1 {
+		t.Fatalf("Expected the webhook to open 1 incident, got %d", len(incidents))
+	}
+	incident := incidents[0]
+
+	if err := handler.incidentService.AddTags(ctx, incident.ID, "user-1", []models.TemplateTag{{Name: "team", Value: "infra"}}); err != nil {
+		t.Fatalf("Failed to add tag: %v", err)
+	}
+	attachment := &models.IncidentAttachment{IncidentID: incident.ID, FileName: "cpu.png", OriginalName: "cpu.png", AttachmentType: models.AttachmentTypeScreenshot}
+	if err := handler.incidentService.AttachFile(ctx, attachment, "user-1"); err != nil {
+		t.Fatalf("Failed to attach file: %v", err)
+	}
+
+	full := func(query string) (*httptest.ResponseRecorder, map[string]json.RawMessage) {
+		w := httptest.NewRecorder()
+		handler.handleIncidentFull(w, httptest.NewRequest(http.MethodGet, "/api/incidents/"+incident.ID+"/full"+query, nil))
+		var response map[string]json.RawMessage
+		if w.Code == http.StatusOK {
+			if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+		}
+		return w, response
+	}
+
+	w, response := full("")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var (
+		got         models.Incident
+		timeline    []*models.IncidentComment
+		tags        []*models.IncidentTag
+		attachments []*models.IncidentAttachment
+		alerts      []*models.Alert
+	)
+	for section, target := range map[string]interface{}{"incident": &got, "timeline": &timeline, "tags": &tags, "attachments": &attachments, "alerts": &alerts} {
+		if err := json.Unmarshal(response[section], target); err != nil {
+			t.Fatalf("Failed to decode section %q: %v", section, err)
+		}
+	}
+	if got.ID != incident.ID {
+		t.Errorf("Expected incident %s, got %s", incident.ID, got.ID)
+	}
+	if len(timeline) == 0 {
+		t.Error("Expected timeline entries")
+	}
+	if len(tags) != 1 || tags[0].TagName != "team" {
+		t.Errorf("Expected the team tag, got %+v", tags)
+	}
+	if len(attachments) != 1 || attachments[0].OriginalName != "cpu.png" {
+		t.Errorf("Expected the screenshot attachment, got %+v", attachments)
+	}
+	if len(alerts) != 1 || alerts[0].Fingerprint != "fp-full" || alerts[0].Labels["alertname"] != "HighCPU" {
+		t.Errorf("Expected the full alert object, got %+v", alerts)
+	}
+
+	_, response = full("?include=alerts,tags")
+	if len(response) != 3 || response["alerts"] == nil || response["tags"] == nil || response["incident"] == nil {
+		t.Errorf("Expected only the incident, alerts and tags, got sections %v", sectionNames(response))
+	}
+
+	if w, _ := full("?include=comments"); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an unknown section, got %d", w.Code)
+	}
+	w = httptest.NewRecorder()
+	handler.handleIncidentFull(w, httptest.NewRequest(http.MethodGet, "/api/incidents/missing/full", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown incident, got %d", w.Code)
+	}
+}
+
+func sectionNames(m map[string]json.RawMessage) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	return names
+}
+
+func TestHandler_BulkOperations(t *testing.T) {
+	ctx := context.Background()
+	handler, store := setupTestHandler(t)
+
+	incident, err := handler.incidentService.CreateIncident(ctx, "Cache misses", "", models.SeverityMedium, nil)
+	if err != nil {
+		t.Fatalf("Failed to create incident: %v", err)
+	}
+
+	mux := http.NewServeMux()
+	handler.RegisterRoutes(mux)
+	token := testToken(t, handler, "admin-1", "admin")
+	createUserWithRole(t, store, "admin-1", "admin-role-id")
+	bulk := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/incidents/bulk", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		return w
+	}
+
+	w := bulk(fmt.Sprintf(`{"incident_ids":[%q,"missing"],"operation":"add_tags","parameters":{"tags":[{"name":"team","value":"cache"}]}}`, incident.ID))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200 for a partially failing batch, got %d: %s", w.Code, w.Body.String())
+	}
+	var response models.BulkOperationResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if response.ProcessedCount != 1 || response.FailedCount != 1 || response.Failures[0].IncidentID != "missing" {
+		t.Errorf("Expected the missing incident reported as the only failure, got %+v", response)
+	}
+	if tags, _ := store.GetIncidentTags(ctx, incident.ID); len(tags) != 1 {
+		t.Errorf("Expected the existing incident to be tagged, got %d tags", len(tags))
+	}
+
+	for body, want := range map[string]int{
+		fmt.Sprintf(`{"incident_ids":[%q],"operation":"assign","parameters":{}}`, incident.ID):                       http.StatusBadRequest,
+		fmt.Sprintf(`{"incident_ids":[%q],"operation":"remove_tags","parameters":{"tags":"team"}}`, incident.ID):     http.StatusBadRequest,
+		fmt.Sprintf(`{"incident_ids":[%q],"operation":"remove_tags","parameters":{"tags":["team"]}}`, incident.ID):   http.StatusOK,
+		fmt.Sprintf(`{"incident_ids":[%q],"operation":"resolve","parameters":{"note":"Warmed cache"}}`, incident.ID): http.StatusOK,
+	} {
+		if w := bulk(body); w.Code != want {
+			t.Errorf("Expected %d for %s, got %d: %s", want, body, w.Code, w.Body.String())
+		}
+	}
+	if resolved, _ := store.GetIncident(ctx, incident.ID); resolved.Status != models.IncidentStatusResolved {
+		t.Errorf("Expected the incident to be resolved, got %s", resolved.Status)
+	}
+}
+
+func TestHandler_BulkOperationsRequirePermission(t *testing.T) {
+	ctx := context.Background()
+	handler, store := setupTestHandler(t)
+	mux := http.NewServeMux()
+	handler.RegisterRoutes(mux)
+	token := testToken(t, handler, "viewer-1", "viewer")
+	createUserWithRole(t, store, "viewer-1", "viewer-role-id")
+
+	incident, err := handler.incidentService.CreateIncident(ctx, "Cache misses", "", models.SeverityMedium, nil)
+	if err != nil {
+		t.Fatalf("Failed to create incident: %v", err)
+	}
+
+	for _, body := range []string{
+		fmt.Sprintf(`{"incident_ids":[%q],"operation":"acknowledge","parameters":{}}`, incident.ID),
+		fmt.Sprintf(`{"incident_ids":[%q],"operation":"resolve","parameters":{}}`, incident.ID),
+		fmt.Sprintf(`{"incident_ids":[%q],"operation":"assign","parameters":{"assignee_id":"viewer-1"}}`, incident.ID),
+		fmt.Sprintf(`{"incident_ids":[%q],"operation":"update_status","parameters":{"status":"resolved"}}`, incident.ID),
+		fmt.Sprintf(`{"incident_ids":[%q],"operation":"add_tags","parameters":{"tags":[{"name":"team"}]}}`, incident.ID),
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/api/incidents/bulk", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		if w.Code != http.StatusForbidden {
+			t.Errorf("Expected 403 for a viewer's %s, got %d: %s", body, w.Code, w.Body.String())
+		}
+	}
+
+	unchanged, err := store.GetIncident(ctx, incident.ID)
+	if err != nil {
+		t.Fatalf("Failed to get incident: %v", err)
+	}
+	if unchanged.Status != models.IncidentStatusOpen || unchanged.AssigneeID != "" {
+		t.Errorf("Expected the incident to be untouched, got %s assigned to %q", unchanged.Status, unchanged.AssigneeID)
+	}
+	if tags, _ := store.GetIncidentTags(ctx, incident.ID); len(tags) != 0 {
+		t.Errorf("Expected no tags, got %d", len(tags))
+	}
+}
+
+func TestHandler_BulkNotify(t *testing.T) {
+	ctx := context.Background()
+	handler, store := setupTestHandler(t)
+
+	var deliveries int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&deliveries, 1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	channel := &models.NotificationChannel{ID: "ops-webhook", Name: "Ops webhook", Type: "webhook", Enabled: true, Config: map[string]string{"url": server.URL}}
+	if err := store.CreateNotificationChannel(ctx, channel); err != nil {
+		t.Fatalf("Failed to create channel: %v", err)
+	}
+
+	var ids []string
+	for i := 0; i < 3; i++ {
+		incident, err := handler.incidentService.CreateIncident(ctx, fmt.Sprintf("Incident %d", i), "", models.SeverityHigh, []string{})
+		if err != nil {
+			t.Fatalf("Failed to create incident: %v", err)
+		}
+		ids = append(ids, incident.ID)
+	}
+
+	body, _ := json.Marshal(models.BulkNotifyRequest{IncidentIDs: append(ids, "missing-incident")})
+	req := httptest.NewRequest(http.MethodPost, "/api/incidents/notify", bytes.NewReader(body))
+	w := httptest.NewRecorder()
+	handler.handleBulkNotify(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if got := atomic.LoadInt32(&deliveries); got != 3 {
+		t.Errorf("Expected 3 delivery attempts, got %d", got)
+	}
+
+	var response models.BulkNotifyResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if response.SentCount != 3 || response.FailedCount != 1 || len(response.Results) != 4 {
+		t.Fatalf("Unexpected aggregate result: %+v", response)
+	}
+	for i, result := range response.Results[:3] {
+		if result.IncidentID != ids[i] || result.Status != "sent" {
+			t.Errorf("Expected incident %s to be sent, got %+v", ids[i], result)
+		}
+	}
+	if last := response.Results[3]; last.Status != "failed" || last.Error == "" {
+		t.Errorf("Expected the unknown incident to fail with an error, got %+v", last)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/api/incidents/notify", strings.NewReader(`{"incident_ids": ["x"], "notification_type": "incident_paged"}`))
+	w = httptest.NewRecorder()
+	handler.handleBulkNotify(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an unknown notification type, got %d", w.Code)
+	}
+}
+
+func TestHandler_StrictJSON(t *testing.T) {
+	handler, _ := setupTestHandler(t)
+	t.Cleanup(func() { SetStrictJSON(false) })
+
+	createIncident := func() *httptest.ResponseRecorder {
+		body := `{"title": "Checkout down", "severity": "high", "assigneeId": "user-1"}`
+		req := httptest.NewRequest(http.MethodPost, "/api/incidents", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		handler.handleIncidents(w, req)
+		return w
+	}
+
+	if w := createIncident(); w.Code != http.StatusCreated {
+		t.Errorf("Expected unknown fields to be tolerated by default, got %d: %s", w.Code, w.Body.String())
+	}
+
+	SetStrictJSON(true)
+	w := createIncident()
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status 400 in strict mode, got %d", w.Code)
+	}
+	var response map[string]interface{}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if message, _ := response["error"].(string); !strings.Contains(message, `"assigneeId"`) {
+		t.Errorf("Expected the error to name the unknown field, got %q", message)
+	}
+}
+
+func TestHandler_CommentDraft(t *testing.T) {
+	ctx := context.Background()
+	handler, _ := setupTestHandler(t)
+
+	incident, err := handler.incidentService.CreateIncident(ctx, "Checkout errors", "", models.SeverityHigh, nil)
+	if err != nil {
+		t.Fatalf("Failed to create incident: %v", err)
+	}
+
+	request := func(method, path, userID, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDContextKey, userID))
+		w := httptest.NewRecorder()
+		if strings.HasSuffix(path, "/comment-draft") {
+			handler.handleIncidentCommentDraft(w, req)
+		} else {
+			handler.handleAddIncidentComment(w, req, incident.ID)
+		}
+		return w
+	}
+	draftPath := "/api/incidents/" + incident.ID + "/comment-draft"
+
+	if w := request(http.MethodGet, draftPath, "user-1", ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 before a draft is saved, got %d", w.Code)
+	}
+
+	if w := request(http.MethodPut, draftPath, "user-1", `{"content": "Rolled back, watching error rates"}`); w.Code != http.StatusOK {
+		t.Fatalf("Expected draft to be saved, got %d: %s", w.Code, w.Body.String())
+	}
+	w := request(http.MethodGet, draftPath, "user-1", "")
+	var draft models.CommentDraft
+	if err := json.NewDecoder(w.Body).Decode(&draft); err != nil || draft.Content != "Rolled back, watching error rates" {
+		t.Fatalf("Expected saved draft to be returned, got %+v (err: %v)", draft, err)
+	}
+	if w := request(http.MethodGet, draftPath, "user-2", ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected drafts to be private to their user, got %d", w.Code)
+	}
+
+	// Posting the comment clears the author's draft only
+	if w := request(http.MethodPut, draftPath, "user-2", `{"content": "Unrelated thought"}`); w.Code != http.StatusOK {
+		t.Fatalf("Expected draft to be saved, got %d", w.Code)
+	}
+	if w := request(http.MethodPost, "/api/incidents/"+incident.ID+"/comments", "user-1", `{"content": "Rolled back, watching error rates", "user_id": "user-1"}`); w.Code != http.StatusCreated {
+		t.Fatalf("Expected comment to be created, got %d", w.Code)
+	}
+	if w := request(http.MethodGet, draftPath, "user-1", ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected the draft to be cleared after posting, got %d", w.Code)
+	}
+	if w := request(http.MethodGet, draftPath, "user-2", ""); w.Code != http.StatusOK {
+		t.Errorf("Expected another user's draft to survive, got %d", w.Code)
+	}
+
+	if w := request(http.MethodDelete, draftPath, "user-2", ""); w.Code != http.StatusNoContent {
+		t.Errorf("Expected draft deletion to return 204, got %d", w.Code)
+	}
+	if w := request(http.MethodGet, draftPath, "user-2", ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected the deleted draft to be gone, got %d", w.Code)
+	}
+}
+
+func TestHandler_CircuitBreakerInspectAndReset(t *testing.T) {
+	handler, _ := setupTestHandler(t)
+
+	list := func() models.CircuitBreakerStatus {
+		t.Helper()
+		w := httptest.NewRecorder()
+		handler.handleCircuitBreakers(w, httptest.NewRequest(http.MethodGet, "/api/admin/circuit-breakers", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var statuses []models.CircuitBreakerStatus
+		if err := json.NewDecoder(w.Body).Decode(&statuses); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if len(statuses) != 1 || statuses[0].Name != "notification-service" {
+			t.Fatalf("Expected the notification-service breaker, got %+v", statuses)
+		}
+		return statuses[0]
+	}
+
+	if status := list(); status.State != "CLOSED" || status.Requests != 0 {
+		t.Fatalf("Expected a fresh closed breaker, got %+v", status)
+	}
+
+	for i := 0; i < 3; i++ {
+		handler.sendNotificationWithCircuitBreaker(func() error { return fmt.Errorf("provider down") })
+	}
+	if status := list(); status.State != "OPEN" || status.TotalFailures != 3 {
+		t.Fatalf("Expected the breaker to be open after 3 failures, got %+v", status)
+	}
+
+	w := httptest.NewRecorder()
+	handler.handleCircuitBreakerReset(w, httptest.NewRequest(http.MethodPost, "/api/admin/circuit-breakers/notification-service/reset", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if status := list(); status.State != "CLOSED" || status.TotalFailures != 0 {
+		t.Errorf("Expected the breaker to be closed with cleared counts after reset, got %+v", status)
+	}
+	if err := handler.sendNotificationWithCircuitBreaker(func() error { return nil }); err != nil {
+		t.Errorf("Expected calls to go through after reset, got %v", err)
+	}
+
+	w = httptest.NewRecorder()
+	handler.handleCircuitBreakerReset(w, httptest.NewRequest(http.MethodPost, "/api/admin/circuit-breakers/unknown/reset", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for an unknown breaker, got %d", w.Code)
+	}
+}
+
+func TestHandler_CircuitBreakerCustomThreshold(t *testing.T) {
+	handler, _ := setupTestHandler(t)
+
+	config := circuitbreaker.DefaultConfig()
+	config.ReadyToTrip = circuitbreaker.TripOnFailureRatio(5, 1.0)
+	handler.ConfigureCircuitBreaker(config)
+
+	fail := func() error { return fmt.Errorf("provider down") }
+	for i := 0; i < 4; i++ {
+		handler.sendNotificationWithCircuitBreaker(fail)
+	}
+	if state := handler.circuitBreaker.State(); state != circuitbreaker.StateClosed {
+		t.Fatalf("Expected the breaker to stay closed below the threshold, got %s", state)
+	}
+
+	handler.sendNotificationWithCircuitBreaker(fail)
+	if state := handler.circuitBreaker.State(); state != circuitbreaker.StateOpen {
+		t.Fatalf("Expected the breaker to open at the configured failure count, got %s", state)
+	}
+	if err := handler.sendNotificationWithCircuitBreaker(func() error { return nil }); err == nil {
+		t.Error("Expected calls to be rejected while the breaker is open")
+	}
+}
+
+// circuitBreakerGauge returns the circuit_breaker_state value for a breaker
+// from the metrics registered on registry
+func circuitBreakerGauge(t *testing.T, registry prometheus.Gatherer, name string) float64 {
+	t.Helper()
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather metrics: %v", err)
+	}
+	for _, family := range families {
+		if family.GetName() != "circuit_breaker_state" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == "name" && label.GetValue() == name {
+					return metric.GetGauge().GetValue()
+				}
+			}
+		}
+	}
+	t.Fatalf("No circuit_breaker_state metric for %s", name)
+	return 0
+}
+
+func TestHandler_CircuitBreakerStateMetric(t *testing.T) {
+	handler, _ := setupTestHandler(t)
+	registry := prometheus.NewRegistry()
+	handler.metricsService = services.NewMetricsService(registry)
+	handler.ConfigureCircuitBreaker(circuitbreaker.DefaultConfig())
+	mux := http.NewServeMux()
+	handler.RegisterRoutes(mux)
+	token := testToken(t, handler, "admin-1", "admin")
+
+	list := func() models.CircuitBreakerStatus {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, "/api/system/circuit-breakers", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var statuses []models.CircuitBreakerStatus
+		if err := json.NewDecoder(w.Body).Decode(&statuses); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if len(statuses) != 1 {
+			t.Fatalf("Expected one breaker, got %+v", statuses)
+		}
+		return statuses[0]
+	}
+
+	if status := list(); status.State != "CLOSED" {
+		t.Fatalf("Expected a closed breaker, got %+v", status)
+	}
+	if value := circuitBreakerGauge(t, registry, "notification-service"); value != 0 {
+		t.Fatalf("Expected the gauge to report closed (0), got %v", value)
+	}
+
+	for i := 0; i < 3; i++ {
+		handler.sendNotificationWithCircuitBreaker(func() error { return fmt.Errorf("provider down") })
+	}
+	if status := list(); status.State != "OPEN" || status.TotalFailures != 3 {
+		t.Errorf("Expected the endpoint to report an open breaker with 3 failures, got %+v", status)
+	}
+	if value := circuitBreakerGauge(t, registry, "notification-service"); value != 2 {
+		t.Errorf("Expected the gauge to report open (2), got %v", value)
+	}
+
+	handler.circuitBreaker.Reset()
+	if value := circuitBreakerGauge(t, registry, "notification-service"); value != 0 {
+		t.Errorf("Expected the gauge to report closed (0) after reset, got %v", value)
+	}
+}
+
+func TestHandler_IncidentNotifications(t *testing.T) {
+	ctx := context.Background()
+	handler, store := setupTestHandler(t)
+
+	incident, err := handler.incidentService.CreateIncident(ctx, "Checkout errors", "", models.SeverityHigh, nil)
+	if err != nil {
+		t.Fatalf("Failed to create incident: %v", err)
+	}
+	sentAt := time.Now()
+	for _, history := range []*models.NotificationHistory{
+		{IncidentID: incident.ID, ChannelID: "pager", Type: "incident_created", Channel: "slack", Recipient: "#oncall",
+			Status: models.DeliveryStatusSent, SentAt: &sentAt, CreatedAt: sentAt.Add(-time.Minute), UpdatedAt: sentAt},
+		{IncidentID: incident.ID, ChannelID: "email", Type: "incident_created", Channel: "email", Recipient: "ops@example.com",
+			Status: models.DeliveryStatusFailed, ErrorMsg: "connection refused", RetryCount: 2, CreatedAt: sentAt, UpdatedAt: sentAt},
+		{IncidentID: "other-incident", ChannelID: "pager", Type: "incident_created", Channel: "slack",
+			Status: models.DeliveryStatusSent, CreatedAt: sentAt, UpdatedAt: sentAt},
+	} {
+		if err := store.CreateNotificationHistory(ctx, history); err != nil {
+			t.Fatalf("Failed to create history: %v", err)
+		}
+	}
+
+	mux := http.NewServeMux()
+	handler.RegisterRoutes(mux)
+	req := httptest.NewRequest(http.MethodGet, "/api/incidents/"+incident.ID+"/notifications", nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 without authentication, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	handler.handleIncidentNotifications(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var response struct {
+		Notifications []map[string]interface{} `json:"notifications"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(response.Notifications) != 2 {
+		t.Fatalf("Expected the incident's 2 notifications, got %d", len(response.Notifications))
+	}
+	newest := response.Notifications[0]
+	if newest["channel_id"] != "email" || newest["channel"] != "email" || newest["recipient"] != "ops@example.com" ||
+		newest["status"] != "failed" || newest["retry_count"] != float64(2) || newest["error_msg"] != "connection refused" {
+		t.Errorf("Unexpected newest entry: %v", newest)
+	}
+	for _, field := range []string{"created_at", "updated_at"} {
+		if _, ok := newest[field]; !ok {
+			t.Errorf("Expected %s in the response, got %v", field, newest)
+		}
+	}
+	if _, ok := response.Notifications[1]["sent_at"]; !ok {
+		t.Errorf("Expected sent_at on the delivered entry, got %v", response.Notifications[1])
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/incidents/missing/notifications", nil)
+	w = httptest.NewRecorder()
+	handler.handleIncidentNotifications(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown incident, got %d", w.Code)
+	}
+}
+
+func TestHandler_AcknowledgeOnBehalf(t *testing.T) {
+	ctx := context.Background()
+	handler, store := setupTestHandler(t)
+
+	for _, user := range []*models.User{
+		{ID: "lead-1", Username: "lead", Email: "lead@example.com", IsActive: true},
+		{ID: "responder-1", Username: "responder", Email: "responder@example.com", IsActive: true},
+	} {
+		if err := store.CreateUser(ctx, user); err != nil {
+			t.Fatalf("Failed to create user: %v", err)
+		}
+	}
+	assignPermission := &models.Permission{Name: "incidents.assign", Resource: "incidents", Action: "assign"}
+	if err := store.CreatePermission(ctx, assignPermission); err != nil {
+		t.Fatalf("Failed to create permission: %v", err)
+	}
+	leadRole := &models.Role{Name: "lead"}
+	if err := store.CreateRole(ctx, leadRole); err != nil {
+		t.Fatalf("Failed to create role: %v", err)
+	}
+	if err := store.AssignPermissionToRole(ctx, leadRole.ID, assignPermission.ID); err != nil {
+		t.Fatalf("Failed to assign permission: %v", err)
+	}
+	incident, err := handler.incidentService.CreateIncident(ctx, "Checkout down", "", models.SeverityHigh, nil)
+	if err != nil {
+		t.Fatalf("Failed to create incident: %v", err)
+	}
+
+	acknowledge := func(claims *services.Claims) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, "/api/incidents/"+incident.ID+"/acknowledge",
+			strings.NewReader(`{"on_behalf_of": "responder-1"}`))
+		if claims != nil {
+			req = req.WithContext(context.WithValue(req.Context(), middleware.ClaimsContextKey, claims))
+		}
+		w := httptest.NewRecorder()
+		handler.handleIncidents(w, req)
+		return w
+	}
+
+	if w := acknowledge(nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 without claims, got %d", w.Code)
+	}
+	// The permission is checked against stored roles, not the token's claims
+	if w := acknowledge(&services.Claims{UserID: "lead-1", Permissions: []string{"incidents.assign"}}); w.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 without a stored role granting the assign permission, got %d", w.Code)
+	}
+	if err := store.AssignRoleToUser(ctx, "lead-1", leadRole.ID); err != nil {
+		t.Fatalf("Failed to assign role: %v", err)
+	}
+	if w := acknowledge(&services.Claims{UserID: "lead-1"}); w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	updated, err := store.GetIncident(ctx, incident.ID)
+	if err != nil {
+		t.Fatalf("Failed to get incident: %v", err)
+	}
+	if updated.Status != models.IncidentStatusAcknowledged || updated.AssigneeID != "responder-1" {
+		t.Errorf("Expected incident acknowledged and assigned to responder-1, got %s/%s", updated.Status, updated.AssigneeID)
+	}
+
+	timeline, err := handler.incidentService.GetTimeline(ctx, incident.ID)
+	if err != nil {
+		t.Fatalf("Failed to get timeline: %v", err)
+	}
+	var entry *models.IncidentComment
+	for _, comment := range timeline {
+		if comment.Metadata["on_behalf_of"] == "responder-1" {
+			entry = comment
+		}
+	}
+	if entry == nil {
+		t.Fatalf("Expected an on-behalf timeline entry, got %v", timeline)
+	}
+	if entry.UserID == nil || *entry.UserID != "lead-1" || entry.Metadata["acted_by"] != "lead-1" {
+		t.Errorf("Expected the entry attributed to lead-1, got user %v metadata %v", entry.UserID, entry.Metadata)
+	}
+	if entry.Content != "lead acknowledged on behalf of responder" {
+		t.Errorf("Unexpected timeline content: %q", entry.Content)
+	}
+
+	// Activity is logged asynchronously
+	var activities []*models.UserActivity
+	for deadline := time.Now().Add(time.Second); len(activities) == 0 && time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+		if activities, err = store.GetUserActivities(ctx, "lead-1", 10); err != nil {
+			t.Fatalf("Failed to get activities: %v", err)
+		}
+	}
+	if len(activities) != 1 || activities[0].ResourceID != incident.ID || activities[0].Metadata["on_behalf_of"] != "responder-1" {
+		t.Errorf("Expected the acting user's activity to record on_behalf_of, got %+v", activities)
+	}
+
+	other, _ := handler.incidentService.CreateIncident(ctx, "Search slow", "", models.SeverityLow, nil)
+	req := httptest.NewRequest(http.MethodPut, "/api/incidents/"+other.ID+"/acknowledge", strings.NewReader(`{"on_behalf_of": "ghost"}`))
+	req = req.WithContext(context.WithValue(req.Context(), middleware.ClaimsContextKey, &services.Claims{UserID: "lead-1"}))
+	w := httptest.NewRecorder()
+	handler.handleIncidents(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an unknown user, got %d", w.Code)
+	}
+}
+
+func TestHandler_AcknowledgeWithAssigneeRequiresAssignPermission(t *testing.T) {
+	ctx := context.Background()
+	handler, store := setupTestHandler(t)
+	mux := http.NewServeMux()
+	handler.RegisterRoutes(mux)
+
+	ackPermission := &models.Permission{Name: "incidents.acknowledge", Resource: "incidents", Action: "acknowledge"}
+	if err := store.CreatePermission(ctx, ackPermission); err != nil {
+		t.Fatalf("Failed to create permission: %v", err)
+	}
+	responderRole := &models.Role{Name: "responder"}
+	if err := store.CreateRole(ctx, responderRole); err != nil {
+		t.Fatalf("Failed to create role: %v", err)
+	}
+	if err := store.AssignPermissionToRole(ctx, responderRole.ID, ackPermission.ID); err != nil {
+		t.Fatalf("Failed to assign permission: %v", err)
+	}
+	createUserWithRole(t, store, "responder-1", responderRole.ID)
+	createUserWithRole(t, store, "responder-2", responderRole.ID)
+	token := testToken(t, handler, "responder-1", "responder")
+
+	request := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		return w
+	}
+
+	incident, err := handler.incidentService.CreateIncident(ctx, "Checkout down", "", models.SeverityHigh, nil)
+	if err != nil {
+		t.Fatalf("Failed to create incident: %v", err)
+	}
+
+	// Naming someone else as the assignee is acknowledging on their behalf
+	if w := request(http.MethodPut, "/api/incidents/"+incident.ID+"/acknowledge", `{"assignee_id": "responder-2"}`); w.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 acknowledging for another user, got %d: %s", w.Code, w.Body.String())
+	}
+	bulk := fmt.Sprintf(`{"incident_ids":[%q],"operation":"acknowledge","parameters":{"assignee_id":"responder-2"}}`, incident.ID)
+	if w := request(http.MethodPost, "/api/incidents/bulk", bulk); w.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 bulk acknowledging for another user, got %d: %s", w.Code, w.Body.String())
+	}
+	if unchanged, _ := store.GetIncident(ctx, incident.ID); unchanged.Status != models.IncidentStatusOpen {
+		t.Fatalf("Expected the incident to stay open, got %s", unchanged.Status)
+	}
+
+	if w := request(http.MethodPut, "/api/incidents/"+incident.ID+"/acknowledge", `{"assignee_id": "responder-1"}`); w.Code != http.StatusOK {
+		t.Fatalf("Expected 200 acknowledging for oneself, got %d: %s", w.Code, w.Body.String())
+	}
+	if acked, _ := store.GetIncident(ctx, incident.ID); acked.Status != models.IncidentStatusAcknowledged || acked.AssigneeID != "responder-1" {
+		t.Errorf("Expected the incident acknowledged by responder-1, got %s/%s", acked.Status, acked.AssigneeID)
+	}
+}
+
+func TestHandler_DeadLetters(t *testing.T) {
+	ctx := context.Background()
+	handler, store := setupTestHandler(t)
+
+	now := time.Now()
+	for _, history := range []*models.NotificationHistory{
+		{ID: "dead-1", IncidentID: "inc-1", ChannelID: "pager", Type: "incident_created", Channel: "webhook",
+			Status: models.DeliveryStatusDeadLettered, ErrorChain: []string{"503", "503", "503"}, RetryCount: 2, CreatedAt: now, UpdatedAt: now},
+		{ID: "sent-1", IncidentID: "inc-1", ChannelID: "pager", Type: "incident_created", Channel: "webhook",
+			Status: models.DeliveryStatusSent, CreatedAt: now, UpdatedAt: now},
+	} {
+		if err := store.CreateNotificationHistory(ctx, history); err != nil {
+			t.Fatalf("Failed to create history: %v", err)
+		}
+	}
+
+	mux := http.NewServeMux()
+	handler.RegisterRoutes(mux)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/admin/notifications/dead-letters", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 without authentication, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	handler.handleDeadLetters(w, httptest.NewRequest(http.MethodGet, "/api/admin/notifications/dead-letters", nil))
+	var response struct {
+		Notifications []*models.NotificationHistory `json:"notifications"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(response.Notifications) != 1 || response.Notifications[0].ID != "dead-1" || len(response.Notifications[0].ErrorChain) != 3 {
+		t.Errorf("Expected only dead-1 with its error chain, got %+v", response.Notifications)
+	}
+
+	requeue := func(id string) int {
+		w := httptest.NewRecorder()
+		handler.handleDeadLetterRequeue(w, httptest.NewRequest(http.MethodPost, "/api/admin/notifications/dead-letters/"+id+"/requeue", nil))
+		return w.Code
+	}
+	if code := requeue("missing"); code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown notification, got %d", code)
+	}
+	if code := requeue("sent-1"); code != http.StatusConflict {
+		t.Errorf("Expected 409 for a notification that is not dead-lettered, got %d", code)
+	}
+
+	w = httptest.NewRecorder()
+	handler.handleNotificationRetry(w, httptest.NewRequest(http.MethodPost, "/api/notifications/sent-1/retry", nil))
+	if w.Code != http.StatusConflict {
+		t.Errorf("Expected 409 retrying a notification that is not dead-lettered, got %d", w.Code)
+	}
+	w = httptest.NewRecorder()
+	handler.handleNotificationRetry(w, httptest.NewRequest(http.MethodPost, "/api/notifications/dead-1/resend", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown action, got %d", w.Code)
+	}
+}
+
+func TestHandler_AcknowledgeLogsActivity(t *testing.T) {
+	ctx := context.Background()
+	handler, store := setupTestHandler(t)
+	mux := http.NewServeMux()
+	handler.RegisterRoutes(mux)
+	createUserWithRole(t, store, "admin-1", "admin-role-id")
+
+	incident, err := handler.incidentService.CreateIncident(ctx, "Checkout errors", "", models.SeverityHigh, nil)
+	if err != nil {
+		t.Fatalf("Failed to create incident: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPut, "/api/incidents/"+incident.ID+"/acknowledge", strings.NewReader("{}"))
+	req.Header.Set("Authorization", "Bearer "+testToken(t, handler, "admin-1", "admin"))
+	req.Header.Set("User-Agent", "incident-cli/1.0")
+	req.Header.Set("X-Forwarded-For", "203.0.113.7")
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	// Activity is logged asynchronously; wait for it, then allow time for
+	// any duplicate to show up
+	var activities []*models.UserActivity
+	for deadline := time.Now().Add(time.Second); len(activities) == 0 && time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+		activities, _ = store.GetUserActivities(req.Context(), "admin-1", 0)
+	}
+	time.Sleep(50 * time.Millisecond)
+	activities, _ = store.GetUserActivities(req.Context(), "admin-1", 0)
+
+	if len(activities) != 1 {
+		t.Fatalf("Expected exactly one activity, got %d", len(activities))
+	}
+	activity := activities[0]
+	if activity.Action != "acknowledge_incident" || activity.Resource != "incident" || activity.ResourceID != incident.ID {
+		t.Errorf("Unexpected activity %s %s/%s", activity.Action, activity.Resource, activity.ResourceID)
+	}
+	if activity.IPAddress != "203.0.113.7" || activity.UserAgent != "incident-cli/1.0" {
+		t.Errorf("Expected the client's IP and user agent, got %q and %q", activity.IPAddress, activity.UserAgent)
+	}
+}
+
+func TestHandler_MergeIncidents(t *testing.T) {
+	ctx := context.Background()
+	handler, store := setupTestHandler(t)
+	mux := http.NewServeMux()
+	handler.RegisterRoutes(mux)
+	createUserWithRole(t, store, "admin-1", "admin-role-id")
+	createUserWithRole(t, store, "viewer-1", "viewer-role-id")
+
+	primary, _ := handler.incidentService.CreateIncident(ctx, "Checkout errors", "", models.SeverityHigh, nil)
+	duplicate, _ := handler.incidentService.CreateIncident(ctx, "Checkout 500s", "", models.SeverityHigh, nil)
+
+	merge := func(token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/incidents/"+primary.ID+"/merge", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		return w
+	}
+
+	body := `{"duplicate_ids": ["` + duplicate.ID + `"]}`
+	if w := merge(testToken(t, handler, "viewer-1", "viewer"), body); w.Code != http.StatusForbidden {
+		t.Errorf("Expected viewers to be forbidden, got %d", w.Code)
+	}
+
+	adminToken := testToken(t, handler, "admin-1", "admin")
+	if w := merge(adminToken, `{"duplicate_ids": []}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d without duplicates, got %d", http.StatusBadRequest, w.Code)
+	}
+	if w := merge(adminToken, body); w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if w := merge(adminToken, body); w.Code != http.StatusConflict {
+		t.Errorf("Expected status %d merging twice, got %d", http.StatusConflict, w.Code)
+	}
+
+	stored, _ := store.GetIncident(ctx, duplicate.ID)
+	if stored.Status != models.IncidentStatusResolved || stored.MergedInto != primary.ID {
+		t.Errorf("Expected the duplicate to be resolved into %s, got %s (%q)", primary.ID, stored.Status, stored.MergedInto)
+	}
+}
+
+func TestHandler_UploadAttachment(t *testing.T) {
+	ctx := context.Background()
+	handler, store := setupTestHandler(t)
+	mux := http.NewServeMux()
+	handler.RegisterRoutes(mux)
+	createUserWithRole(t, store, "admin-1", "admin-role-id")
+	token := testToken(t, handler, "admin-1", "admin")
+	handler.incidentService.SetInlineImageStorage(t.TempDir(), 0)
+	handler.incidentService.SetAttachmentLimits(1024, nil)
+
+	incident, err := handler.incidentService.CreateIncident(ctx, "Checkout errors", "", models.SeverityHigh, nil)
+	if err != nil {
+		t.Fatalf("Failed to create incident: %v", err)
+	}
+
+	upload := func(fileName string, content []byte) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		form := multipart.NewWriter(&body)
+		form.WriteField("attachment_type", "log")
+		part, _ := form.CreateFormFile("file", fileName)
+		part.Write(content)
+		form.Close()
+
+		req := httptest.NewRequest(http.MethodPost, "/api/incidents/"+incident.ID+"/attachments", &body)
+		req.Header.Set("Content-Type", form.FormDataContentType())
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		return w
+	}
+
+	w := upload("checkout.log", []byte("ERROR payment gateway timeout\n"))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	var attachment models.IncidentAttachment
+	if err := json.NewDecoder(w.Body).Decode(&attachment); err != nil {
+		t.Fatalf("Failed to decode attachment: %v", err)
+	}
+	if attachment.OriginalName != "checkout.log" || attachment.MimeType != "text/plain" || attachment.AttachmentType != models.AttachmentTypeLog {
+		t.Errorf("Unexpected attachment %+v", attachment)
+	}
+	if data, err := os.ReadFile(attachment.FilePath); err != nil || string(data) != "ERROR payment gateway timeout\n" {
+		t.Errorf("Expected the file to be stored, got %q (err: %v)", data, err)
+	}
+	if attachments, _ := store.GetIncidentAttachments(ctx, incident.ID); len(attachments) != 1 {
+		t.Errorf("Expected one attachment record, got %d", len(attachments))
+	}
+
+	if w := upload("big.log", bytes.Repeat([]byte("a"), 2048)); w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected status %d for an oversized file, got %d", http.StatusRequestEntityTooLarge, w.Code)
+	}
+
+	// The type is detected from the content, whatever the name claims
+	executable := append([]byte("\x7fELF\x02\x01\x01"), make([]byte, 64)...)
+	if w := upload("notes.txt", executable); w.Code != http.StatusUnsupportedMediaType {
+		t.Errorf("Expected status %d for a disallowed type, got %d", http.StatusUnsupportedMediaType, w.Code)
+	}
+
+	if attachments, _ := store.GetIncidentAttachments(ctx, incident.ID); len(attachments) != 1 {
+		t.Errorf("Expected rejected uploads to leave no records, got %d", len(attachments))
+	}
+}
+
+func TestHandler_DownloadAttachment(t *testing.T) {
+	ctx := context.Background()
+	handler, store := setupTestHandler(t)
+	mux := http.NewServeMux()
+	handler.RegisterRoutes(mux)
+	createUserWithRole(t, store, "admin-1", "admin-role-id")
+	token := testToken(t, handler, "admin-1", "admin")
+	handler.incidentService.SetInlineImageStorage(t.TempDir(), 0)
+
+	incident, _ := handler.incidentService.CreateIncident(ctx, "Checkout errors", "", models.SeverityHigh, nil)
+	other, _ := handler.incidentService.CreateIncident(ctx, "Search slow", "", models.SeverityLow, nil)
+	content := "ERROR payment gateway timeout\n"
+	attachment, err := handler.incidentService.UploadAttachment(ctx, incident.ID, "admin-1", `gateway "eu".log`, models.AttachmentTypeLog, strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("Failed to store attachment: %v", err)
+	}
+
+	download := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		return w
+	}
+
+	w := download(attachment.DownloadURL)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if got := w.Header().Get("Content-Type"); got != "text/plain" {
+		t.Errorf("Expected Content-Type text/plain, got %q", got)
+	}
+	if got := w.Header().Get("Content-Disposition"); got != `attachment; filename="gateway \"eu\".log"` {
+		t.Errorf("Unexpected Content-Disposition %q", got)
+	}
+	if w.Body.String() != content {
+		t.Errorf("Expected the stored bytes, got %q", w.Body.String())
+	}
+
+	if w := download("/api/incidents/" + other.ID + "/attachments/" + attachment.ID); w.Code != http.StatusForbidden {
+		t.Errorf("Expected status %d through another incident, got %d", http.StatusForbidden, w.Code)
+	}
+	if w := download("/api/incidents/" + incident.ID + "/attachments/missing"); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d for an unknown attachment, got %d", http.StatusNotFound, w.Code)
+	}
+	if w := download("/api/incidents/missing/attachments/" + attachment.ID); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d for an unknown incident, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+func TestHandler_EditAndDeleteComment(t *testing.T) {
+	ctx := context.Background()
+	handler, store := setupTestHandler(t)
+	mux := http.NewServeMux()
+	handler.RegisterRoutes(mux)
+	createUserWithRole(t, store, "author-1", "viewer-role-id")
+	createUserWithRole(t, store, "other-1", "viewer-role-id")
+	createUserWithRole(t, store, "admin-1", "admin-role-id")
+	authorToken := testToken(t, handler, "author-1", "viewer")
+	otherToken := testToken(t, handler, "other-1", "viewer")
+	adminToken := testToken(t, handler, "admin-1", "admin")
+
+	incident, _ := handler.incidentService.CreateIncident(ctx, "Queue backlog", "", models.SeverityMedium, nil)
+	comment, err := handler.incidentService.AddComment(ctx, incident.ID, "author-1", "Consumers look stuck", models.CommentTypeComment, nil)
+	if err != nil {
+		t.Fatalf("Failed to add comment: %v", err)
+	}
+	path := "/api/incidents/" + incident.ID + "/comments/" + comment.ID
+
+	send := func(method, path, token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		return w
+	}
+
+	// Only the author may edit or delete
+	if w := send(http.MethodPut, path, otherToken, `{"content":"Not mine"}`); w.Code != http.StatusForbidden {
+		t.Errorf("Expected status %d editing someone else's comment, got %d", http.StatusForbidden, w.Code)
+	}
+	if w := send(http.MethodDelete, path, otherToken, ""); w.Code != http.StatusForbidden {
+		t.Errorf("Expected status %d deleting someone else's comment, got %d", http.StatusForbidden, w.Code)
+	}
+
+	w := send(http.MethodPut, path, authorToken, `{"content":"Consumers were stuck on a poison message"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var edited models.IncidentComment
+	json.Unmarshal(w.Body.Bytes(), &edited)
+	if edited.Content != "Consumers were stuck on a poison message" || edited.EditedAt == nil {
+		t.Errorf("Expected the edited content with edited_at set, got %+v", edited)
+	}
+	if w := send(http.MethodPut, path, authorToken, `{"content":""}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for empty content, got %d", http.StatusBadRequest, w.Code)
+	}
+
+	// Timeline events belong to nobody
+	event, _ := handler.incidentService.AddComment(ctx, incident.ID, "author-1", "Status changed", models.CommentTypeStatusChange, nil)
+	if w := send(http.MethodDelete, "/api/incidents/"+incident.ID+"/comments/"+event.ID, adminToken, ""); w.Code != http.StatusForbidden {
+		t.Errorf("Expected status %d deleting a timeline event, got %d", http.StatusForbidden, w.Code)
+	}
+
+	// Admins may delete anyone's comment
+	if w := send(http.MethodDelete, path, adminToken, ""); w.Code != http.StatusNoContent {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusNoContent, w.Code, w.Body.String())
+	}
+	if w := send(http.MethodDelete, path, adminToken, ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d for a deleted comment, got %d", http.StatusNotFound, w.Code)
+	}
+	comments, _ := store.GetIncidentComments(ctx, incident.ID)
+	for _, c := range comments {
+		if c.ID == comment.ID {
+			t.Error("Expected the comment to be removed from the store")
+		}
+	}
+}
+
+func TestHandler_UpdateAndDeleteTemplate(t *testing.T) {
+	ctx := context.Background()
+	handler, store := setupTestHandler(t)
+	mux := http.NewServeMux()
+	handler.RegisterRoutes(mux)
+	createUserWithRole(t, store, "admin-1", "admin-role-id")
+	createUserWithRole(t, store, "viewer-1", "viewer-role-id")
+	adminToken := testToken(t, handler, "admin-1", "admin")
+	viewerToken := testToken(t, handler, "viewer-1", "viewer")
+
+	template := &models.IncidentTemplate{Name: "db", TitleTemplate: "DB down: {{cluster}}", Severity: models.SeverityHigh}
+	if err := handler.incidentService.CreateTemplate(ctx, template); err != nil {
+		t.Fatalf("Failed to create template: %v", err)
+	}
+	path := "/api/templates/" + template.ID
+
+	send := func(method, path, token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := send(http.MethodPut, path, viewerToken, `{"severity": "low"}`); w.Code != http.StatusForbidden {
+		t.Errorf("Expected a viewer to be denied editing templates, got %d", w.Code)
+	}
+
+	time.Sleep(time.Millisecond)
+	w := send(http.MethodPut, path, adminToken, `{"title_template": "Database down: {{cluster}}", "severity": "critical"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	w = send(http.MethodGet, path, viewerToken, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var updated models.IncidentTemplate
+	json.Unmarshal(w.Body.Bytes(), &updated)
+	if updated.TitleTemplate != "Database down: {{cluster}}" || updated.Severity != models.SeverityCritical {
+		t.Errorf("Expected the edited fields to be saved, got %+v", updated)
+	}
+	if updated.Name != "db" || !updated.IsActive {
+		t.Errorf("Expected omitted fields to keep their values, got %+v", updated)
+	}
+	if !updated.UpdatedAt.After(template.UpdatedAt) || !updated.CreatedAt.Equal(template.CreatedAt) {
+		t.Errorf("Expected updated_at to move and created_at to stay, got %v / %v", updated.UpdatedAt, updated.CreatedAt)
+	}
+	if w := send(http.MethodPut, path, adminToken, `{"priority": "P9"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for an invalid priority, got %d", http.StatusBadRequest, w.Code)
+	}
+
+	if w := send(http.MethodDelete, path, viewerToken, ""); w.Code != http.StatusForbidden {
+		t.Errorf("Expected a viewer to be denied deleting templates, got %d", w.Code)
+	}
+	if w := send(http.MethodDelete, path, adminToken, ""); w.Code != http.StatusNoContent {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusNoContent, w.Code, w.Body.String())
+	}
+	for _, method := range []string{http.MethodGet, http.MethodPut, http.MethodDelete} {
+		if w := send(method, path, adminToken, `{"name": "db"}`); w.Code != http.StatusNotFound {
+			t.Errorf("Expected status %d for %s of a deleted template, got %d", http.StatusNotFound, method, w.Code)
+		}
+	}
+}
+
+func TestHandler_PreviewTemplate(t *testing.T) {
+	ctx := context.Background()
+	handler, store := setupTestHandler(t)
+	mux := http.NewServeMux()
+	handler.RegisterRoutes(mux)
+	token := testToken(t, handler, "user-1", "responder")
+
+	template := &models.IncidentTemplate{
+		Name:                "outage",
+		TitleTemplate:       "{{service}} outage in {{region}}",
+		DescriptionTemplate: "{{service}} is failing health checks.",
+		Severity:            models.SeverityCritical,
+	}
+	if err := handler.incidentService.CreateTemplate(ctx, template); err != nil {
+		t.Fatalf("Failed to create template: %v", err)
+	}
+
+	preview := func(templateID, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/templates/"+templateID+"/preview", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		return w
+	}
+
+	variables := map[string]string{"service": "checkout", "region": "eu-west"}
+	body, _ := json.Marshal(models.TemplatePreviewRequest{Variables: variables})
+	w := preview(template.ID, string(body))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var rendered models.TemplatePreview
+	json.Unmarshal(w.Body.Bytes(), &rendered)
+	if incidents, _ := store.ListIncidents(ctx); len(incidents) != 0 {
+		t.Fatalf("Expected a preview not to create an incident, got %d", len(incidents))
+	}
+
+	incident, err := handler.incidentService.UseTemplate(ctx, &models.CreateIncidentFromTemplateRequest{TemplateID: template.ID, Variables: variables}, "user-1")
+	if err != nil {
+		t.Fatalf("Failed to use template: %v", err)
+	}
+	if rendered.Title != incident.Title || rendered.Description != incident.Description ||
+		rendered.Severity != incident.Severity || rendered.Priority != incident.Priority {
+		t.Errorf("Expected the preview %+v to match the created incident %+v", rendered, incident)
+	}
+
+	if w := preview(template.ID, `{"variables": {"service": "checkout"}}`); w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "region") {
+		t.Errorf("Expected status %d naming the missing variable, got %d: %s", http.StatusBadRequest, w.Code, w.Body.String())
+	}
+	if w := preview("missing", `{}`); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d for an unknown template, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+func TestHandler_ListAlertsFilters(t *testing.T) {
+	ctx := context.Background()
+	handler, store := setupTestHandler(t)
+	mux := http.NewServeMux()
+	handler.RegisterRoutes(mux)
+	token := testToken(t, handler, "viewer-1", "viewer")
+
+	base := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	for i, fixture := range []struct{ fingerprint, status, incidentID string }{
+		{"cpu", "firing", "inc-1"},
+		{"mem", "resolved", "inc-1"},
+		{"disk", "firing", "inc-2"},
+		{"net", "firing", ""},
+		{"dns", "resolved", ""},
+	} {
+		alert := &models.Alert{
+			ID: "alert-" + fixture.fingerprint, Fingerprint: fixture.fingerprint, Status: fixture.status,
+			IncidentID: fixture.incidentID, CreatedAt: base.Add(time.Duration(i) * time.Minute),
+			Labels: map[string]string{}, Annotations: map[string]string{},
+		}
+		if err := store.CreateAlert(ctx, alert); err != nil {
+			t.Fatalf("Failed to create alert: %v", err)
+		}
+	}
+
+	list := func(query string) (*httptest.ResponseRecorder, models.AlertListResponse) {
+		req := httptest.NewRequest(http.MethodGet, "/api/alerts"+query, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		var page models.AlertListResponse
+		json.Unmarshal(w.Body.Bytes(), &page)
+		return w, page
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		want  string
+		total int
+	}{
+		{"newest first", "", "dns,net,disk,mem,cpu", 5},
+		{"status", "?status=firing", "net,disk,cpu", 3},
+		{"fingerprint", "?fingerprint=mem", "mem", 1},
+		{"incident", "?incident_id=inc-1", "mem,cpu", 2},
+		{"combined", "?status=firing&incident_id=inc-1", "cpu", 1},
+		{"first page", "?limit=2", "dns,net", 5},
+		{"second page", "?limit=2&offset=2", "disk,mem", 5},
+		{"past the end", "?limit=2&offset=10", "", 5},
+	}
+	for _, tt := range tests {
+		w, page := list(tt.query)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected status %d, got %d: %s", tt.name, http.StatusOK, w.Code, w.Body.String())
+		}
+		var got []string
+		for _, alert := range page.Alerts {
+			got = append(got, alert.Fingerprint)
+		}
+		if strings.Join(got, ",") != tt.want || page.Total != tt.total {
+			t.Errorf("%s: got %v of %d, want %s of %d", tt.name, got, page.Total, tt.want, tt.total)
+		}
+	}
+
+	if _, page := list(""); page.Limit != 20 || page.Offset != 0 {
+		t.Errorf("Expected the default page to be limit 20 offset 0, got %d/%d", page.Limit, page.Offset)
+	}
+	if _, page := list("?limit=500"); page.Limit != 100 {
+		t.Errorf("Expected the limit to be capped at 100, got %d", page.Limit)
+	}
+	if w, page := list("?status=unknown"); w.Code != http.StatusOK || page.Alerts == nil || len(page.Alerts) != 0 {
+		t.Errorf("Expected an empty alerts array for no matches, got %d: %s", w.Code, w.Body.String())
+	}
+	for _, query := range []string{"?limit=0", "?limit=abc", "?offset=-1"} {
+		if w, _ := list(query); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d for %s, got %d", http.StatusBadRequest, query, w.Code)
+		}
+	}
+}
+
+func TestHandler_ListIncidentsPaging(t *testing.T) {
+	ctx := context.Background()
+	handler, store := setupTestHandler(t)
+	mux := http.NewServeMux()
+	handler.RegisterRoutes(mux)
+	token := testToken(t, handler, "viewer-1", "viewer")
+
+	base := time.Date(2024, 6, 1, 8, 0, 0, 0, time.UTC)
+	for i, fixture := range []struct {
+		title    string
+		status   models.IncidentStatus
+		severity models.IncidentSeverity
+		assignee string
+	}{
+		{"api", models.IncidentStatusOpen, models.SeverityCritical, "user-1"},
+		{"db", models.IncidentStatusOpen, models.SeverityHigh, ""},
+		{"cache", models.IncidentStatusResolved, models.SeverityHigh, "user-1"},
+		{"disk", models.IncidentStatusAcknowledged, models.SeverityLow, "user-2"},
+		{"dns", models.IncidentStatusOpen, models.SeverityMedium, ""},
+	} {
+		incident := &models.Incident{
+			ID: "inc-" + fixture.title, Title: fixture.title, Status: fixture.status, Severity: fixture.severity,
+			AssigneeID: fixture.assignee, CreatedAt: base.Add(time.Duration(i) * time.Hour), UpdatedAt: base,
+			Labels: map[string]string{},
+		}
+		if err := store.CreateIncident(ctx, incident); err != nil {
+			t.Fatalf("Failed to create incident: %v", err)
+		}
+	}
+
+	list := func(query string) (*httptest.ResponseRecorder, models.IncidentListResponse) {
+		req := httptest.NewRequest(http.MethodGet, "/api/incidents"+query, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		var page models.IncidentListResponse
+		json.Unmarshal(w.Body.Bytes(), &page)
+		return w, page
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		want  string
+		total int
+	}{
+		{"newest first", "", "dns,disk,cache,db,api", 5},
+		{"status", "?status=open", "dns,db,api", 3},
+		{"severity", "?severity=high", "cache,db", 2},
+		{"assignee", "?assignee_id=user-1", "cache,api", 2},
+		{"combined", "?status=open&assignee_id=user-1", "api", 1},
+		{"first page", "?limit=2", "dns,disk", 5},
+		{"second page", "?page=2&limit=2", "cache,db", 5},
+		{"last page", "?page=3&limit=2", "api", 5},
+		{"past the end", "?page=9&limit=2", "", 5},
+	}
+	for _, tt := range tests {
+		w, page := list(tt.query)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected status %d, got %d: %s", tt.name, http.StatusOK, w.Code, w.Body.String())
+		}
+		var got []string
+		for _, incident := range page.Incidents {
+			got = append(got, incident.Title)
+		}
+		if strings.Join(got, ",") != tt.want || page.Total != tt.total {
+			t.Errorf("%s: got %v of %d, want %s of %d", tt.name, got, page.Total, tt.want, tt.total)
+		}
+	}
+
+	if _, page := list(""); page.Page != 1 || page.Limit != 20 || page.TotalPages != 1 {
+		t.Errorf("Expected the default page to be page 1 of 1 with limit 20, got %+v", page)
+	}
+	if _, page := list("?limit=2"); page.TotalPages != 3 {
+		t.Errorf("Expected 3 pages of 2, got %d", page.TotalPages)
+	}
+	if _, page := list("?limit=500"); page.Limit != 100 {
+		t.Errorf("Expected the limit to be capped at 100, got %d", page.Limit)
+	}
+	if w, page := list("?assignee_id=nobody"); w.Code != http.StatusOK || page.Incidents == nil || len(page.Incidents) != 0 || page.TotalPages != 0 {
+		t.Errorf("Expected an empty incidents array for no matches, got %d: %s", w.Code, w.Body.String())
+	}
+	for _, query := range []string{"?page=0", "?page=abc", "?limit=0", "?limit=abc", "?status=closed", "?severity=urgent"} {
+		if w, _ := list(query); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d for %s, got %d", http.StatusBadRequest, query, w.Code)
+		}
+	}
+}
+
+func TestHandler_ExportIncidentsCSV(t *testing.T) {
+	ctx := context.Background()
+	handler, store := setupTestHandler(t)
+	mux := http.NewServeMux()
+	handler.RegisterRoutes(mux)
+	token := testToken(t, handler, "viewer-1", "viewer")
+
+	created := time.Date(2024, 6, 1, 8, 0, 0, 0, time.UTC)
+	acked := created.Add(5 * time.Minute)
+	resolved := created.Add(90 * time.Minute)
+	for _, incident := range []*models.Incident{
+		{ID: "inc-db", Title: "Database, primary down", Status: models.IncidentStatusResolved, Severity: models.SeverityCritical,
+			AssigneeID: "user-1", CreatedAt: created, UpdatedAt: resolved, AckedAt: &acked, ResolvedAt: &resolved},
+		{ID: "inc-dns", Title: "=HYPERLINK(\"x\")", Status: models.IncidentStatusOpen, Severity: models.SeverityLow,
+			CreatedAt: created.Add(time.Hour), UpdatedAt: created.Add(time.Hour)},
+	} {
+		incident.Labels = map[string]string{}
+		if err := store.CreateIncident(ctx, incident); err != nil {
+			t.Fatalf("Failed to create incident: %v", err)
+		}
+	}
+
+	export := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/incidents/export"+query, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		return w
+	}
+
+	w := export("?format=csv")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if contentType := w.Header().Get("Content-Type"); !strings.HasPrefix(contentType, "text/csv") {
+		t.Errorf("Expected a CSV content type, got %q", contentType)
+	}
+	if disposition := w.Header().Get("Content-Disposition"); !strings.HasPrefix(disposition, "attachment;") || !strings.Contains(disposition, `.csv"`) {
+		t.Errorf("Expected a .csv attachment, got %q", disposition)
+	}
+
+	rows, err := csv.NewReader(w.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("Failed to parse CSV: %v", err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("Expected a header and 2 rows, got %v", rows)
+	}
+	wantHeader := "id,title,severity,status,created_at,acked_at,resolved_at,assignee,mtta_seconds,mttr_seconds"
+	if got := strings.Join(rows[0], ","); got != wantHeader {
+		t.Errorf("Expected header %q, got %q", wantHeader, got)
+	}
+	wantRow := []string{"inc-db", "Database, primary down", "critical", "resolved", "2024-06-01T08:00:00Z", "2024-06-01T08:05:00Z", "2024-06-01T09:30:00Z", "user-1", "300", "5400"}
+	if strings.Join(rows[2], "|") != strings.Join(wantRow, "|") {
+		t.Errorf("Expected row %q, got %q", wantRow, rows[2])
+	}
+	if rows[1][1] != `'=HYPERLINK("x")` || rows[1][5] != "" || rows[1][9] != "" {
+		t.Errorf("Expected an escaped formula and empty timings for the open incident, got %q", rows[1])
+	}
+
+	// The list filters apply
+	if w := export("?status=open"); strings.Count(strings.TrimSpace(w.Body.String()), "\n") != 1 || !strings.Contains(w.Body.String(), "inc-dns") {
+		t.Errorf("Expected only the open incident, got %q", w.Body.String())
+	}
+	if w := export("?format=xlsx"); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an unsupported format, got %d", w.Code)
+	}
+	if w := export("?severity=urgent"); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an invalid filter, got %d", w.Code)
+	}
+}
+
+func TestHandler_ReportSummary(t *testing.T) {
+	ctx := context.Background()
+	handler, store := setupTestHandler(t)
+	mux := http.NewServeMux()
+	handler.RegisterRoutes(mux)
+	token := testToken(t, handler, "viewer-1", "viewer")
+
+	created := time.Date(2024, 6, 3, 8, 0, 0, 0, time.UTC)
+	acked := created.Add(5 * time.Minute)
+	resolved := created.Add(90 * time.Minute)
+	for _, incident := range []*models.Incident{
+		{ID: "inc-db", Status: models.IncidentStatusResolved, Severity: models.SeverityCritical,
+			CreatedAt: created, AckedAt: &acked, ResolvedAt: &resolved},
+		{ID: "inc-dns", Status: models.IncidentStatusOpen, Severity: models.SeverityLow, CreatedAt: created.Add(time.Hour)},
+		// Outside the window
+		{ID: "inc-old", Status: models.IncidentStatusOpen, Severity: models.SeverityLow, CreatedAt: created.AddDate(0, 0, -10)},
+	} {
+		incident.Title = incident.ID
+		incident.UpdatedAt = incident.CreatedAt
+		incident.Labels = map[string]string{}
+		if err := store.CreateIncident(ctx, incident); err != nil {
+			t.Fatalf("Failed to create incident: %v", err)
+		}
+	}
+
+	report := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/reports/summary"+query, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		return w
+	}
+
+	w := report("?from=2024-06-01&to=2024-06-08")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var summary models.IncidentReport
+	if err := json.NewDecoder(w.Body).Decode(&summary); err != nil {
+		t.Fatalf("Failed to decode report: %v", err)
+	}
+	if summary.TotalIncidents != 2 || summary.IncidentsBySeverity["critical"] != 1 || summary.IncidentsByStatus["open"] != 1 {
+		t.Errorf("Expected the 2 incidents in the window, got %+v", summary)
+	}
+	if summary.MTTASeconds != 300 || summary.MTTRSeconds != 5400 {
+		t.Errorf("Expected an MTTA of 300s and MTTR of 5400s, got %d and %d", summary.MTTASeconds, summary.MTTRSeconds)
+	}
+
+	for _, query := range []string{"?from=yesterday", "?to=2024-13-01", "?from=2024-06-08&to=2024-06-01"} {
+		if w := report(query); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for %s, got %d", query, w.Code)
+		}
+	}
+}
+
+func TestHandler_NotificationReport(t *testing.T) {
+	ctx := context.Background()
+	handler, store := setupTestHandler(t)
+	mux := http.NewServeMux()
+	handler.RegisterRoutes(mux)
+
+	created := time.Date(2024, 6, 3, 8, 0, 0, 0, time.UTC)
+	for i, status := range []models.NotificationDeliveryStatus{
+		models.DeliveryStatusSent, models.DeliveryStatusSent, models.DeliveryStatusSent, models.DeliveryStatusFailed,
+	} {
+		history := &models.NotificationHistory{ID: fmt.Sprintf("history-%d", i), ChannelID: "ops", Type: "incident_created",
+			Channel: "slack", Status: status, CreatedAt: created, UpdatedAt: created}
+		if err := store.CreateNotificationHistory(ctx, history); err != nil {
+			t.Fatalf("Failed to create history: %v", err)
+		}
+	}
+
+	report := func(token, query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/reports/notifications"+query, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := report(testToken(t, handler, "viewer-1", "viewer"), "?from=2024-06-01&to=2024-06-08"); w.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403 for a viewer, got %d", w.Code)
+	}
+
+	adminToken := testToken(t, handler, "admin-1", "admin")
+	w := report(adminToken, "?from=2024-06-01&to=2024-06-08")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var delivery models.NotificationDeliveryReport
+	if err := json.NewDecoder(w.Body).Decode(&delivery); err != nil {
+		t.Fatalf("Failed to decode report: %v", err)
+	}
+	if len(delivery.Breakdown) != 1 || delivery.Breakdown[0].ChannelType != "slack" || delivery.Breakdown[0].SuccessRate == nil || *delivery.Breakdown[0].SuccessRate != 0.75 {
+		t.Errorf("Expected slack to succeed 3 times out of 4, got %+v", delivery.Breakdown)
+	}
+
+	if w := report(adminToken, "?from=2024-06-08&to=2024-06-01"); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an empty window, got %d", w.Code)
+	}
+}
+
+func TestHandler_GrafanaWebhook(t *testing.T) {
+	ctx := context.Background()
+	handler, store := setupTestHandler(t)
+	mux := http.NewServeMux()
+	handler.RegisterRoutes(mux)
+
+	payload := []byte(`{
+		"receiver": "incidents",
+		"status": "firing",
+		"orgId": 1,
+		"groupKey": "{}:{alertname=\"HighLatency\"}",
+		"externalURL": "https://grafana.example.com/",
+		"title": "[FIRING:2] HighLatency",
+		"alerts": [
+			{
+				"status": "firing",
+				"labels": {"alertname": "HighLatency", "service": "checkout", "severity": "critical"},
+				"annotations": {"summary": "Checkout p99 above 2s"},
+				"startsAt": "2024-05-01T12:00:00Z",
+				"endsAt": "0001-01-01T00:00:00Z",
+				"generatorURL": "https://grafana.example.com/alerting/grafana/abc/view",
+				"fingerprint": "1f2e3d4c5b6a7980",
+				"values": {"B": 2.4}
+			},
+			{
+				"status": "firing",
+				"labels": {"alertname": "HighLatency", "service": "search", "severity": "warning"},
+				"annotations": {"summary": "Search p99 above 2s"},
+				"startsAt": "2024-05-01T12:00:00Z",
+				"endsAt": "0001-01-01T00:00:00Z",
+				"fingerprint": "0a9b8c7d6e5f4a3b",
+				"values": {"B": 2.1}
+			}
+		]
+	}`)
+	send := func(body []byte) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/webhooks/grafana", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := send(payload); w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	incidents, err := store.ListIncidents(ctx)
+	if err != nil {
+		t.Fatalf("Failed to list incidents: %v", err)
+	}
+	severities := make(map[string]models.IncidentSeverity)
+	for _, incident := range incidents {
+		if len(incident.AlertIDs) != 1 {
+			t.Fatalf("Expected one alert per incident, got %v", incident.AlertIDs)
+		}
+		alert, err := store.GetAlert(ctx, incident.AlertIDs[0])
+		if err != nil {
+			t.Fatalf("Failed to get alert: %v", err)
+		}
+		severities[alert.Labels["service"]] = incident.Severity
+	}
+	if len(incidents) != 2 || severities["checkout"] != models.SeverityCritical || severities["search"] != models.SeverityMedium {
+		t.Errorf("Expected a critical checkout and a medium search incident, got %v", severities)
+	}
+
+	alert, err := store.GetAlertByFingerprint(ctx, "grafana:1f2e3d4c5b6a7980")
+	if err != nil {
+		t.Fatalf("Expected the alert to be stored under its Grafana fingerprint: %v", err)
+	}
+	if alert.Annotations["generator_url"] != "https://grafana.example.com/alerting/grafana/abc/view" {
+		t.Errorf("Expected the generator URL to be kept as an annotation, got %v", alert.Annotations)
+	}
+
+	// A redelivery is deduplicated by the idempotency check
+	if w := send(payload); w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d for a redelivery, got %d", http.StatusOK, w.Code)
+	}
+	if alerts, _ := store.ListAlerts(ctx); len(alerts) != 2 {
+		t.Errorf("Expected 2 alerts after a redelivery, got %d", len(alerts))
+	}
+
+	// Grafana resolving the alert resolves the stored alert in place
+	resolved := bytes.Replace(payload, []byte(`"status": "firing"`), []byte(`"status": "resolved"`), -1)
+	if w := send(resolved); w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d for the resolved webhook, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if alert, err := store.GetAlertByFingerprint(ctx, "grafana:1f2e3d4c5b6a7980"); err != nil || alert.Status != "resolved" {
+		t.Errorf("Expected the alert to be resolved, got %+v (err: %v)", alert, err)
+	}
+
+	for name, body := range map[string]string{
+		"Invalid JSON":         `{"status": "firing",`,
+		"No alerts":            `{"status": "firing", "alerts": []}`,
+		"Alert without labels": `{"status": "firing", "alerts": [{"status": "firing", "labels": {}}]}`,
+	} {
+		if w := send([]byte(body)); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status %d, got %d", name, http.StatusBadRequest, w.Code)
+		}
+	}
+}
+
+func TestHandler_SharedIdempotencyStore(t *testing.T) {
+	shared := idempotency.NewMemoryIdempotencyStore()
+	payload := testWebhookPayload("fp-replicated")
+
+	// Two replicas with their own storage but a shared idempotency store
+	var stores []storage.Store
+	for i, wantMessage := range []string{"Webhook processed successfully", "Duplicate request processed successfully"} {
+		handler, store := setupTestHandler(t)
+		handler.ConfigureIdempotencyStore(shared)
+		stores = append(stores, store)
+
+		w := httptest.NewRecorder()
+		handler.handleAlertmanagerWebhook(w, httptest.NewRequest(http.MethodPost, DefaultWebhookPath, bytes.NewReader(payload)))
+		var response map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		if w.Code != http.StatusOK || response["message"] != wantMessage {
+			t.Errorf("Replica %d: expected %q, got %d: %s", i+1, wantMessage, w.Code, w.Body.String())
+		}
+	}
+
+	if alerts, _ := stores[1].ListAlerts(context.Background()); len(alerts) != 0 {
+		t.Errorf("Expected the second replica not to process the delivery again, got %d alerts", len(alerts))
+	}
+}
+
+func TestIsStreamingRequest(t *testing.T) {
+	websocketRequest := func(upgrade string) *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/api/ws", nil)
+		req.Header.Set("Upgrade", upgrade)
+		req.Header.Set("Connection", "keep-alive, Upgrade")
+		return req
+	}
+
+	tests := []struct {
+		name      string
+		req       *http.Request
+		streaming bool
+	}{
+		{"WebSocket handshake", websocketRequest("websocket"), true},
+		{"Other upgrade on the WebSocket path", websocketRequest("h2c"), false},
+		{"Export", httptest.NewRequest(http.MethodGet, "/api/incidents/export?status=open", nil), true},
+		{"Attachment download", httptest.NewRequest(http.MethodGet, "/api/incidents/inc-1/attachments/att-1", nil), true},
+		{"Attachment list", httptest.NewRequest(http.MethodGet, "/api/incidents/inc-1/attachments", nil), false},
+		{"Attachment upload", httptest.NewRequest(http.MethodPost, "/api/incidents/inc-1/attachments/att-1", nil), false},
+		{"Upgrade elsewhere", func() *http.Request {
+			req := httptest.NewRequest(http.MethodGet, "/api/incidents", nil)
+			req.Header.Set("Upgrade", "websocket")
+			req.Header.Set("Connection", "Upgrade")
+			return req
+		}(), false},
+	}
+	for _, tt := range tests {
+		if got := IsStreamingRequest(tt.req); got != tt.streaming {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.streaming, got)
+		}
+	}
+}
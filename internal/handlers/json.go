@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+)
+
+// strictJSON makes request bodies with unknown fields fail to decode, so a
+// misspelled field is reported instead of silently dropped
+var strictJSON atomic.Bool
+
+// SetStrictJSON enables or disables strict request body decoding for all handlers
+func SetStrictJSON(strict bool) {
+	strictJSON.Store(strict)
+}
+
+// decodeJSON decodes the request body into v, rejecting unknown fields in strict mode
+func decodeJSON(r *http.Request, v interface{}) error {
+	decoder := json.NewDecoder(r.Body)
+	if strictJSON.Load() {
+		decoder.DisallowUnknownFields()
+	}
+	return decoder.Decode(v)
+}
+
+// invalidBodyMessage returns the client-facing message for a decode error. It
+// names the offending field when the body had an unknown one and falls back
+// to the handler's usual message otherwise.
+func invalidBodyMessage(err error, fallback string) string {
+	// encoding/json reports unknown fields only as `json: unknown field "name"`
+	if field, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+		return fmt.Sprintf("Unknown field %s in request body", field)
+	}
+	return fallback
+}
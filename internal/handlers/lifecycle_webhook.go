@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/models"
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/storage"
+)
+
+// handleLifecycleWebhooks lists or registers lifecycle webhooks
+func (h *Handler) handleLifecycleWebhooks(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		webhooks, err := h.store.ListLifecycleWebhooks(r.Context())
+		if err != nil {
+			h.writeErrorResponse(w, "Failed to list lifecycle webhooks", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(webhooks)
+
+	case http.MethodPost:
+		var req models.LifecycleWebhookRequest
+		if err := decodeJSON(r, &req); err != nil {
+			h.writeErrorResponse(w, invalidBodyMessage(err, "Invalid JSON"), http.StatusBadRequest)
+			return
+		}
+		if msg := validateLifecycleWebhookRequest(&req); msg != "" {
+			h.writeErrorResponse(w, msg, http.StatusBadRequest)
+			return
+		}
+
+		now := time.Now()
+		webhook := &models.LifecycleWebhook{
+			ID:        uuid.New().String(),
+			CreatedAt: now,
+		}
+		applyLifecycleWebhookRequest(webhook, &req, now)
+		if err := h.store.CreateLifecycleWebhook(r.Context(), webhook); err != nil {
+			h.writeErrorResponse(w, "Failed to create lifecycle webhook", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(webhook)
+
+	default:
+		h.writeErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleLifecycleWebhook gets, replaces or deletes the webhook at
+// /api/lifecycle-webhooks/{id}
+func (h *Handler) handleLifecycleWebhook(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/api/lifecycle-webhooks/")
+	if id == "" || strings.Contains(id, "/") {
+		h.writeErrorResponse(w, "Not found", http.StatusNotFound)
+		return
+	}
+
+	webhook, err := h.store.GetLifecycleWebhook(r.Context(), id)
+	if err == storage.ErrNotFound {
+		h.writeErrorResponse(w, "Lifecycle webhook not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		h.writeErrorResponse(w, "Failed to get lifecycle webhook", http.StatusInternalServerError)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(webhook)
+
+	case http.MethodPut:
+		var req models.LifecycleWebhookRequest
+		if err := decodeJSON(r, &req); err != nil {
+			h.writeErrorResponse(w, invalidBodyMessage(err, "Invalid JSON"), http.StatusBadRequest)
+			return
+		}
+		if msg := validateLifecycleWebhookRequest(&req); msg != "" {
+			h.writeErrorResponse(w, msg, http.StatusBadRequest)
+			return
+		}
+
+		applyLifecycleWebhookRequest(webhook, &req, time.Now())
+		if err := h.store.UpdateLifecycleWebhook(r.Context(), webhook); err != nil {
+			h.writeErrorResponse(w, "Failed to update lifecycle webhook", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(webhook)
+
+	case http.MethodDelete:
+		if err := h.store.DeleteLifecycleWebhook(r.Context(), id); err != nil {
+			h.writeErrorResponse(w, "Failed to delete lifecycle webhook", http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		h.writeErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// validateLifecycleWebhookRequest returns a client-facing message for an
+// invalid request, or "" when it is valid
+func validateLifecycleWebhookRequest(req *models.LifecycleWebhookRequest) string {
+	if strings.TrimSpace(req.Name) == "" {
+		return "Name is required"
+	}
+	if u, err := url.Parse(req.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "URL must be an absolute http or https URL"
+	}
+	for _, severity := range req.Severities {
+		switch severity {
+		case models.SeverityCritical, models.SeverityHigh, models.SeverityMedium, models.SeverityLow:
+		default:
+			return "Invalid severity " + string(severity) + ". Must be one of: critical, high, medium, low"
+		}
+	}
+	return ""
+}
+
+func applyLifecycleWebhookRequest(webhook *models.LifecycleWebhook, req *models.LifecycleWebhookRequest, now time.Time) {
+	webhook.Name = strings.TrimSpace(req.Name)
+	webhook.URL = req.URL
+	webhook.Enabled = req.Enabled == nil || *req.Enabled
+	webhook.Severities = req.Severities
+	webhook.Labels = req.Labels
+	webhook.UpdatedAt = now
+}
@@ -3,6 +3,7 @@ package handlers
 import (
 	"encoding/json"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/google/uuid"
@@ -45,8 +46,8 @@ func (h *NotificationHandlers) CreateNotificationChannel(w http.ResponseWriter,
 	}
 
 	var channel models.NotificationChannel
-	if err := json.NewDecoder(r.Body).Decode(&channel); err != nil {
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+	if err := decodeJSON(r, &channel); err != nil {
+		http.Error(w, invalidBodyMessage(err, "Invalid JSON"), http.StatusBadRequest)
 		return
 	}
 
@@ -75,14 +76,45 @@ func (h *NotificationHandlers) CreateNotificationChannel(w http.ResponseWriter,
 	}
 
 	// Validate channel type
-	validTypes := map[string]bool{"slack": true, "email": true, "telegram": true}
+	validTypes := map[string]bool{"slack": true, "email": true, "telegram": true, "webhook": true, "msteams": true, "pagerduty": true, "sms": true}
 	if !validTypes[channel.Type] {
-		http.Error(w, "Invalid channel type. Must be one of: slack, email, telegram", http.StatusBadRequest)
+		http.Error(w, "Invalid channel type. Must be one of: slack, email, telegram, webhook, msteams, pagerduty, sms", http.StatusBadRequest)
 		return
 	}
 
+	if channel.Type == "webhook" && channel.Config["url"] == "" {
+		http.Error(w, "Webhook channels require a url in config", http.StatusBadRequest)
+		return
+	}
+
+	if channel.Type == "msteams" && channel.Config["webhook_url"] == "" {
+		http.Error(w, "Microsoft Teams channels require a webhook_url in config", http.StatusBadRequest)
+		return
+	}
+
+	if channel.Type == "pagerduty" && channel.Config["routing_key"] == "" {
+		http.Error(w, "PagerDuty channels require a routing_key in config", http.StatusBadRequest)
+		return
+	}
+
+	if channel.Type == "sms" {
+		for _, key := range []string{"account_sid", "auth_token", "from", "to"} {
+			if channel.Config[key] == "" {
+				http.Error(w, "SMS channels require account_sid, auth_token, from and to in config", http.StatusBadRequest)
+				return
+			}
+		}
+	}
+
+	if channel.Preferences != nil {
+		if err := services.ValidateQuietHours(channel.Preferences.QuietHours); err != nil {
+			http.Error(w, "Invalid quiet hours: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
 	// Create channel
-	if err := h.store.CreateNotificationChannel(&channel); err != nil {
+	if err := h.store.CreateNotificationChannel(r.Context(), &channel); err != nil {
 		h.logger.Error("Failed to create notification channel", map[string]interface{}{
 			"error": err.Error(),
 		})
@@ -102,7 +134,7 @@ func (h *NotificationHandlers) GetNotificationChannels(w http.ResponseWriter, r
 		return
 	}
 
-	channels, err := h.store.ListNotificationChannels()
+	channels, err := h.store.ListNotificationChannels(r.Context())
 	if err != nil {
 		h.logger.Error("Failed to list notification channels", map[string]interface{}{
 			"error": err.Error(),
@@ -129,7 +161,7 @@ func (h *NotificationHandlers) GetNotificationChannel(w http.ResponseWriter, r *
 		return
 	}
 
-	channel, err := h.store.GetNotificationChannel(channelID)
+	channel, err := h.store.GetNotificationChannel(r.Context(), channelID)
 	if err != nil {
 		if err == storage.ErrNotFound {
 			http.Error(w, "Channel not found", http.StatusNotFound)
@@ -162,16 +194,23 @@ func (h *NotificationHandlers) UpdateNotificationChannel(w http.ResponseWriter,
 	}
 
 	var channel models.NotificationChannel
-	if err := json.NewDecoder(r.Body).Decode(&channel); err != nil {
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+	if err := decodeJSON(r, &channel); err != nil {
+		http.Error(w, invalidBodyMessage(err, "Invalid JSON"), http.StatusBadRequest)
 		return
 	}
 
+	if channel.Preferences != nil {
+		if err := services.ValidateQuietHours(channel.Preferences.QuietHours); err != nil {
+			http.Error(w, "Invalid quiet hours: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
 	// Ensure ID matches URL
 	channel.ID = channelID
 	channel.UpdatedAt = time.Now()
 
-	if err := h.store.UpdateNotificationChannel(&channel); err != nil {
+	if err := h.store.UpdateNotificationChannel(r.Context(), &channel); err != nil {
 		if err == storage.ErrNotFound {
 			http.Error(w, "Channel not found", http.StatusNotFound)
 		} else {
@@ -202,7 +241,7 @@ func (h *NotificationHandlers) DeleteNotificationChannel(w http.ResponseWriter,
 		return
 	}
 
-	if err := h.store.DeleteNotificationChannel(channelID); err != nil {
+	if err := h.store.DeleteNotificationChannel(r.Context(), channelID); err != nil {
 		if err == storage.ErrNotFound {
 			http.Error(w, "Channel not found", http.StatusNotFound)
 		} else {
@@ -235,7 +274,7 @@ func (h *NotificationHandlers) TestNotificationChannel(w http.ResponseWriter, r
 	}
 
 	// Get the channel
-	channel, err := h.store.GetNotificationChannel(channelID)
+	channel, err := h.store.GetNotificationChannel(r.Context(), channelID)
 	if err != nil {
 		if err == storage.ErrNotFound {
 			http.Error(w, "Channel not found", http.StatusNotFound)
@@ -314,6 +353,89 @@ func (h *NotificationHandlers) GetNotificationHistory(w http.ResponseWriter, r *
 	json.NewEncoder(w).Encode(history)
 }
 
+// GetChannelHistory returns everything sent through a channel across incidents.
+// Supports status, since and until (RFC3339) filters with page/limit pagination.
+func (h *NotificationHandlers) GetChannelHistory(w http.ResponseWriter, r *http.Request, channelID string) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if _, err := h.store.GetNotificationChannel(r.Context(), channelID); err != nil {
+		if err == storage.ErrNotFound {
+			http.Error(w, "Channel not found", http.StatusNotFound)
+		} else {
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	query := r.URL.Query()
+	filter := &models.NotificationHistoryFilter{
+		Status: models.NotificationDeliveryStatus(query.Get("status")),
+		Page:   1,
+		Limit:  20,
+	}
+
+	if value := query.Get("since"); value != "" {
+		since, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			http.Error(w, "Invalid since format. Use RFC3339 format", http.StatusBadRequest)
+			return
+		}
+		filter.Since = &since
+	}
+	if value := query.Get("until"); value != "" {
+		until, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			http.Error(w, "Invalid until format. Use RFC3339 format", http.StatusBadRequest)
+			return
+		}
+		filter.Until = &until
+	}
+
+	if value := query.Get("page"); value != "" {
+		page, err := strconv.Atoi(value)
+		if err != nil || page < 1 {
+			http.Error(w, "Invalid page", http.StatusBadRequest)
+			return
+		}
+		filter.Page = page
+	}
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		if limit > 100 {
+			limit = 100 // Maximum limit
+		}
+		filter.Limit = limit
+	}
+
+	history, total, err := h.store.ListNotificationHistoryByChannel(r.Context(), channelID, filter)
+	if err != nil {
+		h.logger.Error("Failed to list notification history", map[string]interface{}{
+			"channel_id": channelID,
+			"error":      err.Error(),
+		})
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	response := models.NotificationHistoryResponse{
+		History:    history,
+		Total:      total,
+		Page:       filter.Page,
+		Limit:      filter.Limit,
+		TotalPages: (total + filter.Limit - 1) / filter.Limit,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
 // ScheduleNotification schedules a notification for future delivery
 func (h *NotificationHandlers) ScheduleNotification(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
@@ -329,8 +451,8 @@ func (h *NotificationHandlers) ScheduleNotification(w http.ResponseWriter, r *ht
 		Metadata         map[string]interface{} `json:"metadata,omitempty"`
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+	if err := decodeJSON(r, &request); err != nil {
+		http.Error(w, invalidBodyMessage(err, "Invalid JSON"), http.StatusBadRequest)
 		return
 	}
 
@@ -357,7 +479,7 @@ func (h *NotificationHandlers) ScheduleNotification(w http.ResponseWriter, r *ht
 		CreatedAt:   time.Now(),
 	}
 
-	channel, err := h.store.GetNotificationChannel(request.ChannelID)
+	channel, err := h.store.GetNotificationChannel(r.Context(), request.ChannelID)
 	if err != nil {
 		if err == storage.ErrNotFound {
 			http.Error(w, "Channel not found", http.StatusNotFound)
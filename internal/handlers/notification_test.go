@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/models"
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/storage"
+)
+
+func seedChannelHistory(t *testing.T, store storage.Store, base time.Time) {
+	ctx := context.Background()
+	t.Helper()
+
+	for _, channel := range []*models.NotificationChannel{
+		{ID: "ops-slack", Name: "Ops Slack", Type: "slack", Enabled: true},
+		{ID: "ops-email", Name: "Ops Email", Type: "email", Enabled: true},
+	} {
+		if err := store.CreateNotificationChannel(ctx, channel); err != nil {
+			t.Fatalf("Failed to create channel: %v", err)
+		}
+	}
+
+	// Five deliveries through ops-slack across three incidents, one through ops-email
+	entries := []struct {
+		incidentID string
+		channelID  string
+		status     models.NotificationDeliveryStatus
+		age        time.Duration
+	}{
+		{"inc-1", "ops-slack", models.DeliveryStatusSent, 5 * time.Hour},
+		{"inc-1", "ops-slack", models.DeliveryStatusFailed, 4 * time.Hour},
+		{"inc-2", "ops-slack", models.DeliveryStatusSent, 3 * time.Hour},
+		{"inc-3", "ops-slack", models.DeliveryStatusSent, 2 * time.Hour},
+		{"inc-3", "ops-slack", models.DeliveryStatusFailed, 1 * time.Hour},
+		{"inc-2", "ops-email", models.DeliveryStatusSent, 1 * time.Hour},
+	}
+	for i, e := range entries {
+		createdAt := base.Add(-e.age)
+		if err := store.CreateNotificationHistory(ctx, &models.NotificationHistory{
+			ID:         fmt.Sprintf("history-%d", i),
+			IncidentID: e.incidentID,
+			ChannelID:  e.channelID,
+			Type:       "incident_created",
+			Status:     e.status,
+			CreatedAt:  createdAt,
+			UpdatedAt:  createdAt,
+		}); err != nil {
+			t.Fatalf("Failed to seed notification history: %v", err)
+		}
+	}
+}
+
+func getChannelHistory(t *testing.T, handler *Handler, url string) (*httptest.ResponseRecorder, models.NotificationHistoryResponse) {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodGet, url, nil)
+	w := httptest.NewRecorder()
+	handler.handleNotificationChannel(w, req)
+
+	var response models.NotificationHistoryResponse
+	if w.Code == http.StatusOK {
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to decode response: %v (body: %s)", err, w.Body.String())
+		}
+	}
+	return w, response
+}
+
+func TestHandler_ChannelNotificationHistory(t *testing.T) {
+	handler, store := setupTestHandler(t)
+	base := time.Now().UTC().Truncate(time.Second)
+	seedChannelHistory(t, store, base)
+
+	t.Run("All entries across incidents", func(t *testing.T) {
+		w, response := getChannelHistory(t, handler, "/api/notification-channels/ops-slack/history")
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		if response.Total != 5 || len(response.History) != 5 {
+			t.Fatalf("Expected 5 entries, got total=%d len=%d", response.Total, len(response.History))
+		}
+
+		incidents := map[string]bool{}
+		for i, entry := range response.History {
+			if entry.ChannelID != "ops-slack" {
+				t.Errorf("Expected only ops-slack entries, got %s", entry.ChannelID)
+			}
+			if i > 0 && entry.CreatedAt.After(response.History[i-1].CreatedAt) {
+				t.Error("Expected entries to be ordered newest first")
+			}
+			incidents[entry.IncidentID] = true
+		}
+		if len(incidents) != 3 {
+			t.Errorf("Expected entries from 3 incidents, got %d", len(incidents))
+		}
+	})
+
+	t.Run("Status filter", func(t *testing.T) {
+		_, response := getChannelHistory(t, handler, "/api/notification-channels/ops-slack/history?status=failed")
+		if response.Total != 2 {
+			t.Fatalf("Expected 2 failed entries, got %d", response.Total)
+		}
+		for _, entry := range response.History {
+			if entry.Status != models.DeliveryStatusFailed {
+				t.Errorf("Expected failed status, got %s", entry.Status)
+			}
+		}
+	})
+
+	t.Run("Date filter", func(t *testing.T) {
+		since := base.Add(-3*time.Hour - time.Minute).Format(time.RFC3339)
+		until := base.Add(-90 * time.Minute).Format(time.RFC3339)
+		_, response := getChannelHistory(t, handler, "/api/notification-channels/ops-slack/history?since="+since+"&until="+until)
+		if response.Total != 2 {
+			t.Errorf("Expected 2 entries in the date range, got %d", response.Total)
+		}
+	})
+
+	t.Run("Pagination", func(t *testing.T) {
+		_, response := getChannelHistory(t, handler, "/api/notification-channels/ops-slack/history?limit=2&page=3")
+		if response.Total != 5 || response.TotalPages != 3 || response.Page != 3 {
+			t.Errorf("Unexpected pagination metadata: %+v", response)
+		}
+		if len(response.History) != 1 || response.History[0].ID != "history-0" {
+			t.Errorf("Expected the oldest entry alone on the last page, got %+v", response.History)
+		}
+	})
+
+	t.Run("Invalid date", func(t *testing.T) {
+		w, _ := getChannelHistory(t, handler, "/api/notification-channels/ops-slack/history?since=yesterday")
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+		}
+	})
+
+	t.Run("Unknown channel", func(t *testing.T) {
+		w, _ := getChannelHistory(t, handler, "/api/notification-channels/missing/history")
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected status %d, got %d", http.StatusNotFound, w.Code)
+		}
+	})
+}
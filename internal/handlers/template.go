@@ -97,8 +97,8 @@ func (h *TemplateHandlers) CreateTemplate(w http.ResponseWriter, r *http.Request
 	}
 
 	var template models.NotificationTemplate
-	if err := json.NewDecoder(r.Body).Decode(&template); err != nil {
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+	if err := decodeJSON(r, &template); err != nil {
+		http.Error(w, invalidBodyMessage(err, "Invalid JSON"), http.StatusBadRequest)
 		return
 	}
 
@@ -145,8 +145,8 @@ func (h *TemplateHandlers) UpdateTemplate(w http.ResponseWriter, r *http.Request
 	}
 
 	var template models.NotificationTemplate
-	if err := json.NewDecoder(r.Body).Decode(&template); err != nil {
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+	if err := decodeJSON(r, &template); err != nil {
+		http.Error(w, invalidBodyMessage(err, "Invalid JSON"), http.StatusBadRequest)
 		return
 	}
 
@@ -213,8 +213,8 @@ func (h *TemplateHandlers) PreviewTemplate(w http.ResponseWriter, r *http.Reques
 		SampleData map[string]interface{} `json:"sample_data,omitempty"`
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+	if err := decodeJSON(r, &request); err != nil {
+		http.Error(w, invalidBodyMessage(err, "Invalid JSON"), http.StatusBadRequest)
 		return
 	}
 
@@ -274,8 +274,8 @@ func (h *TemplateHandlers) ValidateTemplate(w http.ResponseWriter, r *http.Reque
 	}
 
 	var template models.NotificationTemplate
-	if err := json.NewDecoder(r.Body).Decode(&template); err != nil {
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+	if err := decodeJSON(r, &template); err != nil {
+		http.Error(w, invalidBodyMessage(err, "Invalid JSON"), http.StatusBadRequest)
 		return
 	}
 
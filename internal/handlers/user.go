@@ -0,0 +1,337 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/middleware"
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/models"
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/services"
+)
+
+// maxUserImportBatch caps the number of users accepted in a single import
+const maxUserImportBatch = 500
+
+// Activity log page sizes
+const (
+	defaultActivityLimit = 50
+	maxActivityLimit     = 500
+)
+
+// UserHandler handles administrative user management requests
+type UserHandler struct {
+	userService *services.UserService
+	authService *services.AuthService
+	logger      *services.Logger
+}
+
+// NewUserHandler creates a new user handler
+func NewUserHandler(userService *services.UserService, authService *services.AuthService, logger *services.Logger) *UserHandler {
+	return &UserHandler{
+		userService: userService,
+		authService: authService,
+		logger:      logger,
+	}
+}
+
+// ImportUsers handles bulk user creation with role assignment
+func (h *UserHandler) ImportUsers(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req models.BulkUserImportRequest
+	if err := decodeJSON(r, &req); err != nil {
+		http.Error(w, invalidBodyMessage(err, "Invalid request body"), http.StatusBadRequest)
+		return
+	}
+
+	if len(req.Users) == 0 {
+		http.Error(w, "At least one user is required", http.StatusBadRequest)
+		return
+	}
+	if len(req.Users) > maxUserImportBatch {
+		http.Error(w, "Too many users in a single import", http.StatusBadRequest)
+		return
+	}
+
+	actorID, _ := middleware.GetUserIDFromContext(r.Context())
+
+	response, err := h.userService.ImportUsers(r.Context(), actorID, req.Users)
+	if err != nil {
+		h.logger.Error("User import failed", map[string]interface{}{
+			"actor_id": actorID,
+			"error":    err.Error(),
+		})
+		http.Error(w, "User import failed", http.StatusInternalServerError)
+		return
+	}
+
+	status := http.StatusCreated
+	if response.RolledBack {
+		status = http.StatusBadRequest
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
+
+// ListUsers handles GET /api/users, returning a page of users selected with
+// the page and limit query parameters
+func (h *UserHandler) ListUsers(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	page, limit := 1, 20
+	if value := query.Get("page"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 {
+			http.Error(w, "Invalid page", http.StatusBadRequest)
+			return
+		}
+		page = parsed
+	}
+	if value := query.Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		if parsed > 100 {
+			parsed = 100 // Maximum limit
+		}
+		limit = parsed
+	}
+
+	users, total, err := h.userService.ListUsers(r.Context(), page, limit)
+	if err != nil {
+		h.logger.Error("Failed to list users", map[string]interface{}{
+			"error": err.Error(),
+		})
+		http.Error(w, "Failed to list users", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.UserListResponse{
+		Users:      users,
+		Total:      total,
+		Page:       page,
+		Limit:      limit,
+		TotalPages: (total + limit - 1) / limit,
+	})
+}
+
+// HandleUser serves GET, PUT and DELETE on /api/users/{id} and the user's
+// sub-resources below it
+func (h *UserHandler) HandleUser(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/users/"), "/"), "/")
+	userID := parts[0]
+	if userID == "" {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+	if len(parts) > 1 {
+		switch {
+		case parts[1] == "roles" && len(parts) <= 3:
+			h.handleUserRoles(w, r, userID, parts[2:])
+		case parts[1] == "activities" && len(parts) == 2:
+			h.getUserActivities(w, r, userID)
+		default:
+			http.Error(w, "Not found", http.StatusNotFound)
+		}
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		h.getUser(w, r, userID)
+	case http.MethodPut:
+		h.updateUser(w, r, userID)
+	case http.MethodDelete:
+		h.deleteUser(w, r, userID)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *UserHandler) getUser(w http.ResponseWriter, r *http.Request, userID string) {
+	user, err := h.userService.GetUserByID(r.Context(), userID)
+	if err != nil {
+		h.writeUserError(w, userID, "Failed to get user", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(user)
+}
+
+func (h *UserHandler) updateUser(w http.ResponseWriter, r *http.Request, userID string) {
+	var req models.AdminUpdateUserRequest
+	if err := decodeJSON(r, &req); err != nil {
+		http.Error(w, invalidBodyMessage(err, "Invalid request body"), http.StatusBadRequest)
+		return
+	}
+
+	actorID, _ := middleware.GetUserIDFromContext(r.Context())
+	user, err := h.userService.UpdateUser(r.Context(), actorID, userID, &req)
+	if err != nil {
+		h.writeUserError(w, userID, "Failed to update user", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(user)
+}
+
+func (h *UserHandler) deleteUser(w http.ResponseWriter, r *http.Request, userID string) {
+	actorID, _ := middleware.GetUserIDFromContext(r.Context())
+	if err := h.userService.DeleteUser(r.Context(), actorID, userID); err != nil {
+		h.writeUserError(w, userID, "Failed to delete user", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleUserRoles serves POST /api/users/{id}/roles, which grants the role
+// named by role_id in the body, and DELETE /api/users/{id}/roles/{roleID}.
+// Both respond with the user's roles after the change.
+func (h *UserHandler) handleUserRoles(w http.ResponseWriter, r *http.Request, userID string, rest []string) {
+	actorID, _ := middleware.GetUserIDFromContext(r.Context())
+
+	var roles []*models.Role
+	var err error
+	switch {
+	case r.Method == http.MethodPost && len(rest) == 0:
+		var req models.AssignUserRoleRequest
+		if err := decodeJSON(r, &req); err != nil {
+			http.Error(w, invalidBodyMessage(err, "Invalid request body"), http.StatusBadRequest)
+			return
+		}
+		if req.RoleID == "" {
+			http.Error(w, "role_id is required", http.StatusBadRequest)
+			return
+		}
+		roles, err = h.userService.AddUserRole(r.Context(), actorID, userID, req.RoleID)
+	case r.Method == http.MethodDelete && len(rest) == 1 && rest[0] != "":
+		roles, err = h.userService.RemoveUserRole(r.Context(), actorID, userID, rest[0])
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrRoleNotFound):
+			http.Error(w, "Role not found", http.StatusNotFound)
+		case errors.Is(err, services.ErrRoleNotAssigned):
+			http.Error(w, "Role not assigned to user", http.StatusNotFound)
+		default:
+			h.writeUserError(w, userID, "Failed to update user roles", err)
+		}
+		return
+	}
+
+	if roles == nil {
+		roles = []*models.Role{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"user_id": userID,
+		"roles":   roles,
+	})
+}
+
+// getUserActivities serves GET /api/users/{id}/activities?limit=N
+func (h *UserHandler) getUserActivities(w http.ResponseWriter, r *http.Request, userID string) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	limit, ok := activityLimit(w, r)
+	if !ok {
+		return
+	}
+
+	activities, err := h.userService.GetUserActivities(r.Context(), userID, limit)
+	if err != nil {
+		h.writeUserError(w, userID, "Failed to get user activities", err)
+		return
+	}
+	writeActivities(w, activities)
+}
+
+// ListAuditLog handles GET /api/audit, the most recent activity of all users
+func (h *UserHandler) ListAuditLog(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	limit, ok := activityLimit(w, r)
+	if !ok {
+		return
+	}
+
+	activities, err := h.userService.ListRecentActivities(r.Context(), limit)
+	if err != nil {
+		h.logger.Error("Failed to list audit log", map[string]interface{}{
+			"error": err.Error(),
+		})
+		http.Error(w, "Failed to list audit log", http.StatusInternalServerError)
+		return
+	}
+	writeActivities(w, activities)
+}
+
+// activityLimit reads the limit query parameter, writing a 400 when invalid
+func activityLimit(w http.ResponseWriter, r *http.Request) (int, bool) {
+	value := r.URL.Query().Get("limit")
+	if value == "" {
+		return defaultActivityLimit, true
+	}
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit < 1 {
+		http.Error(w, "Invalid limit", http.StatusBadRequest)
+		return 0, false
+	}
+	if limit > maxActivityLimit {
+		limit = maxActivityLimit
+	}
+	return limit, true
+}
+
+func writeActivities(w http.ResponseWriter, activities []*models.UserActivity) {
+	if activities == nil {
+		activities = []*models.UserActivity{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"activities": activities,
+		"count":      len(activities),
+	})
+}
+
+// writeUserError maps user service errors to HTTP responses
+func (h *UserHandler) writeUserError(w http.ResponseWriter, userID, message string, err error) {
+	switch {
+	case errors.Is(err, services.ErrUserNotFound):
+		http.Error(w, "User not found", http.StatusNotFound)
+	case errors.Is(err, services.ErrRoleNotFound), errors.Is(err, services.ErrSelfLockout):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		h.logger.Error(message, map[string]interface{}{
+			"user_id": userID,
+			"error":   err.Error(),
+		})
+		http.Error(w, message, http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,470 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/models"
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/services"
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/storage"
+)
+
+func setupTestUserHandler(t *testing.T) (*UserHandler, *services.UserService, storage.Store) {
+	store, err := storage.NewMemoryStore()
+	if err != nil {
+		t.Fatalf("Failed to create memory store: %v", err)
+	}
+	setupTestRolesAndPermissions(t, store)
+
+	logger := services.NewLogger("debug", false)
+	authService := services.NewAuthService("test-jwt-secret-32-characters-long!", 1*time.Hour, 24*time.Hour)
+	userService := services.NewUserService(store, authService, logger)
+
+	return NewUserHandler(userService, authService, logger), userService, store
+}
+
+func postUserImport(t *testing.T, handler *UserHandler, users []models.UserImportEntry) (*httptest.ResponseRecorder, models.BulkUserImportResponse) {
+	body, _ := json.Marshal(models.BulkUserImportRequest{Users: users})
+	req := httptest.NewRequest(http.MethodPost, "/api/users/import", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	handler.ImportUsers(w, req)
+
+	var response models.BulkUserImportResponse
+	if w.Code == http.StatusCreated || w.Code == http.StatusBadRequest {
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to decode response: %v (body: %s)", err, w.Body.String())
+		}
+	}
+	return w, response
+}
+
+func TestUserHandler_ImportUsers(t *testing.T) {
+	handler, userService, _ := setupTestUserHandler(t)
+
+	w, response := postUserImport(t, handler, []models.UserImportEntry{
+		{Username: "alice", Email: "alice@example.com", FullName: "Alice", Roles: []string{"admin"}},
+		{Username: "bob", Email: "bob@example.com", FullName: "Bob"},
+	})
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	if response.CreatedCount != 2 || response.RolledBack {
+		t.Fatalf("Expected 2 users created without rollback, got %+v", response)
+	}
+
+	for _, result := range response.Results {
+		if !result.Success || result.TemporaryPassword == "" {
+			t.Errorf("Expected row %d to succeed with a temporary password, got %+v", result.Row, result)
+		}
+	}
+
+	user, err := userService.GetUserByUsername(context.Background(), "alice")
+	if err != nil {
+		t.Fatalf("Expected imported user to exist: %v", err)
+	}
+	if len(user.Roles) != 1 || user.Roles[0].Name != "admin" {
+		t.Errorf("Expected alice to have the admin role, got %v", user.Roles)
+	}
+}
+
+func TestUserHandler_ImportUsers_DuplicateEmailRollsBack(t *testing.T) {
+	ctx := context.Background()
+	handler, _, store := setupTestUserHandler(t)
+
+	w, response := postUserImport(t, handler, []models.UserImportEntry{
+		{Username: "carol", Email: "carol@example.com", FullName: "Carol"},
+		{Username: "dave", Email: "CAROL@example.com", FullName: "Dave"},
+	})
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusBadRequest, w.Code, w.Body.String())
+	}
+	if !response.RolledBack || response.CreatedCount != 0 {
+		t.Errorf("Expected batch to be rolled back, got %+v", response)
+	}
+	if response.Results[1].Error == "" {
+		t.Error("Expected duplicate email row to report an error")
+	}
+
+	users, err := store.ListUsers(ctx)
+	if err != nil {
+		t.Fatalf("Failed to list users: %v", err)
+	}
+	if len(users) != 0 {
+		t.Errorf("Expected no users to be created, got %d", len(users))
+	}
+}
+
+// failingImportStore fails to assign roles to one user and, optionally, to
+// delete users, to exercise a user import failing part-way through
+type failingImportStore struct {
+	storage.Store
+	failUsername string
+	failDeletes  bool
+}
+
+func (s *failingImportStore) WithTx(ctx context.Context, fn func(tx storage.Store) error) error {
+	return s.Store.WithTx(ctx, func(tx storage.Store) error {
+		return fn(&failingImportStore{Store: tx, failUsername: s.failUsername, failDeletes: s.failDeletes})
+	})
+}
+
+func (s *failingImportStore) AssignRoleToUser(ctx context.Context, userID, roleID string) error {
+	if user, err := s.Store.GetUser(ctx, userID); err == nil && user.Username == s.failUsername {
+		return fmt.Errorf("connection reset")
+	}
+	return s.Store.AssignRoleToUser(ctx, userID, roleID)
+}
+
+func (s *failingImportStore) DeleteUser(ctx context.Context, id string) error {
+	if s.failDeletes {
+		return fmt.Errorf("connection reset")
+	}
+	return s.Store.DeleteUser(ctx, id)
+}
+
+func TestUserHandler_ImportUsers_WriteFailureRollsBack(t *testing.T) {
+	ctx := context.Background()
+	for _, failDeletes := range []bool{false, true} {
+		memoryStore, err := storage.NewMemoryStore()
+		if err != nil {
+			t.Fatalf("Failed to create memory store: %v", err)
+		}
+		setupTestRolesAndPermissions(t, memoryStore)
+		store := &failingImportStore{Store: memoryStore, failUsername: "frank", failDeletes: failDeletes}
+		logger := services.NewLogger("error", false)
+		authService := services.NewAuthService("test-jwt-secret-32-characters-long!", 1*time.Hour, 24*time.Hour)
+		handler := NewUserHandler(services.NewUserService(store, authService, logger), authService, logger)
+
+		_, response := postUserImport(t, handler, []models.UserImportEntry{
+			{Username: "erin", Email: "erin@example.com", FullName: "Erin"},
+			{Username: "frank", Email: "frank@example.com", FullName: "Frank"},
+		})
+		if !response.RolledBack || response.CreatedCount != 0 {
+			t.Errorf("Expected the batch to be rolled back, got %+v", response)
+		}
+
+		users, _ := memoryStore.ListUsers(ctx)
+		if !failDeletes {
+			if len(users) != 0 || len(response.RollbackErrors) != 0 {
+				t.Errorf("Expected no users to be left, got %d (rollback errors: %v)", len(users), response.RollbackErrors)
+			}
+			continue
+		}
+		// Users that could not be removed are reported rather than hidden
+		if len(response.RollbackErrors) != len(users) || len(users) != 2 {
+			t.Errorf("Expected both leftover users to be reported, got %d users and errors %v", len(users), response.RollbackErrors)
+		}
+	}
+}
+
+// userAdminRequest sends a request through the registered routes with a bearer token
+func userAdminRequest(t *testing.T, mux *http.ServeMux, token, method, path, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	return w
+}
+
+func TestUserHandler_ListUsers(t *testing.T) {
+	ctx := context.Background()
+	handler, store := setupTestHandler(t)
+	mux := http.NewServeMux()
+	handler.RegisterRoutes(mux)
+	token := testToken(t, handler, "admin-1", "admin")
+
+	base := time.Now()
+	for i := 0; i < 5; i++ {
+		user := &models.User{
+			Username:  fmt.Sprintf("user%d", i),
+			Email:     fmt.Sprintf("user%d@example.com", i),
+			Password:  "secret-hash",
+			IsActive:  true,
+			CreatedAt: base.Add(time.Duration(i) * time.Minute),
+		}
+		if err := store.CreateUser(ctx, user); err != nil {
+			t.Fatalf("Failed to create user: %v", err)
+		}
+	}
+
+	tests := []struct {
+		query     string
+		status    int
+		wantUsers []string
+	}{
+		{query: "?page=1&limit=2", status: http.StatusOK, wantUsers: []string{"user4", "user3"}},
+		{query: "?page=3&limit=2", status: http.StatusOK, wantUsers: []string{"user0"}},
+		{query: "?page=4&limit=2", status: http.StatusOK, wantUsers: []string{}},
+		{query: "?limit=500", status: http.StatusOK, wantUsers: []string{"user4", "user3", "user2", "user1", "user0"}},
+		{query: "?page=0", status: http.StatusBadRequest},
+		{query: "?limit=-1", status: http.StatusBadRequest},
+		{query: "?page=abc", status: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		w := userAdminRequest(t, mux, token, http.MethodGet, "/api/users"+tt.query, "")
+		if w.Code != tt.status {
+			t.Fatalf("%s: expected status %d, got %d. Body: %s", tt.query, tt.status, w.Code, w.Body.String())
+		}
+		if tt.status != http.StatusOK {
+			continue
+		}
+		if strings.Contains(w.Body.String(), "password") || strings.Contains(w.Body.String(), "secret-hash") {
+			t.Errorf("%s: response exposes password hashes: %s", tt.query, w.Body.String())
+		}
+
+		var response models.UserListResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if response.Total != 5 {
+			t.Errorf("%s: expected total 5, got %d", tt.query, response.Total)
+		}
+		var got []string
+		for _, user := range response.Users {
+			got = append(got, user.Username)
+		}
+		if strings.Join(got, ",") != strings.Join(tt.wantUsers, ",") {
+			t.Errorf("%s: expected users %v, got %v", tt.query, tt.wantUsers, got)
+		}
+	}
+
+	w := userAdminRequest(t, mux, token, http.MethodGet, "/api/users?limit=500", "")
+	var response models.UserListResponse
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if response.Limit != 100 {
+		t.Errorf("Expected limit to be capped at 100, got %d", response.Limit)
+	}
+}
+
+func TestUserHandler_AdminOnly(t *testing.T) {
+	handler, store := setupTestHandler(t)
+	mux := http.NewServeMux()
+	handler.RegisterRoutes(mux)
+	createUserWithRole(t, store, "viewer-1", "viewer-role-id")
+	token := testToken(t, handler, "viewer-1", "viewer")
+
+	for _, tc := range []struct{ method, path string }{
+		{http.MethodGet, "/api/users"},
+		{http.MethodGet, "/api/users/viewer-1"},
+		{http.MethodPut, "/api/users/viewer-1"},
+		{http.MethodDelete, "/api/users/viewer-1"},
+	} {
+		w := userAdminRequest(t, mux, token, tc.method, tc.path, "{}")
+		if w.Code != http.StatusForbidden {
+			t.Errorf("%s %s: expected status %d for a viewer, got %d", tc.method, tc.path, http.StatusForbidden, w.Code)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/users", nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status %d without a token, got %d", http.StatusUnauthorized, w.Code)
+	}
+}
+
+func TestUserHandler_UpdateAndDeleteUser(t *testing.T) {
+	handler, store := setupTestHandler(t)
+	mux := http.NewServeMux()
+	handler.RegisterRoutes(mux)
+	createUserWithRole(t, store, "admin-1", "admin-role-id")
+	createUserWithRole(t, store, "bob", "viewer-role-id")
+	token := testToken(t, handler, "admin-1", "admin")
+
+	w := userAdminRequest(t, mux, token, http.MethodPut, "/api/users/bob", `{"is_active": false, "roles": ["admin"]}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var updated models.User
+	if err := json.Unmarshal(w.Body.Bytes(), &updated); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if updated.IsActive {
+		t.Error("Expected bob to be deactivated")
+	}
+	if len(updated.Roles) != 1 || updated.Roles[0].Name != "admin" {
+		t.Errorf("Expected bob's roles to be replaced with admin, got %v", updated.Roles)
+	}
+
+	w = userAdminRequest(t, mux, token, http.MethodPut, "/api/users/bob", `{"roles": ["no-such-role"]}`)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for an unknown role, got %d", http.StatusBadRequest, w.Code)
+	}
+
+	w = userAdminRequest(t, mux, token, http.MethodDelete, "/api/users/admin-1", "")
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d when deleting yourself, got %d", http.StatusBadRequest, w.Code)
+	}
+
+	w = userAdminRequest(t, mux, token, http.MethodDelete, "/api/users/bob", "")
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusNoContent, w.Code, w.Body.String())
+	}
+	w = userAdminRequest(t, mux, token, http.MethodGet, "/api/users/bob", "")
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d after deletion, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+func TestUserHandler_UserRoles(t *testing.T) {
+	handler, store := setupTestHandler(t)
+	mux := http.NewServeMux()
+	handler.RegisterRoutes(mux)
+	createUserWithRole(t, store, "admin-1", "admin-role-id")
+	createUserWithRole(t, store, "bob", "viewer-role-id")
+	token := testToken(t, handler, "admin-1", "admin")
+
+	roleNames := func(w *httptest.ResponseRecorder) string {
+		t.Helper()
+		var response struct {
+			Roles []*models.Role `json:"roles"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to decode response: %v (body: %s)", err, w.Body.String())
+		}
+		var names []string
+		for _, role := range response.Roles {
+			names = append(names, role.Name)
+		}
+		return strings.Join(names, ",")
+	}
+
+	t.Run("assign role", func(t *testing.T) {
+		w := userAdminRequest(t, mux, token, http.MethodPost, "/api/users/bob/roles", `{"role_id": "admin-role-id"}`)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		if got := roleNames(w); got != "viewer,admin" {
+			t.Errorf("Expected roles viewer,admin, got %s", got)
+		}
+	})
+
+	t.Run("reassigning a role is a no-op", func(t *testing.T) {
+		w := userAdminRequest(t, mux, token, http.MethodPost, "/api/users/bob/roles", `{"role_id": "admin-role-id"}`)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		if got := roleNames(w); got != "viewer,admin" {
+			t.Errorf("Expected roles viewer,admin, got %s", got)
+		}
+	})
+
+	t.Run("unknown role", func(t *testing.T) {
+		w := userAdminRequest(t, mux, token, http.MethodPost, "/api/users/bob/roles", `{"role_id": "no-such-role"}`)
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected status %d, got %d", http.StatusNotFound, w.Code)
+		}
+	})
+
+	t.Run("unknown user", func(t *testing.T) {
+		w := userAdminRequest(t, mux, token, http.MethodPost, "/api/users/nobody/roles", `{"role_id": "admin-role-id"}`)
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected status %d, got %d", http.StatusNotFound, w.Code)
+		}
+	})
+
+	t.Run("remove role", func(t *testing.T) {
+		w := userAdminRequest(t, mux, token, http.MethodDelete, "/api/users/bob/roles/viewer-role-id", "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		if got := roleNames(w); got != "admin" {
+			t.Errorf("Expected roles admin, got %s", got)
+		}
+	})
+
+	t.Run("remove role not held", func(t *testing.T) {
+		w := userAdminRequest(t, mux, token, http.MethodDelete, "/api/users/bob/roles/viewer-role-id", "")
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected status %d, got %d", http.StatusNotFound, w.Code)
+		}
+	})
+}
+
+func TestUserHandler_Activities(t *testing.T) {
+	ctx := context.Background()
+	handler, store := setupTestHandler(t)
+	mux := http.NewServeMux()
+	handler.RegisterRoutes(mux)
+	createUserWithRole(t, store, "admin-1", "admin-role-id")
+	createUserWithRole(t, store, "bob", "viewer-role-id")
+	createUserWithRole(t, store, "carol", "viewer-role-id")
+	adminToken := testToken(t, handler, "admin-1", "admin")
+	bobToken := testToken(t, handler, "bob", "viewer")
+
+	base := time.Now().Add(-time.Hour)
+	for i, activity := range []*models.UserActivity{
+		{UserID: "bob", Action: "login", Resource: "auth", IPAddress: "10.0.0.1"},
+		{UserID: "carol", Action: "login", Resource: "auth", IPAddress: "10.0.0.2"},
+		{UserID: "bob", Action: "add_comment", Resource: "incident", ResourceID: "inc-1", Metadata: map[string]interface{}{"length": 12}},
+	} {
+		activity.CreatedAt = base.Add(time.Duration(i) * time.Minute)
+		if err := store.LogUserActivity(ctx, activity); err != nil {
+			t.Fatalf("Failed to log activity: %v", err)
+		}
+	}
+
+	actions := func(w *httptest.ResponseRecorder) string {
+		t.Helper()
+		var response struct {
+			Activities []*models.UserActivity `json:"activities"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to decode response: %v (body: %s)", err, w.Body.String())
+		}
+		var got []string
+		for _, activity := range response.Activities {
+			got = append(got, activity.UserID+":"+activity.Action)
+		}
+		return strings.Join(got, ",")
+	}
+
+	tests := []struct {
+		name   string
+		token  string
+		path   string
+		status int
+		want   string
+	}{
+		{"user reads own activities", bobToken, "/api/users/bob/activities", http.StatusOK, "bob:add_comment,bob:login"},
+		{"limit", bobToken, "/api/users/bob/activities?limit=1", http.StatusOK, "bob:add_comment"},
+		{"user reads another user's activities", bobToken, "/api/users/carol/activities", http.StatusForbidden, ""},
+		{"admin reads any user's activities", adminToken, "/api/users/carol/activities", http.StatusOK, "carol:login"},
+		{"unknown user", adminToken, "/api/users/nobody/activities", http.StatusNotFound, ""},
+		{"invalid limit", adminToken, "/api/users/bob/activities?limit=0", http.StatusBadRequest, ""},
+		{"user reads audit log", bobToken, "/api/audit", http.StatusForbidden, ""},
+		{"admin reads audit log", adminToken, "/api/audit", http.StatusOK, "bob:add_comment,carol:login,bob:login"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := userAdminRequest(t, mux, tt.token, http.MethodGet, tt.path, "")
+			if w.Code != tt.status {
+				t.Fatalf("Expected status %d, got %d. Body: %s", tt.status, w.Code, w.Body.String())
+			}
+			if tt.status == http.StatusOK {
+				if got := actions(w); got != tt.want {
+					t.Errorf("Expected activities %s, got %s", tt.want, got)
+				}
+			}
+		})
+	}
+
+	// Users still cannot reach the rest of their own record
+	if w := userAdminRequest(t, mux, bobToken, http.MethodGet, "/api/users/bob", ""); w.Code != http.StatusForbidden {
+		t.Errorf("Expected status %d for a viewer reading their user record, got %d", http.StatusForbidden, w.Code)
+	}
+}
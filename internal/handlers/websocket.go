@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/websocket"
+
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/middleware"
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/models"
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/services"
+)
+
+const (
+	// webSocketEventBuffer is how many events a slow client may fall behind
+	// before it starts missing them
+	webSocketEventBuffer = 64
+	// webSocketWriteTimeout bounds sending one event to a client
+	webSocketWriteTimeout = 10 * time.Second
+)
+
+// webSocketMessage is sent by clients to change which incident they follow.
+// {"action": "subscribe", "incident_id": "..."} limits events to that
+// incident and {"action": "unsubscribe"} goes back to all incidents. Each
+// change is confirmed with a "subscribed" event.
+type webSocketMessage struct {
+	Action     string `json:"action"`
+	IncidentID string `json:"incident_id"`
+}
+
+// ConfigureEventBus enables GET /api/ws, streaming the events published on
+// bus to WebSocket clients
+func (h *Handler) ConfigureEventBus(bus *services.EventBus) {
+	h.eventBus = bus
+}
+
+// ConfigureWebSocketOrigin sets the URL the web UI is served from. Browsers
+// may only open WebSockets from its origin; when it is empty they may only
+// open them from the API's own host.
+func (h *Handler) ConfigureWebSocketOrigin(frontendURL string) {
+	h.webSocketOrigin = strings.TrimSuffix(frontendURL, "/")
+}
+
+// handleWebSocketToken issues a short-lived token for opening a WebSocket.
+// Browsers cannot set an Authorization header on the handshake, so they pass
+// it as GET /api/ws?token=.
+func (h *Handler) handleWebSocketToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.writeErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	claims, ok := middleware.GetClaimsFromContext(r.Context())
+	if !ok || claims == nil {
+		h.writeErrorResponse(w, "Authentication required", http.StatusUnauthorized)
+		return
+	}
+
+	token, expiresAt, err := h.authService.GenerateWebSocketToken(claims)
+	if err != nil {
+		h.writeErrorResponse(w, "Failed to issue token", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"token":      token,
+		"expires_at": expiresAt,
+	})
+}
+
+// handleWebSocket upgrades the request to a WebSocket streaming incident
+// lifecycle and comment events as JSON. ?incident_id= limits the stream to
+// one incident from the start.
+func (h *Handler) handleWebSocket(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.writeErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if h.eventBus == nil {
+		h.writeErrorResponse(w, "Real-time updates are not enabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	incidentID := r.URL.Query().Get("incident_id")
+	server := websocket.Server{
+		Handshake: h.checkWebSocketOrigin,
+		Handler: func(ws *websocket.Conn) {
+			h.streamEvents(ws, incidentID)
+		},
+	}
+	server.ServeHTTP(w, r)
+}
+
+// checkWebSocketOrigin refuses handshakes that a browser sends from a page
+// on another origin, which would otherwise ride on the user's token. Clients
+// that are not browsers send no Origin and are let through.
+func (h *Handler) checkWebSocketOrigin(config *websocket.Config, r *http.Request) error {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return nil
+	}
+	if h.webSocketOrigin != "" {
+		if !strings.EqualFold(origin, h.webSocketOrigin) {
+			return fmt.Errorf("websocket origin %q not allowed", origin)
+		}
+		return nil
+	}
+	u, err := url.Parse(origin)
+	if err != nil || !strings.EqualFold(u.Host, r.Host) {
+		return fmt.Errorf("websocket origin %q not allowed", origin)
+	}
+	return nil
+}
+
+// streamEvents sends events to the client until it disconnects. A reader
+// goroutine applies subscription changes and ends the stream when the
+// connection closes; the subscription is dropped on the way out.
+func (h *Handler) streamEvents(ws *websocket.Conn, incidentID string) {
+	defer ws.Close()
+	// The server's read and write timeouts still apply to the hijacked connection
+	ws.SetDeadline(time.Time{})
+
+	events, unsubscribe := h.eventBus.Subscribe(webSocketEventBuffer)
+	defer unsubscribe()
+
+	var mu sync.Mutex
+	filter := incidentID
+
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			var data []byte
+			if err := websocket.Message.Receive(ws, &data); err != nil {
+				return
+			}
+			var msg webSocketMessage
+			if err := json.Unmarshal(data, &msg); err != nil {
+				continue
+			}
+			switch msg.Action {
+			case "subscribe":
+			case "unsubscribe":
+				msg.IncidentID = ""
+			default:
+				continue
+			}
+			mu.Lock()
+			filter = msg.IncidentID
+			mu.Unlock()
+
+			// Confirm the change so clients know which events follow it
+			ws.SetWriteDeadline(time.Now().Add(webSocketWriteTimeout))
+			confirmation := models.IncidentEvent{Event: "subscribed", IncidentID: msg.IncidentID, OccurredAt: time.Now()}
+			if err := websocket.JSON.Send(ws, confirmation); err != nil {
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case <-closed:
+			return
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			mu.Lock()
+			wanted := filter == "" || filter == event.IncidentID
+			mu.Unlock()
+			if !wanted {
+				continue
+			}
+
+			ws.SetWriteDeadline(time.Now().Add(webSocketWriteTimeout))
+			if err := websocket.JSON.Send(ws, event); err != nil {
+				return
+			}
+		}
+	}
+}
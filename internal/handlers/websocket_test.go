@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/websocket"
+
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/middleware"
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/models"
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/services"
+)
+
+func TestHandler_WebSocketEvents(t *testing.T) {
+	ctx := context.Background()
+	handler, store := setupTestHandler(t)
+	bus := services.NewEventBus()
+	handler.incidentService.SetEventBus(bus)
+	handler.ConfigureEventBus(bus)
+
+	mux := http.NewServeMux()
+	handler.RegisterRoutes(mux)
+	server := httptest.NewServer(middleware.MetricsMiddleware(handler.metricsService)(mux))
+	defer server.Close()
+	token := testToken(t, handler, "user-1", "admin")
+	createUserWithRole(t, store, "user-1", "admin-role-id")
+
+	followed, err := handler.incidentService.CreateIncident(ctx, "Queue backlog", "", models.SeverityHigh, nil)
+	if err != nil {
+		t.Fatalf("Failed to create incident: %v", err)
+	}
+	other, err := handler.incidentService.CreateIncident(ctx, "Disk full", "", models.SeverityLow, nil)
+	if err != nil {
+		t.Fatalf("Failed to create incident: %v", err)
+	}
+
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/ws?incident_id=" + followed.ID
+	config, err := websocket.NewConfig(wsURL, server.URL)
+	if err != nil {
+		t.Fatalf("Failed to create config: %v", err)
+	}
+	if _, err := websocket.DialConfig(config); err == nil {
+		t.Fatal("Expected the handshake to be rejected without a token")
+	}
+	config.Header.Set("Authorization", "Bearer "+token)
+	ws, err := websocket.DialConfig(config)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer ws.Close()
+
+	comment := func(incidentID, content string) {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodPost, server.URL+"/api/incidents/"+incidentID+"/comments", strings.NewReader(`{"content": "`+content+`"}`))
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Failed to create comment: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusCreated {
+			t.Fatalf("Expected status 201, got %d", resp.StatusCode)
+		}
+	}
+	receive := func() models.IncidentEvent {
+		t.Helper()
+		ws.SetReadDeadline(time.Now().Add(2 * time.Second))
+		var event models.IncidentEvent
+		if err := websocket.JSON.Receive(ws, &event); err != nil {
+			t.Fatalf("Failed to receive event: %v", err)
+		}
+		return event
+	}
+
+	// Only events for the followed incident are streamed
+	comment(other.ID, "Unrelated")
+	comment(followed.ID, "Draining the queue")
+	event := receive()
+	if event.Event != "comment.created" || event.IncidentID != followed.ID || event.Comment == nil || event.Comment.Content != "Draining the queue" {
+		t.Fatalf("Expected the followed incident's comment, got %+v", event)
+	}
+
+	// Subscribing to another incident switches the stream over
+	if err := websocket.JSON.Send(ws, map[string]string{"action": "subscribe", "incident_id": other.ID}); err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+	if event = receive(); event.Event != "subscribed" || event.IncidentID != other.ID {
+		t.Fatalf("Expected the subscription to be confirmed, got %+v", event)
+	}
+	if err := handler.incidentService.AcknowledgeIncident(ctx, followed.ID, "user-1"); err != nil {
+		t.Fatalf("Failed to acknowledge incident: %v", err)
+	}
+	if err := handler.incidentService.AcknowledgeIncident(ctx, other.ID, "user-1"); err != nil {
+		t.Fatalf("Failed to acknowledge incident: %v", err)
+	}
+	event = receive()
+	if event.Event != "incident.acknowledged" || event.IncidentID != other.ID || event.Incident == nil || event.Incident.Status != models.IncidentStatusAcknowledged {
+		t.Errorf("Expected the acknowledgement of the newly followed incident, got %+v", event)
+	}
+
+	// Disconnecting drops the subscription
+	ws.Close()
+	for deadline := time.Now().Add(2 * time.Second); bus.Subscribers() > 0; {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the subscription to end when the client disconnects")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestHandler_WebSocketTokenAndOrigin(t *testing.T) {
+	handler, store := setupTestHandler(t)
+	bus := services.NewEventBus()
+	handler.ConfigureEventBus(bus)
+
+	mux := http.NewServeMux()
+	handler.RegisterRoutes(mux)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	token := testToken(t, handler, "user-1", "admin")
+	createUserWithRole(t, store, "user-1", "admin-role-id")
+
+	request := func(method, path, bearer string) *http.Response {
+		t.Helper()
+		req, _ := http.NewRequest(method, server.URL+path, nil)
+		req.Header.Set("Authorization", "Bearer "+bearer)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		return resp
+	}
+
+	resp := request(http.MethodPost, "/api/ws/token", token)
+	var issued struct {
+		Token     string    `json:"token"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+	json.NewDecoder(resp.Body).Decode(&issued)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || issued.Token == "" {
+		t.Fatalf("Expected a WebSocket token, got status %d", resp.StatusCode)
+	}
+	if ttl := time.Until(issued.ExpiresAt); ttl <= 0 || ttl > services.WebSocketTokenTTL {
+		t.Errorf("Expected the token to be short-lived, expires in %s", ttl)
+	}
+
+	// The WebSocket token is not an access token
+	resp = request(http.MethodGet, "/api/incidents", issued.Token)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected the WebSocket token to be refused by the API, got %d", resp.StatusCode)
+	}
+
+	dial := func(token, origin string) (*websocket.Conn, error) {
+		wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/ws?token=" + token
+		config, err := websocket.NewConfig(wsURL, origin)
+		if err != nil {
+			t.Fatalf("Failed to create config: %v", err)
+		}
+		return websocket.DialConfig(config)
+	}
+
+	// Browsers connect with the token in the query string
+	ws, err := dial(issued.Token, server.URL)
+	if err != nil {
+		t.Fatalf("Failed to connect with a WebSocket token: %v", err)
+	}
+	ws.Close()
+	if _, err := dial(token, server.URL); err == nil {
+		t.Error("Expected an access token to be refused in the query string")
+	}
+
+	// Pages on other origins cannot open a WebSocket
+	if _, err := dial(issued.Token, "https://attacker.example"); err == nil {
+		t.Error("Expected a cross-origin handshake to be refused")
+	}
+	handler.ConfigureWebSocketOrigin("https://incidents.example.com/")
+	if _, err := dial(issued.Token, server.URL); err == nil {
+		t.Error("Expected the API's own origin to be refused once a frontend URL is configured")
+	}
+	ws, err = dial(issued.Token, "https://incidents.example.com")
+	if err != nil {
+		t.Fatalf("Failed to connect from the frontend origin: %v", err)
+	}
+	ws.Close()
+}
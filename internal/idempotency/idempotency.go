@@ -18,6 +18,11 @@ type IdempotencyKey struct {
 type IdempotencyStore interface {
 	IsProcessed(key string) bool
 	MarkProcessed(key string, ttl time.Duration) error
+	// MarkIfNotProcessed checks and marks a key in one atomic step,
+	// reporting whether this call marked it
+	MarkIfNotProcessed(key string, ttl time.Duration) (bool, error)
+	// Unmark forgets a key so that it can be processed again
+	Unmark(key string) error
 }
 
 // MemoryIdempotencyStore is an in-memory implementation of IdempotencyStore
@@ -72,6 +77,32 @@ func (s *MemoryIdempotencyStore) MarkProcessed(key string, ttl time.Duration) er
 	return nil
 }
 
+// MarkIfNotProcessed marks a key as processed with a TTL unless it already is
+func (s *MemoryIdempotencyStore) MarkIfNotProcessed(key string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if idempotencyKey, exists := s.keys[key]; exists && !now.After(idempotencyKey.ExpiresAt) {
+		return false, nil
+	}
+	s.keys[key] = &IdempotencyKey{
+		Key:         key,
+		ProcessedAt: now,
+		ExpiresAt:   now.Add(ttl),
+	}
+	return true, nil
+}
+
+// Unmark removes a key
+func (s *MemoryIdempotencyStore) Unmark(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.keys, key)
+	return nil
+}
+
 // cleanupExpiredKeys periodically removes expired keys
 func (s *MemoryIdempotencyStore) cleanupExpiredKeys() {
 	ticker := time.NewTicker(5 * time.Minute) // Cleanup every 5 minutes
@@ -122,4 +153,19 @@ func (m *WebhookIdempotencyManager) IsAlreadyProcessed(payload []byte) bool {
 func (m *WebhookIdempotencyManager) MarkAsProcessed(payload []byte) error {
 	key := GenerateKeyFromPayload(payload)
 	return m.store.MarkProcessed(key, m.ttl)
-}
\ No newline at end of file
+}
+
+// TryMarkAsProcessed marks a webhook payload as processed unless it already
+// was, reporting whether this call marked it. Checking and marking in one
+// step keeps concurrent deliveries of a payload from both being processed.
+func (m *WebhookIdempotencyManager) TryMarkAsProcessed(payload []byte) (bool, error) {
+	key := GenerateKeyFromPayload(payload)
+	return m.store.MarkIfNotProcessed(key, m.ttl)
+}
+
+// UnmarkProcessed forgets a webhook payload, so that a redelivery after a
+// failure is processed again
+func (m *WebhookIdempotencyManager) UnmarkProcessed(payload []byte) error {
+	key := GenerateKeyFromPayload(payload)
+	return m.store.Unmark(key)
+}
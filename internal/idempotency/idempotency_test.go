@@ -88,4 +88,27 @@ func TestGenerateKeyFromPayload(t *testing.T) {
 	if key1 == "" || key3 == "" {
 		t.Error("Expected keys to be non-empty")
 	}
-}
\ No newline at end of file
+}
+func TestMemoryIdempotencyStore_MarkIfNotProcessed(t *testing.T) {
+	store := NewMemoryIdempotencyStore()
+
+	if marked, err := store.MarkIfNotProcessed("test-key", time.Minute); err != nil || !marked {
+		t.Fatalf("Expected the first call to mark the key, got %v (err: %v)", marked, err)
+	}
+	if marked, _ := store.MarkIfNotProcessed("test-key", time.Minute); marked {
+		t.Error("Expected a marked key not to be marked again")
+	}
+
+	if err := store.Unmark("test-key"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if marked, _ := store.MarkIfNotProcessed("test-key", time.Minute); !marked {
+		t.Error("Expected an unmarked key to be marked again")
+	}
+
+	// Expired keys count as unmarked
+	store.MarkProcessed("expired-key", -time.Second)
+	if marked, _ := store.MarkIfNotProcessed("expired-key", time.Minute); !marked {
+		t.Error("Expected an expired key to be marked again")
+	}
+}
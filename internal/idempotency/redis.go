@@ -0,0 +1,105 @@
+package idempotency
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// defaultRedisTimeout bounds dialing and each command unless the URL sets
+// its own timeouts
+const defaultRedisTimeout = 2 * time.Second
+
+// RedisOptions configures a RedisIdempotencyStore
+type RedisOptions struct {
+	// Client configures the connection pool
+	Client *redis.Options
+	// KeyPrefix namespaces the keys, so that one Redis can be shared
+	KeyPrefix string
+}
+
+// ParseRedisURL parses a URL of the form
+// redis[s]://[[user]:password@]host[:port][/db][?option=value]. rediss://
+// connects over TLS; the query accepts go-redis options such as
+// pool_size and dial_timeout.
+func ParseRedisURL(rawURL string) (RedisOptions, error) {
+	client, err := redis.ParseURL(rawURL)
+	if err != nil {
+		return RedisOptions{}, fmt.Errorf("invalid redis URL: %w", err)
+	}
+	if client.DB < 0 {
+		return RedisOptions{}, fmt.Errorf("invalid redis URL %q: database must be a non-negative number", rawURL)
+	}
+	if client.DialTimeout == 0 {
+		client.DialTimeout = defaultRedisTimeout
+	}
+	if client.ReadTimeout == 0 {
+		client.ReadTimeout = defaultRedisTimeout
+	}
+	if client.WriteTimeout == 0 {
+		client.WriteTimeout = defaultRedisTimeout
+	}
+	return RedisOptions{Client: client, KeyPrefix: "incd:webhook:"}, nil
+}
+
+// RedisIdempotencyStore keeps idempotency keys in Redis so that replicas
+// share them. Keys expire through Redis TTLs.
+type RedisIdempotencyStore struct {
+	client    *redis.Client
+	keyPrefix string
+}
+
+// NewRedisIdempotencyStore creates a Redis-backed idempotency store and
+// checks that the server is reachable
+func NewRedisIdempotencyStore(opts RedisOptions) (*RedisIdempotencyStore, error) {
+	client := redis.NewClient(opts.Client)
+	if err := client.Ping(context.Background()).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to connect to redis at %s: %w", opts.Client.Addr, err)
+	}
+	return &RedisIdempotencyStore{client: client, keyPrefix: opts.KeyPrefix}, nil
+}
+
+// IsProcessed checks if a key has already been processed. Redis errors are
+// logged and treated as not processed, so an outage does not drop webhooks.
+func (s *RedisIdempotencyStore) IsProcessed(key string) bool {
+	count, err := s.client.Exists(context.Background(), s.keyPrefix+key).Result()
+	if err != nil {
+		log.Printf("Failed to check idempotency key in redis: %v", err)
+		return false
+	}
+	return count > 0
+}
+
+// MarkProcessed marks a key as processed with a TTL
+func (s *RedisIdempotencyStore) MarkProcessed(key string, ttl time.Duration) error {
+	return s.client.Set(context.Background(), s.keyPrefix+key, "1", redisTTL(ttl)).Err()
+}
+
+// MarkIfNotProcessed marks a key as processed with a TTL unless it already
+// is. It is a single SET NX with an expiry, so replicas racing on the same
+// key cannot both mark it.
+func (s *RedisIdempotencyStore) MarkIfNotProcessed(key string, ttl time.Duration) (bool, error) {
+	return s.client.SetNX(context.Background(), s.keyPrefix+key, "1", redisTTL(ttl)).Result()
+}
+
+// Unmark deletes a key
+func (s *RedisIdempotencyStore) Unmark(key string) error {
+	return s.client.Del(context.Background(), s.keyPrefix+key).Err()
+}
+
+// redisTTL rounds a TTL up to a millisecond, the smallest PX accepts
+func redisTTL(ttl time.Duration) time.Duration {
+	if ttl < time.Millisecond {
+		return time.Millisecond
+	}
+	return ttl
+}
+
+// Close closes the connection pool
+func (s *RedisIdempotencyStore) Close() error {
+	return s.client.Close()
+}
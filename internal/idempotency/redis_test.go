@@ -0,0 +1,199 @@
+package idempotency
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func TestRedisIdempotencyStore_SharedAcrossInstances(t *testing.T) {
+	server := miniredis.RunT(t)
+	server.RequireAuth("s3cret")
+	opts, err := ParseRedisURL("redis://:s3cret@" + server.Addr() + "/2")
+	if err != nil {
+		t.Fatalf("Failed to parse URL: %v", err)
+	}
+
+	// Two replicas, each with its own store and manager
+	var managers []*WebhookIdempotencyManager
+	for i := 0; i < 2; i++ {
+		store, err := NewRedisIdempotencyStore(opts)
+		if err != nil {
+			t.Fatalf("Failed to create store: %v", err)
+		}
+		t.Cleanup(func() { store.Close() })
+		managers = append(managers, NewWebhookIdempotencyManager(store, 10*time.Minute))
+	}
+
+	payload := []byte(`{"status": "firing"}`)
+	if managers[1].IsAlreadyProcessed(payload) {
+		t.Fatal("Expected the payload not to be processed initially")
+	}
+	if err := managers[0].MarkAsProcessed(payload); err != nil {
+		t.Fatalf("Failed to mark payload: %v", err)
+	}
+	if !managers[1].IsAlreadyProcessed(payload) {
+		t.Error("Expected a payload processed by one instance to be seen by the other")
+	}
+	if managers[1].IsAlreadyProcessed([]byte(`{"status": "resolved"}`)) {
+		t.Error("Expected a different payload not to be processed")
+	}
+
+	key := "incd:webhook:" + GenerateKeyFromPayload(payload)
+	server.Select(2)
+	if !server.Exists(key) {
+		t.Errorf("Expected key %s in database 2, got %v", key, server.Keys())
+	}
+	if ttl := server.TTL(key); ttl != 10*time.Minute {
+		t.Errorf("Expected the key to expire in 10m, got %s", ttl)
+	}
+}
+
+func TestRedisIdempotencyStore_MarkIfNotProcessedIsAtomic(t *testing.T) {
+	server := miniredis.RunT(t)
+	opts, err := ParseRedisURL("redis://" + server.Addr())
+	if err != nil {
+		t.Fatalf("Failed to parse URL: %v", err)
+	}
+
+	// Replicas racing on the same delivery
+	const replicas = 10
+	var wg sync.WaitGroup
+	marked := make(chan bool, replicas)
+	for i := 0; i < replicas; i++ {
+		store, err := NewRedisIdempotencyStore(opts)
+		if err != nil {
+			t.Fatalf("Failed to create store: %v", err)
+		}
+		t.Cleanup(func() { store.Close() })
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ok, err := store.MarkIfNotProcessed("delivery", 10*time.Minute)
+			if err != nil {
+				t.Errorf("Failed to mark key: %v", err)
+			}
+			marked <- ok
+		}()
+	}
+	wg.Wait()
+	close(marked)
+
+	count := 0
+	for ok := range marked {
+		if ok {
+			count++
+		}
+	}
+	if count != 1 {
+		t.Errorf("Expected exactly one replica to mark the key, got %d", count)
+	}
+	if ttl := server.TTL("incd:webhook:delivery"); ttl != 10*time.Minute {
+		t.Errorf("Expected the key to be set with its TTL, got %s", ttl)
+	}
+
+	// An unmarked key can be marked again
+	store, err := NewRedisIdempotencyStore(opts)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+	if err := store.Unmark("delivery"); err != nil {
+		t.Fatalf("Failed to unmark key: %v", err)
+	}
+	if ok, err := store.MarkIfNotProcessed("delivery", time.Minute); err != nil || !ok {
+		t.Errorf("Expected an unmarked key to be marked again, got %v (err: %v)", ok, err)
+	}
+}
+
+func TestRedisIdempotencyStore_KeysExpire(t *testing.T) {
+	server := miniredis.RunT(t)
+	opts, err := ParseRedisURL("redis://" + server.Addr())
+	if err != nil {
+		t.Fatalf("Failed to parse URL: %v", err)
+	}
+	store, err := NewRedisIdempotencyStore(opts)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	if err := store.MarkProcessed("short-lived", 20*time.Millisecond); err != nil {
+		t.Fatalf("Failed to mark key: %v", err)
+	}
+	if !store.IsProcessed("short-lived") {
+		t.Fatal("Expected the key to be processed before it expires")
+	}
+	server.FastForward(40 * time.Millisecond)
+	if store.IsProcessed("short-lived") {
+		t.Error("Expected the key to expire with its TTL")
+	}
+}
+
+func TestRedisIdempotencyStore_Unavailable(t *testing.T) {
+	opts, err := ParseRedisURL("redis://127.0.0.1:1?dial_timeout=100ms&max_retries=-1")
+	if err != nil {
+		t.Fatalf("Failed to parse URL: %v", err)
+	}
+	if _, err := NewRedisIdempotencyStore(opts); err == nil {
+		t.Error("Expected an unreachable server to be rejected at startup")
+	}
+
+	server := miniredis.RunT(t)
+	server.RequireAuth("right")
+	opts, err = ParseRedisURL("redis://:wrong@" + server.Addr())
+	if err != nil {
+		t.Fatalf("Failed to parse URL: %v", err)
+	}
+	if _, err := NewRedisIdempotencyStore(opts); err == nil {
+		t.Error("Expected a wrong password to be rejected")
+	}
+
+	opts, err = ParseRedisURL("redis://:right@" + server.Addr() + "?dial_timeout=100ms&max_retries=-1")
+	if err != nil {
+		t.Fatalf("Failed to parse URL: %v", err)
+	}
+	store, err := NewRedisIdempotencyStore(opts)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+	server.Close()
+
+	// With Redis gone webhooks are processed rather than dropped
+	if store.IsProcessed("any") {
+		t.Error("Expected keys to read as unprocessed while redis is unavailable")
+	}
+	if err := store.MarkProcessed("any", time.Minute); err == nil {
+		t.Error("Expected marking a key to fail while redis is unavailable")
+	}
+}
+
+func TestParseRedisURL(t *testing.T) {
+	opts, err := ParseRedisURL("redis://cache.internal")
+	if err != nil || opts.Client.Addr != "cache.internal:6379" || opts.Client.DB != 0 || opts.Client.Password != "" || opts.Client.TLSConfig != nil {
+		t.Errorf("Unexpected options %+v (err: %v)", opts.Client, err)
+	}
+	if opts.Client.ReadTimeout != defaultRedisTimeout || opts.KeyPrefix != "incd:webhook:" {
+		t.Errorf("Expected default timeouts and key prefix, got %+v", opts)
+	}
+
+	opts, err = ParseRedisURL("rediss://:s3cret@cache.internal:6380/1?pool_size=20")
+	if err != nil {
+		t.Fatalf("Failed to parse URL: %v", err)
+	}
+	if opts.Client.TLSConfig == nil || opts.Client.TLSConfig.ServerName != "cache.internal" {
+		t.Errorf("Expected rediss:// to connect over TLS, got %+v", opts.Client.TLSConfig)
+	}
+	if opts.Client.Addr != "cache.internal:6380" || opts.Client.DB != 1 || opts.Client.Password != "s3cret" || opts.Client.PoolSize != 20 {
+		t.Errorf("Unexpected options %+v", opts.Client)
+	}
+
+	for _, rawURL := range []string{"http://cache:6379", "redis://cache:6379/abc", "redis://cache:6379/-1"} {
+		if _, err := ParseRedisURL(rawURL); err == nil {
+			t.Errorf("Expected %q to be rejected", rawURL)
+		}
+	}
+}
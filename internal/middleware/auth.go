@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"strings"
 
@@ -95,6 +96,40 @@ func OptionalAuthMiddleware(authService *services.AuthService) func(http.Handler
 	}
 }
 
+// WebSocketAuthMiddleware authenticates WebSocket handshakes. Browsers
+// cannot set headers on them, so a token from GenerateWebSocketToken is
+// accepted in the token query parameter; other clients can still send an
+// Authorization header.
+func WebSocketAuthMiddleware(authService *services.AuthService) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		headerAuth := AuthMiddleware(authService)(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token := r.URL.Query().Get("token")
+			if token == "" {
+				headerAuth.ServeHTTP(w, r)
+				return
+			}
+
+			claims, err := authService.ValidateWebSocketToken(token)
+			if err != nil {
+				statusCode := http.StatusUnauthorized
+				if !errors.Is(err, services.ErrTokenExpired) && !errors.Is(err, services.ErrInvalidToken) {
+					statusCode = http.StatusInternalServerError
+				}
+				http.Error(w, err.Error(), statusCode)
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), ClaimsContextKey, claims)
+			ctx = context.WithValue(ctx, UserIDContextKey, claims.UserID)
+			ctx = context.WithValue(ctx, "ip_address", GetClientIP(r))
+			ctx = context.WithValue(ctx, "user_agent", r.UserAgent())
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
 // RequireRole creates middleware that requires specific roles
 func RequireRole(authService *services.AuthService, roles ...string) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
@@ -115,8 +150,10 @@ func RequireRole(authService *services.AuthService, roles ...string) func(http.H
 	}
 }
 
-// RequirePermission creates middleware that requires specific permissions
-func RequirePermission(authService *services.AuthService, permissions ...string) func(http.Handler) http.Handler {
+// RequirePermission creates middleware that requires one of the user's roles
+// to grant action on resource. Roles are loaded from the auth service's
+// permission store on every request rather than read from the token.
+func RequirePermission(authService *services.AuthService, resource, action string) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			claims, ok := r.Context().Value(ClaimsContextKey).(*services.Claims)
@@ -125,7 +162,12 @@ func RequirePermission(authService *services.AuthService, permissions ...string)
 				return
 			}
 
-			if !authService.HasAnyPermission(claims, permissions) {
+			allowed, err := authService.UserHasPermission(r.Context(), claims.UserID, resource, action)
+			if err != nil {
+				http.Error(w, "Failed to check permissions", http.StatusInternalServerError)
+				return
+			}
+			if !allowed {
 				http.Error(w, "Insufficient permissions", http.StatusForbidden)
 				return
 			}
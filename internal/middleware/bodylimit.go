@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+)
+
+// MaxBodyBytes limits request bodies to limit bytes, and multipart uploads to
+// multipartLimit bytes so that attachments can be larger than other bodies.
+// Requests declaring a larger Content-Length get a 413 straight away; bodies
+// without one are cut off at the limit, so a handler reading past it gets an
+// error. A limit of 0 disables the check it applies to.
+func MaxBodyBytes(limit, multipartLimit int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if limit <= 0 && multipartLimit <= 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			max := limit
+			if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "multipart/form-data" {
+				max = multipartLimit
+			}
+			if max <= 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if r.ContentLength > max {
+				w.Header().Set("Content-Type", "application/json")
+				w.Header().Set("Connection", "close")
+				w.WriteHeader(http.StatusRequestEntityTooLarge)
+				json.NewEncoder(w).Encode(map[string]interface{}{
+					"status": "error",
+					"error":  "Request body too large",
+					"code":   http.StatusRequestEntityTooLarge,
+				})
+				return
+			}
+
+			r.Body = http.MaxBytesReader(w, r.Body, max)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
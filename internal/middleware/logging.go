@@ -32,14 +32,25 @@ func LoggingMiddleware(logger *services.Logger) func(http.Handler) http.Handler
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			// Log incoming request
 			logger.InfoWithRequest(r.Context(), "HTTP request received", map[string]interface{}{
-				"method": r.Method,
-				"path":   r.URL.Path,
-				"query":  r.URL.RawQuery,
-				"remote": r.RemoteAddr,
+				"method":     r.Method,
+				"path":       r.URL.Path,
+				"query":      redactedQuery(r),
+				"remote":     r.RemoteAddr,
 				"user_agent": r.UserAgent(),
 			})
 			
 			next.ServeHTTP(w, r)
 		})
 	}
-}
\ No newline at end of file
+}
+
+// redactedQuery returns the query string with the value of the token
+// parameter, which carries WebSocket tokens, hidden
+func redactedQuery(r *http.Request) string {
+	query := r.URL.Query()
+	if !query.Has("token") {
+		return r.URL.RawQuery
+	}
+	query.Set("token", "REDACTED")
+	return query.Encode()
+}
@@ -1,6 +1,9 @@
 package middleware
 
 import (
+	"bufio"
+	"fmt"
+	"net"
 	"net/http"
 	"strconv"
 	"time"
@@ -58,4 +61,17 @@ func (w *responseWriterWrapper) Write(data []byte) (int, error) {
 		w.written = true
 	}
 	return w.ResponseWriter.Write(data)
-}
\ No newline at end of file
+}
+
+// Hijack lets handlers take over the connection, e.g. for WebSockets
+func (w *responseWriterWrapper) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("response writer does not support hijacking")
+	}
+	if !w.written {
+		w.statusCode = http.StatusSwitchingProtocols
+		w.written = true
+	}
+	return hijacker.Hijack()
+}
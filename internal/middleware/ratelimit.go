@@ -0,0 +1,169 @@
+package middleware
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/ratelimit"
+)
+
+// RoleRateLimit is the request budget of one role
+type RoleRateLimit struct {
+	PerMinute float64
+	Burst     int
+}
+
+// ParseRoleRateLimits parses limits written as "role:per_minute:burst", e.g.
+// "viewer:60:10". Role names are matched case-insensitively.
+func ParseRoleRateLimits(specs []string) (map[string]RoleRateLimit, error) {
+	limits := make(map[string]RoleRateLimit, len(specs))
+	for _, spec := range specs {
+		parts := strings.Split(spec, ":")
+		if len(parts) != 3 || strings.TrimSpace(parts[0]) == "" {
+			return nil, fmt.Errorf("invalid role rate limit %q: expected role:per_minute:burst", spec)
+		}
+		perMinute, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+		if err != nil || perMinute <= 0 {
+			return nil, fmt.Errorf("invalid role rate limit %q: per_minute must be a positive number", spec)
+		}
+		burst, err := strconv.Atoi(strings.TrimSpace(parts[2]))
+		if err != nil || burst < 1 {
+			return nil, fmt.Errorf("invalid role rate limit %q: burst must be at least 1", spec)
+		}
+		limits[strings.ToLower(strings.TrimSpace(parts[0]))] = RoleRateLimit{PerMinute: perMinute, Burst: burst}
+	}
+	return limits, nil
+}
+
+// RoleRateLimiter throttles authenticated requests per user, with a budget
+// that depends on the user's roles. A user holding any role without a
+// configured limit, such as admin, is not throttled; otherwise the most
+// generous of their roles' limits applies.
+type RoleRateLimiter struct {
+	limits   map[string]RoleRateLimit
+	limiters map[string]*ratelimit.PerIPRateLimiter
+}
+
+// NewRoleRateLimiter creates a limiter for the given per-role limits
+func NewRoleRateLimiter(limits map[string]RoleRateLimit) *RoleRateLimiter {
+	limiters := make(map[string]*ratelimit.PerIPRateLimiter, len(limits))
+	for role, limit := range limits {
+		limiters[role] = ratelimit.NewPerIPRateLimiter(rate.Limit(limit.PerMinute/60), limit.Burst)
+	}
+	return &RoleRateLimiter{limits: limits, limiters: limiters}
+}
+
+// tier returns the role whose limit applies to a user, or false when the
+// user is not throttled
+func (l *RoleRateLimiter) tier(roles []string) (string, bool) {
+	tier := ""
+	for _, role := range roles {
+		role = strings.ToLower(role)
+		limit, ok := l.limits[role]
+		if !ok {
+			return "", false
+		}
+		if tier == "" || limit.PerMinute > l.limits[tier].PerMinute {
+			tier = role
+		}
+	}
+	return tier, tier != ""
+}
+
+// Middleware rejects requests over the caller's budget with 429 Too Many
+// Requests and a Retry-After header. It reads the principal set by
+// AuthMiddleware, so it must run after it; unauthenticated requests pass.
+func (l *RoleRateLimiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, ok := GetClaimsFromContext(r.Context())
+		if !ok || claims == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		tier, limited := l.tier(claims.Roles)
+		if !limited {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		limiter := l.limiters[tier].GetLimiter(claims.UserID)
+		if !limiter.Allow() {
+			writeRateLimited(w, limiter, l.limits[tier].Burst)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// writeRateLimited answers 429 Too Many Requests with a Retry-After header
+// saying when the limiter will next have a token
+func writeRateLimited(w http.ResponseWriter, limiter *rate.Limiter, burst int) {
+	reservation := limiter.Reserve()
+	retryAfter := int(math.Ceil(reservation.Delay().Seconds()))
+	reservation.Cancel()
+	if retryAfter < 1 {
+		retryAfter = 1
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+	w.Header().Set("X-RateLimit-Limit", strconv.Itoa(burst))
+	w.Header().Set("X-RateLimit-Remaining", "0")
+	w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(time.Duration(retryAfter)*time.Second).Unix(), 10))
+	http.Error(w, "Rate limit exceeded", http.StatusTooManyRequests)
+}
+
+// APIRateLimiter throttles API requests per authenticated user and, for
+// requests without a user, per client IP. Either limit may be disabled by
+// leaving its PerMinute at zero.
+type APIRateLimiter struct {
+	userLimit      RoleRateLimit
+	ipLimit        RoleRateLimit
+	users          *ratelimit.PerIPRateLimiter
+	ips            *ratelimit.PerIPRateLimiter
+	trustedProxies []*net.IPNet
+}
+
+// NewAPIRateLimiter creates a limiter with the given per-user and per-IP
+// budgets. Client IPs are taken from the connection, or from the forwarding
+// headers of requests arriving through one of the trusted proxies.
+func NewAPIRateLimiter(userLimit, ipLimit RoleRateLimit, trustedProxies []*net.IPNet) *APIRateLimiter {
+	l := &APIRateLimiter{userLimit: userLimit, ipLimit: ipLimit, trustedProxies: trustedProxies}
+	if userLimit.PerMinute > 0 {
+		l.users = ratelimit.NewPerIPRateLimiter(rate.Limit(userLimit.PerMinute/60), userLimit.Burst)
+	}
+	if ipLimit.PerMinute > 0 {
+		l.ips = ratelimit.NewPerIPRateLimiter(rate.Limit(ipLimit.PerMinute/60), ipLimit.Burst)
+	}
+	return l
+}
+
+// Middleware rejects requests over the caller's budget with 429 Too Many
+// Requests and a Retry-After header. Requests carrying the principal set by
+// AuthMiddleware are counted against the user, all others against their IP.
+func (l *APIRateLimiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		limiters, key, burst := l.ips, ratelimit.ClientIP(r, l.trustedProxies), l.ipLimit.Burst
+		if claims, ok := GetClaimsFromContext(r.Context()); ok && claims != nil {
+			limiters, key, burst = l.users, claims.UserID, l.userLimit.Burst
+		}
+		if limiters == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		limiter := limiters.GetLimiter(key)
+		if !limiter.Allow() {
+			writeRateLimited(w, limiter, burst)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/services"
+)
+
+// RecoveryMiddleware recovers from panics in later handlers, logs them with
+// the request ID and stack trace and responds with a JSON 500, so that one
+// failing request does not take down the server. It should be applied
+// outermost; the request ID is then read from the X-Request-ID response
+// header set by RequestIDMiddleware.
+func RecoveryMiddleware(logger *services.Logger, metricsService *services.MetricsService) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			wrapper := &responseWriterWrapper{ResponseWriter: w, statusCode: http.StatusOK}
+
+			defer func() {
+				recovered := recover()
+				if recovered == nil {
+					return
+				}
+				// net/http uses this panic to abort a response on purpose
+				if recovered == http.ErrAbortHandler {
+					panic(recovered)
+				}
+
+				ctx := r.Context()
+				if requestID := w.Header().Get("X-Request-ID"); requestID != "" && services.GetRequestID(ctx) == "" {
+					ctx = services.SetRequestID(ctx, requestID)
+				}
+				logger.ErrorWithRequest(ctx, "Panic while handling request", map[string]interface{}{
+					"method": r.Method,
+					"path":   r.URL.Path,
+					"panic":  fmt.Sprint(recovered),
+					"stack":  string(debug.Stack()),
+				})
+				if metricsService != nil {
+					metricsService.RecordPanic()
+				}
+
+				// Too late for a clean error once the handler started responding
+				if wrapper.written {
+					return
+				}
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusInternalServerError)
+				json.NewEncoder(w).Encode(map[string]interface{}{
+					"status": "error",
+					"error":  "Internal server error",
+					"code":   http.StatusInternalServerError,
+				})
+			}()
+
+			next.ServeHTTP(wrapper, r)
+		})
+	}
+}
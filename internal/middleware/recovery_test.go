@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/services"
+)
+
+func TestRecoveryMiddleware(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/panic", func(w http.ResponseWriter, r *http.Request) {
+		panic("nil map dereference")
+	})
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	var h http.Handler = mux
+	h = RequestIDMiddleware()(h)
+	h = RecoveryMiddleware(services.NewLogger("error", false), services.NewMetricsService(prometheus.NewRegistry()))(h)
+	server := httptest.NewServer(h)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/panic")
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("Expected status 500, got %d", resp.StatusCode)
+	}
+	if contentType := resp.Header.Get("Content-Type"); contentType != "application/json" {
+		t.Errorf("Expected a JSON response, got %q", contentType)
+	}
+	if resp.Header.Get("X-Request-ID") == "" {
+		t.Error("Expected the request ID to be kept on the error response")
+	}
+	var body map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if body["status"] != "error" {
+		t.Errorf("Expected an error response, got %v", body)
+	}
+
+	// The server is still up after the panic
+	resp, err = http.Get(server.URL + "/ok")
+	if err != nil {
+		t.Fatalf("Request after the panic failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Errorf("Expected status 204 after the panic, got %d", resp.StatusCode)
+	}
+}
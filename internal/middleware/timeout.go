@@ -0,0 +1,141 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// TimeoutMiddleware gives each request a deadline of d. The handler's context
+// is cancelled once it passes, and the client gets a JSON 503 unless the
+// handler finished first, so a stuck query cannot hold a connection until
+// the server's write timeout. Responses are buffered until the handler
+// returns or flushes; after a flush they are written straight through, and a
+// timeout can only cut them short. Requests for which exempt reports true,
+// such as long-lived WebSockets and downloads, are passed through untouched.
+// A duration of 0 disables the timeout.
+func TimeoutMiddleware(d time.Duration, exempt func(*http.Request) bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if d <= 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if exempt != nil && exempt(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx, cancel := context.WithTimeout(r.Context(), d)
+			defer cancel()
+
+			tw := &timeoutWriter{w: w, header: make(http.Header), code: http.StatusOK}
+			done := make(chan struct{})
+			panicked := make(chan interface{}, 1)
+			go func() {
+				defer func() {
+					if p := recover(); p != nil {
+						panicked <- p
+					}
+				}()
+				next.ServeHTTP(tw, r.WithContext(ctx))
+				close(done)
+			}()
+
+			select {
+			case p := <-panicked:
+				// Re-panic on the serving goroutine so RecoveryMiddleware sees it
+				panic(p)
+			case <-done:
+				tw.mu.Lock()
+				defer tw.mu.Unlock()
+				if tw.streaming {
+					return
+				}
+				for key, values := range tw.header {
+					w.Header()[key] = values
+				}
+				w.WriteHeader(tw.code)
+				w.Write(tw.buf.Bytes())
+			case <-ctx.Done():
+				tw.mu.Lock()
+				defer tw.mu.Unlock()
+				tw.timedOut = true
+				if tw.streaming {
+					// Too late for a 503, the response has started
+					return
+				}
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusServiceUnavailable)
+				json.NewEncoder(w).Encode(map[string]interface{}{
+					"status": "error",
+					"error":  "Request timed out",
+					"code":   http.StatusServiceUnavailable,
+				})
+			}
+		})
+	}
+}
+
+// timeoutWriter buffers a response until the handler returns or flushes.
+// Writes after the request timed out fail with http.ErrHandlerTimeout.
+type timeoutWriter struct {
+	w           http.ResponseWriter
+	mu          sync.Mutex
+	header      http.Header
+	buf         bytes.Buffer
+	code        int
+	wroteHeader bool
+	timedOut    bool
+	streaming   bool
+}
+
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.header
+}
+
+func (tw *timeoutWriter) WriteHeader(code int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut || tw.wroteHeader {
+		return
+	}
+	tw.code = code
+	tw.wroteHeader = true
+}
+
+func (tw *timeoutWriter) Write(data []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	tw.wroteHeader = true
+	if tw.streaming {
+		return tw.w.Write(data)
+	}
+	return tw.buf.Write(data)
+}
+
+// Flush sends the buffered response and switches to writing straight through
+func (tw *timeoutWriter) Flush() {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut {
+		return
+	}
+	if !tw.streaming {
+		for key, values := range tw.header {
+			tw.w.Header()[key] = values
+		}
+		tw.w.WriteHeader(tw.code)
+		tw.w.Write(tw.buf.Bytes())
+		tw.buf.Reset()
+		tw.streaming = true
+	}
+	if flusher, ok := tw.w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
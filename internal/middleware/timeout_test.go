@@ -0,0 +1,99 @@
+package middleware
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTimeoutMiddleware(t *testing.T) {
+	cancelled := make(chan struct{})
+	mux := http.NewServeMux()
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+			close(cancelled)
+		case <-time.After(5 * time.Second):
+		}
+		w.Write([]byte("too late"))
+	})
+	mux.HandleFunc("/fast", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Handled", "yes")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("done"))
+	})
+	h := TimeoutMiddleware(50*time.Millisecond, nil)(mux)
+
+	start := time.Now()
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/slow", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("Expected status 503, got %d: %s", w.Code, w.Body.String())
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected the slow request to be cut off at the timeout, took %s", elapsed)
+	}
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Error("Expected the slow handler's context to be cancelled")
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/fast", nil))
+	if w.Code != http.StatusCreated || w.Body.String() != "done" || w.Header().Get("X-Handled") != "yes" {
+		t.Errorf("Expected the fast response to pass through, got %d %q %v", w.Code, w.Body.String(), w.Header())
+	}
+}
+
+func TestTimeoutMiddleware_Streaming(t *testing.T) {
+	release := make(chan struct{})
+	mux := http.NewServeMux()
+	mux.HandleFunc("/stream", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/csv")
+		w.Write([]byte("first\n"))
+		w.(http.Flusher).Flush()
+		<-release
+		w.Write([]byte("second\n"))
+	})
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+		w.Write([]byte("done"))
+	})
+	exempt := func(r *http.Request) bool { return r.URL.Path == "/slow" }
+	h := TimeoutMiddleware(time.Second, exempt)(mux)
+
+	// A flushed response reaches the client before the handler returns
+	server := httptest.NewServer(h)
+	defer server.Close()
+	resp, err := http.Get(server.URL + "/stream")
+	if err != nil {
+		t.Fatalf("Failed to request stream: %v", err)
+	}
+	defer resp.Body.Close()
+	line, err := bufio.NewReader(resp.Body).ReadString('\n')
+	if err != nil || line != "first\n" || resp.Header.Get("Content-Type") != "text/csv" {
+		t.Errorf("Expected the flushed line and headers before the handler returned, got %q, %v (err: %v)", line, resp.Header, err)
+	}
+	close(release)
+
+	// Exempt requests are not cut off
+	slow := TimeoutMiddleware(10*time.Millisecond, exempt)(mux)
+	w := httptest.NewRecorder()
+	slow.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/slow", nil))
+	if w.Code != http.StatusOK || w.Body.String() != "done" {
+		t.Errorf("Expected an exempt request to run past the timeout, got %d %q", w.Code, w.Body.String())
+	}
+
+	// Asking for an upgrade is not enough to skip the timeout
+	req := httptest.NewRequest(http.MethodGet, "/slow", nil)
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Connection", "Upgrade")
+	w = httptest.NewRecorder()
+	TimeoutMiddleware(10*time.Millisecond, nil)(mux).ServeHTTP(w, req)
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected an upgrade request to get the timeout, got %d", w.Code)
+	}
+}
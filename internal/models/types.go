@@ -1,6 +1,7 @@
 package models
 
 import (
+	"strings"
 	"time"
 )
 
@@ -13,6 +14,25 @@ const (
 	IncidentStatusResolved     IncidentStatus = "resolved"
 )
 
+// ResolutionType classifies how an incident was resolved
+type ResolutionType string
+
+const (
+	ResolutionFixed         ResolutionType = "fixed"
+	ResolutionAutoRecovered ResolutionType = "auto_recovered"
+	ResolutionDuplicate     ResolutionType = "duplicate"
+	ResolutionFalsePositive ResolutionType = "false_positive"
+)
+
+// Valid reports whether t is one of the known resolution types
+func (t ResolutionType) Valid() bool {
+	switch t {
+	case ResolutionFixed, ResolutionAutoRecovered, ResolutionDuplicate, ResolutionFalsePositive:
+		return true
+	}
+	return false
+}
+
 // IncidentSeverity represents the severity level of an incident
 type IncidentSeverity string
 
@@ -23,6 +43,40 @@ const (
 	SeverityLow      IncidentSeverity = "low"
 )
 
+// IncidentPriority is the business urgency of an incident, from P1 (most
+// urgent) to P4, set independently of its technical severity
+type IncidentPriority string
+
+const (
+	PriorityP1 IncidentPriority = "P1"
+	PriorityP2 IncidentPriority = "P2"
+	PriorityP3 IncidentPriority = "P3"
+	PriorityP4 IncidentPriority = "P4"
+)
+
+// Valid reports whether p is one of the known priorities
+func (p IncidentPriority) Valid() bool {
+	switch p {
+	case PriorityP1, PriorityP2, PriorityP3, PriorityP4:
+		return true
+	}
+	return false
+}
+
+// DefaultPriority is the priority of an incident of the given severity when
+// none is chosen: critical is P1, high P2, medium P3 and anything else P4
+func DefaultPriority(severity IncidentSeverity) IncidentPriority {
+	switch severity {
+	case SeverityCritical:
+		return PriorityP1
+	case SeverityHigh:
+		return PriorityP2
+	case SeverityMedium:
+		return PriorityP3
+	}
+	return PriorityP4
+}
+
 // Incident represents an incident in the system
 type Incident struct {
 	ID          string            `json:"id"`
@@ -30,6 +84,7 @@ type Incident struct {
 	Description string            `json:"description"`
 	Status      IncidentStatus    `json:"status"`
 	Severity    IncidentSeverity  `json:"severity"`
+	Priority    IncidentPriority  `json:"priority"`
 	CreatedAt   time.Time         `json:"created_at"`
 	UpdatedAt   time.Time         `json:"updated_at"`
 	AckedAt     *time.Time        `json:"acked_at,omitempty"`
@@ -37,6 +92,46 @@ type Incident struct {
 	AssigneeID  string            `json:"assignee_id,omitempty"`
 	AlertIDs    []string          `json:"alert_ids"`
 	Labels      map[string]string `json:"labels"`
+	// OverflowAlertCount counts the distinct correlated alerts still firing
+	// that arrived after the incident reached the alerts-per-incident cap;
+	// they are not stored individually
+	OverflowAlertCount int `json:"overflow_alert_count"`
+	// StormSummary describes the alerts grouped into an alert storm incident;
+	// it is only set on storm incidents
+	StormSummary *AlertStormSummary `json:"storm_summary,omitempty"`
+	// ResolutionType and RootCauseCategory classify a resolved incident
+	ResolutionType    ResolutionType `json:"resolution_type,omitempty"`
+	RootCauseCategory string         `json:"root_cause_category,omitempty"`
+	// MergedInto is the ID of the incident this one was merged into as a duplicate
+	MergedInto string `json:"merged_into,omitempty"`
+	// NeedsAttention is computed, not stored: open, unassigned and older than the triage threshold
+	NeedsAttention bool `json:"needs_attention"`
+	// SLAStatus is computed, not stored, from the configured SLA targets; it
+	// is empty when the incident's severity has none
+	SLAStatus SLAStatus `json:"sla_status,omitempty"`
+}
+
+// SLAStatus is how an incident stands against its SLA targets
+type SLAStatus string
+
+const (
+	SLAOnTrack  SLAStatus = "on_track"
+	SLAAtRisk   SLAStatus = "at_risk"
+	SLABreached SLAStatus = "breached"
+)
+
+// AlertStormSummary records how many alerts a storm incident absorbed
+// instead of opening incidents of their own, with the labels of the first few
+type AlertStormSummary struct {
+	GroupedAlertCount int                 `json:"grouped_alert_count"`
+	SampleLabels      []map[string]string `json:"sample_labels"`
+}
+
+// Resolution describes how an incident was resolved
+type Resolution struct {
+	Note              string         `json:"note"`
+	Type              ResolutionType `json:"resolution_type,omitempty"`
+	RootCauseCategory string         `json:"root_cause_category,omitempty"`
 }
 
 // Alert represents an alert from Prometheus/Alertmanager
@@ -52,19 +147,29 @@ type Alert struct {
 	CreatedAt   time.Time         `json:"created_at"`
 }
 
+// AlertListResponse is a page of alerts
+type AlertListResponse struct {
+	Alerts []*Alert `json:"alerts"`
+	Total  int      `json:"total"`
+	Limit  int      `json:"limit"`
+	Offset int      `json:"offset"`
+}
+
 // NotificationChannel represents a notification destination
 type NotificationChannel struct {
-	ID          string                 `json:"id"`
-	Name        string                 `json:"name"`
-	Type        string                 `json:"type"`    // slack, email, telegram
-	Config      map[string]string      `json:"config"`
-	Enabled     bool                   `json:"enabled"`
-	Templates   map[string]string      `json:"templates"` // template_type -> template_content
-	UserID      string                 `json:"user_id,omitempty"`     // associated user
-	OrgID       string                 `json:"org_id,omitempty"`      // associated organization
-	Preferences *ChannelPreferences    `json:"preferences,omitempty"`
-	CreatedAt   time.Time              `json:"created_at"`
-	UpdatedAt   time.Time              `json:"updated_at"`
+	ID          string              `json:"id"`
+	Name        string              `json:"name"`
+	Type        string              `json:"type"` // slack, email, telegram, webhook
+	Config      map[string]string   `json:"config"`
+	Enabled     bool                `json:"enabled"`
+	Templates   map[string]string   `json:"templates"`         // template_type -> template_content
+	UserID      string              `json:"user_id,omitempty"` // associated user
+	OrgID       string              `json:"org_id,omitempty"`  // associated organization
+	Preferences *ChannelPreferences `json:"preferences,omitempty"`
+	Locale      string              `json:"locale,omitempty"`   // e.g. "en", "es"; overrides the user's preferred locale
+	Priority    int                 `json:"priority,omitempty"` // higher first when a severity's fan-out limit applies
+	CreatedAt   time.Time           `json:"created_at"`
+	UpdatedAt   time.Time           `json:"updated_at"`
 }
 
 // ChannelPreferences defines user preferences for notification channels
@@ -76,6 +181,7 @@ type ChannelPreferences struct {
 	BatchingEnabled    bool              `json:"batching_enabled"`
 	MaxBatchSize       int               `json:"max_batch_size"`
 	BatchingInterval   time.Duration     `json:"batching_interval"`
+	Locale             string            `json:"locale,omitempty"`    // preferred notification language of the channel owner
 }
 
 // QuietHours defines periods when notifications should be suppressed
@@ -83,8 +189,11 @@ type QuietHoursConfig struct {
 	Enabled   bool   `json:"enabled"`
 	StartTime string `json:"start_time"` // HH:MM format
 	EndTime   string `json:"end_time"`   // HH:MM format
-	Timezone  string `json:"timezone"`
-	Days      []int  `json:"days"` // 0=Sunday, 1=Monday, etc.
+	Timezone  string `json:"timezone"`   // IANA name such as Europe/Berlin; empty uses the server's time zone
+	Days      []int  `json:"days"`       // 0=Sunday, 1=Monday, etc.; an overnight window counts for the day it starts
+	// OverrideSeverity lets incidents of this severity or higher notify
+	// during quiet hours; empty suppresses everything
+	OverrideSeverity IncidentSeverity `json:"override_severity,omitempty"`
 }
 
 // NotificationTemplate defines a customizable notification template
@@ -95,6 +204,7 @@ type NotificationTemplate struct {
 	Channel       string            `json:"channel"`      // slack, email, telegram
 	Subject       string            `json:"subject"`      // for email
 	Body          string            `json:"body"`         // template with placeholders
+	Locale        string            `json:"locale,omitempty"` // language of the template, empty means the default locale
 	Variables     map[string]string `json:"variables"`    // available variables with descriptions
 	IsDefault     bool              `json:"is_default"`   // is this the default template for this type/channel
 	UserID        string            `json:"user_id,omitempty"`  // user-specific template
@@ -112,6 +222,9 @@ const (
 	DeliveryStatusDelivered NotificationDeliveryStatus = "delivered"
 	DeliveryStatusFailed    NotificationDeliveryStatus = "failed"
 	DeliveryStatusRetrying  NotificationDeliveryStatus = "retrying"
+	// DeliveryStatusDeadLettered marks a notification that exhausted its
+	// retries and is kept for inspection and manual requeueing
+	DeliveryStatusDeadLettered NotificationDeliveryStatus = "dead_lettered"
 )
 
 // NotificationHistory tracks the delivery history of notifications
@@ -127,6 +240,7 @@ type NotificationHistory struct {
 	Content     string                     `json:"content"`
 	Status      NotificationDeliveryStatus `json:"status"`
 	ErrorMsg    string                     `json:"error_msg,omitempty"`
+	ErrorChain  []string                   `json:"error_chain,omitempty"` // one error per failed attempt
 	RetryCount  int                        `json:"retry_count"`
 	ScheduledAt *time.Time                 `json:"scheduled_at,omitempty"`
 	SentAt      *time.Time                 `json:"sent_at,omitempty"`
@@ -135,6 +249,24 @@ type NotificationHistory struct {
 	UpdatedAt   time.Time                  `json:"updated_at"`
 }
 
+// NotificationHistoryFilter narrows a notification history listing
+type NotificationHistoryFilter struct {
+	Status NotificationDeliveryStatus `json:"status,omitempty"`
+	Since  *time.Time                 `json:"since,omitempty"`
+	Until  *time.Time                 `json:"until,omitempty"`
+	Page   int                        `json:"page"`
+	Limit  int                        `json:"limit"`
+}
+
+// NotificationHistoryResponse is a page of notification history
+type NotificationHistoryResponse struct {
+	History    []*NotificationHistory `json:"history"`
+	Total      int                    `json:"total"`
+	Page       int                    `json:"page"`
+	Limit      int                    `json:"limit"`
+	TotalPages int                    `json:"total_pages"`
+}
+
 // NotificationBatch represents a batch of notifications for efficient delivery
 type NotificationBatch struct {
 	ID            string                   `json:"id"`
@@ -162,6 +294,12 @@ type EscalationRule struct {
 	Targets      []string `json:"targets"` // user IDs or notification channel IDs
 }
 
+// SetEscalationPolicyRequest attaches an escalation policy to an incident;
+// an empty PolicyID detaches it
+type SetEscalationPolicyRequest struct {
+	PolicyID string `json:"policy_id"`
+}
+
 // OnCallSchedule represents an on-call schedule
 type OnCallSchedule struct {
 	ID       string        `json:"id"`
@@ -207,6 +345,9 @@ type User struct {
 	CreatedAt time.Time  `json:"created_at" db:"created_at"`
 	UpdatedAt time.Time  `json:"updated_at" db:"updated_at"`
 	LastLogin *time.Time `json:"last_login,omitempty" db:"last_login"`
+	// TOTPSecret is the encrypted TOTP secret, set once 2FA enrollment starts
+	TOTPSecret       string `json:"-" db:"totp_secret"`
+	TwoFactorEnabled bool   `json:"two_factor_enabled" db:"two_factor_enabled"`
 }
 
 // Role represents a role that can be assigned to users
@@ -247,6 +388,41 @@ type LoginRequest struct {
 	Username string `json:"username" validate:"required"`
 	Email    string `json:"email"`
 	Password string `json:"password" validate:"required"`
+	// TOTPCode is required when the user has two-factor authentication enabled
+	TOTPCode string `json:"totp_code,omitempty"`
+}
+
+// UserListResponse is a page of users for administration
+type UserListResponse struct {
+	Users      []*User `json:"users"`
+	Total      int     `json:"total"`
+	Page       int     `json:"page"`
+	Limit      int     `json:"limit"`
+	TotalPages int     `json:"total_pages"`
+}
+
+// AdminUpdateUserRequest changes another user's account. Omitted fields are
+// left unchanged; Roles, when present, replaces the user's roles by name.
+type AdminUpdateUserRequest struct {
+	IsActive *bool    `json:"is_active,omitempty"`
+	Roles    []string `json:"roles,omitempty"`
+}
+
+// AssignUserRoleRequest grants a role to a user
+type AssignUserRoleRequest struct {
+	RoleID string `json:"role_id"`
+}
+
+// TwoFactorEnrollResponse carries a freshly generated TOTP secret. It is
+// only returned once, at enrollment.
+type TwoFactorEnrollResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURL string `json:"otpauth_url"`
+}
+
+// TwoFactorVerifyRequest confirms a TOTP enrollment
+type TwoFactorVerifyRequest struct {
+	Code string `json:"code"`
 }
 
 // RegisterRequest represents a user registration request
@@ -280,6 +456,41 @@ type PasswordResetConfirm struct {
 	NewPassword string `json:"new_password" validate:"required,min=8"`
 }
 
+// UserImportEntry represents a single user row in a bulk import request
+type UserImportEntry struct {
+	Username string   `json:"username"`
+	Email    string   `json:"email"`
+	FullName string   `json:"full_name"`
+	Roles    []string `json:"roles"`
+}
+
+// BulkUserImportRequest represents a bulk user import request
+type BulkUserImportRequest struct {
+	Users []UserImportEntry `json:"users"`
+}
+
+// UserImportResult reports the outcome of importing a single row
+type UserImportResult struct {
+	Row               int    `json:"row"`
+	Username          string `json:"username"`
+	Email             string `json:"email"`
+	UserID            string `json:"user_id,omitempty"`
+	TemporaryPassword string `json:"temporary_password,omitempty"` // only returned once, at import time
+	Success           bool   `json:"success"`
+	Error             string `json:"error,omitempty"`
+}
+
+// BulkUserImportResponse represents the result of a bulk user import.
+// Imports are all-or-nothing: if any row fails, no users are created.
+// RollbackErrors lists users a failed import created but could not remove.
+type BulkUserImportResponse struct {
+	CreatedCount   int                `json:"created_count"`
+	FailedCount    int                `json:"failed_count"`
+	RolledBack     bool               `json:"rolled_back"`
+	RollbackErrors []string           `json:"rollback_errors,omitempty"`
+	Results        []UserImportResult `json:"results"`
+}
+
 // AuthResponse represents an authentication response with JWT token
 type AuthResponse struct {
 	Token        string    `json:"token"`
@@ -310,6 +521,62 @@ type Metrics struct {
 	MTTR               time.Duration `json:"mttr"` // Mean Time To Resolve
 	IncidentsByStatus  map[string]int `json:"incidents_by_status"`
 	IncidentsBySeverity map[string]int `json:"incidents_by_severity"`
+	// Resolved incidents by resolution type and by root cause category;
+	// unclassified ones are counted as "uncategorized"
+	ResolvedByType      map[string]int `json:"resolved_by_type"`
+	ResolvedByRootCause map[string]int `json:"resolved_by_root_cause"`
+}
+
+// IncidentReport summarizes the incidents created within a time window
+type IncidentReport struct {
+	From                time.Time      `json:"from"`
+	To                  time.Time      `json:"to"`
+	TotalIncidents      int            `json:"total_incidents"`
+	IncidentsByStatus   map[string]int `json:"incidents_by_status"`
+	IncidentsBySeverity map[string]int `json:"incidents_by_severity"`
+	// Mean time to acknowledge and resolve over the incidents acknowledged
+	// and resolved so far, in seconds; 0 when there are none
+	MTTASeconds int64 `json:"mtta_seconds"`
+	MTTRSeconds int64 `json:"mttr_seconds"`
+	// Incidents that missed an SLA target, in total and by severity
+	SLABreaches           int            `json:"sla_breaches"`
+	SLABreachesBySeverity map[string]int `json:"sla_breaches_by_severity"`
+}
+
+// NotificationDeliveryCount is the number of notification history entries
+// with a channel type, notification type and status
+type NotificationDeliveryCount struct {
+	ChannelType      string                     `json:"channel_type"`
+	NotificationType string                     `json:"notification_type"`
+	Status           NotificationDeliveryStatus `json:"status"`
+	Count            int                        `json:"count"`
+}
+
+// NotificationDeliveryRate is the delivery outcome of one channel type and
+// notification type. Sent counts sent and delivered notifications, Failed
+// counts failed and dead-lettered ones and Pending the ones still queued or
+// retrying.
+type NotificationDeliveryRate struct {
+	ChannelType      string `json:"channel_type"`
+	NotificationType string `json:"notification_type"`
+	Sent             int    `json:"sent"`
+	Failed           int    `json:"failed"`
+	Pending          int    `json:"pending"`
+	// SuccessRate is Sent / (Sent + Failed), or nil before any notification
+	// has been sent or has failed
+	SuccessRate *float64 `json:"success_rate"`
+}
+
+// NotificationDeliveryReport summarizes the notifications attempted within
+// a time window
+type NotificationDeliveryReport struct {
+	From        time.Time                   `json:"from"`
+	To          time.Time                   `json:"to"`
+	Sent        int                         `json:"sent"`
+	Failed      int                         `json:"failed"`
+	Pending     int                         `json:"pending"`
+	SuccessRate *float64                    `json:"success_rate"`
+	Breakdown   []*NotificationDeliveryRate `json:"breakdown"`
 }
 
 // IncidentComment represents a comment or timeline event on an incident
@@ -322,6 +589,20 @@ type IncidentComment struct {
 	CommentType IncidentCommentType    `json:"comment_type" db:"comment_type"`
 	Metadata    map[string]interface{} `json:"metadata,omitempty" db:"metadata"`
 	CreatedAt   time.Time              `json:"created_at" db:"created_at"`
+	EditedAt    *time.Time             `json:"edited_at,omitempty" db:"edited_at"` // set when the comment is changed after posting
+}
+
+// UpdateCommentRequest replaces the content of a comment
+type UpdateCommentRequest struct {
+	Content string `json:"content"`
+}
+
+// CommentDraft is an unsent comment autosaved for one user on one incident
+type CommentDraft struct {
+	IncidentID string    `json:"incident_id" db:"incident_id"`
+	UserID     string    `json:"user_id" db:"user_id"`
+	Content    string    `json:"content" db:"content"`
+	UpdatedAt  time.Time `json:"updated_at" db:"updated_at"`
 }
 
 // IncidentCommentType represents the type of timeline event
@@ -332,11 +613,41 @@ const (
 	CommentTypeStatusChange    IncidentCommentType = "status_change"
 	CommentTypeAssignment      IncidentCommentType = "assignment"
 	CommentTypeSeverityChange  IncidentCommentType = "severity_change"
+	CommentTypePriorityChange  IncidentCommentType = "priority_change"
 	CommentTypeTagAdded        IncidentCommentType = "tag_added"
 	CommentTypeTagRemoved      IncidentCommentType = "tag_removed"
 	CommentTypeAttachmentAdded IncidentCommentType = "attachment_added"
+	CommentTypeEscalation      IncidentCommentType = "escalation"
+	CommentTypeSLABreach       IncidentCommentType = "sla_breach"
+	CommentTypeReminder        IncidentCommentType = "reminder"
+	CommentTypeAlertStorm      IncidentCommentType = "alert_storm"
 )
 
+// KeyEventType identifies a milestone in an incident's lifecycle
+type KeyEventType string
+
+const (
+	KeyEventCreated         KeyEventType = "created"
+	KeyEventAcknowledged    KeyEventType = "acknowledged"
+	KeyEventAssigned        KeyEventType = "assigned"
+	KeyEventSeverityChanged KeyEventType = "severity_changed"
+	KeyEventStatusChanged   KeyEventType = "status_changed"
+	KeyEventComment         KeyEventType = "comment"
+	KeyEventResolved        KeyEventType = "resolved"
+)
+
+// KeyEvent is a significant milestone derived from an incident's timeline
+type KeyEvent struct {
+	Type        KeyEventType `json:"type"`
+	Description string       `json:"description"`
+	UserID      string       `json:"user_id,omitempty"`
+	Timestamp   time.Time    `json:"timestamp"`
+	// SincePrevious and SinceCreated are the gaps in seconds to the previous
+	// key event and to the incident's creation
+	SincePrevious int64 `json:"since_previous_seconds"`
+	SinceCreated  int64 `json:"since_created_seconds"`
+}
+
 // IncidentTag represents a tag applied to an incident
 type IncidentTag struct {
 	ID         string     `json:"id" db:"id"`
@@ -351,18 +662,21 @@ type IncidentTag struct {
 
 // IncidentTemplate represents a template for creating incidents
 type IncidentTemplate struct {
-	ID                  string            `json:"id" db:"id"`
-	Name                string            `json:"name" db:"name"`
-	Description         string            `json:"description" db:"description"`
-	TitleTemplate       string            `json:"title_template" db:"title_template"`
-	DescriptionTemplate string            `json:"description_template" db:"description_template"`
-	Severity            IncidentSeverity  `json:"severity" db:"severity"`
-	DefaultTags         []TemplateTag     `json:"default_tags" db:"default_tags"`
-	IsActive            bool              `json:"is_active" db:"is_active"`
-	CreatedBy           *string           `json:"created_by,omitempty" db:"created_by"`
-	CreatedAt           time.Time         `json:"created_at" db:"created_at"`
-	UpdatedAt           time.Time         `json:"updated_at" db:"updated_at"`
-	User                *User             `json:"created_by_user,omitempty" db:"-"` // populated by joins
+	ID                  string           `json:"id" db:"id"`
+	Name                string           `json:"name" db:"name"`
+	Description         string           `json:"description" db:"description"`
+	TitleTemplate       string           `json:"title_template" db:"title_template"`
+	DescriptionTemplate string           `json:"description_template" db:"description_template"`
+	Severity            IncidentSeverity `json:"severity" db:"severity"`
+	// Priority is given to incidents created from the template; when empty it
+	// is derived from the severity
+	Priority    IncidentPriority `json:"priority,omitempty" db:"priority"`
+	DefaultTags []TemplateTag    `json:"default_tags" db:"default_tags"`
+	IsActive    bool             `json:"is_active" db:"is_active"`
+	CreatedBy   *string          `json:"created_by,omitempty" db:"created_by"`
+	CreatedAt   time.Time        `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time        `json:"updated_at" db:"updated_at"`
+	User        *User            `json:"created_by_user,omitempty" db:"-"` // populated by joins
 }
 
 // TemplateTag represents a default tag in a template
@@ -399,19 +713,43 @@ const (
 	AttachmentTypeGeneral   AttachmentType = "general"
 )
 
+// Valid reports whether t is one of the known attachment types
+func (t AttachmentType) Valid() bool {
+	switch t {
+	case AttachmentTypeRunbook, AttachmentTypeScreenshot, AttachmentTypeLog, AttachmentTypeDocument, AttachmentTypeGeneral:
+		return true
+	}
+	return false
+}
+
+// ParseTagFilter splits an incident search tag filter. A bare tag name
+// matches the tag whatever its value; "name=value" also requires the value.
+func ParseTagFilter(filter string) (name, value string, hasValue bool) {
+	name, value, hasValue = strings.Cut(filter, "=")
+	return strings.TrimSpace(name), strings.TrimSpace(value), hasValue
+}
+
 // IncidentSearchRequest represents a search request for incidents
 type IncidentSearchRequest struct {
-	Query      string              `json:"query"`
-	Status     []IncidentStatus    `json:"status"`
-	Severity   []IncidentSeverity  `json:"severity"`
-	AssigneeID *string             `json:"assignee_id"`
-	Tags       []string            `json:"tags"`
-	CreatedAfter  *time.Time       `json:"created_after"`
-	CreatedBefore *time.Time       `json:"created_before"`
-	Page       int                 `json:"page"`
-	Limit      int                 `json:"limit"`
-	OrderBy    string              `json:"order_by"` // created_at, updated_at, severity
-	OrderDir   string              `json:"order_dir"` // asc, desc
+	Query      string             `json:"query"`
+	Status     []IncidentStatus   `json:"status"`
+	Severity   []IncidentSeverity `json:"severity"`
+	Priority   []IncidentPriority `json:"priority,omitempty"`
+	AssigneeID *string            `json:"assignee_id"`
+	// Tags requires every listed tag; see ParseTagFilter for the format
+	Tags              []string         `json:"tags"`
+	CreatedAfter      *time.Time       `json:"created_after"`
+	CreatedBefore     *time.Time       `json:"created_before"`
+	Page              int              `json:"page"`
+	Limit             int              `json:"limit"`
+	OrderBy           string           `json:"order_by"`  // created_at, updated_at, severity
+	OrderDir          string           `json:"order_dir"` // asc, desc
+	NeedsAttention    *bool            `json:"needs_attention,omitempty"`
+	ResolutionType    []ResolutionType `json:"resolution_type,omitempty"`
+	RootCauseCategory []string         `json:"root_cause_category,omitempty"`
+	// AttentionCutoff is set by the service from the triage threshold; incidents
+	// created before it count as needing attention
+	AttentionCutoff time.Time      `json:"-"`
 }
 
 // IncidentSearchResponse represents a search response
@@ -423,6 +761,15 @@ type IncidentSearchResponse struct {
 	TotalPages   int         `json:"total_pages"`
 }
 
+// IncidentListResponse is a page of incidents
+type IncidentListResponse struct {
+	Incidents  []*Incident `json:"incidents"`
+	Total      int         `json:"total"`
+	Page       int         `json:"page"`
+	Limit      int         `json:"limit"`
+	TotalPages int         `json:"total_pages"`
+}
+
 // BulkOperationRequest represents a bulk operation request
 type BulkOperationRequest struct {
 	IncidentIDs []string           `json:"incident_ids"`
@@ -455,10 +802,139 @@ type BulkOperationFailure struct {
 	Error      string `json:"error"`
 }
 
+// BulkNotifyRequest asks for notifications to be re-sent for several incidents.
+// An empty notification type sends the one matching each incident's status.
+type BulkNotifyRequest struct {
+	IncidentIDs      []string `json:"incident_ids"`
+	NotificationType string   `json:"notification_type,omitempty"`
+}
+
+// BulkNotifyResult is the outcome of re-notifying a single incident
+type BulkNotifyResult struct {
+	IncidentID string `json:"incident_id"`
+	Status     string `json:"status"` // sent or failed
+	Error      string `json:"error,omitempty"`
+}
+
+// BulkNotifyResponse aggregates the results of a bulk re-notification
+type BulkNotifyResponse struct {
+	SentCount   int                `json:"sent_count"`
+	FailedCount int                `json:"failed_count"`
+	Results     []BulkNotifyResult `json:"results"`
+}
+
+// LifecycleWebhook is an outbound webhook that receives an
+// IncidentLifecycleEvent on every incident status change, e.g. to keep ChatOps
+// tools in sync. Unlike notification channels it carries no human-readable
+// message.
+type LifecycleWebhook struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	URL     string `json:"url"`
+	Enabled bool   `json:"enabled"`
+	// Severities limits events to incidents of these severities; empty matches all
+	Severities []IncidentSeverity `json:"severities,omitempty"`
+	// Labels limits events to incidents carrying all of these labels
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// Delivery tracking, updated after each event
+	LastStatus     string     `json:"last_status,omitempty"` // delivered or failed
+	LastError      string     `json:"last_error,omitempty"`
+	LastAttemptAt  *time.Time `json:"last_attempt_at,omitempty"`
+	DeliveredCount int        `json:"delivered_count"`
+	FailedCount    int        `json:"failed_count"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// LifecycleWebhookRequest creates or replaces a lifecycle webhook
+type LifecycleWebhookRequest struct {
+	Name       string             `json:"name"`
+	URL        string             `json:"url"`
+	Enabled    *bool              `json:"enabled,omitempty"` // defaults to true
+	Severities []IncidentSeverity `json:"severities,omitempty"`
+	Labels     map[string]string  `json:"labels,omitempty"`
+}
+
+// IncidentLifecycleEvent is the body posted to lifecycle webhooks
+type IncidentLifecycleEvent struct {
+	Event          string            `json:"event"` // incident.acknowledged, incident.resolved, ...
+	IncidentID     string            `json:"incident_id"`
+	Title          string            `json:"title"`
+	Status         IncidentStatus    `json:"status"`
+	PreviousStatus IncidentStatus    `json:"previous_status"`
+	Severity       IncidentSeverity  `json:"severity"`
+	AssigneeID     string            `json:"assignee_id,omitempty"`
+	Labels         map[string]string `json:"labels,omitempty"`
+	OccurredAt     time.Time         `json:"occurred_at"`
+}
+
+// IncidentEvent is a real-time incident update streamed to WebSocket clients
+type IncidentEvent struct {
+	Event      string           `json:"event"` // incident.created, incident.acknowledged, ..., comment.created
+	IncidentID string           `json:"incident_id"`
+	Incident   *Incident        `json:"incident,omitempty"`
+	Comment    *IncidentComment `json:"comment,omitempty"`
+	OccurredAt time.Time        `json:"occurred_at"`
+}
+
+// CircuitBreakerStatus is the current state and counts of a circuit breaker
+type CircuitBreakerStatus struct {
+	Name                 string `json:"name"`
+	State                string `json:"state"`
+	Requests             uint32 `json:"requests"`
+	TotalSuccesses       uint32 `json:"total_successes"`
+	TotalFailures        uint32 `json:"total_failures"`
+	ConsecutiveSuccesses uint32 `json:"consecutive_successes"`
+	ConsecutiveFailures  uint32 `json:"consecutive_failures"`
+}
+
+// WebhookPayload is a raw webhook body kept so it can be replayed later
+type WebhookPayload struct {
+	ID         string    `json:"id" db:"id"`
+	Source     string    `json:"source" db:"source"` // e.g. alertmanager
+	Payload    string    `json:"payload" db:"payload"`
+	ReceivedAt time.Time `json:"received_at" db:"received_at"`
+}
+
+// CreateIncidentRequest represents a request to open an incident manually
+type CreateIncidentRequest struct {
+	Title       string           `json:"title"`
+	Description string           `json:"description"`
+	Severity    IncidentSeverity `json:"severity"`
+	// Priority defaults to the one derived from the severity
+	Priority IncidentPriority  `json:"priority,omitempty"`
+	Labels   map[string]string `json:"labels,omitempty"`
+}
+
+// SetPriorityRequest changes an incident's priority
+type SetPriorityRequest struct {
+	Priority IncidentPriority `json:"priority"`
+}
+
+// MergeIncidentsRequest names the duplicates to merge into an incident
+type MergeIncidentsRequest struct {
+	DuplicateIDs []string `json:"duplicate_ids"`
+}
+
 // CreateIncidentFromTemplateRequest represents a request to create incident from template
 type CreateIncidentFromTemplateRequest struct {
 	TemplateID  string            `json:"template_id"`
 	Variables   map[string]string `json:"variables"`
 	AssigneeID  *string           `json:"assignee_id"`
 	AdditionalTags []TemplateTag  `json:"additional_tags"`
-}
\ No newline at end of file
+}
+
+// TemplatePreviewRequest supplies the variables to render a template with
+type TemplatePreviewRequest struct {
+	Variables map[string]string `json:"variables"`
+}
+
+// TemplatePreview is an incident as a template would create it
+type TemplatePreview struct {
+	Title       string           `json:"title"`
+	Description string           `json:"description"`
+	Severity    IncidentSeverity `json:"severity"`
+	Priority    IncidentPriority `json:"priority"`
+}
@@ -1,8 +1,11 @@
 package ratelimit
 
 import (
+	"fmt"
+	"net"
 	"net/http"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -100,7 +103,7 @@ func RateLimitMiddleware(config *RateLimitConfig) func(http.Handler) http.Handle
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			// Get client IP
 			ip := getClientIP(r)
-			
+
 			// Get limiter for this IP
 			ipLimiter := limiter.GetLimiter(ip)
 			
@@ -164,6 +167,76 @@ func getClientIP(r *http.Request) string {
 	return ip
 }
 
+// ParseTrustedProxies parses the addresses of trusted reverse proxies, given
+// as IPs or CIDR ranges
+func ParseTrustedProxies(specs []string) ([]*net.IPNet, error) {
+	proxies := make([]*net.IPNet, 0, len(specs))
+	for _, spec := range specs {
+		spec = strings.TrimSpace(spec)
+		if !strings.Contains(spec, "/") {
+			ip := net.ParseIP(spec)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q: expected an IP or CIDR range", spec)
+			}
+			bits := 8 * net.IPv4len
+			if ip.To4() == nil {
+				bits = 8 * net.IPv6len
+			}
+			proxies = append(proxies, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(spec)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: expected an IP or CIDR range", spec)
+		}
+		proxies = append(proxies, network)
+	}
+	return proxies, nil
+}
+
+// ClientIP returns the address of the client that sent the request. The
+// forwarding headers can be set by anyone, so they are only believed when
+// the connection comes from a trusted proxy; X-Forwarded-For is then read
+// from the right, skipping trusted proxies, since a client can prepend
+// entries of its own.
+func ClientIP(r *http.Request, trustedProxies []*net.IPNet) string {
+	remote := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(remote); err == nil {
+		remote = host
+	}
+	if !isTrustedProxy(remote, trustedProxies) {
+		return remote
+	}
+
+	if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+		hops := strings.Split(forwarded, ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop := strings.TrimSpace(hops[i])
+			if hop != "" && !isTrustedProxy(hop, trustedProxies) {
+				return hop
+			}
+		}
+	}
+	if realIP := strings.TrimSpace(r.Header.Get("X-Real-IP")); realIP != "" {
+		return realIP
+	}
+	return remote
+}
+
+// isTrustedProxy reports whether an address is within the trusted proxies
+func isTrustedProxy(address string, trustedProxies []*net.IPNet) bool {
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return false
+	}
+	for _, network := range trustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
 // WebhookRateLimitWrapper wraps a handler function with rate limiting
 func WebhookRateLimitWrapper(config *RateLimitConfig, handler http.HandlerFunc) http.HandlerFunc {
 	if !config.Enabled {
@@ -2,6 +2,7 @@ package retry
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math"
 	"strings"
@@ -26,6 +27,10 @@ func DefaultRetryPolicy() *RetryPolicy {
 	}
 }
 
+// ErrMaxAttemptsExceeded is wrapped by Execute's error when every attempt
+// failed with a retryable error
+var ErrMaxAttemptsExceeded = errors.New("max retry attempts exceeded")
+
 // RetryableFunc is a function that can be retried
 type RetryableFunc func() error
 
@@ -111,8 +116,8 @@ func (r *Retryer) Execute(ctx context.Context, fn RetryableFunc) error {
 			// Continue to next attempt
 		}
 	}
-	
-	return fmt.Errorf("max retry attempts (%d) exceeded, last error: %w", r.policy.MaxAttempts, lastErr)
+
+	return fmt.Errorf("%w (%d), last error: %w", ErrMaxAttemptsExceeded, r.policy.MaxAttempts, lastErr)
 }
 
 // calculateDelay calculates the delay for the given attempt using exponential backoff
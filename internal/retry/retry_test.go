@@ -13,6 +13,7 @@ func TestRetryer_Execute(t *testing.T) {
 		policy      *RetryPolicy
 		fn          RetryableFunc
 		wantErr     bool
+		exhausted   bool
 		expectCalls int
 	}{
 		{
@@ -62,6 +63,7 @@ func TestRetryer_Execute(t *testing.T) {
 				return errors.New("persistent error")
 			},
 			wantErr:     true,
+			exhausted:   true,
 			expectCalls: 2,
 		},
 		{
@@ -96,6 +98,9 @@ func TestRetryer_Execute(t *testing.T) {
 			if (err != nil) != tt.wantErr {
 				t.Errorf("Execute() error = %v, wantErr %v", err, tt.wantErr)
 			}
+			if errors.Is(err, ErrMaxAttemptsExceeded) != tt.exhausted {
+				t.Errorf("Execute() error = %v, expected exhausted %v", err, tt.exhausted)
+			}
 
 			if calls != tt.expectCalls {
 				t.Errorf("Expected %d calls, got %d", tt.expectCalls, calls)
@@ -0,0 +1,231 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/models"
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/storage"
+)
+
+// AckEscalationLabel records on an incident which user it was escalated to
+// after missing the acknowledgement deadline. Incidents carrying it are not
+// escalated again.
+const AckEscalationLabel = "ack_escalated_to"
+
+// ErrNoBackupOnCall is returned when no layer of a schedule with a second
+// person has anyone on call
+var ErrNoBackupOnCall = errors.New("on-call schedule has no backup responder")
+
+// AckEscalator pages the backup on-call for incidents that nobody
+// acknowledged within the timeout
+type AckEscalator struct {
+	store               storage.Store
+	incidentService     *IncidentService
+	notificationService *NotificationService
+	logger              *Logger
+	timeout             time.Duration
+	scheduleID          string
+
+	// now and deliver are replaced in tests
+	now     func() time.Time
+	deliver func(channel *models.NotificationChannel, subject, content string) error
+
+	stopChan chan struct{}
+	stopOnce sync.Once
+}
+
+// NewAckEscalator creates an escalator that pages the backup in scheduleID
+// once an incident has been open for timeout without acknowledgement
+func NewAckEscalator(store storage.Store, incidentService *IncidentService, notificationService *NotificationService, timeout time.Duration, scheduleID string, logger *Logger) *AckEscalator {
+	return &AckEscalator{
+		store:               store,
+		incidentService:     incidentService,
+		notificationService: notificationService,
+		logger:              logger,
+		timeout:             timeout,
+		scheduleID:          scheduleID,
+		now:                 time.Now,
+		deliver:             notificationService.sendRendered,
+		stopChan:            make(chan struct{}),
+	}
+}
+
+// Start checks for overdue incidents every 30 seconds until Stop is called
+func (e *AckEscalator) Start() {
+	go func() {
+		ticker := time.NewTicker(30 * time.Second)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if _, err := e.EscalateOverdue(context.Background()); err != nil {
+					e.logger.Error("Failed to escalate unacknowledged incidents", map[string]interface{}{
+						"schedule_id": e.scheduleID,
+						"error":       err.Error(),
+					})
+				}
+			case <-e.stopChan:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the escalator
+func (e *AckEscalator) Stop() {
+	e.stopOnce.Do(func() { close(e.stopChan) })
+}
+
+// EscalateOverdue escalates every open incident past the acknowledgement
+// deadline and returns how many were escalated
+func (e *AckEscalator) EscalateOverdue(ctx context.Context) (int, error) {
+	incidents, err := e.store.ListIncidents(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	now := e.now()
+	var schedule *models.OnCallSchedule
+	escalated := 0
+	for _, incident := range incidents {
+		if incident.Status != models.IncidentStatusOpen || incident.Labels[AckEscalationLabel] != "" {
+			continue
+		}
+		if now.Sub(incident.CreatedAt) < e.timeout {
+			continue
+		}
+
+		// Only load the schedule once something is actually overdue
+		if schedule == nil {
+			if schedule, err = e.store.GetOnCallSchedule(ctx, e.scheduleID); err != nil {
+				return escalated, fmt.Errorf("on-call schedule %s: %w", e.scheduleID, err)
+			}
+		}
+
+		paged, err := e.escalate(ctx, incident, schedule, now)
+		if err != nil {
+			e.logger.Error("Failed to escalate incident", map[string]interface{}{
+				"incident_id": incident.ID,
+				"error":       err.Error(),
+			})
+			continue
+		}
+		if paged {
+			escalated++
+		}
+	}
+
+	return escalated, nil
+}
+
+// escalate pages the backup on-call through their notification channels and
+// records the escalation on the incident and its timeline. It reports false,
+// paging nobody, when the incident was acknowledged since it was listed.
+func (e *AckEscalator) escalate(ctx context.Context, incident *models.Incident, schedule *models.OnCallSchedule, now time.Time) (bool, error) {
+	// Re-read so an acknowledgement since the scan stops escalation
+	incident, err := e.store.GetIncident(ctx, incident.ID)
+	if err != nil {
+		return false, err
+	}
+	if incident.Status != models.IncidentStatusOpen {
+		return false, nil
+	}
+
+	layer, backupID, err := backupOnCall(schedule, now)
+	if err != nil {
+		return false, err
+	}
+
+	backupName := backupID
+	if user, err := e.store.GetUser(ctx, backupID); err == nil && user.Username != "" {
+		backupName = user.Username
+	}
+
+	subject := fmt.Sprintf("[%s] Unacknowledged incident: %s", incident.Severity, incident.Title)
+	content := fmt.Sprintf("Incident %s has not been acknowledged within %s and was escalated to you as backup on-call (%s, layer %s).",
+		incident.ID, e.timeout, schedule.Name, layer.Name)
+
+	channels, err := e.store.ListNotificationChannels(ctx)
+	if err != nil {
+		return false, err
+	}
+	delivered := 0
+	for _, channel := range channels {
+		if !channel.Enabled || channel.UserID != backupID {
+			continue
+		}
+		if err := e.deliver(channel, subject, content); err != nil {
+			e.logger.Error("Failed to page backup on-call", map[string]interface{}{
+				"incident_id": incident.ID,
+				"channel_id":  channel.ID,
+				"error":       err.Error(),
+			})
+			continue
+		}
+		delivered++
+	}
+	if delivered == 0 {
+		e.logger.Warn("Backup on-call could not be paged", map[string]interface{}{
+			"incident_id": incident.ID,
+			"user_id":     backupID,
+		})
+	}
+
+	// Paging takes a while; write the label onto the incident as it is now so
+	// an acknowledgement in the meantime is not reverted
+	current, err := e.store.GetIncident(ctx, incident.ID)
+	if err != nil {
+		return true, err
+	}
+	if current.Labels == nil {
+		current.Labels = make(map[string]string)
+	}
+	current.Labels[AckEscalationLabel] = backupID
+	if err := e.incidentService.UpdateIncident(ctx, current); err != nil {
+		return true, err
+	}
+
+	metadata := map[string]interface{}{
+		"escalated_to": backupID,
+		"schedule_id":  schedule.ID,
+		"layer":        layer.Name,
+		"ack_timeout":  e.timeout.String(),
+		"delivered":    delivered,
+	}
+	_, err = e.incidentService.AddComment(ctx, incident.ID, "system",
+		fmt.Sprintf("Not acknowledged within %s; escalated to %s", e.timeout, backupName),
+		models.CommentTypeEscalation, metadata)
+	return true, err
+}
+
+// backupOnCall picks the person after the primary in the rotation of the
+// first layer that has more than one member and someone on call at the given
+// time. The primary is whoever that layer's rotation has on call.
+func backupOnCall(schedule *models.OnCallSchedule, at time.Time) (*models.ScheduleLayer, string, error) {
+	at, err := scheduleTime(schedule, at)
+	if err != nil {
+		return nil, "", err
+	}
+
+	for i := range schedule.Layers {
+		layer := &schedule.Layers[i]
+		if len(layer.Users) < 2 {
+			continue
+		}
+
+		primary, err := layerOnCallIndex(*layer, at)
+		if err != nil {
+			return nil, "", fmt.Errorf("layer %q: %w", layer.Name, err)
+		}
+		if primary < 0 {
+			continue
+		}
+		return layer, layer.Users[(primary+1)%len(layer.Users)], nil
+	}
+	return nil, "", ErrNoBackupOnCall
+}
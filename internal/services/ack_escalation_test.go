@@ -0,0 +1,174 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/config"
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/models"
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/storage"
+)
+
+func TestAckEscalator_PagesBackupAfterTimeout(t *testing.T) {
+	ctx := context.Background()
+	store, err := storage.NewMemoryStore()
+	if err != nil {
+		t.Fatalf("Failed to create memory store: %v", err)
+	}
+	logger := NewLogger("error", false)
+	incidentService := NewIncidentService(store, NewMetricsService(prometheus.NewRegistry()))
+	notificationService := NewNotificationService(&config.Config{Port: "8080"}, store, NewNotificationTemplateService(logger), NewMetricsService(prometheus.NewRegistry()), logger)
+
+	for _, user := range []*models.User{
+		{ID: "user-alice", Username: "alice", Email: "alice@example.com"},
+		{ID: "user-bob", Username: "bob", Email: "bob@example.com"},
+	} {
+		if err := store.CreateUser(ctx, user); err != nil {
+			t.Fatalf("Failed to create user: %v", err)
+		}
+		channel := &models.NotificationChannel{ID: user.Username + "-pager", Name: user.Username, Type: "slack", Enabled: true, UserID: user.ID}
+		if err := store.CreateNotificationChannel(ctx, channel); err != nil {
+			t.Fatalf("Failed to create channel: %v", err)
+		}
+	}
+	schedule := &models.OnCallSchedule{
+		ID:       "primary-rotation",
+		Name:     "Primary rotation",
+		Timezone: "UTC",
+		Layers:   []models.ScheduleLayer{{Name: "Primary", Users: []string{"user-alice", "user-bob"}, Start: time.Now().Add(-time.Hour)}},
+	}
+	if err := store.CreateOnCallSchedule(ctx, schedule); err != nil {
+		t.Fatalf("Failed to create schedule: %v", err)
+	}
+
+	incident, err := incidentService.CreateIncident(ctx, "Payments failing", "", models.SeverityCritical, []string{})
+	if err != nil {
+		t.Fatalf("Failed to create incident: %v", err)
+	}
+	acked, err := incidentService.CreateIncident(ctx, "Disk filling up", "", models.SeverityLow, []string{})
+	if err != nil {
+		t.Fatalf("Failed to create incident: %v", err)
+	}
+	if err := incidentService.AcknowledgeIncident(ctx, acked.ID, "user-alice"); err != nil {
+		t.Fatalf("Failed to acknowledge incident: %v", err)
+	}
+
+	clock := &fakeClock{current: incident.CreatedAt}
+	var paged []string
+	escalator := NewAckEscalator(store, incidentService, notificationService, 5*time.Minute, schedule.ID, logger)
+	escalator.now = clock.Now
+	escalator.deliver = func(channel *models.NotificationChannel, subject, content string) error {
+		paged = append(paged, channel.ID)
+		return nil
+	}
+
+	clock.Advance(4 * time.Minute)
+	if n, err := escalator.EscalateOverdue(ctx); err != nil || n != 0 {
+		t.Fatalf("Expected no escalation before the deadline (n=%d, err=%v)", n, err)
+	}
+
+	clock.Advance(2 * time.Minute)
+	if n, err := escalator.EscalateOverdue(ctx); err != nil || n != 1 {
+		t.Fatalf("Expected one escalation after the deadline (n=%d, err=%v)", n, err)
+	}
+	if len(paged) != 1 || paged[0] != "bob-pager" {
+		t.Fatalf("Expected the backup bob to be paged, got %v", paged)
+	}
+
+	escalated, _ := incidentService.GetIncident(ctx, incident.ID)
+	if escalated.Labels[AckEscalationLabel] != "user-bob" {
+		t.Errorf("Expected the escalation to be recorded on the incident, got labels %v", escalated.Labels)
+	}
+	timeline, err := incidentService.GetTimeline(ctx, incident.ID)
+	if err != nil || len(timeline) != 1 || timeline[0].CommentType != models.CommentTypeEscalation {
+		t.Fatalf("Expected one escalation timeline entry, got %+v (err: %v)", timeline, err)
+	}
+	if timeline[0].Metadata["escalated_to"] != "user-bob" {
+		t.Errorf("Unexpected escalation metadata: %v", timeline[0].Metadata)
+	}
+
+	clock.Advance(10 * time.Minute)
+	if n, _ := escalator.EscalateOverdue(ctx); n != 0 || len(paged) != 1 {
+		t.Errorf("Expected the incident not to be escalated twice (n=%d, paged=%v)", n, paged)
+	}
+}
+
+func TestAckEscalator_AcknowledgedWhilePaging(t *testing.T) {
+	ctx := context.Background()
+	store, err := storage.NewMemoryStore()
+	if err != nil {
+		t.Fatalf("Failed to create memory store: %v", err)
+	}
+	logger := NewLogger("error", false)
+	incidentService := NewIncidentService(store, NewMetricsService(prometheus.NewRegistry()))
+	notificationService := NewNotificationService(&config.Config{Port: "8080"}, store, NewNotificationTemplateService(logger), NewMetricsService(prometheus.NewRegistry()), logger)
+
+	channel := &models.NotificationChannel{ID: "bob-pager", Name: "bob", Type: "slack", Enabled: true, UserID: "user-bob"}
+	if err := store.CreateNotificationChannel(ctx, channel); err != nil {
+		t.Fatalf("Failed to create channel: %v", err)
+	}
+	schedule := &models.OnCallSchedule{
+		ID:     "primary-rotation",
+		Name:   "Primary rotation",
+		Layers: []models.ScheduleLayer{{Name: "Primary", Users: []string{"user-alice", "user-bob"}, Start: time.Now().Add(-time.Hour)}},
+	}
+	if err := store.CreateOnCallSchedule(ctx, schedule); err != nil {
+		t.Fatalf("Failed to create schedule: %v", err)
+	}
+	incident, err := incidentService.CreateIncident(ctx, "Payments failing", "", models.SeverityCritical, []string{})
+	if err != nil {
+		t.Fatalf("Failed to create incident: %v", err)
+	}
+
+	escalator := NewAckEscalator(store, incidentService, notificationService, 5*time.Minute, schedule.ID, logger)
+	escalator.now = func() time.Time { return incident.CreatedAt.Add(10 * time.Minute) }
+	escalator.deliver = func(channel *models.NotificationChannel, subject, content string) error {
+		// The primary acknowledges while the backup is being paged
+		return incidentService.AcknowledgeIncident(ctx, incident.ID, "user-alice")
+	}
+	if _, err := escalator.EscalateOverdue(ctx); err != nil {
+		t.Fatalf("Failed to escalate: %v", err)
+	}
+
+	stored, _ := incidentService.GetIncident(ctx, incident.ID)
+	if stored.Status != models.IncidentStatusAcknowledged || stored.AckedAt == nil {
+		t.Errorf("Expected the acknowledgement to survive the escalation, got %s", stored.Status)
+	}
+	if stored.Labels[AckEscalationLabel] != "user-bob" {
+		t.Errorf("Expected the escalation to be recorded, got labels %v", stored.Labels)
+	}
+}
+
+func TestBackupOnCall(t *testing.T) {
+	start := time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC) // a Monday
+	schedule := &models.OnCallSchedule{Timezone: "UTC", Layers: []models.ScheduleLayer{
+		{Name: "Solo", Users: []string{"a"}, Start: start},
+		{Name: "Weekly", Users: []string{"b", "c", "d"}, Start: start, Rotation: models.RotationType{Type: "weekly", Length: 1}},
+	}}
+
+	tests := []struct {
+		at       time.Time
+		expected string
+	}{
+		{at: start.Add(time.Hour), expected: "c"},                   // b is on call
+		{at: start.AddDate(0, 0, 7).Add(time.Hour), expected: "d"},  // rotated to c
+		{at: start.AddDate(0, 0, 14).Add(time.Hour), expected: "b"}, // rotated to d, wrapping round
+	}
+	for _, tt := range tests {
+		layer, backup, err := backupOnCall(schedule, tt.at)
+		if err != nil || layer.Name != "Weekly" || backup != tt.expected {
+			t.Errorf("backupOnCall(%s) = %v, %q, %v; expected Weekly, %q", tt.at, layer, backup, err, tt.expected)
+		}
+	}
+
+	// Layers nobody is on call in are skipped
+	if _, _, err := backupOnCall(schedule, start.Add(-time.Hour)); err != ErrNoBackupOnCall {
+		t.Errorf("Expected ErrNoBackupOnCall before the rotation starts, got %v", err)
+	}
+	if _, _, err := backupOnCall(&models.OnCallSchedule{Layers: schedule.Layers[:1]}, start.Add(time.Hour)); err != ErrNoBackupOnCall {
+		t.Errorf("Expected ErrNoBackupOnCall, got %v", err)
+	}
+}
@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"time"
@@ -15,17 +16,99 @@ type AlertService struct {
 	store           storage.Store
 	incidentService *IncidentService
 	metricsService  *MetricsService
+	downgradePolicy SeverityDowngradePolicy
+	severityFloors  []SeverityFloor
+	labelRules      []LabelNormalizationRule
+	maxAlerts       int
+	autoResolve     bool
+	onAutoResolve   func(incident *models.Incident)
+	storm           *alertStorm
+	// correlationLocks serializes processing of alerts that could be grouped
+	// together, so concurrent deliveries cannot race into duplicate incidents
+	correlationLocks *keyedMutex
+}
+
+// SeverityDowngradePolicy controls whether an incident's severity is lowered
+// as its alerts resolve. The new severity is derived from the alerts that are
+// still firing and is never raised by this mechanism.
+type SeverityDowngradePolicy struct {
+	Enabled bool
+	// MinSeverity is the lowest severity an incident can be downgraded to.
+	// An empty value means no floor.
+	MinSeverity models.IncidentSeverity
+}
+
+// SeverityFloor sets the minimum incident severity for alerts carrying a
+// label, e.g. tier=0 alerts always open critical incidents
+type SeverityFloor struct {
+	Label       string
+	Value       string
+	MinSeverity models.IncidentSeverity
+}
+
+// ParseSeverityFloors parses floors written as "label=value:severity",
+// e.g. "tier=0:critical"
+func ParseSeverityFloors(specs []string) ([]SeverityFloor, error) {
+	floors := make([]SeverityFloor, 0, len(specs))
+	for _, spec := range specs {
+		selector, severity, ok := strings.Cut(spec, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid severity floor %q: expected label=value:severity", spec)
+		}
+		label, value, ok := strings.Cut(selector, "=")
+		label, value = strings.TrimSpace(label), strings.TrimSpace(value)
+		if !ok || label == "" || value == "" {
+			return nil, fmt.Errorf("invalid severity floor %q: expected label=value:severity", spec)
+		}
+		minSeverity := models.IncidentSeverity(strings.ToLower(strings.TrimSpace(severity)))
+		if severityRank(minSeverity) == 0 {
+			return nil, fmt.Errorf("invalid severity floor %q: unknown severity %q", spec, severity)
+		}
+		floors = append(floors, SeverityFloor{Label: label, Value: value, MinSeverity: minSeverity})
+	}
+	return floors, nil
 }
 
 // NewAlertService creates a new alert service
 func NewAlertService(store storage.Store, incidentService *IncidentService, metricsService *MetricsService) *AlertService {
 	return &AlertService{
-		store:           store,
-		incidentService: incidentService,
-		metricsService:  metricsService,
+		store:            store,
+		incidentService:  incidentService,
+		metricsService:   metricsService,
+		storm:            newAlertStorm(),
+		correlationLocks: newKeyedMutex(),
 	}
 }
 
+// SetSeverityDowngradePolicy configures severity downgrade on partial alert resolution
+func (s *AlertService) SetSeverityDowngradePolicy(policy SeverityDowngradePolicy) {
+	s.downgradePolicy = policy
+}
+
+// SetSeverityFloors configures per-label minimum severities for new incidents
+func (s *AlertService) SetSeverityFloors(floors []SeverityFloor) {
+	s.severityFloors = floors
+}
+
+// SetMaxAlertsPerIncident caps how many alerts are stored for one incident.
+// Further correlated alerts only increment the incident's overflow counter.
+// Zero removes the cap.
+func (s *AlertService) SetMaxAlertsPerIncident(max int) {
+	s.maxAlerts = max
+}
+
+// SetAutoResolve controls whether an incident is resolved once every alert
+// grouped into it has resolved
+func (s *AlertService) SetAutoResolve(enabled bool) {
+	s.autoResolve = enabled
+}
+
+// SetAutoResolveHook registers a function called after an incident has been
+// auto-resolved, e.g. to send the resolved notification
+func (s *AlertService) SetAutoResolveHook(hook func(incident *models.Incident)) {
+	s.onAutoResolve = hook
+}
+
 // AlertmanagerAlert represents an alert from Alertmanager
 type AlertmanagerAlert struct {
 	Fingerprint string            `json:"fingerprint"`
@@ -50,73 +133,110 @@ type AlertmanagerWebhook struct {
 }
 
 // ProcessAlertmanagerWebhook processes alerts from Alertmanager
-func (s *AlertService) ProcessAlertmanagerWebhook(webhook *AlertmanagerWebhook) error {
+func (s *AlertService) ProcessAlertmanagerWebhook(ctx context.Context, webhook *AlertmanagerWebhook) error {
 	for _, amAlert := range webhook.Alerts {
-		alert := &models.Alert{
-			ID:          uuid.New().String(),
-			Fingerprint: amAlert.Fingerprint,
-			Status:      amAlert.Status,
-			StartsAt:    amAlert.StartsAt,
-			EndsAt:      amAlert.EndsAt,
-			Labels:      amAlert.Labels,
-			Annotations: amAlert.Annotations,
-			CreatedAt:   time.Now(),
-		}
-
-		// Check if we already have this alert
-		existingAlert, err := s.findAlertByFingerprint(amAlert.Fingerprint)
+		if err := s.processAlertmanagerAlert(ctx, amAlert); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// processAlertmanagerAlert stores a single alert and groups it into an incident
+// while holding the locks for its correlation keys
+func (s *AlertService) processAlertmanagerAlert(ctx context.Context, amAlert AlertmanagerAlert) error {
+	unlock := s.correlationLocks.LockAll(correlationKeys(amAlert.Fingerprint, s.correlationLabels(amAlert.Labels)))
+	defer unlock()
+
+	alert := &models.Alert{
+		ID:          uuid.New().String(),
+		Fingerprint: amAlert.Fingerprint,
+		Status:      amAlert.Status,
+		StartsAt:    amAlert.StartsAt,
+		EndsAt:      amAlert.EndsAt,
+		Labels:      amAlert.Labels,
+		Annotations: amAlert.Annotations,
+		CreatedAt:   time.Now(),
+	}
+
+	// Check if we already have this alert
+	existingAlert, err := s.store.GetAlertByFingerprint(ctx, amAlert.Fingerprint)
+	if err != nil && err != storage.ErrNotFound {
+		return fmt.Errorf("failed to check existing alert: %w", err)
+	}
+
+	// An alert firing again after its incident was resolved starts over as a
+	// new alert, so the resolved incident keeps its record of the old one and
+	// the re-fire counts toward storms and incident caps like any other
+	if existingAlert != nil && alert.Status == "firing" && existingAlert.IncidentID != "" {
+		incident, err := s.store.GetIncident(ctx, existingAlert.IncidentID)
 		if err != nil && err != storage.ErrNotFound {
-			return fmt.Errorf("failed to check existing alert: %w", err)
+			return fmt.Errorf("failed to load alert incident: %w", err)
 		}
+		if incident == nil || incident.Status == models.IncidentStatusResolved {
+			existingAlert = nil
+		}
+	}
 
-		if existingAlert != nil {
-			// Update existing alert
-			existingAlert.Status = alert.Status
-			existingAlert.EndsAt = alert.EndsAt
-			if err := s.store.UpdateAlert(existingAlert); err != nil {
-				return fmt.Errorf("failed to update alert: %w", err)
+	if existingAlert != nil {
+		// Update existing alert
+		wasFiring := existingAlert.Status == "firing"
+		existingAlert.Status = alert.Status
+		existingAlert.EndsAt = alert.EndsAt
+		if err := s.store.UpdateAlert(ctx, existingAlert); err != nil {
+			return fmt.Errorf("failed to update alert: %w", err)
+		}
+		alert = existingAlert
+
+		if wasFiring && alert.Status == "resolved" && alert.IncidentID != "" {
+			if err := s.downgradeIncidentSeverity(ctx, alert.IncidentID, alert.ID); err != nil {
+				return fmt.Errorf("failed to downgrade incident severity: %w", err)
 			}
-			alert = existingAlert
-		} else {
-			// Create new alert
-			if err := s.store.CreateAlert(alert); err != nil {
-				return fmt.Errorf("failed to create alert: %w", err)
+			if err := s.autoResolveIncident(ctx, alert.IncidentID); err != nil {
+				return fmt.Errorf("failed to auto-resolve incident: %w", err)
 			}
 		}
-
-		// Group alert into incident if it's firing
-		if alert.Status == "firing" && alert.IncidentID == "" {
-			if err := s.groupAlertIntoIncident(alert); err != nil {
-				return fmt.Errorf("failed to group alert into incident: %w", err)
+	} else {
+		if alert.Status == "firing" {
+			if ended := s.storm.recordArrival(); ended != "" {
+				if err := s.recordStormEnded(ctx, ended); err != nil {
+					return fmt.Errorf("failed to summarize alert storm: %w", err)
+				}
 			}
 		}
-	}
 
-	return nil
-}
+		// Alerts past the incident's cap are counted rather than stored
+		overflowed, err := s.absorbOverflowAlert(ctx, alert)
+		if err != nil {
+			return fmt.Errorf("failed to record overflow alert: %w", err)
+		}
+		if overflowed {
+			return nil
+		}
 
-// findAlertByFingerprint finds an alert by its fingerprint
-func (s *AlertService) findAlertByFingerprint(fingerprint string) (*models.Alert, error) {
-	alerts, err := s.store.ListAlerts()
-	if err != nil {
-		return nil, err
+		// Create new alert
+		if err := s.store.CreateAlert(ctx, alert); err != nil {
+			return fmt.Errorf("failed to create alert: %w", err)
+		}
 	}
 
-	for _, alert := range alerts {
-		if alert.Fingerprint == fingerprint {
-			return alert, nil
+	// Group alert into incident if it's firing
+	if alert.Status == "firing" && alert.IncidentID == "" {
+		if err := s.groupAlertIntoIncident(ctx, alert); err != nil {
+			return fmt.Errorf("failed to group alert into incident: %w", err)
 		}
 	}
 
-	return nil, storage.ErrNotFound
+	return nil
 }
 
-// groupAlertIntoIncident groups an alert into an appropriate incident
-func (s *AlertService) groupAlertIntoIncident(alert *models.Alert) error {
-	// Find existing incidents that this alert could be grouped into
-	incidents, err := s.store.ListIncidents()
+// findGroupingIncident returns the open incident an alert would be grouped
+// into, or nil if it would start a new one
+func (s *AlertService) findGroupingIncident(ctx context.Context, alert *models.Alert) (*models.Incident, error) {
+	incidents, err := s.store.ListIncidents(ctx)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// Look for an open incident with similar labels
@@ -124,63 +244,177 @@ func (s *AlertService) groupAlertIntoIncident(alert *models.Alert) error {
 		if incident.Status == models.IncidentStatusResolved {
 			continue
 		}
+		// Storm incidents only collect alerts while their storm lasts
+		if incident.Labels[AlertStormLabel] != "" {
+			continue
+		}
+		if s.shouldGroupAlertWithIncident(ctx, alert, incident) {
+			return incident, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// absorbOverflowAlert reports whether a new alert correlates with an incident
+// that already holds the maximum number of alerts. Such alerts are tracked by
+// fingerprint instead of being stored, and the incident's overflow counter
+// counts the distinct ones still firing: Alertmanager re-deliveries are not
+// counted again, and a resolved overflow alert leaves the count.
+func (s *AlertService) absorbOverflowAlert(ctx context.Context, alert *models.Alert) (bool, error) {
+	if s.maxAlerts <= 0 {
+		return false, nil
+	}
+
+	if alert.Status != "firing" {
+		released, err := s.releaseOverflowAlert(ctx, alert.Fingerprint)
+		if err != nil || released {
+			return released, err
+		}
+	}
+
+	incident, err := s.findGroupingIncident(ctx, alert)
+	if err != nil || incident == nil || len(incident.AlertIDs) < s.maxAlerts {
+		return false, err
+	}
+
+	if alert.Status == "firing" {
+		added, err := s.store.AddOverflowAlert(ctx, incident.ID, alert.Fingerprint)
+		if err != nil {
+			return false, err
+		}
+		if !added {
+			return true, nil
+		}
+		incident.OverflowAlertCount++
+		incident.UpdatedAt = time.Now()
+		if err := s.store.UpdateIncident(ctx, incident); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
 
-		if s.shouldGroupAlertWithIncident(alert, incident) {
-			// Add alert to existing incident
-			incident.AlertIDs = append(incident.AlertIDs, alert.ID)
-			alert.IncidentID = incident.ID
+// releaseOverflowAlert takes a resolved overflow alert out of the counters
+// of the incidents that absorbed it, or of the incidents they were merged
+// into, and resolves them if nothing is left firing. It reports false for an
+// alert that was not an overflow alert.
+func (s *AlertService) releaseOverflowAlert(ctx context.Context, fingerprint string) (bool, error) {
+	incidentIDs, err := s.store.RemoveOverflowAlert(ctx, fingerprint)
+	if err != nil || len(incidentIDs) == 0 {
+		return false, err
+	}
 
-			if err := s.store.UpdateIncident(incident); err != nil {
-				return err
+	for _, incidentID := range incidentIDs {
+		incident, err := s.store.GetIncident(ctx, incidentID)
+		if err == storage.ErrNotFound {
+			continue
+		}
+		if err != nil {
+			return false, err
+		}
+		if incident.MergedInto != "" {
+			if incident, err = s.store.GetIncident(ctx, incident.MergedInto); err != nil {
+				return false, err
 			}
-			return s.store.UpdateAlert(alert)
 		}
+		if incident.OverflowAlertCount > 0 {
+			incident.OverflowAlertCount--
+			incident.UpdatedAt = time.Now()
+			if err := s.store.UpdateIncident(ctx, incident); err != nil {
+				return false, err
+			}
+		}
+		if err := s.autoResolveIncident(ctx, incident.ID); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// groupAlertIntoIncident groups an alert into an appropriate incident
+func (s *AlertService) groupAlertIntoIncident(ctx context.Context, alert *models.Alert) error {
+	incident, err := s.findGroupingIncident(ctx, alert)
+	if err != nil {
+		return err
+	}
+
+	if incident != nil {
+		// Add alert to existing incident
+		incident.AlertIDs = append(incident.AlertIDs, alert.ID)
+		alert.IncidentID = incident.ID
+
+		if err := s.store.UpdateIncident(ctx, incident); err != nil {
+			return err
+		}
+		return s.store.UpdateAlert(ctx, alert)
+	}
+
+	// During an alert storm, uncorrelated alerts share one incident
+	if grouped, err := s.groupIntoStorm(ctx, alert); err != nil || grouped {
+		return err
 	}
 
 	// Create new incident for this alert
-	severity := s.determineSeverity(alert)
-	title := s.generateIncidentTitle(alert)
-	description := s.generateIncidentDescription(alert)
+	severity := s.incidentSeverity(alert)
+	title, description := s.incidentService.FitText(s.generateIncidentTitle(alert), s.generateIncidentDescription(alert))
 
-	incident, err := s.incidentService.CreateIncident(title, description, severity, []string{alert.ID})
+	incident, err = s.incidentService.CreateIncident(ctx, title, description, severity, []string{alert.ID})
 	if err != nil {
 		return err
 	}
 
 	alert.IncidentID = incident.ID
-	return s.store.UpdateAlert(alert)
+	return s.store.UpdateAlert(ctx, alert)
 }
 
 // shouldGroupAlertWithIncident determines if an alert should be grouped with an incident
-func (s *AlertService) shouldGroupAlertWithIncident(alert *models.Alert, incident *models.Incident) bool {
+func (s *AlertService) shouldGroupAlertWithIncident(ctx context.Context, alert *models.Alert, incident *models.Incident) bool {
 	// Get first alert of the incident to compare
 	if len(incident.AlertIDs) == 0 {
 		return false
 	}
 
-	firstAlert, err := s.store.GetAlert(incident.AlertIDs[0])
+	firstAlert, err := s.store.GetAlert(ctx, incident.AlertIDs[0])
 	if err != nil {
 		return false
 	}
 
+	labels := s.correlationLabels(alert.Labels)
+	firstLabels := s.correlationLabels(firstAlert.Labels)
+
 	// Group by service label
-	if alert.Labels["service"] != "" && firstAlert.Labels["service"] != "" {
-		return alert.Labels["service"] == firstAlert.Labels["service"]
+	if labels["service"] != "" && firstLabels["service"] != "" {
+		return labels["service"] == firstLabels["service"]
 	}
 
 	// Group by instance label
-	if alert.Labels["instance"] != "" && firstAlert.Labels["instance"] != "" {
-		return alert.Labels["instance"] == firstAlert.Labels["instance"]
+	if labels["instance"] != "" && firstLabels["instance"] != "" {
+		return labels["instance"] == firstLabels["instance"]
 	}
 
 	// Group by alertname
-	if alert.Labels["alertname"] != "" && firstAlert.Labels["alertname"] != "" {
-		return alert.Labels["alertname"] == firstAlert.Labels["alertname"]
+	if labels["alertname"] != "" && firstLabels["alertname"] != "" {
+		return labels["alertname"] == firstLabels["alertname"]
 	}
 
 	return false
 }
 
+// correlationKeys returns the lock keys for an alert: its fingerprint and each
+// of the normalized service, instance and alertname labels it has. Alerts are
+// only grouped when one of those labels is equal on both, so any two alerts
+// that could be grouped share a key.
+func correlationKeys(fingerprint string, labels map[string]string) []string {
+	keys := []string{"fingerprint=" + fingerprint}
+	for _, label := range []string{"service", "instance", "alertname"} {
+		if value := labels[label]; value != "" {
+			keys = append(keys, label+"="+value)
+		}
+	}
+	return keys
+}
+
 // determineSeverity determines the severity of an incident based on alert
 func (s *AlertService) determineSeverity(alert *models.Alert) models.IncidentSeverity {
 	severity, exists := alert.Labels["severity"]
@@ -202,6 +436,145 @@ func (s *AlertService) determineSeverity(alert *models.Alert) models.IncidentSev
 	}
 }
 
+// incidentSeverity is the alert's own severity raised to any matching label floor
+func (s *AlertService) incidentSeverity(alert *models.Alert) models.IncidentSeverity {
+	severity := s.determineSeverity(alert)
+	for _, floor := range s.severityFloors {
+		if alert.Labels[floor.Label] == floor.Value && severityRank(floor.MinSeverity) > severityRank(severity) {
+			severity = floor.MinSeverity
+		}
+	}
+	return severity
+}
+
+// downgradeIncidentSeverity lowers an incident's severity to match its
+// remaining firing alerts when the downgrade policy is enabled
+func (s *AlertService) downgradeIncidentSeverity(ctx context.Context, incidentID, resolvedAlertID string) error {
+	if !s.downgradePolicy.Enabled {
+		return nil
+	}
+
+	incident, err := s.store.GetIncident(ctx, incidentID)
+	if err != nil {
+		return err
+	}
+	if incident.Status == models.IncidentStatusResolved {
+		return nil
+	}
+
+	var highest models.IncidentSeverity
+	for _, alertID := range incident.AlertIDs {
+		alert, err := s.store.GetAlert(ctx, alertID)
+		if err != nil {
+			if err == storage.ErrNotFound {
+				continue
+			}
+			return err
+		}
+		if alert.Status != "firing" {
+			continue
+		}
+
+		severity := s.incidentSeverity(alert)
+		if severityRank(severity) > severityRank(highest) {
+			highest = severity
+		}
+	}
+
+	// Nothing left firing; leave the severity for whoever resolves the incident
+	if highest == "" {
+		return nil
+	}
+
+	if floor := s.downgradePolicy.MinSeverity; floor != "" && severityRank(highest) < severityRank(floor) {
+		highest = floor
+	}
+	if severityRank(highest) >= severityRank(incident.Severity) {
+		return nil
+	}
+
+	oldSeverity := incident.Severity
+	incident.Severity = highest
+	incident.UpdatedAt = time.Now()
+	if err := s.store.UpdateIncident(ctx, incident); err != nil {
+		return err
+	}
+
+	metadata := map[string]interface{}{
+		"old_severity":      string(oldSeverity),
+		"new_severity":      string(highest),
+		"resolved_alert_id": resolvedAlertID,
+	}
+	_, err = s.incidentService.AddComment(ctx,
+		incidentID,
+		"system",
+		fmt.Sprintf("Severity downgraded from %s to %s after alert resolution", oldSeverity, highest),
+		models.CommentTypeSeverityChange,
+		metadata,
+	)
+	return err
+}
+
+// autoResolveIncident resolves an incident once none of its alerts are still
+// firing, when auto-resolve is enabled
+func (s *AlertService) autoResolveIncident(ctx context.Context, incidentID string) error {
+	if !s.autoResolve {
+		return nil
+	}
+
+	incident, err := s.store.GetIncident(ctx, incidentID)
+	if err != nil {
+		return err
+	}
+	// Overflow alerts still firing keep the incident open
+	if incident.Status == models.IncidentStatusResolved || incident.OverflowAlertCount > 0 {
+		return nil
+	}
+
+	for _, alertID := range incident.AlertIDs {
+		alert, err := s.store.GetAlert(ctx, alertID)
+		if err != nil {
+			if err == storage.ErrNotFound {
+				continue
+			}
+			return err
+		}
+		if alert.Status != "resolved" {
+			return nil
+		}
+	}
+
+	resolution := models.Resolution{Note: "All alerts resolved", Type: models.ResolutionAutoRecovered}
+	if err := s.incidentService.ResolveIncidentWithDetails(ctx, incidentID, "system", resolution); err != nil {
+		return err
+	}
+
+	if s.onAutoResolve != nil {
+		resolved, err := s.store.GetIncident(ctx, incidentID)
+		if err != nil {
+			return err
+		}
+		s.onAutoResolve(resolved)
+	}
+	return nil
+}
+
+// severityRank orders severities so they can be compared; unknown values rank lowest
+func severityRank(severity models.IncidentSeverity) int {
+	switch severity {
+	case models.SeverityCritical:
+		return 4
+	case models.SeverityHigh:
+		return 3
+	case models.SeverityMedium:
+		return 2
+	case models.SeverityLow:
+		return 1
+	default:
+		return 0
+	}
+}
+
 // generateIncidentTitle generates a title for an incident from an alert
 func (s *AlertService) generateIncidentTitle(alert *models.Alert) string {
 	if summary := alert.Annotations["summary"]; summary != "" {
@@ -242,11 +615,25 @@ func (s *AlertService) generateIncidentDescription(alert *models.Alert) string {
 }
 
 // GetAlert retrieves an alert by ID
-func (s *AlertService) GetAlert(id string) (*models.Alert, error) {
-	return s.store.GetAlert(id)
+func (s *AlertService) GetAlert(ctx context.Context, id string) (*models.Alert, error) {
+	return s.store.GetAlert(ctx, id)
 }
 
 // ListAlerts retrieves all alerts
-func (s *AlertService) ListAlerts() ([]*models.Alert, error) {
-	return s.store.ListAlerts()
-}
\ No newline at end of file
+func (s *AlertService) ListAlerts(ctx context.Context) ([]*models.Alert, error) {
+	return s.store.ListAlerts(ctx)
+}
+
+// ListAlertsWithFilter returns a page of the alerts matching the filter and
+// the number that match in total
+func (s *AlertService) ListAlertsWithFilter(ctx context.Context, filter storage.AlertFilter) ([]*models.Alert, int, error) {
+	alerts, err := s.store.ListAlertsWithFilter(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	total, err := s.store.CountAlerts(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	return alerts, total, nil
+}
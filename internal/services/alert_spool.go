@@ -0,0 +1,138 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrAlertSpoolFull is returned when a webhook cannot be spooled because the
+// spool already holds its maximum number of webhooks
+var ErrAlertSpoolFull = errors.New("alert spool is full")
+
+// AlertSpool buffers Alertmanager webhooks that could not be processed, e.g.
+// while the database is down, and replays them in arrival order once
+// processing succeeds again. The buffer lives in memory and is bounded, so a
+// restart or a long outage can still lose alerts.
+type AlertSpool struct {
+	alertService   *AlertService
+	metricsService *MetricsService
+	logger         *Logger
+	capacity       int
+	interval       time.Duration
+
+	mutex    sync.Mutex
+	webhooks []*AlertmanagerWebhook
+	// replayMutex keeps the ticker and explicit Replay calls from
+	// processing the same webhooks concurrently
+	replayMutex sync.Mutex
+
+	stopChan chan struct{}
+	stopOnce sync.Once
+}
+
+// NewAlertSpool creates a spool holding up to capacity webhooks that retries
+// them every interval once started
+func NewAlertSpool(alertService *AlertService, metricsService *MetricsService, logger *Logger, capacity int, interval time.Duration) *AlertSpool {
+	return &AlertSpool{
+		alertService:   alertService,
+		metricsService: metricsService,
+		logger:         logger,
+		capacity:       capacity,
+		interval:       interval,
+		stopChan:       make(chan struct{}),
+	}
+}
+
+// Enqueue buffers a webhook for replay. It returns ErrAlertSpoolFull, and
+// counts the webhook as dropped, when the spool is at capacity.
+func (sp *AlertSpool) Enqueue(webhook *AlertmanagerWebhook) error {
+	sp.mutex.Lock()
+	defer sp.mutex.Unlock()
+
+	if len(sp.webhooks) >= sp.capacity {
+		sp.metricsService.RecordSpoolEvent("dropped")
+		return ErrAlertSpoolFull
+	}
+	sp.webhooks = append(sp.webhooks, webhook)
+	sp.metricsService.RecordSpoolEvent("spooled")
+	sp.metricsService.UpdateSpooledWebhooks(len(sp.webhooks))
+	return nil
+}
+
+// Len returns the number of buffered webhooks
+func (sp *AlertSpool) Len() int {
+	sp.mutex.Lock()
+	defer sp.mutex.Unlock()
+	return len(sp.webhooks)
+}
+
+// Replay processes buffered webhooks oldest first and returns how many were
+// replayed. It stops at the first failure, leaving that webhook and the
+// ones after it buffered for the next attempt.
+func (sp *AlertSpool) Replay(ctx context.Context) (int, error) {
+	sp.replayMutex.Lock()
+	defer sp.replayMutex.Unlock()
+
+	replayed := 0
+	for {
+		sp.mutex.Lock()
+		if len(sp.webhooks) == 0 {
+			sp.mutex.Unlock()
+			return replayed, nil
+		}
+		webhook := sp.webhooks[0]
+		sp.mutex.Unlock()
+
+		if err := sp.alertService.ProcessAlertmanagerWebhook(ctx, webhook); err != nil {
+			return replayed, err
+		}
+
+		sp.mutex.Lock()
+		sp.webhooks[0] = nil
+		sp.webhooks = sp.webhooks[1:]
+		sp.metricsService.UpdateSpooledWebhooks(len(sp.webhooks))
+		sp.mutex.Unlock()
+
+		sp.metricsService.RecordSpoolEvent("replayed")
+		replayed++
+	}
+}
+
+// Start replays buffered webhooks every interval until Stop is called
+func (sp *AlertSpool) Start() {
+	go func() {
+		ticker := time.NewTicker(sp.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if sp.Len() == 0 {
+					continue
+				}
+				replayed, err := sp.Replay(context.Background())
+				if replayed > 0 {
+					sp.logger.Info("Replayed spooled webhooks", map[string]interface{}{
+						"replayed":  replayed,
+						"remaining": sp.Len(),
+					})
+				}
+				if err != nil {
+					sp.logger.Warn("Spooled webhooks still cannot be processed", map[string]interface{}{
+						"remaining": sp.Len(),
+						"error":     err.Error(),
+					})
+				}
+			case <-sp.stopChan:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the replay loop. Webhooks still buffered are not replayed.
+func (sp *AlertSpool) Stop() {
+	sp.stopOnce.Do(func() { close(sp.stopChan) })
+}
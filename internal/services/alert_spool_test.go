@@ -0,0 +1,152 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/models"
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/storage"
+)
+
+var errDatabaseDown = errors.New("connection refused")
+
+// outageStore fails alert reads and writes while down is set
+type outageStore struct {
+	storage.Store
+	down atomic.Bool
+}
+
+func (s *outageStore) ListAlerts(ctx context.Context) ([]*models.Alert, error) {
+	if s.down.Load() {
+		return nil, errDatabaseDown
+	}
+	return s.Store.ListAlerts(ctx)
+}
+
+func (s *outageStore) GetAlertByFingerprint(ctx context.Context, fingerprint string) (*models.Alert, error) {
+	if s.down.Load() {
+		return nil, errDatabaseDown
+	}
+	return s.Store.GetAlertByFingerprint(ctx, fingerprint)
+}
+
+func (s *outageStore) CreateAlert(ctx context.Context, alert *models.Alert) error {
+	if s.down.Load() {
+		return errDatabaseDown
+	}
+	return s.Store.CreateAlert(ctx, alert)
+}
+
+func TestAlertSpool_ReplaysAfterOutage(t *testing.T) {
+	ctx := context.Background()
+	memoryStore, err := storage.NewMemoryStore()
+	if err != nil {
+		t.Fatalf("Failed to create memory store: %v", err)
+	}
+	store := &outageStore{Store: memoryStore}
+	metricsService := NewMetricsService(prometheus.NewRegistry())
+	incidentService := NewIncidentService(store, metricsService)
+	alertService := NewAlertService(store, incidentService, metricsService)
+	spool := NewAlertSpool(alertService, metricsService, NewLogger("error", false), 2, time.Hour)
+
+	webhook := func(fingerprint string) *AlertmanagerWebhook {
+		alert := testAlert(fingerprint, "firing", "critical")
+		alert.Labels["service"] = fingerprint
+		return &AlertmanagerWebhook{Status: "firing", Alerts: []AlertmanagerAlert{alert}}
+	}
+
+	store.down.Store(true)
+	for _, fingerprint := range []string{"fp-outage-1", "fp-outage-2", "fp-outage-3"} {
+		hook := webhook(fingerprint)
+		if err := alertService.ProcessAlertmanagerWebhook(ctx, hook); !errors.Is(err, errDatabaseDown) {
+			t.Fatalf("Expected processing to fail during the outage, got %v", err)
+		}
+		err := spool.Enqueue(hook)
+		if fingerprint == "fp-outage-3" {
+			if !errors.Is(err, ErrAlertSpoolFull) {
+				t.Errorf("Expected the third webhook to overflow the spool, got %v", err)
+			}
+		} else if err != nil {
+			t.Fatalf("Failed to spool webhook: %v", err)
+		}
+	}
+	if spool.Len() != 2 {
+		t.Fatalf("Expected 2 spooled webhooks, got %d", spool.Len())
+	}
+
+	// Replaying while the database is still down keeps everything buffered
+	if replayed, err := spool.Replay(ctx); replayed != 0 || err == nil {
+		t.Errorf("Expected replay to fail during the outage, replayed %d (err: %v)", replayed, err)
+	}
+	if spool.Len() != 2 {
+		t.Errorf("Expected both webhooks to stay spooled, got %d", spool.Len())
+	}
+
+	store.down.Store(false)
+	replayed, err := spool.Replay(ctx)
+	if err != nil || replayed != 2 {
+		t.Fatalf("Expected 2 webhooks replayed after recovery, got %d (err: %v)", replayed, err)
+	}
+	if spool.Len() != 0 {
+		t.Errorf("Expected an empty spool after replay, got %d", spool.Len())
+	}
+
+	for i, fingerprint := range []string{"fp-outage-1", "fp-outage-2"} {
+		alert, err := store.GetAlertByFingerprint(ctx, fingerprint)
+		if err != nil {
+			t.Fatalf("Expected replayed alert %s to be stored: %v", fingerprint, err)
+		}
+		if alert.IncidentID == "" {
+			t.Errorf("Expected replayed alert %d to open an incident", i+1)
+		}
+	}
+	if _, err := store.GetAlertByFingerprint(ctx, "fp-outage-3"); err != storage.ErrNotFound {
+		t.Errorf("Expected the dropped webhook not to be replayed, got %v", err)
+	}
+}
+
+func TestAlertSpool_StartReplaysInBackground(t *testing.T) {
+	ctx := context.Background()
+	memoryStore, err := storage.NewMemoryStore()
+	if err != nil {
+		t.Fatalf("Failed to create memory store: %v", err)
+	}
+	store := &outageStore{Store: memoryStore}
+	metricsService := NewMetricsService(prometheus.NewRegistry())
+	alertService := NewAlertService(store, NewIncidentService(store, metricsService), metricsService)
+	spool := NewAlertSpool(alertService, metricsService, NewLogger("error", false), 10, 5*time.Millisecond)
+
+	store.down.Store(true)
+	for i := 0; i < 3; i++ {
+		alert := testAlert(fmt.Sprintf("fp-bg-%d", i), "firing", "high")
+		if err := spool.Enqueue(&AlertmanagerWebhook{Status: "firing", Alerts: []AlertmanagerAlert{alert}}); err != nil {
+			t.Fatalf("Failed to spool webhook: %v", err)
+		}
+	}
+
+	spool.Start()
+	defer spool.Stop()
+	time.Sleep(20 * time.Millisecond)
+	if spool.Len() != 3 {
+		t.Fatalf("Expected webhooks to stay spooled during the outage, got %d", spool.Len())
+	}
+
+	store.down.Store(false)
+	deadline := time.Now().Add(time.Second)
+	for spool.Len() > 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if spool.Len() != 0 {
+		t.Fatalf("Expected the spool to drain after recovery, %d webhooks left", spool.Len())
+	}
+	alerts, _ := memoryStore.ListAlerts(ctx)
+	if len(alerts) != 3 {
+		t.Errorf("Expected 3 replayed alerts, got %d", len(alerts))
+	}
+}
@@ -0,0 +1,192 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/models"
+)
+
+// AlertStormLabel marks the incident that collects alerts during a storm
+const AlertStormLabel = "alert_storm"
+
+// DefaultAlertStormWindow is the window new alerts are counted over unless
+// configured otherwise
+const DefaultAlertStormWindow = time.Minute
+
+// alertStormSampleSize is how many grouped alerts' labels a storm incident
+// keeps as a sample of the storm's scope
+const alertStormSampleSize = 5
+
+// AlertStormPolicy controls storm detection. While at least Threshold new
+// alerts arrive within Window, alerts that would open their own incident are
+// grouped into a single storm incident instead. Zero threshold disables it.
+type AlertStormPolicy struct {
+	Threshold int
+	Window    time.Duration
+}
+
+// alertStorm tracks the recent alert arrival rate and the current storm
+type alertStorm struct {
+	policy AlertStormPolicy
+
+	// now is replaced in tests
+	now func() time.Time
+
+	mutex      sync.Mutex
+	arrivals   []time.Time
+	active     bool
+	incidentID string // storm incident of the current storm, if created
+}
+
+func newAlertStorm() *alertStorm {
+	return &alertStorm{now: time.Now}
+}
+
+// recordArrival counts a new alert and updates whether a storm is in
+// progress. A storm ends as soon as the rate drops back below the threshold;
+// the next storm gets a fresh incident. It returns the incident of a storm
+// that just ended, if any.
+func (st *alertStorm) recordArrival() string {
+	st.mutex.Lock()
+	defer st.mutex.Unlock()
+
+	if st.policy.Threshold <= 0 {
+		return ""
+	}
+
+	now := st.now()
+	cutoff := now.Add(-st.policy.Window)
+	recent := st.arrivals[:0]
+	for _, at := range st.arrivals {
+		if at.After(cutoff) {
+			recent = append(recent, at)
+		}
+	}
+	st.arrivals = append(recent, now)
+
+	st.active = len(st.arrivals) >= st.policy.Threshold
+	if st.active {
+		return ""
+	}
+	ended := st.incidentID
+	st.incidentID = ""
+	return ended
+}
+
+// SetAlertStormPolicy configures alert storm detection
+func (s *AlertService) SetAlertStormPolicy(policy AlertStormPolicy) {
+	if policy.Window <= 0 {
+		policy.Window = DefaultAlertStormWindow
+	}
+
+	s.storm.mutex.Lock()
+	defer s.storm.mutex.Unlock()
+	s.storm.policy = policy
+}
+
+// groupIntoStorm adds an alert to the current storm incident, creating it
+// for the first alert of the storm. It reports false when no storm is in
+// progress. The storm lock is held throughout because alerts with different
+// correlation keys join the same incident.
+func (s *AlertService) groupIntoStorm(ctx context.Context, alert *models.Alert) (bool, error) {
+	s.storm.mutex.Lock()
+	defer s.storm.mutex.Unlock()
+
+	if !s.storm.active {
+		return false, nil
+	}
+
+	var incident *models.Incident
+	if s.storm.incidentID != "" {
+		existing, err := s.store.GetIncident(ctx, s.storm.incidentID)
+		if err == nil && existing.Status != models.IncidentStatusResolved {
+			incident = existing
+		}
+	}
+
+	severity := s.incidentSeverity(alert)
+	if incident == nil {
+		policy := s.storm.policy
+		title := "Alert storm in progress"
+		description := fmt.Sprintf("At least %d new alerts arrived within %s. Alerts that would have opened their own incidents are grouped here until the rate drops.",
+			policy.Threshold, policy.Window)
+
+		created, err := s.incidentService.CreateIncident(ctx, title, description, severity, []string{alert.ID})
+		if err != nil {
+			return false, err
+		}
+		created.Labels[AlertStormLabel] = "true"
+		created.StormSummary = &models.AlertStormSummary{}
+		addToStormSummary(created.StormSummary, alert)
+		if err := s.store.UpdateIncident(ctx, created); err != nil {
+			return false, err
+		}
+		s.storm.incidentID = created.ID
+
+		if _, err := s.incidentService.AddComment(ctx, created.ID, "system",
+			fmt.Sprintf("Alert storm detected: %d new alerts within %s", policy.Threshold, policy.Window),
+			models.CommentTypeAlertStorm, map[string]interface{}{
+				"threshold": policy.Threshold,
+				"window":    policy.Window.String(),
+			}); err != nil {
+			return false, err
+		}
+
+		alert.IncidentID = created.ID
+		return true, s.store.UpdateAlert(ctx, alert)
+	}
+
+	incident.AlertIDs = append(incident.AlertIDs, alert.ID)
+	if incident.StormSummary == nil {
+		incident.StormSummary = &models.AlertStormSummary{}
+	}
+	addToStormSummary(incident.StormSummary, alert)
+	if severityRank(severity) > severityRank(incident.Severity) {
+		incident.Severity = severity
+	}
+	incident.UpdatedAt = time.Now()
+	if err := s.store.UpdateIncident(ctx, incident); err != nil {
+		return false, err
+	}
+
+	alert.IncidentID = incident.ID
+	return true, s.store.UpdateAlert(ctx, alert)
+}
+
+// addToStormSummary counts an alert grouped into a storm incident and keeps
+// its labels while the sample has room
+func addToStormSummary(summary *models.AlertStormSummary, alert *models.Alert) {
+	summary.GroupedAlertCount++
+	if len(summary.SampleLabels) >= alertStormSampleSize {
+		return
+	}
+	labels := make(map[string]string, len(alert.Labels))
+	for key, value := range alert.Labels {
+		labels[key] = value
+	}
+	summary.SampleLabels = append(summary.SampleLabels, labels)
+}
+
+// recordStormEnded adds a timeline entry summarizing the alerts grouped into
+// a storm incident once the storm is over
+func (s *AlertService) recordStormEnded(ctx context.Context, incidentID string) error {
+	incident, err := s.store.GetIncident(ctx, incidentID)
+	if err != nil {
+		return err
+	}
+	summary := incident.StormSummary
+	if summary == nil {
+		summary = &models.AlertStormSummary{}
+	}
+
+	_, err = s.incidentService.AddComment(ctx, incident.ID, "system",
+		fmt.Sprintf("Alert storm ended: %d alerts were grouped into this incident", summary.GroupedAlertCount),
+		models.CommentTypeAlertStorm, map[string]interface{}{
+			"grouped_alert_count": summary.GroupedAlertCount,
+			"sample_labels":       summary.SampleLabels,
+		})
+	return err
+}
@@ -0,0 +1,951 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/models"
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/storage"
+)
+
+func setupTestAlertService(t *testing.T) (*AlertService, *IncidentService, storage.Store) {
+	t.Helper()
+
+	store, err := storage.NewMemoryStore()
+	if err != nil {
+		t.Fatalf("Failed to create memory store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	metricsService := NewMetricsService(prometheus.NewRegistry())
+	incidentService := NewIncidentService(store, metricsService)
+	alertService := NewAlertService(store, incidentService, metricsService)
+
+	return alertService, incidentService, store
+}
+
+func testAlert(fingerprint, status, severity string) AlertmanagerAlert {
+	return AlertmanagerAlert{
+		Fingerprint: fingerprint,
+		Status:      status,
+		StartsAt:    time.Now(),
+		Labels: map[string]string{
+			"alertname": "HighLatency",
+			"service":   "checkout",
+			"severity":  severity,
+		},
+		Annotations: map[string]string{
+			"summary": "Checkout latency is high",
+		},
+	}
+}
+
+func TestAlertService_SeverityDowngradeOnPartialResolution(t *testing.T) {
+	ctx := context.Background()
+	alertService, incidentService, store := setupTestAlertService(t)
+	alertService.SetSeverityDowngradePolicy(SeverityDowngradePolicy{Enabled: true})
+
+	firing := &AlertmanagerWebhook{
+		Status: "firing",
+		Alerts: []AlertmanagerAlert{
+			testAlert("fp-critical", "firing", "critical"),
+			testAlert("fp-low", "firing", "low"),
+		},
+	}
+	if err := alertService.ProcessAlertmanagerWebhook(ctx, firing); err != nil {
+		t.Fatalf("Failed to process firing webhook: %v", err)
+	}
+
+	incidents, err := store.ListIncidents(ctx)
+	if err != nil {
+		t.Fatalf("Failed to list incidents: %v", err)
+	}
+	if len(incidents) != 1 {
+		t.Fatalf("Expected 1 incident, got %d", len(incidents))
+	}
+	incidentID := incidents[0].ID
+	if incidents[0].Severity != models.SeverityCritical {
+		t.Fatalf("Expected critical severity, got %s", incidents[0].Severity)
+	}
+
+	resolved := &AlertmanagerWebhook{
+		Status: "resolved",
+		Alerts: []AlertmanagerAlert{testAlert("fp-critical", "resolved", "critical")},
+	}
+	if err := alertService.ProcessAlertmanagerWebhook(ctx, resolved); err != nil {
+		t.Fatalf("Failed to process resolved webhook: %v", err)
+	}
+
+	incident, err := incidentService.GetIncident(ctx, incidentID)
+	if err != nil {
+		t.Fatalf("Failed to get incident: %v", err)
+	}
+	if incident.Severity != models.SeverityLow {
+		t.Errorf("Expected severity to be downgraded to low, got %s", incident.Severity)
+	}
+
+	timeline, err := incidentService.GetTimeline(ctx, incidentID)
+	if err != nil {
+		t.Fatalf("Failed to get timeline: %v", err)
+	}
+	found := false
+	for _, entry := range timeline {
+		if entry.CommentType == models.CommentTypeSeverityChange {
+			found = true
+			if entry.Metadata["old_severity"] != "critical" || entry.Metadata["new_severity"] != "low" {
+				t.Errorf("Unexpected severity change metadata: %v", entry.Metadata)
+			}
+		}
+	}
+	if !found {
+		t.Error("Expected a severity change timeline entry")
+	}
+}
+
+func TestAlertService_SeverityDowngradeRespectsPolicy(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name     string
+		policy   SeverityDowngradePolicy
+		expected models.IncidentSeverity
+	}{
+		{
+			name:     "Disabled",
+			policy:   SeverityDowngradePolicy{},
+			expected: models.SeverityCritical,
+		},
+		{
+			name:     "Minimum severity floor",
+			policy:   SeverityDowngradePolicy{Enabled: true, MinSeverity: models.SeverityHigh},
+			expected: models.SeverityHigh,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			alertService, incidentService, store := setupTestAlertService(t)
+			alertService.SetSeverityDowngradePolicy(tt.policy)
+
+			firing := &AlertmanagerWebhook{
+				Status: "firing",
+				Alerts: []AlertmanagerAlert{
+					testAlert("fp-critical", "firing", "critical"),
+					testAlert("fp-low", "firing", "low"),
+				},
+			}
+			if err := alertService.ProcessAlertmanagerWebhook(ctx, firing); err != nil {
+				t.Fatalf("Failed to process firing webhook: %v", err)
+			}
+
+			resolved := &AlertmanagerWebhook{
+				Status: "resolved",
+				Alerts: []AlertmanagerAlert{testAlert("fp-critical", "resolved", "critical")},
+			}
+			if err := alertService.ProcessAlertmanagerWebhook(ctx, resolved); err != nil {
+				t.Fatalf("Failed to process resolved webhook: %v", err)
+			}
+
+			incidents, err := store.ListIncidents(ctx)
+			if err != nil || len(incidents) != 1 {
+				t.Fatalf("Expected 1 incident, got %d (err: %v)", len(incidents), err)
+			}
+			incident, err := incidentService.GetIncident(ctx, incidents[0].ID)
+			if err != nil {
+				t.Fatalf("Failed to get incident: %v", err)
+			}
+			if incident.Severity != tt.expected {
+				t.Errorf("Expected severity %s, got %s", tt.expected, incident.Severity)
+			}
+		})
+	}
+}
+
+func TestAlertService_ConcurrentIdenticalAlertsCreateSingleIncident(t *testing.T) {
+	ctx := context.Background()
+	alertService, _, store := setupTestAlertService(t)
+
+	const workers = 50
+	var wg sync.WaitGroup
+	errs := make(chan error, workers)
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			webhook := &AlertmanagerWebhook{
+				Status: "firing",
+				Alerts: []AlertmanagerAlert{testAlert("fp-concurrent", "firing", "critical")},
+			}
+			errs <- alertService.ProcessAlertmanagerWebhook(context.Background(), webhook)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("Failed to process webhook: %v", err)
+		}
+	}
+
+	incidents, err := store.ListIncidents(ctx)
+	if err != nil {
+		t.Fatalf("Failed to list incidents: %v", err)
+	}
+	if len(incidents) != 1 {
+		t.Errorf("Expected exactly 1 incident, got %d", len(incidents))
+	}
+
+	alerts, err := store.ListAlerts(ctx)
+	if err != nil {
+		t.Fatalf("Failed to list alerts: %v", err)
+	}
+	if len(alerts) != 1 {
+		t.Errorf("Expected exactly 1 alert, got %d", len(alerts))
+	}
+}
+
+func TestAlertService_ConcurrentMixedLabelAlertsCreateSingleIncident(t *testing.T) {
+	ctx := context.Background()
+	alertService, _, store := setupTestAlertService(t)
+
+	// Every alert shares the alertname, but only some carry a service or an
+	// instance, so each pair still groups on a label both have
+	labelSets := []map[string]string{
+		{"alertname": "DiskFull"},
+		{"alertname": "DiskFull", "service": "storage"},
+		{"alertname": "DiskFull", "instance": "db-1"},
+	}
+
+	const workers = 30
+	var wg sync.WaitGroup
+	errs := make(chan error, workers)
+	start := make(chan struct{})
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			alert := testAlert(fmt.Sprintf("fp-mixed-%d", i), "firing", "high")
+			alert.Labels = labelSets[i%len(labelSets)]
+			webhook := &AlertmanagerWebhook{Status: "firing", Alerts: []AlertmanagerAlert{alert}}
+			<-start
+			errs <- alertService.ProcessAlertmanagerWebhook(context.Background(), webhook)
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("Failed to process webhook: %v", err)
+		}
+	}
+
+	incidents, err := store.ListIncidents(ctx)
+	if err != nil {
+		t.Fatalf("Failed to list incidents: %v", err)
+	}
+	if len(incidents) != 1 {
+		t.Errorf("Expected exactly 1 incident, got %d", len(incidents))
+	}
+}
+
+// blockingAlertStore holds up the lookup of one fingerprint until released
+type blockingAlertStore struct {
+	storage.Store
+	fingerprint string
+	entered     chan struct{}
+	release     chan struct{}
+}
+
+func (s *blockingAlertStore) GetAlertByFingerprint(ctx context.Context, fingerprint string) (*models.Alert, error) {
+	if fingerprint == s.fingerprint {
+		close(s.entered)
+		<-s.release
+	}
+	return s.Store.GetAlertByFingerprint(ctx, fingerprint)
+}
+
+func TestAlertService_DifferentCorrelationKeysRunInParallel(t *testing.T) {
+	memoryStore, err := storage.NewMemoryStore()
+	if err != nil {
+		t.Fatalf("Failed to create memory store: %v", err)
+	}
+	store := &blockingAlertStore{Store: memoryStore, fingerprint: "fp-slow", entered: make(chan struct{}), release: make(chan struct{})}
+	metricsService := NewMetricsService(prometheus.NewRegistry())
+	alertService := NewAlertService(store, NewIncidentService(store, metricsService), metricsService)
+
+	process := func(fingerprint string, labels map[string]string) chan error {
+		done := make(chan error, 1)
+		go func() {
+			alert := testAlert(fingerprint, "firing", "high")
+			alert.Labels = labels
+			done <- alertService.ProcessAlertmanagerWebhook(context.Background(), &AlertmanagerWebhook{Alerts: []AlertmanagerAlert{alert}})
+		}()
+		return done
+	}
+
+	slow := process("fp-slow", map[string]string{"alertname": "DiskFull", "service": "storage"})
+	<-store.entered
+
+	// Sharing the alertname with the held alert waits for it
+	related := process("fp-related", map[string]string{"alertname": "DiskFull"})
+	select {
+	case err := <-related:
+		t.Fatalf("Expected an alert sharing a correlation key to wait, it finished with %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// An unrelated alert is processed meanwhile
+	select {
+	case err := <-process("fp-other", map[string]string{"alertname": "HighLatency", "service": "checkout"}):
+		if err != nil {
+			t.Fatalf("Failed to process unrelated alert: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected an alert with different correlation keys not to wait")
+	}
+
+	close(store.release)
+	for _, done := range []chan error{slow, related} {
+		if err := <-done; err != nil {
+			t.Fatalf("Failed to process alert: %v", err)
+		}
+	}
+	if incidents, _ := memoryStore.ListIncidents(context.Background()); len(incidents) != 2 {
+		t.Errorf("Expected the related alerts to share an incident, got %d incidents", len(incidents))
+	}
+}
+
+func TestAlertService_SeverityFloorByLabel(t *testing.T) {
+	ctx := context.Background()
+	floors, err := ParseSeverityFloors([]string{"tier=0:critical", "tier=1:high"})
+	if err != nil {
+		t.Fatalf("Failed to parse severity floors: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		tier     string
+		severity string
+		expected models.IncidentSeverity
+	}{
+		{name: "Tier 0 warning floored to critical", tier: "0", severity: "warning", expected: models.SeverityCritical},
+		{name: "Tier 1 low floored to high", tier: "1", severity: "low", expected: models.SeverityHigh},
+		{name: "Higher alert severity is kept", tier: "1", severity: "critical", expected: models.SeverityCritical},
+		{name: "No matching floor", tier: "3", severity: "low", expected: models.SeverityLow},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			alertService, _, store := setupTestAlertService(t)
+			alertService.SetSeverityFloors(floors)
+
+			alert := testAlert("fp-tier", "firing", tt.severity)
+			alert.Labels["tier"] = tt.tier
+			if err := alertService.ProcessAlertmanagerWebhook(ctx, &AlertmanagerWebhook{Status: "firing", Alerts: []AlertmanagerAlert{alert}}); err != nil {
+				t.Fatalf("Failed to process webhook: %v", err)
+			}
+
+			incidents, err := store.ListIncidents(ctx)
+			if err != nil || len(incidents) != 1 {
+				t.Fatalf("Expected 1 incident, got %d (err: %v)", len(incidents), err)
+			}
+			if incidents[0].Severity != tt.expected {
+				t.Errorf("Expected severity %s, got %s", tt.expected, incidents[0].Severity)
+			}
+		})
+	}
+}
+
+func TestParseSeverityFloors_Invalid(t *testing.T) {
+	for _, spec := range []string{"tier=0", "tier:critical", "=0:critical", "tier=0:urgent"} {
+		if _, err := ParseSeverityFloors([]string{spec}); err == nil {
+			t.Errorf("Expected %q to be rejected", spec)
+		}
+	}
+}
+
+func TestAlertService_TruncatesLongAlertText(t *testing.T) {
+	ctx := context.Background()
+	alertService, incidentService, store := setupTestAlertService(t)
+	incidentService.SetTextLimits(30, 40)
+
+	alert := testAlert("fp-long", "firing", "critical")
+	alert.Annotations["summary"] = "Checkout latency is above the SLO for every region\x07"
+	alert.Annotations["description"] = strings.Repeat("p99 latency exceeded ", 10)
+	if err := alertService.ProcessAlertmanagerWebhook(ctx, &AlertmanagerWebhook{Status: "firing", Alerts: []AlertmanagerAlert{alert}}); err != nil {
+		t.Fatalf("Expected oversized alert text to be truncated, got error: %v", err)
+	}
+
+	incidents, err := store.ListIncidents(ctx)
+	if err != nil || len(incidents) != 1 {
+		t.Fatalf("Expected 1 incident, got %d (err: %v)", len(incidents), err)
+	}
+	incident := incidents[0]
+	if incident.Title != "Checkout latency is above the…" {
+		t.Errorf("Unexpected truncated title %q", incident.Title)
+	}
+	if n := utf8.RuneCountInString(incident.Description); n > 40 || !strings.HasSuffix(incident.Description, "…") {
+		t.Errorf("Expected description truncated to 40 characters with an ellipsis, got %d: %q", n, incident.Description)
+	}
+}
+
+func TestAlertService_MaxAlertsPerIncidentOverflow(t *testing.T) {
+	ctx := context.Background()
+	alertService, incidentService, store := setupTestAlertService(t)
+	alertService.SetMaxAlertsPerIncident(3)
+
+	var alerts []AlertmanagerAlert
+	for i := 0; i < 5; i++ {
+		alerts = append(alerts, testAlert(fmt.Sprintf("fp-runaway-%d", i), "firing", "high"))
+	}
+	if err := alertService.ProcessAlertmanagerWebhook(ctx, &AlertmanagerWebhook{Status: "firing", Alerts: alerts}); err != nil {
+		t.Fatalf("Failed to process webhook: %v", err)
+	}
+
+	storedAlerts, err := store.ListAlerts(ctx)
+	if err != nil {
+		t.Fatalf("Failed to list alerts: %v", err)
+	}
+	if len(storedAlerts) != 3 {
+		t.Errorf("Expected 3 stored alerts, got %d", len(storedAlerts))
+	}
+
+	incidents, err := store.ListIncidents(ctx)
+	if err != nil || len(incidents) != 1 {
+		t.Fatalf("Expected 1 incident, got %d (err: %v)", len(incidents), err)
+	}
+	incident, err := incidentService.GetIncident(ctx, incidents[0].ID)
+	if err != nil {
+		t.Fatalf("Failed to get incident: %v", err)
+	}
+	if len(incident.AlertIDs) != 3 {
+		t.Errorf("Expected 3 alert IDs on the incident, got %d", len(incident.AlertIDs))
+	}
+	if incident.OverflowAlertCount != 2 {
+		t.Errorf("Expected overflow count 2, got %d", incident.OverflowAlertCount)
+	}
+
+	// Alerts already stored keep updating normally
+	resolved := &AlertmanagerWebhook{Status: "resolved", Alerts: []AlertmanagerAlert{testAlert("fp-runaway-0", "resolved", "high")}}
+	if err := alertService.ProcessAlertmanagerWebhook(ctx, resolved); err != nil {
+		t.Fatalf("Failed to process resolved webhook: %v", err)
+	}
+	if storedAlerts, _ := store.ListAlerts(ctx); len(storedAlerts) != 3 {
+		t.Errorf("Expected resolving a stored alert not to add rows, got %d alerts", len(storedAlerts))
+	}
+
+	data, err := json.Marshal(incident)
+	if err != nil {
+		t.Fatalf("Failed to marshal incident: %v", err)
+	}
+	if !strings.Contains(string(data), `"overflow_alert_count":2`) {
+		t.Errorf("Expected overflow count in incident JSON, got %s", data)
+	}
+}
+
+func TestAlertService_AlertStormGroupsBurst(t *testing.T) {
+	ctx := context.Background()
+	alertService, _, store := setupTestAlertService(t)
+	alertService.SetAlertStormPolicy(AlertStormPolicy{Threshold: 5, Window: time.Minute})
+	clock := &fakeClock{current: time.Date(2024, time.March, 15, 9, 0, 0, 0, time.UTC)}
+	alertService.storm.now = clock.Now
+
+	send := func(fingerprint, service string) {
+		t.Helper()
+		alert := testAlert(fingerprint, "firing", "high")
+		alert.Labels["service"] = service
+		if err := alertService.ProcessAlertmanagerWebhook(ctx, &AlertmanagerWebhook{Status: "firing", Alerts: []AlertmanagerAlert{alert}}); err != nil {
+			t.Fatalf("Failed to process webhook: %v", err)
+		}
+	}
+
+	// 20 unrelated alerts within a few seconds: the first four open their own
+	// incidents, the rest land in a single storm incident
+	for i := 0; i < 20; i++ {
+		send(fmt.Sprintf("fp-burst-%d", i), fmt.Sprintf("svc-%d", i))
+		clock.Advance(100 * time.Millisecond)
+	}
+
+	incidents, err := store.ListIncidents(ctx)
+	if err != nil {
+		t.Fatalf("Failed to list incidents: %v", err)
+	}
+	var storms []*models.Incident
+	for _, incident := range incidents {
+		if incident.Labels[AlertStormLabel] == "true" {
+			storms = append(storms, incident)
+		}
+	}
+	if len(storms) != 1 {
+		t.Fatalf("Expected exactly 1 storm incident, got %d", len(storms))
+	}
+	if len(incidents) != 5 {
+		t.Errorf("Expected 4 individual incidents plus the storm incident, got %d", len(incidents))
+	}
+	storm := storms[0]
+	if len(storm.AlertIDs) != 16 {
+		t.Errorf("Expected 16 alerts recorded on the storm incident, got %d", len(storm.AlertIDs))
+	}
+	for _, alertID := range storm.AlertIDs {
+		alert, err := store.GetAlert(ctx, alertID)
+		if err != nil || alert.IncidentID != storm.ID {
+			t.Errorf("Expected alert %s to point at the storm incident (err: %v)", alertID, err)
+		}
+	}
+
+	// At a normal rate alerts open individual incidents again, even ones that
+	// match the storm incident's first alert
+	for i, service := range []string{"svc-late-1", "svc-4"} {
+		clock.Advance(2 * time.Minute)
+		send(fmt.Sprintf("fp-late-%d", i), service)
+	}
+
+	incidents, _ = store.ListIncidents(ctx)
+	if len(incidents) != 7 {
+		t.Errorf("Expected 2 new individual incidents after the storm, got %d incidents in total", len(incidents))
+	}
+	if storm, _ := store.GetIncident(ctx, storm.ID); len(storm.AlertIDs) != 16 {
+		t.Errorf("Expected the storm incident to stop collecting alerts, got %d", len(storm.AlertIDs))
+	}
+}
+
+func TestAlertService_AlertStormSummary(t *testing.T) {
+	ctx := context.Background()
+	alertService, incidentService, store := setupTestAlertService(t)
+	alertService.SetAlertStormPolicy(AlertStormPolicy{Threshold: 5, Window: time.Minute})
+	clock := &fakeClock{current: time.Date(2024, time.March, 15, 9, 0, 0, 0, time.UTC)}
+	alertService.storm.now = clock.Now
+
+	send := func(fingerprint, service string) {
+		t.Helper()
+		alert := testAlert(fingerprint, "firing", "high")
+		alert.Labels["service"] = service
+		if err := alertService.ProcessAlertmanagerWebhook(ctx, &AlertmanagerWebhook{Status: "firing", Alerts: []AlertmanagerAlert{alert}}); err != nil {
+			t.Fatalf("Failed to process webhook: %v", err)
+		}
+	}
+	for i := 0; i < 20; i++ {
+		send(fmt.Sprintf("fp-burst-%d", i), fmt.Sprintf("svc-%d", i))
+		clock.Advance(100 * time.Millisecond)
+	}
+
+	var storm *models.Incident
+	incidents, _ := store.ListIncidents(ctx)
+	for _, incident := range incidents {
+		if incident.Labels[AlertStormLabel] == "true" {
+			storm = incident
+		} else if incident.StormSummary != nil {
+			t.Errorf("Expected no storm summary on individual incident %s", incident.ID)
+		}
+	}
+	if storm == nil || storm.StormSummary == nil {
+		t.Fatalf("Expected a storm incident with a summary, got %+v", storm)
+	}
+	summary := storm.StormSummary
+	if summary.GroupedAlertCount != 16 {
+		t.Errorf("Expected 16 grouped alerts, got %d", summary.GroupedAlertCount)
+	}
+	if len(summary.SampleLabels) != alertStormSampleSize {
+		t.Fatalf("Expected %d sampled label sets, got %d", alertStormSampleSize, len(summary.SampleLabels))
+	}
+	if summary.SampleLabels[0]["service"] != "svc-4" || summary.SampleLabels[4]["service"] != "svc-8" {
+		t.Errorf("Expected the labels of the first grouped alerts, got %v", summary.SampleLabels)
+	}
+
+	data, err := json.Marshal(storm)
+	if err != nil {
+		t.Fatalf("Failed to marshal incident: %v", err)
+	}
+	if !strings.Contains(string(data), `"storm_summary":{"grouped_alert_count":16`) {
+		t.Errorf("Expected storm summary in incident JSON, got %s", data)
+	}
+
+	// The storm ends with the next alert at a normal rate
+	clock.Advance(2 * time.Minute)
+	send("fp-late", "svc-late")
+
+	timeline, err := incidentService.GetTimeline(ctx, storm.ID)
+	if err != nil {
+		t.Fatalf("Failed to get timeline: %v", err)
+	}
+	var stormEntries []*models.IncidentComment
+	for _, entry := range timeline {
+		if entry.CommentType == models.CommentTypeAlertStorm {
+			stormEntries = append(stormEntries, entry)
+		}
+	}
+	if len(stormEntries) != 2 {
+		t.Fatalf("Expected storm start and end timeline entries, got %d", len(stormEntries))
+	}
+	var ended *models.IncidentComment
+	for _, entry := range stormEntries {
+		if strings.HasPrefix(entry.Content, "Alert storm ended") {
+			ended = entry
+		}
+	}
+	if ended == nil || ended.Metadata["grouped_alert_count"] != 16 {
+		t.Errorf("Expected an end-of-storm entry reporting 16 grouped alerts, got %+v", ended)
+	}
+}
+
+func TestAlertService_LabelNormalizationCorrelatesPods(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name              string
+		rules             []string
+		expectedIncidents int
+	}{
+		{name: "no normalization", expectedIncidents: 2},
+		{name: "rewrite pod suffix", rules: []string{`rewrite:instance=^(.+)-[a-z0-9]+-[a-z0-9]+(:\d+)?$=>$1`}, expectedIncidents: 1},
+		{name: "drop instance", rules: []string{"drop:instance"}, expectedIncidents: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			alertService, _, store := setupTestAlertService(t)
+			rules, err := ParseLabelNormalizationRules(tt.rules)
+			if err != nil {
+				t.Fatalf("Failed to parse rules: %v", err)
+			}
+			alertService.SetLabelNormalization(rules)
+
+			// Same alert from two replicas of one deployment
+			for i, instance := range []string{"checkout-7d9f8c-x2k4p:8080", "checkout-7d9f8c-q9m1z:8080"} {
+				alert := testAlert(fmt.Sprintf("fp-pod-%d", i), "firing", "high")
+				delete(alert.Labels, "service")
+				alert.Labels["instance"] = instance
+				if err := alertService.ProcessAlertmanagerWebhook(ctx, &AlertmanagerWebhook{Status: "firing", Alerts: []AlertmanagerAlert{alert}}); err != nil {
+					t.Fatalf("Failed to process webhook: %v", err)
+				}
+			}
+
+			incidents, err := store.ListIncidents(ctx)
+			if err != nil {
+				t.Fatalf("Failed to list incidents: %v", err)
+			}
+			if len(incidents) != tt.expectedIncidents {
+				t.Fatalf("Expected %d incidents, got %d", tt.expectedIncidents, len(incidents))
+			}
+
+			// Normalization only affects correlation, not the stored labels
+			alerts, _ := store.ListAlerts(ctx)
+			for _, alert := range alerts {
+				if !strings.HasPrefix(alert.Labels["instance"], "checkout-7d9f8c-") {
+					t.Errorf("Expected stored instance label to be unchanged, got %q", alert.Labels["instance"])
+				}
+			}
+		})
+	}
+}
+
+func TestParseLabelNormalizationRules_Invalid(t *testing.T) {
+	for _, spec := range []string{"drop:", "rewrite:instance", "rewrite:instance=(=>x", "rename:pod"} {
+		if _, err := ParseLabelNormalizationRules([]string{spec}); err == nil {
+			t.Errorf("Expected %q to be rejected", spec)
+		}
+	}
+}
+
+func TestAlertService_OverflowAlertRedelivery(t *testing.T) {
+	ctx := context.Background()
+	alertService, incidentService, store := setupTestAlertService(t)
+	alertService.SetMaxAlertsPerIncident(1)
+	alertService.SetAutoResolve(true)
+
+	overflowCount := func() (int, models.IncidentStatus) {
+		t.Helper()
+		incidents, err := store.ListIncidents(ctx)
+		if err != nil || len(incidents) != 1 {
+			t.Fatalf("Expected 1 incident, got %d (err: %v)", len(incidents), err)
+		}
+		incident, err := incidentService.GetIncident(ctx, incidents[0].ID)
+		if err != nil {
+			t.Fatalf("Failed to get incident: %v", err)
+		}
+		return incident.OverflowAlertCount, incident.Status
+	}
+	send := func(alerts ...AlertmanagerAlert) {
+		t.Helper()
+		if err := alertService.ProcessAlertmanagerWebhook(ctx, &AlertmanagerWebhook{Alerts: alerts}); err != nil {
+			t.Fatalf("Failed to process webhook: %v", err)
+		}
+	}
+
+	send(testAlert("fp-stored", "firing", "high"), testAlert("fp-extra-1", "firing", "high"), testAlert("fp-extra-2", "firing", "high"))
+	// Alertmanager re-sends firing alerts every group_interval
+	for i := 0; i < 3; i++ {
+		send(testAlert("fp-extra-1", "firing", "high"), testAlert("fp-extra-2", "firing", "high"))
+	}
+	if count, _ := overflowCount(); count != 2 {
+		t.Fatalf("Expected re-deliveries to leave the overflow count at 2, got %d", count)
+	}
+	if alerts, _ := store.ListAlerts(ctx); len(alerts) != 1 {
+		t.Errorf("Expected only the first alert stored, got %d", len(alerts))
+	}
+
+	// Resolved overflow alerts leave the count, and the incident stays open
+	// until they have all resolved
+	send(testAlert("fp-stored", "resolved", "high"), testAlert("fp-extra-1", "resolved", "high"))
+	if count, status := overflowCount(); count != 1 || status != models.IncidentStatusOpen {
+		t.Fatalf("Expected 1 overflow alert left firing on an open incident, got %d on a %s one", count, status)
+	}
+	send(testAlert("fp-extra-1", "resolved", "high"))
+	if count, _ := overflowCount(); count != 1 {
+		t.Errorf("Expected a repeated resolution not to change the count, got %d", count)
+	}
+	send(testAlert("fp-extra-2", "resolved", "high"))
+	if count, status := overflowCount(); count != 0 || status != models.IncidentStatusResolved {
+		t.Errorf("Expected the incident auto-resolved with no overflow alerts, got %d on a %s one", count, status)
+	}
+}
+
+func TestAlertService_AutoResolveWhenAllAlertsResolve(t *testing.T) {
+	ctx := context.Background()
+	alertService, incidentService, store := setupTestAlertService(t)
+	alertService.SetAutoResolve(true)
+	var notified []*models.Incident
+	alertService.SetAutoResolveHook(func(incident *models.Incident) {
+		notified = append(notified, incident)
+	})
+
+	firing := &AlertmanagerWebhook{
+		Status: "firing",
+		Alerts: []AlertmanagerAlert{
+			testAlert("fp-api", "firing", "critical"),
+			testAlert("fp-db", "firing", "high"),
+		},
+	}
+	if err := alertService.ProcessAlertmanagerWebhook(ctx, firing); err != nil {
+		t.Fatalf("Failed to process firing webhook: %v", err)
+	}
+	incidents, err := store.ListIncidents(ctx)
+	if err != nil || len(incidents) != 1 {
+		t.Fatalf("Expected 1 incident, got %d (err: %v)", len(incidents), err)
+	}
+	incidentID := incidents[0].ID
+
+	// One alert still firing keeps the incident open
+	partial := &AlertmanagerWebhook{
+		Status: "resolved",
+		Alerts: []AlertmanagerAlert{testAlert("fp-api", "resolved", "critical")},
+	}
+	if err := alertService.ProcessAlertmanagerWebhook(ctx, partial); err != nil {
+		t.Fatalf("Failed to process resolved webhook: %v", err)
+	}
+	incident, err := incidentService.GetIncident(ctx, incidentID)
+	if err != nil {
+		t.Fatalf("Failed to get incident: %v", err)
+	}
+	if incident.Status != models.IncidentStatusOpen || len(notified) != 0 {
+		t.Fatalf("Expected a partially resolved incident to stay open, got %s with %d notifications", incident.Status, len(notified))
+	}
+
+	full := &AlertmanagerWebhook{
+		Status: "resolved",
+		Alerts: []AlertmanagerAlert{testAlert("fp-db", "resolved", "high")},
+	}
+	if err := alertService.ProcessAlertmanagerWebhook(ctx, full); err != nil {
+		t.Fatalf("Failed to process resolved webhook: %v", err)
+	}
+	incident, err = incidentService.GetIncident(ctx, incidentID)
+	if err != nil {
+		t.Fatalf("Failed to get incident: %v", err)
+	}
+	if incident.Status != models.IncidentStatusResolved || incident.ResolvedAt == nil {
+		t.Fatalf("Expected the incident to be resolved, got %s", incident.Status)
+	}
+	if incident.ResolutionType != models.ResolutionAutoRecovered {
+		t.Errorf("Expected resolution type %s, got %q", models.ResolutionAutoRecovered, incident.ResolutionType)
+	}
+	if len(notified) != 1 || notified[0].ID != incidentID || notified[0].Status != models.IncidentStatusResolved {
+		t.Errorf("Expected one resolved notification for the incident, got %d", len(notified))
+	}
+
+	// A repeated resolved delivery does not resolve or notify again
+	if err := alertService.ProcessAlertmanagerWebhook(ctx, full); err != nil {
+		t.Fatalf("Failed to process repeated webhook: %v", err)
+	}
+	if len(notified) != 1 {
+		t.Errorf("Expected no further notifications, got %d", len(notified))
+	}
+}
+
+func TestAlertService_RefiringAfterAutoResolveOpensIncident(t *testing.T) {
+	ctx := context.Background()
+	alertService, incidentService, store := setupTestAlertService(t)
+	alertService.SetAutoResolve(true)
+
+	firing := &AlertmanagerWebhook{Status: "firing", Alerts: []AlertmanagerAlert{testAlert("fp-api", "firing", "critical")}}
+	resolved := &AlertmanagerWebhook{Status: "resolved", Alerts: []AlertmanagerAlert{testAlert("fp-api", "resolved", "critical")}}
+	for _, webhook := range []*AlertmanagerWebhook{firing, resolved, firing} {
+		if err := alertService.ProcessAlertmanagerWebhook(ctx, webhook); err != nil {
+			t.Fatalf("Failed to process %s webhook: %v", webhook.Status, err)
+		}
+	}
+
+	incidents, err := store.ListIncidents(ctx)
+	if err != nil || len(incidents) != 2 {
+		t.Fatalf("Expected the alert firing again to open a second incident, got %d (err: %v)", len(incidents), err)
+	}
+	alert, err := store.GetAlertByFingerprint(ctx, "fp-api")
+	if err != nil {
+		t.Fatalf("Failed to get alert: %v", err)
+	}
+	for _, incident := range incidents {
+		incident, err := incidentService.GetIncident(ctx, incident.ID)
+		if err != nil {
+			t.Fatalf("Failed to get incident: %v", err)
+		}
+		if len(incident.AlertIDs) != 1 {
+			t.Fatalf("Expected incident %s to hold one alert, got %v", incident.Status, incident.AlertIDs)
+		}
+		switch incident.Status {
+		case models.IncidentStatusResolved:
+			// The resolved incident keeps the alert it was resolved with
+			old, err := store.GetAlert(ctx, incident.AlertIDs[0])
+			if err != nil || old.IncidentID != incident.ID || old.Status != "resolved" {
+				t.Errorf("Expected the resolved incident's alert to be left as it was, got %+v (err: %v)", old, err)
+			}
+			if old != nil && old.ID == alert.ID {
+				t.Error("Expected the alert firing again to be stored as a new alert")
+			}
+		case models.IncidentStatusOpen:
+			if alert.IncidentID != incident.ID || incident.AlertIDs[0] != alert.ID {
+				t.Errorf("Expected the alert to belong to the new incident %s, got %s", incident.ID, alert.IncidentID)
+			}
+		default:
+			t.Errorf("Unexpected incident status %s", incident.Status)
+		}
+	}
+
+	// Re-deliveries while it keeps firing stay with the new incident
+	if err := alertService.ProcessAlertmanagerWebhook(ctx, firing); err != nil {
+		t.Fatalf("Failed to process repeated webhook: %v", err)
+	}
+	if incidents, _ := store.ListIncidents(ctx); len(incidents) != 2 {
+		t.Errorf("Expected no further incidents, got %d", len(incidents))
+	}
+}
+
+func TestAlertService_RefiringAlertRespectsIncidentCap(t *testing.T) {
+	ctx := context.Background()
+	alertService, incidentService, store := setupTestAlertService(t)
+	alertService.SetAutoResolve(true)
+	alertService.SetMaxAlertsPerIncident(1)
+
+	for _, alert := range []AlertmanagerAlert{
+		testAlert("fp-api", "firing", "critical"),
+		testAlert("fp-api", "resolved", "critical"),
+		// A correlated alert opens a new incident, which is then at its cap
+		testAlert("fp-db", "firing", "critical"),
+		// so the first alert firing again overflows rather than joining it
+		testAlert("fp-api", "firing", "critical"),
+	} {
+		if err := alertService.ProcessAlertmanagerWebhook(ctx, &AlertmanagerWebhook{Status: alert.Status, Alerts: []AlertmanagerAlert{alert}}); err != nil {
+			t.Fatalf("Failed to process %s webhook: %v", alert.Status, err)
+		}
+	}
+
+	incidents, err := store.ListIncidents(ctx)
+	if err != nil || len(incidents) != 2 {
+		t.Fatalf("Expected 2 incidents, got %d (err: %v)", len(incidents), err)
+	}
+	for _, incident := range incidents {
+		incident, err := incidentService.GetIncident(ctx, incident.ID)
+		if err != nil {
+			t.Fatalf("Failed to get incident: %v", err)
+		}
+		if len(incident.AlertIDs) != 1 {
+			t.Errorf("Expected incident %s to keep one alert, got %d", incident.Status, len(incident.AlertIDs))
+		}
+		if incident.Status == models.IncidentStatusOpen && incident.OverflowAlertCount != 1 {
+			t.Errorf("Expected the re-fired alert to be counted as overflow, got %d", incident.OverflowAlertCount)
+		}
+	}
+	if alerts, _ := store.ListAlerts(ctx); len(alerts) != 2 {
+		t.Errorf("Expected the overflowing re-fire not to be stored, got %d alerts", len(alerts))
+	}
+}
+
+func TestAlertService_AutoResolveDisabled(t *testing.T) {
+	ctx := context.Background()
+	alertService, incidentService, store := setupTestAlertService(t)
+	alertService.SetAutoResolveHook(func(incident *models.Incident) {
+		t.Error("Expected no auto-resolve notification when disabled")
+	})
+
+	firing := &AlertmanagerWebhook{Status: "firing", Alerts: []AlertmanagerAlert{testAlert("fp-api", "firing", "critical")}}
+	if err := alertService.ProcessAlertmanagerWebhook(ctx, firing); err != nil {
+		t.Fatalf("Failed to process firing webhook: %v", err)
+	}
+	resolved := &AlertmanagerWebhook{Status: "resolved", Alerts: []AlertmanagerAlert{testAlert("fp-api", "resolved", "critical")}}
+	if err := alertService.ProcessAlertmanagerWebhook(ctx, resolved); err != nil {
+		t.Fatalf("Failed to process resolved webhook: %v", err)
+	}
+
+	incidents, err := store.ListIncidents(ctx)
+	if err != nil || len(incidents) != 1 {
+		t.Fatalf("Expected 1 incident, got %d (err: %v)", len(incidents), err)
+	}
+	incident, err := incidentService.GetIncident(ctx, incidents[0].ID)
+	if err != nil {
+		t.Fatalf("Failed to get incident: %v", err)
+	}
+	if incident.Status != models.IncidentStatusOpen {
+		t.Errorf("Expected the incident to stay open with auto-resolve disabled, got %s", incident.Status)
+	}
+}
+
+func TestAlertService_RedeliveredAlertIsDeduplicated(t *testing.T) {
+	ctx := context.Background()
+	alertService, _, store := setupTestAlertService(t)
+
+	webhook := &AlertmanagerWebhook{Status: "firing", Alerts: []AlertmanagerAlert{testAlert("fp-repeat", "firing", "high")}}
+	for i := 0; i < 2; i++ {
+		if err := alertService.ProcessAlertmanagerWebhook(ctx, webhook); err != nil {
+			t.Fatalf("Failed to process delivery %d: %v", i+1, err)
+		}
+	}
+
+	alerts, err := store.ListAlerts(ctx)
+	if err != nil || len(alerts) != 1 {
+		t.Fatalf("Expected 1 alert after a redelivery, got %d (err: %v)", len(alerts), err)
+	}
+	incidents, err := store.ListIncidents(ctx)
+	if err != nil || len(incidents) != 1 {
+		t.Fatalf("Expected 1 incident after a redelivery, got %d (err: %v)", len(incidents), err)
+	}
+	if alerts[0].IncidentID != incidents[0].ID || len(incidents[0].AlertIDs) != 1 {
+		t.Errorf("Expected the alert to be linked to the incident once, got %q and %v", alerts[0].IncidentID, incidents[0].AlertIDs)
+	}
+
+	// A later delivery updates the stored alert in place
+	endsAt := time.Now().Add(time.Minute).Truncate(time.Second)
+	resolved := testAlert("fp-repeat", "resolved", "high")
+	resolved.EndsAt = endsAt
+	if err := alertService.ProcessAlertmanagerWebhook(ctx, &AlertmanagerWebhook{Status: "resolved", Alerts: []AlertmanagerAlert{resolved}}); err != nil {
+		t.Fatalf("Failed to process resolved delivery: %v", err)
+	}
+	alert, err := store.GetAlertByFingerprint(ctx, "fp-repeat")
+	if err != nil {
+		t.Fatalf("Failed to get alert by fingerprint: %v", err)
+	}
+	if alert.ID != alerts[0].ID || alert.Status != "resolved" || !alert.EndsAt.Equal(endsAt) {
+		t.Errorf("Expected the original alert to be resolved in place, got %+v", alert)
+	}
+	if alerts, _ := store.ListAlerts(ctx); len(alerts) != 1 {
+		t.Errorf("Expected still 1 alert, got %d", len(alerts))
+	}
+}
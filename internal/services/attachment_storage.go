@@ -0,0 +1,121 @@
+package services
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/models"
+)
+
+// AttachmentStorage holds the contents of incident attachments. Keys are
+// slash-separated paths of the form {incidentID}/{fileName}.
+type AttachmentStorage interface {
+	// Put stores data under key and returns where it was stored, which is
+	// recorded as the attachment's file path
+	Put(key string, data []byte, contentType string) (string, error)
+	// Get opens the object stored under key. A missing object is reported
+	// with an error wrapping fs.ErrNotExist.
+	Get(key string) (io.ReadCloser, error)
+	// Delete removes the object stored under key
+	Delete(key string) error
+}
+
+// AttachmentURLSigner is implemented by attachment storage that can hand out
+// time-limited URLs clients download attachments from directly
+type AttachmentURLSigner interface {
+	SignedURL(key, fileName, contentType string) (string, time.Time, error)
+}
+
+// LocalAttachmentStorage keeps attachments in a directory on local disk
+type LocalAttachmentStorage struct {
+	dir string
+}
+
+// NewLocalAttachmentStorage creates attachment storage rooted at dir
+func NewLocalAttachmentStorage(dir string) *LocalAttachmentStorage {
+	return &LocalAttachmentStorage{dir: dir}
+}
+
+// path returns the file key is stored in, refusing keys that would leave the
+// storage directory
+func (l *LocalAttachmentStorage) path(key string) (string, error) {
+	name := filepath.FromSlash(key)
+	if !filepath.IsLocal(name) {
+		return "", fmt.Errorf("invalid attachment key %q", key)
+	}
+	return filepath.Join(l.dir, name), nil
+}
+
+// Put writes data to a file under the storage directory
+func (l *LocalAttachmentStorage) Put(key string, data []byte, contentType string) (string, error) {
+	path, err := l.path(key)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return "", fmt.Errorf("failed to create attachment directory: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o640); err != nil {
+		return "", fmt.Errorf("failed to store attachment: %w", err)
+	}
+	return path, nil
+}
+
+// Get opens the file stored under key
+func (l *LocalAttachmentStorage) Get(key string) (io.ReadCloser, error) {
+	path, err := l.path(key)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(path)
+}
+
+// Delete removes the file stored under key
+func (l *LocalAttachmentStorage) Delete(key string) error {
+	path, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
+
+// SetAttachmentStorage replaces the local attachment directory with other
+// storage, such as an S3 bucket
+func (s *IncidentService) SetAttachmentStorage(storage AttachmentStorage) {
+	s.attachmentStorage = storage
+}
+
+// storage returns the configured attachment storage, defaulting to the local
+// attachment directory
+func (s *IncidentService) storage() AttachmentStorage {
+	if s.attachmentStorage != nil {
+		return s.attachmentStorage
+	}
+	return NewLocalAttachmentStorage(s.attachmentDir)
+}
+
+// attachmentKey is the storage key of an attachment's contents
+func attachmentKey(attachment *models.IncidentAttachment) string {
+	return attachment.IncidentID + "/" + attachment.FileName
+}
+
+// OpenAttachment opens the stored contents of an attachment
+func (s *IncidentService) OpenAttachment(attachment *models.IncidentAttachment) (io.ReadCloser, error) {
+	return s.storage().Get(attachmentKey(attachment))
+}
+
+// setDownloadURL points the attachment's download URL at a signed storage
+// URL when the storage supports them, and at the API otherwise
+func (s *IncidentService) setDownloadURL(attachment *models.IncidentAttachment) {
+	attachment.DownloadURL = AttachmentDownloadURL(attachment)
+	signer, ok := s.storage().(AttachmentURLSigner)
+	if !ok {
+		return
+	}
+	if url, _, err := signer.SignedURL(attachmentKey(attachment), attachment.OriginalName, attachment.MimeType); err == nil {
+		attachment.DownloadURL = url
+	}
+}
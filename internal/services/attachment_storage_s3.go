@@ -0,0 +1,276 @@
+package services
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Defaults for S3 attachment storage
+const (
+	DefaultS3Region        = "us-east-1"
+	DefaultS3PresignExpiry = 15 * time.Minute
+)
+
+// unsignedPayload is the payload hash used in presigned URLs, whose body is
+// not known when the URL is signed
+const unsignedPayload = "UNSIGNED-PAYLOAD"
+
+// S3Config configures attachment storage in an S3-compatible bucket
+type S3Config struct {
+	// Endpoint is the base URL of the S3 API, such as http://minio:9000.
+	// It defaults to the AWS endpoint of the region.
+	Endpoint        string
+	Region          string
+	Bucket          string
+	AccessKeyID     string
+	SecretAccessKey string
+	// PresignExpiry is how long signed download URLs stay valid
+	PresignExpiry time.Duration
+}
+
+// S3AttachmentStorage keeps attachments in an S3-compatible bucket, using
+// path-style requests signed with AWS Signature Version 4 so it works with
+// AWS as well as MinIO and similar servers
+type S3AttachmentStorage struct {
+	endpoint *url.URL
+	config   S3Config
+	client   *http.Client
+	now      func() time.Time
+}
+
+// NewS3AttachmentStorage creates attachment storage for the configured bucket
+func NewS3AttachmentStorage(config S3Config) (*S3AttachmentStorage, error) {
+	if config.Bucket == "" {
+		return nil, errors.New("S3 bucket is required")
+	}
+	if config.AccessKeyID == "" || config.SecretAccessKey == "" {
+		return nil, errors.New("S3 access key ID and secret access key are required")
+	}
+	if config.Region == "" {
+		config.Region = DefaultS3Region
+	}
+	if config.PresignExpiry <= 0 {
+		config.PresignExpiry = DefaultS3PresignExpiry
+	}
+	if config.Endpoint == "" {
+		config.Endpoint = "https://s3." + config.Region + ".amazonaws.com"
+	}
+
+	endpoint, err := url.Parse(strings.TrimRight(config.Endpoint, "/"))
+	if err != nil || endpoint.Host == "" || (endpoint.Scheme != "http" && endpoint.Scheme != "https") {
+		return nil, fmt.Errorf("invalid S3 endpoint %q", config.Endpoint)
+	}
+
+	return &S3AttachmentStorage{
+		endpoint: endpoint,
+		config:   config,
+		client:   &http.Client{Timeout: 30 * time.Second},
+		now:      time.Now,
+	}, nil
+}
+
+// Put uploads data as an object and returns its s3:// location
+func (s *S3AttachmentStorage) Put(key string, data []byte, contentType string) (string, error) {
+	headers := map[string]string{}
+	if contentType != "" {
+		headers["content-type"] = contentType
+	}
+	resp, err := s.do(http.MethodPut, key, data, headers)
+	if err != nil {
+		return "", fmt.Errorf("failed to store attachment: %w", err)
+	}
+	resp.Body.Close()
+	return "s3://" + s.config.Bucket + "/" + key, nil
+}
+
+// Get downloads an object
+func (s *S3AttachmentStorage) Get(key string) (io.ReadCloser, error) {
+	resp, err := s.do(http.MethodGet, key, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// Delete removes an object
+func (s *S3AttachmentStorage) Delete(key string) error {
+	resp, err := s.do(http.MethodDelete, key, nil, nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// SignedURL returns a presigned GET URL for an object that downloads it under
+// fileName with contentType, and when the URL expires
+func (s *S3AttachmentStorage) SignedURL(key, fileName, contentType string) (string, time.Time, error) {
+	now := s.now().UTC()
+	query := url.Values{}
+	query.Set("X-Amz-Algorithm", "AWS4-HMAC-SHA256")
+	query.Set("X-Amz-Credential", s.config.AccessKeyID+"/"+sigV4Scope(now, s.config.Region))
+	query.Set("X-Amz-Date", now.Format(sigV4TimeFormat))
+	query.Set("X-Amz-Expires", strconv.Itoa(int(s.config.PresignExpiry/time.Second)))
+	query.Set("X-Amz-SignedHeaders", "host")
+	if fileName != "" {
+		query.Set("response-content-disposition", ContentDisposition(fileName))
+	}
+	if contentType != "" {
+		query.Set("response-content-type", contentType)
+	}
+
+	path := s.objectPath(key)
+	signature, _ := signV4(s.config.SecretAccessKey, s.config.Region, http.MethodGet, path, query,
+		map[string]string{"host": s.endpoint.Host}, unsignedPayload, now)
+	query.Set("X-Amz-Signature", signature)
+
+	signed := s.endpoint.Scheme + "://" + s.endpoint.Host + path + "?" + canonicalQuery(query)
+	return signed, now.Add(s.config.PresignExpiry), nil
+}
+
+// objectPath returns the escaped path of an object in the bucket
+func (s *S3AttachmentStorage) objectPath(key string) string {
+	return s.endpoint.EscapedPath() + "/" + uriEncode(s.config.Bucket, true) + "/" + uriEncode(key, false)
+}
+
+// do sends a signed request for an object. Responses other than 2xx are
+// turned into errors, with 404 wrapping fs.ErrNotExist.
+func (s *S3AttachmentStorage) do(method, key string, body []byte, headers map[string]string) (*http.Response, error) {
+	now := s.now().UTC()
+	sum := sha256.Sum256(body)
+	payloadHash := hex.EncodeToString(sum[:])
+
+	signed := map[string]string{
+		"host":                 s.endpoint.Host,
+		"x-amz-content-sha256": payloadHash,
+		"x-amz-date":           now.Format(sigV4TimeFormat),
+	}
+	for name, value := range headers {
+		signed[name] = value
+	}
+	path := s.objectPath(key)
+	signature, signedHeaders := signV4(s.config.SecretAccessKey, s.config.Region, method, path, nil, signed, payloadHash, now)
+
+	req, err := http.NewRequest(method, s.endpoint.Scheme+"://"+s.endpoint.Host+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for name, value := range signed {
+		if name != "host" {
+			req.Header.Set(name, value)
+		}
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.config.AccessKeyID, sigV4Scope(now, s.config.Region), signedHeaders, signature))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%w: s3://%s/%s", fs.ErrNotExist, s.config.Bucket, key)
+	}
+	message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return nil, fmt.Errorf("S3 %s %s returned %d: %s", method, key, resp.StatusCode, strings.TrimSpace(string(message)))
+}
+
+// sigV4TimeFormat is the ISO 8601 basic format Signature Version 4 uses
+const sigV4TimeFormat = "20060102T150405Z"
+
+// sigV4Scope returns the credential scope of an S3 signature made at t
+func sigV4Scope(t time.Time, region string) string {
+	return t.Format("20060102") + "/" + region + "/s3/aws4_request"
+}
+
+// signV4 computes the AWS Signature Version 4 of an S3 request. path must
+// already be escaped and headers maps lowercase names to the values of every
+// header being signed, including host. It returns the hex signature and the
+// signed header list.
+func signV4(secretKey, region, method, path string, query url.Values, headers map[string]string, payloadHash string, t time.Time) (string, string) {
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(headers[name]) + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		method, path, canonicalQuery(query), canonicalHeaders.String(), signedHeaders, payloadHash,
+	}, "\n")
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256", t.Format(sigV4TimeFormat), sigV4Scope(t, region), hex.EncodeToString(requestHash[:]),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+secretKey), t.Format("20060102"))
+	for _, part := range []string{region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	return hex.EncodeToString(hmacSHA256(key, stringToSign)), signedHeaders
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// canonicalQuery encodes query parameters sorted by name as Signature
+// Version 4 requires
+func canonicalQuery(query url.Values) string {
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var parts []string
+	for _, name := range names {
+		values := append([]string(nil), query[name]...)
+		sort.Strings(values)
+		for _, value := range values {
+			parts = append(parts, uriEncode(name, true)+"="+uriEncode(value, true))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// uriEncode percent-encodes everything but RFC 3986 unreserved characters,
+// leaving slashes alone unless encodeSlash is set
+func uriEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
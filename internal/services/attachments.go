@@ -0,0 +1,169 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/models"
+)
+
+// Errors returned when an uploaded attachment is rejected
+var (
+	ErrAttachmentTooLarge       = errors.New("attachment is too large")
+	ErrAttachmentTypeNotAllowed = errors.New("attachment type is not allowed")
+	ErrInvalidAttachmentName    = errors.New("invalid attachment file name")
+	ErrInvalidAttachmentType    = errors.New("attachment_type must be one of runbook, screenshot, log, document or general")
+)
+
+// ErrAttachmentOtherIncident is returned when an attachment is requested
+// through an incident it does not belong to
+var ErrAttachmentOtherIncident = errors.New("attachment belongs to a different incident")
+
+// DefaultMaxAttachmentBytes is the largest file that may be uploaded as an
+// attachment unless configured otherwise
+const DefaultMaxAttachmentBytes = 10 << 20
+
+// DefaultAllowedAttachmentTypes are the MIME types accepted for uploads
+// unless configured otherwise
+var DefaultAllowedAttachmentTypes = []string{
+	"image/png", "image/jpeg", "image/gif", "image/webp",
+	"text/plain", "application/pdf", "application/zip",
+}
+
+// SetAttachmentLimits sets the size limit and MIME type allowlist for
+// uploaded attachments. Non-positive or empty values keep the current
+// settings.
+func (s *IncidentService) SetAttachmentLimits(maxBytes int64, allowedTypes []string) {
+	if maxBytes > 0 {
+		s.maxAttachmentBytes = maxBytes
+	}
+	if len(allowedTypes) > 0 {
+		s.allowedAttachmentTypes = make(map[string]bool, len(allowedTypes))
+		for _, mimeType := range allowedTypes {
+			s.allowedAttachmentTypes[strings.ToLower(strings.TrimSpace(mimeType))] = true
+		}
+	}
+}
+
+// MaxAttachmentBytes returns the size limit for uploaded attachments
+func (s *IncidentService) MaxAttachmentBytes() int64 {
+	return s.maxAttachmentBytes
+}
+
+// UploadAttachment stores content as an attachment of the incident. The MIME
+// type is detected from the content rather than trusted from the client and
+// must be on the allowlist. originalName is kept for display only; the file
+// is written under a generated name.
+func (s *IncidentService) UploadAttachment(ctx context.Context, incidentID, userID, originalName string, attachmentType models.AttachmentType, content io.Reader) (*models.IncidentAttachment, error) {
+	if !validAttachmentName(originalName) {
+		return nil, ErrInvalidAttachmentName
+	}
+	if attachmentType == "" {
+		attachmentType = models.AttachmentTypeGeneral
+	}
+	if !attachmentType.Valid() {
+		return nil, ErrInvalidAttachmentType
+	}
+	if _, err := s.store.GetIncident(ctx, incidentID); err != nil {
+		return nil, err
+	}
+
+	data, err := io.ReadAll(io.LimitReader(content, s.maxAttachmentBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read attachment: %w", err)
+	}
+	if int64(len(data)) > s.maxAttachmentBytes {
+		return nil, ErrAttachmentTooLarge
+	}
+
+	mimeType, _, _ := mime.ParseMediaType(http.DetectContentType(data))
+	if !s.allowedAttachmentTypes[mimeType] {
+		return nil, fmt.Errorf("%w: %s", ErrAttachmentTypeNotAllowed, mimeType)
+	}
+
+	attachment := &models.IncidentAttachment{
+		IncidentID:     incidentID,
+		FileName:       uuid.New().String() + attachmentExtension(originalName),
+		OriginalName:   originalName,
+		FileSize:       int64(len(data)),
+		MimeType:       mimeType,
+		AttachmentType: attachmentType,
+	}
+	storage := s.storage()
+	key := attachmentKey(attachment)
+	if attachment.FilePath, err = storage.Put(key, data, mimeType); err != nil {
+		return nil, err
+	}
+	if err := s.AttachFile(ctx, attachment, userID); err != nil {
+		storage.Delete(key)
+		return nil, err
+	}
+	s.setDownloadURL(attachment)
+	return attachment, nil
+}
+
+// GetAttachment returns an attachment of the incident
+func (s *IncidentService) GetAttachment(ctx context.Context, incidentID, attachmentID string) (*models.IncidentAttachment, error) {
+	if _, err := s.store.GetIncident(ctx, incidentID); err != nil {
+		return nil, err
+	}
+	attachment, err := s.store.GetIncidentAttachment(ctx, attachmentID)
+	if err != nil {
+		return nil, err
+	}
+	if attachment.IncidentID != incidentID {
+		return nil, ErrAttachmentOtherIncident
+	}
+	s.setDownloadURL(attachment)
+	return attachment, nil
+}
+
+// validAttachmentName rejects names that are empty, carry a directory part
+// or refer to a directory themselves
+func validAttachmentName(name string) bool {
+	if name == "" || name == "." || name == ".." || len(name) > 255 {
+		return false
+	}
+	return !strings.ContainsAny(name, "/\\\x00")
+}
+
+// attachmentExtension returns the lowercase extension of name, or "" when it
+// has none or it is not plain letters and digits
+func attachmentExtension(name string) string {
+	ext := strings.ToLower(filepath.Ext(name))
+	if len(ext) < 2 || len(ext) > 10 {
+		return ""
+	}
+	for _, r := range ext[1:] {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') {
+			return ""
+		}
+	}
+	return ext
+}
+
+// ContentDisposition returns an attachment Content-Disposition header for
+// fileName, adding an RFC 5987 filename* for names that are not plain ASCII
+func ContentDisposition(fileName string) string {
+	ascii := strings.Map(func(r rune) rune {
+		if r < 0x20 || r > 0x7e {
+			return '_'
+		}
+		return r
+	}, fileName)
+	quoted := strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(ascii)
+
+	disposition := `attachment; filename="` + quoted + `"`
+	if ascii != fileName {
+		disposition += "; filename*=UTF-8''" + url.PathEscape(fileName)
+	}
+	return disposition
+}
@@ -0,0 +1,66 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/models"
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/storage"
+)
+
+func TestUploadAttachment_FileNames(t *testing.T) {
+	ctx := context.Background()
+	store, err := storage.NewMemoryStore()
+	if err != nil {
+		t.Fatalf("Failed to create memory store: %v", err)
+	}
+	incidentService := NewIncidentService(store, NewMetricsService(prometheus.NewRegistry()))
+	dir := t.TempDir()
+	incidentService.SetInlineImageStorage(dir, 0)
+
+	incident, err := incidentService.CreateIncident(ctx, "Checkout errors", "", models.SeverityHigh, []string{})
+	if err != nil {
+		t.Fatalf("Failed to create incident: %v", err)
+	}
+
+	for _, name := range []string{"../../etc/cron.d/job", `..\..\boot.ini`, "..", "", "logs/app.log"} {
+		_, err := incidentService.UploadAttachment(ctx, incident.ID, "user-1", name, "", strings.NewReader("hello"))
+		if !errors.Is(err, ErrInvalidAttachmentName) {
+			t.Errorf("Expected %q to be rejected, got %v", name, err)
+		}
+	}
+
+	// Accepted files are stored under a generated name inside the incident's directory
+	attachment, err := incidentService.UploadAttachment(ctx, incident.ID, "user-1", "Error Log.TXT", "", strings.NewReader("hello"))
+	if err != nil {
+		t.Fatalf("UploadAttachment failed: %v", err)
+	}
+	if filepath.Dir(attachment.FilePath) != filepath.Join(dir, incident.ID) || !strings.HasSuffix(attachment.FileName, ".txt") {
+		t.Errorf("Unexpected storage path %s", attachment.FilePath)
+	}
+	if _, err := os.Stat(attachment.FilePath); err != nil {
+		t.Errorf("Expected the file to exist: %v", err)
+	}
+	if attachment.OriginalName != "Error Log.TXT" || attachment.AttachmentType != models.AttachmentTypeGeneral {
+		t.Errorf("Unexpected attachment %+v", attachment)
+	}
+
+	if _, err := incidentService.UploadAttachment(ctx, incident.ID, "user-1", "a.txt", "video", strings.NewReader("hello")); !errors.Is(err, ErrInvalidAttachmentType) {
+		t.Errorf("Expected ErrInvalidAttachmentType, got %v", err)
+	}
+	if _, err := incidentService.UploadAttachment(ctx, "missing", "user-1", "a.txt", "", strings.NewReader("hello")); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestContentDisposition(t *testing.T) {
+	if got, want := ContentDisposition("résumé.pdf"), `attachment; filename="r_sum_.pdf"; filename*=UTF-8''r%C3%A9sum%C3%A9.pdf`; got != want {
+		t.Errorf("ContentDisposition() = %q, want %q", got, want)
+	}
+}
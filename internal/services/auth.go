@@ -1,10 +1,12 @@
 package services
 
 import (
+	"context"
 	"crypto/rand"
 	"encoding/base64"
 	"errors"
 	"fmt"
+	"slices"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -20,6 +22,9 @@ var (
 	ErrUserNotActive      = errors.New("user account is not active")
 	ErrTokenExpired       = errors.New("token has expired")
 	ErrInvalidToken       = errors.New("invalid token")
+	// ErrNoPermissionStore is returned by permission checks when no store
+	// has been configured to load roles from
+	ErrNoPermissionStore = errors.New("no permission store configured")
 )
 
 // Claims represents JWT claims with user information
@@ -32,11 +37,28 @@ type Claims struct {
 	jwt.RegisteredClaims
 }
 
+// WebSocketTokenTTL is how long a token from GenerateWebSocketToken can be
+// used to open a WebSocket
+const WebSocketTokenTTL = 30 * time.Second
+
+// webSocketTokenAudience marks tokens that are only valid for GET /api/ws
+const webSocketTokenAudience = "websocket"
+
 // AuthService handles authentication operations
 type AuthService struct {
 	jwtSecret      []byte
 	jwtExpiration  time.Duration
 	refreshExpiration time.Duration
+	// permissionStore is where UserHasPermission loads roles from
+	permissionStore PermissionStore
+	// totpKey encrypts stored TOTP secrets; empty derives it from jwtSecret
+	totpKey string
+}
+
+// PermissionStore loads a user's roles and the permissions they grant
+type PermissionStore interface {
+	GetUserRoles(ctx context.Context, userID string) ([]*models.Role, error)
+	GetRolePermissions(ctx context.Context, roleID string) ([]*models.Permission, error)
 }
 
 // NewAuthService creates a new authentication service
@@ -48,6 +70,42 @@ func NewAuthService(jwtSecret string, jwtExpiration, refreshExpiration time.Dura
 	}
 }
 
+// SetPermissionStore sets the store UserHasPermission loads roles and
+// permissions from
+func (s *AuthService) SetPermissionStore(store PermissionStore) {
+	s.permissionStore = store
+}
+
+// UserHasPermission reports whether one of the user's roles, as currently
+// stored, grants action on resource. The admin role grants everything.
+// Unlike HasPermission it does not trust the token, so role changes apply
+// before the user logs in again.
+func (s *AuthService) UserHasPermission(ctx context.Context, userID, resource, action string) (bool, error) {
+	if s.permissionStore == nil {
+		return false, ErrNoPermissionStore
+	}
+
+	roles, err := s.permissionStore.GetUserRoles(ctx, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to load user roles: %w", err)
+	}
+	for _, role := range roles {
+		if role.Name == "admin" {
+			return true, nil
+		}
+		permissions, err := s.permissionStore.GetRolePermissions(ctx, role.ID)
+		if err != nil {
+			return false, fmt.Errorf("failed to load role permissions: %w", err)
+		}
+		for _, permission := range permissions {
+			if permission.Resource == resource && permission.Action == action {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
 // HashPassword hashes a password using bcrypt
 func (s *AuthService) HashPassword(password string) (string, error) {
 	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
@@ -147,6 +205,64 @@ func (s *AuthService) ValidateToken(tokenString string) (*Claims, error) {
 		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
 	}
 
+	if claims, ok := token.Claims.(*Claims); ok && token.Valid {
+		// WebSocket tokens travel in URLs, so they are not accepted as
+		// access tokens
+		if slices.Contains(claims.Audience, webSocketTokenAudience) {
+			return nil, ErrInvalidToken
+		}
+		return claims, nil
+	}
+
+	return nil, ErrInvalidToken
+}
+
+// GenerateWebSocketToken issues a short-lived token carrying the given
+// claims, which browsers pass in the query string of GET /api/ws since they
+// cannot set an Authorization header on a WebSocket handshake
+func (s *AuthService) GenerateWebSocketToken(claims *Claims) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(WebSocketTokenTTL)
+	wsClaims := &Claims{
+		UserID:      claims.UserID,
+		Username:    claims.Username,
+		Email:       claims.Email,
+		Roles:       claims.Roles,
+		Permissions: claims.Permissions,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.New().String(),
+			Subject:   claims.UserID,
+			Audience:  jwt.ClaimStrings{webSocketTokenAudience},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			NotBefore: jwt.NewNumericDate(now),
+			Issuer:    "incident-management-system",
+		},
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, wsClaims).SignedString(s.jwtSecret)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to sign websocket token: %w", err)
+	}
+	return token, expiresAt, nil
+}
+
+// ValidateWebSocketToken validates a token issued by GenerateWebSocketToken
+func (s *AuthService) ValidateWebSocketToken(tokenString string) (*Claims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return s.jwtSecret, nil
+	}, jwt.WithAudience(webSocketTokenAudience))
+
+	if err != nil {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return nil, ErrTokenExpired
+		}
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
 	if claims, ok := token.Claims.(*Claims); ok && token.Valid {
 		return claims, nil
 	}
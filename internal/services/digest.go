@@ -0,0 +1,247 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/cron"
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/models"
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/storage"
+)
+
+// DigestNotificationType is the template type used for open incident digests
+const DigestNotificationType = "incident_digest"
+
+// digestSeverityOrder lists severities from most to least urgent
+var digestSeverityOrder = []models.IncidentSeverity{
+	models.SeverityCritical,
+	models.SeverityHigh,
+	models.SeverityMedium,
+	models.SeverityLow,
+}
+
+// IncidentDigest summarises unresolved incidents for an on-call handoff
+type IncidentDigest struct {
+	GeneratedAt time.Time             `json:"generated_at"`
+	Total       int                   `json:"total"`
+	Groups      []DigestSeverityGroup `json:"groups"`
+}
+
+// DigestSeverityGroup holds the digest entries of a single severity
+type DigestSeverityGroup struct {
+	Severity  models.IncidentSeverity `json:"severity"`
+	Incidents []DigestEntry           `json:"incidents"`
+}
+
+// DigestEntry is a single incident line in a digest
+type DigestEntry struct {
+	ID       string                `json:"id"`
+	Title    string                `json:"title"`
+	Status   models.IncidentStatus `json:"status"`
+	Age      string                `json:"age"`
+	Assignee string                `json:"assignee"`
+}
+
+// DigestScheduler periodically sends a digest of open incidents to a channel
+type DigestScheduler struct {
+	store               storage.Store
+	notificationService *NotificationService
+	logger              *Logger
+	schedule            *cron.Schedule
+	channelID           string
+
+	// now and deliver are replaced in tests
+	now     func() time.Time
+	deliver func(channel *models.NotificationChannel, subject, content string) error
+
+	mutex    sync.Mutex
+	next     time.Time
+	stopChan chan struct{}
+	stopOnce sync.Once
+}
+
+// NewDigestScheduler creates a scheduler that sends the digest to channelID
+// whenever schedule fires
+func NewDigestScheduler(store storage.Store, notificationService *NotificationService, schedule *cron.Schedule, channelID string, logger *Logger) *DigestScheduler {
+	return &DigestScheduler{
+		store:               store,
+		notificationService: notificationService,
+		logger:              logger,
+		schedule:            schedule,
+		channelID:           channelID,
+		now:                 time.Now,
+		deliver:             notificationService.sendRendered,
+		stopChan:            make(chan struct{}),
+	}
+}
+
+// Start checks the schedule every 30 seconds until Stop is called
+func (s *DigestScheduler) Start() {
+	go func() {
+		ticker := time.NewTicker(30 * time.Second)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if _, err := s.runDue(context.Background()); err != nil {
+					s.logger.Error("Failed to send incident digest", map[string]interface{}{
+						"channel_id": s.channelID,
+						"error":      err.Error(),
+					})
+				}
+			case <-s.stopChan:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the digest scheduler
+func (s *DigestScheduler) Stop() {
+	s.stopOnce.Do(func() { close(s.stopChan) })
+}
+
+// NextRun returns when the digest is next due
+func (s *DigestScheduler) NextRun() time.Time {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.next.IsZero() {
+		s.next = s.schedule.Next(s.now())
+	}
+	return s.next
+}
+
+// runDue sends the digest if its scheduled time has passed and reports
+// whether it fired. A missed slot is sent once rather than replayed.
+func (s *DigestScheduler) runDue(ctx context.Context) (bool, error) {
+	now := s.now()
+	due := s.NextRun()
+	if due.IsZero() || now.Before(due) {
+		return false, nil
+	}
+
+	s.mutex.Lock()
+	s.next = s.schedule.Next(now)
+	s.mutex.Unlock()
+
+	return true, s.SendDigest(ctx)
+}
+
+// SendDigest builds the digest and sends it to the configured channel now
+func (s *DigestScheduler) SendDigest(ctx context.Context) error {
+	channel, err := s.store.GetNotificationChannel(ctx, s.channelID)
+	if err != nil {
+		return fmt.Errorf("digest channel %s: %w", s.channelID, err)
+	}
+
+	digest, err := s.BuildDigest(ctx)
+	if err != nil {
+		return err
+	}
+
+	tmpl := s.notificationService.getTemplateForChannel(channel, DigestNotificationType)
+	vars := TemplateVariables{
+		Digest:      digest,
+		Timestamp:   digest.GeneratedAt,
+		SystemName:  "Incident Management System",
+		ChannelName: channel.Name,
+	}
+
+	subject, content, err := s.notificationService.templateService.RenderTemplate(tmpl, vars)
+	if err != nil {
+		return fmt.Errorf("digest template rendering failed: %w", err)
+	}
+
+	if err := s.deliver(channel, subject, content); err != nil {
+		s.notificationService.metricsService.RecordNotificationDelivery(channel.Type, DigestNotificationType, "failed")
+		return err
+	}
+
+	s.notificationService.metricsService.RecordNotificationDelivery(channel.Type, DigestNotificationType, "sent")
+	s.logger.Info("Incident digest sent", map[string]interface{}{
+		"channel_id": channel.ID,
+		"incidents":  digest.Total,
+	})
+	return nil
+}
+
+// BuildDigest collects unresolved incidents grouped by severity, oldest first
+func (s *DigestScheduler) BuildDigest(ctx context.Context) (*IncidentDigest, error) {
+	incidents, err := s.store.ListIncidents(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	bySeverity := make(map[models.IncidentSeverity][]*models.Incident)
+	total := 0
+	for _, incident := range incidents {
+		if incident.Status == models.IncidentStatusResolved {
+			continue
+		}
+		bySeverity[incident.Severity] = append(bySeverity[incident.Severity], incident)
+		total++
+	}
+
+	digest := &IncidentDigest{GeneratedAt: now, Total: total}
+	for _, severity := range digestSeverityOrder {
+		group := bySeverity[severity]
+		if len(group) == 0 {
+			continue
+		}
+
+		sort.Slice(group, func(i, j int) bool {
+			return group[i].CreatedAt.Before(group[j].CreatedAt)
+		})
+
+		entries := make([]DigestEntry, 0, len(group))
+		for _, incident := range group {
+			entries = append(entries, DigestEntry{
+				ID:       incident.ID,
+				Title:    incident.Title,
+				Status:   incident.Status,
+				Age:      formatAge(now.Sub(incident.CreatedAt)),
+				Assignee: s.assigneeName(ctx, incident.AssigneeID),
+			})
+		}
+		digest.Groups = append(digest.Groups, DigestSeverityGroup{Severity: severity, Incidents: entries})
+	}
+
+	return digest, nil
+}
+
+// assigneeName resolves an assignee ID to a username where possible
+func (s *DigestScheduler) assigneeName(ctx context.Context, assigneeID string) string {
+	if assigneeID == "" {
+		return "unassigned"
+	}
+	if user, err := s.store.GetUser(ctx, assigneeID); err == nil && user.Username != "" {
+		return user.Username
+	}
+	return assigneeID
+}
+
+// formatAge renders a duration at minute precision, e.g. "1d3h", "2h15m", "45m"
+func formatAge(d time.Duration) string {
+	if d < time.Minute {
+		return "<1m"
+	}
+
+	days := int(d / (24 * time.Hour))
+	hours := int(d % (24 * time.Hour) / time.Hour)
+	minutes := int(d % time.Hour / time.Minute)
+
+	switch {
+	case days > 0:
+		return fmt.Sprintf("%dd%dh", days, hours)
+	case hours > 0:
+		return fmt.Sprintf("%dh%dm", hours, minutes)
+	default:
+		return fmt.Sprintf("%dm", minutes)
+	}
+}
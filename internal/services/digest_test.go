@@ -0,0 +1,125 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/config"
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/cron"
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/models"
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/storage"
+)
+
+type fakeClock struct {
+	current time.Time
+}
+
+func (c *fakeClock) Now() time.Time { return c.current }
+
+func (c *fakeClock) Advance(d time.Duration) { c.current = c.current.Add(d) }
+
+type sentDigest struct {
+	channelID string
+	subject   string
+	content   string
+}
+
+func TestDigestScheduler_FiresOnSchedule(t *testing.T) {
+	ctx := context.Background()
+	store, err := storage.NewMemoryStore()
+	if err != nil {
+		t.Fatalf("Failed to create memory store: %v", err)
+	}
+	logger := NewLogger("error", false)
+	notificationService := NewNotificationService(&config.Config{Port: "8080"}, store, NewNotificationTemplateService(logger), NewMetricsService(prometheus.NewRegistry()), logger)
+
+	channel := &models.NotificationChannel{ID: "handoff", Name: "On-call handoff", Type: "slack", Enabled: true}
+	if err := store.CreateNotificationChannel(ctx, channel); err != nil {
+		t.Fatalf("Failed to create channel: %v", err)
+	}
+	if err := store.CreateUser(ctx, &models.User{ID: "user-1", Username: "alice"}); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+
+	clock := &fakeClock{current: time.Date(2024, time.March, 15, 8, 58, 0, 0, time.UTC)}
+	incidents := []*models.Incident{
+		{ID: "inc-high", Title: "API errors", Status: models.IncidentStatusOpen, Severity: models.SeverityHigh, CreatedAt: clock.Now().Add(-45 * time.Minute)},
+		{ID: "inc-critical", Title: "Database down", Status: models.IncidentStatusAcknowledged, Severity: models.SeverityCritical, AssigneeID: "user-1", CreatedAt: clock.Now().Add(-26 * time.Hour)},
+		{ID: "inc-resolved", Title: "Old outage", Status: models.IncidentStatusResolved, Severity: models.SeverityCritical, CreatedAt: clock.Now().Add(-48 * time.Hour)},
+	}
+	for _, incident := range incidents {
+		if err := store.CreateIncident(ctx, incident); err != nil {
+			t.Fatalf("Failed to create incident: %v", err)
+		}
+	}
+
+	schedule, err := cron.Parse("0 9 * * *")
+	if err != nil {
+		t.Fatalf("Failed to parse schedule: %v", err)
+	}
+
+	var sent []sentDigest
+	scheduler := NewDigestScheduler(store, notificationService, schedule, channel.ID, logger)
+	scheduler.now = clock.Now
+	scheduler.deliver = func(channel *models.NotificationChannel, subject, content string) error {
+		sent = append(sent, sentDigest{channelID: channel.ID, subject: subject, content: content})
+		return nil
+	}
+
+	if fired, err := scheduler.runDue(ctx); err != nil || fired {
+		t.Fatalf("Expected no digest before the scheduled time (fired=%v, err=%v)", fired, err)
+	}
+
+	clock.Advance(2 * time.Minute)
+	if fired, err := scheduler.runDue(ctx); err != nil || !fired {
+		t.Fatalf("Expected digest at 09:00 (fired=%v, err=%v)", fired, err)
+	}
+	if len(sent) != 1 || sent[0].channelID != "handoff" {
+		t.Fatalf("Expected one digest to the handoff channel, got %+v", sent)
+	}
+
+	content := sent[0].content
+	for _, want := range []string{"2 open", "CRITICAL", "Database down", "open 1d2h", "alice", "HIGH", "API errors", "open 47m", "unassigned"} {
+		if !strings.Contains(content, want) {
+			t.Errorf("Expected digest to contain %q, got:\n%s", want, content)
+		}
+	}
+	if strings.Contains(content, "Old outage") {
+		t.Errorf("Expected resolved incidents to be left out, got:\n%s", content)
+	}
+	if strings.Index(content, "CRITICAL") > strings.Index(content, "HIGH") {
+		t.Errorf("Expected critical incidents to be listed before high, got:\n%s", content)
+	}
+
+	clock.Advance(time.Minute)
+	if fired, _ := scheduler.runDue(ctx); fired {
+		t.Error("Expected the digest to fire only once per slot")
+	}
+
+	clock.Advance(24 * time.Hour)
+	if fired, err := scheduler.runDue(ctx); err != nil || !fired {
+		t.Errorf("Expected digest on the next day (fired=%v, err=%v)", fired, err)
+	}
+	if len(sent) != 2 {
+		t.Errorf("Expected 2 digests in total, got %d", len(sent))
+	}
+}
+
+func TestFormatAge(t *testing.T) {
+	tests := map[time.Duration]string{
+		30 * time.Second:              "<1m",
+		45 * time.Minute:              "45m",
+		2*time.Hour + 15*time.Minute:  "2h15m",
+		27*time.Hour + 30*time.Minute: "1d3h",
+	}
+
+	for d, expected := range tests {
+		if got := formatAge(d); got != expected {
+			t.Errorf("formatAge(%s) = %q, expected %q", d, got, expected)
+		}
+	}
+}
@@ -1,25 +1,31 @@
 package services
 
 import (
+	"context"
+	"errors"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+
 	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/models"
 	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/storage"
 )
 
 func TestEnhancedIncidentFeatures(t *testing.T) {
+	ctx := context.Background()
 	store, err := storage.NewMemoryStore()
 	if err != nil {
 		t.Fatalf("Failed to create memory store: %v", err)
 	}
 	defer store.Close()
 
-	metricsService := NewMetricsService()
+	metricsService := NewMetricsService(prometheus.NewRegistry())
 	incidentService := NewIncidentService(store, metricsService)
 
 	// Create a test incident
-	incident, err := incidentService.CreateIncident(
+	incident, err := incidentService.CreateIncident(ctx,
 		"Test Incident",
 		"Test Description",
 		models.SeverityHigh,
@@ -31,7 +37,7 @@ func TestEnhancedIncidentFeatures(t *testing.T) {
 
 	t.Run("Comments and Timeline", func(t *testing.T) {
 		// Test adding comments
-		comment, err := incidentService.AddComment(
+		comment, err := incidentService.AddComment(ctx,
 			incident.ID,
 			"test-user-1",
 			"This is a test comment",
@@ -47,7 +53,7 @@ func TestEnhancedIncidentFeatures(t *testing.T) {
 		}
 
 		// Test retrieving comments
-		comments, err := incidentService.GetComments(incident.ID)
+		comments, err := incidentService.GetComments(ctx, incident.ID)
 		if err != nil {
 			t.Fatalf("Failed to get comments: %v", err)
 		}
@@ -57,7 +63,7 @@ func TestEnhancedIncidentFeatures(t *testing.T) {
 		}
 
 		// Test timeline
-		timeline, err := incidentService.GetTimeline(incident.ID)
+		timeline, err := incidentService.GetTimeline(ctx, incident.ID)
 		if err != nil {
 			t.Fatalf("Failed to get timeline: %v", err)
 		}
@@ -74,13 +80,13 @@ func TestEnhancedIncidentFeatures(t *testing.T) {
 			{Name: "service", Value: "api", Color: "#00ff00"},
 		}
 
-		err := incidentService.AddTags(incident.ID, "test-user-1", tags)
+		err := incidentService.AddTags(ctx, incident.ID, "test-user-1", tags)
 		if err != nil {
 			t.Fatalf("Failed to add tags: %v", err)
 		}
 
 		// Test retrieving tags
-		retrievedTags, err := incidentService.GetTags(incident.ID)
+		retrievedTags, err := incidentService.GetTags(ctx, incident.ID)
 		if err != nil {
 			t.Fatalf("Failed to get tags: %v", err)
 		}
@@ -90,13 +96,13 @@ func TestEnhancedIncidentFeatures(t *testing.T) {
 		}
 
 		// Test removing tags
-		err = incidentService.RemoveTags(incident.ID, "test-user-1", []string{"environment"})
+		err = incidentService.RemoveTags(ctx, incident.ID, "test-user-1", []string{"environment"})
 		if err != nil {
 			t.Fatalf("Failed to remove tag: %v", err)
 		}
 
 		// Verify tag removal
-		remainingTags, err := incidentService.GetTags(incident.ID)
+		remainingTags, err := incidentService.GetTags(ctx, incident.ID)
 		if err != nil {
 			t.Fatalf("Failed to get remaining tags: %v", err)
 		}
@@ -119,13 +125,13 @@ func TestEnhancedIncidentFeatures(t *testing.T) {
 			},
 		}
 
-		err := incidentService.CreateTemplate(template)
+		err := incidentService.CreateTemplate(ctx, template)
 		if err != nil {
 			t.Fatalf("Failed to create template: %v", err)
 		}
 
 		// Test listing templates
-		templates, err := incidentService.ListTemplates()
+		templates, err := incidentService.ListTemplates(ctx)
 		if err != nil {
 			t.Fatalf("Failed to list templates: %v", err)
 		}
@@ -143,7 +149,7 @@ func TestEnhancedIncidentFeatures(t *testing.T) {
 			},
 		}
 
-		newIncident, err := incidentService.UseTemplate(req, "test-user-1")
+		newIncident, err := incidentService.UseTemplate(ctx, req, "test-user-1")
 		if err != nil {
 			t.Fatalf("Failed to use template: %v", err)
 		}
@@ -164,7 +170,7 @@ func TestEnhancedIncidentFeatures(t *testing.T) {
 			Limit:    10,
 		}
 
-		searchResp, err := incidentService.SearchIncidents(searchReq)
+		searchResp, err := incidentService.SearchIncidents(ctx, searchReq)
 		if err != nil {
 			t.Fatalf("Failed to search incidents: %v", err)
 		}
@@ -176,7 +182,7 @@ func TestEnhancedIncidentFeatures(t *testing.T) {
 
 	t.Run("BulkOperations", func(t *testing.T) {
 		// Create another incident for bulk operations
-		incident2, err := incidentService.CreateIncident(
+		incident2, err := incidentService.CreateIncident(ctx,
 			"Second Test Incident",
 			"Second Test Description",
 			models.SeverityMedium,
@@ -187,7 +193,7 @@ func TestEnhancedIncidentFeatures(t *testing.T) {
 		}
 
 		// Test bulk acknowledge
-		response, err := incidentService.BulkAcknowledge(
+		response, err := incidentService.BulkAcknowledge(ctx,
 			[]string{incident.ID, incident2.ID},
 			"test-assignee",
 			"test-user-1",
@@ -201,7 +207,7 @@ func TestEnhancedIncidentFeatures(t *testing.T) {
 		}
 
 		// Test bulk status update
-		response, err = incidentService.BulkUpdateStatus(
+		response, err = incidentService.BulkUpdateStatus(ctx,
 			[]string{incident.ID, incident2.ID},
 			models.IncidentStatusResolved,
 			"test-user-1",
@@ -217,7 +223,7 @@ func TestEnhancedIncidentFeatures(t *testing.T) {
 
 	t.Run("Assignment", func(t *testing.T) {
 		// Create another incident for assignment testing
-		incident3, err := incidentService.CreateIncident(
+		incident3, err := incidentService.CreateIncident(ctx,
 			"Assignment Test Incident",
 			"Assignment Test Description",
 			models.SeverityLow,
@@ -228,13 +234,13 @@ func TestEnhancedIncidentFeatures(t *testing.T) {
 		}
 
 		// Test assignment
-		err = incidentService.AssignIncident(incident3.ID, "test-assignee", "test-user-1")
+		err = incidentService.AssignIncident(ctx, incident3.ID, "test-assignee", "test-user-1")
 		if err != nil {
 			t.Fatalf("Failed to assign incident: %v", err)
 		}
 
 		// Verify assignment
-		assignedIncident, err := store.GetIncident(incident3.ID)
+		assignedIncident, err := store.GetIncident(ctx, incident3.ID)
 		if err != nil {
 			t.Fatalf("Failed to get assigned incident: %v", err)
 		}
@@ -244,13 +250,13 @@ func TestEnhancedIncidentFeatures(t *testing.T) {
 		}
 
 		// Test reassignment
-		err = incidentService.ReassignIncident(incident3.ID, "new-assignee", "test-user-1")
+		err = incidentService.ReassignIncident(ctx, incident3.ID, "new-assignee", "test-user-1")
 		if err != nil {
 			t.Fatalf("Failed to reassign incident: %v", err)
 		}
 
 		// Verify reassignment
-		reassignedIncident, err := store.GetIncident(incident3.ID)
+		reassignedIncident, err := store.GetIncident(ctx, incident3.ID)
 		if err != nil {
 			t.Fatalf("Failed to get reassigned incident: %v", err)
 		}
@@ -262,6 +268,7 @@ func TestEnhancedIncidentFeatures(t *testing.T) {
 }
 
 func TestMemoryStoreEnhancedFeatures(t *testing.T) {
+	ctx := context.Background()
 	store, err := storage.NewMemoryStore()
 	if err != nil {
 		t.Fatalf("Failed to create memory store: %v", err)
@@ -280,7 +287,7 @@ func TestMemoryStoreEnhancedFeatures(t *testing.T) {
 		Labels:      make(map[string]string),
 	}
 
-	err = store.CreateIncident(incident)
+	err = store.CreateIncident(ctx, incident)
 	if err != nil {
 		t.Fatalf("Failed to create test incident: %v", err)
 	}
@@ -294,12 +301,12 @@ func TestMemoryStoreEnhancedFeatures(t *testing.T) {
 			CreatedAt:   time.Now(),
 		}
 
-		err := store.CreateIncidentComment(comment)
+		err := store.CreateIncidentComment(ctx, comment)
 		if err != nil {
 			t.Fatalf("Failed to create comment: %v", err)
 		}
 
-		comments, err := store.GetIncidentComments(incident.ID)
+		comments, err := store.GetIncidentComments(ctx, incident.ID)
 		if err != nil {
 			t.Fatalf("Failed to get comments: %v", err)
 		}
@@ -319,12 +326,12 @@ func TestMemoryStoreEnhancedFeatures(t *testing.T) {
 			CreatedAt:  time.Now(),
 		}
 
-		err := store.CreateIncidentTag(tag)
+		err := store.CreateIncidentTag(ctx, tag)
 		if err != nil {
 			t.Fatalf("Failed to create tag: %v", err)
 		}
 
-		tags, err := store.GetIncidentTags(incident.ID)
+		tags, err := store.GetIncidentTags(ctx, incident.ID)
 		if err != nil {
 			t.Fatalf("Failed to get tags: %v", err)
 		}
@@ -333,12 +340,12 @@ func TestMemoryStoreEnhancedFeatures(t *testing.T) {
 			t.Errorf("Expected 1 tag, got %d", len(tags))
 		}
 
-		err = store.DeleteIncidentTag(incident.ID, "test")
+		err = store.DeleteIncidentTag(ctx, incident.ID, "test")
 		if err != nil {
 			t.Fatalf("Failed to delete tag: %v", err)
 		}
 
-		remainingTags, err := store.GetIncidentTags(incident.ID)
+		remainingTags, err := store.GetIncidentTags(ctx, incident.ID)
 		if err != nil {
 			t.Fatalf("Failed to get remaining tags: %v", err)
 		}
@@ -360,12 +367,12 @@ func TestMemoryStoreEnhancedFeatures(t *testing.T) {
 			UpdatedAt:           time.Now(),
 		}
 
-		err := store.CreateIncidentTemplate(template)
+		err := store.CreateIncidentTemplate(ctx, template)
 		if err != nil {
 			t.Fatalf("Failed to create template: %v", err)
 		}
 
-		retrievedTemplate, err := store.GetIncidentTemplate(template.ID)
+		retrievedTemplate, err := store.GetIncidentTemplate(ctx, template.ID)
 		if err != nil {
 			t.Fatalf("Failed to get template: %v", err)
 		}
@@ -374,7 +381,7 @@ func TestMemoryStoreEnhancedFeatures(t *testing.T) {
 			t.Errorf("Expected name '%s', got '%s'", template.Name, retrievedTemplate.Name)
 		}
 
-		templates, err := store.ListIncidentTemplates(true)
+		templates, err := store.ListIncidentTemplates(ctx, true)
 		if err != nil {
 			t.Fatalf("Failed to list templates: %v", err)
 		}
@@ -385,7 +392,622 @@ func TestMemoryStoreEnhancedFeatures(t *testing.T) {
 	})
 }
 
+func TestBulkOperations_MixedBatch(t *testing.T) {
+	ctx := context.Background()
+	store, err := storage.NewMemoryStore()
+	if err != nil {
+		t.Fatalf("Failed to create memory store: %v", err)
+	}
+	defer store.Close()
+	incidentService := NewIncidentService(store, NewMetricsService(prometheus.NewRegistry()))
+
+	var ids []string
+	for _, title := range []string{"Disk full", "Queue backlog"} {
+		incident, err := incidentService.CreateIncident(ctx, title, "", models.SeverityHigh, nil)
+		if err != nil {
+			t.Fatalf("Failed to create incident: %v", err)
+		}
+		ids = append(ids, incident.ID)
+	}
+	batch := []string{ids[0], "missing-1", ids[1], "missing-2"}
+
+	assertMixed := func(t *testing.T, response *models.BulkOperationResponse, err error) {
+		t.Helper()
+		if err != nil {
+			t.Fatalf("Bulk operation failed as a whole: %v", err)
+		}
+		if response.ProcessedCount != 2 || response.FailedCount != 2 || len(response.Failures) != 2 {
+			t.Fatalf("Expected 2 processed and 2 failed, got %+v", response)
+		}
+		if response.Failures[0].IncidentID != "missing-1" || response.Failures[1].IncidentID != "missing-2" {
+			t.Errorf("Expected failures for the missing incidents, got %+v", response.Failures)
+		}
+		for _, failure := range response.Failures {
+			if failure.Error == "" {
+				t.Errorf("Expected an error message for %s", failure.IncidentID)
+			}
+		}
+	}
+
+	t.Run("AddTags", func(t *testing.T) {
+		response, err := incidentService.BulkAddTags(ctx, batch, []models.TemplateTag{{Name: "team", Value: "storage"}}, "user-1")
+		assertMixed(t, response, err)
+		for _, id := range ids {
+			if tags, _ := incidentService.GetTags(ctx, id); len(tags) != 1 || tags[0].TagName != "team" {
+				t.Errorf("Expected incident %s to be tagged, got %+v", id, tags)
+			}
+		}
+	})
+
+	t.Run("RemoveTags", func(t *testing.T) {
+		response, err := incidentService.BulkRemoveTags(ctx, batch, []string{"team"}, "user-1")
+		assertMixed(t, response, err)
+		if !strings.Contains(response.Failures[0].Error, "incident not found") {
+			t.Errorf("Expected a missing incident rather than a missing tag, got %q", response.Failures[0].Error)
+		}
+		for _, id := range ids {
+			if tags, _ := incidentService.GetTags(ctx, id); len(tags) != 0 {
+				t.Errorf("Expected incident %s to be untagged, got %+v", id, tags)
+			}
+		}
+	})
+
+	t.Run("Assign", func(t *testing.T) {
+		response, err := incidentService.BulkAssign(ctx, batch, "user-2", "user-1")
+		assertMixed(t, response, err)
+		for _, id := range ids {
+			if incident, _ := store.GetIncident(ctx, id); incident.AssigneeID != "user-2" {
+				t.Errorf("Expected incident %s to be assigned to user-2, got %q", id, incident.AssigneeID)
+			}
+		}
+	})
+
+	t.Run("Resolve", func(t *testing.T) {
+		response, err := incidentService.BulkResolve(ctx, batch, "user-1", models.Resolution{Note: "Cleaned up old snapshots", Type: models.ResolutionFixed})
+		assertMixed(t, response, err)
+		for _, id := range ids {
+			incident, _ := store.GetIncident(ctx, id)
+			if incident.Status != models.IncidentStatusResolved || incident.ResolvedAt == nil {
+				t.Errorf("Expected incident %s to be resolved, got %s", id, incident.Status)
+			}
+		}
+	})
+}
+
+func TestAssignIncident_RoleRestrictions(t *testing.T) {
+	ctx := context.Background()
+	store, err := storage.NewMemoryStore()
+	if err != nil {
+		t.Fatalf("Failed to create memory store: %v", err)
+	}
+	defer store.Close()
+
+	incidentService := NewIncidentService(store, NewMetricsService(prometheus.NewRegistry()))
+	incidentService.SetAssignableRoles([]string{"admin", "responder"})
+
+	for _, roleName := range []string{"responder", "viewer"} {
+		role := &models.Role{ID: roleName + "-role-id", Name: roleName}
+		if err := store.CreateRole(ctx, role); err != nil {
+			t.Fatalf("Failed to create role: %v", err)
+		}
+		user := &models.User{ID: roleName + "-user", Username: roleName, Email: roleName + "@example.com", IsActive: true}
+		if err := store.CreateUser(ctx, user); err != nil {
+			t.Fatalf("Failed to create user: %v", err)
+		}
+		if err := store.AssignRoleToUser(ctx, user.ID, role.ID); err != nil {
+			t.Fatalf("Failed to assign role: %v", err)
+		}
+	}
+
+	incident, err := incidentService.CreateIncident(ctx, "Restricted Assignment", "", models.SeverityHigh, []string{})
+	if err != nil {
+		t.Fatalf("Failed to create incident: %v", err)
+	}
+
+	if err := incidentService.AssignIncident(ctx, incident.ID, "responder-user", "test-user-1"); err != nil {
+		t.Errorf("Expected assignment to responder to succeed, got %v", err)
+	}
+
+	err = incidentService.AssignIncident(ctx, incident.ID, "viewer-user", "test-user-1")
+	if !errors.Is(err, ErrAssigneeNotAssignable) {
+		t.Errorf("Expected ErrAssigneeNotAssignable for viewer, got %v", err)
+	}
+
+	err = incidentService.AssignIncident(ctx, incident.ID, "missing-user", "test-user-1")
+	if !errors.Is(err, ErrAssigneeNotFound) {
+		t.Errorf("Expected ErrAssigneeNotFound for unknown user, got %v", err)
+	}
+
+	updated, err := incidentService.GetIncident(ctx, incident.ID)
+	if err != nil {
+		t.Fatalf("Failed to get incident: %v", err)
+	}
+	if updated.AssigneeID != "responder-user" {
+		t.Errorf("Expected assignee to remain 'responder-user', got '%s'", updated.AssigneeID)
+	}
+}
+
+func TestAcknowledgeIncident_RoleRestrictions(t *testing.T) {
+	ctx := context.Background()
+	store, err := storage.NewMemoryStore()
+	if err != nil {
+		t.Fatalf("Failed to create memory store: %v", err)
+	}
+	defer store.Close()
+
+	incidentService := NewIncidentService(store, NewMetricsService(prometheus.NewRegistry()))
+	incidentService.SetAssignableRoles([]string{"responder"})
+
+	for _, roleName := range []string{"responder", "viewer"} {
+		role := &models.Role{ID: roleName + "-role-id", Name: roleName}
+		if err := store.CreateRole(ctx, role); err != nil {
+			t.Fatalf("Failed to create role: %v", err)
+		}
+		user := &models.User{ID: roleName + "-user", Username: roleName, Email: roleName + "@example.com", IsActive: true}
+		if err := store.CreateUser(ctx, user); err != nil {
+			t.Fatalf("Failed to create user: %v", err)
+		}
+		if err := store.AssignRoleToUser(ctx, user.ID, role.ID); err != nil {
+			t.Fatalf("Failed to assign role: %v", err)
+		}
+	}
+
+	incident, err := incidentService.CreateIncident(ctx, "Restricted Acknowledgement", "", models.SeverityHigh, []string{})
+	if err != nil {
+		t.Fatalf("Failed to create incident: %v", err)
+	}
+
+	err = incidentService.AcknowledgeIncident(ctx, incident.ID, "viewer-user")
+	if !errors.Is(err, ErrAssigneeNotAssignable) {
+		t.Errorf("Expected ErrAssigneeNotAssignable acknowledging for a viewer, got %v", err)
+	}
+	unchanged, err := incidentService.GetIncident(ctx, incident.ID)
+	if err != nil {
+		t.Fatalf("Failed to get incident: %v", err)
+	}
+	if unchanged.Status != models.IncidentStatusOpen || unchanged.AssigneeID != "" {
+		t.Errorf("Expected the incident to stay open and unassigned, got %s/%q", unchanged.Status, unchanged.AssigneeID)
+	}
+
+	if err := incidentService.AcknowledgeIncident(ctx, incident.ID, "responder-user"); err != nil {
+		t.Errorf("Expected acknowledging for a responder to succeed, got %v", err)
+	}
+}
+
+func TestNeedsAttention(t *testing.T) {
+	ctx := context.Background()
+	store, err := storage.NewMemoryStore()
+	if err != nil {
+		t.Fatalf("Failed to create memory store: %v", err)
+	}
+	defer store.Close()
+
+	incidentService := NewIncidentService(store, NewMetricsService(prometheus.NewRegistry()))
+	incidentService.SetNeedsAttentionThreshold(10 * time.Minute)
+
+	createIncident := func(title string, age time.Duration, assigneeID string) *models.Incident {
+		incident, err := incidentService.CreateIncident(ctx, title, "", models.SeverityHigh, []string{})
+		if err != nil {
+			t.Fatalf("Failed to create incident: %v", err)
+		}
+		incident.CreatedAt = time.Now().Add(-age)
+		incident.AssigneeID = assigneeID
+		if err := store.UpdateIncident(ctx, incident); err != nil {
+			t.Fatalf("Failed to update incident: %v", err)
+		}
+		return incident
+	}
+
+	aging := createIncident("Aging unassigned", time.Hour, "")
+	createIncident("Aging assigned", time.Hour, "engineer-1")
+	createIncident("Fresh unassigned", time.Minute, "")
+
+	incidents, err := incidentService.ListNeedsAttention(ctx)
+	if err != nil {
+		t.Fatalf("Failed to list incidents needing attention: %v", err)
+	}
+	if len(incidents) != 1 || incidents[0].ID != aging.ID {
+		t.Fatalf("Expected only the aging unassigned incident, got %d incidents", len(incidents))
+	}
+	if !incidents[0].NeedsAttention {
+		t.Error("Expected NeedsAttention flag to be set")
+	}
+
+	needsAttention := true
+	searchResp, err := incidentService.SearchIncidents(ctx, &models.IncidentSearchRequest{
+		NeedsAttention: &needsAttention,
+		Page:           1,
+		Limit:          10,
+	})
+	if err != nil {
+		t.Fatalf("Failed to search incidents: %v", err)
+	}
+	if searchResp.Total != 1 || searchResp.Incidents[0].ID != aging.ID {
+		t.Errorf("Expected search to return only the aging unassigned incident, got %d", searchResp.Total)
+	}
+}
+
+func TestGetKeyEvents(t *testing.T) {
+	ctx := context.Background()
+	store, err := storage.NewMemoryStore()
+	if err != nil {
+		t.Fatalf("Failed to create memory store: %v", err)
+	}
+	defer store.Close()
+
+	incidentService := NewIncidentService(store, NewMetricsService(prometheus.NewRegistry()))
+
+	start := time.Date(2024, time.March, 15, 9, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) time.Time { return start.Add(d) }
+	ackedAt, resolvedAt := at(5*time.Minute), at(45*time.Minute)
+
+	incident := &models.Incident{
+		ID:         "inc-key-events",
+		Title:      "Checkout outage",
+		Status:     models.IncidentStatusResolved,
+		Severity:   models.SeverityCritical,
+		CreatedAt:  start,
+		AckedAt:    &ackedAt,
+		ResolvedAt: &resolvedAt,
+		AssigneeID: "engineer-1",
+	}
+	if err := store.CreateIncident(ctx, incident); err != nil {
+		t.Fatalf("Failed to create incident: %v", err)
+	}
+
+	entries := []*models.IncidentComment{
+		{ID: "c1", Content: "Looking into it", CommentType: models.CommentTypeComment, CreatedAt: at(2 * time.Minute)},
+		{ID: "c2", Content: "Still looking", CommentType: models.CommentTypeComment, CreatedAt: at(3 * time.Minute)},
+		{ID: "c3", Content: "Incident assigned to user engineer-1", CommentType: models.CommentTypeAssignment, CreatedAt: at(4 * time.Minute)},
+		{ID: "c4", Content: "Tagged", CommentType: models.CommentTypeTagAdded, CreatedAt: at(10 * time.Minute)},
+		{ID: "c5", Content: "Severity downgraded from critical to high", CommentType: models.CommentTypeSeverityChange, CreatedAt: at(20 * time.Minute)},
+		{ID: "c6", Content: "Root cause: bad deploy", CommentType: models.CommentTypeComment, Metadata: map[string]interface{}{"key_event": true}, CreatedAt: at(30 * time.Minute)},
+		{ID: "c7", Content: "Status changed from acknowledged to resolved", CommentType: models.CommentTypeStatusChange, Metadata: map[string]interface{}{"new_status": models.IncidentStatusResolved}, CreatedAt: resolvedAt},
+	}
+	for _, entry := range entries {
+		entry.IncidentID = incident.ID
+		if err := store.CreateIncidentComment(ctx, entry); err != nil {
+			t.Fatalf("Failed to create timeline entry: %v", err)
+		}
+	}
+
+	events, err := incidentService.GetKeyEvents(ctx, incident.ID)
+	if err != nil {
+		t.Fatalf("Failed to get key events: %v", err)
+	}
+
+	expected := []struct {
+		eventType     models.KeyEventType
+		sincePrevious int64
+	}{
+		{models.KeyEventCreated, 0},
+		{models.KeyEventComment, 120},
+		{models.KeyEventAssigned, 120},
+		{models.KeyEventAcknowledged, 60},
+		{models.KeyEventSeverityChanged, 900},
+		{models.KeyEventComment, 600},
+		{models.KeyEventResolved, 900},
+	}
+	if len(events) != len(expected) {
+		t.Fatalf("Expected %d key events, got %d: %+v", len(expected), len(events), events)
+	}
+	for i, want := range expected {
+		if events[i].Type != want.eventType || events[i].SincePrevious != want.sincePrevious {
+			t.Errorf("Event %d: expected %s after %ds, got %s after %ds", i, want.eventType, want.sincePrevious, events[i].Type, events[i].SincePrevious)
+		}
+	}
+	if last := events[len(events)-1]; last.SinceCreated != 2700 {
+		t.Errorf("Expected resolution 2700s after creation, got %d", last.SinceCreated)
+	}
+
+	if _, err := incidentService.GetKeyEvents(ctx, "missing"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for unknown incident, got %v", err)
+	}
+}
+
 // Helper function to create string pointer
 func strPtr(s string) *string {
 	return &s
-}
\ No newline at end of file
+}
+func TestResolveIncident_RequiresNote(t *testing.T) {
+	ctx := context.Background()
+	store, err := storage.NewMemoryStore()
+	if err != nil {
+		t.Fatalf("Failed to create memory store: %v", err)
+	}
+	defer store.Close()
+
+	incidentService := NewIncidentService(store, NewMetricsService(prometheus.NewRegistry()))
+	incidentService.SetRequireResolutionNote(true)
+
+	incident, err := incidentService.CreateIncident(ctx, "Checkout down", "", models.SeverityHigh, []string{})
+	if err != nil {
+		t.Fatalf("Failed to create incident: %v", err)
+	}
+
+	for _, note := range []string{"", "   "} {
+		if err := incidentService.ResolveIncident(ctx, incident.ID, "user-1", note); !errors.Is(err, ErrResolutionNoteRequired) {
+			t.Errorf("Expected ErrResolutionNoteRequired for note %q, got %v", note, err)
+		}
+	}
+	response, err := incidentService.BulkUpdateStatus(ctx, []string{incident.ID}, models.IncidentStatusResolved, "user-1")
+	if err != nil || response.FailedCount != 1 {
+		t.Errorf("Expected bulk resolution to be rejected, got %+v (err: %v)", response, err)
+	}
+	if unresolved, _ := incidentService.GetIncident(ctx, incident.ID); unresolved.Status != models.IncidentStatusOpen {
+		t.Fatalf("Expected incident to stay open, got %s", unresolved.Status)
+	}
+
+	if err := incidentService.ResolveIncident(ctx, incident.ID, "user-1", "Rolled back the 14:02 deploy"); err != nil {
+		t.Fatalf("Expected resolution with a note to succeed, got %v", err)
+	}
+	resolved, err := incidentService.GetIncident(ctx, incident.ID)
+	if err != nil {
+		t.Fatalf("Failed to get incident: %v", err)
+	}
+	if resolved.Status != models.IncidentStatusResolved || resolved.ResolvedAt == nil {
+		t.Errorf("Expected incident to be resolved, got %s", resolved.Status)
+	}
+
+	timeline, err := incidentService.GetTimeline(ctx, incident.ID)
+	if err != nil {
+		t.Fatalf("Failed to get timeline: %v", err)
+	}
+	if len(timeline) != 1 || timeline[0].CommentType != models.CommentTypeStatusChange {
+		t.Fatalf("Expected a single status change entry, got %+v", timeline)
+	}
+	if timeline[0].Metadata["resolution_note"] != "Rolled back the 14:02 deploy" {
+		t.Errorf("Expected the note in the timeline metadata, got %v", timeline[0].Metadata)
+	}
+}
+
+func TestCreateIncident_DefaultLabels(t *testing.T) {
+	ctx := context.Background()
+	store, err := storage.NewMemoryStore()
+	if err != nil {
+		t.Fatalf("Failed to create memory store: %v", err)
+	}
+	defer store.Close()
+
+	incidentService := NewIncidentService(store, NewMetricsService(prometheus.NewRegistry()))
+	defaults, err := ParseDefaultLabels([]string{"environment=prod", "cluster = eu-west-1"})
+	if err != nil {
+		t.Fatalf("Failed to parse default labels: %v", err)
+	}
+	incidentService.SetDefaultLabels(defaults)
+
+	incident, err := incidentService.CreateIncident(ctx, "Checkout down", "", models.SeverityHigh, []string{})
+	if err != nil {
+		t.Fatalf("Failed to create incident: %v", err)
+	}
+	stored, err := store.GetIncident(ctx, incident.ID)
+	if err != nil {
+		t.Fatalf("Failed to get incident: %v", err)
+	}
+	if stored.Labels["environment"] != "prod" || stored.Labels["cluster"] != "eu-west-1" {
+		t.Errorf("Expected the default labels on the incident, got %v", stored.Labels)
+	}
+
+	explicit, err := incidentService.CreateIncidentWithLabels(ctx, "Search slow", "", models.SeverityLow, []string{},
+		map[string]string{"environment": "staging", "team": "search"})
+	if err != nil {
+		t.Fatalf("Failed to create incident: %v", err)
+	}
+	want := map[string]string{"environment": "staging", "cluster": "eu-west-1", "team": "search"}
+	for key, value := range want {
+		if explicit.Labels[key] != value {
+			t.Errorf("Expected label %s=%s, got %v", key, value, explicit.Labels)
+		}
+	}
+	if defaults["environment"] != "prod" {
+		t.Error("Expected the configured defaults to be left unchanged")
+	}
+
+	// Incidents opened from alerts carry the defaults too
+	alertService := NewAlertService(store, incidentService, NewMetricsService(prometheus.NewRegistry()))
+	webhook := &AlertmanagerWebhook{Alerts: []AlertmanagerAlert{testAlert("fp-default-labels", "firing", "critical")}}
+	if err := alertService.ProcessAlertmanagerWebhook(ctx, webhook); err != nil {
+		t.Fatalf("Failed to process webhook: %v", err)
+	}
+	incidents, err := store.ListIncidents(ctx)
+	if err != nil {
+		t.Fatalf("Failed to list incidents: %v", err)
+	}
+	for _, inc := range incidents {
+		if inc.Labels["environment"] == "" || inc.Labels["cluster"] != "eu-west-1" {
+			t.Errorf("Expected incident %q to carry the default labels, got %v", inc.Title, inc.Labels)
+		}
+	}
+	if len(incidents) != 3 {
+		t.Errorf("Expected 3 incidents, got %d", len(incidents))
+	}
+
+	if _, err := ParseDefaultLabels([]string{"environment"}); err == nil {
+		t.Error("Expected a label without a value separator to be rejected")
+	}
+}
+
+func TestCreateIncident_Priority(t *testing.T) {
+	ctx := context.Background()
+	store, err := storage.NewMemoryStore()
+	if err != nil {
+		t.Fatalf("Failed to create memory store: %v", err)
+	}
+	defer store.Close()
+	incidentService := NewIncidentService(store, NewMetricsService(prometheus.NewRegistry()))
+
+	// Without a priority, severity decides
+	for severity, want := range map[models.IncidentSeverity]models.IncidentPriority{
+		models.SeverityCritical: models.PriorityP1,
+		models.SeverityHigh:     models.PriorityP2,
+		models.SeverityMedium:   models.PriorityP3,
+		models.SeverityLow:      models.PriorityP4,
+	} {
+		incident, err := incidentService.CreateIncident(ctx, "Checkout down", "", severity, []string{})
+		if err != nil {
+			t.Fatalf("Failed to create incident: %v", err)
+		}
+		if incident.Priority != want {
+			t.Errorf("Expected %s incidents to default to %s, got %s", severity, want, incident.Priority)
+		}
+	}
+
+	// A chosen priority wins over the severity
+	incident, err := incidentService.CreateIncidentWithPriority(ctx, "Typo on pricing page", "", models.SeverityLow, models.PriorityP1, []string{}, nil)
+	if err != nil {
+		t.Fatalf("Failed to create incident: %v", err)
+	}
+	if incident.Priority != models.PriorityP1 || incident.Severity != models.SeverityLow {
+		t.Errorf("Expected a low severity P1 incident, got %s %s", incident.Severity, incident.Priority)
+	}
+	if _, err := incidentService.CreateIncidentWithPriority(ctx, "Checkout down", "", models.SeverityLow, "P0", []string{}, nil); !errors.Is(err, ErrInvalidPriority) {
+		t.Errorf("Expected ErrInvalidPriority, got %v", err)
+	}
+
+	if err := incidentService.SetPriority(ctx, incident.ID, "user-1", models.PriorityP3); err != nil {
+		t.Fatalf("SetPriority failed: %v", err)
+	}
+	stored, _ := store.GetIncident(ctx, incident.ID)
+	if stored.Priority != models.PriorityP3 {
+		t.Errorf("Expected priority P3, got %s", stored.Priority)
+	}
+	timeline, _ := incidentService.GetTimeline(ctx, incident.ID)
+	if len(timeline) != 1 || timeline[0].CommentType != models.CommentTypePriorityChange {
+		t.Errorf("Expected one priority_change timeline entry, got %+v", timeline)
+	}
+
+	// Templates can set a priority of their own
+	template := &models.IncidentTemplate{Name: "Pricing", TitleTemplate: "Pricing issue", Severity: models.SeverityLow, Priority: models.PriorityP2}
+	if err := incidentService.CreateTemplate(ctx, template); err != nil {
+		t.Fatalf("Failed to create template: %v", err)
+	}
+	fromTemplate, err := incidentService.UseTemplate(ctx, &models.CreateIncidentFromTemplateRequest{TemplateID: template.ID}, "user-1")
+	if err != nil {
+		t.Fatalf("Failed to use template: %v", err)
+	}
+	if fromTemplate.Priority != models.PriorityP2 {
+		t.Errorf("Expected the template's priority P2, got %s", fromTemplate.Priority)
+	}
+}
+
+func TestValidateTemplateVariables(t *testing.T) {
+	ctx := context.Background()
+	template := &models.IncidentTemplate{
+		TitleTemplate:       "{{service}} is down in {{region}}",
+		DescriptionTemplate: "{{service}} has returned errors since {{started_at}}",
+	}
+
+	tests := []struct {
+		name      string
+		variables map[string]string
+		missing   string
+	}{
+		{"complete", map[string]string{"service": "checkout", "region": "eu-west", "started_at": "09:00"}, ""},
+		{"extra", map[string]string{"service": "checkout", "region": "eu-west", "started_at": "09:00", "owner": "payments"}, ""},
+		{"missing", map[string]string{"region": "eu-west"}, "service, started_at"},
+		{"none", nil, "service, region, started_at"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateTemplateVariables(template, tt.variables)
+			if tt.missing == "" {
+				if err != nil {
+					t.Errorf("Expected no error, got %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrMissingTemplateVars) || !strings.HasSuffix(err.Error(), ": "+tt.missing) {
+				t.Errorf("Expected missing variables %q, got %v", tt.missing, err)
+			}
+		})
+	}
+
+	store, err := storage.NewMemoryStore()
+	if err != nil {
+		t.Fatalf("Failed to create memory store: %v", err)
+	}
+	incidentService := NewIncidentService(store, NewMetricsService(prometheus.NewRegistry()))
+	if err := incidentService.CreateTemplate(ctx, template); err != nil {
+		t.Fatalf("Failed to create template: %v", err)
+	}
+	req := &models.CreateIncidentFromTemplateRequest{TemplateID: template.ID, Variables: map[string]string{"service": "checkout"}}
+	if _, err := incidentService.UseTemplate(ctx, req, "user-1"); !errors.Is(err, ErrMissingTemplateVars) {
+		t.Errorf("Expected UseTemplate to reject missing variables, got %v", err)
+	}
+	if incidents, _ := store.ListIncidents(ctx); len(incidents) != 0 {
+		t.Errorf("Expected no incident to be created, got %d", len(incidents))
+	}
+}
+
+// rollbackStore stands in for a transactional store: WithTx hands out a
+// failingTagTx and deletes the incidents it created if the callback fails
+type rollbackStore struct {
+	storage.Store
+}
+
+func (s *rollbackStore) WithTx(ctx context.Context, fn func(tx storage.Store) error) error {
+	tx := &failingTagTx{Store: s.Store}
+	if err := fn(tx); err != nil {
+		for _, id := range tx.created {
+			s.Store.DeleteIncident(ctx, id)
+		}
+		return err
+	}
+	return nil
+}
+
+// failingTagTx records the incidents created through it and fails every
+// tag insert
+type failingTagTx struct {
+	storage.Store
+	created []string
+}
+
+func (tx *failingTagTx) CreateIncident(ctx context.Context, incident *models.Incident) error {
+	if err := tx.Store.CreateIncident(ctx, incident); err != nil {
+		return err
+	}
+	tx.created = append(tx.created, incident.ID)
+	return nil
+}
+
+func (tx *failingTagTx) CreateIncidentTag(ctx context.Context, tag *models.IncidentTag) error {
+	return errors.New("tag insert failed")
+}
+
+func TestUseTemplate_RollsBackWhenTagsFail(t *testing.T) {
+	ctx := context.Background()
+	memoryStore, err := storage.NewMemoryStore()
+	if err != nil {
+		t.Fatalf("Failed to create memory store: %v", err)
+	}
+	store := &rollbackStore{Store: memoryStore}
+	incidentService := NewIncidentService(store, NewMetricsService(prometheus.NewRegistry()))
+
+	tagged := &models.IncidentTemplate{
+		Name: "Tagged", TitleTemplate: "Database down", Severity: models.SeverityHigh,
+		DefaultTags: []models.TemplateTag{{Name: "database", Value: "primary", Color: "#ff0000"}},
+	}
+	if err := incidentService.CreateTemplate(ctx, tagged); err != nil {
+		t.Fatalf("Failed to create template: %v", err)
+	}
+	_, err = incidentService.UseTemplate(ctx, &models.CreateIncidentFromTemplateRequest{TemplateID: tagged.ID}, "user-1")
+	if err == nil || !strings.Contains(err.Error(), "tag insert failed") {
+		t.Fatalf("Expected the tag failure to fail the creation, got %v", err)
+	}
+	if incidents, _ := store.ListIncidents(ctx); len(incidents) != 0 {
+		t.Errorf("Expected the incident to be rolled back, got %d incidents", len(incidents))
+	}
+
+	untagged := &models.IncidentTemplate{Name: "Untagged", TitleTemplate: "Cache cold", Severity: models.SeverityLow}
+	if err := incidentService.CreateTemplate(ctx, untagged); err != nil {
+		t.Fatalf("Failed to create template: %v", err)
+	}
+	incident, err := incidentService.UseTemplate(ctx, &models.CreateIncidentFromTemplateRequest{TemplateID: untagged.ID}, "user-1")
+	if err != nil {
+		t.Fatalf("Failed to use template: %v", err)
+	}
+	if _, err := store.GetIncident(ctx, incident.ID); err != nil {
+		t.Errorf("Expected the incident to be committed, got %v", err)
+	}
+}
@@ -0,0 +1,295 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/models"
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/storage"
+)
+
+// EscalationPolicyLabel attaches an escalation policy to an incident
+const EscalationPolicyLabel = "escalation_policy"
+
+// EscalationLevelLabel records how many of the policy's rules, in order of
+// delay, have fired for an incident. Keeping it on the incident means a
+// restart does not page the same targets again.
+const EscalationLevelLabel = "escalation_level"
+
+// EscalationScheduleTargetPrefix marks an escalation target that resolves to
+// whoever is on call in a schedule, e.g. "schedule:primary"
+const EscalationScheduleTargetPrefix = "schedule:"
+
+// ErrEscalationPolicyNotFound is returned when attaching an unknown policy
+var ErrEscalationPolicyNotFound = errors.New("escalation policy not found")
+
+// EscalationService fires the rules of an incident's escalation policy while
+// the incident stays open and unacknowledged
+type EscalationService struct {
+	store           storage.Store
+	incidentService *IncidentService
+	onCallService   *OnCallService
+	logger          *Logger
+
+	// now and deliver are replaced in tests
+	now     func() time.Time
+	deliver func(channel *models.NotificationChannel, subject, content string) error
+
+	stopChan chan struct{}
+	stopOnce sync.Once
+}
+
+// NewEscalationService creates an escalation service that notifies targets
+// through the notification service's channels
+func NewEscalationService(store storage.Store, incidentService *IncidentService, notificationService *NotificationService, logger *Logger) *EscalationService {
+	return &EscalationService{
+		store:           store,
+		incidentService: incidentService,
+		onCallService:   NewOnCallService(store),
+		logger:          logger,
+		now:             time.Now,
+		deliver:         notificationService.sendRendered,
+		stopChan:        make(chan struct{}),
+	}
+}
+
+// Start evaluates escalation policies every 30 seconds until Stop is called
+func (s *EscalationService) Start() {
+	go func() {
+		ticker := time.NewTicker(30 * time.Second)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if _, err := s.EvaluateEscalations(context.Background()); err != nil {
+					s.logger.Error("Failed to evaluate escalation policies", map[string]interface{}{
+						"error": err.Error(),
+					})
+				}
+			case <-s.stopChan:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the escalation loop
+func (s *EscalationService) Stop() {
+	s.stopOnce.Do(func() { close(s.stopChan) })
+}
+
+// EvaluateEscalations fires every rule whose delay an open incident has
+// passed and returns how many rules fired. Acknowledged and resolved
+// incidents are not escalated further.
+func (s *EscalationService) EvaluateEscalations(ctx context.Context) (int, error) {
+	incidents, err := s.store.ListIncidents(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	now := s.now()
+	policies := make(map[string]*models.EscalationPolicy)
+	fired := 0
+	for _, incident := range incidents {
+		policyID := incident.Labels[EscalationPolicyLabel]
+		if incident.Status != models.IncidentStatusOpen || policyID == "" {
+			continue
+		}
+
+		policy, ok := policies[policyID]
+		if !ok {
+			if policy, err = s.store.GetEscalationPolicy(ctx, policyID); err != nil {
+				s.logger.Warn("Incident references an unknown escalation policy", map[string]interface{}{
+					"incident_id": incident.ID,
+					"policy_id":   policyID,
+				})
+				policy = nil
+			}
+			policies[policyID] = policy
+		}
+		if policy == nil {
+			continue
+		}
+
+		n, err := s.escalate(ctx, incident, policy, now)
+		fired += n
+		if err != nil {
+			s.logger.Error("Failed to escalate incident", map[string]interface{}{
+				"incident_id": incident.ID,
+				"policy_id":   policyID,
+				"error":       err.Error(),
+			})
+		}
+	}
+
+	return fired, nil
+}
+
+// escalate fires the rules the incident has become old enough for since the
+// last evaluation and returns how many fired
+func (s *EscalationService) escalate(ctx context.Context, incident *models.Incident, policy *models.EscalationPolicy, now time.Time) (int, error) {
+	rules := orderedRules(policy)
+	level, _ := strconv.Atoi(incident.Labels[EscalationLevelLabel])
+	age := now.Sub(incident.CreatedAt)
+
+	fired := 0
+	for level < len(rules) && age >= time.Duration(rules[level].DelayMinutes)*time.Minute {
+		// Re-read so an acknowledgement since the scan stops escalation and
+		// is not overwritten below
+		current, err := s.store.GetIncident(ctx, incident.ID)
+		if err != nil {
+			return fired, err
+		}
+		if current.Status != models.IncidentStatusOpen {
+			return fired, nil
+		}
+		incident = current
+
+		rule := rules[level]
+		level++
+
+		delivered := s.notifyTargets(ctx, incident, policy, rule, level, now)
+
+		// Record the level before the timeline entry so a failure below
+		// cannot cause the targets to be paged again
+		incident.Labels[EscalationLevelLabel] = strconv.Itoa(level)
+		if err := s.incidentService.UpdateIncident(ctx, incident); err != nil {
+			return fired, err
+		}
+		fired++
+
+		metadata := map[string]interface{}{
+			"policy_id":     policy.ID,
+			"level":         level,
+			"delay_minutes": rule.DelayMinutes,
+			"targets":       rule.Targets,
+			"delivered":     delivered,
+		}
+		if _, err := s.incidentService.AddComment(ctx, incident.ID, "system",
+			fmt.Sprintf("Not acknowledged after %d minutes; escalated to level %d of %s", rule.DelayMinutes, level, policy.Name),
+			models.CommentTypeEscalation, metadata); err != nil {
+			return fired, err
+		}
+	}
+
+	return fired, nil
+}
+
+// notifyTargets pages every target of a rule and returns the number of
+// successful deliveries. A target is a notification channel ID, a user ID, in
+// which case all of the user's enabled channels are used, or
+// "schedule:<id>" for whoever is currently on call in that schedule.
+func (s *EscalationService) notifyTargets(ctx context.Context, incident *models.Incident, policy *models.EscalationPolicy, rule models.EscalationRule, level int, now time.Time) int {
+	subject := fmt.Sprintf("[%s] Escalated incident: %s", incident.Severity, incident.Title)
+	content := fmt.Sprintf("Incident %s has not been acknowledged after %d minutes and was escalated to you (%s, level %d).",
+		incident.ID, rule.DelayMinutes, policy.Name, level)
+
+	channels, err := s.store.ListNotificationChannels(ctx)
+	if err != nil {
+		s.logger.Error("Failed to list notification channels for escalation", map[string]interface{}{
+			"incident_id": incident.ID,
+			"error":       err.Error(),
+		})
+		return 0
+	}
+
+	delivered := 0
+	for _, target := range s.resolveTargets(ctx, incident, rule.Targets, now) {
+		found := false
+		for _, channel := range channels {
+			if !channel.Enabled || (channel.ID != target && channel.UserID != target) {
+				continue
+			}
+			found = true
+			if err := s.deliver(channel, subject, content); err != nil {
+				s.logger.Error("Failed to notify escalation target", map[string]interface{}{
+					"incident_id": incident.ID,
+					"target":      target,
+					"channel_id":  channel.ID,
+					"error":       err.Error(),
+				})
+				continue
+			}
+			delivered++
+		}
+		if !found {
+			s.logger.Warn("Escalation target has no enabled notification channel", map[string]interface{}{
+				"incident_id": incident.ID,
+				"target":      target,
+			})
+		}
+	}
+	return delivered
+}
+
+// resolveTargets replaces on-call schedule targets with the users currently
+// on call in them
+func (s *EscalationService) resolveTargets(ctx context.Context, incident *models.Incident, targets []string, now time.Time) []string {
+	var resolved []string
+	for _, target := range targets {
+		scheduleID, ok := strings.CutPrefix(target, EscalationScheduleTargetPrefix)
+		if !ok {
+			resolved = append(resolved, target)
+			continue
+		}
+
+		users, err := s.onCallService.GetCurrentOnCall(ctx, scheduleID, now)
+		if err != nil {
+			s.logger.Error("Failed to resolve on-call escalation target", map[string]interface{}{
+				"incident_id": incident.ID,
+				"schedule_id": scheduleID,
+				"error":       err.Error(),
+			})
+			continue
+		}
+		for _, user := range users {
+			resolved = append(resolved, user.ID)
+		}
+	}
+	return resolved
+}
+
+// orderedRules returns the policy's rules sorted by delay
+func orderedRules(policy *models.EscalationPolicy) []models.EscalationRule {
+	rules := append([]models.EscalationRule(nil), policy.Rules...)
+	sort.SliceStable(rules, func(i, j int) bool {
+		return rules[i].DelayMinutes < rules[j].DelayMinutes
+	})
+	return rules
+}
+
+// SetEscalationPolicy attaches an escalation policy to an incident, or
+// detaches it when policyID is empty. Changing the policy restarts
+// escalation from its first rule.
+func (s *IncidentService) SetEscalationPolicy(ctx context.Context, incidentID, policyID string) error {
+	incident, err := s.store.GetIncident(ctx, incidentID)
+	if err != nil {
+		return err
+	}
+
+	if incident.Labels[EscalationPolicyLabel] == policyID {
+		return nil
+	}
+
+	if policyID == "" {
+		delete(incident.Labels, EscalationPolicyLabel)
+	} else {
+		if _, err := s.store.GetEscalationPolicy(ctx, policyID); err != nil {
+			return ErrEscalationPolicyNotFound
+		}
+		if incident.Labels == nil {
+			incident.Labels = make(map[string]string)
+		}
+		incident.Labels[EscalationPolicyLabel] = policyID
+	}
+	delete(incident.Labels, EscalationLevelLabel)
+
+	return s.UpdateIncident(ctx, incident)
+}
@@ -0,0 +1,130 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/config"
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/models"
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/storage"
+)
+
+func TestEscalationService_FiresRulesAtThresholds(t *testing.T) {
+	ctx := context.Background()
+	store, err := storage.NewMemoryStore()
+	if err != nil {
+		t.Fatalf("Failed to create memory store: %v", err)
+	}
+	logger := NewLogger("error", false)
+	incidentService := NewIncidentService(store, NewMetricsService(prometheus.NewRegistry()))
+	notificationService := NewNotificationService(&config.Config{Port: "8080"}, store, NewNotificationTemplateService(logger), NewMetricsService(prometheus.NewRegistry()), logger)
+
+	for _, channel := range []*models.NotificationChannel{
+		{ID: "alice-pager", Type: "slack", Enabled: true, UserID: "user-alice"},
+		{ID: "bob-pager", Type: "slack", Enabled: true, UserID: "user-bob"},
+		{ID: "ops-room", Type: "slack", Enabled: true},
+		{ID: "ops-room-old", Type: "slack", Enabled: false, UserID: "user-bob"},
+	} {
+		if err := store.CreateNotificationChannel(ctx, channel); err != nil {
+			t.Fatalf("Failed to create channel: %v", err)
+		}
+	}
+	policy := &models.EscalationPolicy{
+		ID:   "payments",
+		Name: "Payments escalation",
+		// Deliberately out of order; rules fire by delay
+		Rules: []models.EscalationRule{
+			{DelayMinutes: 15, Targets: []string{"user-bob", "ops-room"}},
+			{DelayMinutes: 5, Targets: []string{"user-alice"}},
+		},
+	}
+	if err := store.CreateEscalationPolicy(ctx, policy); err != nil {
+		t.Fatalf("Failed to create policy: %v", err)
+	}
+
+	incident, err := incidentService.CreateIncident(ctx, "Payments failing", "", models.SeverityCritical, []string{})
+	if err != nil {
+		t.Fatalf("Failed to create incident: %v", err)
+	}
+	acked, err := incidentService.CreateIncident(ctx, "Refunds slow", "", models.SeverityHigh, []string{})
+	if err != nil {
+		t.Fatalf("Failed to create incident: %v", err)
+	}
+	unattached, err := incidentService.CreateIncident(ctx, "Disk filling up", "", models.SeverityLow, []string{})
+	if err != nil {
+		t.Fatalf("Failed to create incident: %v", err)
+	}
+	for _, id := range []string{incident.ID, acked.ID} {
+		if err := incidentService.SetEscalationPolicy(ctx, id, policy.ID); err != nil {
+			t.Fatalf("Failed to attach policy: %v", err)
+		}
+	}
+	if err := incidentService.SetEscalationPolicy(ctx, unattached.ID, "missing"); err != ErrEscalationPolicyNotFound {
+		t.Fatalf("Expected ErrEscalationPolicyNotFound, got %v", err)
+	}
+
+	clock := &fakeClock{current: incident.CreatedAt}
+	var paged []string
+	escalation := NewEscalationService(store, incidentService, notificationService, logger)
+	escalation.now = clock.Now
+	escalation.deliver = func(channel *models.NotificationChannel, subject, content string) error {
+		paged = append(paged, channel.ID)
+		return nil
+	}
+
+	evaluate := func(expectFired int, expectPaged ...string) {
+		t.Helper()
+		paged = nil
+		fired, err := escalation.EvaluateEscalations(ctx)
+		if err != nil {
+			t.Fatalf("EvaluateEscalations failed: %v", err)
+		}
+		if fired != expectFired || len(paged) != len(expectPaged) {
+			t.Fatalf("Expected %d rules to fire paging %v, got %d paging %v", expectFired, expectPaged, fired, paged)
+		}
+		for i := range expectPaged {
+			if paged[i] != expectPaged[i] {
+				t.Fatalf("Expected %v to be paged, got %v", expectPaged, paged)
+			}
+		}
+	}
+
+	clock.Advance(4 * time.Minute)
+	evaluate(0)
+
+	// First rule fires for both attached incidents
+	clock.Advance(2 * time.Minute)
+	evaluate(2, "alice-pager", "alice-pager")
+	evaluate(0)
+
+	// Acknowledging stops further escalation
+	if err := incidentService.AcknowledgeIncident(ctx, acked.ID, "user-alice"); err != nil {
+		t.Fatalf("Failed to acknowledge incident: %v", err)
+	}
+
+	clock.Advance(10 * time.Minute)
+	evaluate(1, "bob-pager", "ops-room")
+
+	clock.Advance(time.Hour)
+	evaluate(0)
+
+	escalated, _ := incidentService.GetIncident(ctx, incident.ID)
+	if escalated.Labels[EscalationLevelLabel] != "2" {
+		t.Errorf("Expected escalation level 2 recorded on the incident, got labels %v", escalated.Labels)
+	}
+	timeline, err := incidentService.GetTimeline(ctx, incident.ID)
+	if err != nil || len(timeline) != 2 {
+		t.Fatalf("Expected two escalation timeline entries, got %d (err: %v)", len(timeline), err)
+	}
+	for i, entry := range timeline {
+		if entry.CommentType != models.CommentTypeEscalation {
+			t.Errorf("Expected escalation timeline entries, got %s", entry.CommentType)
+		}
+		if entry.Metadata["policy_id"] != policy.ID || entry.Metadata["level"] != i+1 {
+			t.Errorf("Expected metadata for level %d of %s, got %v", i+1, policy.ID, entry.Metadata)
+		}
+	}
+}
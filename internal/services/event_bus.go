@@ -0,0 +1,60 @@
+package services
+
+import (
+	"sync"
+
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/models"
+)
+
+// EventBus fans incident events out to in-process subscribers such as
+// WebSocket connections. Publishing never blocks: a subscriber whose buffer
+// is full misses the event rather than stalling the incident workflow.
+type EventBus struct {
+	mu          sync.RWMutex
+	subscribers map[chan models.IncidentEvent]struct{}
+}
+
+// NewEventBus creates an event bus without subscribers
+func NewEventBus() *EventBus {
+	return &EventBus{subscribers: make(map[chan models.IncidentEvent]struct{})}
+}
+
+// Subscribe returns a channel receiving every event published from now on
+// and a function that ends the subscription and closes the channel
+func (b *EventBus) Subscribe(buffer int) (<-chan models.IncidentEvent, func()) {
+	events := make(chan models.IncidentEvent, buffer)
+
+	b.mu.Lock()
+	b.subscribers[events] = struct{}{}
+	b.mu.Unlock()
+
+	var once sync.Once
+	return events, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subscribers, events)
+			b.mu.Unlock()
+			close(events)
+		})
+	}
+}
+
+// Publish delivers an event to every subscriber with room for it
+func (b *EventBus) Publish(event models.IncidentEvent) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for events := range b.subscribers {
+		select {
+		case events <- event:
+		default:
+		}
+	}
+}
+
+// Subscribers returns the number of active subscriptions
+func (b *EventBus) Subscribers() int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.subscribers)
+}
@@ -0,0 +1,128 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strings"
+	"time"
+)
+
+// GrafanaAlert represents an alert from Grafana alerting
+type GrafanaAlert struct {
+	Status       string                 `json:"status"`
+	Fingerprint  string                 `json:"fingerprint"`
+	StartsAt     time.Time              `json:"startsAt"`
+	EndsAt       time.Time              `json:"endsAt"`
+	Labels       map[string]string      `json:"labels"`
+	Annotations  map[string]string      `json:"annotations"`
+	GeneratorURL string                 `json:"generatorURL"`
+	DashboardURL string                 `json:"dashboardURL"`
+	PanelURL     string                 `json:"panelURL"`
+	Values       map[string]interface{} `json:"values"`
+}
+
+// GrafanaWebhook represents the webhook payload from Grafana alerting
+type GrafanaWebhook struct {
+	Receiver          string            `json:"receiver"`
+	Status            string            `json:"status"`
+	OrgID             int64             `json:"orgId"`
+	GroupKey          string            `json:"groupKey"`
+	GroupLabels       map[string]string `json:"groupLabels"`
+	CommonLabels      map[string]string `json:"commonLabels"`
+	CommonAnnotations map[string]string `json:"commonAnnotations"`
+	ExternalURL       string            `json:"externalURL"`
+	Title             string            `json:"title"`
+	Message           string            `json:"message"`
+	Alerts            []GrafanaAlert    `json:"alerts"`
+}
+
+// grafanaSeverities maps severity label values common in Grafana alert rules
+// onto the values understood by determineSeverity
+var grafanaSeverities = map[string]string{
+	"error":   "high",
+	"warning": "medium",
+	"warn":    "medium",
+	"info":    "low",
+}
+
+// ConvertGrafanaWebhook maps a Grafana webhook onto the Alertmanager model so
+// that it can be processed like any other alert. Fingerprints are prefixed
+// with "grafana:" so they never collide with Alertmanager's, and are derived
+// from the labels when Grafana does not send one.
+func ConvertGrafanaWebhook(webhook *GrafanaWebhook) *AlertmanagerWebhook {
+	converted := &AlertmanagerWebhook{
+		Version:           "grafana",
+		GroupKey:          webhook.GroupKey,
+		Status:            webhook.Status,
+		Receiver:          webhook.Receiver,
+		GroupLabels:       webhook.GroupLabels,
+		CommonLabels:      webhook.CommonLabels,
+		CommonAnnotations: webhook.CommonAnnotations,
+		ExternalURL:       webhook.ExternalURL,
+		Alerts:            make([]AlertmanagerAlert, 0, len(webhook.Alerts)),
+	}
+
+	for _, alert := range webhook.Alerts {
+		labels := make(map[string]string, len(alert.Labels))
+		for key, value := range alert.Labels {
+			labels[key] = value
+		}
+		if severity, ok := grafanaSeverities[strings.ToLower(labels["severity"])]; ok {
+			labels["severity"] = severity
+		}
+
+		annotations := make(map[string]string, len(alert.Annotations)+3)
+		for key, value := range alert.Annotations {
+			annotations[key] = value
+		}
+		for key, value := range map[string]string{
+			"generator_url": alert.GeneratorURL,
+			"dashboard_url": alert.DashboardURL,
+			"panel_url":     alert.PanelURL,
+		} {
+			if _, exists := annotations[key]; !exists && value != "" {
+				annotations[key] = value
+			}
+		}
+
+		fingerprint := alert.Fingerprint
+		if fingerprint == "" {
+			fingerprint = labelsFingerprint(alert.Labels)
+		}
+
+		startsAt := alert.StartsAt
+		if startsAt.IsZero() {
+			startsAt = time.Now()
+		}
+
+		converted.Alerts = append(converted.Alerts, AlertmanagerAlert{
+			Fingerprint: "grafana:" + fingerprint,
+			Status:      alert.Status,
+			StartsAt:    startsAt,
+			EndsAt:      alert.EndsAt,
+			Labels:      labels,
+			Annotations: annotations,
+		})
+	}
+
+	return converted
+}
+
+// labelsFingerprint returns a stable hash of a label set
+func labelsFingerprint(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	hash := sha256.New()
+	for _, key := range keys {
+		hash.Write([]byte(key))
+		hash.Write([]byte{0})
+		hash.Write([]byte(labels[key]))
+		hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil))[:16]
+}
@@ -0,0 +1,53 @@
+package services
+
+import (
+	"testing"
+)
+
+func TestConvertGrafanaWebhook(t *testing.T) {
+	webhook := &GrafanaWebhook{
+		Status: "firing",
+		Alerts: []GrafanaAlert{
+			{
+				Status:       "firing",
+				Fingerprint:  "abc123",
+				Labels:       map[string]string{"alertname": "HighLatency", "severity": "Warning"},
+				Annotations:  map[string]string{"summary": "p99 above 2s"},
+				DashboardURL: "https://grafana.example.com/d/latency",
+			},
+			{
+				Status: "firing",
+				Labels: map[string]string{"alertname": "DiskFull", "instance": "db-1"},
+			},
+		},
+	}
+
+	converted := ConvertGrafanaWebhook(webhook)
+	if len(converted.Alerts) != 2 {
+		t.Fatalf("Expected 2 alerts, got %d", len(converted.Alerts))
+	}
+
+	latency := converted.Alerts[0]
+	if latency.Fingerprint != "grafana:abc123" {
+		t.Errorf("Expected a prefixed fingerprint, got %q", latency.Fingerprint)
+	}
+	if latency.Labels["severity"] != "medium" {
+		t.Errorf("Expected warning to map to medium, got %q", latency.Labels["severity"])
+	}
+	if webhook.Alerts[0].Labels["severity"] != "Warning" {
+		t.Error("Expected the Grafana labels not to be modified")
+	}
+	if latency.Annotations["dashboard_url"] != "https://grafana.example.com/d/latency" || latency.Annotations["summary"] != "p99 above 2s" {
+		t.Errorf("Unexpected annotations: %v", latency.Annotations)
+	}
+	if latency.StartsAt.IsZero() {
+		t.Error("Expected a missing start time to default to now")
+	}
+
+	// Without a fingerprint one is derived from the labels, stably
+	disk := converted.Alerts[1]
+	again := ConvertGrafanaWebhook(&GrafanaWebhook{Status: "firing", Alerts: []GrafanaAlert{webhook.Alerts[1]}})
+	if disk.Fingerprint == "grafana:" || disk.Fingerprint != again.Alerts[0].Fingerprint {
+		t.Errorf("Expected a stable derived fingerprint, got %q and %q", disk.Fingerprint, again.Alerts[0].Fingerprint)
+	}
+}
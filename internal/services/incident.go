@@ -1,45 +1,271 @@
 package services
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"maps"
+	"regexp"
+	"sort"
 	"strings"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
 	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/models"
 	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/storage"
 )
 
+var (
+	ErrAssigneeNotFound       = errors.New("assignee not found")
+	ErrAssigneeNotAssignable  = errors.New("assignee does not have a role that can be assigned incidents")
+	ErrTitleRequired          = errors.New("incident title is required")
+	ErrTitleTooLong           = errors.New("incident title is too long")
+	ErrDescriptionTooLong     = errors.New("incident description is too long")
+	ErrResolutionNoteRequired = errors.New("a resolution note is required to resolve an incident")
+	ErrResolutionTypeRequired = errors.New("a resolution type is required to resolve an incident")
+	ErrInvalidResolutionType  = errors.New("unknown resolution type")
+	ErrInvalidPriority        = errors.New("priority must be one of P1, P2, P3 or P4")
+	ErrNotCommentAuthor       = errors.New("only the comment's author can change it")
+	ErrCommentNotEditable     = errors.New("timeline events cannot be changed")
+	ErrMissingTemplateVars    = errors.New("missing template variables")
+)
+
+// DefaultNeedsAttentionThreshold is how long an open, unassigned incident may
+// wait before it is flagged as needing attention
+const DefaultNeedsAttentionThreshold = 15 * time.Minute
+
+// Default limits on incident text, counted in characters
+const (
+	DefaultMaxTitleLength       = 255
+	DefaultMaxDescriptionLength = 10000
+)
+
 // IncidentService handles incident operations
 type IncidentService struct {
-	store          storage.Store
-	metricsService *MetricsService
+	store                  storage.Store
+	metricsService         *MetricsService
+	assignableRoles        []string
+	attentionThreshold     time.Duration
+	maxTitleLength         int
+	maxDescriptionLength   int
+	requireResolutionNote  bool
+	requireResolutionType  bool
+	attachmentDir          string
+	attachmentStorage      AttachmentStorage
+	maxInlineImageBytes    int64
+	maxAttachmentBytes     int64
+	allowedAttachmentTypes map[string]bool
+	defaultLabels          map[string]string
+	slaTargets             SLATargets
+	mentionTeams           map[string][]string
+	maxMentionRecipients   int
+	onStatusChange         func(incident *models.Incident, previous models.IncidentStatus)
+	onMention              func(incident *models.Incident, comment *models.IncidentComment, users []*models.User)
+	eventBus               *EventBus
 }
 
 // NewIncidentService creates a new incident service
 func NewIncidentService(store storage.Store, metricsService *MetricsService) *IncidentService {
-	return &IncidentService{
-		store:          store,
-		metricsService: metricsService,
+	s := &IncidentService{
+		store:                store,
+		metricsService:       metricsService,
+		attentionThreshold:   DefaultNeedsAttentionThreshold,
+		maxTitleLength:       DefaultMaxTitleLength,
+		maxDescriptionLength: DefaultMaxDescriptionLength,
+		attachmentDir:        DefaultAttachmentDir,
+		maxInlineImageBytes:  DefaultMaxInlineImageBytes,
+		maxAttachmentBytes:   DefaultMaxAttachmentBytes,
+		maxMentionRecipients: DefaultMaxMentionRecipients,
+	}
+	s.SetAttachmentLimits(0, DefaultAllowedAttachmentTypes)
+	return s
+}
+
+// SetAssignableRoles restricts incident assignment to users holding at least
+// one of the given roles. An empty list disables the check.
+func (s *IncidentService) SetAssignableRoles(roles []string) {
+	s.assignableRoles = roles
+}
+
+// SetNeedsAttentionThreshold sets how old an open, unassigned incident must be
+// before it is flagged as needing attention
+func (s *IncidentService) SetNeedsAttentionThreshold(threshold time.Duration) {
+	if threshold > 0 {
+		s.attentionThreshold = threshold
+	}
+}
+
+// SetTextLimits sets the maximum title and description lengths in characters.
+// Non-positive values keep the current limit.
+func (s *IncidentService) SetTextLimits(maxTitle, maxDescription int) {
+	if maxTitle > 0 {
+		s.maxTitleLength = maxTitle
+	}
+	if maxDescription > 0 {
+		s.maxDescriptionLength = maxDescription
+	}
+}
+
+// SetSLATargets sets the per-severity targets incidents' SLA status is
+// computed against
+func (s *IncidentService) SetSLATargets(targets SLATargets) {
+	s.slaTargets = targets
+}
+
+// SetRequireResolutionNote makes resolving an incident require a non-empty note
+func (s *IncidentService) SetRequireResolutionNote(required bool) {
+	s.requireResolutionNote = required
+}
+
+// SetRequireResolutionType makes resolving an incident require a resolution
+// type such as fixed or false_positive
+func (s *IncidentService) SetRequireResolutionType(required bool) {
+	s.requireResolutionType = required
+}
+
+// SetDefaultLabels sets labels added to every new incident. Labels given
+// explicitly when an incident is created take precedence.
+func (s *IncidentService) SetDefaultLabels(labels map[string]string) {
+	s.defaultLabels = labels
+}
+
+// ParseDefaultLabels parses labels written as "key=value", e.g. "environment=prod"
+func ParseDefaultLabels(specs []string) (map[string]string, error) {
+	labels := make(map[string]string, len(specs))
+	for _, spec := range specs {
+		key, value, ok := strings.Cut(spec, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid default label %q: expected key=value", spec)
+		}
+		labels[key] = strings.TrimSpace(value)
+	}
+	return labels, nil
+}
+
+// SetStatusChangeHook registers a function called after an incident's status
+// has changed and been saved, e.g. to publish lifecycle webhooks
+func (s *IncidentService) SetStatusChangeHook(hook func(incident *models.Incident, previous models.IncidentStatus)) {
+	s.onStatusChange = hook
+}
+
+// SetEventBus publishes incident lifecycle and comment events to bus for
+// real-time clients. Nil disables publishing.
+func (s *IncidentService) SetEventBus(bus *EventBus) {
+	s.eventBus = bus
+}
+
+// statusChanged runs the status change hook if the status actually changed
+func (s *IncidentService) statusChanged(incident *models.Incident, previous models.IncidentStatus) {
+	if incident.Status == previous {
+		return
+	}
+	if s.onStatusChange != nil {
+		s.onStatusChange(incident, previous)
+	}
+	s.publishEvent("incident."+string(incident.Status), incident, nil)
+}
+
+// publishEvent publishes a snapshot of the incident or comment, which callers
+// may keep modifying, to the event bus
+func (s *IncidentService) publishEvent(event string, incident *models.Incident, comment *models.IncidentComment) {
+	if s.eventBus == nil {
+		return
+	}
+
+	published := models.IncidentEvent{Event: event, OccurredAt: time.Now()}
+	if incident != nil {
+		snapshot := *incident
+		snapshot.Labels = maps.Clone(incident.Labels)
+		published.Incident = &snapshot
+		published.IncidentID = incident.ID
+	}
+	if comment != nil {
+		snapshot := *comment
+		snapshot.Metadata = maps.Clone(comment.Metadata)
+		published.Comment = &snapshot
+		published.IncidentID = comment.IncidentID
+	}
+	s.eventBus.Publish(published)
+}
+
+// FitText sanitizes a title and description and truncates them to the
+// configured limits, for text that is generated rather than typed by a user
+func (s *IncidentService) FitText(title, description string) (string, string) {
+	title = truncateText(sanitizeText(title, false), s.maxTitleLength)
+	description = truncateText(sanitizeText(description, true), s.maxDescriptionLength)
+	return title, description
+}
+
+// CreateIncident creates a new incident. Control characters are stripped from
+// the title and description; text over the configured limits is rejected.
+func (s *IncidentService) CreateIncident(ctx context.Context, title, description string, severity models.IncidentSeverity, alertIDs []string) (*models.Incident, error) {
+	return s.CreateIncidentWithLabels(ctx, title, description, severity, alertIDs, nil)
+}
+
+// CreateIncidentWithLabels creates a new incident carrying the configured
+// default labels merged with labels, which override defaults of the same key
+func (s *IncidentService) CreateIncidentWithLabels(ctx context.Context, title, description string, severity models.IncidentSeverity, alertIDs []string, labels map[string]string) (*models.Incident, error) {
+	return s.CreateIncidentWithPriority(ctx, title, description, severity, "", alertIDs, labels)
+}
+
+// incidentText cleans an incident's title and description and checks them
+// against the length limits
+func (s *IncidentService) incidentText(title, description string) (string, string, error) {
+	title = strings.TrimSpace(sanitizeText(title, false))
+	description = sanitizeText(description, true)
+
+	if title == "" {
+		return "", "", ErrTitleRequired
+	}
+	if n := utf8.RuneCountInString(title); n > s.maxTitleLength {
+		return "", "", fmt.Errorf("%w: %d characters, maximum is %d", ErrTitleTooLong, n, s.maxTitleLength)
 	}
+	if n := utf8.RuneCountInString(description); n > s.maxDescriptionLength {
+		return "", "", fmt.Errorf("%w: %d characters, maximum is %d", ErrDescriptionTooLong, n, s.maxDescriptionLength)
+	}
+	return title, description, nil
 }
 
-// CreateIncident creates a new incident
-func (s *IncidentService) CreateIncident(title, description string, severity models.IncidentSeverity, alertIDs []string) (*models.Incident, error) {
+// CreateIncidentWithPriority creates a new incident like
+// CreateIncidentWithLabels with the given priority. An empty priority is
+// derived from the severity.
+func (s *IncidentService) CreateIncidentWithPriority(ctx context.Context, title, description string, severity models.IncidentSeverity, priority models.IncidentPriority, alertIDs []string, labels map[string]string) (*models.Incident, error) {
+	if priority == "" {
+		priority = models.DefaultPriority(severity)
+	}
+	if !priority.Valid() {
+		return nil, ErrInvalidPriority
+	}
+
+	title, description, err := s.incidentText(title, description)
+	if err != nil {
+		return nil, err
+	}
+
 	incident := &models.Incident{
 		ID:          uuid.New().String(),
 		Title:       title,
 		Description: description,
 		Status:      models.IncidentStatusOpen,
 		Severity:    severity,
+		Priority:    priority,
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
 		AlertIDs:    alertIDs,
-		Labels:      make(map[string]string),
+		Labels:      make(map[string]string, len(s.defaultLabels)+len(labels)),
+	}
+	for key, value := range s.defaultLabels {
+		incident.Labels[key] = value
+	}
+	for key, value := range labels {
+		incident.Labels[key] = value
 	}
 
 	start := time.Now()
-	err := s.store.CreateIncident(incident)
+	err = s.store.CreateIncident(ctx, incident)
 	if s.metricsService != nil {
 		s.metricsService.RecordDBQuery("CREATE", "incidents", time.Since(start))
 	}
@@ -52,65 +278,249 @@ func (s *IncidentService) CreateIncident(title, description string, severity mod
 	if s.metricsService != nil {
 		s.metricsService.RecordIncidentCreated(string(severity), string(incident.Status))
 	}
+	s.publishEvent("incident.created", incident, nil)
 
 	return incident, nil
 }
 
 // GetIncident retrieves an incident by ID
-func (s *IncidentService) GetIncident(id string) (*models.Incident, error) {
-	return s.store.GetIncident(id)
+func (s *IncidentService) GetIncident(ctx context.Context, id string) (*models.Incident, error) {
+	incident, err := s.store.GetIncident(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	s.markComputed([]*models.Incident{incident})
+	return incident, nil
 }
 
 // ListIncidents retrieves all incidents
-func (s *IncidentService) ListIncidents() ([]*models.Incident, error) {
-	return s.store.ListIncidents()
+func (s *IncidentService) ListIncidents(ctx context.Context) ([]*models.Incident, error) {
+	incidents, err := s.store.ListIncidents(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.markComputed(incidents)
+	return incidents, nil
+}
+
+// ListIncidentsWithFilter returns a page of the incidents matching the
+// filter and the number that match in total
+func (s *IncidentService) ListIncidentsWithFilter(ctx context.Context, filter storage.IncidentFilter) ([]*models.Incident, int, error) {
+	incidents, err := s.store.ListIncidentsWithFilter(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	total, err := s.store.CountIncidents(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	s.markComputed(incidents)
+	return incidents, total, nil
 }
 
-// AcknowledgeIncident acknowledges an incident
-func (s *IncidentService) AcknowledgeIncident(id, assigneeID string) error {
-	incident, err := s.store.GetIncident(id)
+// ListNeedsAttention returns open, unassigned incidents older than the
+// triage threshold, oldest first
+func (s *IncidentService) ListNeedsAttention(ctx context.Context) ([]*models.Incident, error) {
+	incidents, err := s.store.ListIncidents(ctx)
 	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	cutoff := now.Add(-s.attentionThreshold)
+	result := make([]*models.Incident, 0)
+	for _, incident := range incidents {
+		if needsAttention(incident, cutoff) {
+			incident.NeedsAttention = true
+			incident.SLAStatus = s.slaTargets.Status(incident, now)
+			result = append(result, incident)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].CreatedAt.Before(result[j].CreatedAt)
+	})
+
+	return result, nil
+}
+
+// markComputed populates the computed NeedsAttention flag and SLA status
+func (s *IncidentService) markComputed(incidents []*models.Incident) {
+	now := time.Now()
+	cutoff := now.Add(-s.attentionThreshold)
+	for _, incident := range incidents {
+		incident.NeedsAttention = needsAttention(incident, cutoff)
+		incident.SLAStatus = s.slaTargets.Status(incident, now)
+	}
+}
+
+// needsAttention reports whether an incident is open, unassigned and was
+// created before the cutoff
+func needsAttention(incident *models.Incident, cutoff time.Time) bool {
+	return incident.Status == models.IncidentStatusOpen &&
+		incident.AssigneeID == "" &&
+		incident.CreatedAt.Before(cutoff)
+}
+
+// AcknowledgeIncident acknowledges an incident, assigning it to assigneeID
+// when set. The assignee must hold one of the assignable roles, as with
+// AssignIncident.
+func (s *IncidentService) AcknowledgeIncident(ctx context.Context, id, assigneeID string) error {
+	incident, err := s.store.GetIncident(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := validateTransition(incident.Status, models.IncidentStatusAcknowledged); err != nil {
 		return err
 	}
+	if assigneeID != "" {
+		if err := s.validateAssignee(ctx, assigneeID); err != nil {
+			return err
+		}
+	}
 
+	previous := incident.Status
 	now := time.Now()
 	incident.Status = models.IncidentStatusAcknowledged
 	incident.AckedAt = &now
 	incident.UpdatedAt = now
 	incident.AssigneeID = assigneeID
 
-	return s.store.UpdateIncident(incident)
+	if err := s.store.UpdateIncident(ctx, incident); err != nil {
+		return err
+	}
+	s.statusChanged(incident, previous)
+	return nil
 }
 
-// ResolveIncident resolves an incident
-func (s *IncidentService) ResolveIncident(id string) error {
-	incident, err := s.store.GetIncident(id)
+// AcknowledgeIncidentOnBehalf acknowledges an incident for a responder who
+// cannot do so themselves. The incident is assigned to onBehalfOf, and the
+// timeline records actorID as the user who acknowledged it.
+func (s *IncidentService) AcknowledgeIncidentOnBehalf(ctx context.Context, id, actorID, onBehalfOf string) error {
+	if _, err := s.store.GetIncident(ctx, id); err != nil {
+		return err
+	}
+	if _, err := s.store.GetUser(ctx, onBehalfOf); err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return ErrAssigneeNotFound
+		}
+		return fmt.Errorf("failed to look up assignee: %w", err)
+	}
+
+	if err := s.AcknowledgeIncident(ctx, id, onBehalfOf); err != nil {
+		return err
+	}
+
+	metadata := map[string]interface{}{
+		"status":       models.IncidentStatusAcknowledged,
+		"acted_by":     actorID,
+		"on_behalf_of": onBehalfOf,
+	}
+	_, err := s.AddComment(ctx, id, actorID,
+		fmt.Sprintf("%s acknowledged on behalf of %s", s.userDisplayName(ctx, actorID), s.userDisplayName(ctx, onBehalfOf)),
+		models.CommentTypeStatusChange, metadata)
+	return err
+}
+
+// userDisplayName returns a user's username, or the ID when the user is unknown
+func (s *IncidentService) userDisplayName(ctx context.Context, userID string) string {
+	if user, err := s.store.GetUser(ctx, userID); err == nil && user.Username != "" {
+		return user.Username
+	}
+	return userID
+}
+
+// ResolveIncident resolves an incident. A non-empty note is recorded on the
+// timeline as a status change by userID; it is mandatory when the service
+// requires resolution notes.
+func (s *IncidentService) ResolveIncident(ctx context.Context, id, userID, note string) error {
+	return s.ResolveIncidentWithDetails(ctx, id, userID, models.Resolution{Note: note})
+}
+
+// ResolveIncidentWithDetails resolves an incident, recording how it was
+// resolved and, optionally, the category of its root cause
+func (s *IncidentService) ResolveIncidentWithDetails(ctx context.Context, id, userID string, resolution models.Resolution) error {
+	note := strings.TrimSpace(resolution.Note)
+	if note == "" && s.requireResolutionNote {
+		return ErrResolutionNoteRequired
+	}
+	if err := s.validateResolutionType(resolution.Type); err != nil {
+		return err
+	}
+
+	incident, err := s.store.GetIncident(ctx, id)
 	if err != nil {
 		return err
 	}
+	if err := validateTransition(incident.Status, models.IncidentStatusResolved); err != nil {
+		return err
+	}
+
+	oldStatus := incident.Status
 
 	now := time.Now()
 	incident.Status = models.IncidentStatusResolved
 	incident.ResolvedAt = &now
 	incident.UpdatedAt = now
+	incident.ResolutionType = resolution.Type
+	incident.RootCauseCategory = strings.TrimSpace(resolution.RootCauseCategory)
+
+	if err := s.store.UpdateIncident(ctx, incident); err != nil {
+		return err
+	}
+
+	if s.metricsService != nil {
+		s.metricsService.RecordIncidentResolved(string(incident.Severity), now.Sub(incident.CreatedAt))
+	}
+	s.statusChanged(incident, oldStatus)
+
+	if note == "" {
+		return nil
+	}
+	metadata := map[string]interface{}{
+		"old_status":      oldStatus,
+		"new_status":      models.IncidentStatusResolved,
+		"resolution_note": note,
+	}
+	if incident.ResolutionType != "" {
+		metadata["resolution_type"] = incident.ResolutionType
+	}
+	if incident.RootCauseCategory != "" {
+		metadata["root_cause_category"] = incident.RootCauseCategory
+	}
+	_, err = s.AddComment(ctx, id, userID, "Resolved: "+note, models.CommentTypeStatusChange, metadata)
+	return err
+}
 
-	return s.store.UpdateIncident(incident)
+// validateResolutionType checks a resolution type against the known types and
+// the configured requirement
+func (s *IncidentService) validateResolutionType(resolutionType models.ResolutionType) error {
+	if resolutionType == "" {
+		if s.requireResolutionType {
+			return ErrResolutionTypeRequired
+		}
+		return nil
+	}
+	if !resolutionType.Valid() {
+		return fmt.Errorf("%w %q", ErrInvalidResolutionType, resolutionType)
+	}
+	return nil
 }
 
 // UpdateIncident updates an incident
-func (s *IncidentService) UpdateIncident(incident *models.Incident) error {
+func (s *IncidentService) UpdateIncident(ctx context.Context, incident *models.Incident) error {
 	incident.UpdatedAt = time.Now()
-	return s.store.UpdateIncident(incident)
+	return s.store.UpdateIncident(ctx, incident)
 }
 
 // DeleteIncident deletes an incident
-func (s *IncidentService) DeleteIncident(id string) error {
-	return s.store.DeleteIncident(id)
+func (s *IncidentService) DeleteIncident(ctx context.Context, id string) error {
+	return s.store.DeleteIncident(ctx, id)
 }
 
 // CalculateMetrics calculates incident metrics
-func (s *IncidentService) CalculateMetrics() (*models.Metrics, error) {
-	incidents, err := s.store.ListIncidents()
+func (s *IncidentService) CalculateMetrics(ctx context.Context) (*models.Metrics, error) {
+	incidents, err := s.store.ListIncidents(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -118,6 +528,8 @@ func (s *IncidentService) CalculateMetrics() (*models.Metrics, error) {
 	metrics := &models.Metrics{
 		IncidentsByStatus:   make(map[string]int),
 		IncidentsBySeverity: make(map[string]int),
+		ResolvedByType:      make(map[string]int),
+		ResolvedByRootCause: make(map[string]int),
 	}
 
 	var totalAckTime time.Duration
@@ -134,6 +546,8 @@ func (s *IncidentService) CalculateMetrics() (*models.Metrics, error) {
 			metrics.OpenIncidents++
 		case models.IncidentStatusResolved:
 			metrics.ResolvedIncidents++
+			metrics.ResolvedByType[categoryOrUncategorized(string(incident.ResolutionType))]++
+			metrics.ResolvedByRootCause[categoryOrUncategorized(incident.RootCauseCategory)]++
 		}
 
 		// Count by severity
@@ -163,14 +577,74 @@ func (s *IncidentService) CalculateMetrics() (*models.Metrics, error) {
 	return metrics, nil
 }
 
+// SummaryReport reports on the incidents created at or after from and before
+// to. Unlike CalculateMetrics, incidents outside the window do not count
+// toward MTTA and MTTR.
+func (s *IncidentService) SummaryReport(ctx context.Context, from, to time.Time) (*models.IncidentReport, error) {
+	incidents, err := s.store.ListIncidentsCreatedBetween(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.IncidentReport{
+		From:                  from,
+		To:                    to,
+		IncidentsByStatus:     make(map[string]int),
+		IncidentsBySeverity:   make(map[string]int),
+		SLABreachesBySeverity: make(map[string]int),
+	}
+
+	now := time.Now()
+	var totalAckTime, totalResolveTime time.Duration
+	var ackCount, resolveCount int
+	for _, incident := range incidents {
+		report.TotalIncidents++
+		report.IncidentsByStatus[string(incident.Status)]++
+		report.IncidentsBySeverity[string(incident.Severity)]++
+
+		if incident.AckedAt != nil {
+			totalAckTime += incident.AckedAt.Sub(incident.CreatedAt)
+			ackCount++
+		}
+		if incident.ResolvedAt != nil {
+			totalResolveTime += incident.ResolvedAt.Sub(incident.CreatedAt)
+			resolveCount++
+		}
+		if s.slaTargets.Status(incident, now) == models.SLABreached {
+			report.SLABreaches++
+			report.SLABreachesBySeverity[string(incident.Severity)]++
+		}
+	}
+
+	if ackCount > 0 {
+		report.MTTASeconds = int64(totalAckTime / time.Duration(ackCount) / time.Second)
+	}
+	if resolveCount > 0 {
+		report.MTTRSeconds = int64(totalResolveTime / time.Duration(resolveCount) / time.Second)
+	}
+
+	return report, nil
+}
+
+// Uncategorized stands in for a missing resolution type or root cause
+// category in metrics breakdowns
+const Uncategorized = "uncategorized"
+
+func categoryOrUncategorized(category string) string {
+	if category == "" {
+		return Uncategorized
+	}
+	return category
+}
+
 // UpdatePrometheusMetrics updates Prometheus metrics with current incident data
-func (s *IncidentService) UpdatePrometheusMetrics() error {
+func (s *IncidentService) UpdatePrometheusMetrics(ctx context.Context) error {
 	if s.metricsService == nil {
 		return nil
 	}
 
 	start := time.Now()
-	metrics, err := s.CalculateMetrics()
+	metrics, err := s.CalculateMetrics(ctx)
 	s.metricsService.RecordDBQuery("SELECT", "incidents", time.Since(start))
 	
 	if err != nil {
@@ -181,29 +655,86 @@ func (s *IncidentService) UpdatePrometheusMetrics() error {
 	s.metricsService.UpdateMTTA(metrics.MTTA)
 	s.metricsService.UpdateMTTR(metrics.MTTR)
 
-	// Update incidents by status and severity
-	for status, count := range metrics.IncidentsByStatus {
-		for severity, severityCount := range metrics.IncidentsBySeverity {
-			// This gives a rough approximation - in a real system you'd want more granular data
-			proportion := float64(severityCount) / float64(metrics.TotalIncidents)
-			estimatedCount := float64(count) * proportion
-			s.metricsService.UpdateIncidentsByStatus(status, severity, estimatedCount)
+	needingAttention, err := s.ListNeedsAttention(ctx)
+	if err != nil {
+		return err
+	}
+	s.metricsService.UpdateIncidentsNeedingAttention(len(needingAttention))
+
+	if err := s.updateSLABreachMetrics(ctx); err != nil {
+		return err
+	}
+
+	for _, resolutionType := range []models.ResolutionType{
+		models.ResolutionFixed, models.ResolutionAutoRecovered, models.ResolutionDuplicate, models.ResolutionFalsePositive, Uncategorized,
+	} {
+		s.metricsService.UpdateIncidentsResolvedByType(string(resolutionType), metrics.ResolvedByType[string(resolutionType)])
+	}
+
+	// Update incidents by status and severity from exact counts. Known
+	// combinations without incidents are set too, so drops to zero show up.
+	start = time.Now()
+	counts, err := s.store.CountIncidentsByStatusSeverity(ctx)
+	s.metricsService.RecordDBQuery("SELECT", "incidents", time.Since(start))
+	if err != nil {
+		return err
+	}
+
+	for _, status := range []models.IncidentStatus{models.IncidentStatusOpen, models.IncidentStatusAcknowledged, models.IncidentStatusResolved} {
+		for _, severity := range digestSeverityOrder {
+			if counts[status][severity] == 0 {
+				s.metricsService.UpdateIncidentsByStatus(string(status), string(severity), 0)
+			}
+		}
+	}
+	for status, bySeverity := range counts {
+		for severity, count := range bySeverity {
+			s.metricsService.UpdateIncidentsByStatus(string(status), string(severity), float64(count))
 		}
 	}
 
 	return nil
 }
 
+// updateSLABreachMetrics sets the number of unresolved incidents past an SLA
+// target for each severity
+func (s *IncidentService) updateSLABreachMetrics(ctx context.Context) error {
+	incidents, err := s.store.ListIncidents(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	breached := make(map[models.IncidentSeverity]int)
+	for _, incident := range incidents {
+		if incident.Status != models.IncidentStatusResolved && s.slaTargets.Status(incident, now) == models.SLABreached {
+			breached[incident.Severity]++
+		}
+	}
+	for _, severity := range digestSeverityOrder {
+		s.metricsService.UpdateIncidentsSLABreached(string(severity), breached[severity])
+	}
+	return nil
+}
+
 // Enhanced Incident Features - Comments and Timeline
 
 // AddComment adds a comment to an incident timeline
-func (s *IncidentService) AddComment(incidentID, userID, content string, commentType models.IncidentCommentType, metadata map[string]interface{}) (*models.IncidentComment, error) {
+func (s *IncidentService) AddComment(ctx context.Context, incidentID, userID, content string, commentType models.IncidentCommentType, metadata map[string]interface{}) (*models.IncidentComment, error) {
 	// Verify incident exists
-	_, err := s.store.GetIncident(incidentID)
+	incident, err := s.store.GetIncident(ctx, incidentID)
 	if err != nil {
 		return nil, fmt.Errorf("incident not found: %w", err)
 	}
 
+	// Only comments written by people mention anyone; timeline events do not
+	var mentioned []*models.User
+	if commentType == models.CommentTypeComment {
+		if mentioned = s.ResolveMentions(ctx, content, userID); len(mentioned) > 0 {
+			metadata = withMentionedUsers(metadata, mentioned)
+		}
+	}
+
 	comment := &models.IncidentComment{
 		ID:          uuid.New().String(),
 		IncidentID:  incidentID,
@@ -214,29 +745,233 @@ func (s *IncidentService) AddComment(incidentID, userID, content string, comment
 		CreatedAt:   time.Now(),
 	}
 
-	if err := s.store.CreateIncidentComment(comment); err != nil {
+	if err := s.store.CreateIncidentComment(ctx, comment); err != nil {
 		return nil, fmt.Errorf("failed to create comment: %w", err)
 	}
 
+	if len(mentioned) > 0 && s.onMention != nil {
+		s.onMention(incident, comment, mentioned)
+	}
+	s.publishEvent("comment.created", nil, comment)
+
 	return comment, nil
 }
 
 // GetComments retrieves comments for an incident
-func (s *IncidentService) GetComments(incidentID string) ([]*models.IncidentComment, error) {
-	return s.store.GetIncidentComments(incidentID)
+func (s *IncidentService) GetComments(ctx context.Context, incidentID string) ([]*models.IncidentComment, error) {
+	return s.store.GetIncidentComments(ctx, incidentID)
+}
+
+// UpdateComment replaces the content of a comment and marks it as edited.
+// Only the author may edit a comment unless asAdmin is set.
+func (s *IncidentService) UpdateComment(ctx context.Context, incidentID, commentID, userID, content string, asAdmin bool) (*models.IncidentComment, error) {
+	comment, err := s.authoredComment(ctx, incidentID, commentID, userID, asAdmin)
+	if err != nil {
+		return nil, err
+	}
+
+	// Users already mentioned before the edit are not notified again
+	previous := make(map[string]bool)
+	for _, id := range MentionedUserIDs(comment) {
+		previous[id] = true
+	}
+	mentioned := s.ResolveMentions(ctx, content, userID)
+	var added []*models.User
+	for _, user := range mentioned {
+		if !previous[user.ID] {
+			added = append(added, user)
+		}
+	}
+	metadata := make(map[string]interface{}, len(comment.Metadata))
+	for key, value := range comment.Metadata {
+		if key != MentionedUserIDsKey {
+			metadata[key] = value
+		}
+	}
+	if len(mentioned) > 0 {
+		metadata = withMentionedUsers(metadata, mentioned)
+	}
+	comment.Metadata = metadata
+
+	now := time.Now()
+	comment.Content = content
+	comment.EditedAt = &now
+	if err := s.store.UpdateIncidentComment(ctx, comment); err != nil {
+		return nil, fmt.Errorf("failed to update comment: %w", err)
+	}
+
+	if len(added) > 0 && s.onMention != nil {
+		if incident, err := s.store.GetIncident(ctx, incidentID); err == nil {
+			s.onMention(incident, comment, added)
+		}
+	}
+	return comment, nil
+}
+
+// DeleteComment removes a comment. Only the author may delete a comment
+// unless asAdmin is set.
+func (s *IncidentService) DeleteComment(ctx context.Context, incidentID, commentID, userID string, asAdmin bool) error {
+	if _, err := s.authoredComment(ctx, incidentID, commentID, userID, asAdmin); err != nil {
+		return err
+	}
+	return s.store.DeleteIncidentComment(ctx, commentID)
+}
+
+// authoredComment returns a comment of the incident that userID may change.
+// Timeline events are never changed, since they record what happened.
+func (s *IncidentService) authoredComment(ctx context.Context, incidentID, commentID, userID string, asAdmin bool) (*models.IncidentComment, error) {
+	comments, err := s.store.GetIncidentComments(ctx, incidentID)
+	if err != nil {
+		return nil, err
+	}
+	for _, comment := range comments {
+		if comment.ID != commentID {
+			continue
+		}
+		if comment.CommentType != models.CommentTypeComment {
+			return nil, ErrCommentNotEditable
+		}
+		if !asAdmin && (comment.UserID == nil || *comment.UserID != userID) {
+			return nil, ErrNotCommentAuthor
+		}
+		return comment, nil
+	}
+	return nil, storage.ErrNotFound
+}
+
+// SaveDraft stores the user's unsent comment for an incident, replacing any
+// earlier draft
+func (s *IncidentService) SaveDraft(ctx context.Context, incidentID, userID, content string) (*models.CommentDraft, error) {
+	if _, err := s.store.GetIncident(ctx, incidentID); err != nil {
+		return nil, err
+	}
+
+	draft := &models.CommentDraft{
+		IncidentID: incidentID,
+		UserID:     userID,
+		Content:    content,
+		UpdatedAt:  time.Now(),
+	}
+	if err := s.store.SaveCommentDraft(ctx, draft); err != nil {
+		return nil, err
+	}
+	return draft, nil
+}
+
+// GetDraft returns the user's draft for an incident, or storage.ErrNotFound
+func (s *IncidentService) GetDraft(ctx context.Context, incidentID, userID string) (*models.CommentDraft, error) {
+	return s.store.GetCommentDraft(ctx, incidentID, userID)
+}
+
+// DeleteDraft discards the user's draft for an incident
+func (s *IncidentService) DeleteDraft(ctx context.Context, incidentID, userID string) error {
+	return s.store.DeleteCommentDraft(ctx, incidentID, userID)
 }
 
 // GetTimeline retrieves the complete timeline for an incident (comments + system events)
-func (s *IncidentService) GetTimeline(incidentID string) ([]*models.IncidentComment, error) {
-	return s.store.GetIncidentTimeline(incidentID)
+func (s *IncidentService) GetTimeline(ctx context.Context, incidentID string) ([]*models.IncidentComment, error) {
+	return s.store.GetIncidentTimeline(ctx, incidentID)
+}
+
+// keyEventCommentLength is how much of a comment is quoted in its key event
+const keyEventCommentLength = 140
+
+// GetKeyEvents summarises an incident's timeline into its milestones: creation,
+// acknowledgement, assignment, severity and status changes, resolution and
+// major comments, oldest first with the gaps between them. A comment is major
+// if it is the first one on the incident or its metadata sets "key_event".
+func (s *IncidentService) GetKeyEvents(ctx context.Context, incidentID string) ([]models.KeyEvent, error) {
+	incident, err := s.store.GetIncident(ctx, incidentID)
+	if err != nil {
+		return nil, err
+	}
+
+	timeline, err := s.store.GetIncidentTimeline(ctx, incidentID)
+	if err != nil {
+		return nil, err
+	}
+
+	events := []models.KeyEvent{{
+		Type:        models.KeyEventCreated,
+		Description: fmt.Sprintf("Incident created with %s severity", incident.Severity),
+		Timestamp:   incident.CreatedAt,
+	}}
+	seen := make(map[models.KeyEventType]bool)
+	sawComment := false
+
+	for _, entry := range timeline {
+		event := models.KeyEvent{Description: entry.Content, Timestamp: entry.CreatedAt}
+		if entry.UserID != nil {
+			event.UserID = *entry.UserID
+		}
+
+		switch entry.CommentType {
+		case models.CommentTypeStatusChange:
+			switch fmt.Sprint(entry.Metadata["new_status"]) {
+			case string(models.IncidentStatusAcknowledged):
+				event.Type = models.KeyEventAcknowledged
+			case string(models.IncidentStatusResolved):
+				event.Type = models.KeyEventResolved
+			default:
+				event.Type = models.KeyEventStatusChanged
+			}
+		case models.CommentTypeSeverityChange:
+			event.Type = models.KeyEventSeverityChanged
+		case models.CommentTypeAssignment:
+			event.Type = models.KeyEventAssigned
+		case models.CommentTypeComment:
+			isKey, _ := entry.Metadata["key_event"].(bool)
+			if sawComment && !isKey {
+				continue
+			}
+			sawComment = true
+			event.Type = models.KeyEventComment
+			event.Description = truncateText(entry.Content, keyEventCommentLength)
+		default:
+			continue
+		}
+
+		seen[event.Type] = true
+		events = append(events, event)
+	}
+
+	// Acknowledgement and resolution are not always recorded on the timeline
+	if incident.AckedAt != nil && !seen[models.KeyEventAcknowledged] {
+		events = append(events, models.KeyEvent{
+			Type:        models.KeyEventAcknowledged,
+			Description: "Incident acknowledged",
+			UserID:      incident.AssigneeID,
+			Timestamp:   *incident.AckedAt,
+		})
+	}
+	if incident.ResolvedAt != nil && !seen[models.KeyEventResolved] {
+		events = append(events, models.KeyEvent{
+			Type:        models.KeyEventResolved,
+			Description: "Incident resolved",
+			Timestamp:   *incident.ResolvedAt,
+		})
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Timestamp.Before(events[j].Timestamp)
+	})
+
+	for i := range events {
+		events[i].SinceCreated = int64(events[i].Timestamp.Sub(incident.CreatedAt) / time.Second)
+		if i > 0 {
+			events[i].SincePrevious = int64(events[i].Timestamp.Sub(events[i-1].Timestamp) / time.Second)
+		}
+	}
+
+	return events, nil
 }
 
 // Enhanced Incident Features - Tags
 
 // AddTags adds tags to an incident
-func (s *IncidentService) AddTags(incidentID, userID string, tags []models.TemplateTag) error {
+func (s *IncidentService) AddTags(ctx context.Context, incidentID, userID string, tags []models.TemplateTag) error {
 	// Verify incident exists
-	_, err := s.store.GetIncident(incidentID)
+	_, err := s.store.GetIncident(ctx, incidentID)
 	if err != nil {
 		return fmt.Errorf("incident not found: %w", err)
 	}
@@ -252,7 +987,7 @@ func (s *IncidentService) AddTags(incidentID, userID string, tags []models.Templ
 			CreatedAt:  time.Now(),
 		}
 
-		if err := s.store.CreateIncidentTag(incidentTag); err != nil {
+		if err := s.store.CreateIncidentTag(ctx, incidentTag); err != nil {
 			return fmt.Errorf("failed to create tag %s: %w", tag.Name, err)
 		}
 
@@ -262,16 +997,16 @@ func (s *IncidentService) AddTags(incidentID, userID string, tags []models.Templ
 			"tag_value": tag.Value,
 			"color":     tag.Color,
 		}
-		_, _ = s.AddComment(incidentID, userID, fmt.Sprintf("Added tag: %s", tag.Name), models.CommentTypeTagAdded, metadata)
+		_, _ = s.AddComment(ctx, incidentID, userID, fmt.Sprintf("Added tag: %s", tag.Name), models.CommentTypeTagAdded, metadata)
 	}
 
 	return nil
 }
 
 // RemoveTags removes tags from an incident
-func (s *IncidentService) RemoveTags(incidentID, userID string, tagNames []string) error {
+func (s *IncidentService) RemoveTags(ctx context.Context, incidentID, userID string, tagNames []string) error {
 	for _, tagName := range tagNames {
-		if err := s.store.DeleteIncidentTag(incidentID, tagName); err != nil {
+		if err := s.store.DeleteIncidentTag(ctx, incidentID, tagName); err != nil {
 			return fmt.Errorf("failed to remove tag %s: %w", tagName, err)
 		}
 
@@ -279,42 +1014,68 @@ func (s *IncidentService) RemoveTags(incidentID, userID string, tagNames []strin
 		metadata := map[string]interface{}{
 			"tag_name": tagName,
 		}
-		_, _ = s.AddComment(incidentID, userID, fmt.Sprintf("Removed tag: %s", tagName), models.CommentTypeTagRemoved, metadata)
+		_, _ = s.AddComment(ctx, incidentID, userID, fmt.Sprintf("Removed tag: %s", tagName), models.CommentTypeTagRemoved, metadata)
 	}
 
 	return nil
 }
 
 // GetTags retrieves all tags for an incident
-func (s *IncidentService) GetTags(incidentID string) ([]*models.IncidentTag, error) {
-	return s.store.GetIncidentTags(incidentID)
+func (s *IncidentService) GetTags(ctx context.Context, incidentID string) ([]*models.IncidentTag, error) {
+	return s.store.GetIncidentTags(ctx, incidentID)
 }
 
 // Enhanced Incident Features - Templates
 
 // CreateTemplate creates a new incident template
-func (s *IncidentService) CreateTemplate(template *models.IncidentTemplate) error {
+func (s *IncidentService) CreateTemplate(ctx context.Context, template *models.IncidentTemplate) error {
+	if template.Priority != "" && !template.Priority.Valid() {
+		return ErrInvalidPriority
+	}
 	template.ID = uuid.New().String()
 	template.CreatedAt = time.Now()
 	template.UpdatedAt = time.Now()
 	template.IsActive = true
 
-	return s.store.CreateIncidentTemplate(template)
+	return s.store.CreateIncidentTemplate(ctx, template)
 }
 
 // ListTemplates retrieves all active incident templates
-func (s *IncidentService) ListTemplates() ([]*models.IncidentTemplate, error) {
-	return s.store.ListIncidentTemplates(true) // only active templates
+func (s *IncidentService) ListTemplates(ctx context.Context) ([]*models.IncidentTemplate, error) {
+	return s.store.ListIncidentTemplates(ctx, true) // only active templates
 }
 
 // GetTemplate retrieves a specific incident template
-func (s *IncidentService) GetTemplate(templateID string) (*models.IncidentTemplate, error) {
-	return s.store.GetIncidentTemplate(templateID)
+func (s *IncidentService) GetTemplate(ctx context.Context, templateID string) (*models.IncidentTemplate, error) {
+	return s.store.GetIncidentTemplate(ctx, templateID)
+}
+
+// UpdateTemplate replaces a template's contents. Its ID, creator and
+// creation time are kept.
+func (s *IncidentService) UpdateTemplate(ctx context.Context, templateID string, template *models.IncidentTemplate) error {
+	if template.Priority != "" && !template.Priority.Valid() {
+		return ErrInvalidPriority
+	}
+	existing, err := s.store.GetIncidentTemplate(ctx, templateID)
+	if err != nil {
+		return err
+	}
+
+	template.ID = existing.ID
+	template.CreatedBy = existing.CreatedBy
+	template.CreatedAt = existing.CreatedAt
+	template.UpdatedAt = time.Now()
+	return s.store.UpdateIncidentTemplate(ctx, template)
+}
+
+// DeleteTemplate removes an incident template
+func (s *IncidentService) DeleteTemplate(ctx context.Context, templateID string) error {
+	return s.store.DeleteIncidentTemplate(ctx, templateID)
 }
 
 // UseTemplate creates an incident from a template
-func (s *IncidentService) UseTemplate(req *models.CreateIncidentFromTemplateRequest, userID string) (*models.Incident, error) {
-	template, err := s.store.GetIncidentTemplate(req.TemplateID)
+func (s *IncidentService) UseTemplate(ctx context.Context, req *models.CreateIncidentFromTemplateRequest, userID string) (*models.Incident, error) {
+	template, err := s.store.GetIncidentTemplate(ctx, req.TemplateID)
 	if err != nil {
 		return nil, fmt.Errorf("template not found: %w", err)
 	}
@@ -322,37 +1083,114 @@ func (s *IncidentService) UseTemplate(req *models.CreateIncidentFromTemplateRequ
 	if !template.IsActive {
 		return nil, fmt.Errorf("template is not active")
 	}
+	if err := ValidateTemplateVariables(template, req.Variables); err != nil {
+		return nil, err
+	}
 
 	// Replace variables in title and description
 	title := s.replaceVariables(template.TitleTemplate, req.Variables)
 	description := s.replaceVariables(template.DescriptionTemplate, req.Variables)
 
-	// Create incident
-	incident, err := s.CreateIncident(title, description, template.Severity, []string{})
-	if err != nil {
-		return nil, fmt.Errorf("failed to create incident from template: %w", err)
-	}
+	// The incident, its assignment and its tags are saved in one transaction
+	// so that a failure part way through leaves no half-built incident
+	var incident *models.Incident
+	err = s.store.WithTx(ctx, func(tx storage.Store) error {
+		txService := s.withStore(tx)
 
-	// Assign if specified
-	if req.AssigneeID != nil {
-		if err := s.AssignIncident(incident.ID, *req.AssigneeID, userID); err != nil {
-			// Log error but don't fail the creation
-			fmt.Printf("Failed to assign incident: %v\n", err)
+		var err error
+		incident, err = txService.CreateIncidentWithPriority(ctx, title, description, template.Severity, template.Priority, []string{}, nil)
+		if err != nil {
+			return fmt.Errorf("failed to create incident from template: %w", err)
+		}
+
+		// Assign if specified
+		if req.AssigneeID != nil {
+			if err := txService.AssignIncident(ctx, incident.ID, *req.AssigneeID, userID); err != nil {
+				return fmt.Errorf("failed to assign incident: %w", err)
+			}
 		}
-	}
 
-	// Add default tags
-	allTags := append(template.DefaultTags, req.AdditionalTags...)
-	if len(allTags) > 0 {
-		if err := s.AddTags(incident.ID, userID, allTags); err != nil {
-			// Log error but don't fail the creation
-			fmt.Printf("Failed to add template tags: %v\n", err)
+		// Add default tags
+		allTags := append(template.DefaultTags, req.AdditionalTags...)
+		if len(allTags) > 0 {
+			if err := txService.AddTags(ctx, incident.ID, userID, allTags); err != nil {
+				return fmt.Errorf("failed to add template tags: %w", err)
+			}
 		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return incident, nil
 }
 
+// withStore returns a copy of the service that reads and writes through
+// store, such as the transaction a Store.WithTx callback is given
+func (s *IncidentService) withStore(store storage.Store) *IncidentService {
+	txService := *s
+	txService.store = store
+	return &txService
+}
+
+// templatePlaceholderPattern matches the {{variable}} placeholders
+// replaceVariables fills in
+var templatePlaceholderPattern = regexp.MustCompile(`\{\{([^{}]+)\}\}`)
+
+// PreviewTemplate renders a template with variables as UseTemplate would,
+// without creating an incident
+func (s *IncidentService) PreviewTemplate(ctx context.Context, templateID string, variables map[string]string) (*models.TemplatePreview, error) {
+	template, err := s.store.GetIncidentTemplate(ctx, templateID)
+	if err != nil {
+		return nil, err
+	}
+	if err := ValidateTemplateVariables(template, variables); err != nil {
+		return nil, err
+	}
+
+	title, description, err := s.incidentText(
+		s.replaceVariables(template.TitleTemplate, variables),
+		s.replaceVariables(template.DescriptionTemplate, variables),
+	)
+	if err != nil {
+		return nil, err
+	}
+	priority := template.Priority
+	if priority == "" {
+		priority = models.DefaultPriority(template.Severity)
+	}
+	return &models.TemplatePreview{
+		Title:       title,
+		Description: description,
+		Severity:    template.Severity,
+		Priority:    priority,
+	}, nil
+}
+
+// ValidateTemplateVariables checks that variables supplies every placeholder
+// in the template's title and description. The error lists the missing
+// variables in order of first use; variables the template does not use are
+// ignored.
+func ValidateTemplateVariables(template *models.IncidentTemplate, variables map[string]string) error {
+	var missing []string
+	seen := make(map[string]bool)
+	for _, text := range []string{template.TitleTemplate, template.DescriptionTemplate} {
+		for _, match := range templatePlaceholderPattern.FindAllStringSubmatch(text, -1) {
+			name := match[1]
+			if _, ok := variables[name]; ok || seen[name] {
+				continue
+			}
+			seen[name] = true
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: %s", ErrMissingTemplateVars, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
 // replaceVariables replaces {{variable}} placeholders in text
 func (s *IncidentService) replaceVariables(text string, variables map[string]string) string {
 	result := text
@@ -366,9 +1204,9 @@ func (s *IncidentService) replaceVariables(text string, variables map[string]str
 // Enhanced Incident Features - Attachments
 
 // AttachFile attaches a file to an incident
-func (s *IncidentService) AttachFile(attachment *models.IncidentAttachment, userID string) error {
+func (s *IncidentService) AttachFile(ctx context.Context, attachment *models.IncidentAttachment, userID string) error {
 	// Verify incident exists
-	_, err := s.store.GetIncident(attachment.IncidentID)
+	_, err := s.store.GetIncident(ctx, attachment.IncidentID)
 	if err != nil {
 		return fmt.Errorf("incident not found: %w", err)
 	}
@@ -377,7 +1215,7 @@ func (s *IncidentService) AttachFile(attachment *models.IncidentAttachment, user
 	attachment.UploadedBy = &userID
 	attachment.CreatedAt = time.Now()
 
-	if err := s.store.CreateIncidentAttachment(attachment); err != nil {
+	if err := s.store.CreateIncidentAttachment(ctx, attachment); err != nil {
 		return fmt.Errorf("failed to attach file: %w", err)
 	}
 
@@ -388,24 +1226,34 @@ func (s *IncidentService) AttachFile(attachment *models.IncidentAttachment, user
 		"attachment_type": attachment.AttachmentType,
 		"attachment_id":   attachment.ID,
 	}
-	_, _ = s.AddComment(attachment.IncidentID, userID, fmt.Sprintf("Attached file: %s", attachment.OriginalName), models.CommentTypeAttachmentAdded, metadata)
+	_, _ = s.AddComment(ctx, attachment.IncidentID, userID, fmt.Sprintf("Attached file: %s", attachment.OriginalName), models.CommentTypeAttachmentAdded, metadata)
 
 	return nil
 }
 
 // GetAttachments retrieves all attachments for an incident
-func (s *IncidentService) GetAttachments(incidentID string) ([]*models.IncidentAttachment, error) {
-	return s.store.GetIncidentAttachments(incidentID)
+func (s *IncidentService) GetAttachments(ctx context.Context, incidentID string) ([]*models.IncidentAttachment, error) {
+	attachments, err := s.store.GetIncidentAttachments(ctx, incidentID)
+	if err != nil {
+		return nil, err
+	}
+	for _, attachment := range attachments {
+		s.setDownloadURL(attachment)
+	}
+	return attachments, nil
 }
 
 // Enhanced Incident Features - Search and Filtering
 
 // SearchIncidents performs full-text search and filtering on incidents
-func (s *IncidentService) SearchIncidents(req *models.IncidentSearchRequest) (*models.IncidentSearchResponse, error) {
-	incidents, total, err := s.store.SearchIncidents(req)
+func (s *IncidentService) SearchIncidents(ctx context.Context, req *models.IncidentSearchRequest) (*models.IncidentSearchResponse, error) {
+	req.AttentionCutoff = time.Now().Add(-s.attentionThreshold)
+
+	incidents, total, err := s.store.SearchIncidents(ctx, req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to search incidents: %w", err)
 	}
+	s.markComputed(incidents)
 
 	totalPages := (total + req.Limit - 1) / req.Limit
 
@@ -421,19 +1269,30 @@ func (s *IncidentService) SearchIncidents(req *models.IncidentSearchRequest) (*m
 // Enhanced Incident Features - Bulk Operations
 
 // BulkAcknowledge acknowledges multiple incidents
-func (s *IncidentService) BulkAcknowledge(incidentIDs []string, assigneeID, userID string) (*models.BulkOperationResponse, error) {
+func (s *IncidentService) BulkAcknowledge(ctx context.Context, incidentIDs []string, assigneeID, userID string) (*models.BulkOperationResponse, error) {
 	return s.performBulkOperation(incidentIDs, func(incidentID string) error {
-		return s.AcknowledgeIncident(incidentID, assigneeID)
+		return s.AcknowledgeIncident(ctx, incidentID, assigneeID)
 	})
 }
 
 // BulkUpdateStatus updates status for multiple incidents
-func (s *IncidentService) BulkUpdateStatus(incidentIDs []string, status models.IncidentStatus, userID string) (*models.BulkOperationResponse, error) {
+func (s *IncidentService) BulkUpdateStatus(ctx context.Context, incidentIDs []string, status models.IncidentStatus, userID string) (*models.BulkOperationResponse, error) {
 	return s.performBulkOperation(incidentIDs, func(incidentID string) error {
-		incident, err := s.store.GetIncident(incidentID)
+		// Bulk updates carry no note or resolution type to resolve with
+		if status == models.IncidentStatusResolved && s.requireResolutionNote {
+			return ErrResolutionNoteRequired
+		}
+		if status == models.IncidentStatusResolved && s.requireResolutionType {
+			return ErrResolutionTypeRequired
+		}
+
+		incident, err := s.store.GetIncident(ctx, incidentID)
 		if err != nil {
 			return err
 		}
+		if err := validateTransition(incident.Status, status); err != nil {
+			return err
+		}
 
 		oldStatus := incident.Status
 		incident.Status = status
@@ -444,21 +1303,53 @@ func (s *IncidentService) BulkUpdateStatus(incidentIDs []string, status models.I
 			incident.ResolvedAt = &now
 		}
 
-		if err := s.store.UpdateIncident(incident); err != nil {
+		if err := s.store.UpdateIncident(ctx, incident); err != nil {
 			return err
 		}
+		s.statusChanged(incident, oldStatus)
 
 		// Add timeline entry
 		metadata := map[string]interface{}{
 			"old_status": oldStatus,
 			"new_status": status,
 		}
-		_, _ = s.AddComment(incidentID, userID, fmt.Sprintf("Status changed from %s to %s", oldStatus, status), models.CommentTypeStatusChange, metadata)
+		_, _ = s.AddComment(ctx, incidentID, userID, fmt.Sprintf("Status changed from %s to %s", oldStatus, status), models.CommentTypeStatusChange, metadata)
 
 		return nil
 	})
 }
 
+// BulkResolve resolves multiple incidents with the same resolution
+func (s *IncidentService) BulkResolve(ctx context.Context, incidentIDs []string, userID string, resolution models.Resolution) (*models.BulkOperationResponse, error) {
+	return s.performBulkOperation(incidentIDs, func(incidentID string) error {
+		return s.ResolveIncidentWithDetails(ctx, incidentID, userID, resolution)
+	})
+}
+
+// BulkAssign assigns multiple incidents to the same user
+func (s *IncidentService) BulkAssign(ctx context.Context, incidentIDs []string, assigneeID, userID string) (*models.BulkOperationResponse, error) {
+	return s.performBulkOperation(incidentIDs, func(incidentID string) error {
+		return s.AssignIncident(ctx, incidentID, assigneeID, userID)
+	})
+}
+
+// BulkAddTags adds the same tags to multiple incidents
+func (s *IncidentService) BulkAddTags(ctx context.Context, incidentIDs []string, tags []models.TemplateTag, userID string) (*models.BulkOperationResponse, error) {
+	return s.performBulkOperation(incidentIDs, func(incidentID string) error {
+		return s.AddTags(ctx, incidentID, userID, tags)
+	})
+}
+
+// BulkRemoveTags removes the named tags from multiple incidents
+func (s *IncidentService) BulkRemoveTags(ctx context.Context, incidentIDs []string, tagNames []string, userID string) (*models.BulkOperationResponse, error) {
+	return s.performBulkOperation(incidentIDs, func(incidentID string) error {
+		if _, err := s.store.GetIncident(ctx, incidentID); err != nil {
+			return fmt.Errorf("incident not found: %w", err)
+		}
+		return s.RemoveTags(ctx, incidentID, userID, tagNames)
+	})
+}
+
 // performBulkOperation executes a bulk operation on incidents
 func (s *IncidentService) performBulkOperation(incidentIDs []string, operation func(string) error) (*models.BulkOperationResponse, error) {
 	response := &models.BulkOperationResponse{}
@@ -481,17 +1372,21 @@ func (s *IncidentService) performBulkOperation(incidentIDs []string, operation f
 // Enhanced Incident Features - Assignment
 
 // AssignIncident assigns an incident to a user
-func (s *IncidentService) AssignIncident(incidentID, assigneeID, userID string) error {
-	incident, err := s.store.GetIncident(incidentID)
+func (s *IncidentService) AssignIncident(ctx context.Context, incidentID, assigneeID, userID string) error {
+	incident, err := s.store.GetIncident(ctx, incidentID)
 	if err != nil {
 		return fmt.Errorf("incident not found: %w", err)
 	}
 
+	if err := s.validateAssignee(ctx, assigneeID); err != nil {
+		return err
+	}
+
 	oldAssigneeID := incident.AssigneeID
 	incident.AssigneeID = assigneeID
 	incident.UpdatedAt = time.Now()
 
-	if err := s.store.UpdateIncident(incident); err != nil {
+	if err := s.store.UpdateIncident(ctx, incident); err != nil {
 		return fmt.Errorf("failed to assign incident: %w", err)
 	}
 
@@ -504,12 +1399,100 @@ func (s *IncidentService) AssignIncident(incidentID, assigneeID, userID string)
 	if oldAssigneeID != "" {
 		action = "reassigned"
 	}
-	_, _ = s.AddComment(incidentID, userID, fmt.Sprintf("Incident %s to user %s", action, assigneeID), models.CommentTypeAssignment, metadata)
+	_, _ = s.AddComment(ctx, incidentID, userID, fmt.Sprintf("Incident %s to user %s", action, assigneeID), models.CommentTypeAssignment, metadata)
 
 	return nil
 }
 
+// SetPriority changes an incident's priority and records the change on the
+// timeline
+func (s *IncidentService) SetPriority(ctx context.Context, incidentID, userID string, priority models.IncidentPriority) error {
+	if !priority.Valid() {
+		return ErrInvalidPriority
+	}
+
+	incident, err := s.store.GetIncident(ctx, incidentID)
+	if err != nil {
+		return err
+	}
+	if incident.Priority == priority {
+		return nil
+	}
+
+	oldPriority := incident.Priority
+	incident.Priority = priority
+	incident.UpdatedAt = time.Now()
+	if err := s.store.UpdateIncident(ctx, incident); err != nil {
+		return fmt.Errorf("failed to update priority: %w", err)
+	}
+
+	metadata := map[string]interface{}{
+		"old_priority": oldPriority,
+		"new_priority": priority,
+	}
+	_, err = s.AddComment(ctx, incidentID, userID, fmt.Sprintf("Priority changed from %s to %s", oldPriority, priority),
+		models.CommentTypePriorityChange, metadata)
+	return err
+}
+
+// validateAssignee checks that the assignee holds one of the assignable roles
+func (s *IncidentService) validateAssignee(ctx context.Context, assigneeID string) error {
+	if len(s.assignableRoles) == 0 {
+		return nil
+	}
+
+	if _, err := s.store.GetUser(ctx, assigneeID); err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return ErrAssigneeNotFound
+		}
+		return fmt.Errorf("failed to look up assignee: %w", err)
+	}
+
+	roles, err := s.store.GetUserRoles(ctx, assigneeID)
+	if err != nil {
+		return fmt.Errorf("failed to load assignee roles: %w", err)
+	}
+
+	for _, role := range roles {
+		for _, assignable := range s.assignableRoles {
+			if role.Name == assignable {
+				return nil
+			}
+		}
+	}
+
+	return fmt.Errorf("%w (allowed roles: %s)", ErrAssigneeNotAssignable, strings.Join(s.assignableRoles, ", "))
+}
+
 // ReassignIncident reassigns an incident to a different user
-func (s *IncidentService) ReassignIncident(incidentID, newAssigneeID, userID string) error {
-	return s.AssignIncident(incidentID, newAssigneeID, userID)
-}
\ No newline at end of file
+func (s *IncidentService) ReassignIncident(ctx context.Context, incidentID, newAssigneeID, userID string) error {
+	return s.AssignIncident(ctx, incidentID, newAssigneeID, userID)
+}
+
+// sanitizeText removes control characters. Multiline text keeps newlines and
+// tabs; single-line text has them replaced with spaces.
+func sanitizeText(text string, multiline bool) string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == '\n' || r == '\t':
+			if multiline {
+				return r
+			}
+			return ' '
+		case unicode.IsControl(r), r == utf8.RuneError:
+			return -1
+		}
+		return r
+	}, text)
+}
+
+// truncateText shortens text to at most max characters, ending with an
+// ellipsis when it had to be cut
+func truncateText(text string, max int) string {
+	if max <= 0 || utf8.RuneCountInString(text) <= max {
+		return text
+	}
+	runes := []rune(text)
+	return strings.TrimRightFunc(string(runes[:max-1]), unicode.IsSpace) + "…"
+}
@@ -0,0 +1,195 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/models"
+)
+
+var (
+	ErrNoDuplicates   = errors.New("at least one duplicate incident is required")
+	ErrMergeIntoSelf  = errors.New("an incident cannot be merged into itself")
+	ErrIncidentMerged = errors.New("incident has already been merged")
+)
+
+// MergeIncidents folds duplicate incidents into the primary one. Each
+// duplicate's alerts are moved to the primary, its comments and tags are
+// copied over, and it is resolved as a duplicate pointing at the primary.
+// Both sides get a timeline entry recording the merge.
+func (s *IncidentService) MergeIncidents(ctx context.Context, primaryID string, duplicateIDs []string, userID string) error {
+	if len(duplicateIDs) == 0 {
+		return ErrNoDuplicates
+	}
+
+	primary, err := s.store.GetIncident(ctx, primaryID)
+	if err != nil {
+		return err
+	}
+	if primary.MergedInto != "" {
+		return fmt.Errorf("%w: %s", ErrIncidentMerged, primary.ID)
+	}
+
+	// Check every duplicate before changing anything
+	seen := make(map[string]bool)
+	var duplicates []*models.Incident
+	for _, id := range duplicateIDs {
+		if id == primaryID {
+			return ErrMergeIntoSelf
+		}
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		duplicate, err := s.store.GetIncident(ctx, id)
+		if err != nil {
+			return err
+		}
+		if duplicate.MergedInto != "" {
+			return fmt.Errorf("%w: %s", ErrIncidentMerged, duplicate.ID)
+		}
+		duplicates = append(duplicates, duplicate)
+	}
+
+	for _, duplicate := range duplicates {
+		if err := s.mergeIncident(ctx, primary, duplicate, userID); err != nil {
+			return fmt.Errorf("failed to merge incident %s: %w", duplicate.ID, err)
+		}
+	}
+	return nil
+}
+
+// mergeIncident moves one duplicate into primary
+func (s *IncidentService) mergeIncident(ctx context.Context, primary, duplicate *models.Incident, userID string) error {
+	now := time.Now()
+
+	// Alerts follow the incident they now belong to
+	for _, alertID := range duplicate.AlertIDs {
+		if alert, err := s.store.GetAlert(ctx, alertID); err == nil {
+			alert.IncidentID = primary.ID
+			if err := s.store.UpdateAlert(ctx, alert); err != nil {
+				return fmt.Errorf("failed to reassign alert %s: %w", alertID, err)
+			}
+		}
+		if !slices.Contains(primary.AlertIDs, alertID) {
+			primary.AlertIDs = append(primary.AlertIDs, alertID)
+		}
+	}
+	primary.OverflowAlertCount += duplicate.OverflowAlertCount
+	primary.UpdatedAt = now
+	if err := s.store.UpdateIncident(ctx, primary); err != nil {
+		return err
+	}
+
+	if err := s.copyComments(ctx, primary.ID, duplicate.ID); err != nil {
+		return err
+	}
+	if err := s.copyTags(ctx, primary.ID, duplicate.ID); err != nil {
+		return err
+	}
+
+	previous := duplicate.Status
+	duplicate.AlertIDs = []string{}
+	duplicate.OverflowAlertCount = 0
+	duplicate.MergedInto = primary.ID
+	duplicate.Status = models.IncidentStatusResolved
+	duplicate.ResolutionType = models.ResolutionDuplicate
+	if duplicate.ResolvedAt == nil {
+		duplicate.ResolvedAt = &now
+	}
+	duplicate.UpdatedAt = now
+	if err := s.store.UpdateIncident(ctx, duplicate); err != nil {
+		return err
+	}
+	if previous != models.IncidentStatusResolved {
+		if s.metricsService != nil {
+			s.metricsService.RecordIncidentResolved(string(duplicate.Severity), now.Sub(duplicate.CreatedAt))
+		}
+		s.statusChanged(duplicate, previous)
+	}
+
+	metadata := map[string]interface{}{
+		"old_status":      previous,
+		"new_status":      models.IncidentStatusResolved,
+		"resolution_type": models.ResolutionDuplicate,
+		"merged_into":     primary.ID,
+	}
+	if _, err := s.AddComment(ctx, duplicate.ID, userID, fmt.Sprintf("Merged into incident %s", primary.ID),
+		models.CommentTypeStatusChange, metadata); err != nil {
+		return err
+	}
+	_, err := s.AddComment(ctx, primary.ID, userID, fmt.Sprintf("Merged incident %s: %s", duplicate.ID, duplicate.Title),
+		models.CommentTypeStatusChange, map[string]interface{}{"merged_from": duplicate.ID})
+	return err
+}
+
+// copyComments copies the comments people wrote on one incident to another,
+// keeping their authors and times. Timeline events are not copied.
+func (s *IncidentService) copyComments(ctx context.Context, toID, fromID string) error {
+	comments, err := s.store.GetIncidentComments(ctx, fromID)
+	if err != nil {
+		return err
+	}
+	for _, comment := range comments {
+		if comment.CommentType != models.CommentTypeComment {
+			continue
+		}
+		metadata := make(map[string]interface{})
+		for key, value := range comment.Metadata {
+			metadata[key] = value
+		}
+		metadata["merged_from"] = fromID
+		copied := *comment
+		copied.ID = uuid.New().String()
+		copied.IncidentID = toID
+		copied.Metadata = metadata
+		if err := s.store.CreateIncidentComment(ctx, &copied); err != nil {
+			return fmt.Errorf("failed to copy comment %s: %w", comment.ID, err)
+		}
+	}
+	return nil
+}
+
+// copyTags copies the tags of one incident to another, skipping tags the
+// target already has
+func (s *IncidentService) copyTags(ctx context.Context, toID, fromID string) error {
+	existing, err := s.store.GetIncidentTags(ctx, toID)
+	if err != nil {
+		return err
+	}
+	has := make(map[string]bool)
+	for _, tag := range existing {
+		has[tagKey(tag)] = true
+	}
+
+	tags, err := s.store.GetIncidentTags(ctx, fromID)
+	if err != nil {
+		return err
+	}
+	for _, tag := range tags {
+		if has[tagKey(tag)] {
+			continue
+		}
+		has[tagKey(tag)] = true
+		copied := *tag
+		copied.ID = uuid.New().String()
+		copied.IncidentID = toID
+		if err := s.store.CreateIncidentTag(ctx, &copied); err != nil {
+			return fmt.Errorf("failed to copy tag %s: %w", tag.TagName, err)
+		}
+	}
+	return nil
+}
+
+// tagKey identifies a tag by name and value
+func tagKey(tag *models.IncidentTag) string {
+	if tag.TagValue == nil {
+		return tag.TagName
+	}
+	return tag.TagName + "=" + *tag.TagValue
+}
@@ -0,0 +1,118 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/models"
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/storage"
+)
+
+func TestMergeIncidents(t *testing.T) {
+	ctx := context.Background()
+	store, err := storage.NewMemoryStore()
+	if err != nil {
+		t.Fatalf("Failed to create memory store: %v", err)
+	}
+	incidentService := NewIncidentService(store, NewMetricsService(prometheus.NewRegistry()))
+
+	newIncident := func(title string, alertIDs ...string) *models.Incident {
+		for _, alertID := range alertIDs {
+			alert := &models.Alert{ID: alertID, Fingerprint: alertID, Status: "firing", StartsAt: time.Now(), CreatedAt: time.Now()}
+			if err := store.CreateAlert(ctx, alert); err != nil {
+				t.Fatalf("Failed to create alert: %v", err)
+			}
+		}
+		incident, err := incidentService.CreateIncident(ctx, title, "", models.SeverityHigh, alertIDs)
+		if err != nil {
+			t.Fatalf("Failed to create incident: %v", err)
+		}
+		for _, alertID := range alertIDs {
+			alert, _ := store.GetAlert(ctx, alertID)
+			alert.IncidentID = incident.ID
+			store.UpdateAlert(ctx, alert)
+		}
+		return incident
+	}
+
+	primary := newIncident("API errors", "alert-1")
+	duplicate := newIncident("API 500s", "alert-2", "alert-3")
+	if _, err := incidentService.AddComment(ctx, duplicate.ID, "user-2", "Seeing this in eu-west too", models.CommentTypeComment, nil); err != nil {
+		t.Fatalf("Failed to add comment: %v", err)
+	}
+	if err := incidentService.AddTags(ctx, duplicate.ID, "user-2", []models.TemplateTag{{Name: "region", Value: "eu-west", Color: "#ff0000"}}); err != nil {
+		t.Fatalf("Failed to add tag: %v", err)
+	}
+
+	if err := incidentService.MergeIncidents(ctx, primary.ID, []string{duplicate.ID}, "user-1"); err != nil {
+		t.Fatalf("MergeIncidents failed: %v", err)
+	}
+
+	merged, _ := store.GetIncident(ctx, primary.ID)
+	if len(merged.AlertIDs) != 3 {
+		t.Errorf("Expected the primary to hold 3 alerts, got %v", merged.AlertIDs)
+	}
+	for _, alertID := range []string{"alert-2", "alert-3"} {
+		alert, _ := store.GetAlert(ctx, alertID)
+		if alert.IncidentID != primary.ID {
+			t.Errorf("Expected %s to be reassigned to %s, got %q", alertID, primary.ID, alert.IncidentID)
+		}
+	}
+	if merged.Status != models.IncidentStatusOpen {
+		t.Errorf("Expected the primary to stay open, got %s", merged.Status)
+	}
+
+	resolved, _ := store.GetIncident(ctx, duplicate.ID)
+	if resolved.Status != models.IncidentStatusResolved || resolved.ResolvedAt == nil {
+		t.Errorf("Expected the duplicate to be resolved, got %s", resolved.Status)
+	}
+	if resolved.MergedInto != primary.ID || resolved.ResolutionType != models.ResolutionDuplicate {
+		t.Errorf("Expected the duplicate to be merged into %s as a duplicate, got %q (%s)", primary.ID, resolved.MergedInto, resolved.ResolutionType)
+	}
+	if len(resolved.AlertIDs) != 0 {
+		t.Errorf("Expected the duplicate to give up its alerts, got %v", resolved.AlertIDs)
+	}
+
+	comments, _ := store.GetIncidentComments(ctx, primary.ID)
+	var copied, mergeEntry bool
+	for _, comment := range comments {
+		switch {
+		case comment.CommentType == models.CommentTypeComment && comment.Content == "Seeing this in eu-west too":
+			copied = comment.UserID != nil && *comment.UserID == "user-2" && comment.Metadata["merged_from"] == duplicate.ID
+		case comment.CommentType == models.CommentTypeStatusChange && comment.Metadata["merged_from"] == duplicate.ID:
+			mergeEntry = true
+		}
+	}
+	if !copied {
+		t.Error("Expected the duplicate's comment to be copied with its author")
+	}
+	if !mergeEntry {
+		t.Error("Expected a timeline entry on the primary recording the merge")
+	}
+
+	timeline, _ := store.GetIncidentComments(ctx, duplicate.ID)
+	if last := timeline[len(timeline)-1]; last.Metadata["merged_into"] != primary.ID {
+		t.Errorf("Expected a timeline entry on the duplicate pointing at the primary, got %+v", last)
+	}
+
+	tags, _ := store.GetIncidentTags(ctx, primary.ID)
+	if len(tags) != 1 || tags[0].TagName != "region" {
+		t.Errorf("Expected the region tag to be copied, got %+v", tags)
+	}
+
+	// Merged incidents cannot be merged again, and nothing merges into itself
+	other := newIncident("API timeouts")
+	if err := incidentService.MergeIncidents(ctx, other.ID, []string{duplicate.ID}, "user-1"); !errors.Is(err, ErrIncidentMerged) {
+		t.Errorf("Expected ErrIncidentMerged, got %v", err)
+	}
+	if err := incidentService.MergeIncidents(ctx, other.ID, []string{other.ID}, "user-1"); !errors.Is(err, ErrMergeIntoSelf) {
+		t.Errorf("Expected ErrMergeIntoSelf, got %v", err)
+	}
+	if err := incidentService.MergeIncidents(ctx, other.ID, []string{"missing"}, "user-1"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
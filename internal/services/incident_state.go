@@ -0,0 +1,97 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/models"
+)
+
+// ErrInvalidTransition is matched by every TransitionError
+var ErrInvalidTransition = errors.New("invalid incident status transition")
+
+// TransitionError is returned when an incident cannot move from its current
+// status to the requested one
+type TransitionError struct {
+	From models.IncidentStatus
+	To   models.IncidentStatus
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("cannot change incident status from %s to %s", e.From, e.To)
+}
+
+// Is makes errors.Is(err, ErrInvalidTransition) match any TransitionError
+func (e *TransitionError) Is(target error) bool {
+	return target == ErrInvalidTransition
+}
+
+// incidentTransitions lists the statuses each status may move to. Incidents
+// go open → acknowledged → resolved and only leave resolved by being
+// reopened. Open → resolved is also allowed on purpose: alert auto-resolve
+// closes incidents nobody acknowledged, and a responder may close a false
+// alarm without acknowledging it first.
+var incidentTransitions = map[models.IncidentStatus][]models.IncidentStatus{
+	models.IncidentStatusOpen: {
+		models.IncidentStatusAcknowledged,
+		// Resolving without acknowledging, see above
+		models.IncidentStatusResolved,
+	},
+	models.IncidentStatusAcknowledged: {models.IncidentStatusResolved},
+	models.IncidentStatusResolved:     {models.IncidentStatusOpen},
+}
+
+// CanTransition reports whether an incident may move from one status to
+// another
+func CanTransition(from, to models.IncidentStatus) bool {
+	for _, allowed := range incidentTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// validateTransition returns a TransitionError for an illegal status change
+func validateTransition(from, to models.IncidentStatus) error {
+	if !CanTransition(from, to) {
+		return &TransitionError{From: from, To: to}
+	}
+	return nil
+}
+
+// ReopenIncident moves a resolved incident back to open, clears its
+// resolution time and classification, and records who reopened it on the
+// timeline
+func (s *IncidentService) ReopenIncident(ctx context.Context, id, userID string) error {
+	incident, err := s.store.GetIncident(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := validateTransition(incident.Status, models.IncidentStatusOpen); err != nil {
+		return err
+	}
+
+	previous := incident.Status
+	incident.Status = models.IncidentStatusOpen
+	incident.ResolvedAt = nil
+	incident.ResolutionType = ""
+	incident.RootCauseCategory = ""
+	incident.UpdatedAt = time.Now()
+
+	if err := s.store.UpdateIncident(ctx, incident); err != nil {
+		return err
+	}
+	s.statusChanged(incident, previous)
+
+	metadata := map[string]interface{}{
+		"old_status":  previous,
+		"new_status":  models.IncidentStatusOpen,
+		"reopened_by": userID,
+	}
+	_, err = s.AddComment(ctx, id, userID, fmt.Sprintf("Reopened by %s", s.userDisplayName(ctx, userID)),
+		models.CommentTypeStatusChange, metadata)
+	return err
+}
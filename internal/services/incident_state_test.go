@@ -0,0 +1,167 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/models"
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/storage"
+)
+
+func TestIncidentTransitions(t *testing.T) {
+	ctx := context.Background()
+	open, acknowledged, resolved := models.IncidentStatusOpen, models.IncidentStatusAcknowledged, models.IncidentStatusResolved
+
+	tests := []struct {
+		from, to models.IncidentStatus
+		legal    bool
+	}{
+		{open, acknowledged, true},
+		{open, resolved, true},
+		{acknowledged, resolved, true},
+		{resolved, open, true},
+		{open, open, false},
+		{acknowledged, open, false},
+		{acknowledged, acknowledged, false},
+		{resolved, acknowledged, false},
+		{resolved, resolved, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s to %s", tt.from, tt.to), func(t *testing.T) {
+			if got := CanTransition(tt.from, tt.to); got != tt.legal {
+				t.Errorf("CanTransition(%s, %s) = %v, want %v", tt.from, tt.to, got, tt.legal)
+			}
+
+			// Drive the same transition through the service
+			store, err := storage.NewMemoryStore()
+			if err != nil {
+				t.Fatalf("Failed to create memory store: %v", err)
+			}
+			incidentService := NewIncidentService(store, NewMetricsService(prometheus.NewRegistry()))
+			incident := &models.Incident{ID: "incident-1", Title: "Disk full", Status: tt.from, Severity: models.SeverityHigh,
+				CreatedAt: time.Now(), UpdatedAt: time.Now(), Labels: map[string]string{}}
+			if err := store.CreateIncident(ctx, incident); err != nil {
+				t.Fatalf("Failed to create incident: %v", err)
+			}
+
+			switch tt.to {
+			case acknowledged:
+				err = incidentService.AcknowledgeIncident(ctx, incident.ID, "user-1")
+			case resolved:
+				err = incidentService.ResolveIncident(ctx, incident.ID, "user-1", "")
+			case open:
+				err = incidentService.ReopenIncident(ctx, incident.ID, "user-1")
+			}
+
+			stored, _ := store.GetIncident(ctx, incident.ID)
+			if tt.legal {
+				if err != nil {
+					t.Fatalf("Expected the transition to succeed, got %v", err)
+				}
+				if stored.Status != tt.to {
+					t.Errorf("Expected status %s, got %s", tt.to, stored.Status)
+				}
+				return
+			}
+
+			var transitionErr *TransitionError
+			if !errors.As(err, &transitionErr) || !errors.Is(err, ErrInvalidTransition) {
+				t.Fatalf("Expected a TransitionError, got %v", err)
+			}
+			if transitionErr.From != tt.from || transitionErr.To != tt.to {
+				t.Errorf("Expected the error to name %s to %s, got %+v", tt.from, tt.to, transitionErr)
+			}
+			if stored.Status != tt.from {
+				t.Errorf("Expected the status to stay %s, got %s", tt.from, stored.Status)
+			}
+		})
+	}
+}
+
+// Open → resolved goes beyond open → acknowledged → resolved so that alerts
+// resolving can close an incident nobody acknowledged
+func TestIncidentTransitions_ResolveWithoutAcknowledging(t *testing.T) {
+	ctx := context.Background()
+	alertService, incidentService, store := setupTestAlertService(t)
+	alertService.SetAutoResolve(true)
+
+	if err := alertService.ProcessAlertmanagerWebhook(ctx, &AlertmanagerWebhook{
+		Alerts: []AlertmanagerAlert{testAlert("fp-unacked", "firing", "critical")},
+	}); err != nil {
+		t.Fatalf("Failed to process firing alert: %v", err)
+	}
+	incidents, _ := store.ListIncidents(ctx)
+	if len(incidents) != 1 || incidents[0].Status != models.IncidentStatusOpen {
+		t.Fatalf("Expected one open incident, got %+v", incidents)
+	}
+	if err := alertService.ProcessAlertmanagerWebhook(ctx, &AlertmanagerWebhook{
+		Alerts: []AlertmanagerAlert{testAlert("fp-unacked", "resolved", "critical")},
+	}); err != nil {
+		t.Fatalf("Failed to process resolved alert: %v", err)
+	}
+	if resolved, _ := store.GetIncident(ctx, incidents[0].ID); resolved.Status != models.IncidentStatusResolved {
+		t.Errorf("Expected auto-resolve to close the unacknowledged incident, got %s", resolved.Status)
+	}
+
+	// A responder can close a false alarm the same way
+	incident, err := incidentService.CreateIncident(ctx, "False alarm", "", models.SeverityLow, nil)
+	if err != nil {
+		t.Fatalf("Failed to create incident: %v", err)
+	}
+	if err := incidentService.ResolveIncident(ctx, incident.ID, "user-1", ""); err != nil {
+		t.Errorf("Expected an open incident to be resolvable directly, got %v", err)
+	}
+}
+
+func TestReopenIncident_ClearsResolution(t *testing.T) {
+	ctx := context.Background()
+	store, err := storage.NewMemoryStore()
+	if err != nil {
+		t.Fatalf("Failed to create memory store: %v", err)
+	}
+	incidentService := NewIncidentService(store, NewMetricsService(prometheus.NewRegistry()))
+
+	incident, err := incidentService.CreateIncident(ctx, "Checkout errors", "", models.SeverityCritical, nil)
+	if err != nil {
+		t.Fatalf("Failed to create incident: %v", err)
+	}
+	if err := incidentService.ResolveIncident(ctx, incident.ID, "user-1", ""); err != nil {
+		t.Fatalf("Failed to resolve incident: %v", err)
+	}
+	if err := incidentService.ReopenIncident(ctx, incident.ID, "user-1"); err != nil {
+		t.Fatalf("Failed to reopen incident: %v", err)
+	}
+
+	reopened, _ := store.GetIncident(ctx, incident.ID)
+	if reopened.Status != models.IncidentStatusOpen || reopened.ResolvedAt != nil {
+		t.Errorf("Expected an open incident without a resolution time, got %s (resolved at %v)", reopened.Status, reopened.ResolvedAt)
+	}
+
+	timeline, err := incidentService.GetTimeline(ctx, incident.ID)
+	if err != nil {
+		t.Fatalf("Failed to get timeline: %v", err)
+	}
+	var entry *models.IncidentComment
+	for _, comment := range timeline {
+		if comment.CommentType == models.CommentTypeStatusChange && comment.Metadata["new_status"] == models.IncidentStatusOpen {
+			entry = comment
+		}
+	}
+	if entry == nil {
+		t.Fatal("Expected a status change entry for the reopen")
+	}
+	if entry.UserID == nil || *entry.UserID != "user-1" || entry.Metadata["reopened_by"] != "user-1" {
+		t.Errorf("Expected the entry to name user-1 as the reopener, got %+v", entry)
+	}
+
+	// A reopened incident follows the normal lifecycle again
+	if err := incidentService.AcknowledgeIncident(ctx, incident.ID, "user-2"); err != nil {
+		t.Errorf("Expected a reopened incident to be acknowledgeable, got %v", err)
+	}
+}
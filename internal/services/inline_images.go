@@ -0,0 +1,111 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/models"
+)
+
+// ErrInlineImageTooLarge is returned when a comment embeds an image larger
+// than the inline image limit
+var ErrInlineImageTooLarge = errors.New("inline image is too large")
+
+// ErrInvalidInlineImage is returned when an embedded data URI is not valid base64
+var ErrInvalidInlineImage = errors.New("inline image is not valid base64")
+
+// Defaults for where attachments are written and how large one image pasted
+// into a comment may be
+const (
+	DefaultAttachmentDir       = "data/attachments"
+	DefaultMaxInlineImageBytes = 5 << 20
+)
+
+// dataURIImagePattern matches base64 data URIs of the image types browsers
+// paste, with or without surrounding markdown image syntax
+var dataURIImagePattern = regexp.MustCompile(`data:image/(png|jpeg|gif|webp);base64,([A-Za-z0-9+/]+={0,2})`)
+
+// SetInlineImageStorage sets the directory pasted comment images are written
+// to and the size limit per image. Empty or non-positive values keep the
+// current settings.
+func (s *IncidentService) SetInlineImageStorage(dir string, maxBytes int64) {
+	if dir != "" {
+		s.attachmentDir = dir
+	}
+	if maxBytes > 0 {
+		s.maxInlineImageBytes = maxBytes
+	}
+}
+
+// ExtractInlineImages stores every data URI image embedded in comment content
+// as a screenshot attachment of the incident and returns the content with
+// each data URI replaced by the attachment's download URL. Images are checked
+// against the size limit before anything is written.
+func (s *IncidentService) ExtractInlineImages(ctx context.Context, incidentID, userID, content string) (string, []*models.IncidentAttachment, error) {
+	matches := dataURIImagePattern.FindAllStringSubmatchIndex(content, -1)
+	if len(matches) == 0 {
+		return content, nil, nil
+	}
+
+	images := make([][]byte, len(matches))
+	for i, match := range matches {
+		encoded := content[match[4]:match[5]]
+		if int64(base64.StdEncoding.DecodedLen(len(encoded))) > s.maxInlineImageBytes+2 {
+			return "", nil, ErrInlineImageTooLarge
+		}
+		data, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return "", nil, ErrInvalidInlineImage
+		}
+		if int64(len(data)) > s.maxInlineImageBytes {
+			return "", nil, ErrInlineImageTooLarge
+		}
+		images[i] = data
+	}
+
+	storage := s.storage()
+	var rewritten strings.Builder
+	attachments := make([]*models.IncidentAttachment, 0, len(matches))
+	last := 0
+	for i, match := range matches {
+		ext := content[match[2]:match[3]]
+		attachment := &models.IncidentAttachment{
+			IncidentID:     incidentID,
+			FileName:       uuid.New().String() + "." + ext,
+			OriginalName:   fmt.Sprintf("pasted-image-%d.%s", i+1, ext),
+			FileSize:       int64(len(images[i])),
+			MimeType:       "image/" + ext,
+			AttachmentType: models.AttachmentTypeScreenshot,
+		}
+		key := attachmentKey(attachment)
+		filePath, err := storage.Put(key, images[i], attachment.MimeType)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to store inline image: %w", err)
+		}
+		attachment.FilePath = filePath
+		if err := s.AttachFile(ctx, attachment, userID); err != nil {
+			storage.Delete(key)
+			return "", nil, err
+		}
+		// The comment links to the API, which outlives any signed URL
+		attachment.DownloadURL = AttachmentDownloadURL(attachment)
+		attachments = append(attachments, attachment)
+
+		rewritten.WriteString(content[last:match[0]])
+		rewritten.WriteString(attachment.DownloadURL)
+		last = match[1]
+	}
+	rewritten.WriteString(content[last:])
+
+	return rewritten.String(), attachments, nil
+}
+
+// AttachmentDownloadURL returns the API path an attachment is served from
+func AttachmentDownloadURL(attachment *models.IncidentAttachment) string {
+	return fmt.Sprintf("/api/incidents/%s/attachments/%s", attachment.IncidentID, attachment.ID)
+}
@@ -0,0 +1,68 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/models"
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/storage"
+)
+
+func TestIncidentService_ExtractInlineImages(t *testing.T) {
+	ctx := context.Background()
+	store, err := storage.NewMemoryStore()
+	if err != nil {
+		t.Fatalf("Failed to create memory store: %v", err)
+	}
+	incidentService := NewIncidentService(store, NewMetricsService(prometheus.NewRegistry()))
+	incidentService.SetInlineImageStorage(t.TempDir(), 64)
+
+	incident, err := incidentService.CreateIncident(ctx, "Checkout errors", "", models.SeverityHigh, []string{})
+	if err != nil {
+		t.Fatalf("Failed to create incident: %v", err)
+	}
+
+	image := []byte("\x89PNG\r\n\x1a\nnot really a png")
+	content := "Error spike right after deploy:\n![dashboard](data:image/png;base64," + base64.StdEncoding.EncodeToString(image) + ")\nRolling back."
+
+	rewritten, attachments, err := incidentService.ExtractInlineImages(ctx, incident.ID, "user-1", content)
+	if err != nil {
+		t.Fatalf("ExtractInlineImages failed: %v", err)
+	}
+	if len(attachments) != 1 {
+		t.Fatalf("Expected one attachment, got %d", len(attachments))
+	}
+	attachment := attachments[0]
+	if attachment.AttachmentType != models.AttachmentTypeScreenshot || attachment.MimeType != "image/png" || attachment.FileSize != int64(len(image)) {
+		t.Errorf("Unexpected attachment: %+v", attachment)
+	}
+	want := "Error spike right after deploy:\n![dashboard](/api/incidents/" + incident.ID + "/attachments/" + attachment.ID + ")\nRolling back."
+	if rewritten != want {
+		t.Errorf("Expected rewritten content %q, got %q", want, rewritten)
+	}
+	if stored, err := os.ReadFile(attachment.FilePath); err != nil || !bytes.Equal(stored, image) {
+		t.Errorf("Expected the image bytes on disk, got %q (err: %v)", stored, err)
+	}
+	if stored, _ := incidentService.GetAttachments(ctx, incident.ID); len(stored) != 1 || stored[0].ID != attachment.ID {
+		t.Errorf("Expected the attachment to be stored on the incident, got %v", stored)
+	}
+
+	// Content without images is left alone
+	if plain, attachments, err := incidentService.ExtractInlineImages(ctx, incident.ID, "user-1", "No images here"); err != nil || plain != "No images here" || len(attachments) != 0 {
+		t.Errorf("Expected plain content to pass through, got %q, %d attachments (err: %v)", plain, len(attachments), err)
+	}
+
+	oversized := "![big](data:image/png;base64," + base64.StdEncoding.EncodeToString([]byte(strings.Repeat("x", 65))) + ")"
+	if _, _, err := incidentService.ExtractInlineImages(ctx, incident.ID, "user-1", oversized); err != ErrInlineImageTooLarge {
+		t.Errorf("Expected ErrInlineImageTooLarge, got %v", err)
+	}
+	if stored, _ := incidentService.GetAttachments(ctx, incident.ID); len(stored) != 1 {
+		t.Errorf("Expected the oversized image not to be stored, got %d attachments", len(stored))
+	}
+}
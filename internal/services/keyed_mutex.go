@@ -0,0 +1,69 @@
+package services
+
+import (
+	"sort"
+	"sync"
+)
+
+// keyedMutex provides one mutex per key. Entries are reference counted and
+// removed once no goroutine holds or waits on them, so the map does not grow
+// with every key ever seen.
+type keyedMutex struct {
+	mu    sync.Mutex
+	locks map[string]*keyedMutexEntry
+}
+
+type keyedMutexEntry struct {
+	mu   sync.Mutex
+	refs int
+}
+
+func newKeyedMutex() *keyedMutex {
+	return &keyedMutex{locks: make(map[string]*keyedMutexEntry)}
+}
+
+// Lock acquires the mutex for key and returns a function that releases it
+func (k *keyedMutex) Lock(key string) func() {
+	k.mu.Lock()
+	entry, exists := k.locks[key]
+	if !exists {
+		entry = &keyedMutexEntry{}
+		k.locks[key] = entry
+	}
+	entry.refs++
+	k.mu.Unlock()
+
+	entry.mu.Lock()
+
+	return func() {
+		entry.mu.Unlock()
+
+		k.mu.Lock()
+		entry.refs--
+		if entry.refs == 0 {
+			delete(k.locks, key)
+		}
+		k.mu.Unlock()
+	}
+}
+
+// LockAll acquires the mutexes for all keys and returns a function that
+// releases them. Keys are locked in sorted order, so callers whose key sets
+// overlap cannot deadlock.
+func (k *keyedMutex) LockAll(keys []string) func() {
+	sorted := append([]string(nil), keys...)
+	sort.Strings(sorted)
+
+	unlocks := make([]func(), 0, len(sorted))
+	for i, key := range sorted {
+		if i > 0 && key == sorted[i-1] {
+			continue
+		}
+		unlocks = append(unlocks, k.Lock(key))
+	}
+	return func() {
+		for i := len(unlocks) - 1; i >= 0; i-- {
+			unlocks[i]()
+		}
+	}
+}
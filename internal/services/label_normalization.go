@@ -0,0 +1,85 @@
+package services
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// LabelNormalizationRule removes or rewrites a volatile alert label before
+// alerts are correlated, so that e.g. alerts from different pods of one
+// service end up in the same incident. The alert's stored labels are not
+// changed.
+type LabelNormalizationRule struct {
+	Label string
+	// Drop removes the label entirely
+	Drop bool
+	// Pattern and Replacement rewrite matching values, using
+	// regexp.ReplaceAllString semantics ($1 refers to the first group)
+	Pattern     *regexp.Regexp
+	Replacement string
+}
+
+// ParseLabelNormalizationRules parses rules written as "drop:label" or
+// "rewrite:label=regex=>replacement", e.g.
+// "rewrite:instance=^(.+)-[a-z0-9]+-[a-z0-9]+(:\d+)?$=>$1"
+func ParseLabelNormalizationRules(specs []string) ([]LabelNormalizationRule, error) {
+	rules := make([]LabelNormalizationRule, 0, len(specs))
+	for _, spec := range specs {
+		action, rest, _ := strings.Cut(spec, ":")
+		switch strings.TrimSpace(action) {
+		case "drop":
+			label := strings.TrimSpace(rest)
+			if label == "" {
+				return nil, fmt.Errorf("invalid label normalization rule %q: expected drop:label", spec)
+			}
+			rules = append(rules, LabelNormalizationRule{Label: label, Drop: true})
+		case "rewrite":
+			label, expr, ok := strings.Cut(rest, "=")
+			label = strings.TrimSpace(label)
+			sep := strings.LastIndex(expr, "=>")
+			if !ok || label == "" || sep <= 0 {
+				return nil, fmt.Errorf("invalid label normalization rule %q: expected rewrite:label=regex=>replacement", spec)
+			}
+			pattern, err := regexp.Compile(expr[:sep])
+			if err != nil {
+				return nil, fmt.Errorf("invalid label normalization rule %q: %w", spec, err)
+			}
+			rules = append(rules, LabelNormalizationRule{Label: label, Pattern: pattern, Replacement: expr[sep+2:]})
+		default:
+			return nil, fmt.Errorf("invalid label normalization rule %q: action must be drop or rewrite", spec)
+		}
+	}
+	return rules, nil
+}
+
+// SetLabelNormalization configures the rules applied to alert labels before
+// correlation
+func (s *AlertService) SetLabelNormalization(rules []LabelNormalizationRule) {
+	s.labelRules = rules
+}
+
+// correlationLabels returns the labels alerts are correlated by. Rules are
+// applied in order to a copy; without rules the labels are returned as is.
+func (s *AlertService) correlationLabels(labels map[string]string) map[string]string {
+	if len(s.labelRules) == 0 {
+		return labels
+	}
+
+	normalized := make(map[string]string, len(labels))
+	for key, value := range labels {
+		normalized[key] = value
+	}
+	for _, rule := range s.labelRules {
+		value, ok := normalized[rule.Label]
+		if !ok {
+			continue
+		}
+		if rule.Drop {
+			delete(normalized, rule.Label)
+			continue
+		}
+		normalized[rule.Label] = rule.Pattern.ReplaceAllString(value, rule.Replacement)
+	}
+	return normalized
+}
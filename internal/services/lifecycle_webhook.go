@@ -0,0 +1,197 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/models"
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/retry"
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/storage"
+)
+
+// LifecycleWebhookService posts incident status changes to registered
+// lifecycle webhooks. Deliveries run in the background and are retried;
+// the outcome is recorded on the webhook.
+type LifecycleWebhookService struct {
+	store   storage.Store
+	logger  *Logger
+	client  *http.Client
+	retryer *retry.Retryer
+
+	// ctx is cancelled by Stop to abandon pending retries at shutdown
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// trackMu serializes delivery tracking updates so concurrent deliveries
+	// to one webhook don't lose counts
+	trackMu  sync.Mutex
+	inFlight sync.WaitGroup
+}
+
+// NewLifecycleWebhookService creates a lifecycle webhook service
+func NewLifecycleWebhookService(store storage.Store, logger *Logger) *LifecycleWebhookService {
+	retryPolicy := &retry.RetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   500 * time.Millisecond,
+		MaxDelay:    10 * time.Second,
+		Multiplier:  2.0,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &LifecycleWebhookService{
+		ctx:     ctx,
+		cancel:  cancel,
+		store:   store,
+		logger:  logger,
+		client:  &http.Client{Timeout: 10 * time.Second},
+		retryer: retry.NewRetryer(retryPolicy, retry.DefaultIsRetryable),
+	}
+}
+
+// IncidentStatusChanged sends a lifecycle event for the incident to every
+// enabled webhook whose filters match it. It is meant to be registered with
+// IncidentService.SetStatusChangeHook and does not block on delivery.
+func (s *LifecycleWebhookService) IncidentStatusChanged(incident *models.Incident, previous models.IncidentStatus) {
+	webhooks, err := s.store.ListLifecycleWebhooks(context.Background())
+	if err != nil {
+		s.logger.Error("Failed to list lifecycle webhooks", map[string]interface{}{
+			"incident_id": incident.ID,
+			"error":       err.Error(),
+		})
+		return
+	}
+
+	// Build the event now; the incident may change before delivery
+	event := models.IncidentLifecycleEvent{
+		Event:          "incident." + string(incident.Status),
+		IncidentID:     incident.ID,
+		Title:          incident.Title,
+		Status:         incident.Status,
+		PreviousStatus: previous,
+		Severity:       incident.Severity,
+		AssigneeID:     incident.AssigneeID,
+		Labels:         maps.Clone(incident.Labels),
+		OccurredAt:     incident.UpdatedAt,
+	}
+	body, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+
+	for _, webhook := range webhooks {
+		if !webhook.Enabled || !lifecycleWebhookMatches(webhook, incident) {
+			continue
+		}
+
+		s.inFlight.Add(1)
+		go func(webhook *models.LifecycleWebhook) {
+			defer s.inFlight.Done()
+			s.deliver(s.ctx, webhook, event, body)
+		}(webhook)
+	}
+}
+
+// Stop cancels in-flight deliveries. Their pending retries are abandoned
+// and recorded as failed; call Wait afterwards for them to finish.
+func (s *LifecycleWebhookService) Stop() {
+	s.cancel()
+}
+
+// Wait blocks until all in-flight deliveries have finished
+func (s *LifecycleWebhookService) Wait() {
+	s.inFlight.Wait()
+}
+
+// deliver posts the event with retries and records the outcome
+func (s *LifecycleWebhookService) deliver(ctx context.Context, webhook *models.LifecycleWebhook, event models.IncidentLifecycleEvent, body []byte) {
+	err := s.retryer.Execute(ctx, func() error {
+		return s.post(ctx, webhook.URL, body)
+	})
+	if err != nil {
+		s.logger.Error("Failed to deliver lifecycle event", map[string]interface{}{
+			"webhook_id":  webhook.ID,
+			"incident_id": event.IncidentID,
+			"event":       event.Event,
+			"error":       err.Error(),
+		})
+	}
+
+	s.trackMu.Lock()
+	defer s.trackMu.Unlock()
+
+	// Record the outcome even when the delivery was cancelled
+	ctx = context.WithoutCancel(ctx)
+
+	// Re-read so counts from other deliveries and concurrent edits are kept
+	current, getErr := s.store.GetLifecycleWebhook(ctx, webhook.ID)
+	if getErr != nil {
+		return
+	}
+	now := time.Now()
+	current.LastAttemptAt = &now
+	current.UpdatedAt = now
+	if err != nil {
+		current.LastStatus = "failed"
+		current.LastError = err.Error()
+		current.FailedCount++
+	} else {
+		current.LastStatus = "delivered"
+		current.LastError = ""
+		current.DeliveredCount++
+	}
+	if err := s.store.UpdateLifecycleWebhook(ctx, current); err != nil {
+		s.logger.Error("Failed to record lifecycle webhook delivery", map[string]interface{}{
+			"webhook_id": webhook.ID,
+			"error":      err.Error(),
+		})
+	}
+}
+
+func (s *LifecycleWebhookService) post(ctx context.Context, url string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("lifecycle webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// lifecycleWebhookMatches reports whether the incident passes the webhook's
+// severity and label filters
+func lifecycleWebhookMatches(webhook *models.LifecycleWebhook, incident *models.Incident) bool {
+	if len(webhook.Severities) > 0 {
+		matched := false
+		for _, severity := range webhook.Severities {
+			if severity == incident.Severity {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+
+	for key, value := range webhook.Labels {
+		if incident.Labels[key] != value {
+			return false
+		}
+	}
+	return true
+}
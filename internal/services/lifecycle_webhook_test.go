@@ -0,0 +1,152 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/models"
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/retry"
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/storage"
+)
+
+func TestLifecycleWebhook_AcknowledgePostsEvent(t *testing.T) {
+	ctx := context.Background()
+	store, err := storage.NewMemoryStore()
+	if err != nil {
+		t.Fatalf("Failed to create memory store: %v", err)
+	}
+	incidentService := NewIncidentService(store, NewMetricsService(prometheus.NewRegistry()))
+	lifecycle := NewLifecycleWebhookService(store, NewLogger("error", false))
+	lifecycle.retryer = retry.NewRetryer(&retry.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond, Multiplier: 1}, nil)
+	incidentService.SetStatusChangeHook(lifecycle.IncidentStatusChanged)
+
+	var mu sync.Mutex
+	var events []models.IncidentLifecycleEvent
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		// The first attempt fails to exercise retries
+		if attempts++; attempts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var event models.IncidentLifecycleEvent
+		if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+			t.Errorf("Failed to decode event: %v", err)
+		}
+		events = append(events, event)
+	}))
+	defer server.Close()
+
+	now := time.Now()
+	hooks := []*models.LifecycleWebhook{
+		{ID: "chatops", Name: "ChatOps", URL: server.URL, Enabled: true, Severities: []models.IncidentSeverity{models.SeverityCritical}, CreatedAt: now},
+		{ID: "team-db", Name: "DB team", URL: server.URL, Enabled: true, Labels: map[string]string{"team": "db"}, CreatedAt: now},
+		{ID: "disabled", Name: "Disabled", URL: server.URL, Enabled: false, CreatedAt: now},
+	}
+	for _, hook := range hooks {
+		if err := store.CreateLifecycleWebhook(ctx, hook); err != nil {
+			t.Fatalf("Failed to create lifecycle webhook: %v", err)
+		}
+	}
+
+	incident, err := incidentService.CreateIncident(ctx, "Checkout down", "", models.SeverityCritical, []string{})
+	if err != nil {
+		t.Fatalf("Failed to create incident: %v", err)
+	}
+	if err := incidentService.AcknowledgeIncident(ctx, incident.ID, "user-1"); err != nil {
+		t.Fatalf("Failed to acknowledge incident: %v", err)
+	}
+	lifecycle.Wait()
+
+	// Only the critical-severity hook matches; the label filter excludes team-db
+	if len(events) != 1 {
+		t.Fatalf("Expected 1 delivered event, got %d", len(events))
+	}
+	event := events[0]
+	if event.Event != "incident.acknowledged" || event.IncidentID != incident.ID ||
+		event.PreviousStatus != models.IncidentStatusOpen || event.AssigneeID != "user-1" {
+		t.Errorf("Unexpected event: %+v", event)
+	}
+
+	hook, _ := store.GetLifecycleWebhook(ctx, "chatops")
+	if hook.LastStatus != "delivered" || hook.DeliveredCount != 1 || hook.LastAttemptAt == nil {
+		t.Errorf("Expected the delivery to be tracked on the hook, got %+v", hook)
+	}
+	if other, _ := store.GetLifecycleWebhook(ctx, "team-db"); other.LastAttemptAt != nil {
+		t.Errorf("Expected no delivery to a hook whose filter does not match")
+	}
+
+	// Acknowledging again is rejected and sends nothing
+	if err := incidentService.AcknowledgeIncident(ctx, incident.ID, "user-1"); !errors.Is(err, ErrInvalidTransition) {
+		t.Fatalf("Expected the repeated ack to be rejected, got %v", err)
+	}
+	if err := incidentService.ResolveIncident(ctx, incident.ID, "user-1", ""); err != nil {
+		t.Fatalf("Failed to resolve incident: %v", err)
+	}
+	lifecycle.Wait()
+	if len(events) != 2 || events[1].Event != "incident.resolved" || events[1].PreviousStatus != models.IncidentStatusAcknowledged {
+		t.Errorf("Expected a single resolve event after the repeated ack, got %+v", events)
+	}
+}
+
+func TestLifecycleWebhook_StopAbandonsRetries(t *testing.T) {
+	ctx := context.Background()
+	store, err := storage.NewMemoryStore()
+	if err != nil {
+		t.Fatalf("Failed to create memory store: %v", err)
+	}
+	lifecycle := NewLifecycleWebhookService(store, NewLogger("error", false))
+	// Retries back off far longer than the test may take
+	lifecycle.retryer = retry.NewRetryer(&retry.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Hour, MaxDelay: time.Hour, Multiplier: 1}, nil)
+
+	attempted := make(chan struct{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case attempted <- struct{}{}:
+		default:
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	hook := &models.LifecycleWebhook{ID: "chatops", Name: "ChatOps", URL: server.URL, Enabled: true, CreatedAt: time.Now()}
+	if err := store.CreateLifecycleWebhook(ctx, hook); err != nil {
+		t.Fatalf("Failed to create lifecycle webhook: %v", err)
+	}
+
+	incident := &models.Incident{ID: "inc-1", Title: "Checkout down", Status: models.IncidentStatusAcknowledged, Severity: models.SeverityCritical, UpdatedAt: time.Now()}
+	lifecycle.IncidentStatusChanged(incident, models.IncidentStatusOpen)
+	select {
+	case <-attempted:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected a first delivery attempt")
+	}
+
+	lifecycle.Stop()
+	done := make(chan struct{})
+	go func() {
+		lifecycle.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected Stop to abandon the pending retries")
+	}
+
+	// The abandoned delivery is still recorded
+	hook, _ = store.GetLifecycleWebhook(ctx, "chatops")
+	if hook.LastStatus != "failed" || hook.FailedCount != 1 {
+		t.Errorf("Expected the cancelled delivery to be recorded as failed, got %+v", hook)
+	}
+}
@@ -0,0 +1,174 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/models"
+)
+
+// MentionTeamPrefix marks a mention of every member of a team, e.g. @team:payments
+const MentionTeamPrefix = "team:"
+
+// DefaultMaxMentionRecipients caps how many users a single comment notifies
+const DefaultMaxMentionRecipients = 25
+
+// mentionPattern matches @username and @team:name mentions that are not part
+// of a longer word such as an email address
+var mentionPattern = regexp.MustCompile(`(?:^|[^\w@])@((?:team:)?[A-Za-z0-9][A-Za-z0-9_.-]*)`)
+
+// ParseMentionTeams parses teams written as "team:username|username", e.g.
+// "payments:alice|bob". Team names are matched case-insensitively.
+func ParseMentionTeams(specs []string) (map[string][]string, error) {
+	teams := make(map[string][]string, len(specs))
+	for _, spec := range specs {
+		name, members, ok := strings.Cut(spec, ":")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid mention team %q: expected team:username|username", spec)
+		}
+		for _, member := range strings.Split(members, "|") {
+			if member = strings.TrimSpace(member); member != "" {
+				teams[name] = append(teams[name], member)
+			}
+		}
+		if len(teams[name]) == 0 {
+			return nil, fmt.Errorf("invalid mention team %q: no members", spec)
+		}
+	}
+	return teams, nil
+}
+
+// SetMentionTeams sets the teams @team:<name> mentions resolve to and the most
+// users one comment may notify. A non-positive cap uses the default.
+func (s *IncidentService) SetMentionTeams(teams map[string][]string, maxRecipients int) {
+	s.mentionTeams = teams
+	if maxRecipients <= 0 {
+		maxRecipients = DefaultMaxMentionRecipients
+	}
+	s.maxMentionRecipients = maxRecipients
+}
+
+// SetMentionHook registers a function called with the users mentioned in a
+// newly added comment, e.g. to notify them
+func (s *IncidentService) SetMentionHook(hook func(incident *models.Incident, comment *models.IncidentComment, users []*models.User)) {
+	s.onMention = hook
+}
+
+// ResolveMentions returns the active users mentioned in content, each once
+// and in order of first mention. Team mentions expand to the team's members.
+// The author is never included, unknown names are ignored, and the result is
+// capped at the configured number of recipients.
+func (s *IncidentService) ResolveMentions(ctx context.Context, content, authorID string) []*models.User {
+	maxRecipients := s.maxMentionRecipients
+	if maxRecipients <= 0 {
+		maxRecipients = DefaultMaxMentionRecipients
+	}
+
+	var usernames []string
+	for _, match := range mentionPattern.FindAllStringSubmatch(content, -1) {
+		name := strings.TrimRight(match[1], ".-")
+		if team, ok := strings.CutPrefix(name, MentionTeamPrefix); ok {
+			usernames = append(usernames, s.mentionTeams[strings.ToLower(team)]...)
+			continue
+		}
+		usernames = append(usernames, name)
+	}
+
+	seen := make(map[string]bool)
+	var users []*models.User
+	for _, username := range usernames {
+		user, err := s.store.GetUserByUsername(ctx, username)
+		if err != nil || !user.IsActive || user.ID == authorID || seen[user.ID] {
+			continue
+		}
+		if len(users) == maxRecipients {
+			break
+		}
+		seen[user.ID] = true
+		users = append(users, user)
+	}
+	return users
+}
+
+// MentionedUserIDsKey is the comment metadata key holding the IDs of the
+// users a comment mentions
+const MentionedUserIDsKey = "mentioned_user_ids"
+
+// withMentionedUsers returns a copy of metadata recording the mentioned users
+func withMentionedUsers(metadata map[string]interface{}, users []*models.User) map[string]interface{} {
+	result := make(map[string]interface{}, len(metadata)+1)
+	for key, value := range metadata {
+		result[key] = value
+	}
+	ids := make([]string, len(users))
+	for i, user := range users {
+		ids[i] = user.ID
+	}
+	result[MentionedUserIDsKey] = ids
+	return result
+}
+
+// MentionedUserIDs returns the IDs of the users a comment mentions
+func MentionedUserIDs(comment *models.IncidentComment) []string {
+	switch ids := comment.Metadata[MentionedUserIDsKey].(type) {
+	case []string:
+		return ids
+	case []interface{}:
+		// Metadata read back from JSON
+		result := make([]string, 0, len(ids))
+		for _, id := range ids {
+			if s, ok := id.(string); ok {
+				result = append(result, s)
+			}
+		}
+		return result
+	}
+	return nil
+}
+
+// NotifyMentionedUsers sends a comment's mentioned users a notification on
+// each of their enabled notification channels and returns the number of
+// successful deliveries
+func (s *NotificationService) NotifyMentionedUsers(ctx context.Context, incident *models.Incident, comment *models.IncidentComment, users []*models.User) int {
+	author := "Someone"
+	if comment.UserID != nil {
+		if user, err := s.store.GetUser(ctx, *comment.UserID); err == nil {
+			author = user.Username
+		}
+	}
+	subject := fmt.Sprintf("[%s] %s mentioned you: %s", incident.Severity, author, incident.Title)
+	content := fmt.Sprintf("%s mentioned you on incident %s:\n\n%s\n\n%s/incidents/%s",
+		author, incident.Title, comment.Content, s.systemURL(), incident.ID)
+
+	channels, err := s.store.ListNotificationChannels(ctx)
+	if err != nil {
+		s.logger.Error("Failed to list notification channels for mentions", map[string]interface{}{
+			"incident_id": incident.ID,
+			"error":       err.Error(),
+		})
+		return 0
+	}
+
+	delivered := 0
+	for _, user := range users {
+		for _, channel := range channels {
+			if !channel.Enabled || channel.UserID != user.ID {
+				continue
+			}
+			if err := s.sendRendered(channel, subject, content); err != nil {
+				s.logger.Error("Failed to notify mentioned user", map[string]interface{}{
+					"incident_id": incident.ID,
+					"user_id":     user.ID,
+					"channel_id":  channel.ID,
+					"error":       err.Error(),
+				})
+				continue
+			}
+			delivered++
+		}
+	}
+	return delivered
+}
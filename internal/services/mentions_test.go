@@ -0,0 +1,174 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/config"
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/models"
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/storage"
+)
+
+func TestTeamMentionNotifiesMembersOnce(t *testing.T) {
+	ctx := context.Background()
+	store, err := storage.NewMemoryStore()
+	if err != nil {
+		t.Fatalf("Failed to create memory store: %v", err)
+	}
+	logger := NewLogger("error", false)
+	incidentService := NewIncidentService(store, NewMetricsService(prometheus.NewRegistry()))
+	notificationService := NewNotificationService(&config.Config{Port: "8080"}, store, NewNotificationTemplateService(logger), NewMetricsService(prometheus.NewRegistry()), logger)
+
+	var mu sync.Mutex
+	received := make(map[string]int)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		received[strings.TrimPrefix(r.URL.Path, "/")]++
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	for _, username := range []string{"alice", "bob", "carol", "dave"} {
+		user := &models.User{ID: username + "-id", Username: username, Email: username + "@example.com", IsActive: true}
+		if err := store.CreateUser(ctx, user); err != nil {
+			t.Fatalf("Failed to create user: %v", err)
+		}
+		channel := &models.NotificationChannel{
+			ID: username + "-hook", Name: username, Type: "webhook", Enabled: true, UserID: user.ID,
+			Config: map[string]string{"url": server.URL + "/" + username},
+		}
+		if err := store.CreateNotificationChannel(ctx, channel); err != nil {
+			t.Fatalf("Failed to create channel: %v", err)
+		}
+	}
+
+	teams, err := ParseMentionTeams([]string{"Payments:alice|bob|carol|dave", "search:carol"})
+	if err != nil {
+		t.Fatalf("Failed to parse teams: %v", err)
+	}
+	incidentService.SetMentionTeams(teams, 0)
+	var mentioned []*models.User
+	incidentService.SetMentionHook(func(incident *models.Incident, comment *models.IncidentComment, users []*models.User) {
+		mentioned = users
+		notificationService.NotifyMentionedUsers(ctx, incident, comment, users)
+	})
+
+	incident, err := incidentService.CreateIncident(ctx, "Checkout errors", "", models.SeverityHigh, nil)
+	if err != nil {
+		t.Fatalf("Failed to create incident: %v", err)
+	}
+	content := "@team:payments @team:search please join, @alice too (cc ops@example.com, @nobody)"
+	if _, err := incidentService.AddComment(ctx, incident.ID, "dave-id", content, models.CommentTypeComment, nil); err != nil {
+		t.Fatalf("Failed to add comment: %v", err)
+	}
+
+	if len(mentioned) != 3 {
+		t.Fatalf("Expected alice, bob and carol to be mentioned once each, got %d users", len(mentioned))
+	}
+	for _, username := range []string{"alice", "bob", "carol"} {
+		if received[username] != 1 {
+			t.Errorf("Expected %s to be notified exactly once, got %d", username, received[username])
+		}
+	}
+	if received["dave"] != 0 {
+		t.Errorf("Expected the author not to be notified of their own mention, got %d", received["dave"])
+	}
+
+	incidentService.SetMentionTeams(teams, 2)
+	if users := incidentService.ResolveMentions(ctx, "@team:payments", "someone-else"); len(users) != 2 {
+		t.Errorf("Expected the fan-out to be capped at 2 users, got %d", len(users))
+	}
+
+	mentioned = nil
+	if _, err := incidentService.AddComment(ctx, incident.ID, "system", "@alice status changed", models.CommentTypeStatusChange, nil); err != nil {
+		t.Fatalf("Failed to add timeline entry: %v", err)
+	}
+	if mentioned != nil {
+		t.Error("Expected timeline entries not to notify mentioned users")
+	}
+
+	if _, err := ParseMentionTeams([]string{"payments"}); err == nil {
+		t.Error("Expected a team without members to be rejected")
+	}
+}
+
+func TestMentionNotifiesKnownUsersOnly(t *testing.T) {
+	ctx := context.Background()
+	store, err := storage.NewMemoryStore()
+	if err != nil {
+		t.Fatalf("Failed to create memory store: %v", err)
+	}
+	logger := NewLogger("error", false)
+	incidentService := NewIncidentService(store, NewMetricsService(prometheus.NewRegistry()))
+	notificationService := NewNotificationService(&config.Config{Port: "8080"}, store, NewNotificationTemplateService(logger), NewMetricsService(prometheus.NewRegistry()), logger)
+
+	var mu sync.Mutex
+	received := make(map[string]int)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		received[strings.TrimPrefix(r.URL.Path, "/")]++
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	for _, username := range []string{"alice", "bob"} {
+		user := &models.User{ID: username + "-id", Username: username, Email: username + "@example.com", IsActive: true}
+		if err := store.CreateUser(ctx, user); err != nil {
+			t.Fatalf("Failed to create user: %v", err)
+		}
+		channel := &models.NotificationChannel{
+			ID: username + "-hook", Name: username, Type: "webhook", Enabled: true, UserID: user.ID,
+			Config: map[string]string{"url": server.URL + "/" + username},
+		}
+		if err := store.CreateNotificationChannel(ctx, channel); err != nil {
+			t.Fatalf("Failed to create channel: %v", err)
+		}
+	}
+	incidentService.SetMentionHook(func(incident *models.Incident, comment *models.IncidentComment, users []*models.User) {
+		notificationService.NotifyMentionedUsers(ctx, incident, comment, users)
+	})
+
+	incident, err := incidentService.CreateIncident(ctx, "Cache misses", "", models.SeverityMedium, nil)
+	if err != nil {
+		t.Fatalf("Failed to create incident: %v", err)
+	}
+
+	comment, err := incidentService.AddComment(ctx, incident.ID, "bob-id", "@alice can you check the eviction rate?", models.CommentTypeComment, nil)
+	if err != nil {
+		t.Fatalf("Failed to add comment: %v", err)
+	}
+	if received["alice"] != 1 {
+		t.Errorf("Expected alice to be notified once, got %d", received["alice"])
+	}
+	if ids := MentionedUserIDs(comment); len(ids) != 1 || ids[0] != "alice-id" {
+		t.Errorf("Expected the comment metadata to record alice, got %v", comment.Metadata)
+	}
+
+	unknown, err := incidentService.AddComment(ctx, incident.ID, "bob-id", "@ghost any ideas?", models.CommentTypeComment, nil)
+	if err != nil {
+		t.Fatalf("Failed to add comment: %v", err)
+	}
+	if unknown.Content != "@ghost any ideas?" {
+		t.Errorf("Expected an unknown mention to stay as plain text, got %q", unknown.Content)
+	}
+	if _, ok := unknown.Metadata[MentionedUserIDsKey]; ok {
+		t.Errorf("Expected no mentioned users for an unknown name, got %v", unknown.Metadata)
+	}
+	if len(received) != 1 || received["alice"] != 1 {
+		t.Errorf("Expected no notifications for an unknown name, got %v", received)
+	}
+
+	// Editing notifies only users who were not mentioned before
+	if _, err := incidentService.UpdateComment(ctx, incident.ID, comment.ID, "bob-id", "@alice @bob can you check the eviction rate?", false); err != nil {
+		t.Fatalf("Failed to edit comment: %v", err)
+	}
+	if received["alice"] != 1 || received["bob"] != 0 {
+		t.Errorf("Expected an edit not to renotify alice or notify the author, got %v", received)
+	}
+}
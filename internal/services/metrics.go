@@ -2,10 +2,14 @@ package services
 
 import (
 	"database/sql"
+	"net/http"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/circuitbreaker"
 )
 
 // MetricsService handles Prometheus metrics collection
@@ -25,23 +29,49 @@ type MetricsService struct {
 	incidentsByStatus *prometheus.GaugeVec
 	mtta              prometheus.Gauge
 	mttr              prometheus.Gauge
+	needsAttention    prometheus.Gauge
+	slaBreached       *prometheus.GaugeVec
+	resolvedByType    *prometheus.GaugeVec
+
+	resolutionDuration *prometheus.HistogramVec
 
 	// Webhook metrics
 	webhookRequestsTotal *prometheus.CounterVec
 	notificationsSent    *prometheus.CounterVec
+	// Delivery outcomes by channel and notification type, from which
+	// dashboards derive success rates
+	notificationDeliveries *prometheus.CounterVec
+
+	// Alert spool metrics
+	spooledWebhooks prometheus.Gauge
+	spoolEvents     *prometheus.CounterVec
+
+	// Circuit breaker metrics
+	circuitBreakerState *prometheus.GaugeVec
+
+	// Recovered handler panics
+	httpPanicsTotal prometheus.Counter
+
+	// registerer is where the collectors above are registered
+	registerer prometheus.Registerer
 }
 
-// NewMetricsService creates a new metrics service
-func NewMetricsService() *MetricsService {
+// NewMetricsService creates a metrics service whose collectors are
+// registered on reg. A registry rejects collectors registered twice, so the
+// server passes prometheus.DefaultRegisterer once and tests a fresh
+// prometheus.NewRegistry() each.
+func NewMetricsService(reg prometheus.Registerer) *MetricsService {
+	factory := promauto.With(reg)
 	return &MetricsService{
-		httpRequestsTotal: promauto.NewCounterVec(
+		registerer: reg,
+		httpRequestsTotal: factory.NewCounterVec(
 			prometheus.CounterOpts{
 				Name: "http_requests_total",
 				Help: "Total number of HTTP requests",
 			},
 			[]string{"method", "path", "status_code"},
 		),
-		httpRequestDuration: promauto.NewHistogramVec(
+		httpRequestDuration: factory.NewHistogramVec(
 			prometheus.HistogramOpts{
 				Name: "http_request_duration_seconds",
 				Help: "HTTP request duration in seconds",
@@ -49,13 +79,13 @@ func NewMetricsService() *MetricsService {
 			},
 			[]string{"method", "path", "status_code"},
 		),
-		httpRequestsInFlight: promauto.NewGauge(
+		httpRequestsInFlight: factory.NewGauge(
 			prometheus.GaugeOpts{
 				Name: "http_requests_in_flight",
 				Help: "Current number of HTTP requests being served",
 			},
 		),
-		dbQueryDuration: promauto.NewHistogramVec(
+		dbQueryDuration: factory.NewHistogramVec(
 			prometheus.HistogramOpts{
 				Name: "db_query_duration_seconds",
 				Help: "Database query duration in seconds",
@@ -63,61 +93,133 @@ func NewMetricsService() *MetricsService {
 			},
 			[]string{"query_type", "table"},
 		),
-		dbConnections: promauto.NewGaugeVec(
+		dbConnections: factory.NewGaugeVec(
 			prometheus.GaugeOpts{
 				Name: "db_connections",
 				Help: "Current database connections",
 			},
 			[]string{"status"}, // open, idle, in_use
 		),
-		incidentsTotal: promauto.NewCounterVec(
+		incidentsTotal: factory.NewCounterVec(
 			prometheus.CounterOpts{
 				Name: "incidents_total",
 				Help: "Total number of incidents created",
 			},
 			[]string{"severity", "status"},
 		),
-		alertsTotal: promauto.NewCounterVec(
+		alertsTotal: factory.NewCounterVec(
 			prometheus.CounterOpts{
 				Name: "alerts_total",
 				Help: "Total number of alerts processed",
 			},
 			[]string{"status"},
 		),
-		incidentsByStatus: promauto.NewGaugeVec(
+		incidentsByStatus: factory.NewGaugeVec(
 			prometheus.GaugeOpts{
 				Name: "incidents_by_status",
 				Help: "Current number of incidents by status",
 			},
 			[]string{"status", "severity"},
 		),
-		mtta: promauto.NewGauge(
+		mtta: factory.NewGauge(
 			prometheus.GaugeOpts{
 				Name: "incident_mtta_seconds",
 				Help: "Mean Time To Acknowledge in seconds",
 			},
 		),
-		mttr: promauto.NewGauge(
+		mttr: factory.NewGauge(
 			prometheus.GaugeOpts{
 				Name: "incident_mttr_seconds",
 				Help: "Mean Time To Resolve in seconds",
 			},
 		),
-		webhookRequestsTotal: promauto.NewCounterVec(
+		needsAttention: factory.NewGauge(
+			prometheus.GaugeOpts{
+				Name: "incidents_needing_attention",
+				Help: "Current number of open, unassigned incidents older than the triage threshold",
+			},
+		),
+		slaBreached: factory.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "incidents_sla_breached",
+				Help: "Current number of unresolved incidents that have missed an SLA target",
+			},
+			[]string{"severity"},
+		),
+		resolvedByType: factory.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "incidents_resolved_by_type",
+				Help: "Current number of resolved incidents by resolution type",
+			},
+			[]string{"resolution_type"},
+		),
+		resolutionDuration: factory.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name: "incident_resolution_duration_seconds",
+				Help: "Time from incident creation to resolution in seconds",
+				// 1m, 5m, 15m, 30m, 1h, 2h, 4h, 8h, 1d, 3d, 1w
+				Buckets: []float64{60, 300, 900, 1800, 3600, 7200, 14400, 28800, 86400, 259200, 604800},
+			},
+			[]string{"severity"},
+		),
+		webhookRequestsTotal: factory.NewCounterVec(
 			prometheus.CounterOpts{
 				Name: "webhook_requests_total",
 				Help: "Total number of webhook requests processed",
 			},
 			[]string{"source", "status"},
 		),
-		notificationsSent: promauto.NewCounterVec(
+		notificationsSent: factory.NewCounterVec(
 			prometheus.CounterOpts{
 				Name: "notifications_sent_total",
 				Help: "Total number of notifications sent",
 			},
 			[]string{"channel", "status"},
 		),
+		notificationDeliveries: factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "notification_deliveries_total",
+				Help: "Total number of notification delivery outcomes by channel type and notification type",
+			},
+			[]string{"channel_type", "notification_type", "status"},
+		),
+		spooledWebhooks: factory.NewGauge(
+			prometheus.GaugeOpts{
+				Name: "alert_spool_buffered_webhooks",
+				Help: "Current number of webhooks buffered while storage is unavailable",
+			},
+		),
+		spoolEvents: factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "alert_spool_events_total",
+				Help: "Total number of webhooks spooled, replayed or dropped",
+			},
+			[]string{"event"}, // spooled, replayed, dropped
+		),
+		circuitBreakerState: factory.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "circuit_breaker_state",
+				Help: "Current circuit breaker state (0=closed, 1=half-open, 2=open)",
+			},
+			[]string{"name"},
+		),
+		httpPanicsTotal: factory.NewCounter(
+			prometheus.CounterOpts{
+				Name: "http_panics_total",
+				Help: "Total number of panics recovered while handling HTTP requests",
+			},
+		),
+	}
+}
+
+// Handler serves the metrics registered on the service's registerer in the
+// Prometheus exposition format
+func (m *MetricsService) Handler() http.Handler {
+	gatherer, ok := m.registerer.(prometheus.Gatherer)
+	if !ok {
+		gatherer = prometheus.DefaultGatherer
 	}
+	return promhttp.InstrumentMetricHandler(m.registerer, promhttp.HandlerFor(gatherer, promhttp.HandlerOpts{}))
 }
 
 // RecordHTTPRequest records an HTTP request metric
@@ -168,6 +270,28 @@ func (m *MetricsService) UpdateMTTR(mttr time.Duration) {
 	m.mttr.Set(mttr.Seconds())
 }
 
+// UpdateIncidentsNeedingAttention updates the needs-attention incidents gauge
+func (m *MetricsService) UpdateIncidentsNeedingAttention(count int) {
+	m.needsAttention.Set(float64(count))
+}
+
+// UpdateIncidentsSLABreached sets the number of unresolved incidents of a
+// severity that have missed an SLA target
+func (m *MetricsService) UpdateIncidentsSLABreached(severity string, count int) {
+	m.slaBreached.WithLabelValues(severity).Set(float64(count))
+}
+
+// UpdateIncidentsResolvedByType sets the number of resolved incidents with a
+// resolution type
+func (m *MetricsService) UpdateIncidentsResolvedByType(resolutionType string, count int) {
+	m.resolvedByType.WithLabelValues(resolutionType).Set(float64(count))
+}
+
+// RecordIncidentResolved records how long an incident took to resolve
+func (m *MetricsService) RecordIncidentResolved(severity string, duration time.Duration) {
+	m.resolutionDuration.WithLabelValues(severity).Observe(duration.Seconds())
+}
+
 // RecordAlertProcessed records an alert processing event
 func (m *MetricsService) RecordAlertProcessed(status string) {
 	m.alertsTotal.WithLabelValues(status).Inc()
@@ -181,4 +305,41 @@ func (m *MetricsService) RecordWebhookRequest(source, status string) {
 // RecordNotificationSent records a notification sending event
 func (m *MetricsService) RecordNotificationSent(channel, status string) {
 	m.notificationsSent.WithLabelValues(channel, status).Inc()
-}
\ No newline at end of file
+}
+
+// RecordNotificationDelivery records the outcome ("sent" or "failed") of
+// delivering a notification of the given type, including it in
+// notifications_sent_total as well. The success rate of a channel type is
+// rate(notification_deliveries_total{status="sent"}) over the rate of all
+// outcomes.
+func (m *MetricsService) RecordNotificationDelivery(channelType, notificationType, status string) {
+	m.RecordNotificationSent(channelType, status)
+	m.notificationDeliveries.WithLabelValues(channelType, notificationType, status).Inc()
+}
+
+// UpdateSpooledWebhooks records how many webhooks are waiting in the spool
+func (m *MetricsService) UpdateSpooledWebhooks(count int) {
+	m.spooledWebhooks.Set(float64(count))
+}
+
+// RecordSpoolEvent records a webhook being spooled, replayed or dropped
+func (m *MetricsService) RecordSpoolEvent(event string) {
+	m.spoolEvents.WithLabelValues(event).Inc()
+}
+
+// UpdateCircuitBreakerState records the current state of a circuit breaker
+func (m *MetricsService) UpdateCircuitBreakerState(name string, state circuitbreaker.State) {
+	value := 0.0
+	switch state {
+	case circuitbreaker.StateHalfOpen:
+		value = 1
+	case circuitbreaker.StateOpen:
+		value = 2
+	}
+	m.circuitBreakerState.WithLabelValues(name).Set(value)
+}
+
+// RecordPanic records a panic recovered while handling an HTTP request
+func (m *MetricsService) RecordPanic() {
+	m.httpPanicsTotal.Inc()
+}
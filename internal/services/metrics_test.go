@@ -0,0 +1,239 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/models"
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/storage"
+)
+
+// resolutionHistogram returns the current sample count and sum for a severity
+func resolutionHistogram(t *testing.T, m *MetricsService, severity string) (uint64, float64) {
+	t.Helper()
+
+	var metric dto.Metric
+	observer := m.resolutionDuration.WithLabelValues(severity)
+	if err := observer.(prometheus.Metric).Write(&metric); err != nil {
+		t.Fatalf("Failed to read histogram: %v", err)
+	}
+	return metric.GetHistogram().GetSampleCount(), metric.GetHistogram().GetSampleSum()
+}
+
+func TestNewMetricsService_RegistersOnGivenRegistry(t *testing.T) {
+	first, second := prometheus.NewRegistry(), prometheus.NewRegistry()
+	firstService := NewMetricsService(first)
+	// A second service on its own registry does not collide with the first
+	NewMetricsService(second)
+
+	firstService.RecordAlertProcessed("firing")
+	for name, registry := range map[string]*prometheus.Registry{"first": first, "second": second} {
+		families, err := registry.Gather()
+		if err != nil {
+			t.Fatalf("Failed to gather %s registry: %v", name, err)
+		}
+		alerts := 0.0
+		for _, family := range families {
+			if family.GetName() == "alerts_total" {
+				for _, metric := range family.GetMetric() {
+					alerts += metric.GetCounter().GetValue()
+				}
+			}
+		}
+		if expected := map[string]float64{"first": 1, "second": 0}[name]; alerts != expected {
+			t.Errorf("Expected %v alerts on the %s registry, got %v", expected, name, alerts)
+		}
+	}
+}
+
+func TestResolveIncident_RecordsResolutionDuration(t *testing.T) {
+	ctx := context.Background()
+	store, err := storage.NewMemoryStore()
+	if err != nil {
+		t.Fatalf("Failed to create memory store: %v", err)
+	}
+	metricsService := NewMetricsService(prometheus.NewRegistry())
+	incidentService := NewIncidentService(store, metricsService)
+
+	durations := []time.Duration{10 * time.Minute, 2 * time.Hour}
+	beforeCount, beforeSum := resolutionHistogram(t, metricsService, string(models.SeverityHigh))
+
+	for _, d := range durations {
+		incident, err := incidentService.CreateIncident(ctx, "Database latency", "", models.SeverityHigh, nil)
+		if err != nil {
+			t.Fatalf("Failed to create incident: %v", err)
+		}
+		incident.CreatedAt = time.Now().Add(-d)
+		if err := store.UpdateIncident(ctx, incident); err != nil {
+			t.Fatalf("Failed to backdate incident: %v", err)
+		}
+
+		if err := incidentService.ResolveIncident(ctx, incident.ID, "", ""); err != nil {
+			t.Fatalf("Failed to resolve incident: %v", err)
+		}
+		// Resolving again is rejected and must not record a second observation
+		if err := incidentService.ResolveIncident(ctx, incident.ID, "", ""); !errors.Is(err, ErrInvalidTransition) {
+			t.Fatalf("Expected re-resolving to be rejected, got %v", err)
+		}
+	}
+
+	afterCount, afterSum := resolutionHistogram(t, metricsService, string(models.SeverityHigh))
+
+	if got := afterCount - beforeCount; got != uint64(len(durations)) {
+		t.Errorf("Expected %d observations, got %d", len(durations), got)
+	}
+
+	expected := (10 * time.Minute).Seconds() + (2 * time.Hour).Seconds()
+	if got := afterSum - beforeSum; got < expected || got > expected+5 {
+		t.Errorf("Expected observed durations to sum to ~%.0fs, got %.1fs", expected, got)
+	}
+}
+
+func TestUpdatePrometheusMetrics_IncidentsByStatusMatchesCounts(t *testing.T) {
+	ctx := context.Background()
+	store, err := storage.NewMemoryStore()
+	if err != nil {
+		t.Fatalf("Failed to create memory store: %v", err)
+	}
+	metricsService := NewMetricsService(prometheus.NewRegistry())
+	incidentService := NewIncidentService(store, metricsService)
+
+	dataset := []struct {
+		status   models.IncidentStatus
+		severity models.IncidentSeverity
+		count    int
+	}{
+		{models.IncidentStatusOpen, models.SeverityCritical, 3},
+		{models.IncidentStatusOpen, models.SeverityLow, 1},
+		{models.IncidentStatusAcknowledged, models.SeverityHigh, 2},
+		{models.IncidentStatusResolved, models.SeverityCritical, 4},
+	}
+	n := 0
+	for _, d := range dataset {
+		for i := 0; i < d.count; i++ {
+			n++
+			incident := &models.Incident{ID: fmt.Sprintf("incident-%d", n), Title: "Test", Status: d.status, Severity: d.severity, CreatedAt: time.Now()}
+			if err := store.CreateIncident(ctx, incident); err != nil {
+				t.Fatalf("Failed to create incident: %v", err)
+			}
+		}
+	}
+
+	if err := incidentService.UpdatePrometheusMetrics(ctx); err != nil {
+		t.Fatalf("Failed to update metrics: %v", err)
+	}
+
+	gauge := func(status models.IncidentStatus, severity models.IncidentSeverity) float64 {
+		t.Helper()
+		var metric dto.Metric
+		if err := metricsService.incidentsByStatus.WithLabelValues(string(status), string(severity)).(prometheus.Metric).Write(&metric); err != nil {
+			t.Fatalf("Failed to read gauge: %v", err)
+		}
+		return metric.GetGauge().GetValue()
+	}
+
+	for _, d := range dataset {
+		if got := gauge(d.status, d.severity); got != float64(d.count) {
+			t.Errorf("incidents_by_status{status=%q,severity=%q} = %v, expected %d", d.status, d.severity, got, d.count)
+		}
+	}
+	for _, empty := range [][2]string{{"open", "high"}, {"acknowledged", "critical"}, {"resolved", "low"}} {
+		if got := gauge(models.IncidentStatus(empty[0]), models.IncidentSeverity(empty[1])); got != 0 {
+			t.Errorf("incidents_by_status{status=%q,severity=%q} = %v, expected 0", empty[0], empty[1], got)
+		}
+	}
+}
+
+func TestResolveIncident_ResolutionType(t *testing.T) {
+	ctx := context.Background()
+	store, err := storage.NewMemoryStore()
+	if err != nil {
+		t.Fatalf("Failed to create memory store: %v", err)
+	}
+	metricsService := NewMetricsService(prometheus.NewRegistry())
+	incidentService := NewIncidentService(store, metricsService)
+
+	create := func(title string) *models.Incident {
+		t.Helper()
+		incident, err := incidentService.CreateIncident(ctx, title, "", models.SeverityHigh, nil)
+		if err != nil {
+			t.Fatalf("Failed to create incident: %v", err)
+		}
+		return incident
+	}
+	fixed, falseAlarm, untyped := create("Bad deploy"), create("Flapping probe"), create("Disk full")
+	create("Still open")
+
+	if err := incidentService.ResolveIncidentWithDetails(ctx, fixed.ID, "user-1", models.Resolution{Type: "rebooted"}); !errors.Is(err, ErrInvalidResolutionType) {
+		t.Errorf("Expected ErrInvalidResolutionType, got %v", err)
+	}
+	if err := incidentService.ResolveIncidentWithDetails(ctx, fixed.ID, "user-1", models.Resolution{Type: models.ResolutionFixed, RootCauseCategory: "deploy"}); err != nil {
+		t.Fatalf("Failed to resolve incident: %v", err)
+	}
+	if err := incidentService.ResolveIncidentWithDetails(ctx, falseAlarm.ID, "user-1", models.Resolution{Type: models.ResolutionFalsePositive}); err != nil {
+		t.Fatalf("Failed to resolve incident: %v", err)
+	}
+	if err := incidentService.ResolveIncident(ctx, untyped.ID, "user-1", ""); err != nil {
+		t.Fatalf("Failed to resolve incident: %v", err)
+	}
+
+	incidentService.SetRequireResolutionType(true)
+	required := create("Needs a type")
+	if err := incidentService.ResolveIncident(ctx, required.ID, "user-1", ""); !errors.Is(err, ErrResolutionTypeRequired) {
+		t.Errorf("Expected ErrResolutionTypeRequired, got %v", err)
+	}
+	incidentService.SetRequireResolutionType(false)
+
+	search := func(req *models.IncidentSearchRequest) []*models.Incident {
+		t.Helper()
+		req.Page, req.Limit = 1, 20
+		result, err := incidentService.SearchIncidents(ctx, req)
+		if err != nil {
+			t.Fatalf("Failed to search incidents: %v", err)
+		}
+		return result.Incidents
+	}
+	if found := search(&models.IncidentSearchRequest{ResolutionType: []models.ResolutionType{models.ResolutionFixed}}); len(found) != 1 || found[0].ID != fixed.ID {
+		t.Errorf("Expected only the fixed incident, got %d incidents", len(found))
+	}
+	if found := search(&models.IncidentSearchRequest{ResolutionType: []models.ResolutionType{models.ResolutionFixed, models.ResolutionFalsePositive}}); len(found) != 2 {
+		t.Errorf("Expected 2 incidents resolved as fixed or false positive, got %d", len(found))
+	}
+	if found := search(&models.IncidentSearchRequest{RootCauseCategory: []string{"deploy"}}); len(found) != 1 || found[0].RootCauseCategory != "deploy" {
+		t.Errorf("Expected only the incident caused by a deploy, got %d incidents", len(found))
+	}
+
+	metrics, err := incidentService.CalculateMetrics(ctx)
+	if err != nil {
+		t.Fatalf("Failed to calculate metrics: %v", err)
+	}
+	expected := map[string]int{"fixed": 1, "false_positive": 1, Uncategorized: 1}
+	for category, count := range expected {
+		if metrics.ResolvedByType[category] != count {
+			t.Errorf("ResolvedByType[%q] = %d, expected %d", category, metrics.ResolvedByType[category], count)
+		}
+	}
+	if metrics.ResolvedByRootCause["deploy"] != 1 || metrics.ResolvedByRootCause[Uncategorized] != 2 {
+		t.Errorf("Unexpected root cause breakdown: %v", metrics.ResolvedByRootCause)
+	}
+
+	if err := incidentService.UpdatePrometheusMetrics(ctx); err != nil {
+		t.Fatalf("Failed to update metrics: %v", err)
+	}
+	expected["duplicate"], expected["auto_recovered"] = 0, 0
+	for category, count := range expected {
+		var metric dto.Metric
+		if err := metricsService.resolvedByType.WithLabelValues(category).(prometheus.Metric).Write(&metric); err != nil {
+			t.Fatalf("Failed to read gauge: %v", err)
+		}
+		if got := metric.GetGauge().GetValue(); got != float64(count) {
+			t.Errorf("incidents_resolved_by_type{resolution_type=%q} = %v, expected %d", category, got, count)
+		}
+	}
+}
@@ -4,10 +4,14 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"net/smtp"
+	"net/url"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -16,17 +20,24 @@ import (
 	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/models"
 	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/retry"
 	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/storage"
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/validation"
 )
 
 // NotificationService handles sending notifications with enhanced features
 type NotificationService struct {
-	config                   *config.Config
-	store                   storage.Store
-	templateService         *NotificationTemplateService
-	metricsService          *MetricsService
-	logger                  *Logger
-	retryer                 *retry.Retryer
-	batchProcessor          *NotificationBatchProcessor
+	config          *config.Config
+	store           storage.Store
+	templateService *NotificationTemplateService
+	metricsService  *MetricsService
+	logger          *Logger
+	retryer         *retry.Retryer
+	batchProcessor  *NotificationBatchProcessor
+	failureMonitor  *NotificationFailureMonitor
+	fanoutLimits    map[models.IncidentSeverity]int
+
+	// now and transport are replaced in tests
+	now       func() time.Time
+	transport http.RoundTripper
 }
 
 // NewNotificationService creates a new notification service with enhanced features
@@ -37,13 +48,25 @@ func NewNotificationService(
 	metricsService *MetricsService,
 	logger *Logger,
 ) *NotificationService {
-	// Create retry policy for notifications
+	// Create retry policy for notifications; unset values keep the defaults
 	retryPolicy := &retry.RetryPolicy{
 		MaxAttempts: 3,
 		BaseDelay:   2 * time.Second,
 		MaxDelay:    30 * time.Second,
 		Multiplier:  2.0,
 	}
+	if config.NotifyRetryMaxAttempts > 0 {
+		retryPolicy.MaxAttempts = config.NotifyRetryMaxAttempts
+	}
+	if config.NotifyRetryBaseDelay > 0 {
+		retryPolicy.BaseDelay = config.NotifyRetryBaseDelay
+	}
+	if config.NotifyRetryMaxDelay > 0 {
+		retryPolicy.MaxDelay = config.NotifyRetryMaxDelay
+	}
+	if config.NotifyRetryMultiplier > 0 {
+		retryPolicy.Multiplier = config.NotifyRetryMultiplier
+	}
 	
 	retryer := retry.NewRetryer(retryPolicy, retry.DefaultIsRetryable)
 	
@@ -54,6 +77,7 @@ func NewNotificationService(
 		metricsService:  metricsService,
 		logger:          logger,
 		retryer:         retryer,
+		now:             time.Now,
 	}
 	
 	// Initialize batch processor
@@ -62,25 +86,76 @@ func NewNotificationService(
 	return service
 }
 
+// SetFailureMonitor reports failed channel deliveries to monitor
+func (s *NotificationService) SetFailureMonitor(monitor *NotificationFailureMonitor) {
+	s.failureMonitor = monitor
+}
+
+// SetFanoutLimits caps how many channels an incident of each severity is
+// sent to. Severities without a limit, or with zero, reach every channel.
+func (s *NotificationService) SetFanoutLimits(limits map[models.IncidentSeverity]int) {
+	s.fanoutLimits = limits
+}
+
+// ParseFanoutLimits parses limits written as "severity:max", e.g. "low:1"
+func ParseFanoutLimits(specs []string) (map[models.IncidentSeverity]int, error) {
+	limits := make(map[models.IncidentSeverity]int, len(specs))
+	for _, spec := range specs {
+		name, max, ok := strings.Cut(spec, ":")
+		severity := models.IncidentSeverity(strings.ToLower(strings.TrimSpace(name)))
+		if !ok || severityRank(severity) == 0 {
+			return nil, fmt.Errorf("invalid fan-out limit %q: expected severity:max", spec)
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(max))
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid fan-out limit %q: max must be a non-negative integer", spec)
+		}
+		limits[severity] = n
+	}
+	return limits, nil
+}
+
 // NotifyIncidentCreated sends notifications when an incident is created using templates
-func (s *NotificationService) NotifyIncidentCreated(incident *models.Incident) error {
-	return s.sendTemplatedNotification(incident, "incident_created")
+func (s *NotificationService) NotifyIncidentCreated(ctx context.Context, incident *models.Incident) error {
+	return s.sendTemplatedNotification(ctx, incident, "incident_created")
 }
 
 // NotifyIncidentAcknowledged sends notifications when an incident is acknowledged using templates
-func (s *NotificationService) NotifyIncidentAcknowledged(incident *models.Incident) error {
-	return s.sendTemplatedNotification(incident, "incident_acknowledged")
+func (s *NotificationService) NotifyIncidentAcknowledged(ctx context.Context, incident *models.Incident) error {
+	return s.sendTemplatedNotification(ctx, incident, "incident_acknowledged")
 }
 
 // NotifyIncidentResolved sends notifications when an incident is resolved using templates
-func (s *NotificationService) NotifyIncidentResolved(incident *models.Incident) error {
-	return s.sendTemplatedNotification(incident, "incident_resolved")
+func (s *NotificationService) NotifyIncidentResolved(ctx context.Context, incident *models.Incident) error {
+	return s.sendTemplatedNotification(ctx, incident, "incident_resolved")
+}
+
+// NotifyIncident re-sends a notification of the given type for an incident.
+// An empty type sends the notification matching the incident's current status.
+func (s *NotificationService) NotifyIncident(ctx context.Context, incident *models.Incident, notificationType string) error {
+	if notificationType == "" {
+		switch incident.Status {
+		case models.IncidentStatusAcknowledged:
+			notificationType = "incident_acknowledged"
+		case models.IncidentStatusResolved:
+			notificationType = "incident_resolved"
+		default:
+			notificationType = "incident_created"
+		}
+	}
+
+	switch notificationType {
+	case "incident_created", "incident_acknowledged", "incident_resolved":
+		return s.sendTemplatedNotification(ctx, incident, notificationType)
+	default:
+		return fmt.Errorf("unsupported notification type %q", notificationType)
+	}
 }
 
 // sendTemplatedNotification sends notifications using templates and enhanced delivery tracking
-func (s *NotificationService) sendTemplatedNotification(incident *models.Incident, notificationType string) error {
+func (s *NotificationService) sendTemplatedNotification(ctx context.Context, incident *models.Incident, notificationType string) error {
 	// Get enabled notification channels
-	channels, err := s.store.ListNotificationChannels()
+	channels, err := s.store.ListNotificationChannels(ctx)
 	if err != nil {
 		s.logger.Error("Failed to get notification channels", map[string]interface{}{
 			"error": err.Error(),
@@ -89,20 +164,11 @@ func (s *NotificationService) sendTemplatedNotification(incident *models.Inciden
 	}
 
 	var errors []string
-	
-	for _, channel := range channels {
-		if !channel.Enabled {
-			continue
-		}
-		
-		// Check user preferences if applicable
-		if !s.shouldNotify(channel, incident, notificationType) {
-			continue
-		}
-		
+
+	for _, channel := range s.fanOut(channels, incident, notificationType) {
 		// Check if batching is enabled
 		if channel.Preferences != nil && channel.Preferences.BatchingEnabled {
-			if err := s.batchProcessor.AddToBatch(incident, channel, notificationType); err != nil {
+			if err := s.batchProcessor.AddToBatch(ctx, incident, channel, notificationType); err != nil {
 				s.logger.Error("Failed to add notification to batch", map[string]interface{}{
 					"channel_id":        channel.ID,
 					"notification_type": notificationType,
@@ -114,7 +180,7 @@ func (s *NotificationService) sendTemplatedNotification(incident *models.Inciden
 		}
 		
 		// Send immediately
-		if err := s.sendNotificationToChannel(incident, channel, notificationType); err != nil {
+		if err := s.sendNotificationToChannel(ctx, incident, channel, notificationType); err != nil {
 			errors = append(errors, fmt.Sprintf("%s: %v", channel.Name, err))
 		}
 	}
@@ -131,8 +197,39 @@ func (s *NotificationService) sendTemplatedNotification(incident *models.Inciden
 	return nil
 }
 
+// fanOut selects the channels an incident notification goes to: enabled
+// channels whose preferences accept it, capped by the severity's fan-out
+// limit. When capped, the highest-priority channels are kept.
+func (s *NotificationService) fanOut(channels []*models.NotificationChannel, incident *models.Incident, notificationType string) []*models.NotificationChannel {
+	var eligible []*models.NotificationChannel
+	for _, channel := range channels {
+		if channel.Enabled && s.shouldNotify(channel, incident, notificationType) {
+			eligible = append(eligible, channel)
+		}
+	}
+
+	limit := s.fanoutLimits[incident.Severity]
+	if limit <= 0 || len(eligible) <= limit {
+		return eligible
+	}
+
+	sort.SliceStable(eligible, func(i, j int) bool {
+		if eligible[i].Priority != eligible[j].Priority {
+			return eligible[i].Priority > eligible[j].Priority
+		}
+		return eligible[i].ID < eligible[j].ID
+	})
+	s.logger.Info("Notification fan-out limited by severity", map[string]interface{}{
+		"incident_id":       incident.ID,
+		"severity":          incident.Severity,
+		"limit":             limit,
+		"eligible_channels": len(eligible),
+	})
+	return eligible[:limit]
+}
+
 // sendNotificationToChannel sends a notification to a specific channel with template support
-func (s *NotificationService) sendNotificationToChannel(incident *models.Incident, channel *models.NotificationChannel, notificationType string) error {
+func (s *NotificationService) sendNotificationToChannel(ctx context.Context, incident *models.Incident, channel *models.NotificationChannel, notificationType string) error {
 	// Create notification history entry
 	history := &models.NotificationHistory{
 		ID:         uuid.New().String(),
@@ -152,38 +249,59 @@ func (s *NotificationService) sendNotificationToChannel(incident *models.Inciden
 	}
 
 	// Store notification history
-	if err := s.storeNotificationHistory(history); err != nil {
+	if err := s.storeNotificationHistory(ctx, history); err != nil {
 		s.logger.Error("Failed to store notification history", map[string]interface{}{
-			"error": err.Error(),
+			"history_id": history.ID,
+			"error":      err.Error(),
 		})
 	}
 
-	// Send notification with retry
-	ctx := context.Background()
-	err := s.retryer.Execute(ctx, func() error {
-		return s.deliverNotification(history, template, incident, channel)
+	return s.deliverWithRetry(ctx, history, template, incident, channel)
+}
+
+// deliverWithRetry delivers a notification with retries, recording each failed
+// attempt in the history's error chain. A notification that fails every
+// attempt is dead-lettered so it can be inspected and requeued later.
+func (s *NotificationService) deliverWithRetry(ctx context.Context, history *models.NotificationHistory, template *models.NotificationTemplate, incident *models.Incident, channel *models.NotificationChannel) error {
+	notificationType := history.Type
+	err := s.retryer.Execute(context.Background(), func() error {
+		if len(history.ErrorChain) > 0 {
+			history.RetryCount++
+		}
+		attemptErr := s.deliverNotification(history, template, incident, channel)
+		if attemptErr != nil {
+			history.ErrorChain = append(history.ErrorChain, attemptErr.Error())
+		}
+		return attemptErr
 	})
 
 	// Update history status
 	if err != nil {
 		history.Status = models.DeliveryStatusFailed
+		if errors.Is(err, retry.ErrMaxAttemptsExceeded) {
+			history.Status = models.DeliveryStatusDeadLettered
+		}
 		history.ErrorMsg = err.Error()
 		history.UpdatedAt = time.Now()
-		
-		s.metricsService.RecordNotificationSent(channel.Type, "failed")
+
+		s.metricsService.RecordNotificationDelivery(channel.Type, notificationType, "failed")
 		s.logger.Error("Notification delivery failed", map[string]interface{}{
 			"channel_id":        channel.ID,
 			"channel_type":      channel.Type,
 			"notification_type": notificationType,
-			"error":            err.Error(),
+			"status":            history.Status,
+			"error":             err.Error(),
 		})
+		if s.failureMonitor != nil {
+			s.failureMonitor.RecordFailure(ctx, channel, err)
+		}
 	} else {
 		history.Status = models.DeliveryStatusSent
 		now := time.Now()
 		history.SentAt = &now
 		history.UpdatedAt = now
-		
-		s.metricsService.RecordNotificationSent(channel.Type, "sent")
+
+		s.metricsService.RecordNotificationDelivery(channel.Type, notificationType, "sent")
 		s.logger.Info("Notification sent successfully", map[string]interface{}{
 			"channel_id":        channel.ID,
 			"channel_type":      channel.Type,
@@ -192,9 +310,10 @@ func (s *NotificationService) sendNotificationToChannel(incident *models.Inciden
 	}
 
 	// Update history
-	if updateErr := s.updateNotificationHistory(history); updateErr != nil {
+	if updateErr := s.updateNotificationHistory(ctx, history); updateErr != nil {
 		s.logger.Error("Failed to update notification history", map[string]interface{}{
-			"error": updateErr.Error(),
+			"history_id": history.ID,
+			"error":      updateErr.Error(),
 		})
 	}
 
@@ -208,7 +327,7 @@ func (s *NotificationService) deliverNotification(history *models.NotificationHi
 		Incident:    incident,
 		Timestamp:   time.Now(),
 		SystemName:  "Incident Management System",
-		SystemURL:   "http://localhost:" + s.config.Port, // Use configured port
+		SystemURL:   s.systemURL(),
 		ChannelName: channel.Name,
 		Severity:    string(incident.Severity),
 		Status:      string(incident.Status),
@@ -233,7 +352,11 @@ func (s *NotificationService) deliverNotification(history *models.NotificationHi
 	history.Subject = subject
 	history.Content = content
 	
-	// Send based on channel type
+	return s.sendToChannel(channel, subject, content, incident)
+}
+
+// sendToChannel sends already-rendered content using the channel's transport
+func (s *NotificationService) sendToChannel(channel *models.NotificationChannel, subject, content string, incident *models.Incident) error {
 	switch channel.Type {
 	case "slack":
 		return s.sendSlackNotificationWithConfig(content, channel.Config)
@@ -241,11 +364,26 @@ func (s *NotificationService) deliverNotification(history *models.NotificationHi
 		return s.sendEmailNotificationWithConfig(subject, content, channel.Config, incident)
 	case "telegram":
 		return s.sendTelegramNotificationWithConfig(content, channel.Config)
+	case "webhook":
+		return s.sendWebhookNotificationWithConfig(subject, content, channel.Config, incident)
+	case "msteams":
+		return s.sendMSTeamsNotificationWithConfig(subject, content, channel.Config, incident)
+	case "pagerduty":
+		return s.sendPagerDutyNotificationWithConfig(subject, channel.Config, incident)
+	case "sms":
+		return s.sendSMSNotificationWithConfig(subject, channel.Config, incident)
 	default:
 		return fmt.Errorf("unsupported channel type: %s", channel.Type)
 	}
 }
 
+// sendRendered sends content that is not tied to a single incident, such as a digest
+func (s *NotificationService) sendRendered(channel *models.NotificationChannel, subject, content string) error {
+	return s.retryer.Execute(context.Background(), func() error {
+		return s.sendToChannel(channel, subject, content, nil)
+	})
+}
+
 // sendNotifications sends notifications via all configured channels
 func (s *NotificationService) sendNotifications(message string, incident *models.Incident) error {
 	var errors []string
@@ -538,6 +676,335 @@ func (s *NotificationService) sendTelegramNotificationWithConfig(message string,
 	return nil
 }
 
+// WebhookMessage is the JSON body posted to webhook channels
+type WebhookMessage struct {
+	Subject   string           `json:"subject"`
+	Content   string           `json:"content"`
+	Incident  *models.Incident `json:"incident,omitempty"`
+	Timestamp time.Time        `json:"timestamp"`
+}
+
+// sendWebhookNotificationWithConfig posts a notification to a generic webhook.
+// When the channel has a "secret", the body is signed with HMAC-SHA256 and the
+// hex digest is sent as "sha256=<digest>" in the "signature_header" header
+// (default X-Signature). The signature covers the exact bytes of the body.
+func (s *NotificationService) sendWebhookNotificationWithConfig(subject, content string, config map[string]string, incident *models.Incident) error {
+	url := config["url"]
+	if url == "" {
+		return fmt.Errorf("webhook url is required")
+	}
+
+	jsonData, err := json.Marshal(WebhookMessage{
+		Subject:   subject,
+		Content:   content,
+		Incident:  incident,
+		Timestamp: time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", url, bytes.NewReader(jsonData))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	if secret := config["secret"]; secret != "" {
+		header := config["signature_header"]
+		if header == "" {
+			header = validation.SignatureHeader
+		}
+		req.Header.Set(header, "sha256="+validation.Sign(secret, jsonData))
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// MSTeamsMessageCard is the legacy actionable message card accepted by
+// Microsoft Teams incoming webhooks
+type MSTeamsMessageCard struct {
+	Type       string           `json:"@type"`
+	Context    string           `json:"@context"`
+	Summary    string           `json:"summary"`
+	ThemeColor string           `json:"themeColor,omitempty"`
+	Title      string           `json:"title"`
+	Text       string           `json:"text"`
+	Sections   []MSTeamsSection `json:"sections,omitempty"`
+}
+
+// MSTeamsSection groups facts shown on a message card
+type MSTeamsSection struct {
+	Facts []MSTeamsFact `json:"facts"`
+}
+
+// MSTeamsFact is a name/value pair shown on a message card
+type MSTeamsFact struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// msTeamsThemeColors colors the card's accent bar by incident severity
+var msTeamsThemeColors = map[models.IncidentSeverity]string{
+	models.SeverityCritical: "D13438",
+	models.SeverityHigh:     "FF8C00",
+	models.SeverityMedium:   "FFB900",
+	models.SeverityLow:      "0078D7",
+}
+
+// sendMSTeamsNotificationWithConfig posts a message card to the Microsoft
+// Teams incoming webhook in config["webhook_url"]. Incident notifications show
+// the incident's title, severity and status.
+func (s *NotificationService) sendMSTeamsNotificationWithConfig(subject, content string, config map[string]string, incident *models.Incident) error {
+	url := config["webhook_url"]
+	if url == "" {
+		return fmt.Errorf("msteams webhook_url is required")
+	}
+
+	card := MSTeamsMessageCard{
+		Type:    "MessageCard",
+		Context: "https://schema.org/extensions",
+		Summary: subject,
+		Title:   subject,
+		Text:    content,
+	}
+	if incident != nil {
+		card.Title = incident.Title
+		card.ThemeColor = msTeamsThemeColors[incident.Severity]
+		card.Sections = []MSTeamsSection{{
+			Facts: []MSTeamsFact{
+				{Name: "Severity", Value: string(incident.Severity)},
+				{Name: "Status", Value: string(incident.Status)},
+				{Name: "Incident ID", Value: incident.ID},
+			},
+		}}
+	}
+
+	jsonData, err := json.Marshal(card)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", url, bytes.NewReader(jsonData))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("msteams webhook returned status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// PagerDutyEventsURL is the PagerDuty Events API v2 endpoint. Channels may
+// override it with config["events_url"], e.g. for the EU service region.
+const PagerDutyEventsURL = "https://events.pagerduty.com/v2/enqueue"
+
+// PagerDutyEvent is an Events API v2 event
+type PagerDutyEvent struct {
+	RoutingKey  string            `json:"routing_key"`
+	EventAction string            `json:"event_action"` // trigger, acknowledge or resolve
+	DedupKey    string            `json:"dedup_key"`
+	Payload     *PagerDutyPayload `json:"payload,omitempty"`
+}
+
+// PagerDutyPayload describes the alert of a trigger event
+type PagerDutyPayload struct {
+	Summary       string           `json:"summary"`
+	Source        string           `json:"source"`
+	Severity      string           `json:"severity"`
+	Timestamp     string           `json:"timestamp,omitempty"`
+	CustomDetails *models.Incident `json:"custom_details,omitempty"`
+}
+
+// pagerDutySeverities maps incident severities to PagerDuty's
+var pagerDutySeverities = map[models.IncidentSeverity]string{
+	models.SeverityCritical: "critical",
+	models.SeverityHigh:     "error",
+	models.SeverityMedium:   "warning",
+	models.SeverityLow:      "info",
+}
+
+// pagerDutyDedupKey identifies an incident's PagerDuty alert so later
+// acknowledge and resolve events apply to it
+func pagerDutyDedupKey(incident *models.Incident) string {
+	return "incd-" + incident.ID
+}
+
+// sendPagerDutyNotificationWithConfig sends an incident to PagerDuty with the
+// integration key in config["routing_key"]. The event action follows the
+// incident's status, so resolving the incident closes the PagerDuty alert.
+func (s *NotificationService) sendPagerDutyNotificationWithConfig(subject string, config map[string]string, incident *models.Incident) error {
+	routingKey := config["routing_key"]
+	if routingKey == "" {
+		return fmt.Errorf("pagerduty routing_key is required")
+	}
+	if incident == nil {
+		return fmt.Errorf("pagerduty notifications require an incident")
+	}
+
+	event := PagerDutyEvent{
+		RoutingKey: routingKey,
+		DedupKey:   pagerDutyDedupKey(incident),
+	}
+	switch incident.Status {
+	case models.IncidentStatusResolved:
+		event.EventAction = "resolve"
+	case models.IncidentStatusAcknowledged:
+		event.EventAction = "acknowledge"
+	default:
+		source := config["source"]
+		if source == "" {
+			source = "incident-management-system"
+		}
+		severity := pagerDutySeverities[incident.Severity]
+		if severity == "" {
+			severity = "error"
+		}
+		event.EventAction = "trigger"
+		event.Payload = &PagerDutyPayload{
+			Summary:       subject,
+			Source:        source,
+			Severity:      severity,
+			Timestamp:     incident.CreatedAt.UTC().Format(time.RFC3339),
+			CustomDetails: incident,
+		}
+	}
+
+	jsonData, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	url := config["events_url"]
+	if url == "" {
+		url = PagerDutyEventsURL
+	}
+	req, err := http.NewRequest("POST", url, bytes.NewReader(jsonData))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("pagerduty returned status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// TwilioAPIURL is the base URL of the Twilio REST API
+const TwilioAPIURL = "https://api.twilio.com/2010-04-01"
+
+// smsMaxLength keeps SMS notifications within a single message segment
+const smsMaxLength = 160
+
+// TwilioError is the error body returned by the Twilio REST API
+type TwilioError struct {
+	Code     int    `json:"code"`
+	Message  string `json:"message"`
+	MoreInfo string `json:"more_info"`
+}
+
+// systemURL returns the base URL used to link to incidents
+func (s *NotificationService) systemURL() string {
+	return "http://localhost:" + s.config.Port
+}
+
+// smsBody builds a short SMS text with the incident's severity, title and a
+// link to it, shortening the title to keep the text in one segment
+func (s *NotificationService) smsBody(subject string, incident *models.Incident) string {
+	if incident == nil {
+		return truncateRunes(subject, smsMaxLength)
+	}
+
+	prefix := fmt.Sprintf("[%s] ", strings.ToUpper(string(incident.Severity)))
+	link := fmt.Sprintf("\n%s/incidents/%s", s.systemURL(), incident.ID)
+	title := truncateRunes(incident.Title, smsMaxLength-len([]rune(prefix))-len([]rune(link)))
+	return prefix + title + link
+}
+
+// truncateRunes shortens text to at most max runes, ending it with an
+// ellipsis when it was cut
+func truncateRunes(text string, max int) string {
+	runes := []rune(text)
+	if len(runes) <= max {
+		return text
+	}
+	if max <= 1 {
+		return string(runes[:max])
+	}
+	return string(runes[:max-1]) + "…"
+}
+
+// sendSMSNotificationWithConfig texts an incident through the Twilio Messages
+// API using config["account_sid"], config["auth_token"], config["from"] and
+// config["to"]. Twilio error codes are included in the returned error.
+func (s *NotificationService) sendSMSNotificationWithConfig(subject string, config map[string]string, incident *models.Incident) error {
+	for _, key := range []string{"account_sid", "auth_token", "from", "to"} {
+		if config[key] == "" {
+			return fmt.Errorf("sms %s is required", key)
+		}
+	}
+
+	form := url.Values{}
+	form.Set("From", config["from"])
+	form.Set("To", config["to"])
+	form.Set("Body", s.smsBody(subject, incident))
+
+	endpoint := fmt.Sprintf("%s/Accounts/%s/Messages.json", TwilioAPIURL, url.PathEscape(config["account_sid"]))
+	req, err := http.NewRequest("POST", endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(config["account_sid"], config["auth_token"])
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	client := &http.Client{Timeout: 10 * time.Second, Transport: s.transport}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var twilioErr TwilioError
+		if err := json.NewDecoder(resp.Body).Decode(&twilioErr); err == nil && twilioErr.Code != 0 {
+			return fmt.Errorf("twilio returned status %d: error %d: %s", resp.StatusCode, twilioErr.Code, twilioErr.Message)
+		}
+		return fmt.Errorf("twilio returned status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
 // shouldNotify checks if a notification should be sent based on preferences
 func (s *NotificationService) shouldNotify(channel *models.NotificationChannel, incident *models.Incident, notificationType string) bool {
 	if channel.Preferences == nil {
@@ -562,10 +1029,10 @@ func (s *NotificationService) shouldNotify(channel *models.NotificationChannel,
 			return false
 		}
 	}
-	
-	// Check quiet hours
-	if channel.Preferences.QuietHours != nil && channel.Preferences.QuietHours.Enabled {
-		if s.isInQuietHours(channel.Preferences.QuietHours) {
+
+	// Check quiet hours; severe enough incidents break through
+	if quiet := channel.Preferences.QuietHours; quiet != nil && quiet.Enabled {
+		if s.isInQuietHours(quiet, s.now()) && !overridesQuietHours(quiet, incident.Severity) {
 			return false
 		}
 	}
@@ -573,44 +1040,115 @@ func (s *NotificationService) shouldNotify(channel *models.NotificationChannel,
 	return true
 }
 
-// isInQuietHours checks if current time is within quiet hours
-func (s *NotificationService) isInQuietHours(config *models.QuietHoursConfig) bool {
-	// Simple implementation - can be enhanced with timezone support
-	now := time.Now()
-	currentHour := now.Hour()
-	currentDay := int(now.Weekday())
-	
-	// Check if current day is in quiet days
-	if len(config.Days) > 0 {
-		dayMatch := false
-		for _, day := range config.Days {
-			if day == currentDay {
-				dayMatch = true
-				break
-			}
-		}
-		if !dayMatch {
-			return false
+// overridesQuietHours reports whether an incident of the given severity
+// notifies despite the channel's quiet hours
+func overridesQuietHours(config *models.QuietHoursConfig, severity models.IncidentSeverity) bool {
+	minimum := severityRank(config.OverrideSeverity)
+	return minimum > 0 && severityRank(severity) >= minimum
+}
+
+// isInQuietHours checks if the given time is within quiet hours. Start, end
+// and days are evaluated in the configured timezone; an overnight window
+// (e.g. 22:30 - 06:15) belongs to the day it starts on, so its early-morning
+// part is checked against the previous day.
+func (s *NotificationService) isInQuietHours(config *models.QuietHoursConfig, now time.Time) bool {
+	if config.Timezone != "" {
+		location, err := time.LoadLocation(config.Timezone)
+		if err != nil {
+			s.logger.Warn("Ignoring invalid quiet hours timezone", map[string]interface{}{
+				"timezone": config.Timezone,
+				"error":    err.Error(),
+			})
+		} else {
+			now = now.In(location)
 		}
 	}
-	
-	// Parse start and end times (simplified)
-	startHour := 0
-	endHour := 24
+
+	start, end := 0, 24*60
 	if config.StartTime != "" {
-		fmt.Sscanf(config.StartTime, "%d:", &startHour)
+		if minutes, err := parseClockTime(config.StartTime); err == nil {
+			start = minutes
+		}
 	}
 	if config.EndTime != "" {
-		fmt.Sscanf(config.EndTime, "%d:", &endHour)
+		if minutes, err := parseClockTime(config.EndTime); err == nil {
+			end = minutes
+		}
 	}
-	
-	// Check if current time is within quiet hours
-	if startHour <= endHour {
-		return currentHour >= startHour && currentHour < endHour
-	} else {
-		// Overnight quiet hours (e.g., 22:00 - 06:00)
-		return currentHour >= startHour || currentHour < endHour
+	current := now.Hour()*60 + now.Minute()
+
+	windowStart := now
+	switch {
+	case start <= end:
+		if current < start || current >= end {
+			return false
+		}
+	case current >= start:
+		// Overnight quiet hours, before midnight
+	case current < end:
+		// Overnight quiet hours, after midnight: the window began yesterday
+		windowStart = now.AddDate(0, 0, -1)
+	default:
+		return false
+	}
+
+	if len(config.Days) == 0 {
+		return true
+	}
+	for _, day := range config.Days {
+		if day == int(windowStart.Weekday()) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseClockTime parses an HH:MM time of day into minutes since midnight.
+// 24:00 is accepted as the end of the day.
+func parseClockTime(value string) (int, error) {
+	hours, minutes, found := strings.Cut(value, ":")
+	if !found {
+		return 0, fmt.Errorf("invalid time %q: expected HH:MM", value)
+	}
+	h, err := strconv.Atoi(hours)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q: expected HH:MM", value)
+	}
+	m, err := strconv.Atoi(minutes)
+	if err != nil || len(minutes) != 2 {
+		return 0, fmt.Errorf("invalid time %q: expected HH:MM", value)
+	}
+	if h < 0 || m < 0 || m > 59 || h > 24 || (h == 24 && m != 0) {
+		return 0, fmt.Errorf("invalid time %q: out of range", value)
+	}
+	return h*60 + m, nil
+}
+
+// ValidateQuietHours checks that a quiet hours configuration has HH:MM start
+// and end times, a known IANA timezone and days from 0 (Sunday) to 6
+func ValidateQuietHours(config *models.QuietHoursConfig) error {
+	if config == nil {
+		return nil
+	}
+	for _, value := range []string{config.StartTime, config.EndTime} {
+		if value == "" {
+			continue
+		}
+		if _, err := parseClockTime(value); err != nil {
+			return err
+		}
+	}
+	if config.Timezone != "" {
+		if _, err := time.LoadLocation(config.Timezone); err != nil {
+			return fmt.Errorf("invalid timezone %q", config.Timezone)
+		}
 	}
+	for _, day := range config.Days {
+		if day < 0 || day > 6 {
+			return fmt.Errorf("invalid day %d: expected 0 (Sunday) to 6 (Saturday)", day)
+		}
+	}
+	return nil
 }
 
 // getTemplateForChannel gets the appropriate template for a channel and notification type
@@ -628,8 +1166,20 @@ func (s *NotificationService) getTemplateForChannel(channel *models.Notification
 		}
 	}
 	
-	// Fall back to default template
-	return s.templateService.GetDefaultTemplate(notificationType, channel.Type)
+	// Fall back to the built-in template in the channel's language
+	return s.templateService.GetLocalizedTemplate(notificationType, channel.Type, channelLocale(channel))
+}
+
+// channelLocale returns the locale configured on the channel, falling back to
+// the channel owner's preferred locale
+func channelLocale(channel *models.NotificationChannel) string {
+	if channel.Locale != "" {
+		return channel.Locale
+	}
+	if channel.Preferences != nil {
+		return channel.Preferences.Locale
+	}
+	return ""
 }
 
 // generateLegacyMessage generates a legacy-format message for backward compatibility
@@ -670,29 +1220,15 @@ func (s *NotificationService) generateLegacyMessage(incident *models.Incident, n
 	}
 }
 
-// storeNotificationHistory stores notification history (placeholder implementation)
-func (s *NotificationService) storeNotificationHistory(history *models.NotificationHistory) error {
-	// In a real implementation, this would store to the database
-	// For now, we'll just log it
-	s.logger.Info("Storing notification history", map[string]interface{}{
-		"id":         history.ID,
-		"incident_id": history.IncidentID,
-		"channel_id": history.ChannelID,
-		"type":       history.Type,
-		"status":     history.Status,
-	})
-	return nil
+// storeNotificationHistory records a notification attempt. Callers log
+// failures and carry on, so a history outage never blocks delivery.
+func (s *NotificationService) storeNotificationHistory(ctx context.Context, history *models.NotificationHistory) error {
+	return s.store.CreateNotificationHistory(ctx, history)
 }
 
-// updateNotificationHistory updates notification history (placeholder implementation)
-func (s *NotificationService) updateNotificationHistory(history *models.NotificationHistory) error {
-	// In a real implementation, this would update the database record
-	s.logger.Info("Updating notification history", map[string]interface{}{
-		"id":     history.ID,
-		"status": history.Status,
-		"error":  history.ErrorMsg,
-	})
-	return nil
+// updateNotificationHistory records the outcome of a notification attempt
+func (s *NotificationService) updateNotificationHistory(ctx context.Context, history *models.NotificationHistory) error {
+	return s.store.UpdateNotificationHistory(ctx, history)
 }
 
 // SendTestNotification sends a test notification to verify channel configuration
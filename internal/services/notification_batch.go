@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"sync"
@@ -15,6 +16,7 @@ type NotificationBatchProcessor struct {
 	service      *NotificationService
 	logger       *Logger
 	batches      map[string]*models.NotificationBatch
+	histories    map[string]*models.NotificationHistory // batched entries by history ID
 	mutex        sync.RWMutex
 	ticker       *time.Ticker
 	stopChan     chan bool
@@ -27,19 +29,20 @@ func NewNotificationBatchProcessor(service *NotificationService, logger *Logger)
 		service:      service,
 		logger:       logger,
 		batches:      make(map[string]*models.NotificationBatch),
+		histories:    make(map[string]*models.NotificationHistory),
 		stopChan:     make(chan bool),
 		batchTimeout: 5 * time.Minute, // default batch timeout
 	}
 	
 	// Start the batch processing ticker
 	processor.ticker = time.NewTicker(1 * time.Minute) // check every minute
-	go processor.processBatches()
-	
+	go processor.processBatches(context.Background())
+
 	return processor
 }
 
 // AddToBatch adds a notification to a batch for the given channel
-func (bp *NotificationBatchProcessor) AddToBatch(incident *models.Incident, channel *models.NotificationChannel, notificationType string) error {
+func (bp *NotificationBatchProcessor) AddToBatch(ctx context.Context, incident *models.Incident, channel *models.NotificationChannel, notificationType string) error {
 	bp.mutex.Lock()
 	defer bp.mutex.Unlock()
 	
@@ -75,10 +78,15 @@ func (bp *NotificationBatchProcessor) AddToBatch(incident *models.Incident, chan
 	}
 	
 	// Store notification history
-	if err := bp.service.storeNotificationHistory(history); err != nil {
-		return fmt.Errorf("failed to store notification history: %w", err)
+	if err := bp.service.storeNotificationHistory(ctx, history); err != nil {
+		bp.logger.Error("Failed to store notification history", map[string]interface{}{
+			"history_id": historyID,
+			"error":      err.Error(),
+		})
 	}
-	
+
+	bp.histories[historyID] = history
+
 	// Add to batch
 	batch.Notifications = append(batch.Notifications, historyID)
 	batch.Count++
@@ -98,18 +106,18 @@ func (bp *NotificationBatchProcessor) AddToBatch(incident *models.Incident, chan
 	}
 	
 	if batch.Count >= maxBatchSize {
-		return bp.processBatch(batch, channel)
+		return bp.processBatch(ctx, batch, channel)
 	}
 	
 	return nil
 }
 
 // processBatches periodically processes pending batches
-func (bp *NotificationBatchProcessor) processBatches() {
+func (bp *NotificationBatchProcessor) processBatches(ctx context.Context) {
 	for {
 		select {
 		case <-bp.ticker.C:
-			bp.processTimedOutBatches()
+			bp.processTimedOutBatches(ctx)
 		case <-bp.stopChan:
 			return
 		}
@@ -117,7 +125,7 @@ func (bp *NotificationBatchProcessor) processBatches() {
 }
 
 // processTimedOutBatches processes batches that have timed out
-func (bp *NotificationBatchProcessor) processTimedOutBatches() {
+func (bp *NotificationBatchProcessor) processTimedOutBatches(ctx context.Context) {
 	bp.mutex.Lock()
 	defer bp.mutex.Unlock()
 	
@@ -127,7 +135,7 @@ func (bp *NotificationBatchProcessor) processTimedOutBatches() {
 		// Check if batch has timed out
 		if now.Sub(batch.CreatedAt) > bp.batchTimeout {
 			// Get channel info for processing
-			channel, err := bp.service.store.GetNotificationChannel(batch.ChannelID)
+			channel, err := bp.service.store.GetNotificationChannel(ctx, batch.ChannelID)
 			if err != nil {
 				bp.logger.Error("Failed to get channel for batch processing", map[string]interface{}{
 					"batch_id":   batch.ID,
@@ -138,7 +146,7 @@ func (bp *NotificationBatchProcessor) processTimedOutBatches() {
 			}
 			
 			// Process the batch
-			if err := bp.processBatch(batch, channel); err != nil {
+			if err := bp.processBatch(ctx, batch, channel); err != nil {
 				bp.logger.Error("Failed to process timed out batch", map[string]interface{}{
 					"batch_id": batch.ID,
 					"error":    err.Error(),
@@ -152,7 +160,7 @@ func (bp *NotificationBatchProcessor) processTimedOutBatches() {
 }
 
 // processBatch processes a single batch
-func (bp *NotificationBatchProcessor) processBatch(batch *models.NotificationBatch, channel *models.NotificationChannel) error {
+func (bp *NotificationBatchProcessor) processBatch(ctx context.Context, batch *models.NotificationBatch, channel *models.NotificationChannel) error {
 	bp.logger.Info("Processing notification batch", map[string]interface{}{
 		"batch_id":   batch.ID,
 		"channel_id": channel.ID,
@@ -198,6 +206,12 @@ func (bp *NotificationBatchProcessor) processBatch(batch *models.NotificationBat
 		err = bp.service.sendEmailNotificationWithConfig(subject, content, channel.Config, nil)
 	case "telegram":
 		err = bp.service.sendTelegramNotificationWithConfig(content, channel.Config)
+	case "webhook":
+		err = bp.service.sendWebhookNotificationWithConfig(subject, content, channel.Config, nil)
+	case "msteams":
+		err = bp.service.sendMSTeamsNotificationWithConfig(subject, content, channel.Config, nil)
+	case "sms":
+		err = bp.service.sendSMSNotificationWithConfig(subject, channel.Config, nil)
 	default:
 		err = fmt.Errorf("unsupported channel type: %s", channel.Type)
 	}
@@ -208,19 +222,12 @@ func (bp *NotificationBatchProcessor) processBatch(batch *models.NotificationBat
 			"batch_id": batch.ID,
 			"error":    err.Error(),
 		})
+		bp.updateHistories(ctx, batch, err)
 		return err
 	}
-	
-	// Update all individual notification histories
-	for _, historyID := range batch.Notifications {
-		// In a real implementation, we would update the database
-		bp.logger.Info("Updated batched notification status", map[string]interface{}{
-			"history_id": historyID,
-			"status":     "sent",
-			"batch_id":   batch.ID,
-		})
-	}
-	
+
+	bp.updateHistories(ctx, batch, nil)
+
 	bp.logger.Info("Batch processed successfully", map[string]interface{}{
 		"batch_id":   batch.ID,
 		"channel_id": channel.ID,
@@ -230,6 +237,38 @@ func (bp *NotificationBatchProcessor) processBatch(batch *models.NotificationBat
 	return nil
 }
 
+// updateHistories records the outcome of a batch delivery on each batched
+// notification's history. Entries stay tracked after a failure since the
+// batch is retried.
+func (bp *NotificationBatchProcessor) updateHistories(ctx context.Context, batch *models.NotificationBatch, deliveryErr error) {
+	now := time.Now()
+	for _, historyID := range batch.Notifications {
+		history, ok := bp.histories[historyID]
+		if !ok {
+			continue
+		}
+		history.UpdatedAt = now
+		if deliveryErr != nil {
+			history.Status = models.DeliveryStatusFailed
+			history.ErrorMsg = deliveryErr.Error()
+			history.RetryCount++
+		} else {
+			history.Status = models.DeliveryStatusSent
+			history.ErrorMsg = ""
+			history.SentAt = &now
+			delete(bp.histories, historyID)
+		}
+
+		if err := bp.service.updateNotificationHistory(ctx, history); err != nil {
+			bp.logger.Error("Failed to update notification history", map[string]interface{}{
+				"history_id": historyID,
+				"batch_id":   batch.ID,
+				"error":      err.Error(),
+			})
+		}
+	}
+}
+
 // createBatchedMessage creates a message for batched notifications
 func (bp *NotificationBatchProcessor) createBatchedMessage(incidents []*models.Incident, notificationType string, template *models.NotificationTemplate) string {
 	if len(incidents) == 0 {
@@ -0,0 +1,58 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/models"
+)
+
+// ErrNotDeadLettered is returned when requeueing a notification that is not
+// in the dead_lettered status
+var ErrNotDeadLettered = errors.New("notification is not dead-lettered")
+
+// ListDeadLetters returns the notifications that exhausted their retries,
+// newest first
+func (s *NotificationService) ListDeadLetters(ctx context.Context) ([]*models.NotificationHistory, error) {
+	return s.store.ListNotificationHistoryByStatus(ctx, models.DeliveryStatusDeadLettered)
+}
+
+// RequeueDeadLetter redelivers a dead-lettered notification in the background
+// with the full retry policy. The entry is marked retrying right away; a
+// delivery that fails again adds to its error chain and dead-letters it anew.
+func (s *NotificationService) RequeueDeadLetter(ctx context.Context, id string) (*models.NotificationHistory, error) {
+	history, err := s.store.GetNotificationHistory(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if history.Status != models.DeliveryStatusDeadLettered {
+		return nil, ErrNotDeadLettered
+	}
+
+	incident, err := s.store.GetIncident(ctx, history.IncidentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load incident %s: %w", history.IncidentID, err)
+	}
+	channel, err := s.store.GetNotificationChannel(ctx, history.ChannelID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load channel %s: %w", history.ChannelID, err)
+	}
+
+	history.Status = models.DeliveryStatusRetrying
+	history.UpdatedAt = time.Now()
+	if err := s.updateNotificationHistory(ctx, history); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Requeued dead-lettered notification", map[string]interface{}{
+		"history_id":  history.ID,
+		"incident_id": incident.ID,
+		"channel_id":  channel.ID,
+	})
+
+	requeued := *history
+	go s.deliverWithRetry(context.Background(), history, s.getTemplateForChannel(channel, history.Type), incident, channel)
+	return &requeued, nil
+}
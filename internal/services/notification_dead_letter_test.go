@@ -0,0 +1,161 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/config"
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/models"
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/retry"
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/storage"
+)
+
+func TestNotificationDeadLetter(t *testing.T) {
+	ctx := context.Background()
+	store, err := storage.NewMemoryStore()
+	if err != nil {
+		t.Fatalf("Failed to create memory store: %v", err)
+	}
+	logger := NewLogger("error", false)
+	cfg := &config.Config{Port: "8080", NotifyRetryMaxAttempts: 4}
+	notificationService := NewNotificationService(cfg, store, NewNotificationTemplateService(logger), NewMetricsService(prometheus.NewRegistry()), logger)
+	notificationService.retryer = retry.NewRetryer(&retry.RetryPolicy{
+		MaxAttempts: cfg.NotifyRetryMaxAttempts, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond, Multiplier: 1,
+	}, retry.DefaultIsRetryable)
+
+	var attempts int32
+	var healthy atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	channel := &models.NotificationChannel{
+		ID: "ops-hook", Name: "ops", Type: "webhook", Enabled: true,
+		Config: map[string]string{"url": server.URL},
+	}
+	if err := store.CreateNotificationChannel(ctx, channel); err != nil {
+		t.Fatalf("Failed to create channel: %v", err)
+	}
+	incident := &models.Incident{ID: "incident-1", Title: "Checkout down", Severity: models.SeverityCritical, Status: models.IncidentStatusOpen, CreatedAt: time.Now()}
+	if err := store.CreateIncident(ctx, incident); err != nil {
+		t.Fatalf("Failed to create incident: %v", err)
+	}
+
+	if err := notificationService.NotifyIncidentCreated(ctx, incident); err == nil {
+		t.Fatal("Expected the failing channel to report an error")
+	}
+	if got := atomic.LoadInt32(&attempts); got != 4 {
+		t.Errorf("Expected 4 delivery attempts, got %d", got)
+	}
+
+	deadLetters, err := notificationService.ListDeadLetters(ctx)
+	if err != nil || len(deadLetters) != 1 {
+		t.Fatalf("Expected one dead-lettered notification, got %d (err: %v)", len(deadLetters), err)
+	}
+	entry := deadLetters[0]
+	if entry.Status != models.DeliveryStatusDeadLettered || entry.ChannelID != channel.ID || entry.IncidentID != incident.ID {
+		t.Errorf("Unexpected dead-lettered entry: %+v", entry)
+	}
+	if len(entry.ErrorChain) != 4 || entry.RetryCount != 3 {
+		t.Errorf("Expected 4 recorded errors and 3 retries, got %d errors and %d retries: %v", len(entry.ErrorChain), entry.RetryCount, entry.ErrorChain)
+	}
+	if entry.ErrorMsg == "" {
+		t.Errorf("Expected the final error to be recorded, got %q", entry.ErrorMsg)
+	}
+
+	healthy.Store(true)
+	requeued, err := notificationService.RequeueDeadLetter(ctx, entry.ID)
+	if err != nil {
+		t.Fatalf("Failed to requeue: %v", err)
+	}
+	if requeued.Status != models.DeliveryStatusRetrying {
+		t.Errorf("Expected the requeued entry to be retrying, got %s", requeued.Status)
+	}
+	if _, err := notificationService.RequeueDeadLetter(ctx, entry.ID); !errors.Is(err, ErrNotDeadLettered) {
+		t.Errorf("Expected a second requeue to be rejected, got %v", err)
+	}
+
+	var delivered *models.NotificationHistory
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+		if delivered, err = store.GetNotificationHistory(ctx, entry.ID); err == nil && delivered.Status == models.DeliveryStatusSent {
+			break
+		}
+	}
+	if delivered == nil || delivered.Status != models.DeliveryStatusSent {
+		t.Fatalf("Expected the requeued notification to be sent, got %+v", delivered)
+	}
+	if len(delivered.ErrorChain) != 4 || delivered.RetryCount != 4 {
+		t.Errorf("Expected the error chain kept and the redelivery counted as a retry, got %d errors and %d retries", len(delivered.ErrorChain), delivered.RetryCount)
+	}
+	if remaining, _ := notificationService.ListDeadLetters(ctx); len(remaining) != 0 {
+		t.Errorf("Expected no dead-lettered notifications after redelivery, got %d", len(remaining))
+	}
+}
+
+func TestNotificationDeadLetter_ThreeFailures(t *testing.T) {
+	ctx := context.Background()
+	store, err := storage.NewMemoryStore()
+	if err != nil {
+		t.Fatalf("Failed to create memory store: %v", err)
+	}
+	logger := NewLogger("error", false)
+	cfg := &config.Config{
+		Port:                   "8080",
+		NotifyRetryMaxAttempts: 3,
+		NotifyRetryBaseDelay:   time.Millisecond,
+		NotifyRetryMaxDelay:    time.Millisecond,
+		NotifyRetryMultiplier:  1,
+	}
+	notificationService := NewNotificationService(cfg, store, NewNotificationTemplateService(logger), NewMetricsService(prometheus.NewRegistry()), logger)
+
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	channel := &models.NotificationChannel{
+		ID: "pager-hook", Name: "pager", Type: "webhook", Enabled: true,
+		Config: map[string]string{"url": server.URL},
+	}
+	if err := store.CreateNotificationChannel(ctx, channel); err != nil {
+		t.Fatalf("Failed to create channel: %v", err)
+	}
+	incident := &models.Incident{ID: "incident-2", Title: "Provider outage", Severity: models.SeverityCritical, Status: models.IncidentStatusOpen, CreatedAt: time.Now()}
+	if err := store.CreateIncident(ctx, incident); err != nil {
+		t.Fatalf("Failed to create incident: %v", err)
+	}
+
+	if err := notificationService.NotifyIncidentCreated(ctx, incident); err == nil {
+		t.Fatal("Expected the failing channel to report an error")
+	}
+	if got := atomic.LoadInt32(&attempts); got != 3 {
+		t.Fatalf("Expected 3 delivery attempts, got %d", got)
+	}
+
+	rows, err := store.ListNotificationHistoryByStatus(ctx, models.DeliveryStatusDeadLettered)
+	if err != nil {
+		t.Fatalf("Failed to list dead-lettered notifications: %v", err)
+	}
+	if len(rows) != 1 {
+		t.Fatalf("Expected one dead-letter row, got %d", len(rows))
+	}
+	if rows[0].IncidentID != incident.ID || rows[0].ChannelID != channel.ID || len(rows[0].ErrorChain) != 3 {
+		t.Errorf("Unexpected dead-letter row: %+v", rows[0])
+	}
+	if failed, _ := store.ListNotificationHistoryByStatus(ctx, models.DeliveryStatusFailed); len(failed) != 0 {
+		t.Errorf("Expected no entries left merely failed, got %d", len(failed))
+	}
+}
@@ -0,0 +1,134 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/models"
+)
+
+// DefaultNotificationFailureWindow is the window failures are counted over
+// unless configured otherwise
+const DefaultNotificationFailureWindow = 10 * time.Minute
+
+// NotificationFailureMonitor raises a meta-alert when delivery to a channel
+// keeps failing, e.g. because its token expired. The alert goes to an admin
+// channel, or becomes a system incident when no usable admin channel exists.
+type NotificationFailureMonitor struct {
+	threshold       int
+	window          time.Duration
+	adminChannelID  string
+	incidentService *IncidentService
+	notifier        *NotificationService
+	logger          *Logger
+
+	// now and deliver are replaced in tests
+	now     func() time.Time
+	deliver func(channel *models.NotificationChannel, subject, content string) error
+
+	mutex    sync.Mutex
+	failures map[string][]time.Time // channelID -> failure times within the window
+	alerted  map[string]bool        // channels with an outstanding meta-alert
+}
+
+// NewNotificationFailureMonitor creates a monitor that alerts once threshold
+// failures for a channel fall within window. adminChannelID may be empty, in
+// which case a system incident is created instead.
+func NewNotificationFailureMonitor(threshold int, window time.Duration, adminChannelID string, incidentService *IncidentService, notifier *NotificationService, logger *Logger) *NotificationFailureMonitor {
+	if window <= 0 {
+		window = DefaultNotificationFailureWindow
+	}
+	return &NotificationFailureMonitor{
+		threshold:       threshold,
+		window:          window,
+		adminChannelID:  adminChannelID,
+		incidentService: incidentService,
+		notifier:        notifier,
+		logger:          logger,
+		now:             time.Now,
+		deliver:         notifier.sendRendered,
+		failures:        make(map[string][]time.Time),
+		alerted:         make(map[string]bool),
+	}
+}
+
+// RecordFailure notes a failed delivery to channel and raises a meta-alert if
+// the channel has crossed the threshold. Only one meta-alert is raised per
+// burst; the channel is re-armed once its failures age out of the window.
+func (m *NotificationFailureMonitor) RecordFailure(ctx context.Context, channel *models.NotificationChannel, deliveryErr error) {
+	if m.threshold <= 0 {
+		return
+	}
+
+	now := m.now()
+	m.mutex.Lock()
+	recent := m.pruneLocked(channel.ID, now)
+	recent = append(recent, now)
+	m.failures[channel.ID] = recent
+
+	if len(recent) < m.threshold || m.alerted[channel.ID] {
+		m.mutex.Unlock()
+		return
+	}
+	m.alerted[channel.ID] = true
+	count := len(recent)
+	m.mutex.Unlock()
+
+	m.raise(ctx, channel, count, deliveryErr)
+}
+
+// pruneLocked drops failures older than the window and re-arms the channel
+// once it has dropped back below the threshold
+func (m *NotificationFailureMonitor) pruneLocked(channelID string, now time.Time) []time.Time {
+	cutoff := now.Add(-m.window)
+	recent := m.failures[channelID][:0]
+	for _, at := range m.failures[channelID] {
+		if at.After(cutoff) {
+			recent = append(recent, at)
+		}
+	}
+	if len(recent) < m.threshold {
+		delete(m.alerted, channelID)
+	}
+	return recent
+}
+
+// raise delivers the meta-alert. It never goes through the failing channel,
+// and its own delivery failures are not recorded, so it cannot loop.
+func (m *NotificationFailureMonitor) raise(ctx context.Context, channel *models.NotificationChannel, count int, deliveryErr error) {
+	subject := fmt.Sprintf("Notifications to %s are failing", channel.Name)
+	content := fmt.Sprintf("%d notifications to channel %s (%s, id %s) failed in the last %s.\nLast error: %v",
+		count, channel.Name, channel.Type, channel.ID, m.window, deliveryErr)
+
+	m.logger.Warn("Notification failure threshold exceeded", map[string]interface{}{
+		"channel_id": channel.ID,
+		"failures":   count,
+		"window":     m.window.String(),
+	})
+
+	if m.adminChannelID != "" && m.adminChannelID != channel.ID {
+		admin, err := m.notifier.store.GetNotificationChannel(ctx, m.adminChannelID)
+		if err == nil && admin.Enabled {
+			err = m.deliver(admin, subject, content)
+			if err == nil {
+				return
+			}
+			m.logger.Error("Failed to notify admin channel about notification failures", map[string]interface{}{
+				"admin_channel_id": admin.ID,
+				"error":            err.Error(),
+			})
+		}
+	}
+
+	if m.incidentService == nil {
+		return
+	}
+	if _, err := m.incidentService.CreateIncident(ctx, subject, content, models.SeverityHigh, []string{}); err != nil {
+		m.logger.Error("Failed to create notification failure incident", map[string]interface{}{
+			"channel_id": channel.ID,
+			"error":      err.Error(),
+		})
+	}
+}
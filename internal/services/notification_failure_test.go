@@ -0,0 +1,118 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/config"
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/models"
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/storage"
+)
+
+func setupTestFailureMonitor(t *testing.T, adminChannelID string) (*NotificationFailureMonitor, storage.Store, *fakeClock, *[]string) {
+	ctx := context.Background()
+	t.Helper()
+
+	store, err := storage.NewMemoryStore()
+	if err != nil {
+		t.Fatalf("Failed to create memory store: %v", err)
+	}
+	logger := NewLogger("error", false)
+	metricsService := NewMetricsService(prometheus.NewRegistry())
+	notificationService := NewNotificationService(&config.Config{Port: "8080"}, store, NewNotificationTemplateService(logger), metricsService, logger)
+	incidentService := NewIncidentService(store, metricsService)
+
+	for _, channel := range []*models.NotificationChannel{
+		{ID: "slack-ops", Name: "Ops Slack", Type: "slack", Enabled: true},
+		{ID: "admin-email", Name: "Admin email", Type: "email", Enabled: true},
+	} {
+		if err := store.CreateNotificationChannel(ctx, channel); err != nil {
+			t.Fatalf("Failed to create channel: %v", err)
+		}
+	}
+
+	clock := &fakeClock{current: time.Date(2024, time.March, 15, 9, 0, 0, 0, time.UTC)}
+	var sent []string
+	monitor := NewNotificationFailureMonitor(3, 5*time.Minute, adminChannelID, incidentService, notificationService, logger)
+	monitor.now = clock.Now
+	monitor.deliver = func(channel *models.NotificationChannel, subject, content string) error {
+		sent = append(sent, channel.ID)
+		return nil
+	}
+
+	return monitor, store, clock, &sent
+}
+
+func TestNotificationFailureMonitor_BurstRaisesSingleMetaAlert(t *testing.T) {
+	ctx := context.Background()
+	monitor, store, clock, sent := setupTestFailureMonitor(t, "admin-email")
+	failing, _ := store.GetNotificationChannel(ctx, "slack-ops")
+
+	for i := 0; i < 10; i++ {
+		monitor.RecordFailure(ctx, failing, errors.New("invalid_auth"))
+		clock.Advance(10 * time.Second)
+	}
+
+	if len(*sent) != 1 {
+		t.Fatalf("Expected exactly 1 meta-alert, got %d", len(*sent))
+	}
+	if (*sent)[0] != "admin-email" {
+		t.Errorf("Expected meta-alert on the admin channel, got %s", (*sent)[0])
+	}
+	if incidents, _ := store.ListIncidents(ctx); len(incidents) != 0 {
+		t.Errorf("Expected no system incident when the admin channel works, got %d", len(incidents))
+	}
+
+	// Once the burst ages out of the window, a new burst alerts again
+	clock.Advance(10 * time.Minute)
+	for i := 0; i < 3; i++ {
+		monitor.RecordFailure(ctx, failing, errors.New("invalid_auth"))
+	}
+	if len(*sent) != 2 {
+		t.Errorf("Expected a second meta-alert for a later burst, got %d", len(*sent))
+	}
+}
+
+func TestNotificationFailureMonitor_FailingAdminChannelCreatesIncident(t *testing.T) {
+	ctx := context.Background()
+	monitor, store, _, sent := setupTestFailureMonitor(t, "admin-email")
+	failing, _ := store.GetNotificationChannel(ctx, "admin-email")
+
+	for i := 0; i < 5; i++ {
+		monitor.RecordFailure(ctx, failing, errors.New("connection refused"))
+	}
+
+	if len(*sent) != 0 {
+		t.Errorf("Expected no meta-alert through the failing channel, got %d", len(*sent))
+	}
+	incidents, err := store.ListIncidents(ctx)
+	if err != nil || len(incidents) != 1 {
+		t.Fatalf("Expected 1 system incident, got %d (err: %v)", len(incidents), err)
+	}
+	if incidents[0].Title != "Notifications to Admin email are failing" {
+		t.Errorf("Unexpected incident title %q", incidents[0].Title)
+	}
+}
+
+func TestNotificationFailureMonitor_BelowThreshold(t *testing.T) {
+	ctx := context.Background()
+	monitor, store, clock, sent := setupTestFailureMonitor(t, "")
+	failing, _ := store.GetNotificationChannel(ctx, "slack-ops")
+
+	// Failures spread wider than the window never accumulate to the threshold
+	for i := 0; i < 6; i++ {
+		monitor.RecordFailure(ctx, failing, errors.New("timeout"))
+		clock.Advance(3 * time.Minute)
+	}
+
+	if len(*sent) != 0 {
+		t.Errorf("Expected no meta-alert, got %d", len(*sent))
+	}
+	if incidents, _ := store.ListIncidents(ctx); len(incidents) != 0 {
+		t.Errorf("Expected no system incident, got %d", len(incidents))
+	}
+}
@@ -0,0 +1,59 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/models"
+)
+
+// DeliveryReport computes notification delivery success rates over the
+// history entries created at or after from and before to, overall and by
+// channel type and notification type
+func (s *NotificationService) DeliveryReport(ctx context.Context, from, to time.Time) (*models.NotificationDeliveryReport, error) {
+	counts, err := s.store.CountNotificationHistory(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.NotificationDeliveryReport{
+		From:      from,
+		To:        to,
+		Breakdown: []*models.NotificationDeliveryRate{},
+	}
+	// Counts arrive grouped by channel type, then notification type
+	var current *models.NotificationDeliveryRate
+	for _, count := range counts {
+		if current == nil || current.ChannelType != count.ChannelType || current.NotificationType != count.NotificationType {
+			current = &models.NotificationDeliveryRate{ChannelType: count.ChannelType, NotificationType: count.NotificationType}
+			report.Breakdown = append(report.Breakdown, current)
+		}
+		switch count.Status {
+		case models.DeliveryStatusSent, models.DeliveryStatusDelivered:
+			current.Sent += count.Count
+			report.Sent += count.Count
+		case models.DeliveryStatusFailed, models.DeliveryStatusDeadLettered:
+			current.Failed += count.Count
+			report.Failed += count.Count
+		default:
+			current.Pending += count.Count
+			report.Pending += count.Count
+		}
+	}
+
+	for _, rate := range report.Breakdown {
+		rate.SuccessRate = successRate(rate.Sent, rate.Failed)
+	}
+	report.SuccessRate = successRate(report.Sent, report.Failed)
+	return report, nil
+}
+
+// successRate is the share of settled notifications that were sent, or nil
+// when none have settled
+func successRate(sent, failed int) *float64 {
+	if sent+failed == 0 {
+		return nil
+	}
+	rate := float64(sent) / float64(sent+failed)
+	return &rate
+}
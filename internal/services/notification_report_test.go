@@ -0,0 +1,81 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/config"
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/models"
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/storage"
+)
+
+func TestNotificationDeliveryReport(t *testing.T) {
+	ctx := context.Background()
+	store, err := storage.NewMemoryStore()
+	if err != nil {
+		t.Fatalf("Failed to create memory store: %v", err)
+	}
+	logger := NewLogger("error", false)
+	notificationService := NewNotificationService(&config.Config{Port: "8080"}, store, NewNotificationTemplateService(logger), NewMetricsService(prometheus.NewRegistry()), logger)
+
+	from := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(24 * time.Hour)
+	add := func(channel, notificationType string, status models.NotificationDeliveryStatus, count int, createdAt time.Time) {
+		t.Helper()
+		for i := 0; i < count; i++ {
+			history := &models.NotificationHistory{
+				ID: fmt.Sprintf("%s-%s-%s-%d-%d", channel, notificationType, status, createdAt.Unix(), i), ChannelID: channel,
+				Type: notificationType, Channel: channel, Status: status, CreatedAt: createdAt, UpdatedAt: createdAt,
+			}
+			if err := store.CreateNotificationHistory(ctx, history); err != nil {
+				t.Fatalf("Failed to create history: %v", err)
+			}
+		}
+	}
+	// Slack: 3 of 4 settled created notifications succeeded, one is retrying
+	add("slack", "incident_created", models.DeliveryStatusSent, 2, from.Add(time.Hour))
+	add("slack", "incident_created", models.DeliveryStatusDelivered, 1, from.Add(time.Hour))
+	add("slack", "incident_created", models.DeliveryStatusFailed, 1, from.Add(time.Hour))
+	add("slack", "incident_created", models.DeliveryStatusRetrying, 1, from.Add(time.Hour))
+	// Email: 1 of 2 resolved notifications succeeded
+	add("email", "incident_resolved", models.DeliveryStatusSent, 1, from.Add(2*time.Hour))
+	add("email", "incident_resolved", models.DeliveryStatusDeadLettered, 1, from.Add(2*time.Hour))
+	// Outside the window
+	add("email", "incident_resolved", models.DeliveryStatusFailed, 5, from.Add(-time.Hour))
+	add("email", "incident_resolved", models.DeliveryStatusFailed, 5, to)
+
+	report, err := notificationService.DeliveryReport(ctx, from, to)
+	if err != nil {
+		t.Fatalf("DeliveryReport failed: %v", err)
+	}
+	if report.Sent != 4 || report.Failed != 2 || report.Pending != 1 {
+		t.Errorf("Expected 4 sent, 2 failed and 1 pending, got %d, %d and %d", report.Sent, report.Failed, report.Pending)
+	}
+	if report.SuccessRate == nil || *report.SuccessRate != 4.0/6.0 {
+		t.Errorf("Expected an overall success rate of 4/6, got %v", report.SuccessRate)
+	}
+
+	if len(report.Breakdown) != 2 {
+		t.Fatalf("Expected 2 channel and notification type pairs, got %d", len(report.Breakdown))
+	}
+	email, slack := report.Breakdown[0], report.Breakdown[1]
+	if email.ChannelType != "email" || email.NotificationType != "incident_resolved" || email.SuccessRate == nil || *email.SuccessRate != 0.5 {
+		t.Errorf("Expected email to succeed half the time, got %+v", email)
+	}
+	if slack.ChannelType != "slack" || slack.NotificationType != "incident_created" || slack.SuccessRate == nil || *slack.SuccessRate != 0.75 {
+		t.Errorf("Expected slack to succeed 3 times out of 4, got %+v", slack)
+	}
+
+	// Nothing settled means no rate rather than a 0% one
+	empty, err := notificationService.DeliveryReport(ctx, to.Add(time.Hour), to.Add(2*time.Hour))
+	if err != nil {
+		t.Fatalf("DeliveryReport failed: %v", err)
+	}
+	if empty.SuccessRate != nil || len(empty.Breakdown) != 0 {
+		t.Errorf("Expected an empty report, got %+v", empty)
+	}
+}
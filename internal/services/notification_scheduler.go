@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"fmt"
 	"sort"
 	"sync"
@@ -43,8 +44,8 @@ func NewNotificationScheduler(service *NotificationService, logger *Logger) *Not
 	
 	// Start the scheduler ticker
 	scheduler.ticker = time.NewTicker(30 * time.Second) // Check every 30 seconds
-	go scheduler.processScheduledNotifications()
-	
+	go scheduler.processScheduledNotifications(context.Background())
+
 	return scheduler
 }
 
@@ -132,11 +133,11 @@ func (s *NotificationScheduler) GetScheduledNotifications(channelID string, stat
 }
 
 // processScheduledNotifications processes notifications that are due for delivery
-func (s *NotificationScheduler) processScheduledNotifications() {
+func (s *NotificationScheduler) processScheduledNotifications(ctx context.Context) {
 	for {
 		select {
 		case <-s.ticker.C:
-			s.processDueNotifications()
+			s.processDueNotifications(ctx)
 		case <-s.stopChan:
 			return
 		}
@@ -144,7 +145,7 @@ func (s *NotificationScheduler) processScheduledNotifications() {
 }
 
 // processDueNotifications processes notifications that are due for delivery
-func (s *NotificationScheduler) processDueNotifications() {
+func (s *NotificationScheduler) processDueNotifications(ctx context.Context) {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 	
@@ -162,13 +163,13 @@ func (s *NotificationScheduler) processDueNotifications() {
 	// Process due notifications (outside of the main mutex to avoid blocking)
 	if len(toProcess) > 0 {
 		s.mutex.Unlock()
-		s.processNotifications(toProcess)
+		s.processNotifications(ctx, toProcess)
 		s.mutex.Lock()
 	}
 }
 
 // processNotifications processes a batch of due notifications
-func (s *NotificationScheduler) processNotifications(notifications []*ScheduledNotification) {
+func (s *NotificationScheduler) processNotifications(ctx context.Context, notifications []*ScheduledNotification) {
 	for _, notification := range notifications {
 		s.logger.Info("Processing scheduled notification", map[string]interface{}{
 			"notification_id": notification.ID,
@@ -177,8 +178,8 @@ func (s *NotificationScheduler) processNotifications(notifications []*ScheduledN
 		})
 		
 		// Send the notification
-		err := s.service.sendNotificationToChannel(notification.Incident, notification.Channel, notification.Type)
-		
+		err := s.service.sendNotificationToChannel(ctx, notification.Incident, notification.Channel, notification.Type)
+
 		if err != nil {
 			s.logger.Error("Scheduled notification failed", map[string]interface{}{
 				"notification_id": notification.ID,
@@ -4,6 +4,8 @@ import (
 	"testing"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+
 	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/config"
 	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/models"
 	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/storage"
@@ -19,7 +21,7 @@ func TestNotificationScheduler(t *testing.T) {
 	}
 	
 	templateService := NewNotificationTemplateService(logger)
-	metricsService := NewMetricsService()
+	metricsService := NewMetricsService(prometheus.NewRegistry())
 	notificationService := NewNotificationService(cfg, store, templateService, metricsService, logger)
 	
 	scheduler := NewNotificationScheduler(notificationService, logger)
@@ -32,6 +32,7 @@ type TemplateVariables struct {
 	Severity    string
 	Status      string
 	Duration    string
+	Digest      *IncidentDigest
 }
 
 // GetDefaultTemplate returns the default template for a given type and channel
@@ -46,6 +47,33 @@ func (s *NotificationTemplateService) GetDefaultTemplate(notificationType, chann
 	return s.getGenericTemplate(notificationType, channel)
 }
 
+// DefaultNotificationLocale is the language of the built-in templates
+const DefaultNotificationLocale = "en"
+
+// GetLocalizedTemplate returns the built-in template for a type and channel in
+// the requested locale. Regional locales such as "es-MX" fall back to their
+// base language, and unknown locales fall back to the default template.
+func (s *NotificationTemplateService) GetLocalizedTemplate(notificationType, channel, locale string) *models.NotificationTemplate {
+	locale = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(locale), "_", "-"))
+	if locale == "" || locale == DefaultNotificationLocale {
+		return s.GetDefaultTemplate(notificationType, channel)
+	}
+
+	templates := s.getLocalizedTemplates()
+	candidates := []string{locale}
+	if base, _, found := strings.Cut(locale, "-"); found {
+		candidates = append(candidates, base)
+	}
+	for _, candidate := range candidates {
+		key := fmt.Sprintf("%s_%s_%s", notificationType, channel, candidate)
+		if tmpl, exists := templates[key]; exists {
+			return tmpl
+		}
+	}
+
+	return s.GetDefaultTemplate(notificationType, channel)
+}
+
 // RenderTemplate renders a notification template with the provided variables
 func (s *NotificationTemplateService) RenderTemplate(tmpl *models.NotificationTemplate, vars TemplateVariables) (subject, content string, err error) {
 	// Create template functions
@@ -198,6 +226,107 @@ func (s *NotificationTemplateService) getBuiltinTemplates() map[string]*models.N
 			CreatedAt: now,
 			UpdatedAt: now,
 		},
+		"incident_digest_slack": {
+			ID:        "default_incident_digest_slack",
+			Name:      "Default Incident Digest - Slack",
+			Type:      "incident_digest",
+			Channel:   "slack",
+			Subject:   "",
+			Body:      "📋 *Open Incident Digest* ({{.Digest.Total}} open, {{formatTime .Timestamp}})\n{{range .Digest.Groups}}\n*{{.Severity | upper}}* ({{len .Incidents}})\n{{range .Incidents}}• {{.Title}} | {{.Status}} | open {{.Age}} | {{.Assignee}}\n{{end}}{{else}}\nNo open incidents.\n{{end}}",
+			IsDefault: true,
+			CreatedAt: now,
+			UpdatedAt: now,
+		},
+		"incident_digest_email": {
+			ID:        "default_incident_digest_email",
+			Name:      "Default Incident Digest - Email",
+			Type:      "incident_digest",
+			Channel:   "email",
+			Subject:   "📋 Open Incident Digest: {{.Digest.Total}} open",
+			Body:      "Open incidents in {{.SystemName}} as of {{formatTime .Timestamp}}.\n{{range .Digest.Groups}}\n{{.Severity | upper}} ({{len .Incidents}})\n{{range .Incidents}}  - {{.Title}} [{{.Status}}] open {{.Age}}, assignee: {{.Assignee}}\n{{end}}{{else}}\nNo open incidents.\n{{end}}",
+			IsDefault: true,
+			CreatedAt: now,
+			UpdatedAt: now,
+		},
+		"incident_digest_telegram": {
+			ID:        "default_incident_digest_telegram",
+			Name:      "Default Incident Digest - Telegram",
+			Type:      "incident_digest",
+			Channel:   "telegram",
+			Subject:   "",
+			Body:      "📋 <b>Open Incident Digest</b> ({{.Digest.Total}} open)\n{{range .Digest.Groups}}\n<b>{{.Severity | upper}}</b> ({{len .Incidents}})\n{{range .Incidents}}• {{.Title}} | {{.Status}} | open {{.Age}} | {{.Assignee}}\n{{end}}{{else}}\nNo open incidents.\n{{end}}",
+			IsDefault: true,
+			CreatedAt: now,
+			UpdatedAt: now,
+		},
+	}
+}
+
+// getLocalizedTemplates returns built-in translations keyed by type, channel and locale
+func (s *NotificationTemplateService) getLocalizedTemplates() map[string]*models.NotificationTemplate {
+	now := time.Now()
+
+	return map[string]*models.NotificationTemplate{
+		"incident_created_slack_es": {
+			ID:        "default_incident_created_slack_es",
+			Name:      "Default Incident Created - Slack (es)",
+			Type:      "incident_created",
+			Channel:   "slack",
+			Locale:    "es",
+			Subject:   "",
+			Body:      "🚨 *Nuevo incidente creado*\n\n*Título:* {{.Incident.Title}}\n*Severidad:* {{.Incident.Severity | upper}}\n*Estado:* {{.Incident.Status}}\n*Creado:* {{formatTime .Incident.CreatedAt}}\n\n*Descripción:* {{.Incident.Description}}",
+			IsDefault: true,
+			CreatedAt: now,
+			UpdatedAt: now,
+		},
+		"incident_created_email_es": {
+			ID:        "default_incident_created_email_es",
+			Name:      "Default Incident Created - Email (es)",
+			Type:      "incident_created",
+			Channel:   "email",
+			Locale:    "es",
+			Subject:   "🚨 Nuevo incidente: {{.Incident.Title}}",
+			Body:      "Se ha creado un nuevo incidente en {{.SystemName}}.\n\nTítulo: {{.Incident.Title}}\nSeveridad: {{.Incident.Severity | upper}}\nEstado: {{.Incident.Status}}\nCreado: {{formatTime .Incident.CreatedAt}}\n\nDescripción:\n{{.Incident.Description}}\n\nVer incidente: {{.SystemURL}}/incidents/{{.Incident.ID}}",
+			IsDefault: true,
+			CreatedAt: now,
+			UpdatedAt: now,
+		},
+		"incident_created_telegram_es": {
+			ID:        "default_incident_created_telegram_es",
+			Name:      "Default Incident Created - Telegram (es)",
+			Type:      "incident_created",
+			Channel:   "telegram",
+			Locale:    "es",
+			Subject:   "",
+			Body:      "🚨 <b>Nuevo incidente creado</b>\n\n<b>Título:</b> {{.Incident.Title}}\n<b>Severidad:</b> {{.Incident.Severity | upper}}\n<b>Estado:</b> {{.Incident.Status}}\n<b>Creado:</b> {{formatTime .Incident.CreatedAt}}\n\n<b>Descripción:</b> {{.Incident.Description}}",
+			IsDefault: true,
+			CreatedAt: now,
+			UpdatedAt: now,
+		},
+		"incident_acknowledged_slack_es": {
+			ID:        "default_incident_acknowledged_slack_es",
+			Name:      "Default Incident Acknowledged - Slack (es)",
+			Type:      "incident_acknowledged",
+			Channel:   "slack",
+			Locale:    "es",
+			Subject:   "",
+			Body:      "✅ *Incidente reconocido*\n\n*Título:* {{.Incident.Title}}\n*Estado:* {{.Incident.Status}}\n*Reconocido:* {{formatTime .Incident.AckedAt}}\n*Responsable:* {{.Incident.AssigneeID}}",
+			IsDefault: true,
+			CreatedAt: now,
+			UpdatedAt: now,
+		},
+		"incident_resolved_slack_es": {
+			ID:        "default_incident_resolved_slack_es",
+			Name:      "Default Incident Resolved - Slack (es)",
+			Type:      "incident_resolved",
+			Channel:   "slack",
+			Locale:    "es",
+			Subject:   "",
+			Body:      "🎉 *Incidente resuelto*\n\n*Título:* {{.Incident.Title}}\n*Estado:* {{.Incident.Status}}\n*Resuelto:* {{formatTime .Incident.ResolvedAt}}\n*Duración:* {{duration .Incident.CreatedAt .Incident.ResolvedAt}}",
+			IsDefault: true,
+			CreatedAt: now,
+			UpdatedAt: now,
+		},
 	}
 }
 
@@ -1,6 +1,7 @@
 package services
 
 import (
+	"strings"
 	"testing"
 	"time"
 
@@ -16,15 +17,15 @@ func TestNotificationTemplateService(t *testing.T) {
 		if template == nil {
 			t.Fatal("Expected default template, got nil")
 		}
-		
+
 		if template.Type != "incident_created" {
 			t.Errorf("Expected type 'incident_created', got '%s'", template.Type)
 		}
-		
+
 		if template.Channel != "slack" {
 			t.Errorf("Expected channel 'slack', got '%s'", template.Channel)
 		}
-		
+
 		if template.Body == "" {
 			t.Error("Expected non-empty body")
 		}
@@ -32,7 +33,7 @@ func TestNotificationTemplateService(t *testing.T) {
 
 	t.Run("RenderTemplate", func(t *testing.T) {
 		template := templateService.GetDefaultTemplate("incident_created", "slack")
-		
+
 		incident := &models.Incident{
 			ID:          "test-123",
 			Title:       "Test Incident",
@@ -41,7 +42,7 @@ func TestNotificationTemplateService(t *testing.T) {
 			Severity:    models.SeverityHigh,
 			CreatedAt:   time.Now(),
 		}
-		
+
 		vars := TemplateVariables{
 			Incident:    incident,
 			Timestamp:   time.Now(),
@@ -51,25 +52,25 @@ func TestNotificationTemplateService(t *testing.T) {
 			Severity:    string(incident.Severity),
 			Status:      string(incident.Status),
 		}
-		
+
 		subject, content, err := templateService.RenderTemplate(template, vars)
 		if err != nil {
 			t.Fatalf("Template rendering failed: %v", err)
 		}
-		
+
 		if content == "" {
 			t.Error("Expected non-empty content")
 		}
-		
+
 		// Check that variables were substituted
 		if !containsString(content, "Test Incident") {
 			t.Error("Expected content to contain incident title")
 		}
-		
+
 		if !containsString(content, "HIGH") {
 			t.Error("Expected content to contain severity")
 		}
-		
+
 		t.Logf("Rendered subject: %s", subject)
 		t.Logf("Rendered content: %s", content)
 	})
@@ -82,23 +83,23 @@ func TestNotificationTemplateService(t *testing.T) {
 			Channel: "slack",
 			Body:    "Incident: {{.Incident.Title}}",
 		}
-		
+
 		err := templateService.ValidateTemplate(validTemplate)
 		if err != nil {
 			t.Errorf("Valid template should pass validation, got error: %v", err)
 		}
-		
+
 		// Test invalid template - missing required fields
 		invalidTemplate := &models.NotificationTemplate{
 			Name: "Invalid Template",
 			// Missing Type, Channel, Body
 		}
-		
+
 		err = templateService.ValidateTemplate(invalidTemplate)
 		if err == nil {
 			t.Error("Invalid template should fail validation")
 		}
-		
+
 		// Test template with invalid syntax
 		invalidSyntaxTemplate := &models.NotificationTemplate{
 			Name:    "Invalid Syntax Template",
@@ -106,7 +107,7 @@ func TestNotificationTemplateService(t *testing.T) {
 			Channel: "slack",
 			Body:    "Incident: {{.InvalidField",
 		}
-		
+
 		err = templateService.ValidateTemplate(invalidSyntaxTemplate)
 		if err == nil {
 			t.Error("Template with invalid syntax should fail validation")
@@ -116,10 +117,10 @@ func TestNotificationTemplateService(t *testing.T) {
 
 // containsString checks if a string contains a substring (case-insensitive)
 func containsString(s, substr string) bool {
-	return len(s) >= len(substr) && 
-		   (s == substr || 
-			len(s) > len(substr) && 
-			findSubstring(s, substr))
+	return len(s) >= len(substr) &&
+		(s == substr ||
+			len(s) > len(substr) &&
+				findSubstring(s, substr))
 }
 
 func findSubstring(s, substr string) bool {
@@ -136,4 +137,52 @@ func findSubstring(s, substr string) bool {
 		}
 	}
 	return false
-}
\ No newline at end of file
+}
+
+func TestNotificationTemplateLocalization(t *testing.T) {
+	logger := NewLogger("info", true)
+	templateService := NewNotificationTemplateService(logger)
+	notificationService := &NotificationService{templateService: templateService, logger: logger}
+
+	t.Run("SpanishChannelReceivesSpanishTemplate", func(t *testing.T) {
+		channel := &models.NotificationChannel{ID: "es-channel", Name: "Equipo", Type: "slack", Locale: "es"}
+
+		tmpl := notificationService.getTemplateForChannel(channel, "incident_created")
+		if tmpl.Locale != "es" {
+			t.Fatalf("Expected Spanish template, got locale '%s'", tmpl.Locale)
+		}
+
+		_, content, err := templateService.RenderTemplate(tmpl, TemplateVariables{
+			Incident: &models.Incident{Title: "Base de datos caída", Severity: models.SeverityHigh, CreatedAt: time.Now()},
+		})
+		if err != nil {
+			t.Fatalf("Template rendering failed: %v", err)
+		}
+		if !strings.Contains(content, "Nuevo incidente creado") {
+			t.Errorf("Expected Spanish content, got: %s", content)
+		}
+	})
+
+	t.Run("UserPreferredLocaleAndRegionalFallback", func(t *testing.T) {
+		channel := &models.NotificationChannel{
+			ID:          "user-channel",
+			Type:        "email",
+			Preferences: &models.ChannelPreferences{Locale: "es-MX"},
+		}
+
+		tmpl := notificationService.getTemplateForChannel(channel, "incident_created")
+		if tmpl.Locale != "es" {
+			t.Errorf("Expected es-MX to fall back to the Spanish template, got locale '%s'", tmpl.Locale)
+		}
+	})
+
+	t.Run("UnconfiguredLocaleFallsBackToDefault", func(t *testing.T) {
+		channel := &models.NotificationChannel{ID: "fr-channel", Type: "slack", Locale: "fr"}
+
+		tmpl := notificationService.getTemplateForChannel(channel, "incident_created")
+		expected := templateService.GetDefaultTemplate("incident_created", "slack")
+		if tmpl.ID != expected.ID {
+			t.Errorf("Expected default template '%s', got '%s'", expected.ID, tmpl.ID)
+		}
+	})
+}
@@ -1,15 +1,29 @@
 package services
 
 import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+
 	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/config"
 	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/models"
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/retry"
 	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/storage"
 )
 
 func TestNotificationServiceEnhanced(t *testing.T) {
+	ctx := context.Background()
 	// Setup test environment
 	cfg := &config.Config{
 		Port:         "8080",
@@ -23,7 +37,7 @@ func TestNotificationServiceEnhanced(t *testing.T) {
 	}
 	logger := NewLogger("info", true)
 	templateService := NewNotificationTemplateService(logger)
-	metricsService := NewMetricsService()
+	metricsService := NewMetricsService(prometheus.NewRegistry())
 	
 	notificationService := NewNotificationService(cfg, store, templateService, metricsService, logger)
 
@@ -43,7 +57,7 @@ func TestNotificationServiceEnhanced(t *testing.T) {
 		}
 		
 		// Store channel
-		err := store.CreateNotificationChannel(channel)
+		err := store.CreateNotificationChannel(ctx, channel)
 		if err != nil {
 			t.Fatalf("Failed to create test channel: %v", err)
 		}
@@ -168,8 +182,8 @@ func TestNotificationServiceEnhanced(t *testing.T) {
 			CreatedAt: time.Now(),
 			UpdatedAt: time.Now(),
 		}
-		
-		err := store.CreateNotificationChannel(channel)
+
+		err := store.CreateNotificationChannel(ctx, channel)
 		if err != nil {
 			t.Fatalf("Failed to create batch test channel: %v", err)
 		}
@@ -185,7 +199,7 @@ func TestNotificationServiceEnhanced(t *testing.T) {
 		}
 		
 		// Test adding to batch
-		err = notificationService.batchProcessor.AddToBatch(incident, channel, "incident_created")
+		err = notificationService.batchProcessor.AddToBatch(ctx, incident, channel, "incident_created")
 		if err != nil {
 			t.Errorf("Failed to add notification to batch: %v", err)
 		}
@@ -198,6 +212,7 @@ func TestNotificationServiceEnhanced(t *testing.T) {
 }
 
 func TestNotificationBatchProcessor(t *testing.T) {
+	ctx := context.Background()
 	logger := NewLogger("info", true)
 	
 	// Create a mock notification service for testing
@@ -233,8 +248,8 @@ func TestNotificationBatchProcessor(t *testing.T) {
 			Severity: models.SeverityHigh,
 			Status:   models.IncidentStatusOpen,
 		}
-		
-		err := processor.AddToBatch(incident, channel, "incident_created")
+
+		err := processor.AddToBatch(ctx, incident, channel, "incident_created")
 		if err != nil {
 			t.Errorf("Failed to add to batch: %v", err)
 		}
@@ -257,4 +272,450 @@ func TestNotificationBatchProcessor(t *testing.T) {
 	
 	// Stop the processor
 	processor.Stop()
-}
\ No newline at end of file
+}
+func TestWebhookChannelSigning(t *testing.T) {
+	store, err := storage.NewMemoryStore()
+	if err != nil {
+		t.Fatalf("Failed to create memory store: %v", err)
+	}
+	logger := NewLogger("error", false)
+	notificationService := NewNotificationService(&config.Config{Port: "8080"}, store, NewNotificationTemplateService(logger), NewMetricsService(prometheus.NewRegistry()), logger)
+
+	var body []byte
+	var headers http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		headers = r.Header.Clone()
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	incident := &models.Incident{ID: "incident-1", Title: "Checkout down", Severity: models.SeverityCritical, Status: models.IncidentStatusOpen}
+	expectedSignature := func(secret string) string {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write(body)
+		return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	}
+
+	tests := []struct {
+		name   string
+		config map[string]string
+		header string
+	}{
+		{name: "Default header", config: map[string]string{"secret": "s3cret-key"}, header: "X-Signature"},
+		{name: "Custom header", config: map[string]string{"secret": "other-key", "signature_header": "X-Hub-Signature-256"}, header: "X-Hub-Signature-256"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config["url"] = server.URL
+			channel := &models.NotificationChannel{ID: "hook", Name: "Event hook", Type: "webhook", Enabled: true, Config: tt.config}
+
+			if err := notificationService.sendToChannel(channel, "Incident created", "Checkout down", incident); err != nil {
+				t.Fatalf("Failed to send webhook: %v", err)
+			}
+
+			if got, want := headers.Get(tt.header), expectedSignature(tt.config["secret"]); got != want {
+				t.Errorf("Expected %s %q, got %q", tt.header, want, got)
+			}
+
+			var message WebhookMessage
+			if err := json.Unmarshal(body, &message); err != nil {
+				t.Fatalf("Failed to decode webhook body: %v", err)
+			}
+			if message.Subject != "Incident created" || message.Incident == nil || message.Incident.ID != "incident-1" {
+				t.Errorf("Unexpected webhook body: %s", body)
+			}
+		})
+	}
+
+	t.Run("Unsigned without secret", func(t *testing.T) {
+		channel := &models.NotificationChannel{ID: "hook", Name: "Event hook", Type: "webhook", Enabled: true, Config: map[string]string{"url": server.URL}}
+		if err := notificationService.sendToChannel(channel, "Incident created", "Checkout down", incident); err != nil {
+			t.Fatalf("Failed to send webhook: %v", err)
+		}
+		if got := headers.Get("X-Signature"); got != "" {
+			t.Errorf("Expected no signature header, got %q", got)
+		}
+	})
+}
+
+func TestNotificationFanoutLimits(t *testing.T) {
+	ctx := context.Background()
+	store, err := storage.NewMemoryStore()
+	if err != nil {
+		t.Fatalf("Failed to create memory store: %v", err)
+	}
+	logger := NewLogger("error", false)
+	notificationService := NewNotificationService(&config.Config{Port: "8080"}, store, NewNotificationTemplateService(logger), NewMetricsService(prometheus.NewRegistry()), logger)
+	limits, err := ParseFanoutLimits([]string{"low:2", "critical:0"})
+	if err != nil {
+		t.Fatalf("Failed to parse limits: %v", err)
+	}
+	notificationService.SetFanoutLimits(limits)
+
+	var delivered []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		delivered = append(delivered, r.URL.Path[1:])
+	}))
+	defer server.Close()
+
+	for id, priority := range map[string]int{"pager": 10, "team-chat": 5, "email-digest": 1, "ops-room": 5} {
+		channel := &models.NotificationChannel{
+			ID: id, Name: id, Type: "webhook", Enabled: true, Priority: priority,
+			Config: map[string]string{"url": server.URL + "/" + id},
+		}
+		if err := store.CreateNotificationChannel(ctx, channel); err != nil {
+			t.Fatalf("Failed to create channel: %v", err)
+		}
+	}
+
+	low := &models.Incident{ID: "low-incident", Title: "Disk filling up", Severity: models.SeverityLow, Status: models.IncidentStatusOpen, CreatedAt: time.Now()}
+	if err := notificationService.NotifyIncidentCreated(ctx, low); err != nil {
+		t.Fatalf("Failed to notify: %v", err)
+	}
+	// Highest priority first; the tie at 5 is broken by channel ID
+	if len(delivered) != 2 || delivered[0] != "pager" || delivered[1] != "ops-room" {
+		t.Errorf("Expected the low incident to reach pager and ops-room only, got %v", delivered)
+	}
+
+	delivered = nil
+	critical := &models.Incident{ID: "critical-incident", Title: "Checkout down", Severity: models.SeverityCritical, Status: models.IncidentStatusOpen, CreatedAt: time.Now()}
+	if err := notificationService.NotifyIncidentCreated(ctx, critical); err != nil {
+		t.Fatalf("Failed to notify: %v", err)
+	}
+	if len(delivered) != 4 {
+		t.Errorf("Expected the critical incident to reach all 4 channels, got %v", delivered)
+	}
+
+	if _, err := ParseFanoutLimits([]string{"urgent:1"}); err == nil {
+		t.Error("Expected an unknown severity to be rejected")
+	}
+}
+
+func TestNotificationHistoryPersisted(t *testing.T) {
+	ctx := context.Background()
+	store, err := storage.NewMemoryStore()
+	if err != nil {
+		t.Fatalf("Failed to create memory store: %v", err)
+	}
+	logger := NewLogger("error", false)
+	notificationService := NewNotificationService(&config.Config{Port: "8080"}, store, NewNotificationTemplateService(logger), NewMetricsService(prometheus.NewRegistry()), logger)
+	notificationService.retryer = retry.NewRetryer(&retry.RetryPolicy{MaxAttempts: 1}, retry.DefaultIsRetryable)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/broken" {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer server.Close()
+
+	for _, id := range []string{"ok", "broken"} {
+		channel := &models.NotificationChannel{
+			ID: id, Name: id, Type: "webhook", Enabled: true,
+			Config: map[string]string{"url": server.URL + "/" + id},
+		}
+		if err := store.CreateNotificationChannel(ctx, channel); err != nil {
+			t.Fatalf("Failed to create channel: %v", err)
+		}
+	}
+
+	incident := &models.Incident{ID: "incident-1", Title: "Checkout down", Severity: models.SeverityCritical, Status: models.IncidentStatusOpen, CreatedAt: time.Now()}
+	if err := notificationService.NotifyIncidentCreated(ctx, incident); err == nil {
+		t.Fatal("Expected the broken channel to report an error")
+	}
+
+	for channelID, want := range map[string]models.NotificationDeliveryStatus{
+		"ok":     models.DeliveryStatusSent,
+		"broken": models.DeliveryStatusDeadLettered,
+	} {
+		history, total, err := store.ListNotificationHistoryByChannel(ctx, channelID, &models.NotificationHistoryFilter{})
+		if err != nil || total != 1 {
+			t.Fatalf("Expected one history entry for %s, got %d (err: %v)", channelID, total, err)
+		}
+		entry := history[0]
+		if entry.Status != want || entry.IncidentID != incident.ID || entry.Type != "incident_created" {
+			t.Errorf("Expected a %s incident_created entry for %s, got %+v", want, channelID, entry)
+		}
+		if want == models.DeliveryStatusSent && entry.SentAt == nil {
+			t.Errorf("Expected sent_at to be recorded for %s", channelID)
+		}
+		if want == models.DeliveryStatusDeadLettered && entry.ErrorMsg == "" {
+			t.Errorf("Expected the delivery error to be recorded for %s", channelID)
+		}
+	}
+}
+
+func TestNotificationQuietHoursOverride(t *testing.T) {
+	store, err := storage.NewMemoryStore()
+	if err != nil {
+		t.Fatalf("Failed to create memory store: %v", err)
+	}
+	logger := NewLogger("error", false)
+	notificationService := NewNotificationService(&config.Config{Port: "8080"}, store, NewNotificationTemplateService(logger), NewMetricsService(prometheus.NewRegistry()), logger)
+	clock := &fakeClock{current: time.Date(2024, time.March, 5, 23, 30, 0, 0, time.UTC)}
+	notificationService.now = clock.Now
+
+	channel := &models.NotificationChannel{
+		ID: "pager", Name: "pager", Type: "webhook", Enabled: true,
+		Preferences: &models.ChannelPreferences{
+			OptIn: true,
+			QuietHours: &models.QuietHoursConfig{
+				Enabled:          true,
+				StartTime:        "22:00",
+				EndTime:          "06:00",
+				OverrideSeverity: models.SeverityCritical,
+			},
+		},
+	}
+	critical := &models.Incident{Severity: models.SeverityCritical}
+	low := &models.Incident{Severity: models.SeverityLow}
+
+	if !notificationService.shouldNotify(channel, critical, "incident_created") {
+		t.Error("Expected a critical incident to break through quiet hours")
+	}
+	if notificationService.shouldNotify(channel, low, "incident_created") {
+		t.Error("Expected a low incident to be suppressed during quiet hours")
+	}
+
+	channel.Preferences.QuietHours.OverrideSeverity = ""
+	if notificationService.shouldNotify(channel, critical, "incident_created") {
+		t.Error("Expected quiet hours without an override to suppress critical incidents")
+	}
+
+	clock.current = time.Date(2024, time.March, 6, 9, 0, 0, 0, time.UTC)
+	if !notificationService.shouldNotify(channel, low, "incident_created") {
+		t.Error("Expected a low incident to notify outside quiet hours")
+	}
+}
+
+func TestNotificationQuietHoursTimezone(t *testing.T) {
+	store, err := storage.NewMemoryStore()
+	if err != nil {
+		t.Fatalf("Failed to create memory store: %v", err)
+	}
+	logger := NewLogger("error", false)
+	notificationService := NewNotificationService(&config.Config{Port: "8080"}, store, NewNotificationTemplateService(logger), NewMetricsService(prometheus.NewRegistry()), logger)
+
+	// 22:30 - 06:15 on weeknights (Monday to Friday) in New York, which is
+	// UTC-4 in June
+	quiet := &models.QuietHoursConfig{
+		Enabled:   true,
+		StartTime: "22:30",
+		EndTime:   "06:15",
+		Timezone:  "America/New_York",
+		Days:      []int{1, 2, 3, 4, 5},
+	}
+	tests := []struct {
+		name string
+		now  time.Time
+		want bool
+	}{
+		// Monday 22:00 UTC is 18:00 in New York, and would be quiet in UTC
+		{"evening in UTC, afternoon locally", time.Date(2024, time.June, 3, 23, 0, 0, 0, time.UTC), false},
+		{"minute before the start", time.Date(2024, time.June, 4, 2, 29, 0, 0, time.UTC), false},
+		{"at the start", time.Date(2024, time.June, 4, 2, 30, 0, 0, time.UTC), true},
+		// Tuesday 04:00 UTC is still Monday night in New York
+		{"across midnight UTC", time.Date(2024, time.June, 4, 4, 0, 0, 0, time.UTC), true},
+		{"minute before the end", time.Date(2024, time.June, 4, 10, 14, 0, 0, time.UTC), true},
+		{"at the end", time.Date(2024, time.June, 4, 10, 15, 0, 0, time.UTC), false},
+		// Friday night runs into Saturday morning
+		{"Saturday morning after a Friday night", time.Date(2024, time.June, 8, 9, 0, 0, 0, time.UTC), true},
+		// Sunday night is not a quiet night, nor is the Monday morning after it
+		{"Sunday night", time.Date(2024, time.June, 10, 3, 0, 0, 0, time.UTC), false},
+		{"Monday morning after a Sunday night", time.Date(2024, time.June, 10, 9, 0, 0, 0, time.UTC), false},
+	}
+	for _, tt := range tests {
+		if got := notificationService.isInQuietHours(quiet, tt.now); got != tt.want {
+			t.Errorf("%s (%s): expected %v, got %v", tt.name, tt.now.Format(time.RFC3339), tt.want, got)
+		}
+	}
+
+	// Without a timezone the time is used as given
+	quiet.Timezone = ""
+	if !notificationService.isInQuietHours(quiet, time.Date(2024, time.June, 3, 23, 0, 0, 0, time.UTC)) {
+		t.Error("Expected Monday 23:00 UTC to be quiet without a timezone")
+	}
+}
+
+func TestValidateQuietHours(t *testing.T) {
+	valid := &models.QuietHoursConfig{StartTime: "22:30", EndTime: "24:00", Timezone: "Asia/Ho_Chi_Minh", Days: []int{0, 6}}
+	if err := ValidateQuietHours(valid); err != nil {
+		t.Errorf("Expected a valid configuration, got %v", err)
+	}
+	if err := ValidateQuietHours(nil); err != nil {
+		t.Errorf("Expected no quiet hours to be valid, got %v", err)
+	}
+
+	for name, config := range map[string]*models.QuietHoursConfig{
+		"hour only":        {StartTime: "22"},
+		"minutes too high": {StartTime: "22:60"},
+		"single digit":     {EndTime: "6:5"},
+		"past midnight":    {EndTime: "24:30"},
+		"unknown timezone": {Timezone: "Mars/Olympus_Mons"},
+		"day out of range": {Days: []int{7}},
+	} {
+		if err := ValidateQuietHours(config); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestMSTeamsChannel(t *testing.T) {
+	store, err := storage.NewMemoryStore()
+	if err != nil {
+		t.Fatalf("Failed to create memory store: %v", err)
+	}
+	logger := NewLogger("error", false)
+	notificationService := NewNotificationService(&config.Config{Port: "8080"}, store, NewNotificationTemplateService(logger), NewMetricsService(prometheus.NewRegistry()), logger)
+
+	var card MSTeamsMessageCard
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&card); err != nil {
+			t.Errorf("Failed to decode card: %v", err)
+		}
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	incident := &models.Incident{ID: "incident-1", Title: "Checkout down", Severity: models.SeverityCritical, Status: models.IncidentStatusOpen}
+	channelConfig := map[string]string{"webhook_url": server.URL}
+	if err := notificationService.sendMSTeamsNotificationWithConfig("Incident Alert: Checkout down", "Payments are failing", channelConfig, incident); err != nil {
+		t.Fatalf("Failed to send Teams notification: %v", err)
+	}
+
+	if card.Type != "MessageCard" || card.Title != "Checkout down" || card.Text != "Payments are failing" || card.ThemeColor == "" {
+		t.Errorf("Unexpected card: %+v", card)
+	}
+	facts := map[string]string{}
+	for _, section := range card.Sections {
+		for _, fact := range section.Facts {
+			facts[fact.Name] = fact.Value
+		}
+	}
+	if facts["Severity"] != "critical" || facts["Status"] != "open" {
+		t.Errorf("Expected severity and status facts, got %v", facts)
+	}
+
+	status = http.StatusBadRequest
+	if err := notificationService.sendMSTeamsNotificationWithConfig("subject", "content", channelConfig, incident); err == nil {
+		t.Error("Expected an error for a non-2xx response")
+	}
+	if err := notificationService.sendMSTeamsNotificationWithConfig("subject", "content", map[string]string{}, incident); err == nil {
+		t.Error("Expected an error without a webhook_url")
+	}
+}
+
+func TestPagerDutyChannel(t *testing.T) {
+	store, err := storage.NewMemoryStore()
+	if err != nil {
+		t.Fatalf("Failed to create memory store: %v", err)
+	}
+	logger := NewLogger("error", false)
+	notificationService := NewNotificationService(&config.Config{Port: "8080"}, store, NewNotificationTemplateService(logger), NewMetricsService(prometheus.NewRegistry()), logger)
+
+	var events []PagerDutyEvent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event PagerDutyEvent
+		if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+			t.Errorf("Failed to decode event: %v", err)
+		}
+		events = append(events, event)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	channelConfig := map[string]string{"routing_key": "R0UT1NGK3Y", "events_url": server.URL}
+	incident := &models.Incident{ID: "incident-1", Title: "Checkout down", Severity: models.SeverityHigh, Status: models.IncidentStatusOpen, CreatedAt: time.Now()}
+
+	if err := notificationService.sendPagerDutyNotificationWithConfig("Incident Alert: Checkout down", channelConfig, incident); err != nil {
+		t.Fatalf("Failed to send trigger: %v", err)
+	}
+	incident.Status = models.IncidentStatusResolved
+	if err := notificationService.sendPagerDutyNotificationWithConfig("Incident Resolved: Checkout down", channelConfig, incident); err != nil {
+		t.Fatalf("Failed to send resolve: %v", err)
+	}
+
+	if len(events) != 2 {
+		t.Fatalf("Expected 2 events, got %d", len(events))
+	}
+	trigger, resolve := events[0], events[1]
+	if trigger.EventAction != "trigger" || trigger.RoutingKey != "R0UT1NGK3Y" || trigger.Payload == nil ||
+		trigger.Payload.Severity != "error" || trigger.Payload.Summary != "Incident Alert: Checkout down" {
+		t.Errorf("Unexpected trigger event: %+v", trigger)
+	}
+	if resolve.EventAction != "resolve" || resolve.Payload != nil {
+		t.Errorf("Unexpected resolve event: %+v", resolve)
+	}
+	if trigger.DedupKey == "" || resolve.DedupKey != trigger.DedupKey {
+		t.Errorf("Expected the resolve to reuse the trigger's dedup key, got %q and %q", trigger.DedupKey, resolve.DedupKey)
+	}
+
+	if err := notificationService.sendPagerDutyNotificationWithConfig("subject", map[string]string{"events_url": server.URL}, incident); err == nil {
+		t.Error("Expected an error without a routing_key")
+	}
+}
+
+// roundTripFunc mocks an HTTP transport
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func TestSMSChannel(t *testing.T) {
+	store, err := storage.NewMemoryStore()
+	if err != nil {
+		t.Fatalf("Failed to create memory store: %v", err)
+	}
+	logger := NewLogger("error", false)
+	notificationService := NewNotificationService(&config.Config{Port: "8080"}, store, NewNotificationTemplateService(logger), NewMetricsService(prometheus.NewRegistry()), logger)
+
+	var requests []*http.Request
+	var forms []url.Values
+	status, body := http.StatusCreated, `{"sid": "SM123"}`
+	notificationService.transport = roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("Failed to parse form: %v", err)
+		}
+		requests = append(requests, r)
+		forms = append(forms, r.PostForm)
+		return &http.Response{StatusCode: status, Body: io.NopCloser(strings.NewReader(body)), Header: make(http.Header)}, nil
+	})
+
+	channelConfig := map[string]string{"account_sid": "AC123", "auth_token": "secret", "from": "+15550001111", "to": "+15550002222"}
+	incident := &models.Incident{ID: "incident-1", Title: strings.Repeat("Checkout latency above SLO ", 10), Severity: models.SeverityCritical, Status: models.IncidentStatusOpen}
+
+	if err := notificationService.sendSMSNotificationWithConfig("Incident Alert", channelConfig, incident); err != nil {
+		t.Fatalf("Failed to send SMS: %v", err)
+	}
+	if len(requests) != 1 {
+		t.Fatalf("Expected 1 request, got %d", len(requests))
+	}
+	req, form := requests[0], forms[0]
+	if req.URL.String() != TwilioAPIURL+"/Accounts/AC123/Messages.json" {
+		t.Errorf("Unexpected Twilio URL: %s", req.URL)
+	}
+	if user, pass, ok := req.BasicAuth(); !ok || user != "AC123" || pass != "secret" {
+		t.Errorf("Expected basic auth with the account SID and token, got %q/%q", user, pass)
+	}
+	if form.Get("From") != "+15550001111" || form.Get("To") != "+15550002222" {
+		t.Errorf("Unexpected from/to: %v", form)
+	}
+	smsText := form.Get("Body")
+	if len([]rune(smsText)) > 160 || !strings.HasPrefix(smsText, "[CRITICAL] Checkout latency") ||
+		!strings.HasSuffix(smsText, "http://localhost:8080/incidents/incident-1") {
+		t.Errorf("Expected a short body with severity, title and link, got %q (%d chars)", smsText, len([]rune(smsText)))
+	}
+
+	status, body = http.StatusBadRequest, `{"code": 21211, "message": "The 'To' number +1555 is not a valid phone number.", "status": 400}`
+	err = notificationService.sendSMSNotificationWithConfig("Incident Alert", channelConfig, incident)
+	if err == nil || !strings.Contains(err.Error(), "21211") {
+		t.Errorf("Expected the Twilio error code in the error, got %v", err)
+	}
+
+	if err := notificationService.sendSMSNotificationWithConfig("Incident Alert", map[string]string{"account_sid": "AC123"}, incident); err == nil {
+		t.Error("Expected an error without credentials and numbers")
+	}
+}
@@ -0,0 +1,209 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/models"
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/storage"
+)
+
+const minutesPerDay = 24 * 60
+
+// OnCallService resolves on-call schedules to the people currently on call
+type OnCallService struct {
+	store storage.Store
+}
+
+// NewOnCallService creates an on-call service
+func NewOnCallService(store storage.Store) *OnCallService {
+	return &OnCallService{store: store}
+}
+
+// GetCurrentOnCall returns who is on call in the schedule at the given time:
+// for each layer, the user whose rotation shift covers it, provided it falls
+// within one of the layer's restrictions. Users on call in several layers
+// are returned once, in layer order. Times are evaluated in the schedule's
+// timezone.
+func (s *OnCallService) GetCurrentOnCall(ctx context.Context, scheduleID string, at time.Time) ([]*models.User, error) {
+	schedule, err := s.store.GetOnCallSchedule(ctx, scheduleID)
+	if err != nil {
+		return nil, err
+	}
+
+	userIDs, err := onCallUserIDs(schedule, at)
+	if err != nil {
+		return nil, err
+	}
+
+	users := make([]*models.User, 0, len(userIDs))
+	for _, userID := range userIDs {
+		user, err := s.store.GetUser(ctx, userID)
+		if err != nil {
+			return nil, fmt.Errorf("on-call user %s: %w", userID, err)
+		}
+		users = append(users, user)
+	}
+	return users, nil
+}
+
+// scheduleTime returns the given time in the schedule's timezone
+func scheduleTime(schedule *models.OnCallSchedule, at time.Time) (time.Time, error) {
+	if schedule.Timezone == "" {
+		return at.In(time.UTC), nil
+	}
+	loc, err := time.LoadLocation(schedule.Timezone)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid schedule timezone %q: %w", schedule.Timezone, err)
+	}
+	return at.In(loc), nil
+}
+
+// onCallUserIDs returns the IDs of the users on call at the given time
+func onCallUserIDs(schedule *models.OnCallSchedule, at time.Time) ([]string, error) {
+	at, err := scheduleTime(schedule, at)
+	if err != nil {
+		return nil, err
+	}
+
+	var userIDs []string
+	seen := make(map[string]bool)
+	for _, layer := range schedule.Layers {
+		userID, err := layerOnCall(layer, at)
+		if err != nil {
+			return nil, fmt.Errorf("layer %q: %w", layer.Name, err)
+		}
+		if userID != "" && !seen[userID] {
+			seen[userID] = true
+			userIDs = append(userIDs, userID)
+		}
+	}
+	return userIDs, nil
+}
+
+// layerOnCall returns the user on call in a layer at the given local time, or
+// "" when the layer has not started or the time is outside its restrictions
+func layerOnCall(layer models.ScheduleLayer, at time.Time) (string, error) {
+	index, err := layerOnCallIndex(layer, at)
+	if err != nil || index < 0 {
+		return "", err
+	}
+	return layer.Users[index], nil
+}
+
+// layerOnCallIndex returns the position in the layer's users of the user on
+// call at the given local time, or -1 when nobody is
+func layerOnCallIndex(layer models.ScheduleLayer, at time.Time) (int, error) {
+	if len(layer.Users) == 0 || at.Before(layer.Start) {
+		return -1, nil
+	}
+
+	covered, err := withinRestrictions(layer.Restrictions, at)
+	if err != nil || !covered {
+		return -1, err
+	}
+
+	shift, err := rotationShift(layer, at)
+	if err != nil {
+		return -1, err
+	}
+	if shift < 0 {
+		shift = 0 // before the first handoff
+	}
+	return shift % len(layer.Users), nil
+}
+
+// rotationShift returns how many complete shifts have passed between the
+// layer's first handoff and the given time. Shifts change at the handoff time
+// of day (the start's time of day by default) every Length days, weeks or
+// months, counted in calendar days so DST changes do not move the handoff.
+func rotationShift(layer models.ScheduleLayer, at time.Time) (int, error) {
+	start := layer.Start.In(at.Location())
+	handoff := start.Hour()*60 + start.Minute()
+	if layer.Rotation.Handoff != "" {
+		var err error
+		if handoff, err = parseClock(layer.Rotation.Handoff); err != nil {
+			return 0, fmt.Errorf("invalid handoff: %w", err)
+		}
+	}
+	length := layer.Rotation.Length
+	if length <= 0 {
+		length = 1
+	}
+
+	// The first shift runs until the handoff on the start date plus one
+	// period. A time belongs to the rotation day of its date, or of the
+	// previous date before that day's handoff.
+	first := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.UTC)
+	current := time.Date(at.Year(), at.Month(), at.Day(), 0, 0, 0, 0, time.UTC)
+	if at.Hour()*60+at.Minute() < handoff {
+		current = current.AddDate(0, 0, -1)
+	}
+	days := int(current.Sub(first).Hours() / 24)
+
+	switch layer.Rotation.Type {
+	case "daily":
+		return days / length, nil
+	case "", "weekly":
+		return days / (7 * length), nil
+	case "monthly":
+		months := (current.Year()-first.Year())*12 + int(current.Month()-first.Month())
+		if current.Day() < first.Day() {
+			months--
+		}
+		return months / length, nil
+	default:
+		return 0, fmt.Errorf("unknown rotation type %q", layer.Rotation.Type)
+	}
+}
+
+// withinRestrictions reports whether a local time falls inside any of the
+// restriction windows. No restrictions means always on call. Windows whose
+// end is not after their start wrap around, e.g. a daily 22:00-06:00 window
+// covers the night.
+func withinRestrictions(restrictions []models.Restriction, at time.Time) (bool, error) {
+	if len(restrictions) == 0 {
+		return true, nil
+	}
+
+	minuteOfDay := at.Hour()*60 + at.Minute()
+	for _, restriction := range restrictions {
+		start, err := parseClock(restriction.StartTime)
+		if err != nil {
+			return false, fmt.Errorf("invalid restriction start: %w", err)
+		}
+		end, err := parseClock(restriction.EndTime)
+		if err != nil {
+			return false, fmt.Errorf("invalid restriction end: %w", err)
+		}
+
+		now := minuteOfDay
+		switch restriction.Type {
+		case "", "daily":
+		case "weekly":
+			now += int(at.Weekday()) * minutesPerDay
+			start += restriction.StartDay * minutesPerDay
+			end += restriction.EndDay * minutesPerDay
+		default:
+			return false, fmt.Errorf("unknown restriction type %q", restriction.Type)
+		}
+
+		if start < end && now >= start && now < end {
+			return true, nil
+		}
+		if start >= end && (now >= start || now < end) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// parseClock parses an "HH:MM" time of day into minutes after midnight
+func parseClock(value string) (int, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("expected HH:MM, got %q", value)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
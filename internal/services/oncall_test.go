@@ -0,0 +1,123 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/models"
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/storage"
+)
+
+func TestOnCallService_WeeklyRotationWithHandoff(t *testing.T) {
+	ctx := context.Background()
+	store, err := storage.NewMemoryStore()
+	if err != nil {
+		t.Fatalf("Failed to create memory store: %v", err)
+	}
+	for _, id := range []string{"alice", "bob", "carol"} {
+		if err := store.CreateUser(ctx, &models.User{ID: id, Username: id, Email: id + "@example.com"}); err != nil {
+			t.Fatalf("Failed to create user: %v", err)
+		}
+	}
+
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("Timezone data unavailable: %v", err)
+	}
+	schedule := &models.OnCallSchedule{
+		ID:       "primary",
+		Timezone: "America/New_York",
+		Layers: []models.ScheduleLayer{{
+			Name:     "Primary",
+			Users:    []string{"alice", "bob", "carol"},
+			Rotation: models.RotationType{Type: "weekly", Length: 1, Handoff: "09:00"},
+			Start:    time.Date(2024, time.March, 4, 0, 0, 0, 0, newYork), // Monday
+		}},
+	}
+	if err := store.CreateOnCallSchedule(ctx, schedule); err != nil {
+		t.Fatalf("Failed to create schedule: %v", err)
+	}
+
+	onCall := NewOnCallService(store)
+	tests := []struct {
+		name     string
+		at       time.Time
+		expected string
+	}{
+		{name: "before the first handoff", at: time.Date(2024, time.March, 4, 8, 0, 0, 0, newYork), expected: "alice"},
+		{name: "end of the first week", at: time.Date(2024, time.March, 11, 8, 59, 0, 0, newYork), expected: "alice"},
+		// DST started on March 10; the handoff stays at 09:00 local time
+		{name: "second week handoff", at: time.Date(2024, time.March, 11, 9, 0, 0, 0, newYork), expected: "bob"},
+		{name: "given in UTC", at: time.Date(2024, time.March, 18, 13, 0, 0, 0, time.UTC), expected: "carol"},
+		{name: "rotation wraps", at: time.Date(2024, time.March, 25, 9, 30, 0, 0, newYork), expected: "alice"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users, err := onCall.GetCurrentOnCall(ctx, "primary", tt.at)
+			if err != nil {
+				t.Fatalf("GetCurrentOnCall failed: %v", err)
+			}
+			if len(users) != 1 || users[0].ID != tt.expected {
+				t.Errorf("Expected %s on call, got %v", tt.expected, users)
+			}
+		})
+	}
+
+	if users, err := onCall.GetCurrentOnCall(ctx, "primary", schedule.Layers[0].Start.Add(-time.Hour)); err != nil || len(users) != 0 {
+		t.Errorf("Expected nobody on call before the layer starts, got %v (err: %v)", users, err)
+	}
+}
+
+func TestOnCallService_OvernightRestriction(t *testing.T) {
+	schedule := &models.OnCallSchedule{
+		Timezone: "UTC",
+		Layers: []models.ScheduleLayer{
+			{
+				Name:         "Nights",
+				Users:        []string{"night-1", "night-2"},
+				Rotation:     models.RotationType{Type: "daily", Length: 1, Handoff: "22:00"},
+				Start:        time.Date(2024, time.March, 4, 22, 0, 0, 0, time.UTC),
+				Restrictions: []models.Restriction{{Type: "daily", StartTime: "22:00", EndTime: "06:00"}},
+			},
+			{
+				Name:     "Weekends",
+				Users:    []string{"weekend"},
+				Rotation: models.RotationType{Type: "weekly", Length: 1},
+				Start:    time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC),
+				// Friday 18:00 until Monday 08:00
+				Restrictions: []models.Restriction{{Type: "weekly", StartDay: 5, StartTime: "18:00", EndDay: 1, EndTime: "08:00"}},
+			},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		at       time.Time
+		expected []string
+	}{
+		{name: "first night", at: time.Date(2024, time.March, 4, 23, 0, 0, 0, time.UTC), expected: []string{"night-1"}},
+		{name: "after midnight stays with the same shift", at: time.Date(2024, time.March, 5, 5, 59, 0, 0, time.UTC), expected: []string{"night-1"}},
+		{name: "daytime is uncovered", at: time.Date(2024, time.March, 5, 6, 0, 0, 0, time.UTC), expected: nil},
+		{name: "second night", at: time.Date(2024, time.March, 5, 22, 30, 0, 0, time.UTC), expected: []string{"night-2"}},
+		{name: "saturday night", at: time.Date(2024, time.March, 9, 23, 0, 0, 0, time.UTC), expected: []string{"night-2", "weekend"}},
+		{name: "saturday afternoon", at: time.Date(2024, time.March, 9, 14, 0, 0, 0, time.UTC), expected: []string{"weekend"}},
+		{name: "monday morning", at: time.Date(2024, time.March, 11, 8, 0, 0, 0, time.UTC), expected: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userIDs, err := onCallUserIDs(schedule, tt.at)
+			if err != nil {
+				t.Fatalf("onCallUserIDs failed: %v", err)
+			}
+			if len(userIDs) != len(tt.expected) {
+				t.Fatalf("Expected %v on call, got %v", tt.expected, userIDs)
+			}
+			for i := range userIDs {
+				if userIDs[i] != tt.expected[i] {
+					t.Fatalf("Expected %v on call, got %v", tt.expected, userIDs)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,234 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/models"
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/storage"
+)
+
+// Labels marking that an incident's acknowledgement or resolution SLA was
+// breached, so each breach is recorded on the timeline once
+const (
+	SLAAckBreachedLabel     = "sla_ack_breached"
+	SLAResolveBreachedLabel = "sla_resolve_breached"
+)
+
+// SLATargets are the per-severity times within which incidents must be
+// acknowledged and resolved. Severities without a target have no SLA.
+type SLATargets struct {
+	Ack     map[models.IncidentSeverity]time.Duration
+	Resolve map[models.IncidentSeverity]time.Duration
+}
+
+// slaAtRiskFraction is the share of a target that may elapse before a
+// pending incident is reported at risk rather than on track
+const slaAtRiskFraction = 0.8
+
+// Status returns how the incident stands against its acknowledgement and
+// resolution targets at now: the worse of the two, or "" when its severity
+// has no targets
+func (t SLATargets) Status(incident *models.Incident, now time.Time) models.SLAStatus {
+	var status models.SLAStatus
+	for _, check := range []struct {
+		target time.Duration
+		doneAt *time.Time
+	}{
+		{target: t.Ack[incident.Severity], doneAt: incident.AckedAt},
+		{target: t.Resolve[incident.Severity], doneAt: incident.ResolvedAt},
+	} {
+		if check.target <= 0 {
+			continue
+		}
+		if checked := slaCheck(incident.CreatedAt, check.doneAt, check.target, now); slaStatusRank[checked] > slaStatusRank[status] {
+			status = checked
+		}
+	}
+	return status
+}
+
+// slaStatusRank orders SLA states from best to worst
+var slaStatusRank = map[models.SLAStatus]int{
+	models.SLAOnTrack:  1,
+	models.SLAAtRisk:   2,
+	models.SLABreached: 3,
+}
+
+// slaCheck returns the state of one target for an incident created at
+// createdAt and handled at doneAt, or still pending when doneAt is nil
+func slaCheck(createdAt time.Time, doneAt *time.Time, target time.Duration, now time.Time) models.SLAStatus {
+	deadline := createdAt.Add(target)
+	switch {
+	case doneAt != nil && doneAt.After(deadline):
+		return models.SLABreached
+	case doneAt != nil:
+		return models.SLAOnTrack
+	case !now.Before(deadline):
+		return models.SLABreached
+	case now.Sub(createdAt) >= time.Duration(float64(target)*slaAtRiskFraction):
+		return models.SLAAtRisk
+	}
+	return models.SLAOnTrack
+}
+
+// ParseSLATargets parses targets written as "severity:duration",
+// e.g. "critical:15m"
+func ParseSLATargets(specs []string) (map[models.IncidentSeverity]time.Duration, error) {
+	targets := make(map[models.IncidentSeverity]time.Duration, len(specs))
+	for _, spec := range specs {
+		name, value, ok := strings.Cut(spec, ":")
+		severity := models.IncidentSeverity(strings.ToLower(strings.TrimSpace(name)))
+		if !ok || severityRank(severity) == 0 {
+			return nil, fmt.Errorf("invalid SLA target %q: expected severity:duration", spec)
+		}
+		target, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil || target <= 0 {
+			return nil, fmt.Errorf("invalid SLA target %q: duration must be positive", spec)
+		}
+		targets[severity] = target
+	}
+	return targets, nil
+}
+
+// SLAMonitor records a timeline entry when an incident misses its
+// acknowledgement or resolution target
+type SLAMonitor struct {
+	store           storage.Store
+	incidentService *IncidentService
+	targets         SLATargets
+	logger          *Logger
+
+	// now is replaced in tests
+	now func() time.Time
+
+	stopChan chan struct{}
+	stopOnce sync.Once
+}
+
+// NewSLAMonitor creates an SLA monitor for the given targets
+func NewSLAMonitor(store storage.Store, incidentService *IncidentService, targets SLATargets, logger *Logger) *SLAMonitor {
+	return &SLAMonitor{
+		store:           store,
+		incidentService: incidentService,
+		targets:         targets,
+		logger:          logger,
+		now:             time.Now,
+		stopChan:        make(chan struct{}),
+	}
+}
+
+// Start checks for SLA breaches every 30 seconds until Stop is called
+func (m *SLAMonitor) Start() {
+	go func() {
+		ticker := time.NewTicker(30 * time.Second)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if _, err := m.CheckBreaches(context.Background()); err != nil {
+					m.logger.Error("Failed to check SLA breaches", map[string]interface{}{
+						"error": err.Error(),
+					})
+				}
+			case <-m.stopChan:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the monitor
+func (m *SLAMonitor) Stop() {
+	m.stopOnce.Do(func() { close(m.stopChan) })
+}
+
+// CheckBreaches records newly breached SLAs and returns how many were found.
+// A target is breached when the incident was not acknowledged (or resolved)
+// within it, including incidents that were handled late since the last check.
+func (m *SLAMonitor) CheckBreaches(ctx context.Context) (int, error) {
+	incidents, err := m.store.ListIncidents(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	now := m.now()
+	breaches := 0
+	for _, incident := range incidents {
+		checks := []struct {
+			kind   string
+			label  string
+			target time.Duration
+			doneAt *time.Time
+		}{
+			{kind: "ack", label: SLAAckBreachedLabel, target: m.targets.Ack[incident.Severity], doneAt: incident.AckedAt},
+			{kind: "resolve", label: SLAResolveBreachedLabel, target: m.targets.Resolve[incident.Severity], doneAt: incident.ResolvedAt},
+		}
+		for _, check := range checks {
+			if check.target <= 0 || incident.Labels[check.label] != "" {
+				continue
+			}
+			if slaCheck(incident.CreatedAt, check.doneAt, check.target, now) != models.SLABreached {
+				continue
+			}
+			deadline := incident.CreatedAt.Add(check.target)
+
+			recorded, err := m.recordBreach(ctx, incident.ID, check.kind, check.label, check.target, deadline)
+			if err != nil {
+				m.logger.Error("Failed to record SLA breach", map[string]interface{}{
+					"incident_id": incident.ID,
+					"sla":         check.kind,
+					"error":       err.Error(),
+				})
+				continue
+			}
+			if recorded {
+				breaches++
+			}
+		}
+	}
+
+	return breaches, nil
+}
+
+// recordBreach marks the incident and adds an sla_breach timeline entry. The
+// incident is re-read first so that changes made since it was listed are not
+// overwritten; it reports false if the breach was already recorded.
+func (m *SLAMonitor) recordBreach(ctx context.Context, incidentID, kind, label string, target time.Duration, deadline time.Time) (bool, error) {
+	incident, err := m.store.GetIncident(ctx, incidentID)
+	if err != nil {
+		return false, err
+	}
+	if incident.Labels[label] != "" {
+		return false, nil
+	}
+	if incident.Labels == nil {
+		incident.Labels = make(map[string]string)
+	}
+	incident.Labels[label] = deadline.UTC().Format(time.RFC3339)
+	if err := m.incidentService.UpdateIncident(ctx, incident); err != nil {
+		return false, err
+	}
+
+	metadata := map[string]interface{}{
+		"sla":      kind,
+		"target":   target.String(),
+		"deadline": deadline.UTC().Format(time.RFC3339),
+		"severity": incident.Severity,
+	}
+	_, err = m.incidentService.AddComment(ctx, incident.ID, "system",
+		fmt.Sprintf("%s SLA of %s breached", slaDisplayName(kind), target),
+		models.CommentTypeSLABreach, metadata)
+	return true, err
+}
+
+func slaDisplayName(kind string) string {
+	if kind == "ack" {
+		return "Acknowledgement"
+	}
+	return "Resolution"
+}
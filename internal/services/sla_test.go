@@ -0,0 +1,292 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/models"
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/storage"
+)
+
+func TestSLAMonitor_RecordsBreachOnTimeline(t *testing.T) {
+	ctx := context.Background()
+	store, err := storage.NewMemoryStore()
+	if err != nil {
+		t.Fatalf("Failed to create memory store: %v", err)
+	}
+	incidentService := NewIncidentService(store, NewMetricsService(prometheus.NewRegistry()))
+
+	late, err := incidentService.CreateIncident(ctx, "Checkout down", "", models.SeverityCritical, []string{})
+	if err != nil {
+		t.Fatalf("Failed to create incident: %v", err)
+	}
+	onTime, err := incidentService.CreateIncident(ctx, "Checkout slow", "", models.SeverityCritical, []string{})
+	if err != nil {
+		t.Fatalf("Failed to create incident: %v", err)
+	}
+	noTarget, err := incidentService.CreateIncident(ctx, "Disk filling up", "", models.SeverityLow, []string{})
+	if err != nil {
+		t.Fatalf("Failed to create incident: %v", err)
+	}
+	if err := incidentService.AcknowledgeIncident(ctx, onTime.ID, "user-alice"); err != nil {
+		t.Fatalf("Failed to acknowledge incident: %v", err)
+	}
+
+	targets := SLATargets{
+		Ack:     map[models.IncidentSeverity]time.Duration{models.SeverityCritical: 15 * time.Minute},
+		Resolve: map[models.IncidentSeverity]time.Duration{models.SeverityCritical: 4 * time.Hour},
+	}
+	clock := &fakeClock{current: late.CreatedAt}
+	monitor := NewSLAMonitor(store, incidentService, targets, NewLogger("error", false))
+	monitor.now = clock.Now
+
+	check := func(expect int) {
+		t.Helper()
+		breaches, err := monitor.CheckBreaches(ctx)
+		if err != nil {
+			t.Fatalf("CheckBreaches failed: %v", err)
+		}
+		if breaches != expect {
+			t.Fatalf("Expected %d breaches, got %d", expect, breaches)
+		}
+	}
+
+	clock.Advance(10 * time.Minute)
+	check(0)
+
+	// Only the unacknowledged critical incident misses its target, once
+	clock.Advance(10 * time.Minute)
+	check(1)
+	check(0)
+
+	timeline, err := incidentService.GetTimeline(ctx, late.ID)
+	if err != nil || len(timeline) != 1 {
+		t.Fatalf("Expected one timeline entry, got %d (err: %v)", len(timeline), err)
+	}
+	entry := timeline[0]
+	if entry.CommentType != models.CommentTypeSLABreach {
+		t.Errorf("Expected an sla_breach timeline entry, got %s", entry.CommentType)
+	}
+	if entry.Metadata["sla"] != "ack" || entry.Metadata["target"] != "15m0s" {
+		t.Errorf("Expected ack breach metadata with a 15m target, got %v", entry.Metadata)
+	}
+	breached, _ := incidentService.GetIncident(ctx, late.ID)
+	if breached.Labels[SLAAckBreachedLabel] == "" {
+		t.Errorf("Expected the incident to be labelled %s, got %v", SLAAckBreachedLabel, breached.Labels)
+	}
+
+	for _, id := range []string{onTime.ID, noTarget.ID} {
+		if timeline, _ := incidentService.GetTimeline(ctx, id); len(timeline) != 0 {
+			t.Errorf("Expected no SLA entries for incident %s, got %d", id, len(timeline))
+		}
+	}
+}
+
+func TestParseSLATargets_Invalid(t *testing.T) {
+	for _, spec := range []string{"critical", "urgent:15m", "high:soon", "low:0s"} {
+		if _, err := ParseSLATargets([]string{spec}); err == nil {
+			t.Errorf("Expected %q to be rejected", spec)
+		}
+	}
+}
+
+func TestSLATargets_Status(t *testing.T) {
+	targets := SLATargets{
+		Ack:     map[models.IncidentSeverity]time.Duration{models.SeverityCritical: 15 * time.Minute},
+		Resolve: map[models.IncidentSeverity]time.Duration{models.SeverityCritical: 4 * time.Hour},
+	}
+	created := time.Date(2024, time.March, 15, 9, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) *time.Time {
+		t := created.Add(d)
+		return &t
+	}
+
+	tests := []struct {
+		name     string
+		severity models.IncidentSeverity
+		acked    *time.Time
+		resolved *time.Time
+		now      time.Time
+		want     models.SLAStatus
+	}{
+		{name: "pending ack inside target", severity: models.SeverityCritical, now: created.Add(5 * time.Minute), want: models.SLAOnTrack},
+		{name: "pending ack near target", severity: models.SeverityCritical, now: created.Add(12 * time.Minute), want: models.SLAAtRisk},
+		{name: "pending ack past target", severity: models.SeverityCritical, now: created.Add(15 * time.Minute), want: models.SLABreached},
+		{name: "acked late", severity: models.SeverityCritical, acked: at(20 * time.Minute), now: created.Add(time.Hour), want: models.SLABreached},
+		{name: "acked in time", severity: models.SeverityCritical, acked: at(10 * time.Minute), now: created.Add(time.Hour), want: models.SLAOnTrack},
+		{name: "pending resolve near target", severity: models.SeverityCritical, acked: at(10 * time.Minute), now: created.Add(3*time.Hour + 30*time.Minute), want: models.SLAAtRisk},
+		{name: "pending resolve past target", severity: models.SeverityCritical, acked: at(10 * time.Minute), now: created.Add(5 * time.Hour), want: models.SLABreached},
+		{name: "resolved late", severity: models.SeverityCritical, acked: at(10 * time.Minute), resolved: at(4*time.Hour + time.Minute), now: created.Add(24 * time.Hour), want: models.SLABreached},
+		{name: "resolved in time", severity: models.SeverityCritical, acked: at(10 * time.Minute), resolved: at(2 * time.Hour), now: created.Add(24 * time.Hour), want: models.SLAOnTrack},
+		{name: "no target", severity: models.SeverityLow, now: created.Add(24 * time.Hour), want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			incident := &models.Incident{Severity: tt.severity, CreatedAt: created, AckedAt: tt.acked, ResolvedAt: tt.resolved}
+			if got := targets.Status(incident, tt.now); got != tt.want {
+				t.Errorf("Status() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIncidentService_ReportsSLAStatus(t *testing.T) {
+	ctx := context.Background()
+	store, err := storage.NewMemoryStore()
+	if err != nil {
+		t.Fatalf("Failed to create memory store: %v", err)
+	}
+	incidentService := NewIncidentService(store, NewMetricsService(prometheus.NewRegistry()))
+	incidentService.SetSLATargets(SLATargets{
+		Ack: map[models.IncidentSeverity]time.Duration{models.SeverityCritical: 15 * time.Minute},
+	})
+
+	incident := &models.Incident{ID: "incident-1", Title: "Checkout down", Status: models.IncidentStatusOpen, Severity: models.SeverityCritical,
+		CreatedAt: time.Now().Add(-time.Hour), UpdatedAt: time.Now(), Labels: map[string]string{}}
+	if err := store.CreateIncident(ctx, incident); err != nil {
+		t.Fatalf("Failed to create incident: %v", err)
+	}
+
+	got, err := incidentService.GetIncident(ctx, incident.ID)
+	if err != nil {
+		t.Fatalf("GetIncident failed: %v", err)
+	}
+	if got.SLAStatus != models.SLABreached {
+		t.Errorf("Expected sla_status %q, got %q", models.SLABreached, got.SLAStatus)
+	}
+}
+
+func TestIncidentService_SummaryReport(t *testing.T) {
+	ctx := context.Background()
+	store, err := storage.NewMemoryStore()
+	if err != nil {
+		t.Fatalf("Failed to create memory store: %v", err)
+	}
+	incidentService := NewIncidentService(store, NewMetricsService(prometheus.NewRegistry()))
+	incidentService.SetSLATargets(SLATargets{
+		Ack: map[models.IncidentSeverity]time.Duration{models.SeverityCritical: 15 * time.Minute},
+	})
+
+	from := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(24 * time.Hour)
+	at := func(offset time.Duration) *time.Time {
+		t := from.Add(offset)
+		return &t
+	}
+	for _, incident := range []*models.Incident{
+		// Before the window: slow and breached, and must not skew the report
+		{ID: "old", Status: models.IncidentStatusResolved, Severity: models.SeverityCritical,
+			CreatedAt: from.Add(-time.Hour), AckedAt: at(10 * time.Hour), ResolvedAt: at(20 * time.Hour)},
+		// Acknowledged after 10 minutes and resolved after an hour
+		{ID: "fast", Status: models.IncidentStatusResolved, Severity: models.SeverityCritical,
+			CreatedAt: from, AckedAt: at(10 * time.Minute), ResolvedAt: at(time.Hour)},
+		// Acknowledged after 30 minutes, missing the 15 minute target
+		{ID: "slow", Status: models.IncidentStatusAcknowledged, Severity: models.SeverityCritical,
+			CreatedAt: from.Add(2 * time.Hour), AckedAt: at(2*time.Hour + 30*time.Minute)},
+		{ID: "new", Status: models.IncidentStatusOpen, Severity: models.SeverityLow, CreatedAt: from.Add(3 * time.Hour)},
+		// At the end of the window, which is exclusive
+		{ID: "next", Status: models.IncidentStatusOpen, Severity: models.SeverityLow, CreatedAt: to},
+	} {
+		incident.Title = incident.ID
+		incident.UpdatedAt = incident.CreatedAt
+		incident.Labels = map[string]string{}
+		if err := store.CreateIncident(ctx, incident); err != nil {
+			t.Fatalf("Failed to create incident: %v", err)
+		}
+	}
+
+	report, err := incidentService.SummaryReport(ctx, from, to)
+	if err != nil {
+		t.Fatalf("SummaryReport failed: %v", err)
+	}
+	if report.TotalIncidents != 3 {
+		t.Errorf("Expected 3 incidents in the window, got %d", report.TotalIncidents)
+	}
+	if report.IncidentsByStatus["resolved"] != 1 || report.IncidentsByStatus["acknowledged"] != 1 || report.IncidentsByStatus["open"] != 1 {
+		t.Errorf("Unexpected counts by status: %v", report.IncidentsByStatus)
+	}
+	if report.IncidentsBySeverity["critical"] != 2 || report.IncidentsBySeverity["low"] != 1 {
+		t.Errorf("Unexpected counts by severity: %v", report.IncidentsBySeverity)
+	}
+	if report.MTTASeconds != 20*60 {
+		t.Errorf("Expected an MTTA of 20 minutes, got %ds", report.MTTASeconds)
+	}
+	if report.MTTRSeconds != 60*60 {
+		t.Errorf("Expected an MTTR of 1 hour, got %ds", report.MTTRSeconds)
+	}
+	if report.SLABreaches != 1 || report.SLABreachesBySeverity["critical"] != 1 {
+		t.Errorf("Expected 1 critical SLA breach, got %d (%v)", report.SLABreaches, report.SLABreachesBySeverity)
+	}
+}
+
+// snapshotListStore lists copies of the incidents, as Postgres does, and runs
+// afterList once they have been taken
+type snapshotListStore struct {
+	storage.Store
+	afterList func()
+}
+
+func (s *snapshotListStore) ListIncidents(ctx context.Context) ([]*models.Incident, error) {
+	incidents, err := s.Store.ListIncidents(ctx)
+	if err != nil {
+		return nil, err
+	}
+	snapshots := make([]*models.Incident, 0, len(incidents))
+	for _, incident := range incidents {
+		snapshot := *incident
+		snapshot.Labels = make(map[string]string, len(incident.Labels))
+		for k, v := range incident.Labels {
+			snapshot.Labels[k] = v
+		}
+		snapshots = append(snapshots, &snapshot)
+	}
+	if s.afterList != nil {
+		s.afterList()
+	}
+	return snapshots, nil
+}
+
+func TestSLAMonitor_BreachKeepsConcurrentChanges(t *testing.T) {
+	ctx := context.Background()
+	memoryStore, err := storage.NewMemoryStore()
+	if err != nil {
+		t.Fatalf("Failed to create memory store: %v", err)
+	}
+	store := &snapshotListStore{Store: memoryStore}
+	incidentService := NewIncidentService(store, NewMetricsService(prometheus.NewRegistry()))
+
+	incident, err := incidentService.CreateIncident(ctx, "Checkout down", "", models.SeverityCritical, []string{})
+	if err != nil {
+		t.Fatalf("Failed to create incident: %v", err)
+	}
+	// The incident is resolved after the monitor listed it, but before the
+	// breach is recorded
+	store.afterList = func() {
+		if err := incidentService.ResolveIncident(ctx, incident.ID, "user-alice", ""); err != nil {
+			t.Errorf("Failed to resolve incident: %v", err)
+		}
+	}
+
+	targets := SLATargets{
+		Ack: map[models.IncidentSeverity]time.Duration{models.SeverityCritical: 15 * time.Minute},
+	}
+	clock := &fakeClock{current: incident.CreatedAt.Add(20 * time.Minute)}
+	monitor := NewSLAMonitor(store, incidentService, targets, NewLogger("error", false))
+	monitor.now = clock.Now
+
+	if breaches, err := monitor.CheckBreaches(ctx); err != nil || breaches != 1 {
+		t.Fatalf("Expected one breach, got %d (err: %v)", breaches, err)
+	}
+
+	current, _ := incidentService.GetIncident(ctx, incident.ID)
+	if current.Status != models.IncidentStatusResolved || current.ResolvedAt == nil {
+		t.Errorf("Expected the resolution to be kept, got status %s", current.Status)
+	}
+	if current.Labels[SLAAckBreachedLabel] == "" {
+		t.Errorf("Expected the incident to be labelled %s, got %v", SLAAckBreachedLabel, current.Labels)
+	}
+}
@@ -0,0 +1,153 @@
+package services
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// TOTP parameters (RFC 6238 defaults, as expected by authenticator apps)
+const (
+	totpDigits = 6
+	totpPeriod = 30 * time.Second
+	// totpSkew is how many periods before or after the current one a code
+	// is still accepted, to absorb clock drift
+	totpSkew = 1
+	// TOTPIssuer names the service in authenticator apps
+	TOTPIssuer = "Incident Management"
+)
+
+var (
+	ErrTwoFactorRequired      = errors.New("two-factor code required")
+	ErrInvalidTwoFactorCode   = errors.New("invalid two-factor code")
+	ErrTwoFactorNotEnrolled   = errors.New("two-factor authentication is not enrolled")
+	ErrTwoFactorAlreadyActive = errors.New("two-factor authentication is already enabled")
+)
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateTOTPSecret returns a random base32-encoded 160-bit secret
+func GenerateTOTPSecret() (string, error) {
+	secret := make([]byte, 20)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("failed to generate secret: %w", err)
+	}
+	return totpEncoding.EncodeToString(secret), nil
+}
+
+// TOTPCode returns the code for a base32 secret at time t
+func TOTPCode(secret string, t time.Time) (string, error) {
+	key, err := totpEncoding.DecodeString(strings.ToUpper(strings.TrimRight(secret, "=")))
+	if err != nil {
+		return "", fmt.Errorf("invalid TOTP secret: %w", err)
+	}
+	return totpCodeAt(key, t.Unix()/int64(totpPeriod/time.Second)), nil
+}
+
+func totpCodeAt(key []byte, counter int64) string {
+	var message [8]byte
+	binary.BigEndian.PutUint64(message[:], uint64(counter))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(message[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", totpDigits, value%1000000)
+}
+
+// ValidateTOTPCode reports whether code is valid for secret at time t,
+// allowing for one period of clock drift either way
+func ValidateTOTPCode(secret, code string, t time.Time) bool {
+	code = strings.TrimSpace(code)
+	if len(code) != totpDigits {
+		return false
+	}
+	key, err := totpEncoding.DecodeString(strings.ToUpper(strings.TrimRight(secret, "=")))
+	if err != nil {
+		return false
+	}
+
+	counter := t.Unix() / int64(totpPeriod/time.Second)
+	for skew := -totpSkew; skew <= totpSkew; skew++ {
+		if subtle.ConstantTimeCompare([]byte(totpCodeAt(key, counter+int64(skew))), []byte(code)) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// TOTPAuthURL returns the otpauth:// URL authenticator apps enroll from
+func TOTPAuthURL(account, secret string) string {
+	params := url.Values{}
+	params.Set("secret", secret)
+	params.Set("issuer", TOTPIssuer)
+	params.Set("digits", fmt.Sprint(totpDigits))
+	params.Set("period", fmt.Sprint(int(totpPeriod/time.Second)))
+	label := url.PathEscape(TOTPIssuer + ":" + account)
+	return "otpauth://totp/" + label + "?" + params.Encode()
+}
+
+// SetTOTPEncryptionKey sets the key TOTP secrets are encrypted with. An empty
+// key derives one from the JWT secret.
+func (s *AuthService) SetTOTPEncryptionKey(key string) {
+	s.totpKey = key
+}
+
+// totpCipher returns the AES-256-GCM cipher for TOTP secrets
+func (s *AuthService) totpCipher() (cipher.AEAD, error) {
+	material := []byte(s.totpKey)
+	if len(material) == 0 {
+		material = append([]byte("totp:"), s.jwtSecret...)
+	}
+	key := sha256.Sum256(material)
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// EncryptTOTPSecret encrypts a TOTP secret for storage
+func (s *AuthService) EncryptTOTPSecret(secret string) (string, error) {
+	gcm, err := s.totpCipher()
+	if err != nil {
+		return "", fmt.Errorf("failed to create cipher: %w", err)
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(secret), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// DecryptTOTPSecret decrypts a secret encrypted by EncryptTOTPSecret
+func (s *AuthService) DecryptTOTPSecret(encrypted string) (string, error) {
+	gcm, err := s.totpCipher()
+	if err != nil {
+		return "", fmt.Errorf("failed to create cipher: %w", err)
+	}
+	sealed, err := base64.StdEncoding.DecodeString(encrypted)
+	if err != nil || len(sealed) < gcm.NonceSize() {
+		return "", errors.New("malformed encrypted TOTP secret")
+	}
+	secret, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt TOTP secret: %w", err)
+	}
+	return string(secret), nil
+}
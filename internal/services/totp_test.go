@@ -0,0 +1,120 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/models"
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/storage"
+)
+
+// rfc6238Secret is the SHA-1 test key from RFC 6238 appendix B
+// ("12345678901234567890"), base32-encoded
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTPCode_KnownVectors(t *testing.T) {
+	// The RFC lists 8-digit codes; 6-digit codes are their last six digits
+	vectors := map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		1234567890: "005924",
+		2000000000: "279037",
+	}
+	for unix, want := range vectors {
+		got, err := TOTPCode(rfc6238Secret, time.Unix(unix, 0))
+		if err != nil {
+			t.Fatalf("TOTPCode(%d): %v", unix, err)
+		}
+		if got != want {
+			t.Errorf("TOTPCode(%d) = %s, want %s", unix, got, want)
+		}
+	}
+}
+
+func TestValidateTOTPCode(t *testing.T) {
+	now := time.Unix(1111111109, 0)
+
+	if !ValidateTOTPCode(rfc6238Secret, "081804", now) {
+		t.Error("expected the current code to be accepted")
+	}
+	if !ValidateTOTPCode(rfc6238Secret, "081804", now.Add(totpPeriod)) {
+		t.Error("expected the previous period's code to be accepted for clock drift")
+	}
+	if ValidateTOTPCode(rfc6238Secret, "081804", now.Add(3*totpPeriod)) {
+		t.Error("expected a stale code to be rejected")
+	}
+	if ValidateTOTPCode(rfc6238Secret, "81804", now) {
+		t.Error("expected a code of the wrong length to be rejected")
+	}
+}
+
+func TestTOTPAuthURL(t *testing.T) {
+	url := TOTPAuthURL("alice", rfc6238Secret)
+	if !strings.HasPrefix(url, "otpauth://totp/") {
+		t.Fatalf("unexpected otpauth URL %q", url)
+	}
+	if !strings.Contains(url, "secret="+rfc6238Secret) {
+		t.Errorf("expected the secret in %q", url)
+	}
+}
+
+func TestUserService_TwoFactorLogin(t *testing.T) {
+	store, err := storage.NewMemoryStore()
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	authService := NewAuthService("test-jwt-secret-32-characters-long!", time.Hour, 24*time.Hour)
+	userService := NewUserService(store, authService, NewLogger("error", false))
+	now := time.Unix(1700000000, 0)
+	userService.now = func() time.Time { return now }
+
+	hashed, err := authService.HashPassword("password123")
+	if err != nil {
+		t.Fatalf("failed to hash password: %v", err)
+	}
+	user := &models.User{Username: "alice", Email: "alice@example.com", Password: hashed, IsActive: true}
+	if err := store.CreateUser(context.Background(), user); err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+
+	ctx := context.Background()
+	enrollment, err := userService.EnrollTwoFactor(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("EnrollTwoFactor: %v", err)
+	}
+	stored, _ := store.GetUser(ctx, user.ID)
+	if stored.TOTPSecret == "" || strings.Contains(stored.TOTPSecret, enrollment.Secret) {
+		t.Error("expected the TOTP secret to be stored encrypted")
+	}
+
+	// Logging in still works with a password alone until enrollment is verified
+	if _, err := userService.Login(ctx, &models.LoginRequest{Username: "alice", Password: "password123"}); err != nil {
+		t.Fatalf("expected login before verification to succeed, got %v", err)
+	}
+
+	code, _ := TOTPCode(enrollment.Secret, now)
+	stale, _ := TOTPCode(enrollment.Secret, now.Add(-5*time.Minute))
+	if err := userService.ConfirmTwoFactor(ctx, user.ID, stale); !errors.Is(err, ErrInvalidTwoFactorCode) {
+		t.Errorf("expected a stale code to be rejected at enrollment, got %v", err)
+	}
+	if err := userService.ConfirmTwoFactor(ctx, user.ID, code); err != nil {
+		t.Fatalf("ConfirmTwoFactor: %v", err)
+	}
+
+	login := func(code string) error {
+		_, err := userService.Login(ctx, &models.LoginRequest{Username: "alice", Password: "password123", TOTPCode: code})
+		return err
+	}
+	if err := login(""); !errors.Is(err, ErrTwoFactorRequired) {
+		t.Errorf("expected ErrTwoFactorRequired without a code, got %v", err)
+	}
+	if err := login(stale); !errors.Is(err, ErrInvalidTwoFactorCode) {
+		t.Errorf("expected a stale code to be rejected, got %v", err)
+	}
+	if err := login(code); err != nil {
+		t.Errorf("expected login with a current code to succeed, got %v", err)
+	}
+}
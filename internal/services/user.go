@@ -2,6 +2,8 @@ package services
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"strings"
@@ -12,9 +14,14 @@ import (
 )
 
 var (
-	ErrUsernameExists    = errors.New("username already exists")
-	ErrEmailExists       = errors.New("email already exists")
-	ErrInvalidUserID     = errors.New("invalid user ID")
+	ErrUsernameExists  = errors.New("username already exists")
+	ErrEmailExists     = errors.New("email already exists")
+	ErrInvalidUserID   = errors.New("invalid user ID")
+	ErrRoleNotFound    = errors.New("role not found")
+	ErrRoleNotAssigned = errors.New("role is not assigned to user")
+	// ErrSelfLockout guards admins against deactivating or deleting the
+	// account they are signed in with
+	ErrSelfLockout = errors.New("cannot deactivate or delete your own account")
 )
 
 // UserService handles user management operations
@@ -22,6 +29,8 @@ type UserService struct {
 	store       storage.Store
 	authService *AuthService
 	logger      *Logger
+	// now is the clock TOTP codes are checked against
+	now func() time.Time
 }
 
 // NewUserService creates a new user service
@@ -30,6 +39,7 @@ func NewUserService(store storage.Store, authService *AuthService, logger *Logge
 		store:       store,
 		authService: authService,
 		logger:      logger,
+		now:         time.Now,
 	}
 }
 
@@ -123,6 +133,16 @@ func (s *UserService) Login(ctx context.Context, req *models.LoginRequest) (*mod
 		return nil, ErrInvalidCredentials
 	}
 
+	// Second factor
+	if user.TwoFactorEnabled {
+		if req.TOTPCode == "" {
+			return nil, ErrTwoFactorRequired
+		}
+		if err := s.checkTOTPCode(user, req.TOTPCode); err != nil {
+			return nil, err
+		}
+	}
+
 	// Update last login
 	if err := s.UpdateLastLogin(ctx, user.ID); err != nil {
 		s.logger.Error("Failed to update last login", map[string]interface{}{
@@ -229,7 +249,7 @@ func (s *UserService) GetUserByEmail(ctx context.Context, email string) (*models
 
 // CreateUser creates a new user in the database
 func (s *UserService) CreateUser(ctx context.Context, user *models.User) error {
-	return s.store.CreateUser(user)
+	return s.store.CreateUser(ctx, user)
 }
 
 // UpdateProfile updates a user's profile information
@@ -304,20 +324,470 @@ func (s *UserService) ChangePassword(ctx context.Context, userID string, req *mo
 	return nil
 }
 
+// EnrollTwoFactor generates a new TOTP secret for the user and stores it
+// encrypted. Two-factor authentication is only enforced at login once the
+// enrollment is confirmed with ConfirmTwoFactor.
+func (s *UserService) EnrollTwoFactor(ctx context.Context, userID string) (*models.TwoFactorEnrollResponse, error) {
+	user, err := s.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TwoFactorEnabled {
+		return nil, ErrTwoFactorAlreadyActive
+	}
+
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+	encrypted, err := s.authService.EncryptTOTPSecret(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	user.TOTPSecret = encrypted
+	user.UpdatedAt = time.Now()
+	if err := s.updateUserInStorage(ctx, user); err != nil {
+		return nil, fmt.Errorf("failed to store TOTP secret: %w", err)
+	}
+
+	s.LogUserActivity(ctx, userID, "enroll_2fa", "user", userID, GetIPAddress(ctx), GetUserAgent(ctx), nil)
+
+	return &models.TwoFactorEnrollResponse{
+		Secret:     secret,
+		OTPAuthURL: TOTPAuthURL(user.Username, secret),
+	}, nil
+}
+
+// ConfirmTwoFactor enables two-factor authentication once the user proves
+// their authenticator produces valid codes for the enrolled secret
+func (s *UserService) ConfirmTwoFactor(ctx context.Context, userID, code string) error {
+	user, err := s.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user.TwoFactorEnabled {
+		return ErrTwoFactorAlreadyActive
+	}
+	if user.TOTPSecret == "" {
+		return ErrTwoFactorNotEnrolled
+	}
+	if err := s.checkTOTPCode(user, code); err != nil {
+		return err
+	}
+
+	user.TwoFactorEnabled = true
+	user.UpdatedAt = time.Now()
+	if err := s.updateUserInStorage(ctx, user); err != nil {
+		return fmt.Errorf("failed to enable two-factor authentication: %w", err)
+	}
+
+	s.LogUserActivity(ctx, userID, "enable_2fa", "user", userID, GetIPAddress(ctx), GetUserAgent(ctx), nil)
+
+	s.logger.Info("Two-factor authentication enabled", map[string]interface{}{
+		"user_id": userID,
+	})
+
+	return nil
+}
+
+// checkTOTPCode validates a code against the user's stored secret
+func (s *UserService) checkTOTPCode(user *models.User, code string) error {
+	secret, err := s.authService.DecryptTOTPSecret(user.TOTPSecret)
+	if err != nil {
+		return fmt.Errorf("failed to load TOTP secret: %w", err)
+	}
+	if !ValidateTOTPCode(secret, code, s.now()) {
+		return ErrInvalidTwoFactorCode
+	}
+	return nil
+}
+
+// ListUsers returns one page of users, newest first, with their roles, and
+// the total number of users
+func (s *UserService) ListUsers(ctx context.Context, page, limit int) ([]*models.User, int, error) {
+	users, err := s.store.ListUsers(ctx)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list users: %w", err)
+	}
+
+	total := len(users)
+	start := (page - 1) * limit
+	if start > total {
+		start = total
+	}
+	end := start + limit
+	if end > total {
+		end = total
+	}
+
+	users = users[start:end]
+	for _, user := range users {
+		if err := s.loadUserRoles(ctx, user); err != nil {
+			return nil, 0, fmt.Errorf("failed to load roles: %w", err)
+		}
+	}
+	return users, total, nil
+}
+
+// UpdateUser applies an administrator's changes to another user's account:
+// activating or deactivating it, and replacing its roles
+func (s *UserService) UpdateUser(ctx context.Context, actorID, userID string, req *models.AdminUpdateUserRequest) (*models.User, error) {
+	user, err := s.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Resolve every role before changing anything
+	var roles []*models.Role
+	if req.Roles != nil {
+		for _, name := range req.Roles {
+			role, err := s.store.GetRoleByName(ctx, name)
+			if err != nil {
+				if errors.Is(err, storage.ErrNotFound) {
+					return nil, fmt.Errorf("%w: %s", ErrRoleNotFound, name)
+				}
+				return nil, fmt.Errorf("failed to load role %s: %w", name, err)
+			}
+			roles = append(roles, role)
+		}
+	}
+
+	if req.IsActive != nil && *req.IsActive != user.IsActive {
+		if !*req.IsActive && actorID == userID {
+			return nil, ErrSelfLockout
+		}
+		user.IsActive = *req.IsActive
+		user.UpdatedAt = time.Now()
+		if err := s.updateUserInStorage(ctx, user); err != nil {
+			return nil, fmt.Errorf("failed to update user: %w", err)
+		}
+	}
+
+	if req.Roles != nil {
+		wanted := make(map[string]bool, len(roles))
+		for _, role := range roles {
+			wanted[role.ID] = true
+		}
+		current := make(map[string]bool, len(user.Roles))
+		for _, role := range user.Roles {
+			current[role.ID] = true
+			if !wanted[role.ID] {
+				if err := s.store.RemoveRoleFromUser(ctx, userID, role.ID); err != nil {
+					return nil, fmt.Errorf("failed to remove role %s: %w", role.Name, err)
+				}
+			}
+		}
+		for _, role := range roles {
+			if !current[role.ID] {
+				if err := s.store.AssignRoleToUser(ctx, userID, role.ID); err != nil {
+					return nil, fmt.Errorf("failed to assign role %s: %w", role.Name, err)
+				}
+			}
+		}
+	}
+
+	s.LogUserActivity(ctx, actorID, "update_user", "user", userID, GetIPAddress(ctx), GetUserAgent(ctx), map[string]interface{}{
+		"is_active": user.IsActive,
+		"roles":     req.Roles,
+	})
+
+	return s.GetUserByID(ctx, userID)
+}
+
+// DeleteUser removes a user account
+func (s *UserService) DeleteUser(ctx context.Context, actorID, userID string) error {
+	if actorID == userID {
+		return ErrSelfLockout
+	}
+	if err := s.store.DeleteUser(ctx, userID); err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return ErrUserNotFound
+		}
+		return fmt.Errorf("failed to delete user: %w", err)
+	}
+
+	s.LogUserActivity(ctx, actorID, "delete_user", "user", userID, GetIPAddress(ctx), GetUserAgent(ctx), nil)
+
+	s.logger.Info("User deleted", map[string]interface{}{
+		"actor_id": actorID,
+		"user_id":  userID,
+	})
+
+	return nil
+}
+
+// AddUserRole grants a role to a user and returns the user's roles.
+// Granting a role the user already holds changes nothing.
+func (s *UserService) AddUserRole(ctx context.Context, actorID, userID, roleID string) ([]*models.Role, error) {
+	if _, err := s.GetUserByID(ctx, userID); err != nil {
+		return nil, err
+	}
+	role, err := s.store.GetRole(ctx, roleID)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, ErrRoleNotFound
+		}
+		return nil, fmt.Errorf("failed to load role: %w", err)
+	}
+
+	if err := s.store.AssignRoleToUser(ctx, userID, role.ID); err != nil {
+		return nil, fmt.Errorf("failed to assign role: %w", err)
+	}
+
+	s.LogUserActivity(ctx, actorID, "assign_role", "user", userID, GetIPAddress(ctx), GetUserAgent(ctx), map[string]interface{}{
+		"role": role.Name,
+	})
+
+	return s.store.GetUserRoles(ctx, userID)
+}
+
+// RemoveUserRole revokes a role from a user and returns the remaining roles
+func (s *UserService) RemoveUserRole(ctx context.Context, actorID, userID, roleID string) ([]*models.Role, error) {
+	if _, err := s.GetUserByID(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	if err := s.store.RemoveRoleFromUser(ctx, userID, roleID); err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, ErrRoleNotAssigned
+		}
+		return nil, fmt.Errorf("failed to remove role: %w", err)
+	}
+
+	s.LogUserActivity(ctx, actorID, "remove_role", "user", userID, GetIPAddress(ctx), GetUserAgent(ctx), map[string]interface{}{
+		"role_id": roleID,
+	})
+
+	return s.store.GetUserRoles(ctx, userID)
+}
+
+// GetUserActivities returns a user's most recent activity, newest first
+func (s *UserService) GetUserActivities(ctx context.Context, userID string, limit int) ([]*models.UserActivity, error) {
+	if _, err := s.getUserFromStorage(ctx, "id", userID); err != nil {
+		return nil, err
+	}
+	activities, err := s.store.GetUserActivities(ctx, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user activities: %w", err)
+	}
+	return activities, nil
+}
+
+// ListRecentActivities returns the most recent activity across all users
+func (s *UserService) ListRecentActivities(ctx context.Context, limit int) ([]*models.UserActivity, error) {
+	activities, err := s.store.ListRecentActivities(ctx, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list recent activities: %w", err)
+	}
+	return activities, nil
+}
+
 // AssignRole assigns a role to a user
 func (s *UserService) AssignRole(ctx context.Context, userID, roleName string) error {
 	// Get role by name
-	role, err := s.store.GetRoleByName(roleName)
+	role, err := s.store.GetRoleByName(ctx, roleName)
 	if err != nil {
 		return fmt.Errorf("role not found: %w", err)
 	}
 
-	return s.store.AssignRoleToUser(userID, role.ID)
+	return s.store.AssignRoleToUser(ctx, userID, role.ID)
+}
+
+// ImportUsers creates a batch of users with generated temporary passwords.
+// Every row is validated before anything is written, and the users are
+// written in one transaction, so the import is all-or-nothing. Stores that
+// cannot roll back keep the users written before a failure; those are
+// deleted again, and any that cannot be are listed in RollbackErrors.
+func (s *UserService) ImportUsers(ctx context.Context, actorID string, entries []models.UserImportEntry) (*models.BulkUserImportResponse, error) {
+	response := &models.BulkUserImportResponse{
+		Results: make([]models.UserImportResult, len(entries)),
+	}
+
+	seenUsernames := make(map[string]int)
+	seenEmails := make(map[string]int)
+	roleIDs := make([][]string, len(entries))
+
+	for i, entry := range entries {
+		username := strings.ToLower(strings.TrimSpace(entry.Username))
+		email := strings.ToLower(strings.TrimSpace(entry.Email))
+		result := &response.Results[i]
+		result.Row = i + 1
+		result.Username = username
+		result.Email = email
+
+		if err := s.validateImportEntry(ctx, username, email, seenUsernames, seenEmails); err != nil {
+			result.Error = err.Error()
+			continue
+		}
+		seenUsernames[username] = result.Row
+		seenEmails[email] = result.Row
+
+		roleNames := entry.Roles
+		if len(roleNames) == 0 {
+			roleNames = []string{"viewer"}
+		}
+		for _, roleName := range roleNames {
+			role, err := s.store.GetRoleByName(ctx, roleName)
+			if err != nil {
+				result.Error = fmt.Sprintf("role %q not found", roleName)
+				break
+			}
+			roleIDs[i] = append(roleIDs[i], role.ID)
+		}
+	}
+
+	for _, result := range response.Results {
+		if result.Error != "" {
+			response.FailedCount++
+		}
+	}
+	if response.FailedCount > 0 {
+		response.RolledBack = true
+		for i := range response.Results {
+			if response.Results[i].Error == "" {
+				response.Results[i].Error = "not imported: batch rolled back"
+			}
+		}
+		return response, nil
+	}
+
+	created := make([]string, 0, len(entries))
+	err := s.store.WithTx(ctx, func(tx storage.Store) error {
+		txService := *s
+		txService.store = tx
+		for i, entry := range entries {
+			result := &response.Results[i]
+
+			if err := txService.createImportedUser(ctx, result, entry.FullName, roleIDs[i]); err != nil {
+				result.Error = err.Error()
+				if result.UserID != "" {
+					created = append(created, result.UserID)
+				}
+				return err
+			}
+			created = append(created, result.UserID)
+		}
+		return nil
+	})
+	if err != nil {
+		s.rollbackImport(ctx, created, response)
+		return response, nil
+	}
+
+	response.CreatedCount = len(created)
+	s.LogUserActivity(ctx, actorID, "import_users", "user", "", GetIPAddress(ctx), GetUserAgent(ctx), map[string]interface{}{
+		"count": response.CreatedCount,
+	})
+
+	s.logger.Info("Users imported", map[string]interface{}{
+		"actor_id": actorID,
+		"count":    response.CreatedCount,
+	})
+
+	return response, nil
+}
+
+// validateImportEntry checks a single import row for required fields and uniqueness
+func (s *UserService) validateImportEntry(ctx context.Context, username, email string, seenUsernames, seenEmails map[string]int) error {
+	if len(username) < 3 || len(username) > 50 {
+		return errors.New("username must be between 3 and 50 characters")
+	}
+	if !strings.Contains(email, "@") {
+		return errors.New("a valid email is required")
+	}
+	if row, ok := seenUsernames[username]; ok {
+		return fmt.Errorf("duplicate username in batch (row %d)", row)
+	}
+	if row, ok := seenEmails[email]; ok {
+		return fmt.Errorf("duplicate email in batch (row %d)", row)
+	}
+
+	if _, err := s.GetUserByUsername(ctx, username); err == nil {
+		return ErrUsernameExists
+	} else if !errors.Is(err, ErrUserNotFound) {
+		return fmt.Errorf("failed to check username: %w", err)
+	}
+	if _, err := s.GetUserByEmail(ctx, email); err == nil {
+		return ErrEmailExists
+	} else if !errors.Is(err, ErrUserNotFound) {
+		return fmt.Errorf("failed to check email: %w", err)
+	}
+
+	return nil
+}
+
+// createImportedUser creates one validated import row and assigns its roles
+func (s *UserService) createImportedUser(ctx context.Context, result *models.UserImportResult, fullName string, roleIDs []string) error {
+	password, err := generateTemporaryPassword()
+	if err != nil {
+		return err
+	}
+	hashedPassword, err := s.authService.HashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	user := &models.User{
+		Username:  result.Username,
+		Email:     result.Email,
+		FullName:  strings.TrimSpace(fullName),
+		Password:  hashedPassword,
+		IsActive:  true,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := s.store.CreateUser(ctx, user); err != nil {
+		return fmt.Errorf("failed to create user: %w", err)
+	}
+	result.UserID = user.ID
+
+	for _, roleID := range roleIDs {
+		if err := s.store.AssignRoleToUser(ctx, user.ID, roleID); err != nil {
+			return fmt.Errorf("failed to assign role: %w", err)
+		}
+	}
+
+	result.TemporaryPassword = password
+	result.Success = true
+	return nil
+}
+
+// rollbackImport marks a failed import as rolled back and removes the users
+// it created that the store's transaction did not discard
+func (s *UserService) rollbackImport(ctx context.Context, userIDs []string, response *models.BulkUserImportResponse) {
+	for _, userID := range userIDs {
+		err := s.store.DeleteUser(ctx, userID)
+		if err == nil || errors.Is(err, storage.ErrNotFound) {
+			continue
+		}
+		s.logger.Error("Failed to roll back imported user", map[string]interface{}{
+			"user_id": userID,
+			"error":   err.Error(),
+		})
+		response.RollbackErrors = append(response.RollbackErrors, fmt.Sprintf("user %s was created but could not be removed: %v", userID, err))
+	}
+
+	response.RolledBack = true
+	response.CreatedCount = 0
+	response.FailedCount = 0
+	for i := range response.Results {
+		result := &response.Results[i]
+		result.UserID = ""
+		result.TemporaryPassword = ""
+		result.Success = false
+		if result.Error == "" {
+			result.Error = "not imported: batch rolled back"
+		} else {
+			response.FailedCount++
+		}
+	}
 }
 
 // UpdateLastLogin updates the user's last login timestamp
 func (s *UserService) UpdateLastLogin(ctx context.Context, userID string) error {
-	return s.store.UpdateLastLogin(userID, time.Now())
+	return s.store.UpdateLastLogin(ctx, userID, time.Now())
 }
 
 // LogUserActivity logs user activity for audit trails
@@ -333,7 +803,9 @@ func (s *UserService) LogUserActivity(ctx context.Context, userID, action, resou
 		CreatedAt:  time.Now(),
 	}
 
-	// Log activity asynchronously to avoid blocking operations
+	// Log activity asynchronously to avoid blocking operations. The write
+	// outlives the request, so it must not be cancelled along with it.
+	ctx = context.WithoutCancel(ctx)
 	go func() {
 		if err := s.logActivityToStorage(ctx, activity); err != nil {
 			s.logger.Error("Failed to log user activity", map[string]interface{}{
@@ -353,11 +825,11 @@ func (s *UserService) getUserFromStorage(ctx context.Context, field, value strin
 	
 	switch field {
 	case "id":
-		user, err = s.store.GetUser(value)
+		user, err = s.store.GetUser(ctx, value)
 	case "username":
-		user, err = s.store.GetUserByUsername(value)
+		user, err = s.store.GetUserByUsername(ctx, value)
 	case "email":
-		user, err = s.store.GetUserByEmail(value)
+		user, err = s.store.GetUserByEmail(ctx, value)
 	default:
 		return nil, ErrUserNotFound
 	}
@@ -374,7 +846,7 @@ func (s *UserService) getUserFromStorage(ctx context.Context, field, value strin
 }
 
 func (s *UserService) loadUserRoles(ctx context.Context, user *models.User) error {
-	roles, err := s.store.GetUserRoles(user.ID)
+	roles, err := s.store.GetUserRoles(ctx, user.ID)
 	if err != nil {
 		return err
 	}
@@ -383,11 +855,11 @@ func (s *UserService) loadUserRoles(ctx context.Context, user *models.User) erro
 }
 
 func (s *UserService) updateUserInStorage(ctx context.Context, user *models.User) error {
-	return s.store.UpdateUser(user)
+	return s.store.UpdateUser(ctx, user)
 }
 
 func (s *UserService) logActivityToStorage(ctx context.Context, activity *models.UserActivity) error {
-	return s.store.LogUserActivity(activity)
+	return s.store.LogUserActivity(ctx, activity)
 }
 
 // Helper functions for extracting request context
@@ -404,4 +876,13 @@ func GetUserAgent(ctx context.Context) string {
 		return ua
 	}
 	return "unknown"
-}
\ No newline at end of file
+}
+
+// generateTemporaryPassword generates a random password for newly imported users
+func generateTemporaryPassword() (string, error) {
+	bytes := make([]byte, 12)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", fmt.Errorf("failed to generate temporary password: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(bytes), nil
+}
@@ -0,0 +1,22 @@
+package storage
+
+import (
+	"fmt"
+
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/config"
+)
+
+// NewStore creates the store selected by the configuration's storage backend
+func NewStore(cfg *config.Config) (Store, error) {
+	switch backend := cfg.ResolvedStorageBackend(); backend {
+	case config.StorageBackendMemory:
+		return NewMemoryStore()
+	case config.StorageBackendPostgres:
+		if cfg.DatabaseURL == "" {
+			return nil, fmt.Errorf("storage backend postgres requires DATABASE_URL")
+		}
+		return NewPostgresStore(cfg)
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", backend)
+	}
+}
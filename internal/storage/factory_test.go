@@ -0,0 +1,37 @@
+package storage
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/config"
+)
+
+func TestNewStore_MemoryIgnoresDatabaseURL(t *testing.T) {
+	cfg := &config.Config{
+		StorageBackend: config.StorageBackendMemory,
+		DatabaseURL:    "postgres://nobody@127.0.0.1:1/unreachable?sslmode=disable",
+	}
+
+	store, err := NewStore(cfg)
+	if err != nil {
+		t.Fatalf("Expected the memory store, got error: %v", err)
+	}
+	defer store.Close()
+	if _, ok := store.(*MemoryStore); !ok {
+		t.Errorf("Expected *MemoryStore, got %T", store)
+	}
+}
+
+func TestNewStore_PostgresRequiresDatabaseURL(t *testing.T) {
+	_, err := NewStore(&config.Config{StorageBackend: config.StorageBackendPostgres})
+	if err == nil || !strings.Contains(err.Error(), "DATABASE_URL") {
+		t.Errorf("Expected an error naming DATABASE_URL, got %v", err)
+	}
+}
+
+func TestNewStore_UnknownBackend(t *testing.T) {
+	if _, err := NewStore(&config.Config{StorageBackend: "sqlite"}); err == nil {
+		t.Error("Expected an unknown backend to be rejected")
+	}
+}
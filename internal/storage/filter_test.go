@@ -0,0 +1,242 @@
+package storage
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/models"
+)
+
+func TestListWithFilter_MemoryMatchesPostgres(t *testing.T) {
+	ctx := context.Background()
+	base := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	open, resolved := models.IncidentStatusOpen, models.IncidentStatusResolved
+	high := models.SeverityHigh
+	firing := "firing"
+
+	for storeName, store := range searchStores(t) {
+		assignee := &models.User{ID: uuid.New().String(), Username: "oncall-" + storeName, Email: storeName + "@example.com", IsActive: true}
+		if err := store.CreateUser(ctx, assignee); err != nil {
+			t.Fatalf("%s: failed to create user: %v", storeName, err)
+		}
+
+		// Every incident has its own created_at so the expected order is exact
+		names := make(map[string]string)
+		ids := make(map[string]string)
+		for i, fixture := range []struct {
+			name     string
+			status   models.IncidentStatus
+			severity models.IncidentSeverity
+			assigned bool
+		}{
+			{"api", open, models.SeverityCritical, true},
+			{"db", open, models.SeverityHigh, false},
+			{"cache", resolved, models.SeverityHigh, true},
+			{"disk", open, models.SeverityLow, true},
+			{"dns", resolved, models.SeverityMedium, false},
+		} {
+			incident := &models.Incident{
+				ID:        uuid.New().String(),
+				Title:     fixture.name,
+				Status:    fixture.status,
+				Severity:  fixture.severity,
+				Priority:  models.DefaultPriority(fixture.severity),
+				CreatedAt: base.Add(time.Duration(i) * time.Hour),
+				UpdatedAt: base.Add(time.Duration(10-i) * time.Hour),
+				Labels:    map[string]string{},
+			}
+			if fixture.assigned {
+				incident.AssigneeID = assignee.ID
+			}
+			if err := store.CreateIncident(ctx, incident); err != nil {
+				t.Fatalf("%s: failed to create incident: %v", storeName, err)
+			}
+			names[incident.ID] = fixture.name
+			ids[fixture.name] = incident.ID
+		}
+
+		incidentTests := []struct {
+			name   string
+			filter IncidentFilter
+			want   string
+			total  int
+		}{
+			{"all by created_at", IncidentFilter{}, "dns,disk,cache,db,api", 5},
+			{"open", IncidentFilter{Status: &open}, "disk,db,api", 3},
+			{"high", IncidentFilter{Severity: &high}, "cache,db", 2},
+			{"assigned and open", IncidentFilter{Status: &open, AssigneeID: &assignee.ID}, "disk,api", 2},
+			{"by updated_at", IncidentFilter{OrderBy: "updated_at"}, "api,db,cache,disk,dns", 5},
+			{"by severity", IncidentFilter{OrderBy: "severity", Status: &open}, "disk,db,api", 3},
+			{"unknown order falls back", IncidentFilter{OrderBy: "1; DROP TABLE incidents"}, "dns,disk,cache,db,api", 5},
+			{"first page", IncidentFilter{Limit: 2}, "dns,disk", 5},
+			{"second page", IncidentFilter{Limit: 2, Offset: 2}, "cache,db", 5},
+			{"past the end", IncidentFilter{Limit: 2, Offset: 6}, "", 5},
+			{"offset without limit", IncidentFilter{Offset: 3}, "dns,disk,cache,db,api", 5},
+		}
+		for _, tt := range incidentTests {
+			incidents, err := store.ListIncidentsWithFilter(ctx, tt.filter)
+			if err != nil {
+				t.Fatalf("%s: %s: list failed: %v", storeName, tt.name, err)
+			}
+			var got []string
+			for _, incident := range incidents {
+				got = append(got, names[incident.ID])
+			}
+			if strings.Join(got, ",") != tt.want {
+				t.Errorf("%s: %s = %v, want %s", storeName, tt.name, got, tt.want)
+			}
+			if total, err := store.CountIncidents(ctx, tt.filter); err != nil || total != tt.total {
+				t.Errorf("%s: %s count = %d (%v), want %d", storeName, tt.name, total, err, tt.total)
+			}
+		}
+
+		alertNames := make(map[string]string)
+		for i, fixture := range []struct {
+			name     string
+			status   string
+			incident string
+		}{
+			{"cpu", "firing", "api"},
+			{"mem", "resolved", "api"},
+			{"io", "firing", "disk"},
+			{"net", "firing", ""},
+		} {
+			alert := &models.Alert{
+				ID:          uuid.New().String(),
+				Fingerprint: fixture.name + "-" + storeName,
+				Status:      fixture.status,
+				StartsAt:    base.Add(time.Duration(10-i) * time.Minute),
+				CreatedAt:   base.Add(time.Duration(i) * time.Minute),
+				Labels:      map[string]string{},
+				Annotations: map[string]string{},
+				IncidentID:  ids[fixture.incident],
+			}
+			if err := store.CreateAlert(ctx, alert); err != nil {
+				t.Fatalf("%s: failed to create alert: %v", storeName, err)
+			}
+			alertNames[alert.ID] = fixture.name
+		}
+
+		apiID := ids["api"]
+		fingerprint := "io-" + storeName
+		alertTests := []struct {
+			name   string
+			filter AlertFilter
+			want   string
+			total  int
+		}{
+			{"all by created_at", AlertFilter{}, "net,io,mem,cpu", 4},
+			{"firing", AlertFilter{Status: &firing}, "net,io,cpu", 3},
+			{"incident", AlertFilter{IncidentID: &apiID}, "mem,cpu", 2},
+			{"fingerprint", AlertFilter{Fingerprint: &fingerprint}, "io", 1},
+			{"by starts_at", AlertFilter{OrderBy: "starts_at"}, "cpu,mem,io,net", 4},
+			{"second page", AlertFilter{Limit: 3, Offset: 3}, "cpu", 4},
+		}
+		for _, tt := range alertTests {
+			alerts, err := store.ListAlertsWithFilter(ctx, tt.filter)
+			if err != nil {
+				t.Fatalf("%s: %s: list failed: %v", storeName, tt.name, err)
+			}
+			var got []string
+			for _, alert := range alerts {
+				got = append(got, alertNames[alert.ID])
+			}
+			if strings.Join(got, ",") != tt.want {
+				t.Errorf("%s: alerts %s = %v, want %s", storeName, tt.name, got, tt.want)
+			}
+			if total, err := store.CountAlerts(ctx, tt.filter); err != nil || total != tt.total {
+				t.Errorf("%s: alerts %s count = %d (%v), want %d", storeName, tt.name, total, err, tt.total)
+			}
+		}
+	}
+}
+
+func TestListIncidentsCreatedBetween_MemoryMatchesPostgres(t *testing.T) {
+	ctx := context.Background()
+	base := time.Date(2019, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	for storeName, store := range searchStores(t) {
+		names := make(map[string]string)
+		for _, fixture := range []struct {
+			name    string
+			created time.Time
+		}{
+			{"before", base.Add(-time.Second)},
+			{"at-start", base},
+			{"inside", base.Add(12 * time.Hour)},
+			{"at-end", base.Add(24 * time.Hour)},
+		} {
+			incident := &models.Incident{
+				ID:        uuid.New().String(),
+				Title:     fixture.name,
+				Status:    models.IncidentStatusOpen,
+				Severity:  models.SeverityLow,
+				Priority:  models.DefaultPriority(models.SeverityLow),
+				CreatedAt: fixture.created,
+				UpdatedAt: fixture.created,
+				Labels:    map[string]string{},
+			}
+			if err := store.CreateIncident(ctx, incident); err != nil {
+				t.Fatalf("%s: failed to create incident: %v", storeName, err)
+			}
+			names[incident.ID] = fixture.name
+		}
+
+		incidents, err := store.ListIncidentsCreatedBetween(ctx, base, base.Add(24*time.Hour))
+		if err != nil {
+			t.Fatalf("%s: failed to list incidents: %v", storeName, err)
+		}
+		var got []string
+		for _, incident := range incidents {
+			got = append(got, names[incident.ID])
+		}
+		if strings.Join(got, ",") != "at-start,inside" {
+			t.Errorf("%s: expected at-start,inside oldest first, got %v", storeName, got)
+		}
+	}
+}
+
+func TestOverflowAlerts_MemoryMatchesPostgres(t *testing.T) {
+	ctx := context.Background()
+
+	for storeName, store := range searchStores(t) {
+		incident := &models.Incident{
+			ID:        uuid.New().String(),
+			Title:     "Runaway alerts",
+			Status:    models.IncidentStatusOpen,
+			Severity:  models.SeverityLow,
+			Priority:  models.DefaultPriority(models.SeverityLow),
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+			Labels:    map[string]string{},
+		}
+		if err := store.CreateIncident(ctx, incident); err != nil {
+			t.Fatalf("%s: failed to create incident: %v", storeName, err)
+		}
+		fingerprint := "fp-" + incident.ID
+
+		for i, want := range []bool{true, false} {
+			added, err := store.AddOverflowAlert(ctx, incident.ID, fingerprint)
+			if err != nil {
+				t.Fatalf("%s: failed to add overflow alert: %v", storeName, err)
+			}
+			if added != want {
+				t.Errorf("%s: expected add %d to report %v, got %v", storeName, i+1, want, added)
+			}
+		}
+
+		incidentIDs, err := store.RemoveOverflowAlert(ctx, fingerprint)
+		if err != nil {
+			t.Fatalf("%s: failed to remove overflow alert: %v", storeName, err)
+		}
+		if len(incidentIDs) != 1 || incidentIDs[0] != incident.ID {
+			t.Errorf("%s: expected the removal to report %s, got %v", storeName, incident.ID, incidentIDs)
+		}
+		if incidentIDs, _ := store.RemoveOverflowAlert(ctx, fingerprint); len(incidentIDs) != 0 {
+			t.Errorf("%s: expected nothing left to remove, got %v", storeName, incidentIDs)
+		}
+	}
+}
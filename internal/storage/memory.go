@@ -1,7 +1,9 @@
 package storage
 
 import (
+	"context"
 	"errors"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -17,96 +19,157 @@ var (
 // Store defines the storage interface
 type Store interface {
 	// Incidents
-	GetIncident(id string) (*models.Incident, error)
-	ListIncidents() ([]*models.Incident, error)
-	CreateIncident(incident *models.Incident) error
-	UpdateIncident(incident *models.Incident) error
-	DeleteIncident(id string) error
+	GetIncident(ctx context.Context, id string) (*models.Incident, error)
+	ListIncidents(ctx context.Context) ([]*models.Incident, error)
+	CreateIncident(ctx context.Context, incident *models.Incident) error
+	UpdateIncident(ctx context.Context, incident *models.Incident) error
+	DeleteIncident(ctx context.Context, id string) error
+	CountIncidentsByStatusSeverity(ctx context.Context) (map[models.IncidentStatus]map[models.IncidentSeverity]int, error)
+	// ListIncidentsWithFilter returns the incidents matching the filter,
+	// ordered by filter.OrderBy descending and paginated by Limit and Offset
+	ListIncidentsWithFilter(ctx context.Context, filter IncidentFilter) ([]*models.Incident, error)
+	// CountIncidents counts the incidents matching the filter, ignoring pagination
+	CountIncidents(ctx context.Context, filter IncidentFilter) (int, error)
+	// ListIncidentsCreatedBetween returns the incidents created at or after
+	// from and before to, oldest first
+	ListIncidentsCreatedBetween(ctx context.Context, from, to time.Time) ([]*models.Incident, error)
 
 	// Alerts
-	GetAlert(id string) (*models.Alert, error)
-	ListAlerts() ([]*models.Alert, error)
-	CreateAlert(alert *models.Alert) error
-	UpdateAlert(alert *models.Alert) error
-	DeleteAlert(id string) error
+	GetAlert(ctx context.Context, id string) (*models.Alert, error)
+	// GetAlertByFingerprint returns the most recently created alert with the
+	// fingerprint, or ErrNotFound
+	GetAlertByFingerprint(ctx context.Context, fingerprint string) (*models.Alert, error)
+	ListAlerts(ctx context.Context) ([]*models.Alert, error)
+	ListAlertsWithFilter(ctx context.Context, filter AlertFilter) ([]*models.Alert, error)
+	CountAlerts(ctx context.Context, filter AlertFilter) (int, error)
+	CreateAlert(ctx context.Context, alert *models.Alert) error
+	UpdateAlert(ctx context.Context, alert *models.Alert) error
+	DeleteAlert(ctx context.Context, id string) error
+	// AddOverflowAlert records that an incident absorbed a firing alert past
+	// its alert cap. It reports false if the fingerprint was already recorded
+	// for the incident.
+	AddOverflowAlert(ctx context.Context, incidentID, fingerprint string) (bool, error)
+	// RemoveOverflowAlert forgets a resolved overflow alert and returns the
+	// incidents that had absorbed it
+	RemoveOverflowAlert(ctx context.Context, fingerprint string) ([]string, error)
 
 	// Notification Channels
-	GetNotificationChannel(id string) (*models.NotificationChannel, error)
-	ListNotificationChannels() ([]*models.NotificationChannel, error)
-	CreateNotificationChannel(channel *models.NotificationChannel) error
-	UpdateNotificationChannel(channel *models.NotificationChannel) error
-	DeleteNotificationChannel(id string) error
+	GetNotificationChannel(ctx context.Context, id string) (*models.NotificationChannel, error)
+	ListNotificationChannels(ctx context.Context) ([]*models.NotificationChannel, error)
+	CreateNotificationChannel(ctx context.Context, channel *models.NotificationChannel) error
+	UpdateNotificationChannel(ctx context.Context, channel *models.NotificationChannel) error
+	DeleteNotificationChannel(ctx context.Context, id string) error
+
+	// Notification History
+	CreateNotificationHistory(ctx context.Context, history *models.NotificationHistory) error
+	UpdateNotificationHistory(ctx context.Context, history *models.NotificationHistory) error
+	ListNotificationHistoryByChannel(ctx context.Context, channelID string, filter *models.NotificationHistoryFilter) ([]*models.NotificationHistory, int, error)
+	ListNotificationHistory(ctx context.Context, incidentID string) ([]*models.NotificationHistory, error)
+	GetNotificationHistory(ctx context.Context, id string) (*models.NotificationHistory, error)
+	ListNotificationHistoryByStatus(ctx context.Context, status models.NotificationDeliveryStatus) ([]*models.NotificationHistory, error)
+	// CountNotificationHistory counts the entries created in [from, to) by
+	// channel type, notification type and status
+	CountNotificationHistory(ctx context.Context, from, to time.Time) ([]*models.NotificationDeliveryCount, error)
 
 	// Escalation Policies
-	GetEscalationPolicy(id string) (*models.EscalationPolicy, error)
-	ListEscalationPolicies() ([]*models.EscalationPolicy, error)
-	CreateEscalationPolicy(policy *models.EscalationPolicy) error
-	UpdateEscalationPolicy(policy *models.EscalationPolicy) error
-	DeleteEscalationPolicy(id string) error
+	GetEscalationPolicy(ctx context.Context, id string) (*models.EscalationPolicy, error)
+	ListEscalationPolicies(ctx context.Context) ([]*models.EscalationPolicy, error)
+	CreateEscalationPolicy(ctx context.Context, policy *models.EscalationPolicy) error
+	UpdateEscalationPolicy(ctx context.Context, policy *models.EscalationPolicy) error
+	DeleteEscalationPolicy(ctx context.Context, id string) error
 
 	// On-Call Schedules
-	GetOnCallSchedule(id string) (*models.OnCallSchedule, error)
-	ListOnCallSchedules() ([]*models.OnCallSchedule, error)
-	CreateOnCallSchedule(schedule *models.OnCallSchedule) error
-	UpdateOnCallSchedule(schedule *models.OnCallSchedule) error
-	DeleteOnCallSchedule(id string) error
+	GetOnCallSchedule(ctx context.Context, id string) (*models.OnCallSchedule, error)
+	ListOnCallSchedules(ctx context.Context) ([]*models.OnCallSchedule, error)
+	CreateOnCallSchedule(ctx context.Context, schedule *models.OnCallSchedule) error
+	UpdateOnCallSchedule(ctx context.Context, schedule *models.OnCallSchedule) error
+	DeleteOnCallSchedule(ctx context.Context, id string) error
 
 	// User Management
-	GetUser(id string) (*models.User, error)
-	GetUserByUsername(username string) (*models.User, error)
-	GetUserByEmail(email string) (*models.User, error)
-	ListUsers() ([]*models.User, error)
-	CreateUser(user *models.User) error
-	UpdateUser(user *models.User) error
-	DeleteUser(id string) error
-	UpdateLastLogin(userID string, timestamp time.Time) error
+	GetUser(ctx context.Context, id string) (*models.User, error)
+	GetUserByUsername(ctx context.Context, username string) (*models.User, error)
+	GetUserByEmail(ctx context.Context, email string) (*models.User, error)
+	ListUsers(ctx context.Context) ([]*models.User, error)
+	CreateUser(ctx context.Context, user *models.User) error
+	UpdateUser(ctx context.Context, user *models.User) error
+	DeleteUser(ctx context.Context, id string) error
+	UpdateLastLogin(ctx context.Context, userID string, timestamp time.Time) error
 
 	// Role Management
-	GetRole(id string) (*models.Role, error)
-	GetRoleByName(name string) (*models.Role, error)
-	ListRoles() ([]*models.Role, error)
-	CreateRole(role *models.Role) error
-	UpdateRole(role *models.Role) error
-	DeleteRole(id string) error
+	GetRole(ctx context.Context, id string) (*models.Role, error)
+	GetRoleByName(ctx context.Context, name string) (*models.Role, error)
+	ListRoles(ctx context.Context) ([]*models.Role, error)
+	CreateRole(ctx context.Context, role *models.Role) error
+	UpdateRole(ctx context.Context, role *models.Role) error
+	DeleteRole(ctx context.Context, id string) error
 
 	// User-Role Associations
-	AssignRoleToUser(userID, roleID string) error
-	RemoveRoleFromUser(userID, roleID string) error
-	GetUserRoles(userID string) ([]*models.Role, error)
-	
+	AssignRoleToUser(ctx context.Context, userID, roleID string) error
+	RemoveRoleFromUser(ctx context.Context, userID, roleID string) error
+	GetUserRoles(ctx context.Context, userID string) ([]*models.Role, error)
+
 	// Permissions
-	GetPermission(id string) (*models.Permission, error)
-	ListPermissions() ([]*models.Permission, error)
-	GetRolePermissions(roleID string) ([]*models.Permission, error)
+	GetPermission(ctx context.Context, id string) (*models.Permission, error)
+	ListPermissions(ctx context.Context) ([]*models.Permission, error)
+	GetRolePermissions(ctx context.Context, roleID string) ([]*models.Permission, error)
+	CreatePermission(ctx context.Context, permission *models.Permission) error
+	AssignPermissionToRole(ctx context.Context, roleID, permissionID string) error
 
 	// User Activity Logging
-	LogUserActivity(activity *models.UserActivity) error
-	GetUserActivities(userID string, limit int) ([]*models.UserActivity, error)
+	LogUserActivity(ctx context.Context, activity *models.UserActivity) error
+	GetUserActivities(ctx context.Context, userID string, limit int) ([]*models.UserActivity, error)
+	// ListRecentActivities returns the most recent activity across all users
+	ListRecentActivities(ctx context.Context, limit int) ([]*models.UserActivity, error)
 
 	// Enhanced Incident Features - Comments
-	CreateIncidentComment(comment *models.IncidentComment) error
-	GetIncidentComments(incidentID string) ([]*models.IncidentComment, error)
-	GetIncidentTimeline(incidentID string) ([]*models.IncidentComment, error)
-
-	// Enhanced Incident Features - Tags  
-	CreateIncidentTag(tag *models.IncidentTag) error
-	GetIncidentTags(incidentID string) ([]*models.IncidentTag, error)
-	DeleteIncidentTag(incidentID, tagName string) error
+	CreateIncidentComment(ctx context.Context, comment *models.IncidentComment) error
+	GetIncidentComments(ctx context.Context, incidentID string) ([]*models.IncidentComment, error)
+	GetIncidentTimeline(ctx context.Context, incidentID string) ([]*models.IncidentComment, error)
+	// UpdateIncidentComment saves a comment's content, metadata and edit time
+	UpdateIncidentComment(ctx context.Context, comment *models.IncidentComment) error
+	DeleteIncidentComment(ctx context.Context, id string) error
+
+	// Enhanced Incident Features - Comment Drafts
+	SaveCommentDraft(ctx context.Context, draft *models.CommentDraft) error
+	GetCommentDraft(ctx context.Context, incidentID, userID string) (*models.CommentDraft, error)
+	DeleteCommentDraft(ctx context.Context, incidentID, userID string) error
+
+	// Enhanced Incident Features - Tags
+	CreateIncidentTag(ctx context.Context, tag *models.IncidentTag) error
+	GetIncidentTags(ctx context.Context, incidentID string) ([]*models.IncidentTag, error)
+	DeleteIncidentTag(ctx context.Context, incidentID, tagName string) error
 
 	// Enhanced Incident Features - Templates
-	CreateIncidentTemplate(template *models.IncidentTemplate) error
-	GetIncidentTemplate(id string) (*models.IncidentTemplate, error)
-	ListIncidentTemplates(activeOnly bool) ([]*models.IncidentTemplate, error)
-	UpdateIncidentTemplate(template *models.IncidentTemplate) error
-	DeleteIncidentTemplate(id string) error
+	CreateIncidentTemplate(ctx context.Context, template *models.IncidentTemplate) error
+	GetIncidentTemplate(ctx context.Context, id string) (*models.IncidentTemplate, error)
+	ListIncidentTemplates(ctx context.Context, activeOnly bool) ([]*models.IncidentTemplate, error)
+	UpdateIncidentTemplate(ctx context.Context, template *models.IncidentTemplate) error
+	DeleteIncidentTemplate(ctx context.Context, id string) error
 
 	// Enhanced Incident Features - Attachments
-	CreateIncidentAttachment(attachment *models.IncidentAttachment) error
-	GetIncidentAttachments(incidentID string) ([]*models.IncidentAttachment, error)
-	DeleteIncidentAttachment(id string) error
+	CreateIncidentAttachment(ctx context.Context, attachment *models.IncidentAttachment) error
+	GetIncidentAttachments(ctx context.Context, incidentID string) ([]*models.IncidentAttachment, error)
+	GetIncidentAttachment(ctx context.Context, id string) (*models.IncidentAttachment, error)
+	DeleteIncidentAttachment(ctx context.Context, id string) error
 
 	// Enhanced Incident Features - Search
-	SearchIncidents(req *models.IncidentSearchRequest) ([]*models.Incident, int, error)
+	SearchIncidents(ctx context.Context, req *models.IncidentSearchRequest) ([]*models.Incident, int, error)
+
+	// Webhook Payloads
+	CreateWebhookPayload(ctx context.Context, payload *models.WebhookPayload) error
+	GetWebhookPayload(ctx context.Context, id string) (*models.WebhookPayload, error)
+	DeleteWebhookPayloadsBefore(ctx context.Context, cutoff time.Time) (int, error)
+
+	// Lifecycle Webhooks
+	GetLifecycleWebhook(ctx context.Context, id string) (*models.LifecycleWebhook, error)
+	ListLifecycleWebhooks(ctx context.Context) ([]*models.LifecycleWebhook, error)
+	CreateLifecycleWebhook(ctx context.Context, webhook *models.LifecycleWebhook) error
+	UpdateLifecycleWebhook(ctx context.Context, webhook *models.LifecycleWebhook) error
+	DeleteLifecycleWebhook(ctx context.Context, id string) error
+
+	// WithTx runs fn with a store whose writes are committed together if
+	// fn returns nil and rolled back otherwise
+	WithTx(ctx context.Context, fn func(tx Store) error) error
 
 	// Close closes the store connection
 	Close() error
@@ -117,6 +180,7 @@ type MemoryStore struct {
 	incidents            map[string]*models.Incident
 	alerts               map[string]*models.Alert
 	notificationChannels map[string]*models.NotificationChannel
+	notificationHistory  map[string]*models.NotificationHistory
 	escalationPolicies   map[string]*models.EscalationPolicy
 	onCallSchedules      map[string]*models.OnCallSchedule
 	users                map[string]*models.User
@@ -129,11 +193,16 @@ type MemoryStore struct {
 	rolePermissions      map[string][]string // roleID -> permissionIDs
 	userActivities       map[string][]*models.UserActivity // userID -> activities
 	// Enhanced incident features
-	incidentComments     map[string][]*models.IncidentComment // incidentID -> comments
-	incidentTags         map[string][]*models.IncidentTag     // incidentID -> tags
-	incidentTemplates    map[string]*models.IncidentTemplate  // templateID -> template
-	incidentAttachments  map[string][]*models.IncidentAttachment // incidentID -> attachments
-	mu                   sync.RWMutex
+	incidentComments    map[string][]*models.IncidentComment    // incidentID -> comments
+	incidentTags        map[string][]*models.IncidentTag        // incidentID -> tags
+	incidentTemplates   map[string]*models.IncidentTemplate     // templateID -> template
+	incidentAttachments map[string][]*models.IncidentAttachment // incidentID -> attachments
+	webhookPayloads     map[string]*models.WebhookPayload
+	commentDrafts       map[string]*models.CommentDraft // incidentID/userID -> draft
+	lifecycleWebhooks   map[string]*models.LifecycleWebhook
+	overflowAlerts      map[string]map[string]bool // fingerprint -> incident IDs
+	mu                  sync.RWMutex
+	txMu                sync.Mutex // serializes WithTx callbacks
 }
 
 // NewMemoryStore creates a new in-memory store
@@ -142,6 +211,7 @@ func NewMemoryStore() (*MemoryStore, error) {
 		incidents:            make(map[string]*models.Incident),
 		alerts:               make(map[string]*models.Alert),
 		notificationChannels: make(map[string]*models.NotificationChannel),
+		notificationHistory:  make(map[string]*models.NotificationHistory),
 		escalationPolicies:   make(map[string]*models.EscalationPolicy),
 		onCallSchedules:      make(map[string]*models.OnCallSchedule),
 		users:                make(map[string]*models.User),
@@ -154,15 +224,19 @@ func NewMemoryStore() (*MemoryStore, error) {
 		rolePermissions:      make(map[string][]string),
 		userActivities:       make(map[string][]*models.UserActivity),
 		// Enhanced incident features
-		incidentComments:     make(map[string][]*models.IncidentComment),
-		incidentTags:         make(map[string][]*models.IncidentTag),
-		incidentTemplates:    make(map[string]*models.IncidentTemplate),
-		incidentAttachments:  make(map[string][]*models.IncidentAttachment),
+		incidentComments:    make(map[string][]*models.IncidentComment),
+		incidentTags:        make(map[string][]*models.IncidentTag),
+		incidentTemplates:   make(map[string]*models.IncidentTemplate),
+		incidentAttachments: make(map[string][]*models.IncidentAttachment),
+		webhookPayloads:     make(map[string]*models.WebhookPayload),
+		lifecycleWebhooks:   make(map[string]*models.LifecycleWebhook),
+		overflowAlerts:      make(map[string]map[string]bool),
+		commentDrafts:       make(map[string]*models.CommentDraft),
 	}, nil
 }
 
 // Incident methods
-func (s *MemoryStore) GetIncident(id string) (*models.Incident, error) {
+func (s *MemoryStore) GetIncident(ctx context.Context, id string) (*models.Incident, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
@@ -173,7 +247,7 @@ func (s *MemoryStore) GetIncident(id string) (*models.Incident, error) {
 	return incident, nil
 }
 
-func (s *MemoryStore) ListIncidents() ([]*models.Incident, error) {
+func (s *MemoryStore) ListIncidents(ctx context.Context) ([]*models.Incident, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
@@ -184,7 +258,130 @@ func (s *MemoryStore) ListIncidents() ([]*models.Incident, error) {
 	return incidents, nil
 }
 
-func (s *MemoryStore) CreateIncident(incident *models.Incident) error {
+// Postgres orders its incident_status and incident_severity enums by
+// declaration order, so sorting by them is not alphabetical
+var (
+	incidentStatusEnumOrder   = map[models.IncidentStatus]int{models.IncidentStatusOpen: 0, models.IncidentStatusAcknowledged: 1, models.IncidentStatusResolved: 2}
+	incidentSeverityEnumOrder = map[models.IncidentSeverity]int{models.SeverityCritical: 0, models.SeverityHigh: 1, models.SeverityMedium: 2, models.SeverityLow: 3}
+)
+
+// ListIncidentsWithFilter returns the incidents matching the filter with the
+// same ordering and pagination as the Postgres store. Incidents that tie on
+// the sort field are ordered by ID so pages are stable.
+func (s *MemoryStore) ListIncidentsWithFilter(ctx context.Context, filter IncidentFilter) ([]*models.Incident, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var incidents []*models.Incident
+	for _, incident := range s.incidents {
+		if incidentMatchesFilter(incident, filter) {
+			incidents = append(incidents, incident)
+		}
+	}
+
+	less := func(a, b *models.Incident) bool { return a.CreatedAt.Before(b.CreatedAt) }
+	switch filter.OrderBy {
+	case "updated_at":
+		less = func(a, b *models.Incident) bool { return a.UpdatedAt.Before(b.UpdatedAt) }
+	case "title":
+		less = func(a, b *models.Incident) bool { return a.Title < b.Title }
+	case "status":
+		less = func(a, b *models.Incident) bool {
+			return incidentStatusEnumOrder[a.Status] < incidentStatusEnumOrder[b.Status]
+		}
+	case "severity":
+		less = func(a, b *models.Incident) bool {
+			return incidentSeverityEnumOrder[a.Severity] < incidentSeverityEnumOrder[b.Severity]
+		}
+	}
+	sort.Slice(incidents, func(i, j int) bool {
+		switch {
+		case less(incidents[j], incidents[i]):
+			return true
+		case less(incidents[i], incidents[j]):
+			return false
+		}
+		return incidents[i].ID > incidents[j].ID
+	})
+
+	return paginate(incidents, filter.Limit, filter.Offset), nil
+}
+
+// ListIncidentsCreatedBetween returns the incidents created at or after from
+// and before to, oldest first
+func (s *MemoryStore) ListIncidentsCreatedBetween(ctx context.Context, from, to time.Time) ([]*models.Incident, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var incidents []*models.Incident
+	for _, incident := range s.incidents {
+		if !incident.CreatedAt.Before(from) && incident.CreatedAt.Before(to) {
+			incidents = append(incidents, incident)
+		}
+	}
+	sort.Slice(incidents, func(i, j int) bool {
+		if !incidents[i].CreatedAt.Equal(incidents[j].CreatedAt) {
+			return incidents[i].CreatedAt.Before(incidents[j].CreatedAt)
+		}
+		return incidents[i].ID < incidents[j].ID
+	})
+	return incidents, nil
+}
+
+// CountIncidents counts the incidents matching the filter
+func (s *MemoryStore) CountIncidents(ctx context.Context, filter IncidentFilter) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	count := 0
+	for _, incident := range s.incidents {
+		if incidentMatchesFilter(incident, filter) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func incidentMatchesFilter(incident *models.Incident, filter IncidentFilter) bool {
+	return (filter.Status == nil || incident.Status == *filter.Status) &&
+		(filter.Severity == nil || incident.Severity == *filter.Severity) &&
+		(filter.AssigneeID == nil || incident.AssigneeID == *filter.AssigneeID)
+}
+
+// paginate applies a limit and offset the way the Postgres store does: the
+// offset only applies together with a positive limit
+func paginate[T any](items []T, limit, offset int) []T {
+	if limit <= 0 {
+		return items
+	}
+	if offset > 0 {
+		if offset >= len(items) {
+			return nil
+		}
+		items = items[offset:]
+	}
+	if limit < len(items) {
+		items = items[:limit]
+	}
+	return items
+}
+
+// CountIncidentsByStatusSeverity counts incidents per status and severity
+func (s *MemoryStore) CountIncidentsByStatusSeverity(ctx context.Context) (map[models.IncidentStatus]map[models.IncidentSeverity]int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	counts := make(map[models.IncidentStatus]map[models.IncidentSeverity]int)
+	for _, incident := range s.incidents {
+		if counts[incident.Status] == nil {
+			counts[incident.Status] = make(map[models.IncidentSeverity]int)
+		}
+		counts[incident.Status][incident.Severity]++
+	}
+	return counts, nil
+}
+
+func (s *MemoryStore) CreateIncident(ctx context.Context, incident *models.Incident) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -192,7 +389,7 @@ func (s *MemoryStore) CreateIncident(incident *models.Incident) error {
 	return nil
 }
 
-func (s *MemoryStore) UpdateIncident(incident *models.Incident) error {
+func (s *MemoryStore) UpdateIncident(ctx context.Context, incident *models.Incident) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -203,7 +400,7 @@ func (s *MemoryStore) UpdateIncident(incident *models.Incident) error {
 	return nil
 }
 
-func (s *MemoryStore) DeleteIncident(id string) error {
+func (s *MemoryStore) DeleteIncident(ctx context.Context, id string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -215,7 +412,7 @@ func (s *MemoryStore) DeleteIncident(id string) error {
 }
 
 // Alert methods
-func (s *MemoryStore) GetAlert(id string) (*models.Alert, error) {
+func (s *MemoryStore) GetAlert(ctx context.Context, id string) (*models.Alert, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
@@ -226,7 +423,27 @@ func (s *MemoryStore) GetAlert(id string) (*models.Alert, error) {
 	return alert, nil
 }
 
-func (s *MemoryStore) ListAlerts() ([]*models.Alert, error) {
+func (s *MemoryStore) GetAlertByFingerprint(ctx context.Context, fingerprint string) (*models.Alert, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var latest *models.Alert
+	for _, alert := range s.alerts {
+		if alert.Fingerprint != fingerprint {
+			continue
+		}
+		if latest == nil || alert.CreatedAt.After(latest.CreatedAt) ||
+			(alert.CreatedAt.Equal(latest.CreatedAt) && alert.ID > latest.ID) {
+			latest = alert
+		}
+	}
+	if latest == nil {
+		return nil, ErrNotFound
+	}
+	return latest, nil
+}
+
+func (s *MemoryStore) ListAlerts(ctx context.Context) ([]*models.Alert, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
@@ -237,7 +454,62 @@ func (s *MemoryStore) ListAlerts() ([]*models.Alert, error) {
 	return alerts, nil
 }
 
-func (s *MemoryStore) CreateAlert(alert *models.Alert) error {
+// ListAlertsWithFilter returns the alerts matching the filter with the same
+// ordering and pagination as the Postgres store, breaking ties by ID
+func (s *MemoryStore) ListAlertsWithFilter(ctx context.Context, filter AlertFilter) ([]*models.Alert, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var alerts []*models.Alert
+	for _, alert := range s.alerts {
+		if alertMatchesFilter(alert, filter) {
+			alerts = append(alerts, alert)
+		}
+	}
+
+	less := func(a, b *models.Alert) bool { return a.CreatedAt.Before(b.CreatedAt) }
+	switch filter.OrderBy {
+	case "starts_at":
+		less = func(a, b *models.Alert) bool { return a.StartsAt.Before(b.StartsAt) }
+	case "status":
+		less = func(a, b *models.Alert) bool { return a.Status < b.Status }
+	case "fingerprint":
+		less = func(a, b *models.Alert) bool { return a.Fingerprint < b.Fingerprint }
+	}
+	sort.Slice(alerts, func(i, j int) bool {
+		switch {
+		case less(alerts[j], alerts[i]):
+			return true
+		case less(alerts[i], alerts[j]):
+			return false
+		}
+		return alerts[i].ID > alerts[j].ID
+	})
+
+	return paginate(alerts, filter.Limit, filter.Offset), nil
+}
+
+// CountAlerts counts the alerts matching the filter
+func (s *MemoryStore) CountAlerts(ctx context.Context, filter AlertFilter) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	count := 0
+	for _, alert := range s.alerts {
+		if alertMatchesFilter(alert, filter) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func alertMatchesFilter(alert *models.Alert, filter AlertFilter) bool {
+	return (filter.Status == nil || alert.Status == *filter.Status) &&
+		(filter.IncidentID == nil || alert.IncidentID == *filter.IncidentID) &&
+		(filter.Fingerprint == nil || alert.Fingerprint == *filter.Fingerprint)
+}
+
+func (s *MemoryStore) CreateAlert(ctx context.Context, alert *models.Alert) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -245,7 +517,7 @@ func (s *MemoryStore) CreateAlert(alert *models.Alert) error {
 	return nil
 }
 
-func (s *MemoryStore) UpdateAlert(alert *models.Alert) error {
+func (s *MemoryStore) UpdateAlert(ctx context.Context, alert *models.Alert) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -256,7 +528,7 @@ func (s *MemoryStore) UpdateAlert(alert *models.Alert) error {
 	return nil
 }
 
-func (s *MemoryStore) DeleteAlert(id string) error {
+func (s *MemoryStore) DeleteAlert(ctx context.Context, id string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -267,8 +539,41 @@ func (s *MemoryStore) DeleteAlert(id string) error {
 	return nil
 }
 
+// AddOverflowAlert records that an incident absorbed a firing alert past
+// its alert cap, reporting false if it was already recorded
+func (s *MemoryStore) AddOverflowAlert(ctx context.Context, incidentID, fingerprint string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	incidents := s.overflowAlerts[fingerprint]
+	if incidents == nil {
+		incidents = make(map[string]bool)
+		s.overflowAlerts[fingerprint] = incidents
+	}
+	if incidents[incidentID] {
+		return false, nil
+	}
+	incidents[incidentID] = true
+	return true, nil
+}
+
+// RemoveOverflowAlert forgets a resolved overflow alert and returns the
+// incidents that had absorbed it
+func (s *MemoryStore) RemoveOverflowAlert(ctx context.Context, fingerprint string) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	incidentIDs := []string{}
+	for incidentID := range s.overflowAlerts[fingerprint] {
+		incidentIDs = append(incidentIDs, incidentID)
+	}
+	sort.Strings(incidentIDs)
+	delete(s.overflowAlerts, fingerprint)
+	return incidentIDs, nil
+}
+
 // NotificationChannel methods
-func (s *MemoryStore) GetNotificationChannel(id string) (*models.NotificationChannel, error) {
+func (s *MemoryStore) GetNotificationChannel(ctx context.Context, id string) (*models.NotificationChannel, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
@@ -279,7 +584,7 @@ func (s *MemoryStore) GetNotificationChannel(id string) (*models.NotificationCha
 	return channel, nil
 }
 
-func (s *MemoryStore) ListNotificationChannels() ([]*models.NotificationChannel, error) {
+func (s *MemoryStore) ListNotificationChannels(ctx context.Context) ([]*models.NotificationChannel, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
@@ -290,7 +595,7 @@ func (s *MemoryStore) ListNotificationChannels() ([]*models.NotificationChannel,
 	return channels, nil
 }
 
-func (s *MemoryStore) CreateNotificationChannel(channel *models.NotificationChannel) error {
+func (s *MemoryStore) CreateNotificationChannel(ctx context.Context, channel *models.NotificationChannel) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -298,7 +603,7 @@ func (s *MemoryStore) CreateNotificationChannel(channel *models.NotificationChan
 	return nil
 }
 
-func (s *MemoryStore) UpdateNotificationChannel(channel *models.NotificationChannel) error {
+func (s *MemoryStore) UpdateNotificationChannel(ctx context.Context, channel *models.NotificationChannel) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -309,7 +614,7 @@ func (s *MemoryStore) UpdateNotificationChannel(channel *models.NotificationChan
 	return nil
 }
 
-func (s *MemoryStore) DeleteNotificationChannel(id string) error {
+func (s *MemoryStore) DeleteNotificationChannel(ctx context.Context, id string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -320,8 +625,173 @@ func (s *MemoryStore) DeleteNotificationChannel(id string) error {
 	return nil
 }
 
+// NotificationHistory methods
+func (s *MemoryStore) CreateNotificationHistory(ctx context.Context, history *models.NotificationHistory) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if history.ID == "" {
+		history.ID = uuid.New().String()
+	}
+	entry := *history
+	s.notificationHistory[history.ID] = &entry
+	return nil
+}
+
+func (s *MemoryStore) UpdateNotificationHistory(ctx context.Context, history *models.NotificationHistory) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.notificationHistory[history.ID]; !exists {
+		return ErrNotFound
+	}
+	entry := *history
+	s.notificationHistory[history.ID] = &entry
+	return nil
+}
+
+// ListNotificationHistoryByChannel returns a channel's history newest first,
+// along with the total number of entries matching the filter
+func (s *MemoryStore) ListNotificationHistoryByChannel(ctx context.Context, channelID string, filter *models.NotificationHistoryFilter) ([]*models.NotificationHistory, int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if filter == nil {
+		filter = &models.NotificationHistoryFilter{}
+	}
+
+	var matching []*models.NotificationHistory
+	for _, history := range s.notificationHistory {
+		if history.ChannelID != channelID {
+			continue
+		}
+		if filter.Status != "" && history.Status != filter.Status {
+			continue
+		}
+		if filter.Since != nil && history.CreatedAt.Before(*filter.Since) {
+			continue
+		}
+		if filter.Until != nil && history.CreatedAt.After(*filter.Until) {
+			continue
+		}
+		entry := *history
+		matching = append(matching, &entry)
+	}
+
+	sort.Slice(matching, func(i, j int) bool {
+		return matching[i].CreatedAt.After(matching[j].CreatedAt)
+	})
+
+	total := len(matching)
+	if filter.Limit > 0 {
+		page := filter.Page
+		if page < 1 {
+			page = 1
+		}
+		start := (page - 1) * filter.Limit
+		if start >= total {
+			return []*models.NotificationHistory{}, total, nil
+		}
+		end := start + filter.Limit
+		if end > total {
+			end = total
+		}
+		matching = matching[start:end]
+	}
+
+	return matching, total, nil
+}
+
+// ListNotificationHistory returns the notification attempts for an incident,
+// newest first
+func (s *MemoryStore) ListNotificationHistory(ctx context.Context, incidentID string) ([]*models.NotificationHistory, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	history := []*models.NotificationHistory{}
+	for _, entry := range s.notificationHistory {
+		if entry.IncidentID != incidentID {
+			continue
+		}
+		copied := *entry
+		history = append(history, &copied)
+	}
+
+	sort.Slice(history, func(i, j int) bool {
+		return history[i].CreatedAt.After(history[j].CreatedAt)
+	})
+	return history, nil
+}
+
+// GetNotificationHistory returns a single notification history entry
+func (s *MemoryStore) GetNotificationHistory(ctx context.Context, id string) (*models.NotificationHistory, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	entry, exists := s.notificationHistory[id]
+	if !exists {
+		return nil, ErrNotFound
+	}
+	copied := *entry
+	return &copied, nil
+}
+
+// ListNotificationHistoryByStatus returns every entry in the given status,
+// newest first
+func (s *MemoryStore) ListNotificationHistoryByStatus(ctx context.Context, status models.NotificationDeliveryStatus) ([]*models.NotificationHistory, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	history := []*models.NotificationHistory{}
+	for _, entry := range s.notificationHistory {
+		if entry.Status != status {
+			continue
+		}
+		copied := *entry
+		history = append(history, &copied)
+	}
+	sort.Slice(history, func(i, j int) bool {
+		return history[i].CreatedAt.After(history[j].CreatedAt)
+	})
+	return history, nil
+}
+
+// CountNotificationHistory counts the entries created in [from, to) by
+// channel type, notification type and status
+func (s *MemoryStore) CountNotificationHistory(ctx context.Context, from, to time.Time) ([]*models.NotificationDeliveryCount, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	counts := make(map[models.NotificationDeliveryCount]int)
+	for _, entry := range s.notificationHistory {
+		if entry.CreatedAt.Before(from) || !entry.CreatedAt.Before(to) {
+			continue
+		}
+		key := models.NotificationDeliveryCount{ChannelType: entry.Channel, NotificationType: entry.Type, Status: entry.Status}
+		counts[key]++
+	}
+
+	result := []*models.NotificationDeliveryCount{}
+	for key, count := range counts {
+		entry := key
+		entry.Count = count
+		result = append(result, &entry)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		if a.ChannelType != b.ChannelType {
+			return a.ChannelType < b.ChannelType
+		}
+		if a.NotificationType != b.NotificationType {
+			return a.NotificationType < b.NotificationType
+		}
+		return a.Status < b.Status
+	})
+	return result, nil
+}
+
 // EscalationPolicy methods
-func (s *MemoryStore) GetEscalationPolicy(id string) (*models.EscalationPolicy, error) {
+func (s *MemoryStore) GetEscalationPolicy(ctx context.Context, id string) (*models.EscalationPolicy, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
@@ -332,7 +802,7 @@ func (s *MemoryStore) GetEscalationPolicy(id string) (*models.EscalationPolicy,
 	return policy, nil
 }
 
-func (s *MemoryStore) ListEscalationPolicies() ([]*models.EscalationPolicy, error) {
+func (s *MemoryStore) ListEscalationPolicies(ctx context.Context) ([]*models.EscalationPolicy, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
@@ -343,7 +813,7 @@ func (s *MemoryStore) ListEscalationPolicies() ([]*models.EscalationPolicy, erro
 	return policies, nil
 }
 
-func (s *MemoryStore) CreateEscalationPolicy(policy *models.EscalationPolicy) error {
+func (s *MemoryStore) CreateEscalationPolicy(ctx context.Context, policy *models.EscalationPolicy) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -351,7 +821,7 @@ func (s *MemoryStore) CreateEscalationPolicy(policy *models.EscalationPolicy) er
 	return nil
 }
 
-func (s *MemoryStore) UpdateEscalationPolicy(policy *models.EscalationPolicy) error {
+func (s *MemoryStore) UpdateEscalationPolicy(ctx context.Context, policy *models.EscalationPolicy) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -362,7 +832,7 @@ func (s *MemoryStore) UpdateEscalationPolicy(policy *models.EscalationPolicy) er
 	return nil
 }
 
-func (s *MemoryStore) DeleteEscalationPolicy(id string) error {
+func (s *MemoryStore) DeleteEscalationPolicy(ctx context.Context, id string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -374,7 +844,7 @@ func (s *MemoryStore) DeleteEscalationPolicy(id string) error {
 }
 
 // OnCallSchedule methods
-func (s *MemoryStore) GetOnCallSchedule(id string) (*models.OnCallSchedule, error) {
+func (s *MemoryStore) GetOnCallSchedule(ctx context.Context, id string) (*models.OnCallSchedule, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
@@ -385,7 +855,7 @@ func (s *MemoryStore) GetOnCallSchedule(id string) (*models.OnCallSchedule, erro
 	return schedule, nil
 }
 
-func (s *MemoryStore) ListOnCallSchedules() ([]*models.OnCallSchedule, error) {
+func (s *MemoryStore) ListOnCallSchedules(ctx context.Context) ([]*models.OnCallSchedule, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
@@ -396,7 +866,7 @@ func (s *MemoryStore) ListOnCallSchedules() ([]*models.OnCallSchedule, error) {
 	return schedules, nil
 }
 
-func (s *MemoryStore) CreateOnCallSchedule(schedule *models.OnCallSchedule) error {
+func (s *MemoryStore) CreateOnCallSchedule(ctx context.Context, schedule *models.OnCallSchedule) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -404,7 +874,7 @@ func (s *MemoryStore) CreateOnCallSchedule(schedule *models.OnCallSchedule) erro
 	return nil
 }
 
-func (s *MemoryStore) UpdateOnCallSchedule(schedule *models.OnCallSchedule) error {
+func (s *MemoryStore) UpdateOnCallSchedule(ctx context.Context, schedule *models.OnCallSchedule) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -415,7 +885,7 @@ func (s *MemoryStore) UpdateOnCallSchedule(schedule *models.OnCallSchedule) erro
 	return nil
 }
 
-func (s *MemoryStore) DeleteOnCallSchedule(id string) error {
+func (s *MemoryStore) DeleteOnCallSchedule(ctx context.Context, id string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -431,9 +901,28 @@ func (s *MemoryStore) Close() error {
 	return nil
 }
 
+// WithTx runs fn against the store while holding a lock that other WithTx
+// callers wait on. The memory store cannot roll back, so writes fn made
+// before failing are kept.
+func (s *MemoryStore) WithTx(ctx context.Context, fn func(tx Store) error) error {
+	s.txMu.Lock()
+	defer s.txMu.Unlock()
+	return fn(memoryTx{s})
+}
+
+// memoryTx is the store handed to a MemoryStore.WithTx callback. Nested
+// WithTx calls run inline rather than waiting on the lock already held.
+type memoryTx struct {
+	*MemoryStore
+}
+
+func (tx memoryTx) WithTx(ctx context.Context, fn func(tx Store) error) error {
+	return fn(tx)
+}
+
 // Enhanced Incident Features - Comments Implementation
 
-func (s *MemoryStore) CreateIncidentComment(comment *models.IncidentComment) error {
+func (s *MemoryStore) CreateIncidentComment(ctx context.Context, comment *models.IncidentComment) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -446,7 +935,7 @@ func (s *MemoryStore) CreateIncidentComment(comment *models.IncidentComment) err
 	return nil
 }
 
-func (s *MemoryStore) GetIncidentComments(incidentID string) ([]*models.IncidentComment, error) {
+func (s *MemoryStore) GetIncidentComments(ctx context.Context, incidentID string) ([]*models.IncidentComment, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
@@ -465,14 +954,46 @@ func (s *MemoryStore) GetIncidentComments(incidentID string) ([]*models.Incident
 	return result, nil
 }
 
-func (s *MemoryStore) GetIncidentTimeline(incidentID string) ([]*models.IncidentComment, error) {
+func (s *MemoryStore) GetIncidentTimeline(ctx context.Context, incidentID string) ([]*models.IncidentComment, error) {
 	// For memory store, timeline is the same as comments (sorted by creation time)
-	return s.GetIncidentComments(incidentID)
+	return s.GetIncidentComments(ctx, incidentID)
+}
+
+func (s *MemoryStore) UpdateIncidentComment(ctx context.Context, comment *models.IncidentComment) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, existing := range s.incidentComments[comment.IncidentID] {
+		if existing.ID == comment.ID {
+			existing.Content = comment.Content
+			existing.Metadata = comment.Metadata
+			existing.EditedAt = comment.EditedAt
+			return nil
+		}
+	}
+
+	return ErrNotFound
+}
+
+func (s *MemoryStore) DeleteIncidentComment(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for incidentID, comments := range s.incidentComments {
+		for i, comment := range comments {
+			if comment.ID == id {
+				s.incidentComments[incidentID] = append(comments[:i:i], comments[i+1:]...)
+				return nil
+			}
+		}
+	}
+
+	return ErrNotFound
 }
 
 // Enhanced Incident Features - Tags Implementation
 
-func (s *MemoryStore) CreateIncidentTag(tag *models.IncidentTag) error {
+func (s *MemoryStore) CreateIncidentTag(ctx context.Context, tag *models.IncidentTag) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -485,7 +1006,7 @@ func (s *MemoryStore) CreateIncidentTag(tag *models.IncidentTag) error {
 	return nil
 }
 
-func (s *MemoryStore) GetIncidentTags(incidentID string) ([]*models.IncidentTag, error) {
+func (s *MemoryStore) GetIncidentTags(ctx context.Context, incidentID string) ([]*models.IncidentTag, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
@@ -504,7 +1025,7 @@ func (s *MemoryStore) GetIncidentTags(incidentID string) ([]*models.IncidentTag,
 	return result, nil
 }
 
-func (s *MemoryStore) DeleteIncidentTag(incidentID, tagName string) error {
+func (s *MemoryStore) DeleteIncidentTag(ctx context.Context, incidentID, tagName string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -522,7 +1043,7 @@ func (s *MemoryStore) DeleteIncidentTag(incidentID, tagName string) error {
 
 // Enhanced Incident Features - Templates Implementation
 
-func (s *MemoryStore) CreateIncidentTemplate(template *models.IncidentTemplate) error {
+func (s *MemoryStore) CreateIncidentTemplate(ctx context.Context, template *models.IncidentTemplate) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -533,7 +1054,7 @@ func (s *MemoryStore) CreateIncidentTemplate(template *models.IncidentTemplate)
 	return nil
 }
 
-func (s *MemoryStore) GetIncidentTemplate(id string) (*models.IncidentTemplate, error) {
+func (s *MemoryStore) GetIncidentTemplate(ctx context.Context, id string) (*models.IncidentTemplate, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
@@ -547,7 +1068,7 @@ func (s *MemoryStore) GetIncidentTemplate(id string) (*models.IncidentTemplate,
 	return &templateCopy, nil
 }
 
-func (s *MemoryStore) ListIncidentTemplates(activeOnly bool) ([]*models.IncidentTemplate, error) {
+func (s *MemoryStore) ListIncidentTemplates(ctx context.Context, activeOnly bool) ([]*models.IncidentTemplate, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
@@ -565,7 +1086,7 @@ func (s *MemoryStore) ListIncidentTemplates(activeOnly bool) ([]*models.Incident
 	return templates, nil
 }
 
-func (s *MemoryStore) UpdateIncidentTemplate(template *models.IncidentTemplate) error {
+func (s *MemoryStore) UpdateIncidentTemplate(ctx context.Context, template *models.IncidentTemplate) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -580,7 +1101,7 @@ func (s *MemoryStore) UpdateIncidentTemplate(template *models.IncidentTemplate)
 	return nil
 }
 
-func (s *MemoryStore) DeleteIncidentTemplate(id string) error {
+func (s *MemoryStore) DeleteIncidentTemplate(ctx context.Context, id string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -594,7 +1115,7 @@ func (s *MemoryStore) DeleteIncidentTemplate(id string) error {
 
 // Enhanced Incident Features - Attachments Implementation
 
-func (s *MemoryStore) CreateIncidentAttachment(attachment *models.IncidentAttachment) error {
+func (s *MemoryStore) CreateIncidentAttachment(ctx context.Context, attachment *models.IncidentAttachment) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -607,7 +1128,7 @@ func (s *MemoryStore) CreateIncidentAttachment(attachment *models.IncidentAttach
 	return nil
 }
 
-func (s *MemoryStore) GetIncidentAttachments(incidentID string) ([]*models.IncidentAttachment, error) {
+func (s *MemoryStore) GetIncidentAttachments(ctx context.Context, incidentID string) ([]*models.IncidentAttachment, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
@@ -626,7 +1147,23 @@ func (s *MemoryStore) GetIncidentAttachments(incidentID string) ([]*models.Incid
 	return result, nil
 }
 
-func (s *MemoryStore) DeleteIncidentAttachment(id string) error {
+func (s *MemoryStore) GetIncidentAttachment(ctx context.Context, id string) (*models.IncidentAttachment, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, attachments := range s.incidentAttachments {
+		for _, attachment := range attachments {
+			if attachment.ID == id {
+				attachmentCopy := *attachment
+				return &attachmentCopy, nil
+			}
+		}
+	}
+
+	return nil, ErrNotFound
+}
+
+func (s *MemoryStore) DeleteIncidentAttachment(ctx context.Context, id string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -645,7 +1182,7 @@ func (s *MemoryStore) DeleteIncidentAttachment(id string) error {
 
 // Enhanced Incident Features - Search Implementation
 
-func (s *MemoryStore) SearchIncidents(req *models.IncidentSearchRequest) ([]*models.Incident, int, error) {
+func (s *MemoryStore) SearchIncidents(ctx context.Context, req *models.IncidentSearchRequest) ([]*models.Incident, int, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
@@ -680,13 +1217,9 @@ func (s *MemoryStore) SearchIncidents(req *models.IncidentSearchRequest) ([]*mod
 }
 
 func (s *MemoryStore) matchesSearchCriteria(incident *models.Incident, req *models.IncidentSearchRequest) bool {
-	// Text search in title and description
-	if req.Query != "" {
-		query := strings.ToLower(req.Query)
-		if !strings.Contains(strings.ToLower(incident.Title), query) &&
-		   !strings.Contains(strings.ToLower(incident.Description), query) {
-			return false
-		}
+	// Text search with the same word matching as Postgres (see search.go)
+	if req.Query != "" && !matchesSearchQuery(incident, searchQueryTerms(req.Query)) {
+		return false
 	}
 
 	// Status filter
@@ -717,6 +1250,20 @@ func (s *MemoryStore) matchesSearchCriteria(incident *models.Incident, req *mode
 		}
 	}
 
+	// Priority filter
+	if len(req.Priority) > 0 {
+		found := false
+		for _, priority := range req.Priority {
+			if incident.Priority == priority {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
 	// Assignee filter
 	if req.AssigneeID != nil && incident.AssigneeID != *req.AssigneeID {
 		return false
@@ -730,13 +1277,52 @@ func (s *MemoryStore) matchesSearchCriteria(incident *models.Incident, req *mode
 		return false
 	}
 
-	// Tag filter (simplified - would need to check incident tags in real implementation)
+	// Resolution type filter
+	if len(req.ResolutionType) > 0 {
+		found := false
+		for _, resolutionType := range req.ResolutionType {
+			if incident.ResolutionType == resolutionType {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	// Root cause category filter
+	if len(req.RootCauseCategory) > 0 {
+		found := false
+		for _, category := range req.RootCauseCategory {
+			if incident.RootCauseCategory == category {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	// Needs-attention filter: open, unassigned and created before the cutoff
+	if req.NeedsAttention != nil {
+		needsAttention := incident.Status == models.IncidentStatusOpen &&
+			incident.AssigneeID == "" &&
+			incident.CreatedAt.Before(req.AttentionCutoff)
+		if needsAttention != *req.NeedsAttention {
+			return false
+		}
+	}
+
+	// Tag filter: every tag must be present, with the given value if any
 	if len(req.Tags) > 0 {
 		incidentTags := s.incidentTags[incident.ID]
 		for _, requiredTag := range req.Tags {
+			name, value, hasValue := models.ParseTagFilter(requiredTag)
 			found := false
 			for _, tag := range incidentTags {
-				if tag.TagName == requiredTag {
+				if tag.TagName == name && (!hasValue || (tag.TagValue != nil && *tag.TagValue == value)) {
 					found = true
 					break
 				}
@@ -750,43 +1336,75 @@ func (s *MemoryStore) matchesSearchCriteria(incident *models.Incident, req *mode
 	return true
 }
 
+// incidentSeverityRank orders severities from low to critical
+func incidentSeverityRank(severity models.IncidentSeverity) int {
+	switch severity {
+	case models.SeverityCritical:
+		return 4
+	case models.SeverityHigh:
+		return 3
+	case models.SeverityMedium:
+		return 2
+	case models.SeverityLow:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// incidentStatusRank orders statuses along the incident lifecycle
+func incidentStatusRank(status models.IncidentStatus) int {
+	switch status {
+	case models.IncidentStatusOpen:
+		return 1
+	case models.IncidentStatusAcknowledged:
+		return 2
+	case models.IncidentStatusResolved:
+		return 3
+	default:
+		return 0
+	}
+}
+
+// sortIncidents orders incidents by created_at, updated_at, severity (low to
+// critical), status (open, acknowledged, resolved) or title, ascending or
+// descending. Unknown fields sort by created_at, and the default is newest
+// first. Ties are broken by ID so pages are stable.
 func (s *MemoryStore) sortIncidents(incidents []*models.Incident, orderBy, orderDir string) {
-	// Simple sorting implementation - could be enhanced with more sophisticated sorting
-	if orderBy == "" {
-		orderBy = "created_at"
-	}
-	if orderDir == "" {
-		orderDir = "desc"
-	}
-
-	// For simplicity, we'll implement basic sorting by created_at
-	// A full implementation would handle all order fields
-	if orderBy == "created_at" {
-		if orderDir == "desc" {
-			// Sort by creation time descending (newest first)
-			for i := 0; i < len(incidents)-1; i++ {
-				for j := i + 1; j < len(incidents); j++ {
-					if incidents[i].CreatedAt.Before(incidents[j].CreatedAt) {
-						incidents[i], incidents[j] = incidents[j], incidents[i]
-					}
-				}
-			}
-		} else {
-			// Sort by creation time ascending (oldest first)  
-			for i := 0; i < len(incidents)-1; i++ {
-				for j := i + 1; j < len(incidents); j++ {
-					if incidents[i].CreatedAt.After(incidents[j].CreatedAt) {
-						incidents[i], incidents[j] = incidents[j], incidents[i]
-					}
-				}
-			}
+	var compare func(a, b *models.Incident) int
+	switch orderBy {
+	case "updated_at":
+		compare = func(a, b *models.Incident) int { return a.UpdatedAt.Compare(b.UpdatedAt) }
+	case "severity":
+		compare = func(a, b *models.Incident) int {
+			return incidentSeverityRank(a.Severity) - incidentSeverityRank(b.Severity)
+		}
+	case "status":
+		compare = func(a, b *models.Incident) int {
+			return incidentStatusRank(a.Status) - incidentStatusRank(b.Status)
 		}
+	case "title":
+		compare = func(a, b *models.Incident) int { return strings.Compare(a.Title, b.Title) }
+	default:
+		compare = func(a, b *models.Incident) int { return a.CreatedAt.Compare(b.CreatedAt) }
 	}
+
+	descending := !strings.EqualFold(orderDir, "asc")
+	sort.Slice(incidents, func(i, j int) bool {
+		result := compare(incidents[i], incidents[j])
+		if result == 0 {
+			return incidents[i].ID < incidents[j].ID
+		}
+		if descending {
+			return result > 0
+		}
+		return result < 0
+	})
 }
 
 // User Management Methods
 
-func (s *MemoryStore) GetUser(id string) (*models.User, error) {
+func (s *MemoryStore) GetUser(ctx context.Context, id string) (*models.User, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
@@ -803,7 +1421,7 @@ func (s *MemoryStore) GetUser(id string) (*models.User, error) {
 	return &userCopy, nil
 }
 
-func (s *MemoryStore) GetUserByUsername(username string) (*models.User, error) {
+func (s *MemoryStore) GetUserByUsername(ctx context.Context, username string) (*models.User, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
@@ -820,7 +1438,7 @@ func (s *MemoryStore) GetUserByUsername(username string) (*models.User, error) {
 	return &userCopy, nil
 }
 
-func (s *MemoryStore) GetUserByEmail(email string) (*models.User, error) {
+func (s *MemoryStore) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
@@ -837,7 +1455,7 @@ func (s *MemoryStore) GetUserByEmail(email string) (*models.User, error) {
 	return &userCopy, nil
 }
 
-func (s *MemoryStore) ListUsers() ([]*models.User, error) {
+func (s *MemoryStore) ListUsers(ctx context.Context) ([]*models.User, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
@@ -848,10 +1466,17 @@ func (s *MemoryStore) ListUsers() ([]*models.User, error) {
 		userCopy.Roles = roles
 		users = append(users, &userCopy)
 	}
+	// Newest first, like the Postgres store
+	sort.Slice(users, func(i, j int) bool {
+		if !users[i].CreatedAt.Equal(users[j].CreatedAt) {
+			return users[i].CreatedAt.After(users[j].CreatedAt)
+		}
+		return users[i].ID < users[j].ID
+	})
 	return users, nil
 }
 
-func (s *MemoryStore) CreateUser(user *models.User) error {
+func (s *MemoryStore) CreateUser(ctx context.Context, user *models.User) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -873,7 +1498,7 @@ func (s *MemoryStore) CreateUser(user *models.User) error {
 	return nil
 }
 
-func (s *MemoryStore) UpdateUser(user *models.User) error {
+func (s *MemoryStore) UpdateUser(ctx context.Context, user *models.User) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -905,7 +1530,7 @@ func (s *MemoryStore) UpdateUser(user *models.User) error {
 	return nil
 }
 
-func (s *MemoryStore) DeleteUser(id string) error {
+func (s *MemoryStore) DeleteUser(ctx context.Context, id string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -922,7 +1547,7 @@ func (s *MemoryStore) DeleteUser(id string) error {
 	return nil
 }
 
-func (s *MemoryStore) UpdateLastLogin(userID string, timestamp time.Time) error {
+func (s *MemoryStore) UpdateLastLogin(ctx context.Context, userID string, timestamp time.Time) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -937,7 +1562,7 @@ func (s *MemoryStore) UpdateLastLogin(userID string, timestamp time.Time) error
 
 // Role Management Methods
 
-func (s *MemoryStore) GetRole(id string) (*models.Role, error) {
+func (s *MemoryStore) GetRole(ctx context.Context, id string) (*models.Role, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
@@ -954,7 +1579,7 @@ func (s *MemoryStore) GetRole(id string) (*models.Role, error) {
 	return &roleCopy, nil
 }
 
-func (s *MemoryStore) GetRoleByName(name string) (*models.Role, error) {
+func (s *MemoryStore) GetRoleByName(ctx context.Context, name string) (*models.Role, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
@@ -971,7 +1596,7 @@ func (s *MemoryStore) GetRoleByName(name string) (*models.Role, error) {
 	return &roleCopy, nil
 }
 
-func (s *MemoryStore) ListRoles() ([]*models.Role, error) {
+func (s *MemoryStore) ListRoles(ctx context.Context) ([]*models.Role, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
@@ -985,7 +1610,7 @@ func (s *MemoryStore) ListRoles() ([]*models.Role, error) {
 	return roles, nil
 }
 
-func (s *MemoryStore) CreateRole(role *models.Role) error {
+func (s *MemoryStore) CreateRole(ctx context.Context, role *models.Role) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -1002,7 +1627,7 @@ func (s *MemoryStore) CreateRole(role *models.Role) error {
 	return nil
 }
 
-func (s *MemoryStore) UpdateRole(role *models.Role) error {
+func (s *MemoryStore) UpdateRole(ctx context.Context, role *models.Role) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -1026,7 +1651,7 @@ func (s *MemoryStore) UpdateRole(role *models.Role) error {
 	return nil
 }
 
-func (s *MemoryStore) DeleteRole(id string) error {
+func (s *MemoryStore) DeleteRole(ctx context.Context, id string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -1055,7 +1680,7 @@ func (s *MemoryStore) DeleteRole(id string) error {
 
 // User-Role Association Methods
 
-func (s *MemoryStore) AssignRoleToUser(userID, roleID string) error {
+func (s *MemoryStore) AssignRoleToUser(ctx context.Context, userID, roleID string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -1079,7 +1704,7 @@ func (s *MemoryStore) AssignRoleToUser(userID, roleID string) error {
 	return nil
 }
 
-func (s *MemoryStore) RemoveRoleFromUser(userID, roleID string) error {
+func (s *MemoryStore) RemoveRoleFromUser(ctx context.Context, userID, roleID string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -1103,7 +1728,7 @@ func (s *MemoryStore) RemoveRoleFromUser(userID, roleID string) error {
 	return nil
 }
 
-func (s *MemoryStore) GetUserRoles(userID string) ([]*models.Role, error) {
+func (s *MemoryStore) GetUserRoles(ctx context.Context, userID string) ([]*models.Role, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
@@ -1128,7 +1753,7 @@ func (s *MemoryStore) getUserRolesInternal(userID string) ([]*models.Role, error
 
 // Permission Methods
 
-func (s *MemoryStore) GetPermission(id string) (*models.Permission, error) {
+func (s *MemoryStore) GetPermission(ctx context.Context, id string) (*models.Permission, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
@@ -1139,7 +1764,7 @@ func (s *MemoryStore) GetPermission(id string) (*models.Permission, error) {
 	return permission, nil
 }
 
-func (s *MemoryStore) ListPermissions() ([]*models.Permission, error) {
+func (s *MemoryStore) ListPermissions(ctx context.Context) ([]*models.Permission, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
@@ -1150,7 +1775,7 @@ func (s *MemoryStore) ListPermissions() ([]*models.Permission, error) {
 	return permissions, nil
 }
 
-func (s *MemoryStore) GetRolePermissions(roleID string) ([]*models.Permission, error) {
+func (s *MemoryStore) GetRolePermissions(ctx context.Context, roleID string) ([]*models.Permission, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
@@ -1170,9 +1795,48 @@ func (s *MemoryStore) getRolePermissionsInternal(roleID string) ([]*models.Permi
 	return permissions, nil
 }
 
+func (s *MemoryStore) CreatePermission(ctx context.Context, permission *models.Permission) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, existing := range s.permissions {
+		if existing.Name == permission.Name {
+			return errors.New("permission name already exists")
+		}
+	}
+	if permission.ID == "" {
+		permission.ID = uuid.New().String()
+	}
+
+	s.permissions[permission.ID] = permission
+	return nil
+}
+
+func (s *MemoryStore) AssignPermissionToRole(ctx context.Context, roleID, permissionID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.roles[roleID]; !exists {
+		return ErrNotFound
+	}
+	if _, exists := s.permissions[permissionID]; !exists {
+		return ErrNotFound
+	}
+
+	permissionIDs := s.rolePermissions[roleID]
+	for _, id := range permissionIDs {
+		if id == permissionID {
+			return nil // Already assigned
+		}
+	}
+
+	s.rolePermissions[roleID] = append(permissionIDs, permissionID)
+	return nil
+}
+
 // User Activity Methods
 
-func (s *MemoryStore) LogUserActivity(activity *models.UserActivity) error {
+func (s *MemoryStore) LogUserActivity(ctx context.Context, activity *models.UserActivity) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -1184,7 +1848,7 @@ func (s *MemoryStore) LogUserActivity(activity *models.UserActivity) error {
 	return nil
 }
 
-func (s *MemoryStore) GetUserActivities(userID string, limit int) ([]*models.UserActivity, error) {
+func (s *MemoryStore) GetUserActivities(ctx context.Context, userID string, limit int) ([]*models.UserActivity, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
@@ -1201,3 +1865,164 @@ func (s *MemoryStore) GetUserActivities(userID string, limit int) ([]*models.Use
 
 	return result, nil
 }
+
+func (s *MemoryStore) ListRecentActivities(ctx context.Context, limit int) ([]*models.UserActivity, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var result []*models.UserActivity
+	for _, activities := range s.userActivities {
+		result = append(result, activities...)
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].CreatedAt.After(result[j].CreatedAt)
+	})
+	if limit > 0 && limit < len(result) {
+		result = result[:limit]
+	}
+	return result, nil
+}
+
+// Comment draft methods
+
+func commentDraftKey(incidentID, userID string) string {
+	return incidentID + "/" + userID
+}
+
+// SaveCommentDraft creates or replaces the user's draft for an incident
+func (s *MemoryStore) SaveCommentDraft(ctx context.Context, draft *models.CommentDraft) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry := *draft
+	s.commentDrafts[commentDraftKey(draft.IncidentID, draft.UserID)] = &entry
+	return nil
+}
+
+func (s *MemoryStore) GetCommentDraft(ctx context.Context, incidentID, userID string) (*models.CommentDraft, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	draft, exists := s.commentDrafts[commentDraftKey(incidentID, userID)]
+	if !exists {
+		return nil, ErrNotFound
+	}
+	entry := *draft
+	return &entry, nil
+}
+
+// DeleteCommentDraft removes the user's draft for an incident, if any
+func (s *MemoryStore) DeleteCommentDraft(ctx context.Context, incidentID, userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.commentDrafts, commentDraftKey(incidentID, userID))
+	return nil
+}
+
+// Webhook payload methods
+
+func (s *MemoryStore) CreateWebhookPayload(ctx context.Context, payload *models.WebhookPayload) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if payload.ID == "" {
+		payload.ID = uuid.New().String()
+	}
+	entry := *payload
+	s.webhookPayloads[payload.ID] = &entry
+	return nil
+}
+
+func (s *MemoryStore) GetWebhookPayload(ctx context.Context, id string) (*models.WebhookPayload, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	payload, exists := s.webhookPayloads[id]
+	if !exists {
+		return nil, ErrNotFound
+	}
+	entry := *payload
+	return &entry, nil
+}
+
+// DeleteWebhookPayloadsBefore removes payloads received before cutoff and
+// returns how many were removed
+func (s *MemoryStore) DeleteWebhookPayloadsBefore(ctx context.Context, cutoff time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	deleted := 0
+	for id, payload := range s.webhookPayloads {
+		if payload.ReceivedAt.Before(cutoff) {
+			delete(s.webhookPayloads, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// Lifecycle webhook methods
+
+func (s *MemoryStore) GetLifecycleWebhook(ctx context.Context, id string) (*models.LifecycleWebhook, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	webhook, exists := s.lifecycleWebhooks[id]
+	if !exists {
+		return nil, ErrNotFound
+	}
+	entry := *webhook
+	return &entry, nil
+}
+
+// ListLifecycleWebhooks returns all lifecycle webhooks, oldest first
+func (s *MemoryStore) ListLifecycleWebhooks(ctx context.Context) ([]*models.LifecycleWebhook, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	webhooks := make([]*models.LifecycleWebhook, 0, len(s.lifecycleWebhooks))
+	for _, webhook := range s.lifecycleWebhooks {
+		entry := *webhook
+		webhooks = append(webhooks, &entry)
+	}
+	sort.Slice(webhooks, func(i, j int) bool {
+		return webhooks[i].CreatedAt.Before(webhooks[j].CreatedAt)
+	})
+	return webhooks, nil
+}
+
+func (s *MemoryStore) CreateLifecycleWebhook(ctx context.Context, webhook *models.LifecycleWebhook) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if webhook.ID == "" {
+		webhook.ID = uuid.New().String()
+	}
+	entry := *webhook
+	s.lifecycleWebhooks[webhook.ID] = &entry
+	return nil
+}
+
+func (s *MemoryStore) UpdateLifecycleWebhook(ctx context.Context, webhook *models.LifecycleWebhook) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.lifecycleWebhooks[webhook.ID]; !exists {
+		return ErrNotFound
+	}
+	entry := *webhook
+	s.lifecycleWebhooks[webhook.ID] = &entry
+	return nil
+}
+
+func (s *MemoryStore) DeleteLifecycleWebhook(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.lifecycleWebhooks[id]; !exists {
+		return ErrNotFound
+	}
+	delete(s.lifecycleWebhooks, id)
+	return nil
+}
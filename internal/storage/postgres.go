@@ -13,7 +13,8 @@ import (
 	"github.com/golang-migrate/migrate/v4"
 	"github.com/golang-migrate/migrate/v4/database/postgres"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
-	_ "github.com/lib/pq"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/config"
 	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/models"
 )
@@ -21,6 +22,16 @@ import (
 // PostgresStore implements the Store interface using PostgreSQL
 type PostgresStore struct {
 	db *sql.DB
+	// conn runs the store's queries: the connection pool, or the
+	// transaction of a store handed to a WithTx callback
+	conn queryer
+}
+
+// queryer is the part of *sql.DB and *sql.Tx the store queries through
+type queryer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
 }
 
 // NewPostgresStore creates a new PostgreSQL store with connection pooling
@@ -45,7 +56,7 @@ func NewPostgresStore(cfg *config.Config) (*PostgresStore, error) {
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
-	store := &PostgresStore{db: db}
+	store := &PostgresStore{db: db, conn: db}
 
 	// Run migrations
 	if err := store.runMigrations(); err != nil {
@@ -61,6 +72,31 @@ func (s *PostgresStore) Close() error {
 	return s.db.Close()
 }
 
+// WithTx runs fn with a store whose queries all belong to one transaction,
+// committing it if fn returns nil and rolling it back otherwise. Calls on a
+// store that is already in a transaction join it.
+func (s *PostgresStore) WithTx(ctx context.Context, fn func(tx Store) error) error {
+	if _, ok := s.conn.(*sql.Tx); ok {
+		return fn(s)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	// Rolling back after a commit is a no-op, so this only undoes a
+	// transaction that fn failed or panicked in
+	defer tx.Rollback()
+
+	if err := fn(&PostgresStore{db: s.db, conn: tx}); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
 // runMigrations runs database migrations
 func (s *PostgresStore) runMigrations() error {
 	driver, err := postgres.WithInstance(s.db, &postgres.Config{})
@@ -121,18 +157,20 @@ func getMigrationsPath() string {
 func (s *PostgresStore) GetIncidentByID(ctx context.Context, id string) (*models.Incident, error) {
 	query := `
 		SELECT id, title, description, status, severity, created_at, updated_at,
-		       acked_at, resolved_at, assignee_id, labels
+		       acked_at, resolved_at, assignee_id, labels, overflow_alert_count, storm_summary,
+		       resolution_type, root_cause_category, merged_into, priority
 		FROM incidents
 		WHERE id = $1
 	`
 
 	var incident models.Incident
-	var labelsJSON []byte
+	var labelsJSON, stormJSON []byte
 
-	err := s.db.QueryRowContext(ctx, query, id).Scan(
+	err := s.conn.QueryRowContext(ctx, query, id).Scan(
 		&incident.ID, &incident.Title, &incident.Description,
 		&incident.Status, &incident.Severity, &incident.CreatedAt, &incident.UpdatedAt,
-		&incident.AckedAt, &incident.ResolvedAt, &incident.AssigneeID, &labelsJSON,
+		&incident.AckedAt, &incident.ResolvedAt, &incident.AssigneeID, &labelsJSON, &incident.OverflowAlertCount, &stormJSON,
+		&incident.ResolutionType, &incident.RootCauseCategory, &incident.MergedInto, &incident.Priority,
 	)
 
 	if err == sql.ErrNoRows {
@@ -150,10 +188,13 @@ func (s *PostgresStore) GetIncidentByID(ctx context.Context, id string) (*models
 	} else {
 		incident.Labels = make(map[string]string)
 	}
+	if incident.StormSummary, err = unmarshalStormSummary(stormJSON); err != nil {
+		return nil, err
+	}
 
 	// Get associated alert IDs
 	alertQuery := `SELECT id FROM alerts WHERE incident_id = $1`
-	rows, err := s.db.QueryContext(ctx, alertQuery, id)
+	rows, err := s.conn.QueryContext(ctx, alertQuery, id)
 	if err != nil {
 		return nil, err
 	}
@@ -173,8 +214,8 @@ func (s *PostgresStore) GetIncidentByID(ctx context.Context, id string) (*models
 }
 
 // GetIncident provides backward compatibility
-func (s *PostgresStore) GetIncident(id string) (*models.Incident, error) {
-	return s.GetIncidentByID(context.Background(), id)
+func (s *PostgresStore) GetIncident(ctx context.Context, id string) (*models.Incident, error) {
+	return s.GetIncidentByID(ctx, id)
 }
 
 // ListIncidents implements IncidentRepository.ListIncidents with filtering and pagination
@@ -182,7 +223,8 @@ func (s *PostgresStore) ListIncidentsWithFilter(ctx context.Context, filter Inci
 	// Build query with filtering
 	query := `
 		SELECT id, title, description, status, severity, created_at, updated_at,
-		       acked_at, resolved_at, assignee_id, labels
+		       acked_at, resolved_at, assignee_id, labels, overflow_alert_count, storm_summary,
+		       resolution_type, root_cause_category, merged_into, priority
 		FROM incidents
 		WHERE ($1::incident_status IS NULL OR status = $1)
 		  AND ($2::incident_severity IS NULL OR severity = $2)
@@ -215,21 +257,22 @@ func (s *PostgresStore) ListIncidentsWithFilter(ctx context.Context, filter Inci
 
 	if filter.Limit > 0 {
 		if filter.Offset > 0 {
-			rows, err = s.db.QueryContext(ctx, query, filter.Status, filter.Severity, filter.AssigneeID, filter.Limit, filter.Offset)
+			rows, err = s.conn.QueryContext(ctx, query, filter.Status, filter.Severity, filter.AssigneeID, filter.Limit, filter.Offset)
 		} else {
-			rows, err = s.db.QueryContext(ctx, query, filter.Status, filter.Severity, filter.AssigneeID, filter.Limit)
+			rows, err = s.conn.QueryContext(ctx, query, filter.Status, filter.Severity, filter.AssigneeID, filter.Limit)
 		}
 	} else {
 		// Remove LIMIT clause if no limit specified
 		query = `
 			SELECT id, title, description, status, severity, created_at, updated_at,
-			       acked_at, resolved_at, assignee_id, labels
+			       acked_at, resolved_at, assignee_id, labels, overflow_alert_count, storm_summary,
+			       resolution_type, root_cause_category, merged_into, priority
 			FROM incidents
 			WHERE ($1::incident_status IS NULL OR status = $1)
 			  AND ($2::incident_severity IS NULL OR severity = $2)
 			  AND ($3::text IS NULL OR assignee_id = $3)
 			ORDER BY ` + orderBy + ` DESC`
-		rows, err = s.db.QueryContext(ctx, query, filter.Status, filter.Severity, filter.AssigneeID)
+		rows, err = s.conn.QueryContext(ctx, query, filter.Status, filter.Severity, filter.AssigneeID)
 	}
 
 	if err != nil {
@@ -240,12 +283,13 @@ func (s *PostgresStore) ListIncidentsWithFilter(ctx context.Context, filter Inci
 	var incidents []*models.Incident
 	for rows.Next() {
 		var incident models.Incident
-		var labelsJSON []byte
+		var labelsJSON, stormJSON []byte
 
 		err := rows.Scan(
 			&incident.ID, &incident.Title, &incident.Description,
 			&incident.Status, &incident.Severity, &incident.CreatedAt, &incident.UpdatedAt,
-			&incident.AckedAt, &incident.ResolvedAt, &incident.AssigneeID, &labelsJSON,
+			&incident.AckedAt, &incident.ResolvedAt, &incident.AssigneeID, &labelsJSON, &incident.OverflowAlertCount, &stormJSON,
+			&incident.ResolutionType, &incident.RootCauseCategory, &incident.MergedInto, &incident.Priority,
 		)
 		if err != nil {
 			return nil, err
@@ -259,43 +303,86 @@ func (s *PostgresStore) ListIncidentsWithFilter(ctx context.Context, filter Inci
 		} else {
 			incident.Labels = make(map[string]string)
 		}
-
-		// Get associated alert IDs for each incident
-		alertQuery := `SELECT id FROM alerts WHERE incident_id = $1`
-		alertRows, err := s.db.QueryContext(ctx, alertQuery, incident.ID)
-		if err != nil {
+		if incident.StormSummary, err = unmarshalStormSummary(stormJSON); err != nil {
 			return nil, err
 		}
 
-		var alertIDs []string
-		for alertRows.Next() {
-			var alertID string
-			if err := alertRows.Scan(&alertID); err != nil {
-				alertRows.Close()
-				return nil, err
-			}
-			alertIDs = append(alertIDs, alertID)
-		}
-		alertRows.Close()
-		incident.AlertIDs = alertIDs
-
 		incidents = append(incidents, &incident)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	// A transaction's connection cannot start the alert query while these
+	// rows are still open
+	rows.Close()
 
+	if err := s.loadAlertIDs(ctx, incidents); err != nil {
+		return nil, err
+	}
 	return incidents, nil
 }
 
+// loadAlertIDs sets AlertIDs on every incident with one query for the whole
+// list rather than one per incident
+func (s *PostgresStore) loadAlertIDs(ctx context.Context, incidents []*models.Incident) error {
+	if len(incidents) == 0 {
+		return nil
+	}
+	byID := make(map[string]*models.Incident, len(incidents))
+	ids := make([]string, len(incidents))
+	for i, incident := range incidents {
+		byID[incident.ID] = incident
+		ids[i] = incident.ID
+	}
+
+	rows, err := s.conn.QueryContext(ctx, `SELECT incident_id, id FROM alerts WHERE incident_id = ANY($1::uuid[])`, pq.Array(ids))
+	if err != nil {
+		return fmt.Errorf("failed to load incident alerts: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var incidentID, alertID string
+		if err := rows.Scan(&incidentID, &alertID); err != nil {
+			return err
+		}
+		if incident := byID[incidentID]; incident != nil {
+			incident.AlertIDs = append(incident.AlertIDs, alertID)
+		}
+	}
+	return rows.Err()
+}
+
 // ListIncidents provides backward compatibility for the old Store interface
-func (s *PostgresStore) ListIncidents() ([]*models.Incident, error) {
-	ctx := context.Background()
+func (s *PostgresStore) ListIncidents(ctx context.Context) ([]*models.Incident, error) {
 	query := `
 		SELECT id, title, description, status, severity, created_at, updated_at,
-		       acked_at, resolved_at, assignee_id, labels
+		       acked_at, resolved_at, assignee_id, labels, overflow_alert_count, storm_summary,
+		       resolution_type, root_cause_category, merged_into, priority
 		FROM incidents
 		ORDER BY created_at DESC
 	`
+	return s.queryIncidents(ctx, query)
+}
+
+// ListIncidentsCreatedBetween returns the incidents created at or after from
+// and before to, oldest first
+func (s *PostgresStore) ListIncidentsCreatedBetween(ctx context.Context, from, to time.Time) ([]*models.Incident, error) {
+	query := `
+		SELECT id, title, description, status, severity, created_at, updated_at,
+		       acked_at, resolved_at, assignee_id, labels, overflow_alert_count, storm_summary,
+		       resolution_type, root_cause_category, merged_into, priority
+		FROM incidents
+		WHERE created_at >= $1 AND created_at < $2
+		ORDER BY created_at, id
+	`
+	return s.queryIncidents(ctx, query, from, to)
+}
 
-	rows, err := s.db.QueryContext(ctx, query)
+// queryIncidents runs a query selecting incident columns in the order used
+// by ListIncidents and loads the incidents' alert IDs
+func (s *PostgresStore) queryIncidents(ctx context.Context, query string, args ...interface{}) ([]*models.Incident, error) {
+	rows, err := s.conn.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -304,12 +391,13 @@ func (s *PostgresStore) ListIncidents() ([]*models.Incident, error) {
 	var incidents []*models.Incident
 	for rows.Next() {
 		var incident models.Incident
-		var labelsJSON []byte
+		var labelsJSON, stormJSON []byte
 
 		err := rows.Scan(
 			&incident.ID, &incident.Title, &incident.Description,
 			&incident.Status, &incident.Severity, &incident.CreatedAt, &incident.UpdatedAt,
-			&incident.AckedAt, &incident.ResolvedAt, &incident.AssigneeID, &labelsJSON,
+			&incident.AckedAt, &incident.ResolvedAt, &incident.AssigneeID, &labelsJSON, &incident.OverflowAlertCount, &stormJSON,
+			&incident.ResolutionType, &incident.RootCauseCategory, &incident.MergedInto, &incident.Priority,
 		)
 		if err != nil {
 			return nil, err
@@ -323,29 +411,22 @@ func (s *PostgresStore) ListIncidents() ([]*models.Incident, error) {
 		} else {
 			incident.Labels = make(map[string]string)
 		}
-
-		// Get associated alert IDs for each incident
-		alertQuery := `SELECT id FROM alerts WHERE incident_id = $1`
-		alertRows, err := s.db.QueryContext(ctx, alertQuery, incident.ID)
-		if err != nil {
+		if incident.StormSummary, err = unmarshalStormSummary(stormJSON); err != nil {
 			return nil, err
 		}
 
-		var alertIDs []string
-		for alertRows.Next() {
-			var alertID string
-			if err := alertRows.Scan(&alertID); err != nil {
-				alertRows.Close()
-				return nil, err
-			}
-			alertIDs = append(alertIDs, alertID)
-		}
-		alertRows.Close()
-		incident.AlertIDs = alertIDs
-
 		incidents = append(incidents, &incident)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	// A transaction's connection cannot start the alert query while these
+	// rows are still open
+	rows.Close()
 
+	if err := s.loadAlertIDs(ctx, incidents); err != nil {
+		return nil, err
+	}
 	return incidents, nil
 }
 
@@ -355,15 +436,21 @@ func (s *PostgresStore) CreateIncidentWithContext(ctx context.Context, incident
 	if err != nil {
 		return fmt.Errorf("failed to marshal labels: %w", err)
 	}
+	stormJSON, err := marshalStormSummary(incident.StormSummary)
+	if err != nil {
+		return err
+	}
 
 	query := `
-		INSERT INTO incidents (id, title, description, status, severity, created_at, updated_at, assignee_id, labels)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		INSERT INTO incidents (id, title, description, status, severity, created_at, updated_at, assignee_id, labels, overflow_alert_count, storm_summary,
+		                       resolution_type, root_cause_category, merged_into, priority)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
 	`
 
-	_, err = s.db.ExecContext(ctx, query,
+	_, err = s.conn.ExecContext(ctx, query,
 		incident.ID, incident.Title, incident.Description, incident.Status, incident.Severity,
-		incident.CreatedAt, incident.UpdatedAt, incident.AssigneeID, labelsJSON,
+		incident.CreatedAt, incident.UpdatedAt, incident.AssigneeID, labelsJSON, incident.OverflowAlertCount, stormJSON,
+		incident.ResolutionType, incident.RootCauseCategory, incident.MergedInto, incident.Priority,
 	)
 
 	return err
@@ -373,8 +460,8 @@ func (s *PostgresStore) CreateIncidentWithContext(ctx context.Context, incident
 
 // CreateIncident (old interface signature)
 // CreateIncident provides backward compatibility for the old Store interface
-func (s *PostgresStore) CreateIncident(incident *models.Incident) error {
-	return s.CreateIncidentWithContext(context.Background(), incident)
+func (s *PostgresStore) CreateIncident(ctx context.Context, incident *models.Incident) error {
+	return s.CreateIncidentWithContext(ctx, incident)
 }
 
 // UpdateIncident implements IncidentRepository.UpdateIncident
@@ -383,17 +470,24 @@ func (s *PostgresStore) UpdateIncidentWithContext(ctx context.Context, incident
 	if err != nil {
 		return fmt.Errorf("failed to marshal labels: %w", err)
 	}
+	stormJSON, err := marshalStormSummary(incident.StormSummary)
+	if err != nil {
+		return err
+	}
 
 	query := `
 		UPDATE incidents 
 		SET title = $2, description = $3, status = $4, severity = $5,
-		    updated_at = $6, acked_at = $7, resolved_at = $8, assignee_id = $9, labels = $10
+		    updated_at = $6, acked_at = $7, resolved_at = $8, assignee_id = $9, labels = $10,
+		    overflow_alert_count = $11, storm_summary = $12, resolution_type = $13, root_cause_category = $14,
+		    merged_into = $15, priority = $16
 		WHERE id = $1
 	`
 
-	result, err := s.db.ExecContext(ctx, query,
+	result, err := s.conn.ExecContext(ctx, query,
 		incident.ID, incident.Title, incident.Description, incident.Status, incident.Severity,
 		incident.UpdatedAt, incident.AckedAt, incident.ResolvedAt, incident.AssigneeID, labelsJSON,
+		incident.OverflowAlertCount, stormJSON, incident.ResolutionType, incident.RootCauseCategory, incident.MergedInto, incident.Priority,
 	)
 	if err != nil {
 		return err
@@ -412,14 +506,14 @@ func (s *PostgresStore) UpdateIncidentWithContext(ctx context.Context, incident
 
 // UpdateIncident (old interface signature - backward compatibility)
 // UpdateIncident provides backward compatibility for the old Store interface
-func (s *PostgresStore) UpdateIncident(incident *models.Incident) error {
-	return s.UpdateIncidentWithContext(context.Background(), incident)
+func (s *PostgresStore) UpdateIncident(ctx context.Context, incident *models.Incident) error {
+	return s.UpdateIncidentWithContext(ctx, incident)
 }
 
 // DeleteIncident implements IncidentRepository.DeleteIncident
 func (s *PostgresStore) DeleteIncidentWithContext(ctx context.Context, id string) error {
 	query := `DELETE FROM incidents WHERE id = $1`
-	result, err := s.db.ExecContext(ctx, query, id)
+	result, err := s.conn.ExecContext(ctx, query, id)
 	if err != nil {
 		return err
 	}
@@ -437,8 +531,8 @@ func (s *PostgresStore) DeleteIncidentWithContext(ctx context.Context, id string
 
 // DeleteIncident (old interface signature - backward compatibility)
 // DeleteIncident provides backward compatibility for the old Store interface
-func (s *PostgresStore) DeleteIncident(id string) error {
-	return s.DeleteIncidentWithContext(context.Background(), id)
+func (s *PostgresStore) DeleteIncident(ctx context.Context, id string) error {
+	return s.DeleteIncidentWithContext(ctx, id)
 }
 
 // CountIncidents implements IncidentRepository.CountIncidents
@@ -452,7 +546,7 @@ func (s *PostgresStore) CountIncidents(ctx context.Context, filter IncidentFilte
 	`
 
 	var count int
-	err := s.db.QueryRowContext(ctx, query, filter.Status, filter.Severity, filter.AssigneeID).Scan(&count)
+	err := s.conn.QueryRowContext(ctx, query, filter.Status, filter.Severity, filter.AssigneeID).Scan(&count)
 	if err != nil {
 		return 0, err
 	}
@@ -460,21 +554,63 @@ func (s *PostgresStore) CountIncidents(ctx context.Context, filter IncidentFilte
 	return count, nil
 }
 
+// CountIncidentsByStatusSeverity counts incidents per status and severity in
+// a single grouped query
+func (s *PostgresStore) CountIncidentsByStatusSeverity(ctx context.Context) (map[models.IncidentStatus]map[models.IncidentSeverity]int, error) {
+	query := `
+		SELECT status, severity, COUNT(*)
+		FROM incidents
+		GROUP BY status, severity
+	`
+
+	rows, err := s.conn.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[models.IncidentStatus]map[models.IncidentSeverity]int)
+	for rows.Next() {
+		var status models.IncidentStatus
+		var severity models.IncidentSeverity
+		var count int
+		if err := rows.Scan(&status, &severity, &count); err != nil {
+			return nil, err
+		}
+		if counts[status] == nil {
+			counts[status] = make(map[models.IncidentSeverity]int)
+		}
+		counts[status][severity] = count
+	}
+
+	return counts, rows.Err()
+}
+
 // Alert methods
 
 // GetByID implements AlertRepository.GetByID for alerts
 func (s *PostgresStore) GetAlertByID(ctx context.Context, id string) (*models.Alert, error) {
+	return s.getAlert(ctx, "WHERE id = $1", id)
+}
+
+// GetAlertByFingerprint returns the most recently created alert with the
+// given fingerprint
+func (s *PostgresStore) GetAlertByFingerprint(ctx context.Context, fingerprint string) (*models.Alert, error) {
+	return s.getAlert(ctx, "WHERE fingerprint = $1 ORDER BY created_at DESC LIMIT 1", fingerprint)
+}
+
+// getAlert loads the first alert selected by the query suffix
+func (s *PostgresStore) getAlert(ctx context.Context, suffix string, arg interface{}) (*models.Alert, error) {
 	query := `
 		SELECT id, fingerprint, status, starts_at, ends_at, labels, annotations, incident_id, created_at
 		FROM alerts
-		WHERE id = $1
-	`
+	` + suffix
 
 	var alert models.Alert
 	var labelsJSON, annotationsJSON []byte
 	var incidentID sql.NullString
 
-	err := s.db.QueryRowContext(ctx, query, id).Scan(
+	err := s.conn.QueryRowContext(ctx, query, arg).Scan(
 		&alert.ID, &alert.Fingerprint, &alert.Status, &alert.StartsAt, &alert.EndsAt,
 		&labelsJSON, &annotationsJSON, &incidentID, &alert.CreatedAt,
 	)
@@ -512,8 +648,8 @@ func (s *PostgresStore) GetAlertByID(ctx context.Context, id string) (*models.Al
 }
 
 // GetAlert provides backward compatibility
-func (s *PostgresStore) GetAlert(id string) (*models.Alert, error) {
-	return s.GetAlertByID(context.Background(), id)
+func (s *PostgresStore) GetAlert(ctx context.Context, id string) (*models.Alert, error) {
+	return s.GetAlertByID(ctx, id)
 }
 
 // ListAlerts implements AlertRepository.ListAlerts with filtering and pagination
@@ -553,9 +689,9 @@ func (s *PostgresStore) ListAlertsWithFilter(ctx context.Context, filter AlertFi
 
 	if filter.Limit > 0 {
 		if filter.Offset > 0 {
-			rows, err = s.db.QueryContext(ctx, query, filter.Status, filter.IncidentID, filter.Fingerprint, filter.Limit, filter.Offset)
+			rows, err = s.conn.QueryContext(ctx, query, filter.Status, filter.IncidentID, filter.Fingerprint, filter.Limit, filter.Offset)
 		} else {
-			rows, err = s.db.QueryContext(ctx, query, filter.Status, filter.IncidentID, filter.Fingerprint, filter.Limit)
+			rows, err = s.conn.QueryContext(ctx, query, filter.Status, filter.IncidentID, filter.Fingerprint, filter.Limit)
 		}
 	} else {
 		// Remove LIMIT clause if no limit specified
@@ -566,7 +702,7 @@ func (s *PostgresStore) ListAlertsWithFilter(ctx context.Context, filter AlertFi
 			  AND ($2::uuid IS NULL OR incident_id = $2::uuid)
 			  AND ($3::text IS NULL OR fingerprint = $3)
 			ORDER BY ` + orderBy + ` DESC`
-		rows, err = s.db.QueryContext(ctx, query, filter.Status, filter.IncidentID, filter.Fingerprint)
+		rows, err = s.conn.QueryContext(ctx, query, filter.Status, filter.IncidentID, filter.Fingerprint)
 	}
 
 	if err != nil {
@@ -617,15 +753,14 @@ func (s *PostgresStore) ListAlertsWithFilter(ctx context.Context, filter AlertFi
 }
 
 // ListAlerts (old interface signature - backward compatibility)
-func (s *PostgresStore) ListAlerts() ([]*models.Alert, error) {
-	ctx := context.Background()
+func (s *PostgresStore) ListAlerts(ctx context.Context) ([]*models.Alert, error) {
 	query := `
 		SELECT id, fingerprint, status, starts_at, ends_at, labels, annotations, incident_id, created_at
 		FROM alerts
 		ORDER BY created_at DESC
 	`
 
-	rows, err := s.db.QueryContext(ctx, query)
+	rows, err := s.conn.QueryContext(ctx, query)
 	if err != nil {
 		return nil, err
 	}
@@ -698,7 +833,7 @@ func (s *PostgresStore) CreateAlertWithContext(ctx context.Context, alert *model
 		incidentID = alert.IncidentID
 	}
 
-	_, err = s.db.ExecContext(ctx, query,
+	_, err = s.conn.ExecContext(ctx, query,
 		alert.ID, alert.Fingerprint, alert.Status, alert.StartsAt, alert.EndsAt,
 		labelsJSON, annotationsJSON, incidentID, alert.CreatedAt,
 	)
@@ -733,7 +868,7 @@ func (s *PostgresStore) UpdateAlertWithContext(ctx context.Context, alert *model
 		incidentID = alert.IncidentID
 	}
 
-	result, err := s.db.ExecContext(ctx, query,
+	result, err := s.conn.ExecContext(ctx, query,
 		alert.ID, alert.Fingerprint, alert.Status, alert.StartsAt, alert.EndsAt,
 		labelsJSON, annotationsJSON, incidentID,
 	)
@@ -755,7 +890,7 @@ func (s *PostgresStore) UpdateAlertWithContext(ctx context.Context, alert *model
 // DeleteAlert implements AlertRepository.DeleteAlert
 func (s *PostgresStore) DeleteAlertWithContext(ctx context.Context, id string) error {
 	query := `DELETE FROM alerts WHERE id = $1`
-	result, err := s.db.ExecContext(ctx, query, id)
+	result, err := s.conn.ExecContext(ctx, query, id)
 	if err != nil {
 		return err
 	}
@@ -782,7 +917,7 @@ func (s *PostgresStore) CountAlerts(ctx context.Context, filter AlertFilter) (in
 	`
 
 	var count int
-	err := s.db.QueryRowContext(ctx, query, filter.Status, filter.IncidentID, filter.Fingerprint).Scan(&count)
+	err := s.conn.QueryRowContext(ctx, query, filter.Status, filter.IncidentID, filter.Fingerprint).Scan(&count)
 	if err != nil {
 		return 0, err
 	}
@@ -793,99 +928,503 @@ func (s *PostgresStore) CountAlerts(ctx context.Context, filter AlertFilter) (in
 // Old interface backward compatibility methods for alerts
 
 // CreateAlert provides backward compatibility for the old Store interface
-func (s *PostgresStore) CreateAlert(alert *models.Alert) error {
-	return s.CreateAlertWithContext(context.Background(), alert)
+func (s *PostgresStore) CreateAlert(ctx context.Context, alert *models.Alert) error {
+	return s.CreateAlertWithContext(ctx, alert)
 }
 
 // UpdateAlert provides backward compatibility for the old Store interface
-func (s *PostgresStore) UpdateAlert(alert *models.Alert) error {
-	return s.UpdateAlertWithContext(context.Background(), alert)
+func (s *PostgresStore) UpdateAlert(ctx context.Context, alert *models.Alert) error {
+	return s.UpdateAlertWithContext(ctx, alert)
 }
 
 // DeleteAlert provides backward compatibility for the old Store interface
-func (s *PostgresStore) DeleteAlert(id string) error {
-	return s.DeleteAlertWithContext(context.Background(), id)
+func (s *PostgresStore) DeleteAlert(ctx context.Context, id string) error {
+	return s.DeleteAlertWithContext(ctx, id)
+}
+
+// AddOverflowAlert records that an incident absorbed a firing alert past
+// its alert cap, reporting false if it was already recorded
+func (s *PostgresStore) AddOverflowAlert(ctx context.Context, incidentID, fingerprint string) (bool, error) {
+	result, err := s.conn.ExecContext(ctx, `
+		INSERT INTO incident_overflow_alerts (incident_id, fingerprint)
+		VALUES ($1, $2)
+		ON CONFLICT (incident_id, fingerprint) DO NOTHING
+	`, incidentID, fingerprint)
+	if err != nil {
+		return false, err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rowsAffected > 0, nil
+}
+
+// RemoveOverflowAlert forgets a resolved overflow alert and returns the
+// incidents that had absorbed it
+func (s *PostgresStore) RemoveOverflowAlert(ctx context.Context, fingerprint string) ([]string, error) {
+	rows, err := s.conn.QueryContext(ctx, `
+		DELETE FROM incident_overflow_alerts
+		WHERE fingerprint = $1
+		RETURNING incident_id
+	`, fingerprint)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	incidentIDs := []string{}
+	for rows.Next() {
+		var incidentID string
+		if err := rows.Scan(&incidentID); err != nil {
+			return nil, err
+		}
+		incidentIDs = append(incidentIDs, incidentID)
+	}
+	return incidentIDs, rows.Err()
 }
 
 // Placeholder implementations for other entity types (to maintain interface compatibility)
 // These will be implemented in subsequent phases
 
-func (s *PostgresStore) GetNotificationChannel(id string) (*models.NotificationChannel, error) {
+func (s *PostgresStore) GetNotificationChannel(ctx context.Context, id string) (*models.NotificationChannel, error) {
 	return nil, fmt.Errorf("notification channels not yet implemented in postgres store")
 }
 
-func (s *PostgresStore) ListNotificationChannels() ([]*models.NotificationChannel, error) {
+func (s *PostgresStore) ListNotificationChannels(ctx context.Context) ([]*models.NotificationChannel, error) {
 	return nil, fmt.Errorf("notification channels not yet implemented in postgres store")
 }
 
-func (s *PostgresStore) CreateNotificationChannel(channel *models.NotificationChannel) error {
+func (s *PostgresStore) CreateNotificationChannel(ctx context.Context, channel *models.NotificationChannel) error {
 	return fmt.Errorf("notification channels not yet implemented in postgres store")
 }
 
-func (s *PostgresStore) UpdateNotificationChannel(channel *models.NotificationChannel) error {
+func (s *PostgresStore) UpdateNotificationChannel(ctx context.Context, channel *models.NotificationChannel) error {
 	return fmt.Errorf("notification channels not yet implemented in postgres store")
 }
 
-func (s *PostgresStore) DeleteNotificationChannel(id string) error {
+func (s *PostgresStore) DeleteNotificationChannel(ctx context.Context, id string) error {
 	return fmt.Errorf("notification channels not yet implemented in postgres store")
 }
 
-func (s *PostgresStore) GetEscalationPolicy(id string) (*models.EscalationPolicy, error) {
+// Notification history methods
+
+func (s *PostgresStore) CreateNotificationHistory(ctx context.Context, history *models.NotificationHistory) error {
+	if history.ID == "" {
+		history.ID = uuid.New().String()
+	}
+
+	query := `
+		INSERT INTO notification_history (id, incident_id, channel_id, template_id, type, channel, recipient,
+			subject, content, status, error_msg, error_chain, retry_count, scheduled_at, sent_at, delivered_at, created_at, updated_at)
+		VALUES ($1, NULLIF($2, ''), $3, NULLIF($4, ''), $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)
+	`
+
+	errorChainJSON, err := marshalErrorChain(history.ErrorChain)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.conn.ExecContext(ctx, query,
+		history.ID, history.IncidentID, history.ChannelID, history.TemplateID, history.Type, history.Channel,
+		history.Recipient, history.Subject, history.Content, history.Status, history.ErrorMsg, errorChainJSON, history.RetryCount,
+		history.ScheduledAt, history.SentAt, history.DeliveredAt, history.CreatedAt, history.UpdatedAt,
+	)
+	return err
+}
+
+func (s *PostgresStore) UpdateNotificationHistory(ctx context.Context, history *models.NotificationHistory) error {
+	query := `
+		UPDATE notification_history
+		SET template_id = NULLIF($2, ''), recipient = $3, subject = $4, content = $5, status = $6,
+			error_msg = $7, error_chain = $8, retry_count = $9, sent_at = $10, delivered_at = $11, updated_at = $12
+		WHERE id = $1
+	`
+
+	errorChainJSON, err := marshalErrorChain(history.ErrorChain)
+	if err != nil {
+		return err
+	}
+
+	result, err := s.conn.ExecContext(ctx, query,
+		history.ID, history.TemplateID, history.Recipient, history.Subject, history.Content, history.Status,
+		history.ErrorMsg, errorChainJSON, history.RetryCount, history.SentAt, history.DeliveredAt, history.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *PostgresStore) ListNotificationHistoryByChannel(ctx context.Context, channelID string, filter *models.NotificationHistoryFilter) ([]*models.NotificationHistory, int, error) {
+	if filter == nil {
+		filter = &models.NotificationHistoryFilter{}
+	}
+
+	conditions := []string{"channel_id = $1"}
+	args := []interface{}{channelID}
+	argIndex := 2
+
+	if filter.Status != "" {
+		conditions = append(conditions, fmt.Sprintf("status = $%d", argIndex))
+		args = append(args, string(filter.Status))
+		argIndex++
+	}
+	if filter.Since != nil {
+		conditions = append(conditions, fmt.Sprintf("created_at >= $%d", argIndex))
+		args = append(args, *filter.Since)
+		argIndex++
+	}
+	if filter.Until != nil {
+		conditions = append(conditions, fmt.Sprintf("created_at <= $%d", argIndex))
+		args = append(args, *filter.Until)
+		argIndex++
+	}
+
+	whereClause := "WHERE " + strings.Join(conditions, " AND ")
+
+	var total int
+	if err := s.conn.QueryRowContext(ctx, "SELECT COUNT(*) FROM notification_history "+whereClause, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	query := fmt.Sprintf(`
+		SELECT `+notificationHistoryColumns+`
+		FROM notification_history
+		%s
+		ORDER BY created_at DESC
+	`, whereClause)
+
+	if filter.Limit > 0 {
+		page := filter.Page
+		if page < 1 {
+			page = 1
+		}
+		query += fmt.Sprintf(" LIMIT $%d OFFSET $%d", argIndex, argIndex+1)
+		args = append(args, filter.Limit, (page-1)*filter.Limit)
+	}
+
+	rows, err := s.conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	history, err := scanNotificationHistoryRows(rows)
+	if err != nil {
+		return nil, 0, err
+	}
+	return history, total, nil
+}
+
+// ListNotificationHistory returns the notification attempts for an incident,
+// newest first
+func (s *PostgresStore) ListNotificationHistory(ctx context.Context, incidentID string) ([]*models.NotificationHistory, error) {
+	rows, err := s.conn.QueryContext(ctx, `
+		SELECT `+notificationHistoryColumns+`
+		FROM notification_history
+		WHERE incident_id = $1
+		ORDER BY created_at DESC
+	`, incidentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanNotificationHistoryRows(rows)
+}
+
+// GetNotificationHistory returns a single notification history entry
+func (s *PostgresStore) GetNotificationHistory(ctx context.Context, id string) (*models.NotificationHistory, error) {
+	rows, err := s.conn.QueryContext(ctx, `
+		SELECT `+notificationHistoryColumns+`
+		FROM notification_history
+		WHERE id = $1
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	history, err := scanNotificationHistoryRows(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(history) == 0 {
+		return nil, ErrNotFound
+	}
+	return history[0], nil
+}
+
+// ListNotificationHistoryByStatus returns every entry in the given status,
+// newest first
+func (s *PostgresStore) ListNotificationHistoryByStatus(ctx context.Context, status models.NotificationDeliveryStatus) ([]*models.NotificationHistory, error) {
+	rows, err := s.conn.QueryContext(ctx, `
+		SELECT `+notificationHistoryColumns+`
+		FROM notification_history
+		WHERE status = $1
+		ORDER BY created_at DESC
+	`, string(status))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanNotificationHistoryRows(rows)
+}
+
+// CountNotificationHistory counts the entries created in [from, to) by
+// channel type, notification type and status
+func (s *PostgresStore) CountNotificationHistory(ctx context.Context, from, to time.Time) ([]*models.NotificationDeliveryCount, error) {
+	rows, err := s.conn.QueryContext(ctx, `
+		SELECT channel, type, status, COUNT(*)
+		FROM notification_history
+		WHERE created_at >= $1 AND created_at < $2
+		GROUP BY channel, type, status
+		ORDER BY channel, type, status
+	`, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := []*models.NotificationDeliveryCount{}
+	for rows.Next() {
+		var count models.NotificationDeliveryCount
+		if err := rows.Scan(&count.ChannelType, &count.NotificationType, &count.Status, &count.Count); err != nil {
+			return nil, err
+		}
+		counts = append(counts, &count)
+	}
+	return counts, rows.Err()
+}
+
+// marshalStormSummary encodes an incident's storm summary for the
+// storm_summary column, which is NULL for incidents that are not storms
+func marshalStormSummary(summary *models.AlertStormSummary) ([]byte, error) {
+	if summary == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(summary)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal storm summary: %w", err)
+	}
+	return data, nil
+}
+
+// unmarshalStormSummary decodes the storm_summary column
+func unmarshalStormSummary(data []byte) (*models.AlertStormSummary, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+	var summary models.AlertStormSummary
+	if err := json.Unmarshal(data, &summary); err != nil {
+		return nil, fmt.Errorf("failed to parse storm summary: %w", err)
+	}
+	return &summary, nil
+}
+
+// marshalErrorChain encodes a history entry's per-attempt errors for the error_chain column
+func marshalErrorChain(chain []string) ([]byte, error) {
+	if chain == nil {
+		chain = []string{}
+	}
+	return json.Marshal(chain)
+}
+
+const notificationHistoryColumns = `id, COALESCE(incident_id, ''), channel_id, COALESCE(template_id, ''), type, channel,
+		       COALESCE(recipient, ''), COALESCE(subject, ''), COALESCE(content, ''), status,
+		       COALESCE(error_msg, ''), error_chain, retry_count, scheduled_at, sent_at, delivered_at, created_at, updated_at`
+
+// scanNotificationHistoryRows scans rows selected with notificationHistoryColumns
+func scanNotificationHistoryRows(rows *sql.Rows) ([]*models.NotificationHistory, error) {
+	history := []*models.NotificationHistory{}
+	for rows.Next() {
+		var entry models.NotificationHistory
+		var errorChainJSON []byte
+		if err := rows.Scan(
+			&entry.ID, &entry.IncidentID, &entry.ChannelID, &entry.TemplateID, &entry.Type, &entry.Channel,
+			&entry.Recipient, &entry.Subject, &entry.Content, &entry.Status,
+			&entry.ErrorMsg, &errorChainJSON, &entry.RetryCount, &entry.ScheduledAt, &entry.SentAt, &entry.DeliveredAt,
+			&entry.CreatedAt, &entry.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		if len(errorChainJSON) > 0 {
+			if err := json.Unmarshal(errorChainJSON, &entry.ErrorChain); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal error chain: %w", err)
+			}
+		}
+		history = append(history, &entry)
+	}
+	return history, rows.Err()
+}
+
+func (s *PostgresStore) GetEscalationPolicy(ctx context.Context, id string) (*models.EscalationPolicy, error) {
 	return nil, fmt.Errorf("escalation policies not yet implemented in postgres store")
 }
 
-func (s *PostgresStore) ListEscalationPolicies() ([]*models.EscalationPolicy, error) {
+func (s *PostgresStore) ListEscalationPolicies(ctx context.Context) ([]*models.EscalationPolicy, error) {
 	return nil, fmt.Errorf("escalation policies not yet implemented in postgres store")
 }
 
-func (s *PostgresStore) CreateEscalationPolicy(policy *models.EscalationPolicy) error {
+func (s *PostgresStore) CreateEscalationPolicy(ctx context.Context, policy *models.EscalationPolicy) error {
 	return fmt.Errorf("escalation policies not yet implemented in postgres store")
 }
 
-func (s *PostgresStore) UpdateEscalationPolicy(policy *models.EscalationPolicy) error {
+func (s *PostgresStore) UpdateEscalationPolicy(ctx context.Context, policy *models.EscalationPolicy) error {
 	return fmt.Errorf("escalation policies not yet implemented in postgres store")
 }
 
-func (s *PostgresStore) DeleteEscalationPolicy(id string) error {
+func (s *PostgresStore) DeleteEscalationPolicy(ctx context.Context, id string) error {
 	return fmt.Errorf("escalation policies not yet implemented in postgres store")
 }
 
-func (s *PostgresStore) GetOnCallSchedule(id string) (*models.OnCallSchedule, error) {
-	return nil, fmt.Errorf("on-call schedules not yet implemented in postgres store")
+// On-call schedule methods
+
+// scanOnCallSchedule scans a row of id, name, timezone and layers
+func scanOnCallSchedule(scanner interface{ Scan(...interface{}) error }) (*models.OnCallSchedule, error) {
+	var schedule models.OnCallSchedule
+	var layersJSON []byte
+	if err := scanner.Scan(&schedule.ID, &schedule.Name, &schedule.Timezone, &layersJSON); err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(layersJSON, &schedule.Layers); err != nil {
+		return nil, fmt.Errorf("failed to parse schedule layers: %w", err)
+	}
+	return &schedule, nil
+}
+
+func (s *PostgresStore) GetOnCallSchedule(ctx context.Context, id string) (*models.OnCallSchedule, error) {
+	row := s.conn.QueryRowContext(ctx, `SELECT id, name, timezone, layers FROM on_call_schedules WHERE id = $1`, id)
+	schedule, err := scanOnCallSchedule(row)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	return schedule, err
 }
 
-func (s *PostgresStore) ListOnCallSchedules() ([]*models.OnCallSchedule, error) {
-	return nil, fmt.Errorf("on-call schedules not yet implemented in postgres store")
+// ListOnCallSchedules returns all on-call schedules, oldest first
+func (s *PostgresStore) ListOnCallSchedules(ctx context.Context) ([]*models.OnCallSchedule, error) {
+	rows, err := s.conn.QueryContext(ctx, `SELECT id, name, timezone, layers FROM on_call_schedules ORDER BY created_at, id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	schedules := []*models.OnCallSchedule{}
+	for rows.Next() {
+		schedule, err := scanOnCallSchedule(rows)
+		if err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, schedule)
+	}
+	return schedules, rows.Err()
 }
 
-func (s *PostgresStore) CreateOnCallSchedule(schedule *models.OnCallSchedule) error {
-	return fmt.Errorf("on-call schedules not yet implemented in postgres store")
+func (s *PostgresStore) CreateOnCallSchedule(ctx context.Context, schedule *models.OnCallSchedule) error {
+	if schedule.ID == "" {
+		schedule.ID = uuid.New().String()
+	}
+
+	layersJSON, err := marshalScheduleLayers(schedule)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO on_call_schedules (id, name, timezone, layers)
+		VALUES ($1, $2, $3, $4)
+	`
+
+	_, err = s.conn.ExecContext(ctx, query, schedule.ID, schedule.Name, schedule.Timezone, layersJSON)
+	return err
+}
+
+func (s *PostgresStore) UpdateOnCallSchedule(ctx context.Context, schedule *models.OnCallSchedule) error {
+	layersJSON, err := marshalScheduleLayers(schedule)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE on_call_schedules
+		SET name = $2, timezone = $3, layers = $4, updated_at = NOW()
+		WHERE id = $1
+	`
+
+	result, err := s.conn.ExecContext(ctx, query, schedule.ID, schedule.Name, schedule.Timezone, layersJSON)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
 }
 
-func (s *PostgresStore) UpdateOnCallSchedule(schedule *models.OnCallSchedule) error {
-	return fmt.Errorf("on-call schedules not yet implemented in postgres store")
+func (s *PostgresStore) DeleteOnCallSchedule(ctx context.Context, id string) error {
+	result, err := s.conn.ExecContext(ctx, `DELETE FROM on_call_schedules WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
 }
 
-func (s *PostgresStore) DeleteOnCallSchedule(id string) error {
-	return fmt.Errorf("on-call schedules not yet implemented in postgres store")
+// marshalScheduleLayers encodes the layers column, storing a schedule without
+// layers as an empty array rather than null
+func marshalScheduleLayers(schedule *models.OnCallSchedule) ([]byte, error) {
+	layers := schedule.Layers
+	if layers == nil {
+		layers = []models.ScheduleLayer{}
+	}
+
+	layersJSON, err := json.Marshal(layers)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal schedule layers: %w", err)
+	}
+	return layersJSON, nil
 }
 
 // User Management Methods
 
-func (s *PostgresStore) GetUser(id string) (*models.User, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (s *PostgresStore) GetUser(ctx context.Context, id string) (*models.User, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
 	query := `
 		SELECT id, username, email, full_name, password_hash, is_active, 
-			   created_at, updated_at, last_login
+			   created_at, updated_at, last_login, totp_secret, two_factor_enabled
 		FROM users WHERE id = $1`
 
 	user := &models.User{}
-	err := s.db.QueryRowContext(ctx, query, id).Scan(
-		&user.ID, &user.Username, &user.Email, &user.FullName, 
-		&user.Password, &user.IsActive, &user.CreatedAt, 
-		&user.UpdatedAt, &user.LastLogin,
+	err := s.conn.QueryRowContext(ctx, query, id).Scan(
+		&user.ID, &user.Username, &user.Email, &user.FullName,
+		&user.Password, &user.IsActive, &user.CreatedAt,
+		&user.UpdatedAt, &user.LastLogin, &user.TOTPSecret, &user.TwoFactorEnabled,
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -895,7 +1434,7 @@ func (s *PostgresStore) GetUser(id string) (*models.User, error) {
 	}
 
 	// Load user roles
-	roles, err := s.GetUserRoles(id)
+	roles, err := s.GetUserRoles(ctx, id)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load user roles: %w", err)
 	}
@@ -904,20 +1443,20 @@ func (s *PostgresStore) GetUser(id string) (*models.User, error) {
 	return user, nil
 }
 
-func (s *PostgresStore) GetUserByUsername(username string) (*models.User, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (s *PostgresStore) GetUserByUsername(ctx context.Context, username string) (*models.User, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
 	query := `
 		SELECT id, username, email, full_name, password_hash, is_active, 
-			   created_at, updated_at, last_login
+			   created_at, updated_at, last_login, totp_secret, two_factor_enabled
 		FROM users WHERE username = $1`
 
 	user := &models.User{}
-	err := s.db.QueryRowContext(ctx, query, username).Scan(
-		&user.ID, &user.Username, &user.Email, &user.FullName, 
-		&user.Password, &user.IsActive, &user.CreatedAt, 
-		&user.UpdatedAt, &user.LastLogin,
+	err := s.conn.QueryRowContext(ctx, query, username).Scan(
+		&user.ID, &user.Username, &user.Email, &user.FullName,
+		&user.Password, &user.IsActive, &user.CreatedAt,
+		&user.UpdatedAt, &user.LastLogin, &user.TOTPSecret, &user.TwoFactorEnabled,
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -927,7 +1466,7 @@ func (s *PostgresStore) GetUserByUsername(username string) (*models.User, error)
 	}
 
 	// Load user roles
-	roles, err := s.GetUserRoles(user.ID)
+	roles, err := s.GetUserRoles(ctx, user.ID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load user roles: %w", err)
 	}
@@ -936,20 +1475,20 @@ func (s *PostgresStore) GetUserByUsername(username string) (*models.User, error)
 	return user, nil
 }
 
-func (s *PostgresStore) GetUserByEmail(email string) (*models.User, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (s *PostgresStore) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
 	query := `
 		SELECT id, username, email, full_name, password_hash, is_active, 
-			   created_at, updated_at, last_login
+			   created_at, updated_at, last_login, totp_secret, two_factor_enabled
 		FROM users WHERE email = $1`
 
 	user := &models.User{}
-	err := s.db.QueryRowContext(ctx, query, email).Scan(
-		&user.ID, &user.Username, &user.Email, &user.FullName, 
-		&user.Password, &user.IsActive, &user.CreatedAt, 
-		&user.UpdatedAt, &user.LastLogin,
+	err := s.conn.QueryRowContext(ctx, query, email).Scan(
+		&user.ID, &user.Username, &user.Email, &user.FullName,
+		&user.Password, &user.IsActive, &user.CreatedAt,
+		&user.UpdatedAt, &user.LastLogin, &user.TOTPSecret, &user.TwoFactorEnabled,
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -959,7 +1498,7 @@ func (s *PostgresStore) GetUserByEmail(email string) (*models.User, error) {
 	}
 
 	// Load user roles
-	roles, err := s.GetUserRoles(user.ID)
+	roles, err := s.GetUserRoles(ctx, user.ID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load user roles: %w", err)
 	}
@@ -968,16 +1507,16 @@ func (s *PostgresStore) GetUserByEmail(email string) (*models.User, error) {
 	return user, nil
 }
 
-func (s *PostgresStore) ListUsers() ([]*models.User, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+func (s *PostgresStore) ListUsers(ctx context.Context) ([]*models.User, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
 
 	query := `
 		SELECT id, username, email, full_name, password_hash, is_active, 
-			   created_at, updated_at, last_login
+			   created_at, updated_at, last_login, totp_secret, two_factor_enabled
 		FROM users ORDER BY created_at DESC`
 
-	rows, err := s.db.QueryContext(ctx, query)
+	rows, err := s.conn.QueryContext(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list users: %w", err)
 	}
@@ -989,14 +1528,14 @@ func (s *PostgresStore) ListUsers() ([]*models.User, error) {
 		err := rows.Scan(
 			&user.ID, &user.Username, &user.Email, &user.FullName,
 			&user.Password, &user.IsActive, &user.CreatedAt,
-			&user.UpdatedAt, &user.LastLogin,
+			&user.UpdatedAt, &user.LastLogin, &user.TOTPSecret, &user.TwoFactorEnabled,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan user: %w", err)
 		}
 
 		// Load user roles (this could be optimized with a join query)
-		roles, err := s.GetUserRoles(user.ID)
+		roles, err := s.GetUserRoles(ctx, user.ID)
 		if err != nil {
 			return nil, fmt.Errorf("failed to load user roles: %w", err)
 		}
@@ -1008,8 +1547,8 @@ func (s *PostgresStore) ListUsers() ([]*models.User, error) {
 	return users, nil
 }
 
-func (s *PostgresStore) CreateUser(user *models.User) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (s *PostgresStore) CreateUser(ctx context.Context, user *models.User) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
 	if user.ID == "" {
@@ -1019,7 +1558,7 @@ func (s *PostgresStore) CreateUser(user *models.User) error {
 			VALUES ($1, $2, $3, $4, $5, $6, $7)
 			RETURNING id`
 
-		err := s.db.QueryRowContext(ctx, query,
+		err := s.conn.QueryRowContext(ctx, query,
 			user.Username, user.Email, user.FullName, user.Password,
 			user.IsActive, user.CreatedAt, user.UpdatedAt,
 		).Scan(&user.ID)
@@ -1032,7 +1571,7 @@ func (s *PostgresStore) CreateUser(user *models.User) error {
 			INSERT INTO users (id, username, email, full_name, password_hash, is_active, created_at, updated_at)
 			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
 
-		_, err := s.db.ExecContext(ctx, query,
+		_, err := s.conn.ExecContext(ctx, query,
 			user.ID, user.Username, user.Email, user.FullName, user.Password,
 			user.IsActive, user.CreatedAt, user.UpdatedAt,
 		)
@@ -1045,19 +1584,21 @@ func (s *PostgresStore) CreateUser(user *models.User) error {
 	return nil
 }
 
-func (s *PostgresStore) UpdateUser(user *models.User) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (s *PostgresStore) UpdateUser(ctx context.Context, user *models.User) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
 	query := `
 		UPDATE users 
 		SET username = $2, email = $3, full_name = $4, password_hash = $5, 
-			is_active = $6, updated_at = $7, last_login = $8
+			is_active = $6, updated_at = $7, last_login = $8,
+			totp_secret = $9, two_factor_enabled = $10
 		WHERE id = $1`
 
-	result, err := s.db.ExecContext(ctx, query,
+	result, err := s.conn.ExecContext(ctx, query,
 		user.ID, user.Username, user.Email, user.FullName, user.Password,
 		user.IsActive, user.UpdatedAt, user.LastLogin,
+		user.TOTPSecret, user.TwoFactorEnabled,
 	)
 	if err != nil {
 		return fmt.Errorf("failed to update user: %w", err)
@@ -1074,12 +1615,12 @@ func (s *PostgresStore) UpdateUser(user *models.User) error {
 	return nil
 }
 
-func (s *PostgresStore) DeleteUser(id string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (s *PostgresStore) DeleteUser(ctx context.Context, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
 	query := `DELETE FROM users WHERE id = $1`
-	result, err := s.db.ExecContext(ctx, query, id)
+	result, err := s.conn.ExecContext(ctx, query, id)
 	if err != nil {
 		return fmt.Errorf("failed to delete user: %w", err)
 	}
@@ -1095,12 +1636,12 @@ func (s *PostgresStore) DeleteUser(id string) error {
 	return nil
 }
 
-func (s *PostgresStore) UpdateLastLogin(userID string, timestamp time.Time) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (s *PostgresStore) UpdateLastLogin(ctx context.Context, userID string, timestamp time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
 	query := `UPDATE users SET last_login = $2 WHERE id = $1`
-	result, err := s.db.ExecContext(ctx, query, userID, timestamp)
+	result, err := s.conn.ExecContext(ctx, query, userID, timestamp)
 	if err != nil {
 		return fmt.Errorf("failed to update last login: %w", err)
 	}
@@ -1118,8 +1659,8 @@ func (s *PostgresStore) UpdateLastLogin(userID string, timestamp time.Time) erro
 
 // Role Management Methods
 
-func (s *PostgresStore) GetRole(id string) (*models.Role, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (s *PostgresStore) GetRole(ctx context.Context, id string) (*models.Role, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
 	query := `
@@ -1127,7 +1668,7 @@ func (s *PostgresStore) GetRole(id string) (*models.Role, error) {
 		FROM roles WHERE id = $1`
 
 	role := &models.Role{}
-	err := s.db.QueryRowContext(ctx, query, id).Scan(
+	err := s.conn.QueryRowContext(ctx, query, id).Scan(
 		&role.ID, &role.Name, &role.DisplayName, &role.Description,
 		&role.CreatedAt, &role.UpdatedAt,
 	)
@@ -1139,7 +1680,7 @@ func (s *PostgresStore) GetRole(id string) (*models.Role, error) {
 	}
 
 	// Load role permissions
-	permissions, err := s.GetRolePermissions(id)
+	permissions, err := s.GetRolePermissions(ctx, id)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load role permissions: %w", err)
 	}
@@ -1148,8 +1689,8 @@ func (s *PostgresStore) GetRole(id string) (*models.Role, error) {
 	return role, nil
 }
 
-func (s *PostgresStore) GetRoleByName(name string) (*models.Role, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (s *PostgresStore) GetRoleByName(ctx context.Context, name string) (*models.Role, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
 	query := `
@@ -1157,7 +1698,7 @@ func (s *PostgresStore) GetRoleByName(name string) (*models.Role, error) {
 		FROM roles WHERE name = $1`
 
 	role := &models.Role{}
-	err := s.db.QueryRowContext(ctx, query, name).Scan(
+	err := s.conn.QueryRowContext(ctx, query, name).Scan(
 		&role.ID, &role.Name, &role.DisplayName, &role.Description,
 		&role.CreatedAt, &role.UpdatedAt,
 	)
@@ -1169,7 +1710,7 @@ func (s *PostgresStore) GetRoleByName(name string) (*models.Role, error) {
 	}
 
 	// Load role permissions
-	permissions, err := s.GetRolePermissions(role.ID)
+	permissions, err := s.GetRolePermissions(ctx, role.ID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load role permissions: %w", err)
 	}
@@ -1178,15 +1719,15 @@ func (s *PostgresStore) GetRoleByName(name string) (*models.Role, error) {
 	return role, nil
 }
 
-func (s *PostgresStore) ListRoles() ([]*models.Role, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+func (s *PostgresStore) ListRoles(ctx context.Context) ([]*models.Role, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
 
 	query := `
 		SELECT id, name, display_name, description, created_at, updated_at
 		FROM roles ORDER BY name`
 
-	rows, err := s.db.QueryContext(ctx, query)
+	rows, err := s.conn.QueryContext(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list roles: %w", err)
 	}
@@ -1204,7 +1745,7 @@ func (s *PostgresStore) ListRoles() ([]*models.Role, error) {
 		}
 
 		// Load role permissions
-		permissions, err := s.GetRolePermissions(role.ID)
+		permissions, err := s.GetRolePermissions(ctx, role.ID)
 		if err != nil {
 			return nil, fmt.Errorf("failed to load role permissions: %w", err)
 		}
@@ -1216,8 +1757,8 @@ func (s *PostgresStore) ListRoles() ([]*models.Role, error) {
 	return roles, nil
 }
 
-func (s *PostgresStore) CreateRole(role *models.Role) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (s *PostgresStore) CreateRole(ctx context.Context, role *models.Role) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
 	if role.ID == "" {
@@ -1226,7 +1767,7 @@ func (s *PostgresStore) CreateRole(role *models.Role) error {
 			VALUES ($1, $2, $3, $4, $5)
 			RETURNING id`
 
-		err := s.db.QueryRowContext(ctx, query,
+		err := s.conn.QueryRowContext(ctx, query,
 			role.Name, role.DisplayName, role.Description,
 			role.CreatedAt, role.UpdatedAt,
 		).Scan(&role.ID)
@@ -1239,7 +1780,7 @@ func (s *PostgresStore) CreateRole(role *models.Role) error {
 			INSERT INTO roles (id, name, display_name, description, created_at, updated_at)
 			VALUES ($1, $2, $3, $4, $5, $6)`
 
-		_, err := s.db.ExecContext(ctx, query,
+		_, err := s.conn.ExecContext(ctx, query,
 			role.ID, role.Name, role.DisplayName, role.Description,
 			role.CreatedAt, role.UpdatedAt,
 		)
@@ -1252,8 +1793,8 @@ func (s *PostgresStore) CreateRole(role *models.Role) error {
 	return nil
 }
 
-func (s *PostgresStore) UpdateRole(role *models.Role) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (s *PostgresStore) UpdateRole(ctx context.Context, role *models.Role) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
 	query := `
@@ -1261,7 +1802,7 @@ func (s *PostgresStore) UpdateRole(role *models.Role) error {
 		SET name = $2, display_name = $3, description = $4, updated_at = $5
 		WHERE id = $1`
 
-	result, err := s.db.ExecContext(ctx, query,
+	result, err := s.conn.ExecContext(ctx, query,
 		role.ID, role.Name, role.DisplayName, role.Description, role.UpdatedAt,
 	)
 	if err != nil {
@@ -1279,12 +1820,12 @@ func (s *PostgresStore) UpdateRole(role *models.Role) error {
 	return nil
 }
 
-func (s *PostgresStore) DeleteRole(id string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (s *PostgresStore) DeleteRole(ctx context.Context, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
 	query := `DELETE FROM roles WHERE id = $1`
-	result, err := s.db.ExecContext(ctx, query, id)
+	result, err := s.conn.ExecContext(ctx, query, id)
 	if err != nil {
 		return fmt.Errorf("failed to delete role: %w", err)
 	}
@@ -1302,8 +1843,8 @@ func (s *PostgresStore) DeleteRole(id string) error {
 
 // User-Role Association Methods
 
-func (s *PostgresStore) AssignRoleToUser(userID, roleID string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (s *PostgresStore) AssignRoleToUser(ctx context.Context, userID, roleID string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
 	query := `
@@ -1311,7 +1852,7 @@ func (s *PostgresStore) AssignRoleToUser(userID, roleID string) error {
 		VALUES ($1, $2, $3)
 		ON CONFLICT (user_id, role_id) DO NOTHING`
 
-	_, err := s.db.ExecContext(ctx, query, userID, roleID, time.Now())
+	_, err := s.conn.ExecContext(ctx, query, userID, roleID, time.Now())
 	if err != nil {
 		return fmt.Errorf("failed to assign role to user: %w", err)
 	}
@@ -1319,12 +1860,12 @@ func (s *PostgresStore) AssignRoleToUser(userID, roleID string) error {
 	return nil
 }
 
-func (s *PostgresStore) RemoveRoleFromUser(userID, roleID string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (s *PostgresStore) RemoveRoleFromUser(ctx context.Context, userID, roleID string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
 	query := `DELETE FROM user_roles WHERE user_id = $1 AND role_id = $2`
-	result, err := s.db.ExecContext(ctx, query, userID, roleID)
+	result, err := s.conn.ExecContext(ctx, query, userID, roleID)
 	if err != nil {
 		return fmt.Errorf("failed to remove role from user: %w", err)
 	}
@@ -1340,8 +1881,8 @@ func (s *PostgresStore) RemoveRoleFromUser(userID, roleID string) error {
 	return nil
 }
 
-func (s *PostgresStore) GetUserRoles(userID string) ([]*models.Role, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+func (s *PostgresStore) GetUserRoles(ctx context.Context, userID string) ([]*models.Role, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
 
 	query := `
@@ -1351,7 +1892,7 @@ func (s *PostgresStore) GetUserRoles(userID string) ([]*models.Role, error) {
 		WHERE ur.user_id = $1
 		ORDER BY r.name`
 
-	rows, err := s.db.QueryContext(ctx, query, userID)
+	rows, err := s.conn.QueryContext(ctx, query, userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user roles: %w", err)
 	}
@@ -1369,7 +1910,7 @@ func (s *PostgresStore) GetUserRoles(userID string) ([]*models.Role, error) {
 		}
 
 		// Load role permissions
-		permissions, err := s.GetRolePermissions(role.ID)
+		permissions, err := s.GetRolePermissions(ctx, role.ID)
 		if err != nil {
 			return nil, fmt.Errorf("failed to load role permissions: %w", err)
 		}
@@ -1383,8 +1924,8 @@ func (s *PostgresStore) GetUserRoles(userID string) ([]*models.Role, error) {
 
 // Permission Methods
 
-func (s *PostgresStore) GetPermission(id string) (*models.Permission, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (s *PostgresStore) GetPermission(ctx context.Context, id string) (*models.Permission, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
 	query := `
@@ -1392,7 +1933,7 @@ func (s *PostgresStore) GetPermission(id string) (*models.Permission, error) {
 		FROM permissions WHERE id = $1`
 
 	permission := &models.Permission{}
-	err := s.db.QueryRowContext(ctx, query, id).Scan(
+	err := s.conn.QueryRowContext(ctx, query, id).Scan(
 		&permission.ID, &permission.Name, &permission.Resource,
 		&permission.Action, &permission.Description,
 	)
@@ -1406,15 +1947,15 @@ func (s *PostgresStore) GetPermission(id string) (*models.Permission, error) {
 	return permission, nil
 }
 
-func (s *PostgresStore) ListPermissions() ([]*models.Permission, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+func (s *PostgresStore) ListPermissions(ctx context.Context) ([]*models.Permission, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
 
 	query := `
 		SELECT id, name, resource, action, description
 		FROM permissions ORDER BY resource, action`
 
-	rows, err := s.db.QueryContext(ctx, query)
+	rows, err := s.conn.QueryContext(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list permissions: %w", err)
 	}
@@ -1436,8 +1977,8 @@ func (s *PostgresStore) ListPermissions() ([]*models.Permission, error) {
 	return permissions, nil
 }
 
-func (s *PostgresStore) GetRolePermissions(roleID string) ([]*models.Permission, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+func (s *PostgresStore) GetRolePermissions(ctx context.Context, roleID string) ([]*models.Permission, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
 
 	query := `
@@ -1447,7 +1988,7 @@ func (s *PostgresStore) GetRolePermissions(roleID string) ([]*models.Permission,
 		WHERE rp.role_id = $1
 		ORDER BY p.resource, p.action`
 
-	rows, err := s.db.QueryContext(ctx, query, roleID)
+	rows, err := s.conn.QueryContext(ctx, query, roleID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get role permissions: %w", err)
 	}
@@ -1469,10 +2010,46 @@ func (s *PostgresStore) GetRolePermissions(roleID string) ([]*models.Permission,
 	return permissions, nil
 }
 
+func (s *PostgresStore) CreatePermission(ctx context.Context, permission *models.Permission) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	query := `
+		INSERT INTO permissions (name, resource, action, description)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id`
+
+	err := s.conn.QueryRowContext(ctx, query,
+		permission.Name, permission.Resource, permission.Action, permission.Description,
+	).Scan(&permission.ID)
+	if err != nil {
+		return fmt.Errorf("failed to create permission: %w", err)
+	}
+
+	return nil
+}
+
+func (s *PostgresStore) AssignPermissionToRole(ctx context.Context, roleID, permissionID string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	query := `
+		INSERT INTO role_permissions (role_id, permission_id)
+		VALUES ($1, $2)
+		ON CONFLICT (role_id, permission_id) DO NOTHING`
+
+	_, err := s.conn.ExecContext(ctx, query, roleID, permissionID)
+	if err != nil {
+		return fmt.Errorf("failed to assign permission to role: %w", err)
+	}
+
+	return nil
+}
+
 // User Activity Methods
 
-func (s *PostgresStore) LogUserActivity(activity *models.UserActivity) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (s *PostgresStore) LogUserActivity(ctx context.Context, activity *models.UserActivity) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
 	// Convert metadata to JSON
@@ -1487,7 +2064,7 @@ func (s *PostgresStore) LogUserActivity(activity *models.UserActivity) error {
 			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 			RETURNING id`
 
-		err := s.db.QueryRowContext(ctx, query,
+		err := s.conn.QueryRowContext(ctx, query,
 			activity.UserID, activity.Action, activity.Resource, activity.ResourceID,
 			activity.IPAddress, activity.UserAgent, metadataJSON, activity.CreatedAt,
 		).Scan(&activity.ID)
@@ -1500,7 +2077,7 @@ func (s *PostgresStore) LogUserActivity(activity *models.UserActivity) error {
 			INSERT INTO user_activities (id, user_id, action, resource, resource_id, ip_address, user_agent, metadata, created_at)
 			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
 
-		_, err := s.db.ExecContext(ctx, query,
+		_, err := s.conn.ExecContext(ctx, query,
 			activity.ID, activity.UserID, activity.Action, activity.Resource, activity.ResourceID,
 			activity.IPAddress, activity.UserAgent, metadataJSON, activity.CreatedAt,
 		)
@@ -1513,8 +2090,8 @@ func (s *PostgresStore) LogUserActivity(activity *models.UserActivity) error {
 	return nil
 }
 
-func (s *PostgresStore) GetUserActivities(userID string, limit int) ([]*models.UserActivity, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+func (s *PostgresStore) GetUserActivities(ctx context.Context, userID string, limit int) ([]*models.UserActivity, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
 
 	if limit <= 0 {
@@ -1528,10 +2105,36 @@ func (s *PostgresStore) GetUserActivities(userID string, limit int) ([]*models.U
 		ORDER BY created_at DESC 
 		LIMIT $2`
 
-	rows, err := s.db.QueryContext(ctx, query, userID, limit)
+	rows, err := s.conn.QueryContext(ctx, query, userID, limit)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user activities: %w", err)
 	}
+	return scanUserActivities(rows)
+}
+
+func (s *PostgresStore) ListRecentActivities(ctx context.Context, limit int) ([]*models.UserActivity, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	if limit <= 0 {
+		limit = 100
+	}
+
+	query := `
+		SELECT id, user_id, action, resource, resource_id, ip_address, user_agent, metadata, created_at
+		FROM user_activities
+		ORDER BY created_at DESC
+		LIMIT $1`
+
+	rows, err := s.conn.QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list recent activities: %w", err)
+	}
+	return scanUserActivities(rows)
+}
+
+// scanUserActivities reads user_activities rows selected in column order
+func scanUserActivities(rows *sql.Rows) ([]*models.UserActivity, error) {
 	defer rows.Close()
 
 	var activities []*models.UserActivity
@@ -1577,7 +2180,7 @@ func (s *PostgresStore) GetDBStats() sql.DBStats {
 
 // Enhanced Incident Features - Comments Implementation
 
-func (s *PostgresStore) CreateIncidentComment(comment *models.IncidentComment) error {
+func (s *PostgresStore) CreateIncidentComment(ctx context.Context, comment *models.IncidentComment) error {
 	query := `
 		INSERT INTO incident_comments (id, incident_id, user_id, content, comment_type, metadata, created_at)
 		VALUES ($1, $2, $3, $4, $5, $6, $7)
@@ -1588,24 +2191,24 @@ func (s *PostgresStore) CreateIncidentComment(comment *models.IncidentComment) e
 		return fmt.Errorf("failed to marshal metadata: %w", err)
 	}
 
-	_, err = s.db.Exec(query,
+	_, err = s.conn.ExecContext(ctx, query,
 		comment.ID, comment.IncidentID, comment.UserID, comment.Content,
 		comment.CommentType, metadataJSON, comment.CreatedAt,
 	)
 	return err
 }
 
-func (s *PostgresStore) GetIncidentComments(incidentID string) ([]*models.IncidentComment, error) {
+func (s *PostgresStore) GetIncidentComments(ctx context.Context, incidentID string) ([]*models.IncidentComment, error) {
 	query := `
 		SELECT c.id, c.incident_id, c.user_id, c.content, c.comment_type, c.metadata, c.created_at,
-		       u.username, u.full_name
+		       c.edited_at, u.username, u.full_name
 		FROM incident_comments c
 		LEFT JOIN users u ON c.user_id = u.id
 		WHERE c.incident_id = $1
 		ORDER BY c.created_at ASC
 	`
 
-	rows, err := s.db.Query(query, incidentID)
+	rows, err := s.conn.QueryContext(ctx, query, incidentID)
 	if err != nil {
 		return nil, err
 	}
@@ -1621,7 +2224,7 @@ func (s *PostgresStore) GetIncidentComments(incidentID string) ([]*models.Incide
 		err := rows.Scan(
 			&comment.ID, &comment.IncidentID, &comment.UserID, &comment.Content,
 			&comment.CommentType, &metadataJSON, &comment.CreatedAt,
-			&username, &fullName,
+			&comment.EditedAt, &username, &fullName,
 		)
 		if err != nil {
 			return nil, err
@@ -1647,28 +2250,76 @@ func (s *PostgresStore) GetIncidentComments(incidentID string) ([]*models.Incide
 	return comments, nil
 }
 
-func (s *PostgresStore) GetIncidentTimeline(incidentID string) ([]*models.IncidentComment, error) {
+func (s *PostgresStore) GetIncidentTimeline(ctx context.Context, incidentID string) ([]*models.IncidentComment, error) {
 	// For PostgreSQL, timeline is the same as comments (ordered by creation time)
-	return s.GetIncidentComments(incidentID)
+	return s.GetIncidentComments(ctx, incidentID)
+}
+
+func (s *PostgresStore) UpdateIncidentComment(ctx context.Context, comment *models.IncidentComment) error {
+	query := `
+		UPDATE incident_comments
+		SET content = $2, metadata = $3, edited_at = $4
+		WHERE id = $1
+	`
+
+	metadataJSON, err := json.Marshal(comment.Metadata)
+	if err != nil {
+		return fmt.Errorf("failed to marshal metadata: %w", err)
+	}
+
+	result, err := s.conn.ExecContext(ctx, query, comment.ID, comment.Content, metadataJSON, comment.EditedAt)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (s *PostgresStore) DeleteIncidentComment(ctx context.Context, id string) error {
+	query := `DELETE FROM incident_comments WHERE id = $1`
+	result, err := s.conn.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
 }
 
 // Enhanced Incident Features - Tags Implementation
 
-func (s *PostgresStore) CreateIncidentTag(tag *models.IncidentTag) error {
+func (s *PostgresStore) CreateIncidentTag(ctx context.Context, tag *models.IncidentTag) error {
 	query := `
 		INSERT INTO incident_tags (id, incident_id, tag_name, tag_value, color, created_by, created_at)
 		VALUES ($1, $2, $3, $4, $5, $6, $7)
 		ON CONFLICT (incident_id, tag_name, tag_value) DO NOTHING
 	`
 
-	_, err := s.db.Exec(query,
+	_, err := s.conn.ExecContext(ctx, query,
 		tag.ID, tag.IncidentID, tag.TagName, tag.TagValue,
 		tag.Color, tag.CreatedBy, tag.CreatedAt,
 	)
 	return err
 }
 
-func (s *PostgresStore) GetIncidentTags(incidentID string) ([]*models.IncidentTag, error) {
+func (s *PostgresStore) GetIncidentTags(ctx context.Context, incidentID string) ([]*models.IncidentTag, error) {
 	query := `
 		SELECT t.id, t.incident_id, t.tag_name, t.tag_value, t.color, t.created_by, t.created_at,
 		       u.username, u.full_name
@@ -1678,7 +2329,7 @@ func (s *PostgresStore) GetIncidentTags(incidentID string) ([]*models.IncidentTa
 		ORDER BY t.created_at ASC
 	`
 
-	rows, err := s.db.Query(query, incidentID)
+	rows, err := s.conn.QueryContext(ctx, query, incidentID)
 	if err != nil {
 		return nil, err
 	}
@@ -1712,9 +2363,9 @@ func (s *PostgresStore) GetIncidentTags(incidentID string) ([]*models.IncidentTa
 	return tags, nil
 }
 
-func (s *PostgresStore) DeleteIncidentTag(incidentID, tagName string) error {
+func (s *PostgresStore) DeleteIncidentTag(ctx context.Context, incidentID, tagName string) error {
 	query := `DELETE FROM incident_tags WHERE incident_id = $1 AND tag_name = $2`
-	result, err := s.db.Exec(query, incidentID, tagName)
+	result, err := s.conn.ExecContext(ctx, query, incidentID, tagName)
 	if err != nil {
 		return err
 	}
@@ -1733,11 +2384,11 @@ func (s *PostgresStore) DeleteIncidentTag(incidentID, tagName string) error {
 
 // Enhanced Incident Features - Templates Implementation
 
-func (s *PostgresStore) CreateIncidentTemplate(template *models.IncidentTemplate) error {
+func (s *PostgresStore) CreateIncidentTemplate(ctx context.Context, template *models.IncidentTemplate) error {
 	query := `
 		INSERT INTO incident_templates (id, name, description, title_template, description_template,
-			severity, default_tags, is_active, created_by, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+			severity, default_tags, is_active, created_by, created_at, updated_at, priority)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
 	`
 
 	defaultTagsJSON, err := json.Marshal(template.DefaultTags)
@@ -1745,19 +2396,19 @@ func (s *PostgresStore) CreateIncidentTemplate(template *models.IncidentTemplate
 		return fmt.Errorf("failed to marshal default tags: %w", err)
 	}
 
-	_, err = s.db.Exec(query,
+	_, err = s.conn.ExecContext(ctx, query,
 		template.ID, template.Name, template.Description,
 		template.TitleTemplate, template.DescriptionTemplate,
 		template.Severity, defaultTagsJSON, template.IsActive,
-		template.CreatedBy, template.CreatedAt, template.UpdatedAt,
+		template.CreatedBy, template.CreatedAt, template.UpdatedAt, template.Priority,
 	)
 	return err
 }
 
-func (s *PostgresStore) GetIncidentTemplate(id string) (*models.IncidentTemplate, error) {
+func (s *PostgresStore) GetIncidentTemplate(ctx context.Context, id string) (*models.IncidentTemplate, error) {
 	query := `
 		SELECT t.id, t.name, t.description, t.title_template, t.description_template,
-		       t.severity, t.default_tags, t.is_active, t.created_by, t.created_at, t.updated_at,
+		       t.severity, t.default_tags, t.is_active, t.created_by, t.created_at, t.updated_at, t.priority,
 		       u.username, u.full_name
 		FROM incident_templates t
 		LEFT JOIN users u ON t.created_by = u.id
@@ -1769,11 +2420,11 @@ func (s *PostgresStore) GetIncidentTemplate(id string) (*models.IncidentTemplate
 	var defaultTagsJSON []byte
 	var username, fullName sql.NullString
 
-	err := s.db.QueryRow(query, id).Scan(
+	err := s.conn.QueryRowContext(ctx, query, id).Scan(
 		&template.ID, &template.Name, &template.Description,
 		&template.TitleTemplate, &template.DescriptionTemplate,
 		&template.Severity, &defaultTagsJSON, &template.IsActive,
-		&template.CreatedBy, &template.CreatedAt, &template.UpdatedAt,
+		&template.CreatedBy, &template.CreatedAt, &template.UpdatedAt, &template.Priority,
 		&username, &fullName,
 	)
 
@@ -1801,10 +2452,10 @@ func (s *PostgresStore) GetIncidentTemplate(id string) (*models.IncidentTemplate
 	return &template, nil
 }
 
-func (s *PostgresStore) ListIncidentTemplates(activeOnly bool) ([]*models.IncidentTemplate, error) {
+func (s *PostgresStore) ListIncidentTemplates(ctx context.Context, activeOnly bool) ([]*models.IncidentTemplate, error) {
 	query := `
 		SELECT t.id, t.name, t.description, t.title_template, t.description_template,
-		       t.severity, t.default_tags, t.is_active, t.created_by, t.created_at, t.updated_at,
+		       t.severity, t.default_tags, t.is_active, t.created_by, t.created_at, t.updated_at, t.priority,
 		       u.username, u.full_name
 		FROM incident_templates t
 		LEFT JOIN users u ON t.created_by = u.id
@@ -1816,7 +2467,7 @@ func (s *PostgresStore) ListIncidentTemplates(activeOnly bool) ([]*models.Incide
 	
 	query += " ORDER BY t.created_at DESC"
 
-	rows, err := s.db.Query(query)
+	rows, err := s.conn.QueryContext(ctx, query)
 	if err != nil {
 		return nil, err
 	}
@@ -1833,7 +2484,7 @@ func (s *PostgresStore) ListIncidentTemplates(activeOnly bool) ([]*models.Incide
 			&template.ID, &template.Name, &template.Description,
 			&template.TitleTemplate, &template.DescriptionTemplate,
 			&template.Severity, &defaultTagsJSON, &template.IsActive,
-			&template.CreatedBy, &template.CreatedAt, &template.UpdatedAt,
+			&template.CreatedBy, &template.CreatedAt, &template.UpdatedAt, &template.Priority,
 			&username, &fullName,
 		)
 		if err != nil {
@@ -1860,11 +2511,11 @@ func (s *PostgresStore) ListIncidentTemplates(activeOnly bool) ([]*models.Incide
 	return templates, nil
 }
 
-func (s *PostgresStore) UpdateIncidentTemplate(template *models.IncidentTemplate) error {
+func (s *PostgresStore) UpdateIncidentTemplate(ctx context.Context, template *models.IncidentTemplate) error {
 	query := `
 		UPDATE incident_templates
 		SET name = $2, description = $3, title_template = $4, description_template = $5,
-		    severity = $6, default_tags = $7, is_active = $8, updated_at = $9
+		    severity = $6, default_tags = $7, is_active = $8, updated_at = $9, priority = $10
 		WHERE id = $1
 	`
 
@@ -1873,10 +2524,10 @@ func (s *PostgresStore) UpdateIncidentTemplate(template *models.IncidentTemplate
 		return fmt.Errorf("failed to marshal default tags: %w", err)
 	}
 
-	result, err := s.db.Exec(query,
+	result, err := s.conn.ExecContext(ctx, query,
 		template.ID, template.Name, template.Description,
 		template.TitleTemplate, template.DescriptionTemplate,
-		template.Severity, defaultTagsJSON, template.IsActive, template.UpdatedAt,
+		template.Severity, defaultTagsJSON, template.IsActive, template.UpdatedAt, template.Priority,
 	)
 	if err != nil {
 		return err
@@ -1894,9 +2545,9 @@ func (s *PostgresStore) UpdateIncidentTemplate(template *models.IncidentTemplate
 	return nil
 }
 
-func (s *PostgresStore) DeleteIncidentTemplate(id string) error {
+func (s *PostgresStore) DeleteIncidentTemplate(ctx context.Context, id string) error {
 	query := `DELETE FROM incident_templates WHERE id = $1`
-	result, err := s.db.Exec(query, id)
+	result, err := s.conn.ExecContext(ctx, query, id)
 	if err != nil {
 		return err
 	}
@@ -1915,14 +2566,14 @@ func (s *PostgresStore) DeleteIncidentTemplate(id string) error {
 
 // Enhanced Incident Features - Attachments Implementation
 
-func (s *PostgresStore) CreateIncidentAttachment(attachment *models.IncidentAttachment) error {
+func (s *PostgresStore) CreateIncidentAttachment(ctx context.Context, attachment *models.IncidentAttachment) error {
 	query := `
 		INSERT INTO incident_attachments (id, incident_id, file_name, original_name, file_size,
 			mime_type, file_path, attachment_type, uploaded_by, created_at)
 		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
 	`
 
-	_, err := s.db.Exec(query,
+	_, err := s.conn.ExecContext(ctx, query,
 		attachment.ID, attachment.IncidentID, attachment.FileName,
 		attachment.OriginalName, attachment.FileSize, attachment.MimeType,
 		attachment.FilePath, attachment.AttachmentType, attachment.UploadedBy,
@@ -1931,7 +2582,7 @@ func (s *PostgresStore) CreateIncidentAttachment(attachment *models.IncidentAtta
 	return err
 }
 
-func (s *PostgresStore) GetIncidentAttachments(incidentID string) ([]*models.IncidentAttachment, error) {
+func (s *PostgresStore) GetIncidentAttachments(ctx context.Context, incidentID string) ([]*models.IncidentAttachment, error) {
 	query := `
 		SELECT a.id, a.incident_id, a.file_name, a.original_name, a.file_size,
 		       a.mime_type, a.file_path, a.attachment_type, a.uploaded_by, a.created_at,
@@ -1942,7 +2593,7 @@ func (s *PostgresStore) GetIncidentAttachments(incidentID string) ([]*models.Inc
 		ORDER BY a.created_at DESC
 	`
 
-	rows, err := s.db.Query(query, incidentID)
+	rows, err := s.conn.QueryContext(ctx, query, incidentID)
 	if err != nil {
 		return nil, err
 	}
@@ -1977,9 +2628,34 @@ func (s *PostgresStore) GetIncidentAttachments(incidentID string) ([]*models.Inc
 	return attachments, nil
 }
 
-func (s *PostgresStore) DeleteIncidentAttachment(id string) error {
+func (s *PostgresStore) GetIncidentAttachment(ctx context.Context, id string) (*models.IncidentAttachment, error) {
+	query := `
+		SELECT id, incident_id, file_name, original_name, file_size,
+		       mime_type, file_path, attachment_type, uploaded_by, created_at
+		FROM incident_attachments
+		WHERE id = $1
+	`
+
+	var attachment models.IncidentAttachment
+	err := s.conn.QueryRowContext(ctx, query, id).Scan(
+		&attachment.ID, &attachment.IncidentID, &attachment.FileName,
+		&attachment.OriginalName, &attachment.FileSize, &attachment.MimeType,
+		&attachment.FilePath, &attachment.AttachmentType, &attachment.UploadedBy,
+		&attachment.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &attachment, nil
+}
+
+func (s *PostgresStore) DeleteIncidentAttachment(ctx context.Context, id string) error {
 	query := `DELETE FROM incident_attachments WHERE id = $1`
-	result, err := s.db.Exec(query, id)
+	result, err := s.conn.ExecContext(ctx, query, id)
 	if err != nil {
 		return err
 	}
@@ -1998,7 +2674,17 @@ func (s *PostgresStore) DeleteIncidentAttachment(id string) error {
 
 // Enhanced Incident Features - Search Implementation
 
-func (s *PostgresStore) SearchIncidents(req *models.IncidentSearchRequest) ([]*models.Incident, int, error) {
+// incidentOrderExpressions maps the sortable incident search fields to SQL.
+// Severity and status sort by rank rather than alphabetically.
+var incidentOrderExpressions = map[string]string{
+	"created_at": "created_at",
+	"updated_at": "updated_at",
+	"title":      "title",
+	"severity":   "CASE severity WHEN 'critical' THEN 4 WHEN 'high' THEN 3 WHEN 'medium' THEN 2 WHEN 'low' THEN 1 ELSE 0 END",
+	"status":     "CASE status WHEN 'open' THEN 1 WHEN 'acknowledged' THEN 2 WHEN 'resolved' THEN 3 ELSE 0 END",
+}
+
+func (s *PostgresStore) SearchIncidents(ctx context.Context, req *models.IncidentSearchRequest) ([]*models.Incident, int, error) {
 	// Build WHERE clause dynamically
 	var conditions []string
 	var args []interface{}
@@ -2033,6 +2719,17 @@ func (s *PostgresStore) SearchIncidents(req *models.IncidentSearchRequest) ([]*m
 		conditions = append(conditions, fmt.Sprintf("severity IN (%s)", strings.Join(severityPlaceholders, ",")))
 	}
 
+	// Priority filter
+	if len(req.Priority) > 0 {
+		priorityPlaceholders := make([]string, len(req.Priority))
+		for i, priority := range req.Priority {
+			priorityPlaceholders[i] = fmt.Sprintf("$%d", argIndex)
+			args = append(args, string(priority))
+			argIndex++
+		}
+		conditions = append(conditions, fmt.Sprintf("priority IN (%s)", strings.Join(priorityPlaceholders, ",")))
+	}
+
 	// Assignee filter
 	if req.AssigneeID != nil {
 		conditions = append(conditions, fmt.Sprintf("assignee_id = $%d", argIndex))
@@ -2053,20 +2750,56 @@ func (s *PostgresStore) SearchIncidents(req *models.IncidentSearchRequest) ([]*m
 		argIndex++
 	}
 
-	// Tag filter (using EXISTS with subquery)
-	if len(req.Tags) > 0 {
-		tagPlaceholders := make([]string, len(req.Tags))
-		for i, tag := range req.Tags {
-			tagPlaceholders[i] = fmt.Sprintf("$%d", argIndex)
-			args = append(args, tag)
+	// Needs-attention filter
+	if req.NeedsAttention != nil {
+		condition := fmt.Sprintf("(status = 'open' AND assignee_id IS NULL AND created_at < $%d)", argIndex)
+		if !*req.NeedsAttention {
+			condition = "NOT " + condition
+		}
+		conditions = append(conditions, condition)
+		args = append(args, req.AttentionCutoff)
+		argIndex++
+	}
+
+	// Resolution type filter
+	if len(req.ResolutionType) > 0 {
+		typePlaceholders := make([]string, len(req.ResolutionType))
+		for i, resolutionType := range req.ResolutionType {
+			typePlaceholders[i] = fmt.Sprintf("$%d", argIndex)
+			args = append(args, string(resolutionType))
+			argIndex++
+		}
+		conditions = append(conditions, fmt.Sprintf("resolution_type IN (%s)", strings.Join(typePlaceholders, ",")))
+	}
+
+	// Root cause category filter
+	if len(req.RootCauseCategory) > 0 {
+		categoryPlaceholders := make([]string, len(req.RootCauseCategory))
+		for i, category := range req.RootCauseCategory {
+			categoryPlaceholders[i] = fmt.Sprintf("$%d", argIndex)
+			args = append(args, category)
+			argIndex++
+		}
+		conditions = append(conditions, fmt.Sprintf("root_cause_category IN (%s)", strings.Join(categoryPlaceholders, ",")))
+	}
+
+	// Tag filters: every tag must be present, with the given value if any
+	for _, tag := range req.Tags {
+		name, value, hasValue := models.ParseTagFilter(tag)
+		condition := fmt.Sprintf("t.tag_name = $%d", argIndex)
+		args = append(args, name)
+		argIndex++
+		if hasValue {
+			condition += fmt.Sprintf(" AND t.tag_value = $%d", argIndex)
+			args = append(args, value)
 			argIndex++
 		}
 		conditions = append(conditions, fmt.Sprintf(`
 			EXISTS (
-				SELECT 1 FROM incident_tags t 
-				WHERE t.incident_id = incidents.id 
-				AND t.tag_name IN (%s)
-			)`, strings.Join(tagPlaceholders, ",")))
+				SELECT 1 FROM incident_tags t
+				WHERE t.incident_id = incidents.id
+				AND %s
+			)`, condition))
 	}
 
 	whereClause := ""
@@ -2077,19 +2810,19 @@ func (s *PostgresStore) SearchIncidents(req *models.IncidentSearchRequest) ([]*m
 	// Count total matching incidents
 	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM incidents %s", whereClause)
 	var total int
-	err := s.db.QueryRow(countQuery, args...).Scan(&total)
+	err := s.conn.QueryRowContext(ctx, countQuery, args...).Scan(&total)
 	if err != nil {
 		return nil, 0, err
 	}
 
-	// Build ORDER BY clause
-	orderBy := "created_at"
-	orderDir := "DESC"
-	if req.OrderBy != "" {
-		orderBy = req.OrderBy
+	// Build ORDER BY clause from known fields only, matching MemoryStore
+	orderBy, ok := incidentOrderExpressions[req.OrderBy]
+	if !ok {
+		orderBy = incidentOrderExpressions["created_at"]
 	}
-	if req.OrderDir != "" {
-		orderDir = strings.ToUpper(req.OrderDir)
+	orderDir := "DESC"
+	if strings.EqualFold(req.OrderDir, "asc") {
+		orderDir = "ASC"
 	}
 
 	// Calculate offset
@@ -2098,16 +2831,17 @@ func (s *PostgresStore) SearchIncidents(req *models.IncidentSearchRequest) ([]*m
 	// Build main query
 	query := fmt.Sprintf(`
 		SELECT id, title, description, status, severity, created_at, updated_at,
-		       acked_at, resolved_at, assignee_id, labels
+		       acked_at, resolved_at, assignee_id, labels, overflow_alert_count, storm_summary,
+		       resolution_type, root_cause_category, merged_into, priority
 		FROM incidents
 		%s
-		ORDER BY %s %s
+		ORDER BY %s %s, id ASC
 		LIMIT $%d OFFSET $%d
 	`, whereClause, orderBy, orderDir, argIndex, argIndex+1)
 
 	args = append(args, req.Limit, offset)
 
-	rows, err := s.db.Query(query, args...)
+	rows, err := s.conn.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -2116,12 +2850,13 @@ func (s *PostgresStore) SearchIncidents(req *models.IncidentSearchRequest) ([]*m
 	var incidents []*models.Incident
 	for rows.Next() {
 		var incident models.Incident
-		var labelsJSON []byte
+		var labelsJSON, stormJSON []byte
 
 		err := rows.Scan(
 			&incident.ID, &incident.Title, &incident.Description,
 			&incident.Status, &incident.Severity, &incident.CreatedAt, &incident.UpdatedAt,
-			&incident.AckedAt, &incident.ResolvedAt, &incident.AssigneeID, &labelsJSON,
+			&incident.AckedAt, &incident.ResolvedAt, &incident.AssigneeID, &labelsJSON, &incident.OverflowAlertCount, &stormJSON,
+			&incident.ResolutionType, &incident.RootCauseCategory, &incident.MergedInto, &incident.Priority,
 		)
 		if err != nil {
 			return nil, 0, err
@@ -2133,9 +2868,238 @@ func (s *PostgresStore) SearchIncidents(req *models.IncidentSearchRequest) ([]*m
 				return nil, 0, fmt.Errorf("failed to unmarshal labels: %w", err)
 			}
 		}
+		if incident.StormSummary, err = unmarshalStormSummary(stormJSON); err != nil {
+			return nil, 0, err
+		}
 
 		incidents = append(incidents, &incident)
 	}
 
 	return incidents, total, nil
 }
+
+// Comment draft methods
+
+// SaveCommentDraft creates or replaces the user's draft for an incident
+func (s *PostgresStore) SaveCommentDraft(ctx context.Context, draft *models.CommentDraft) error {
+	query := `
+		INSERT INTO incident_comment_drafts (incident_id, user_id, content, updated_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (incident_id, user_id)
+		DO UPDATE SET content = EXCLUDED.content, updated_at = EXCLUDED.updated_at
+	`
+
+	_, err := s.conn.ExecContext(ctx, query, draft.IncidentID, draft.UserID, draft.Content, draft.UpdatedAt)
+	return err
+}
+
+func (s *PostgresStore) GetCommentDraft(ctx context.Context, incidentID, userID string) (*models.CommentDraft, error) {
+	query := `
+		SELECT incident_id, user_id, content, updated_at
+		FROM incident_comment_drafts
+		WHERE incident_id = $1 AND user_id = $2
+	`
+
+	var draft models.CommentDraft
+	err := s.conn.QueryRowContext(ctx, query, incidentID, userID).Scan(&draft.IncidentID, &draft.UserID, &draft.Content, &draft.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &draft, nil
+}
+
+// DeleteCommentDraft removes the user's draft for an incident, if any
+func (s *PostgresStore) DeleteCommentDraft(ctx context.Context, incidentID, userID string) error {
+	_, err := s.conn.ExecContext(ctx, `DELETE FROM incident_comment_drafts WHERE incident_id = $1 AND user_id = $2`, incidentID, userID)
+	return err
+}
+
+// Webhook payload methods
+
+func (s *PostgresStore) CreateWebhookPayload(ctx context.Context, payload *models.WebhookPayload) error {
+	if payload.ID == "" {
+		payload.ID = uuid.New().String()
+	}
+
+	query := `
+		INSERT INTO webhook_payloads (id, source, payload, received_at)
+		VALUES ($1, $2, $3, $4)
+	`
+
+	_, err := s.conn.ExecContext(ctx, query, payload.ID, payload.Source, payload.Payload, payload.ReceivedAt)
+	return err
+}
+
+func (s *PostgresStore) GetWebhookPayload(ctx context.Context, id string) (*models.WebhookPayload, error) {
+	query := `SELECT id, source, payload, received_at FROM webhook_payloads WHERE id = $1`
+
+	var payload models.WebhookPayload
+	err := s.conn.QueryRowContext(ctx, query, id).Scan(&payload.ID, &payload.Source, &payload.Payload, &payload.ReceivedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &payload, nil
+}
+
+// DeleteWebhookPayloadsBefore removes payloads received before cutoff and
+// returns how many were removed
+func (s *PostgresStore) DeleteWebhookPayloadsBefore(ctx context.Context, cutoff time.Time) (int, error) {
+	result, err := s.conn.ExecContext(ctx, `DELETE FROM webhook_payloads WHERE received_at < $1`, cutoff)
+	if err != nil {
+		return 0, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return int(rowsAffected), nil
+}
+
+// Lifecycle webhook methods
+
+const lifecycleWebhookColumns = `id, name, url, enabled, severities, labels, COALESCE(last_status, ''), COALESCE(last_error, ''),
+		last_attempt_at, delivered_count, failed_count, created_at, updated_at`
+
+// scanLifecycleWebhook scans a row selected with lifecycleWebhookColumns
+func scanLifecycleWebhook(scanner interface{ Scan(...interface{}) error }) (*models.LifecycleWebhook, error) {
+	var webhook models.LifecycleWebhook
+	var severitiesJSON, labelsJSON []byte
+	err := scanner.Scan(&webhook.ID, &webhook.Name, &webhook.URL, &webhook.Enabled, &severitiesJSON, &labelsJSON,
+		&webhook.LastStatus, &webhook.LastError, &webhook.LastAttemptAt, &webhook.DeliveredCount, &webhook.FailedCount,
+		&webhook.CreatedAt, &webhook.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(severitiesJSON, &webhook.Severities); err != nil {
+		return nil, fmt.Errorf("failed to parse severities: %w", err)
+	}
+	if err := json.Unmarshal(labelsJSON, &webhook.Labels); err != nil {
+		return nil, fmt.Errorf("failed to parse labels: %w", err)
+	}
+	return &webhook, nil
+}
+
+func (s *PostgresStore) GetLifecycleWebhook(ctx context.Context, id string) (*models.LifecycleWebhook, error) {
+	row := s.conn.QueryRowContext(ctx, `SELECT `+lifecycleWebhookColumns+` FROM lifecycle_webhooks WHERE id = $1`, id)
+	webhook, err := scanLifecycleWebhook(row)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	return webhook, err
+}
+
+// ListLifecycleWebhooks returns all lifecycle webhooks, oldest first
+func (s *PostgresStore) ListLifecycleWebhooks(ctx context.Context) ([]*models.LifecycleWebhook, error) {
+	rows, err := s.conn.QueryContext(ctx, `SELECT `+lifecycleWebhookColumns+` FROM lifecycle_webhooks ORDER BY created_at`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	webhooks := []*models.LifecycleWebhook{}
+	for rows.Next() {
+		webhook, err := scanLifecycleWebhook(rows)
+		if err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, webhook)
+	}
+	return webhooks, rows.Err()
+}
+
+func (s *PostgresStore) CreateLifecycleWebhook(ctx context.Context, webhook *models.LifecycleWebhook) error {
+	if webhook.ID == "" {
+		webhook.ID = uuid.New().String()
+	}
+
+	severitiesJSON, labelsJSON, err := marshalLifecycleWebhookFilters(webhook)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO lifecycle_webhooks (id, name, url, enabled, severities, labels, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+
+	_, err = s.conn.ExecContext(ctx, query, webhook.ID, webhook.Name, webhook.URL, webhook.Enabled, severitiesJSON, labelsJSON,
+		webhook.CreatedAt, webhook.UpdatedAt)
+	return err
+}
+
+func (s *PostgresStore) UpdateLifecycleWebhook(ctx context.Context, webhook *models.LifecycleWebhook) error {
+	severitiesJSON, labelsJSON, err := marshalLifecycleWebhookFilters(webhook)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE lifecycle_webhooks
+		SET name = $2, url = $3, enabled = $4, severities = $5, labels = $6, last_status = NULLIF($7, ''),
+			last_error = NULLIF($8, ''), last_attempt_at = $9, delivered_count = $10, failed_count = $11, updated_at = $12
+		WHERE id = $1
+	`
+
+	result, err := s.conn.ExecContext(ctx, query, webhook.ID, webhook.Name, webhook.URL, webhook.Enabled, severitiesJSON, labelsJSON,
+		webhook.LastStatus, webhook.LastError, webhook.LastAttemptAt, webhook.DeliveredCount, webhook.FailedCount,
+		webhook.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *PostgresStore) DeleteLifecycleWebhook(ctx context.Context, id string) error {
+	result, err := s.conn.ExecContext(ctx, `DELETE FROM lifecycle_webhooks WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// marshalLifecycleWebhookFilters encodes the JSONB filter columns, storing
+// empty filters as an empty array/object rather than null
+func marshalLifecycleWebhookFilters(webhook *models.LifecycleWebhook) ([]byte, []byte, error) {
+	severities := webhook.Severities
+	if severities == nil {
+		severities = []models.IncidentSeverity{}
+	}
+	labels := webhook.Labels
+	if labels == nil {
+		labels = map[string]string{}
+	}
+
+	severitiesJSON, err := json.Marshal(severities)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal severities: %w", err)
+	}
+	labelsJSON, err := json.Marshal(labels)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal labels: %w", err)
+	}
+	return severitiesJSON, labelsJSON, nil
+}
@@ -1,9 +1,11 @@
 package storage
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
@@ -40,6 +42,7 @@ func setupTestDB(t *testing.T) (*PostgresStore, func()) {
 		// Clean up test data
 		store.db.Exec("DELETE FROM alerts")
 		store.db.Exec("DELETE FROM incidents")
+		store.db.Exec("DELETE FROM on_call_schedules")
 		store.Close()
 	}
 
@@ -48,6 +51,7 @@ func setupTestDB(t *testing.T) (*PostgresStore, func()) {
 
 // TestPostgresStore_IncidentCRUD tests complete CRUD operations for incidents
 func TestPostgresStore_IncidentCRUD(t *testing.T) {
+	ctx := context.Background()
 	store, cleanup := setupTestDB(t)
 	defer cleanup()
 
@@ -66,13 +70,13 @@ func TestPostgresStore_IncidentCRUD(t *testing.T) {
 	}
 
 	// Test Create
-	err := store.CreateIncident(incident)
+	err := store.CreateIncident(ctx, incident)
 	if err != nil {
 		t.Fatalf("Failed to create incident: %v", err)
 	}
 
 	// Test GetIncident
-	retrieved, err := store.GetIncident(incident.ID)
+	retrieved, err := store.GetIncident(ctx, incident.ID)
 	if err != nil {
 		t.Fatalf("Failed to get incident: %v", err)
 	}
@@ -100,13 +104,13 @@ func TestPostgresStore_IncidentCRUD(t *testing.T) {
 	ackedTime := time.Now()
 	retrieved.AckedAt = &ackedTime
 
-	err = store.UpdateIncident(retrieved)
+	err = store.UpdateIncident(ctx, retrieved)
 	if err != nil {
 		t.Fatalf("Failed to update incident: %v", err)
 	}
 
 	// Verify update
-	updated, err := store.GetIncident(incident.ID)
+	updated, err := store.GetIncident(ctx, incident.ID)
 	if err != nil {
 		t.Fatalf("Failed to get updated incident: %v", err)
 	}
@@ -118,7 +122,7 @@ func TestPostgresStore_IncidentCRUD(t *testing.T) {
 	}
 
 	// Test ListIncidents
-	incidents, err := store.ListIncidents()
+	incidents, err := store.ListIncidents(ctx)
 	if err != nil {
 		t.Fatalf("Failed to list incidents: %v", err)
 	}
@@ -127,13 +131,13 @@ func TestPostgresStore_IncidentCRUD(t *testing.T) {
 	}
 
 	// Test Delete
-	err = store.DeleteIncident(incident.ID)
+	err = store.DeleteIncident(ctx, incident.ID)
 	if err != nil {
 		t.Fatalf("Failed to delete incident: %v", err)
 	}
 
 	// Verify deletion
-	_, err = store.GetIncident(incident.ID)
+	_, err = store.GetIncident(ctx, incident.ID)
 	if err != ErrNotFound {
 		t.Errorf("Expected ErrNotFound after deletion, got %v", err)
 	}
@@ -141,6 +145,7 @@ func TestPostgresStore_IncidentCRUD(t *testing.T) {
 
 // TestPostgresStore_AlertCRUD tests complete CRUD operations for alerts
 func TestPostgresStore_AlertCRUD(t *testing.T) {
+	ctx := context.Background()
 	store, cleanup := setupTestDB(t)
 	defer cleanup()
 
@@ -156,7 +161,7 @@ func TestPostgresStore_AlertCRUD(t *testing.T) {
 		Labels:      make(map[string]string),
 		AlertIDs:    []string{},
 	}
-	err := store.CreateIncident(incident)
+	err := store.CreateIncident(ctx, incident)
 	if err != nil {
 		t.Fatalf("Failed to create test incident: %v", err)
 	}
@@ -175,13 +180,13 @@ func TestPostgresStore_AlertCRUD(t *testing.T) {
 	}
 
 	// Test Create
-	err = store.CreateAlert(alert)
+	err = store.CreateAlert(ctx, alert)
 	if err != nil {
 		t.Fatalf("Failed to create alert: %v", err)
 	}
 
 	// Test GetAlert
-	retrieved, err := store.GetAlert(alert.ID)
+	retrieved, err := store.GetAlert(ctx, alert.ID)
 	if err != nil {
 		t.Fatalf("Failed to get alert: %v", err)
 	}
@@ -208,13 +213,13 @@ func TestPostgresStore_AlertCRUD(t *testing.T) {
 
 	// Test Update
 	retrieved.Status = "resolved"
-	err = store.UpdateAlert(retrieved)
+	err = store.UpdateAlert(ctx, retrieved)
 	if err != nil {
 		t.Fatalf("Failed to update alert: %v", err)
 	}
 
 	// Verify update
-	updated, err := store.GetAlert(alert.ID)
+	updated, err := store.GetAlert(ctx, alert.ID)
 	if err != nil {
 		t.Fatalf("Failed to get updated alert: %v", err)
 	}
@@ -223,7 +228,7 @@ func TestPostgresStore_AlertCRUD(t *testing.T) {
 	}
 
 	// Test ListAlerts
-	alerts, err := store.ListAlerts()
+	alerts, err := store.ListAlerts(ctx)
 	if err != nil {
 		t.Fatalf("Failed to list alerts: %v", err)
 	}
@@ -232,13 +237,13 @@ func TestPostgresStore_AlertCRUD(t *testing.T) {
 	}
 
 	// Test Delete
-	err = store.DeleteAlert(alert.ID)
+	err = store.DeleteAlert(ctx, alert.ID)
 	if err != nil {
 		t.Fatalf("Failed to delete alert: %v", err)
 	}
 
 	// Verify deletion
-	_, err = store.GetAlert(alert.ID)
+	_, err = store.GetAlert(ctx, alert.ID)
 	if err != ErrNotFound {
 		t.Errorf("Expected ErrNotFound after deletion, got %v", err)
 	}
@@ -246,6 +251,7 @@ func TestPostgresStore_AlertCRUD(t *testing.T) {
 
 // TestPostgresStore_IncidentAlertRelationship tests the foreign key relationship
 func TestPostgresStore_IncidentAlertRelationship(t *testing.T) {
+	ctx := context.Background()
 	store, cleanup := setupTestDB(t)
 	defer cleanup()
 
@@ -261,7 +267,7 @@ func TestPostgresStore_IncidentAlertRelationship(t *testing.T) {
 		Labels:      make(map[string]string),
 		AlertIDs:    []string{},
 	}
-	err := store.CreateIncident(incident)
+	err := store.CreateIncident(ctx, incident)
 	if err != nil {
 		t.Fatalf("Failed to create incident: %v", err)
 	}
@@ -291,18 +297,18 @@ func TestPostgresStore_IncidentAlertRelationship(t *testing.T) {
 		CreatedAt:   time.Now(),
 	}
 
-	err = store.CreateAlert(alert1)
+	err = store.CreateAlert(ctx, alert1)
 	if err != nil {
 		t.Fatalf("Failed to create alert1: %v", err)
 	}
 
-	err = store.CreateAlert(alert2)
+	err = store.CreateAlert(ctx, alert2)
 	if err != nil {
 		t.Fatalf("Failed to create alert2: %v", err)
 	}
 
 	// Retrieve incident and verify alert IDs are populated
-	retrievedIncident, err := store.GetIncident(incident.ID)
+	retrievedIncident, err := store.GetIncident(ctx, incident.ID)
 	if err != nil {
 		t.Fatalf("Failed to get incident: %v", err)
 	}
@@ -327,6 +333,7 @@ func TestPostgresStore_IncidentAlertRelationship(t *testing.T) {
 
 // TestPostgresStore_DatabaseConstraints tests database constraints
 func TestPostgresStore_DatabaseConstraints(t *testing.T) {
+	ctx := context.Background()
 	store, cleanup := setupTestDB(t)
 	defer cleanup()
 
@@ -354,18 +361,99 @@ func TestPostgresStore_DatabaseConstraints(t *testing.T) {
 	}
 
 	// First alert should succeed
-	err := store.CreateAlert(alert1)
+	err := store.CreateAlert(ctx, alert1)
 	if err != nil {
 		t.Fatalf("Failed to create first alert: %v", err)
 	}
 
 	// Second alert should fail due to unique constraint
-	err = store.CreateAlert(alert2)
+	err = store.CreateAlert(ctx, alert2)
 	if err == nil {
 		t.Error("Expected error due to unique fingerprint constraint, but got none")
 	}
 }
 
+// TestPostgresStore_OnCallScheduleCRUD tests that schedules, including their
+// timezone and layers, survive a round trip
+func TestPostgresStore_OnCallScheduleCRUD(t *testing.T) {
+	ctx := context.Background()
+	store, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	start := time.Date(2024, time.March, 4, 9, 0, 0, 0, time.FixedZone("EST", -5*60*60))
+	schedule := &models.OnCallSchedule{
+		ID:       "primary",
+		Name:     "Primary on-call",
+		Timezone: "America/New_York",
+		Layers: []models.ScheduleLayer{
+			{
+				Name:     "Weekdays",
+				Users:    []string{"user-alice", "user-bob"},
+				Rotation: models.RotationType{Type: "weekly", Length: 1, Handoff: "09:00"},
+				Start:    start,
+				Restrictions: []models.Restriction{
+					{Type: "weekly", StartTime: "09:00", EndTime: "17:00", StartDay: 1, EndDay: 5},
+				},
+			},
+			{
+				Name:     "Nights",
+				Users:    []string{"user-carol"},
+				Rotation: models.RotationType{Type: "daily", Length: 2},
+				Start:    start,
+				Restrictions: []models.Restriction{
+					{Type: "daily", StartTime: "22:00", EndTime: "06:00"},
+				},
+			},
+		},
+	}
+
+	if err := store.CreateOnCallSchedule(ctx, schedule); err != nil {
+		t.Fatalf("Failed to create schedule: %v", err)
+	}
+
+	retrieved, err := store.GetOnCallSchedule(ctx, schedule.ID)
+	if err != nil {
+		t.Fatalf("Failed to get schedule: %v", err)
+	}
+	if retrieved.Name != schedule.Name || retrieved.Timezone != schedule.Timezone {
+		t.Errorf("Expected %s in %s, got %s in %s", schedule.Name, schedule.Timezone, retrieved.Name, retrieved.Timezone)
+	}
+	if len(retrieved.Layers) != 2 {
+		t.Fatalf("Expected 2 layers, got %d", len(retrieved.Layers))
+	}
+	for i, layer := range retrieved.Layers {
+		want := schedule.Layers[i]
+		if layer.Name != want.Name || len(layer.Users) != len(want.Users) || layer.Rotation != want.Rotation {
+			t.Errorf("Layer %d: expected %+v, got %+v", i, want, layer)
+		}
+		if !layer.Start.Equal(want.Start) {
+			t.Errorf("Layer %d: expected start %v, got %v", i, want.Start, layer.Start)
+		}
+		if len(layer.Restrictions) != 1 || layer.Restrictions[0] != want.Restrictions[0] {
+			t.Errorf("Layer %d: expected restrictions %+v, got %+v", i, want.Restrictions, layer.Restrictions)
+		}
+	}
+
+	schedule.Layers = schedule.Layers[:1]
+	if err := store.UpdateOnCallSchedule(ctx, schedule); err != nil {
+		t.Fatalf("Failed to update schedule: %v", err)
+	}
+	schedules, err := store.ListOnCallSchedules(ctx)
+	if err != nil || len(schedules) != 1 || len(schedules[0].Layers) != 1 {
+		t.Fatalf("Expected one schedule with one layer, got %v (err: %v)", schedules, err)
+	}
+
+	if err := store.DeleteOnCallSchedule(ctx, schedule.ID); err != nil {
+		t.Fatalf("Failed to delete schedule: %v", err)
+	}
+	if _, err := store.GetOnCallSchedule(ctx, schedule.ID); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound after delete, got %v", err)
+	}
+	if err := store.UpdateOnCallSchedule(ctx, schedule); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound updating a deleted schedule, got %v", err)
+	}
+}
+
 // TestPostgresStore_Migration tests migration functionality
 func TestPostgresStore_Migration(t *testing.T) {
 	// Use a separate database URL for migration testing
@@ -423,3 +511,165 @@ func TestPostgresStore_Migration(t *testing.T) {
 		}
 	}
 }
+
+// TestPostgresStore_CancelledContextAbortsQuery checks that a query blocked
+// on a lock returns once the caller's context is done instead of waiting
+func TestPostgresStore_CancelledContextAbortsQuery(t *testing.T) {
+	store, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	// Hold an exclusive lock so that reads of incidents block until rollback
+	tx, err := store.db.Begin()
+	if err != nil {
+		t.Fatalf("Failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec("LOCK TABLE incidents IN ACCESS EXCLUSIVE MODE"); err != nil {
+		t.Fatalf("Failed to lock incidents: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	if _, err := store.ListIncidents(ctx); err == nil {
+		t.Fatal("Expected the blocked query to fail once the context was cancelled")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Expected the query to be aborted promptly, took %v", elapsed)
+	}
+	if ctx.Err() != context.DeadlineExceeded {
+		t.Errorf("Expected the context deadline to have passed, got %v", ctx.Err())
+	}
+}
+
+// TestPostgresStore_WithTxRollsBack checks that writes made in a WithTx
+// callback are kept only when the callback succeeds
+func TestPostgresStore_WithTxRollsBack(t *testing.T) {
+	ctx := context.Background()
+	store, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	newIncident := func(title string) *models.Incident {
+		return &models.Incident{
+			ID:        uuid.New().String(),
+			Title:     title,
+			Status:    models.IncidentStatusOpen,
+			Severity:  models.SeverityHigh,
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+			Labels:    map[string]string{},
+		}
+	}
+
+	// A tag name with spaces violates incident_tags_name_check
+	rolledBack := newIncident("Rolled back")
+	err := store.WithTx(ctx, func(tx Store) error {
+		if err := tx.CreateIncident(ctx, rolledBack); err != nil {
+			return err
+		}
+		return tx.CreateIncidentTag(ctx, &models.IncidentTag{
+			ID: uuid.New().String(), IncidentID: rolledBack.ID, TagName: "not a valid name", Color: "#ff0000", CreatedAt: time.Now(),
+		})
+	})
+	if err == nil {
+		t.Fatal("Expected the invalid tag to fail the transaction")
+	}
+	if _, err := store.GetIncident(ctx, rolledBack.ID); err != ErrNotFound {
+		t.Errorf("Expected the incident to be rolled back, got %v", err)
+	}
+
+	committed := newIncident("Committed")
+	err = store.WithTx(ctx, func(tx Store) error {
+		if err := tx.CreateIncident(ctx, committed); err != nil {
+			return err
+		}
+		return tx.CreateIncidentTag(ctx, &models.IncidentTag{
+			ID: uuid.New().String(), IncidentID: committed.ID, TagName: "database", Color: "#ff0000", CreatedAt: time.Now(),
+		})
+	})
+	if err != nil {
+		t.Fatalf("Failed to commit transaction: %v", err)
+	}
+	if tags, err := store.GetIncidentTags(ctx, committed.ID); err != nil || len(tags) != 1 {
+		t.Errorf("Expected the committed incident to have one tag, got %v (err: %v)", tags, err)
+	}
+}
+
+// countingQueryer counts the queries that read from the alerts table
+type countingQueryer struct {
+	queryer
+	alertQueries int
+}
+
+func (q *countingQueryer) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	if strings.Contains(query, "FROM alerts") {
+		q.alertQueries++
+	}
+	return q.queryer.QueryContext(ctx, query, args...)
+}
+
+// TestPostgresStore_ListIncidentsLoadsAlertIDsInOneQuery checks that listing
+// incidents reads their alert IDs with one query however many there are
+func TestPostgresStore_ListIncidentsLoadsAlertIDsInOneQuery(t *testing.T) {
+	ctx := context.Background()
+	store, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	wantAlerts := make(map[string]int)
+	for i := 0; i < 5; i++ {
+		incident := &models.Incident{
+			ID:        uuid.New().String(),
+			Title:     fmt.Sprintf("Incident %d", i),
+			Status:    models.IncidentStatusOpen,
+			Severity:  models.SeverityHigh,
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+			Labels:    map[string]string{},
+		}
+		if err := store.CreateIncident(ctx, incident); err != nil {
+			t.Fatalf("Failed to create incident: %v", err)
+		}
+		// Incident i has i alerts
+		for j := 0; j < i; j++ {
+			alert := &models.Alert{
+				ID:          uuid.New().String(),
+				Fingerprint: uuid.New().String(),
+				Status:      "firing",
+				StartsAt:    time.Now(),
+				CreatedAt:   time.Now(),
+				Labels:      map[string]string{},
+				Annotations: map[string]string{},
+				IncidentID:  incident.ID,
+			}
+			if err := store.CreateAlert(ctx, alert); err != nil {
+				t.Fatalf("Failed to create alert: %v", err)
+			}
+		}
+		wantAlerts[incident.ID] = i
+	}
+
+	counter := &countingQueryer{queryer: store.conn}
+	store.conn = counter
+	list := map[string]func() ([]*models.Incident, error){
+		"ListIncidents": func() ([]*models.Incident, error) { return store.ListIncidents(ctx) },
+		"ListIncidentsWithFilter": func() ([]*models.Incident, error) {
+			return store.ListIncidentsWithFilter(ctx, IncidentFilter{Limit: 10})
+		},
+	}
+	for name, fn := range list {
+		counter.alertQueries = 0
+		incidents, err := fn()
+		if err != nil {
+			t.Fatalf("%s failed: %v", name, err)
+		}
+		if counter.alertQueries != 1 {
+			t.Errorf("%s: expected 1 alert query for %d incidents, got %d", name, len(incidents), counter.alertQueries)
+		}
+		for _, incident := range incidents {
+			if want, ok := wantAlerts[incident.ID]; ok && len(incident.AlertIDs) != want {
+				t.Errorf("%s: expected %d alert IDs for %s, got %d", name, want, incident.Title, len(incident.AlertIDs))
+			}
+		}
+	}
+}
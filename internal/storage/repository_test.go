@@ -1,6 +1,7 @@
 package storage
 
 import (
+	"context"
 	"testing"
 	"time"
 
@@ -71,6 +72,7 @@ func TestRepositoryPattern(t *testing.T) {
 
 // TestMemoryStoreBasicOperations tests basic CRUD operations with memory store
 func TestMemoryStoreBasicOperations(t *testing.T) {
+	ctx := context.Background()
 	store, err := NewMemoryStore()
 	if err != nil {
 		t.Fatalf("Failed to create memory store: %v", err)
@@ -92,13 +94,13 @@ func TestMemoryStoreBasicOperations(t *testing.T) {
 	}
 
 	// Create incident
-	err = store.CreateIncident(incident)
+	err = store.CreateIncident(ctx, incident)
 	if err != nil {
 		t.Fatalf("Failed to create incident: %v", err)
 	}
 
 	// Get incident
-	retrieved, err := store.GetIncident(incident.ID)
+	retrieved, err := store.GetIncident(ctx, incident.ID)
 	if err != nil {
 		t.Fatalf("Failed to get incident: %v", err)
 	}
@@ -111,7 +113,7 @@ func TestMemoryStoreBasicOperations(t *testing.T) {
 	}
 
 	// List incidents
-	incidents, err := store.ListIncidents()
+	incidents, err := store.ListIncidents(ctx)
 	if err != nil {
 		t.Fatalf("Failed to list incidents: %v", err)
 	}
@@ -125,13 +127,13 @@ func TestMemoryStoreBasicOperations(t *testing.T) {
 	ackTime := time.Now()
 	incident.AckedAt = &ackTime
 
-	err = store.UpdateIncident(incident)
+	err = store.UpdateIncident(ctx, incident)
 	if err != nil {
 		t.Fatalf("Failed to update incident: %v", err)
 	}
 
 	// Get updated incident
-	updated, err := store.GetIncident(incident.ID)
+	updated, err := store.GetIncident(ctx, incident.ID)
 	if err != nil {
 		t.Fatalf("Failed to get updated incident: %v", err)
 	}
@@ -143,13 +145,13 @@ func TestMemoryStoreBasicOperations(t *testing.T) {
 	}
 
 	// Delete incident
-	err = store.DeleteIncident(incident.ID)
+	err = store.DeleteIncident(ctx, incident.ID)
 	if err != nil {
 		t.Fatalf("Failed to delete incident: %v", err)
 	}
 
 	// Verify deletion
-	_, err = store.GetIncident(incident.ID)
+	_, err = store.GetIncident(ctx, incident.ID)
 	if err != ErrNotFound {
 		t.Errorf("Expected ErrNotFound after deletion, got %v", err)
 	}
@@ -157,6 +159,7 @@ func TestMemoryStoreBasicOperations(t *testing.T) {
 
 // TestAlertOperations tests basic alert CRUD operations
 func TestAlertOperations(t *testing.T) {
+	ctx := context.Background()
 	store, err := NewMemoryStore()
 	if err != nil {
 		t.Fatalf("Failed to create memory store: %v", err)
@@ -174,13 +177,13 @@ func TestAlertOperations(t *testing.T) {
 	}
 
 	// Create alert
-	err = store.CreateAlert(alert)
+	err = store.CreateAlert(ctx, alert)
 	if err != nil {
 		t.Fatalf("Failed to create alert: %v", err)
 	}
 
 	// Get alert
-	retrieved, err := store.GetAlert(alert.ID)
+	retrieved, err := store.GetAlert(ctx, alert.ID)
 	if err != nil {
 		t.Fatalf("Failed to get alert: %v", err)
 	}
@@ -192,8 +195,20 @@ func TestAlertOperations(t *testing.T) {
 		t.Errorf("Expected Fingerprint=%s, got %s", alert.Fingerprint, retrieved.Fingerprint)
 	}
 
+	// Get alert by fingerprint
+	byFingerprint, err := store.GetAlertByFingerprint(ctx, alert.Fingerprint)
+	if err != nil {
+		t.Fatalf("Failed to get alert by fingerprint: %v", err)
+	}
+	if byFingerprint.ID != alert.ID {
+		t.Errorf("Expected ID=%s, got %s", alert.ID, byFingerprint.ID)
+	}
+	if _, err := store.GetAlertByFingerprint(ctx, "unknown-fingerprint"); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound for an unknown fingerprint, got %v", err)
+	}
+
 	// List alerts
-	alerts, err := store.ListAlerts()
+	alerts, err := store.ListAlerts(ctx)
 	if err != nil {
 		t.Fatalf("Failed to list alerts: %v", err)
 	}
@@ -206,13 +221,13 @@ func TestAlertOperations(t *testing.T) {
 	endTime := time.Now()
 	alert.EndsAt = endTime
 
-	err = store.UpdateAlert(alert)
+	err = store.UpdateAlert(ctx, alert)
 	if err != nil {
 		t.Fatalf("Failed to update alert: %v", err)
 	}
 
 	// Get updated alert
-	updated, err := store.GetAlert(alert.ID)
+	updated, err := store.GetAlert(ctx, alert.ID)
 	if err != nil {
 		t.Fatalf("Failed to get updated alert: %v", err)
 	}
@@ -221,13 +236,13 @@ func TestAlertOperations(t *testing.T) {
 	}
 
 	// Delete alert
-	err = store.DeleteAlert(alert.ID)
+	err = store.DeleteAlert(ctx, alert.ID)
 	if err != nil {
 		t.Fatalf("Failed to delete alert: %v", err)
 	}
 
 	// Verify deletion
-	_, err = store.GetAlert(alert.ID)
+	_, err = store.GetAlert(ctx, alert.ID)
 	if err != ErrNotFound {
 		t.Errorf("Expected ErrNotFound after deletion, got %v", err)
 	}
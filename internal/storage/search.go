@@ -0,0 +1,111 @@
+package storage
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/models"
+)
+
+// Incident text search
+//
+// PostgresStore matches incident search queries with
+// search_vector @@ plainto_tsquery('english', query), where search_vector
+// holds the title, description, assignee and label values. MemoryStore
+// approximates the same semantics:
+//
+//   - text is split into words at anything that is not a letter or digit and
+//     lowercased;
+//   - English stop words ("the", "is", "on", ...) are ignored in the query;
+//   - words are reduced to a stem, so "errors" finds "error" and "failing"
+//     finds "failed";
+//   - every remaining query word must occur somewhere in the searched fields,
+//     in any order. Partial words do not match: "data" does not find
+//     "database".
+//
+// The stemmer only strips common inflections (plurals, -ed, -ing, -ly), so
+// derivations the Postgres stemmer also conflates, such as "connection" and
+// "connect", match only in Postgres. Tags live in their own table and are
+// matched by neither store; use the tags filter instead.
+
+// englishStopWords are words Postgres' english configuration drops from
+// queries. A query made only of stop words matches everything.
+var englishStopWords = map[string]bool{
+	"a": true, "about": true, "after": true, "all": true, "an": true, "and": true,
+	"any": true, "are": true, "as": true, "at": true, "be": true, "been": true,
+	"before": true, "but": true, "by": true, "can": true, "did": true, "do": true,
+	"does": true, "for": true, "from": true, "had": true, "has": true, "have": true,
+	"he": true, "her": true, "his": true, "how": true, "i": true, "if": true,
+	"in": true, "into": true, "is": true, "it": true, "its": true, "no": true,
+	"not": true, "of": true, "off": true, "on": true, "or": true, "our": true,
+	"out": true, "over": true, "she": true, "so": true, "some": true, "than": true,
+	"that": true, "the": true, "their": true, "them": true, "then": true,
+	"there": true, "these": true, "they": true, "this": true, "to": true,
+	"up": true, "was": true, "we": true, "were": true, "what": true, "when": true,
+	"which": true, "while": true, "who": true, "will": true, "with": true,
+	"you": true, "your": true,
+}
+
+// searchWords splits text into lowercase words
+func searchWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// stemWord strips common English inflections from a lowercase word
+func stemWord(word string) string {
+	switch {
+	case len(word) > 4 && strings.HasSuffix(word, "ies"):
+		return word[:len(word)-3] + "y"
+	case len(word) > 4 && strings.HasSuffix(word, "sses"):
+		return word[:len(word)-2]
+	case len(word) > 5 && strings.HasSuffix(word, "ing"):
+		return word[:len(word)-3]
+	case len(word) > 4 && strings.HasSuffix(word, "ed"):
+		return word[:len(word)-2]
+	case len(word) > 4 && strings.HasSuffix(word, "ly"):
+		return word[:len(word)-2]
+	case len(word) > 3 && strings.HasSuffix(word, "s") &&
+		!strings.HasSuffix(word, "ss") && !strings.HasSuffix(word, "us") && !strings.HasSuffix(word, "is"):
+		return word[:len(word)-1]
+	}
+	return word
+}
+
+// searchQueryTerms returns the stemmed words of a query that must all match
+func searchQueryTerms(query string) []string {
+	var terms []string
+	for _, word := range searchWords(query) {
+		if !englishStopWords[word] {
+			terms = append(terms, stemWord(word))
+		}
+	}
+	return terms
+}
+
+// matchesSearchQuery reports whether every query term occurs in the
+// incident's title, description, assignee or label values
+func matchesSearchQuery(incident *models.Incident, terms []string) bool {
+	if len(terms) == 0 {
+		return true
+	}
+
+	fields := []string{incident.Title, incident.Description, incident.AssigneeID}
+	for _, value := range incident.Labels {
+		fields = append(fields, value)
+	}
+	document := make(map[string]bool)
+	for _, field := range fields {
+		for _, word := range searchWords(field) {
+			document[stemWord(word)] = true
+		}
+	}
+
+	for _, term := range terms {
+		if !document[term] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,280 @@
+package storage
+
+import (
+	"context"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/models"
+)
+
+// searchFixture is a fixed dataset both stores are searched over, keyed by a
+// short name used in expectations
+var searchFixture = map[string]models.Incident{
+	"db-errors": {
+		Title:       "Database connection errors",
+		Description: "Primary database is refusing connections",
+		Labels:      map[string]string{"service": "checkout"},
+	},
+	"latency": {
+		Title:       "Checkout latency",
+		Description: "Payments are slow after the deploy",
+	},
+	"disk": {
+		Title:       "Disk full on db-3",
+		Description: "The database disk filled up",
+	},
+	"login": {
+		Title:       "Login failures",
+		Description: "Users failing to log in",
+		Labels:      map[string]string{"team": "identity"},
+	},
+}
+
+// searchStores returns a memory store, plus a Postgres store when
+// TEST_DATABASE_URL is set, so search behaviour is checked on both
+func searchStores(t *testing.T) map[string]Store {
+	t.Helper()
+	memory, err := NewMemoryStore()
+	if err != nil {
+		t.Fatalf("Failed to create memory store: %v", err)
+	}
+	stores := map[string]Store{"memory": memory}
+	if os.Getenv("TEST_DATABASE_URL") != "" {
+		postgres, cleanup := setupTestDB(t)
+		t.Cleanup(cleanup)
+		stores["postgres"] = postgres
+	}
+	return stores
+}
+
+func TestSearchIncidents_MemoryMatchesPostgres(t *testing.T) {
+	ctx := context.Background()
+	stores := searchStores(t)
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{query: "database", want: []string{"db-errors", "disk"}},
+		{query: "the database", want: []string{"db-errors", "disk"}},
+		{query: "data", want: nil},
+		{query: "error", want: []string{"db-errors"}},
+		{query: "failing login", want: []string{"login"}},
+		{query: "Payments slow", want: []string{"latency"}},
+		{query: "checkout", want: []string{"db-errors", "latency"}},
+		{query: "checkout database", want: []string{"db-errors"}},
+		{query: "identity", want: []string{"login"}},
+		{query: "outage", want: nil},
+	}
+
+	for storeName, store := range stores {
+		names := make(map[string]string)
+		now := time.Now()
+		for name, fixture := range searchFixture {
+			incident := fixture
+			incident.ID = uuid.New().String()
+			incident.Status = models.IncidentStatusOpen
+			incident.Severity = models.SeverityMedium
+			incident.CreatedAt = now
+			incident.UpdatedAt = now
+			if err := store.CreateIncident(ctx, &incident); err != nil {
+				t.Fatalf("%s: failed to create incident: %v", storeName, err)
+			}
+			names[incident.ID] = name
+		}
+
+		for _, tt := range tests {
+			incidents, total, err := store.SearchIncidents(ctx, &models.IncidentSearchRequest{Query: tt.query, Page: 1, Limit: 50})
+			if err != nil {
+				t.Fatalf("%s: search %q failed: %v", storeName, tt.query, err)
+			}
+			var got []string
+			for _, incident := range incidents {
+				got = append(got, names[incident.ID])
+			}
+			sort.Strings(got)
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") || total != len(tt.want) {
+				t.Errorf("%s: search %q = %v (total %d), want %v", storeName, tt.query, got, total, tt.want)
+			}
+		}
+	}
+}
+
+func TestSearchQueryTerms(t *testing.T) {
+	got := searchQueryTerms("The failing DB-3 queries, on checkout")
+	want := []string{"fail", "db", "3", "query", "checkout"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("searchQueryTerms() = %v, want %v", got, want)
+	}
+}
+
+func TestMemoryStore_SearchIncidentsOrdering(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewMemoryStore()
+	if err != nil {
+		t.Fatalf("Failed to create memory store: %v", err)
+	}
+
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, incident := range []*models.Incident{
+		{ID: "a", Title: "Cache misses", Severity: models.SeverityMedium, Status: models.IncidentStatusResolved,
+			CreatedAt: base, UpdatedAt: base.Add(3 * time.Hour)},
+		{ID: "b", Title: "API errors", Severity: models.SeverityCritical, Status: models.IncidentStatusOpen,
+			CreatedAt: base.Add(time.Hour), UpdatedAt: base.Add(time.Hour)},
+		{ID: "c", Title: "Disk full", Severity: models.SeverityLow, Status: models.IncidentStatusAcknowledged,
+			CreatedAt: base.Add(2 * time.Hour), UpdatedAt: base.Add(4 * time.Hour)},
+		{ID: "d", Title: "Backup failed", Severity: models.SeverityHigh, Status: models.IncidentStatusOpen,
+			CreatedAt: base.Add(3 * time.Hour), UpdatedAt: base.Add(2 * time.Hour)},
+	} {
+		if err := store.CreateIncident(ctx, incident); err != nil {
+			t.Fatalf("Failed to create incident: %v", err)
+		}
+	}
+
+	tests := []struct {
+		orderBy string
+		asc     string
+		desc    string
+	}{
+		{orderBy: "created_at", asc: "a,b,c,d", desc: "d,c,b,a"},
+		{orderBy: "updated_at", asc: "b,d,a,c", desc: "c,a,d,b"},
+		{orderBy: "severity", asc: "c,a,d,b", desc: "b,d,a,c"},
+		// Ties keep ID order in both directions
+		{orderBy: "status", asc: "b,d,c,a", desc: "a,c,b,d"},
+		{orderBy: "title", asc: "b,d,a,c", desc: "c,a,d,b"},
+		{orderBy: "unknown", asc: "a,b,c,d", desc: "d,c,b,a"},
+	}
+
+	for _, tt := range tests {
+		for dir, want := range map[string]string{"asc": tt.asc, "desc": tt.desc} {
+			incidents, _, err := store.SearchIncidents(ctx, &models.IncidentSearchRequest{OrderBy: tt.orderBy, OrderDir: dir, Page: 1, Limit: 10})
+			if err != nil {
+				t.Fatalf("SearchIncidents failed: %v", err)
+			}
+			var got []string
+			for _, incident := range incidents {
+				got = append(got, incident.ID)
+			}
+			if strings.Join(got, ",") != want {
+				t.Errorf("order by %s %s = %v, want %s", tt.orderBy, dir, got, want)
+			}
+		}
+	}
+
+	incidents, _, _ := store.SearchIncidents(ctx, &models.IncidentSearchRequest{Page: 1, Limit: 10})
+	if incidents[0].ID != "d" {
+		t.Errorf("Expected newest first by default, got %s first", incidents[0].ID)
+	}
+}
+
+func TestSearchIncidents_TagFilters(t *testing.T) {
+	ctx := context.Background()
+	production, staging := "production", "staging"
+	tagged := map[string][]models.IncidentTag{
+		"prod-db":  {{TagName: "environment", TagValue: &production}, {TagName: "database"}},
+		"stage-db": {{TagName: "environment", TagValue: &staging}, {TagName: "database"}},
+		"prod-web": {{TagName: "environment", TagValue: &production}},
+		"untagged": nil,
+	}
+
+	tests := []struct {
+		tags []string
+		want []string
+	}{
+		{tags: []string{"environment"}, want: []string{"prod-db", "prod-web", "stage-db"}},
+		{tags: []string{"environment=production"}, want: []string{"prod-db", "prod-web"}},
+		{tags: []string{"environment=staging", "database"}, want: []string{"stage-db"}},
+		{tags: []string{"environment=qa"}, want: nil},
+		{tags: []string{"database=production"}, want: nil},
+	}
+
+	for storeName, store := range searchStores(t) {
+		names := make(map[string]string)
+		for name, tags := range tagged {
+			incident := &models.Incident{
+				ID:        uuid.New().String(),
+				Title:     name,
+				Status:    models.IncidentStatusOpen,
+				Severity:  models.SeverityLow,
+				CreatedAt: time.Now(),
+				UpdatedAt: time.Now(),
+			}
+			if err := store.CreateIncident(ctx, incident); err != nil {
+				t.Fatalf("%s: failed to create incident: %v", storeName, err)
+			}
+			names[incident.ID] = name
+			for _, tag := range tags {
+				tag := tag
+				tag.ID = uuid.New().String()
+				tag.IncidentID = incident.ID
+				tag.CreatedAt = time.Now()
+				if err := store.CreateIncidentTag(ctx, &tag); err != nil {
+					t.Fatalf("%s: failed to tag incident: %v", storeName, err)
+				}
+			}
+		}
+
+		for _, tt := range tests {
+			incidents, _, err := store.SearchIncidents(ctx, &models.IncidentSearchRequest{Tags: tt.tags, Page: 1, Limit: 50})
+			if err != nil {
+				t.Fatalf("%s: search %v failed: %v", storeName, tt.tags, err)
+			}
+			var got []string
+			for _, incident := range incidents {
+				got = append(got, names[incident.ID])
+			}
+			sort.Strings(got)
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("%s: tags %v = %v, want %v", storeName, tt.tags, got, tt.want)
+			}
+		}
+	}
+}
+
+func TestSearchIncidents_PriorityFilter(t *testing.T) {
+	ctx := context.Background()
+	for storeName, store := range searchStores(t) {
+		names := make(map[string]string)
+		for name, priority := range map[string]models.IncidentPriority{
+			"p1": models.PriorityP1, "p2": models.PriorityP2, "p4": models.PriorityP4,
+		} {
+			incident := &models.Incident{
+				ID:        uuid.New().String(),
+				Title:     name,
+				Status:    models.IncidentStatusOpen,
+				Severity:  models.SeverityLow,
+				Priority:  priority,
+				CreatedAt: time.Now(),
+				UpdatedAt: time.Now(),
+			}
+			if err := store.CreateIncident(ctx, incident); err != nil {
+				t.Fatalf("%s: failed to create incident: %v", storeName, err)
+			}
+			names[incident.ID] = name
+		}
+
+		for priorities, want := range map[string]string{"P1": "p1", "P1,P2": "p1,p2", "P3": ""} {
+			req := &models.IncidentSearchRequest{Page: 1, Limit: 50}
+			for _, priority := range strings.Split(priorities, ",") {
+				req.Priority = append(req.Priority, models.IncidentPriority(priority))
+			}
+			incidents, _, err := store.SearchIncidents(ctx, req)
+			if err != nil {
+				t.Fatalf("%s: search %s failed: %v", storeName, priorities, err)
+			}
+			var got []string
+			for _, incident := range incidents {
+				got = append(got, names[incident.ID])
+			}
+			sort.Strings(got)
+			if strings.Join(got, ",") != want {
+				t.Errorf("%s: priority %s = %v, want %s", storeName, priorities, got, want)
+			}
+		}
+	}
+}
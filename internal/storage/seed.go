@@ -0,0 +1,125 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/models"
+)
+
+// defaultPermissions is the canonical permission set. The names follow
+// the resource.action scheme of the permissions table.
+var defaultPermissions = []models.Permission{
+	{Name: "incidents.read", Resource: "incidents", Action: "read", Description: "View incidents"},
+	{Name: "incidents.create", Resource: "incidents", Action: "create", Description: "Create new incidents"},
+	{Name: "incidents.update", Resource: "incidents", Action: "update", Description: "Update incident details"},
+	{Name: "incidents.delete", Resource: "incidents", Action: "delete", Description: "Delete incidents"},
+	{Name: "incidents.acknowledge", Resource: "incidents", Action: "acknowledge", Description: "Acknowledge incidents"},
+	{Name: "incidents.resolve", Resource: "incidents", Action: "resolve", Description: "Resolve incidents"},
+	{Name: "incidents.assign", Resource: "incidents", Action: "assign", Description: "Assign incidents to users"},
+	{Name: "alerts.read", Resource: "alerts", Action: "read", Description: "View alerts"},
+	{Name: "alerts.update", Resource: "alerts", Action: "update", Description: "Update alert details"},
+	{Name: "alerts.delete", Resource: "alerts", Action: "delete", Description: "Delete alerts"},
+	{Name: "templates.create", Resource: "templates", Action: "create", Description: "Create incident templates"},
+	{Name: "templates.update", Resource: "templates", Action: "update", Description: "Edit incident templates"},
+	{Name: "templates.delete", Resource: "templates", Action: "delete", Description: "Delete incident templates"},
+	{Name: "users.read", Resource: "users", Action: "read", Description: "View users"},
+	{Name: "users.create", Resource: "users", Action: "create", Description: "Create new users"},
+	{Name: "users.update", Resource: "users", Action: "update", Description: "Update user details"},
+	{Name: "users.delete", Resource: "users", Action: "delete", Description: "Delete users"},
+	{Name: "users.manage_roles", Resource: "users", Action: "manage_roles", Description: "Assign roles to users"},
+	{Name: "roles.read", Resource: "roles", Action: "read", Description: "View roles"},
+	{Name: "roles.create", Resource: "roles", Action: "create", Description: "Create new roles"},
+	{Name: "roles.update", Resource: "roles", Action: "update", Description: "Update role details"},
+	{Name: "roles.delete", Resource: "roles", Action: "delete", Description: "Delete roles"},
+	{Name: "roles.manage_permissions", Resource: "roles", Action: "manage_permissions", Description: "Assign permissions to roles"},
+	{Name: "metrics.read", Resource: "metrics", Action: "read", Description: "View system metrics"},
+	{Name: "system.health", Resource: "system", Action: "health", Description: "View system health"},
+	{Name: "audit.read", Resource: "audit", Action: "read", Description: "View audit logs"},
+}
+
+// defaultRoles lists the baseline roles and the permissions each is granted.
+// A nil permission list grants every default permission.
+var defaultRoles = []struct {
+	role        models.Role
+	permissions []string
+}{
+	{
+		role: models.Role{Name: "admin", DisplayName: "Administrator", Description: "Full system access with all permissions"},
+	},
+	{
+		role: models.Role{Name: "responder", DisplayName: "Incident Responder", Description: "Can manage incidents and alerts"},
+		permissions: []string{
+			"incidents.read", "incidents.create", "incidents.update", "incidents.acknowledge",
+			"incidents.resolve", "incidents.assign", "alerts.read", "alerts.update",
+			"templates.create", "templates.update", "templates.delete", "metrics.read", "system.health",
+		},
+	},
+	{
+		role:        models.Role{Name: "viewer", DisplayName: "Viewer", Description: "Read-only access to incidents and alerts"},
+		permissions: []string{"incidents.read", "alerts.read", "metrics.read", "system.health"},
+	},
+}
+
+// SeedDefaultRolesAndPermissions creates the default permissions and any
+// missing admin, responder and viewer roles, granting each role it creates
+// its permissions. Roles that already exist keep whatever grants they have,
+// so permissions an operator revoked stay revoked and it is safe to run on
+// every startup.
+func SeedDefaultRolesAndPermissions(ctx context.Context, store Store) error {
+	existing, err := store.ListPermissions(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list permissions: %w", err)
+	}
+	permissionIDs := make(map[string]string, len(existing))
+	for _, permission := range existing {
+		permissionIDs[permission.Name] = permission.ID
+	}
+	for _, permission := range defaultPermissions {
+		if _, ok := permissionIDs[permission.Name]; ok {
+			continue
+		}
+		permission := permission
+		if err := store.CreatePermission(ctx, &permission); err != nil {
+			return fmt.Errorf("failed to create permission %s: %w", permission.Name, err)
+		}
+		permissionIDs[permission.Name] = permission.ID
+	}
+
+	for _, defaultRole := range defaultRoles {
+		role, err := store.GetRoleByName(ctx, defaultRole.role.Name)
+		if err == nil {
+			continue
+		}
+		if errors.Is(err, ErrNotFound) {
+			now := time.Now()
+			role = &models.Role{
+				Name:        defaultRole.role.Name,
+				DisplayName: defaultRole.role.DisplayName,
+				Description: defaultRole.role.Description,
+				CreatedAt:   now,
+				UpdatedAt:   now,
+			}
+			err = store.CreateRole(ctx, role)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to seed role %s: %w", defaultRole.role.Name, err)
+		}
+
+		granted := defaultRole.permissions
+		if granted == nil {
+			for _, permission := range defaultPermissions {
+				granted = append(granted, permission.Name)
+			}
+		}
+		for _, name := range granted {
+			if err := store.AssignPermissionToRole(ctx, role.ID, permissionIDs[name]); err != nil {
+				return fmt.Errorf("failed to grant %s to role %s: %w", name, role.Name, err)
+			}
+		}
+	}
+
+	return nil
+}
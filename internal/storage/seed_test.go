@@ -0,0 +1,96 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/tunghauvan-interspace/incd-mgnt-system/internal/models"
+)
+
+func TestSeedDefaultRolesAndPermissions_Idempotent(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewMemoryStore()
+	if err != nil {
+		t.Fatalf("Failed to create memory store: %v", err)
+	}
+	// A role that already exists is reused rather than duplicated
+	viewer := &models.Role{Name: "viewer", DisplayName: "Read only"}
+	if err := store.CreateRole(ctx, viewer); err != nil {
+		t.Fatalf("Failed to create role: %v", err)
+	}
+
+	for run := 1; run <= 2; run++ {
+		if err := SeedDefaultRolesAndPermissions(ctx, store); err != nil {
+			t.Fatalf("Seed run %d failed: %v", run, err)
+		}
+
+		roles, _ := store.ListRoles(ctx)
+		if len(roles) != 3 {
+			t.Errorf("Run %d: expected 3 roles, got %d", run, len(roles))
+		}
+		permissions, _ := store.ListPermissions(ctx)
+		if len(permissions) != len(defaultPermissions) {
+			t.Errorf("Run %d: expected %d permissions, got %d", run, len(defaultPermissions), len(permissions))
+		}
+
+		// The existing viewer role is not granted anything
+		for name, expected := range map[string]int{"admin": len(defaultPermissions), "responder": 13, "viewer": 0} {
+			role, err := store.GetRoleByName(ctx, name)
+			if err != nil {
+				t.Fatalf("Run %d: expected role %s: %v", run, name, err)
+			}
+			granted, _ := store.GetRolePermissions(ctx, role.ID)
+			if len(granted) != expected {
+				t.Errorf("Run %d: expected %s to have %d permissions, got %d", run, name, expected, len(granted))
+			}
+		}
+	}
+
+	role, _ := store.GetRoleByName(ctx, "viewer")
+	if role.ID != viewer.ID || role.DisplayName != "Read only" {
+		t.Errorf("Expected the existing viewer role to be kept, got %+v", role)
+	}
+}
+
+func TestSeedDefaultRolesAndPermissions_RevokedGrantStaysRevoked(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewMemoryStore()
+	if err != nil {
+		t.Fatalf("Failed to create memory store: %v", err)
+	}
+	if err := SeedDefaultRolesAndPermissions(ctx, store); err != nil {
+		t.Fatalf("First seed failed: %v", err)
+	}
+
+	responder, err := store.GetRoleByName(ctx, "responder")
+	if err != nil {
+		t.Fatalf("Expected the responder role: %v", err)
+	}
+	granted, _ := store.GetRolePermissions(ctx, responder.ID)
+	var kept []string
+	for _, permission := range granted {
+		if permission.Name != "incidents.resolve" {
+			kept = append(kept, permission.ID)
+		}
+	}
+	if len(kept) != len(granted)-1 {
+		t.Fatalf("Expected responders to be granted incidents.resolve, got %d permissions", len(granted))
+	}
+	// The store has no revoke operation yet; operators revoke in the database
+	store.mu.Lock()
+	store.rolePermissions[responder.ID] = kept
+	store.mu.Unlock()
+
+	if err := SeedDefaultRolesAndPermissions(ctx, store); err != nil {
+		t.Fatalf("Second seed failed: %v", err)
+	}
+	granted, _ = store.GetRolePermissions(ctx, responder.ID)
+	for _, permission := range granted {
+		if permission.Name == "incidents.resolve" {
+			t.Error("Expected the revoked incidents.resolve grant to stay revoked")
+		}
+	}
+	if len(granted) != len(kept) {
+		t.Errorf("Expected responders to keep %d permissions, got %d", len(kept), len(granted))
+	}
+}
@@ -0,0 +1,42 @@
+package validation
+
+import (
+	"testing"
+)
+
+func TestSignatureVerifier_Rotation(t *testing.T) {
+	payload := []byte(`{"version":"4","status":"firing","alerts":[]}`)
+	verifier := NewSignatureVerifier([]string{"old-secret", "new-secret"})
+
+	tests := []struct {
+		name      string
+		signature string
+		wantErr   error
+	}{
+		{name: "signed with old secret", signature: Sign("old-secret", payload)},
+		{name: "signed with new secret", signature: Sign("new-secret", payload)},
+		{name: "sha256 prefix", signature: "sha256=" + Sign("new-secret", payload)},
+		{name: "signed with unknown secret", signature: Sign("other-secret", payload), wantErr: ErrInvalidSignature},
+		{name: "malformed signature", signature: "not-hex", wantErr: ErrInvalidSignature},
+		{name: "missing signature", signature: "", wantErr: ErrMissingSignature},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := verifier.Verify(payload, tt.signature); err != tt.wantErr {
+				t.Errorf("Verify() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSignatureVerifier_DisabledWithoutSecrets(t *testing.T) {
+	verifier := NewSignatureVerifier([]string{"", "  "})
+
+	if verifier.Enabled() {
+		t.Error("Expected verifier to be disabled without secrets")
+	}
+	if err := verifier.Verify([]byte("payload"), ""); err != nil {
+		t.Errorf("Expected no error when disabled, got %v", err)
+	}
+}
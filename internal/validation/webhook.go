@@ -88,9 +88,111 @@ const AlertmanagerWebhookSchema = `{
 	}
 }`
 
+// GrafanaWebhookSchema defines the JSON schema for Grafana alerting webhooks.
+// Grafana computes a fingerprint for each alert, but it is optional here since
+// older versions omit it.
+const GrafanaWebhookSchema = `{
+	"$schema": "http://json-schema.org/draft-07/schema#",
+	"type": "object",
+	"required": ["status", "alerts"],
+	"properties": {
+		"receiver": {
+			"type": "string"
+		},
+		"status": {
+			"type": "string",
+			"enum": ["firing", "resolved"]
+		},
+		"orgId": {
+			"type": "integer"
+		},
+		"groupKey": {
+			"type": "string"
+		},
+		"groupLabels": {
+			"type": "object",
+			"additionalProperties": {
+				"type": "string"
+			}
+		},
+		"commonLabels": {
+			"type": "object",
+			"additionalProperties": {
+				"type": "string"
+			}
+		},
+		"commonAnnotations": {
+			"type": "object",
+			"additionalProperties": {
+				"type": "string"
+			}
+		},
+		"externalURL": {
+			"type": "string"
+		},
+		"title": {
+			"type": "string"
+		},
+		"message": {
+			"type": "string"
+		},
+		"alerts": {
+			"type": "array",
+			"minItems": 1,
+			"items": {
+				"type": "object",
+				"required": ["status", "labels"],
+				"properties": {
+					"status": {
+						"type": "string",
+						"enum": ["firing", "resolved"]
+					},
+					"fingerprint": {
+						"type": "string"
+					},
+					"startsAt": {
+						"type": "string",
+						"format": "date-time"
+					},
+					"endsAt": {
+						"type": "string",
+						"format": "date-time"
+					},
+					"labels": {
+						"type": "object",
+						"minProperties": 1,
+						"additionalProperties": {
+							"type": "string"
+						}
+					},
+					"annotations": {
+						"type": "object",
+						"additionalProperties": {
+							"type": "string"
+						}
+					},
+					"generatorURL": {
+						"type": "string"
+					},
+					"dashboardURL": {
+						"type": "string"
+					},
+					"panelURL": {
+						"type": "string"
+					},
+					"values": {
+						"type": ["object", "null"]
+					}
+				}
+			}
+		}
+	}
+}`
+
 // WebhookValidator handles webhook validation
 type WebhookValidator struct {
 	alertmanagerSchema gojsonschema.JSONLoader
+	grafanaSchema      gojsonschema.JSONLoader
 }
 
 // NewWebhookValidator creates a new webhook validator
@@ -98,11 +200,22 @@ func NewWebhookValidator() *WebhookValidator {
 	schemaLoader := gojsonschema.NewStringLoader(AlertmanagerWebhookSchema)
 	return &WebhookValidator{
 		alertmanagerSchema: schemaLoader,
+		grafanaSchema:      gojsonschema.NewStringLoader(GrafanaWebhookSchema),
 	}
 }
 
 // ValidateAlertmanagerWebhook validates an Alertmanager webhook payload against the schema
 func (v *WebhookValidator) ValidateAlertmanagerWebhook(payload []byte) error {
+	return validatePayload(v.alertmanagerSchema, payload)
+}
+
+// ValidateGrafanaWebhook validates a Grafana alerting webhook payload against the schema
+func (v *WebhookValidator) ValidateGrafanaWebhook(payload []byte) error {
+	return validatePayload(v.grafanaSchema, payload)
+}
+
+// validatePayload validates a webhook payload against a JSON schema
+func validatePayload(schema gojsonschema.JSONLoader, payload []byte) error {
 	// Parse JSON to check basic structure
 	var jsonData interface{}
 	if err := json.Unmarshal(payload, &jsonData); err != nil {
@@ -111,7 +224,7 @@ func (v *WebhookValidator) ValidateAlertmanagerWebhook(payload []byte) error {
 
 	// Validate against schema
 	documentLoader := gojsonschema.NewBytesLoader(payload)
-	result, err := gojsonschema.Validate(v.alertmanagerSchema, documentLoader)
+	result, err := gojsonschema.Validate(schema, documentLoader)
 	if err != nil {
 		return fmt.Errorf("schema validation error: %w", err)
 	}
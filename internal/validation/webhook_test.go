@@ -82,4 +82,73 @@ func TestWebhookValidator_ValidateAlertmanagerWebhook(t *testing.T) {
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+func TestWebhookValidator_ValidateGrafanaWebhook(t *testing.T) {
+	validator := NewWebhookValidator()
+
+	tests := []struct {
+		name    string
+		payload string
+		wantErr bool
+	}{
+		{
+			name: "valid webhook payload",
+			payload: `{
+				"receiver": "incidents",
+				"status": "firing",
+				"orgId": 1,
+				"alerts": [
+					{
+						"status": "firing",
+						"labels": {"alertname": "HighLatency", "severity": "warning"},
+						"annotations": {"summary": "p99 above 2s"},
+						"startsAt": "2024-05-01T12:00:00Z",
+						"endsAt": "0001-01-01T00:00:00Z",
+						"fingerprint": "5d1a0e2c0b6c9c3e",
+						"values": {"B": 2.4}
+					}
+				],
+				"title": "[FIRING:1] HighLatency"
+			}`,
+			wantErr: false,
+		},
+		{
+			name: "missing fingerprint and start time",
+			payload: `{
+				"status": "resolved",
+				"alerts": [{"status": "resolved", "labels": {"alertname": "HighLatency"}, "values": null}]
+			}`,
+			wantErr: false,
+		},
+		{
+			name:    "missing alerts",
+			payload: `{"status": "firing"}`,
+			wantErr: true,
+		},
+		{
+			name: "alert without labels",
+			payload: `{
+				"status": "firing",
+				"alerts": [{"status": "firing", "labels": {}}]
+			}`,
+			wantErr: true,
+		},
+		{
+			name: "invalid alert status",
+			payload: `{
+				"status": "firing",
+				"alerts": [{"status": "alerting", "labels": {"alertname": "HighLatency"}}]
+			}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validator.ValidateGrafanaWebhook([]byte(tt.payload))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateGrafanaWebhook() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
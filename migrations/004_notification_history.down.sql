@@ -0,0 +1,7 @@
+-- Drop indexes
+DROP INDEX IF EXISTS idx_notification_history_status;
+DROP INDEX IF EXISTS idx_notification_history_incident_id;
+DROP INDEX IF EXISTS idx_notification_history_channel_created;
+
+-- Drop table
+DROP TABLE IF EXISTS notification_history;
@@ -0,0 +1,31 @@
+-- Create notification_history table for delivery auditing
+-- incident_id and channel_id are not foreign keys: test and digest notifications
+-- are not tied to a stored incident, and channels may be managed outside the database
+CREATE TABLE notification_history (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    incident_id VARCHAR(255),
+    channel_id VARCHAR(255) NOT NULL,
+    template_id VARCHAR(255),
+    type VARCHAR(100) NOT NULL,
+    channel VARCHAR(50) NOT NULL, -- slack, email, telegram
+    recipient VARCHAR(500),
+    subject TEXT,
+    content TEXT,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    error_msg TEXT,
+    retry_count INTEGER NOT NULL DEFAULT 0,
+    scheduled_at TIMESTAMP WITH TIME ZONE,
+    sent_at TIMESTAMP WITH TIME ZONE,
+    delivered_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT notification_history_status_check CHECK (
+        status IN ('pending', 'sent', 'delivered', 'failed', 'retrying')
+    )
+);
+
+-- Add indexes for per-channel and per-incident lookups
+CREATE INDEX idx_notification_history_channel_created ON notification_history(channel_id, created_at DESC);
+CREATE INDEX idx_notification_history_incident_id ON notification_history(incident_id) WHERE incident_id IS NOT NULL;
+CREATE INDEX idx_notification_history_status ON notification_history(status);
@@ -0,0 +1,5 @@
+-- Drop indexes
+DROP INDEX IF EXISTS idx_webhook_payloads_received_at;
+
+-- Drop table
+DROP TABLE IF EXISTS webhook_payloads;
@@ -0,0 +1,12 @@
+-- Create webhook_payloads table holding raw webhook bodies for replay
+-- Payloads are stored as received (TEXT rather than JSONB) so a replay sees
+-- exactly the bytes that arrived
+CREATE TABLE webhook_payloads (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    source VARCHAR(50) NOT NULL,
+    payload TEXT NOT NULL,
+    received_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Add index for retention pruning
+CREATE INDEX idx_webhook_payloads_received_at ON webhook_payloads(received_at);
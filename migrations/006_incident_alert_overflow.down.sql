@@ -0,0 +1,2 @@
+-- Drop overflow counter
+ALTER TABLE incidents DROP COLUMN IF EXISTS overflow_alert_count;
@@ -0,0 +1,3 @@
+-- Count correlated alerts received after an incident reached the
+-- alerts-per-incident cap; those alerts are not stored as individual rows
+ALTER TABLE incidents ADD COLUMN overflow_alert_count INTEGER NOT NULL DEFAULT 0;
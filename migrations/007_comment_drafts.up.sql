@@ -0,0 +1,9 @@
+-- Create incident_comment_drafts table holding autosaved, unsent comments
+-- Each user has at most one draft per incident
+CREATE TABLE incident_comment_drafts (
+    incident_id UUID NOT NULL REFERENCES incidents(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    content TEXT NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (incident_id, user_id)
+);
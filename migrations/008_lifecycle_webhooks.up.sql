@@ -0,0 +1,17 @@
+-- Create lifecycle_webhooks table for outbound incident status-change hooks
+-- Severities and labels filter which incidents a hook receives events for
+CREATE TABLE lifecycle_webhooks (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(255) NOT NULL,
+    url TEXT NOT NULL,
+    enabled BOOLEAN NOT NULL DEFAULT true,
+    severities JSONB NOT NULL DEFAULT '[]',
+    labels JSONB NOT NULL DEFAULT '{}',
+    last_status VARCHAR(20),
+    last_error TEXT,
+    last_attempt_at TIMESTAMP WITH TIME ZONE,
+    delivered_count INTEGER NOT NULL DEFAULT 0,
+    failed_count INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,6 @@
+-- Remove entries of the new types and restore the original constraint
+DELETE FROM incident_comments WHERE comment_type IN ('escalation', 'sla_breach', 'reminder');
+ALTER TABLE incident_comments DROP CONSTRAINT incident_comments_type_check;
+ALTER TABLE incident_comments ADD CONSTRAINT incident_comments_type_check CHECK (
+    comment_type IN ('comment', 'status_change', 'assignment', 'severity_change', 'tag_added', 'tag_removed', 'attachment_added')
+);
@@ -0,0 +1,6 @@
+-- Allow timeline entries recorded by escalation, SLA and reminder subsystems
+ALTER TABLE incident_comments DROP CONSTRAINT incident_comments_type_check;
+ALTER TABLE incident_comments ADD CONSTRAINT incident_comments_type_check CHECK (
+    comment_type IN ('comment', 'status_change', 'assignment', 'severity_change', 'tag_added', 'tag_removed', 'attachment_added',
+                     'escalation', 'sla_breach', 'reminder')
+);
@@ -0,0 +1,11 @@
+-- Create on_call_schedules table
+-- Layers, with their rotations and restrictions, are stored as JSONB in the
+-- same shape the API uses; schedule IDs are chosen by the caller
+CREATE TABLE on_call_schedules (
+    id VARCHAR(255) PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    timezone VARCHAR(64) NOT NULL DEFAULT '',
+    layers JSONB NOT NULL DEFAULT '[]',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,8 @@
+-- Fold dead-lettered notifications back into failed and drop the error chain
+UPDATE notification_history SET status = 'failed' WHERE status = 'dead_lettered';
+ALTER TABLE notification_history DROP CONSTRAINT notification_history_status_check;
+ALTER TABLE notification_history ADD CONSTRAINT notification_history_status_check CHECK (
+    status IN ('pending', 'sent', 'delivered', 'failed', 'retrying')
+);
+
+ALTER TABLE notification_history DROP COLUMN error_chain;
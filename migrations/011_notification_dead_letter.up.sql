@@ -0,0 +1,8 @@
+-- Keep the per-attempt errors of each notification and allow dead-lettering
+-- notifications that exhausted their retries
+ALTER TABLE notification_history ADD COLUMN error_chain JSONB NOT NULL DEFAULT '[]';
+
+ALTER TABLE notification_history DROP CONSTRAINT notification_history_status_check;
+ALTER TABLE notification_history ADD CONSTRAINT notification_history_status_check CHECK (
+    status IN ('pending', 'sent', 'delivered', 'failed', 'retrying', 'dead_lettered')
+);
@@ -0,0 +1,9 @@
+-- Remove alert storm timeline entries and the storm summary column
+DELETE FROM incident_comments WHERE comment_type = 'alert_storm';
+ALTER TABLE incident_comments DROP CONSTRAINT incident_comments_type_check;
+ALTER TABLE incident_comments ADD CONSTRAINT incident_comments_type_check CHECK (
+    comment_type IN ('comment', 'status_change', 'assignment', 'severity_change', 'tag_added', 'tag_removed', 'attachment_added',
+                     'escalation', 'sla_breach', 'reminder')
+);
+
+ALTER TABLE incidents DROP COLUMN IF EXISTS storm_summary;
@@ -0,0 +1,9 @@
+-- Summarize the alerts grouped into alert storm incidents and allow the
+-- timeline entries that report on a storm
+ALTER TABLE incidents ADD COLUMN storm_summary JSONB;
+
+ALTER TABLE incident_comments DROP CONSTRAINT incident_comments_type_check;
+ALTER TABLE incident_comments ADD CONSTRAINT incident_comments_type_check CHECK (
+    comment_type IN ('comment', 'status_change', 'assignment', 'severity_change', 'tag_added', 'tag_removed', 'attachment_added',
+                     'escalation', 'sla_breach', 'reminder', 'alert_storm')
+);
@@ -0,0 +1,4 @@
+-- Drop incident resolution classification
+DROP INDEX IF EXISTS idx_incidents_resolution_type;
+ALTER TABLE incidents DROP COLUMN IF EXISTS root_cause_category;
+ALTER TABLE incidents DROP COLUMN IF EXISTS resolution_type;
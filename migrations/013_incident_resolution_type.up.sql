@@ -0,0 +1,6 @@
+-- Classify how incidents were resolved for trend analysis
+ALTER TABLE incidents ADD COLUMN resolution_type TEXT NOT NULL DEFAULT ''
+    CHECK (resolution_type IN ('', 'fixed', 'auto_recovered', 'duplicate', 'false_positive'));
+ALTER TABLE incidents ADD COLUMN root_cause_category TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX idx_incidents_resolution_type ON incidents(resolution_type) WHERE resolution_type <> '';
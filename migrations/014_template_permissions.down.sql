@@ -0,0 +1,2 @@
+-- Drop the template creation permission; role_permissions rows cascade
+DELETE FROM permissions WHERE name = 'templates.create';
@@ -0,0 +1,9 @@
+-- Permission to create incident templates, granted to admins and responders
+INSERT INTO permissions (name, resource, action, description) VALUES
+('templates.create', 'templates', 'create', 'Create incident templates')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id FROM roles r, permissions p
+WHERE r.name IN ('admin', 'responder') AND p.name = 'templates.create'
+ON CONFLICT (role_id, permission_id) DO NOTHING;
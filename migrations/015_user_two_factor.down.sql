@@ -0,0 +1,3 @@
+-- Drop TOTP two-factor authentication
+ALTER TABLE users DROP COLUMN IF EXISTS two_factor_enabled;
+ALTER TABLE users DROP COLUMN IF EXISTS totp_secret;
@@ -0,0 +1,3 @@
+-- Optional TOTP two-factor authentication; the secret is stored encrypted
+ALTER TABLE users ADD COLUMN totp_secret TEXT NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN two_factor_enabled BOOLEAN NOT NULL DEFAULT FALSE;
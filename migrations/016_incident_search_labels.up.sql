@@ -0,0 +1,17 @@
+-- Include label values in incident full-text search
+CREATE OR REPLACE FUNCTION update_incident_search_vector() RETURNS trigger AS $$
+BEGIN
+    NEW.search_vector :=
+        setweight(to_tsvector('english', COALESCE(NEW.title, '')), 'A') ||
+        setweight(to_tsvector('english', COALESCE(NEW.description, '')), 'B') ||
+        setweight(to_tsvector('english', COALESCE(NEW.assignee_id::text, '')), 'C') ||
+        setweight(jsonb_to_tsvector('english', COALESCE(NEW.labels, '{}'::jsonb), '["string"]'), 'D');
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+UPDATE incidents SET search_vector =
+    setweight(to_tsvector('english', COALESCE(title, '')), 'A') ||
+    setweight(to_tsvector('english', COALESCE(description, '')), 'B') ||
+    setweight(to_tsvector('english', COALESCE(assignee_id::text, '')), 'C') ||
+    setweight(jsonb_to_tsvector('english', COALESCE(labels, '{}'::jsonb), '["string"]'), 'D');
@@ -0,0 +1,2 @@
+-- Drop the merged-into reference on duplicate incidents
+ALTER TABLE incidents DROP COLUMN IF EXISTS merged_into;
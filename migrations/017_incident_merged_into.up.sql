@@ -0,0 +1,2 @@
+-- Incidents merged into another incident as duplicates point at it
+ALTER TABLE incidents ADD COLUMN merged_into TEXT NOT NULL DEFAULT '';
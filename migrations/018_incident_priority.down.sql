@@ -0,0 +1,12 @@
+-- Remove incident priority and its timeline entries
+DELETE FROM incident_comments WHERE comment_type = 'priority_change';
+ALTER TABLE incident_comments DROP CONSTRAINT incident_comments_type_check;
+ALTER TABLE incident_comments ADD CONSTRAINT incident_comments_type_check CHECK (
+    comment_type IN ('comment', 'status_change', 'assignment', 'severity_change', 'tag_added', 'tag_removed', 'attachment_added',
+                     'escalation', 'sla_breach', 'reminder', 'alert_storm')
+);
+
+ALTER TABLE incident_templates DROP COLUMN IF EXISTS priority;
+
+DROP INDEX IF EXISTS idx_incidents_priority;
+ALTER TABLE incidents DROP COLUMN IF EXISTS priority;
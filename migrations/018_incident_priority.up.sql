@@ -0,0 +1,22 @@
+-- Business priority of incidents, separate from their technical severity.
+-- Existing incidents get the priority their severity implies.
+ALTER TABLE incidents ADD COLUMN priority TEXT NOT NULL DEFAULT ''
+    CHECK (priority IN ('', 'P1', 'P2', 'P3', 'P4'));
+
+UPDATE incidents SET priority = CASE severity
+    WHEN 'critical' THEN 'P1'
+    WHEN 'high' THEN 'P2'
+    WHEN 'medium' THEN 'P3'
+    ELSE 'P4'
+END;
+
+CREATE INDEX idx_incidents_priority ON incidents(priority);
+
+ALTER TABLE incident_templates ADD COLUMN priority TEXT NOT NULL DEFAULT ''
+    CHECK (priority IN ('', 'P1', 'P2', 'P3', 'P4'));
+
+ALTER TABLE incident_comments DROP CONSTRAINT incident_comments_type_check;
+ALTER TABLE incident_comments ADD CONSTRAINT incident_comments_type_check CHECK (
+    comment_type IN ('comment', 'status_change', 'assignment', 'severity_change', 'tag_added', 'tag_removed', 'attachment_added',
+                     'escalation', 'sla_breach', 'reminder', 'alert_storm', 'priority_change')
+);
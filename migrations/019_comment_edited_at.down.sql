@@ -0,0 +1,2 @@
+-- Drop the comment edit marker
+ALTER TABLE incident_comments DROP COLUMN IF EXISTS edited_at;
@@ -0,0 +1,2 @@
+-- Comments edited by their author record when the edit happened
+ALTER TABLE incident_comments ADD COLUMN edited_at TIMESTAMP WITH TIME ZONE;
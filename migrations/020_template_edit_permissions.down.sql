@@ -0,0 +1,2 @@
+-- Drop the template edit and delete permissions; role_permissions rows cascade
+DELETE FROM permissions WHERE name IN ('templates.update', 'templates.delete');
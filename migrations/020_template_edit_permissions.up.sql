@@ -0,0 +1,10 @@
+-- Permissions to edit and delete incident templates, granted to admins and responders
+INSERT INTO permissions (name, resource, action, description) VALUES
+('templates.update', 'templates', 'update', 'Edit incident templates'),
+('templates.delete', 'templates', 'delete', 'Delete incident templates')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id FROM roles r, permissions p
+WHERE r.name IN ('admin', 'responder') AND p.name IN ('templates.update', 'templates.delete')
+ON CONFLICT (role_id, permission_id) DO NOTHING;
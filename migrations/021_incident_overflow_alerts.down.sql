@@ -0,0 +1,2 @@
+-- Drop the overflow alert fingerprints
+DROP TABLE IF EXISTS incident_overflow_alerts;
@@ -0,0 +1,10 @@
+-- Fingerprints of the firing alerts an incident absorbed past its
+-- alerts-per-incident cap, so re-deliveries are not counted twice
+CREATE TABLE incident_overflow_alerts (
+    incident_id UUID NOT NULL REFERENCES incidents(id) ON DELETE CASCADE,
+    fingerprint VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (incident_id, fingerprint)
+);
+
+CREATE INDEX idx_incident_overflow_alerts_fingerprint ON incident_overflow_alerts(fingerprint);
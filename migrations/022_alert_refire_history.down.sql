@@ -0,0 +1,7 @@
+-- Keep only the latest alert per fingerprint and restore the unique constraint
+DELETE FROM alerts a
+USING alerts newer
+WHERE a.fingerprint = newer.fingerprint
+  AND (a.created_at, a.id) < (newer.created_at, newer.id);
+
+ALTER TABLE alerts ADD CONSTRAINT alerts_fingerprint_key UNIQUE (fingerprint);
@@ -0,0 +1,4 @@
+-- An alert firing again after its incident was resolved is stored as a new
+-- row, so a fingerprint can appear once per incident it caused; the latest
+-- row is the current alert
+ALTER TABLE alerts DROP CONSTRAINT IF EXISTS alerts_fingerprint_key;